@@ -0,0 +1,334 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+)
+
+// TypedDataDomainType is the name of the struct type describing the signing
+// domain of EIP-712 typed data.
+const TypedDataDomainType = "EIP712Domain"
+
+// TypedDataField is a named member of an EIP-712 struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataTypes maps the struct type names of EIP-712 typed data to their
+// members, in declaration order.
+type TypedDataTypes map[string][]TypedDataField
+
+// TypedData is an EIP-712 typed structured data message, as passed to the
+// signTypedData RPC calls.
+type TypedData struct {
+	Types       TypedDataTypes         `json:"types"`
+	PrimaryType string                 `json:"primaryType"`
+	Domain      map[string]interface{} `json:"domain"`
+	Message     map[string]interface{} `json:"message"`
+}
+
+// typedArrayRegex matches array types, capturing the element type and the
+// optional fixed length.
+var typedArrayRegex = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+
+// SigHash calculates the digest to sign for the typed data:
+//   keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func (td *TypedData) SigHash() ([]byte, error) {
+	domain, err := td.HashStruct(TypedDataDomainType, td.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("domain: %v", err)
+	}
+	message, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message: %v", err)
+	}
+	return crypto.Keccak256([]byte("\x19\x01"), domain, message), nil
+}
+
+// HashStruct calculates the hash of a struct value of the given type:
+//   keccak256(typeHash ‖ encodeData(value))
+func (td *TypedData) HashStruct(typ string, value map[string]interface{}) ([]byte, error) {
+	data, err := td.EncodeData(typ, value)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(data), nil
+}
+
+// TypeHash calculates the hash of the encoded type signature of a struct type.
+func (td *TypedData) TypeHash(typ string) ([]byte, error) {
+	enc, err := td.EncodeType(typ)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte(enc)), nil
+}
+
+// EncodeType returns the type signature of a struct type, followed by the
+// signatures of all the struct types it references sorted by name, e.g.
+//   Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (td *TypedData) EncodeType(typ string) (string, error) {
+	if _, ok := td.Types[typ]; !ok {
+		return "", fmt.Errorf("unknown type %q", typ)
+	}
+	deps := make(map[string]bool)
+	td.dependencies(typ, deps)
+	delete(deps, typ)
+
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range append([]string{typ}, names...) {
+		buf.WriteString(name)
+		buf.WriteByte('(')
+		for i, field := range td.Types[name] {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(field.Type)
+			buf.WriteByte(' ')
+			buf.WriteString(field.Name)
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String(), nil
+}
+
+// dependencies collects the struct types referenced by a type, including itself.
+func (td *TypedData) dependencies(typ string, deps map[string]bool) {
+	typ = elementType(typ)
+	if deps[typ] {
+		return
+	}
+	if _, ok := td.Types[typ]; !ok {
+		return
+	}
+	deps[typ] = true
+	for _, field := range td.Types[typ] {
+		td.dependencies(field.Type, deps)
+	}
+}
+
+// elementType strips all array dimensions of a type.
+func elementType(typ string) string {
+	for {
+		match := typedArrayRegex.FindStringSubmatch(typ)
+		if match == nil {
+			return typ
+		}
+		typ = match[1]
+	}
+}
+
+// EncodeData encodes a struct value as the type hash followed by the 32 byte
+// encoding of each of its members.
+func (td *TypedData) EncodeData(typ string, value map[string]interface{}) ([]byte, error) {
+	fields, ok := td.Types[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	for name := range value {
+		found := false
+		for _, field := range fields {
+			found = found || field.Name == name
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown member %q of type %s", name, typ)
+		}
+	}
+	typeHash, err := td.TypeHash(typ)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(typeHash)
+	for _, field := range fields {
+		enc, err := td.encodeValue(field.Type, value[field.Name])
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", typ, field.Name, err)
+		}
+		buf.Write(enc)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeValue encodes a single member value into 32 bytes: atomic values are
+// padded, dynamic ones, arrays and structs are hashed.
+func (td *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, fmt.Errorf("missing value of type %s", typ)
+	}
+	// Structs are encoded by their hash
+	if _, ok := td.Types[typ]; ok {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected %s object, got %T", typ, value)
+		}
+		return td.HashStruct(typ, fields)
+	}
+	// Arrays are encoded by the hash of their concatenated element encodings
+	if match := typedArrayRegex.FindStringSubmatch(typ); match != nil {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected %s array, got %T", typ, value)
+		}
+		if match[2] != "" {
+			if size, _ := strconv.Atoi(match[2]); size != len(items) {
+				return nil, fmt.Errorf("expected %d array items, got %d", size, len(items))
+			}
+		}
+		var buf bytes.Buffer
+		for i, item := range items {
+			enc, err := td.encodeValue(match[1], item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %v", i, err)
+			}
+			buf.Write(enc)
+		}
+		return crypto.Keccak256(buf.Bytes()), nil
+	}
+	// Dynamic values are encoded by their hash
+	switch typ {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %T", value)
+		}
+		return crypto.Keccak256([]byte(s)), nil
+
+	case "bytes":
+		b, err := typedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %T", value)
+		}
+		if b {
+			return U256(big.NewInt(1)), nil
+		}
+		return U256(new(big.Int)), nil
+
+	case "address":
+		s, ok := value.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("expected hex address, got %v", value)
+		}
+		return common.LeftPadBytes(common.HexToAddress(s).Bytes(), 32), nil
+	}
+	// Fixed size byte arrays are right padded
+	if strings.HasPrefix(typ, "bytes") {
+		size, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil || size < 1 || size > 32 {
+			return nil, fmt.Errorf("invalid type %s", typ)
+		}
+		b, err := typedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+	}
+	// Integers are sign extended to 256 bits
+	signed := strings.HasPrefix(typ, "int")
+	if signed || strings.HasPrefix(typ, "uint") {
+		bits := 256
+		if suffix := strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"); suffix != "" {
+			var err error
+			if bits, err = strconv.Atoi(suffix); err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+				return nil, fmt.Errorf("invalid type %s", typ)
+			}
+		}
+		n, err := typedInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		min, max := big.NewInt(0), new(big.Int).Lsh(common.Big1, uint(bits))
+		if signed {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if n.Cmp(min) < 0 || n.Cmp(max) >= 0 {
+			return nil, fmt.Errorf("value %v out of range for %s", n, typ)
+		}
+		return U256(n), nil
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+// typedBytes converts a hex string value into a byte slice.
+func typedBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected hex string, got %T", value)
+	}
+	return hexutil.Decode(s)
+}
+
+// maxSafeInteger bounds the magnitude of integers accepted as JSON numbers
+// decoded into float64, beyond which they may have lost precision.
+const maxSafeInteger = 1 << 53
+
+// typedInteger converts a JSON number or a decimal or hex string value into a
+// big integer. Larger values must be passed as strings, as JSON numbers decoded
+// into float64 are only exact up to 2^53.
+func typedInteger(value interface{}) (*big.Int, error) {
+	var s string
+	switch v := value.(type) {
+	case float64:
+		if v <= -maxSafeInteger || v >= maxSafeInteger {
+			return nil, fmt.Errorf("integer %v may have lost precision, pass it as a string", v)
+		}
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("expected integer, got %v", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case json.Number:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return nil, fmt.Errorf("expected integer, got %T", value)
+	}
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
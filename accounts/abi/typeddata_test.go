@@ -0,0 +1,131 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+// mailTypedData is the example message of the EIP-712 specification.
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+// Tests the encoding of the EIP-712 specification example.
+func TestTypedDataSigHash(t *testing.T) {
+	var td TypedData
+	if err := json.Unmarshal([]byte(mailTypedData), &td); err != nil {
+		t.Fatalf("failed to decode typed data: %v", err)
+	}
+	if enc, _ := td.EncodeType("Mail"); enc != "Mail(Person from,Person to,string contents)Person(string name,address wallet)" {
+		t.Errorf("type encoding mismatch: have %s", enc)
+	}
+	if hash, _ := td.TypeHash("Mail"); common.BytesToHash(hash) != common.HexToHash("0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2") {
+		t.Errorf("type hash mismatch: have %x", hash)
+	}
+	domain, err := td.HashStruct(TypedDataDomainType, td.Domain)
+	if err != nil {
+		t.Fatalf("failed to hash domain: %v", err)
+	}
+	if common.BytesToHash(domain) != common.HexToHash("0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f") {
+		t.Errorf("domain separator mismatch: have %x", domain)
+	}
+	message, err := td.HashStruct("Mail", td.Message)
+	if err != nil {
+		t.Fatalf("failed to hash message: %v", err)
+	}
+	if common.BytesToHash(message) != common.HexToHash("0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e") {
+		t.Errorf("message hash mismatch: have %x", message)
+	}
+	hash, err := td.SigHash()
+	if err != nil {
+		t.Fatalf("failed to calculate digest: %v", err)
+	}
+	if common.BytesToHash(hash) != common.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2") {
+		t.Errorf("digest mismatch: have %x", hash)
+	}
+}
+
+// Tests that invalid member values are rejected.
+func TestTypedDataInvalidValues(t *testing.T) {
+	tests := []struct {
+		typ   string
+		value interface{}
+	}{
+		{"uint8", float64(256)},
+		{"uint256", float64(-1)},
+		{"uint256", float64(1 << 53)},
+		{"uint256", float64(1 << 63)},
+		{"int256", float64(-(1 << 53))},
+		{"int8", "0x80"},
+		{"int8", "-129"},
+		{"bytes4", "0x0102"},
+		{"bytes33", "0x01"},
+		{"address", "0x01"},
+		{"bool", "true"},
+		{"string[2]", []interface{}{"a"}},
+		{"Unknown", "a"},
+	}
+	td := new(TypedData)
+	for i, tt := range tests {
+		if _, err := td.encodeValue(tt.typ, tt.value); err == nil {
+			t.Errorf("test %d: expected error encoding %v as %s", i, tt.value, tt.typ)
+		}
+	}
+	for _, value := range []interface{}{float64(-128), "0x7f", "-1"} {
+		if _, err := td.encodeValue("int8", value); err != nil {
+			t.Errorf("failed to encode %v as int8: %v", value, err)
+		}
+	}
+	// Large integers are accepted as strings
+	for _, value := range []interface{}{float64(1<<53 - 1), "9007199254740993", "0xffffffffffffffffffff"} {
+		if _, err := td.encodeValue("uint256", value); err != nil {
+			t.Errorf("failed to encode %v as uint256: %v", value, err)
+		}
+	}
+}
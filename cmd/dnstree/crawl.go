@@ -0,0 +1,188 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// crawlConcurrency is the number of record requests running in parallel.
+const crawlConcurrency = 16
+
+// crawlNodes collects the node records of the network into a node list.
+func crawlNodes(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("Usage: crawl <nodes.json>")
+	}
+	file := ctx.Args().Get(0)
+	prev := make(nodeSet)
+	if _, err := os.Stat(file); err == nil {
+		if prev, err = loadNodeSet(file); err != nil {
+			utils.Fatalf("Failed to load node list: %v", err)
+		}
+	}
+
+	urls := params.MainnetBootnodes
+	if ctx.IsSet(bootnodesFlag.Name) {
+		urls = strings.Split(ctx.String(bootnodesFlag.Name), ",")
+	}
+	var bootnodes []*discover.Node
+	for _, url := range urls {
+		n, err := discover.ParseNode(url)
+		if err != nil {
+			utils.Fatalf("Invalid bootnode %q: %v", url, err)
+		}
+		bootnodes = append(bootnodes, n)
+	}
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		utils.Fatalf("Failed to generate node key: %v", err)
+	}
+	tab, err := discover.ListenUDP(key, ctx.String(listenAddrFlag.Name), nil, "", nil)
+	if err != nil {
+		utils.Fatalf("Failed to start discovery: %v", err)
+	}
+	defer tab.Close()
+	if err := tab.SetFallbackNodes(bootnodes); err != nil {
+		utils.Fatalf("%v", err)
+	}
+
+	c := newCrawler(tab)
+	c.recheck(prev)
+	c.run(time.Now().Add(ctx.Duration(crawlTimeoutFlag.Name)))
+
+	log.Info("Crawl finished", "nodes", len(c.nodes), "previous", len(prev))
+	return writeJSON(file, c.nodes)
+}
+
+// crawler walks the network with random lookups, requesting the record of
+// every node it finds.
+type crawler struct {
+	tab   *discover.Table
+	mu    sync.Mutex
+	nodes nodeSet
+	seen  map[discover.NodeID]bool
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newCrawler(tab *discover.Table) *crawler {
+	return &crawler{
+		tab:   tab,
+		nodes: make(nodeSet),
+		seen:  make(map[discover.NodeID]bool),
+		slots: make(chan struct{}, crawlConcurrency),
+	}
+}
+
+// recheck requests the records of the nodes in a previous node list. Nodes
+// which don't respond are dropped.
+func (c *crawler) recheck(prev nodeSet) {
+	for _, entry := range prev {
+		if n := nodeFromRecord(entry.Record); n != nil {
+			c.visit(n)
+		}
+	}
+	c.wg.Wait()
+}
+
+// run performs random lookups until the deadline.
+func (c *crawler) run(deadline time.Time) {
+	for time.Now().Before(deadline) {
+		var target discover.NodeID
+		rand.Read(target[:])
+		for _, n := range c.tab.Lookup(target) {
+			c.visit(n)
+		}
+	}
+	c.wg.Wait()
+}
+
+// visit requests the record of a node unless it was visited before.
+func (c *crawler) visit(n *discover.Node) {
+	c.mu.Lock()
+	seen := c.seen[n.ID]
+	c.seen[n.ID] = true
+	c.mu.Unlock()
+	if seen {
+		return
+	}
+
+	c.slots <- struct{}{}
+	c.wg.Add(1)
+	go func() {
+		defer func() { <-c.slots; c.wg.Done() }()
+
+		r, err := c.tab.RequestENR(n)
+		if err != nil {
+			// The node only answers if it knows us, resolving it bonds with it
+			if rn := c.tab.Resolve(n.ID); rn != nil {
+				r, err = c.tab.RequestENR(rn)
+			}
+		}
+		if err != nil {
+			log.Debug("Node record request failed", "id", n.ID, "err", err)
+			return
+		}
+		c.mu.Lock()
+		c.nodes.add(r, time.Now())
+		total := len(c.nodes)
+		c.mu.Unlock()
+		log.Debug("Found node", "id", n.ID, "seq", r.Seq(), "total", total)
+	}()
+}
+
+// recordID returns the hex node ID of a record, or the empty string if the
+// record has no public key.
+func recordID(r *enr.Record) string {
+	var pubkey enr.Secp256k1
+	if r == nil || r.Load(&pubkey) != nil {
+		return ""
+	}
+	return discover.PubkeyID((*ecdsa.PublicKey)(&pubkey)).String()
+}
+
+// nodeFromRecord returns the discovery endpoint of a record, or nil if it
+// has none.
+func nodeFromRecord(r *enr.Record) *discover.Node {
+	var (
+		pubkey enr.Secp256k1
+		ip     enr.IP
+		udp    enr.UDP
+		tcp    enr.TCP
+	)
+	if r.Load(&pubkey) != nil || r.Load(&ip) != nil || r.Load(&udp) != nil {
+		return nil
+	}
+	r.Load(&tcp)
+	return discover.NewNode(discover.PubkeyID((*ecdsa.PublicKey)(&pubkey)), net.IP(ip), uint16(udp), uint16(tcp))
+}
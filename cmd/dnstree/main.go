@@ -0,0 +1,263 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// dnstree maintains DNS node lists for discovery via DNS (EIP-1459).
+//
+// The crawl command collects the node records of the network into a JSON node
+// list, the sign command turns a node list into a signed tree of TXT records
+// ready to be deployed to a DNS zone, and the sync command downloads a deployed
+// tree to verify it.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p/dnsdisc"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	gitCommit = "" // Git SHA1 commit hash of the release (set via linker flags)
+
+	app = utils.NewApp(gitCommit, "DNS node list maintenance tool")
+
+	resolver dnsdisc.Resolver // DNS resolver of the sync command, nil for the system one
+)
+
+var (
+	bootnodesFlag = cli.StringFlag{
+		Name:  "bootnodes",
+		Usage: "Comma separated enode URLs to start crawling from (default: main network bootnodes)",
+	}
+	listenAddrFlag = cli.StringFlag{
+		Name:  "addr",
+		Usage: "UDP listening address of the crawler",
+		Value: ":0",
+	}
+	crawlTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Time spent crawling the network",
+		Value: 30 * time.Minute,
+	}
+	keyFlag = cli.StringFlag{
+		Name:  "key",
+		Usage: "Private key file signing the tree root",
+	}
+	seqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "Sequence number of the tree (default: current unix time)",
+	}
+	linkFlag = cli.StringSliceFlag{
+		Name:  "link",
+		Usage: "enrtree:// URL of a tree to link to (may be repeated)",
+	}
+	verbosityFlag = cli.IntFlag{
+		Name:  "verbosity",
+		Usage: "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
+		Value: int(log.LvlInfo),
+	}
+)
+
+var (
+	crawlCommand = cli.Command{
+		Action:    crawlNodes,
+		Name:      "crawl",
+		Usage:     "Collects the node records of the network into a node list",
+		ArgsUsage: "<nodes.json>",
+		Flags:     []cli.Flag{bootnodesFlag, listenAddrFlag, crawlTimeoutFlag},
+		Description: `
+Crawls the network through discovery lookups and requests the node record of every
+node found. The records are merged into the given node list, nodes of the existing
+list are rechecked and dropped if they don't respond.`,
+	}
+	signCommand = cli.Command{
+		Action:    signTree,
+		Name:      "sign",
+		Usage:     "Creates a signed tree of TXT records from a node list",
+		ArgsUsage: "<nodes.json> <domain> <tree.json>",
+		Flags:     []cli.Flag{keyFlag, seqFlag, linkFlag},
+		Description: `
+Builds the tree of the nodes in the node list and signs its root. The TXT records
+of the tree are written to tree.json as a map of DNS names to record contents, the
+enrtree:// URL of the tree is printed.`,
+	}
+	syncCommand = cli.Command{
+		Action:    syncTree,
+		Name:      "sync",
+		Usage:     "Downloads a tree from DNS into a node list",
+		ArgsUsage: "<enrtree-url> [nodes.json]",
+		Description: `
+Syncs the tree at the given URL, verifying its signature and hashes. Linked trees
+are listed but not followed. The node records are written to the node list if one
+is given.`,
+	}
+)
+
+func init() {
+	app.Flags = []cli.Flag{verbosityFlag}
+	app.Commands = []cli.Command{crawlCommand, signCommand, syncCommand}
+	app.Before = func(ctx *cli.Context) error {
+		glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+		glogger.Verbosity(log.Lvl(ctx.GlobalInt(verbosityFlag.Name)))
+		log.Root().SetHandler(glogger)
+		return nil
+	}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// signTree creates and signs a tree from a node list.
+func signTree(ctx *cli.Context) error {
+	if ctx.NArg() != 3 {
+		utils.Fatalf("Usage: sign <nodes.json> <domain> <tree.json>")
+	}
+	nodes, err := loadNodeSet(ctx.Args().Get(0))
+	if err != nil {
+		utils.Fatalf("Failed to load node list: %v", err)
+	}
+	if !ctx.IsSet(keyFlag.Name) {
+		utils.Fatalf("Missing signing key, use --%s", keyFlag.Name)
+	}
+	key, err := crypto.LoadECDSA(ctx.String(keyFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to load signing key: %v", err)
+	}
+	seq := uint(time.Now().Unix())
+	if ctx.IsSet(seqFlag.Name) {
+		seq = ctx.Uint(seqFlag.Name)
+	}
+	domain := strings.TrimSuffix(ctx.Args().Get(1), ".")
+
+	tree, err := dnsdisc.MakeTree(seq, nodes.records(), ctx.StringSlice(linkFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to create tree: %v", err)
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		utils.Fatalf("Failed to sign tree: %v", err)
+	}
+	txts, err := tree.ToTXT(domain)
+	if err != nil {
+		utils.Fatalf("Failed to create TXT records: %v", err)
+	}
+	if err := writeJSON(ctx.Args().Get(2), txts); err != nil {
+		utils.Fatalf("Failed to write tree: %v", err)
+	}
+	log.Info("Created signed tree", "nodes", len(nodes), "records", len(txts), "seq", seq)
+	fmt.Println(url)
+	return nil
+}
+
+// syncTree downloads a tree from DNS.
+func syncTree(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		utils.Fatalf("Usage: sync <enrtree-url> [nodes.json]")
+	}
+	client, err := dnsdisc.NewClient(dnsdisc.Config{Resolver: resolver})
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	tree, err := client.SyncTree(ctx.Args().Get(0))
+	if err != nil {
+		utils.Fatalf("Failed to sync tree: %v", err)
+	}
+	nodes := make(nodeSet)
+	for _, r := range tree.Nodes() {
+		nodes.add(r, time.Time{})
+	}
+	fmt.Printf("seq:   %d\n", tree.Seq())
+	fmt.Printf("nodes: %d\n", len(nodes))
+	for _, link := range tree.Links() {
+		fmt.Printf("link:  %s\n", link)
+	}
+	if ctx.NArg() == 2 {
+		if err := writeJSON(ctx.Args().Get(1), nodes); err != nil {
+			utils.Fatalf("Failed to write node list: %v", err)
+		}
+	}
+	return nil
+}
+
+// nodeJSON is the node list entry of a single node.
+type nodeJSON struct {
+	Record   *enr.Record `json:"record"`
+	LastSeen time.Time   `json:"lastSeen,omitempty"`
+}
+
+// nodeSet is a node list, keyed by the hex node ID.
+type nodeSet map[string]nodeJSON
+
+func loadNodeSet(file string) (nodeSet, error) {
+	nodes := make(nodeSet)
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(blob, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// add inserts a record into the set, unless a newer record of the node is
+// already contained.
+func (ns nodeSet) add(r *enr.Record, seen time.Time) {
+	id := recordID(r)
+	if id == "" {
+		return
+	}
+	if prev, ok := ns[id]; ok && prev.Record.Seq() > r.Seq() {
+		return
+	}
+	ns[id] = nodeJSON{Record: r, LastSeen: seen}
+}
+
+// records returns the records of the set, ordered by node ID.
+func (ns nodeSet) records() []*enr.Record {
+	ids := make([]string, 0, len(ns))
+	for id := range ns {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	records := make([]*enr.Record, len(ids))
+	for i, id := range ids {
+		records[i] = ns[id].Record
+	}
+	return records
+}
+
+func writeJSON(file string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(blob, '\n'), 0644)
+}
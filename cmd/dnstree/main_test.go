@@ -0,0 +1,128 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+)
+
+// mapResolver serves the TXT records of a tree written by the sign command.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(name string) ([]string, error) {
+	if txt, ok := mr[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// runApp runs the tool with the given arguments, returning its output.
+func runApp(t *testing.T, args ...string) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = app.Run(append([]string{"dnstree"}, args...))
+	os.Stdout = stdout
+	w.Close()
+
+	out, _ := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("%s failed: %v", args[0], err)
+	}
+	return string(out)
+}
+
+// Tests that a node list signed into a tree is synced back unchanged.
+func TestSignSyncRoundtrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dnstree-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		nodesFile  = filepath.Join(dir, "nodes.json")
+		keyFile    = filepath.Join(dir, "key")
+		treeFile   = filepath.Join(dir, "tree.json")
+		syncedFile = filepath.Join(dir, "synced.json")
+	)
+	nodes := make(nodeSet)
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		r := new(enr.Record)
+		r.Set(enr.IP{10, 0, 0, byte(i)})
+		r.Set(enr.UDP(30303))
+		if err := enr.SignV4(r, key); err != nil {
+			t.Fatal(err)
+		}
+		nodes.add(r, time.Now())
+	}
+	if err := writeJSON(nodesFile, nodes); err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.GenerateKey()
+	if err := crypto.SaveECDSA(keyFile, key); err != nil {
+		t.Fatal(err)
+	}
+	url := strings.TrimSpace(runApp(t, "sign", "--key", keyFile, "--seq", "5", nodesFile, "nodes.example.org.", treeFile))
+	if !strings.HasPrefix(url, "enrtree://") || !strings.HasSuffix(url, "@nodes.example.org") {
+		t.Fatalf("invalid tree URL %q", url)
+	}
+
+	// Serve the TXT records of the tree and sync it back.
+	blob, err := ioutil.ReadFile(treeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txts := make(mapResolver)
+	if err := json.Unmarshal(blob, &txts); err != nil {
+		t.Fatal(err)
+	}
+	resolver = txts
+	defer func() { resolver = nil }()
+
+	out := runApp(t, "sync", url, syncedFile)
+	if !strings.Contains(out, "seq:   5\n") || !strings.Contains(out, "nodes: 3\n") {
+		t.Errorf("sync output mismatch:\n%s", out)
+	}
+	synced, err := loadNodeSet(syncedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(synced) != len(nodes) {
+		t.Fatalf("synced node count mismatch: have %d, want %d", len(synced), len(nodes))
+	}
+	for id, n := range nodes {
+		if s, ok := synced[id]; !ok || s.Record.String() != n.Record.String() {
+			t.Errorf("node %s not synced", id)
+		}
+	}
+}
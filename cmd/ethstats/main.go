@@ -0,0 +1,59 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// ethstats is a collector server for the ethstats reports of EarthDollar nodes,
+// serving an overview of the fleet for private networks.
+//
+// Nodes report to it with --ethstats nodename:secret@ws://host:port, the overview is
+// available at http://host:port/ and as JSON at http://host:port/api/nodes.
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/log"
+)
+
+func main() {
+	var (
+		listenAddr = flag.String("addr", ":3000", "listen address of the collector and the overview")
+		secret     = flag.String("secret", "", "secret the nodes need to report with")
+		history    = flag.Int("history", 50, "number of recent blocks kept for each node")
+		verbosity  = flag.Int("verbosity", int(log.LvlInfo), "log verbosity (0-9)")
+		vmodule    = flag.String("vmodule", "", "log verbosity pattern")
+	)
+	flag.Parse()
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(*verbosity))
+	if err := glogger.Vmodule(*vmodule); err != nil {
+		utils.Fatalf("-vmodule: %v", err)
+	}
+	log.Root().SetHandler(glogger)
+
+	if *history <= 0 {
+		utils.Fatalf("-history must be positive")
+	}
+	server := NewServer(*secret, *history)
+
+	log.Info("Starting stats collector", "addr", *listenAddr)
+	if err := http.ListenAndServe(*listenAddr, newHandler(server)); err != nil {
+		utils.Fatalf("%v", err)
+	}
+}
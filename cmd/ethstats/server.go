@@ -0,0 +1,532 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	loginTimeout  = 10 * time.Second // Time allowed for a node to authenticate
+	idleTimeout   = 60 * time.Second // Time after which a silent node is dropped
+	maxHistoryGap = 64               // Maximum number of missing blocks requested from a node at once
+)
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errInvalidLogin = errors.New("invalid login message")
+)
+
+// emitMsg is the envelope of all the messages exchanged with the stats
+// clients: a command name followed by an optional payload.
+type emitMsg struct {
+	Emit []json.RawMessage `json:"emit"`
+}
+
+// nodeInfo is the metainformation a node sends when logging in.
+type nodeInfo struct {
+	Name     string `json:"name"`
+	Node     string `json:"node"`
+	Port     int    `json:"port"`
+	Network  string `json:"net"`
+	Protocol string `json:"protocol"`
+	API      string `json:"api"`
+	Os       string `json:"os"`
+	OsVer    string `json:"os_v"`
+	Client   string `json:"client"`
+	History  bool   `json:"canUpdateHistory"`
+}
+
+// authMsg is the login request of a node.
+type authMsg struct {
+	Id     string   `json:"id"`
+	Info   nodeInfo `json:"info"`
+	Secret string   `json:"secret"`
+}
+
+// blockStats is the information reported about individual blocks.
+type blockStats struct {
+	Number    *big.Int          `json:"number"`
+	Hash      common.Hash       `json:"hash"`
+	Timestamp *big.Int          `json:"timestamp"`
+	Miner     common.Address    `json:"miner"`
+	GasUsed   *big.Int          `json:"gasUsed"`
+	GasLimit  *big.Int          `json:"gasLimit"`
+	Diff      string            `json:"difficulty"`
+	TotalDiff string            `json:"totalDifficulty"`
+	Txs       []json.RawMessage `json:"transactions"`
+	Uncles    []json.RawMessage `json:"uncles"`
+}
+
+// nodeStats is the information reported about the state of a node.
+type nodeStats struct {
+	Active   bool `json:"active"`
+	Syncing  bool `json:"syncing"`
+	Mining   bool `json:"mining"`
+	Hashrate int  `json:"hashrate"`
+	Peers    int  `json:"peers"`
+	GasPrice int  `json:"gasPrice"`
+	Uptime   int  `json:"uptime"`
+}
+
+// BlockSummary is the condensed form of a reported block exposed by the API.
+type BlockSummary struct {
+	Number      uint64         `json:"number"`
+	Hash        common.Hash    `json:"hash"`
+	Timestamp   uint64         `json:"timestamp"`
+	Miner       common.Address `json:"miner"`
+	GasUsed     uint64         `json:"gasUsed"`
+	GasLimit    uint64         `json:"gasLimit"`
+	Difficulty  string         `json:"difficulty"`
+	TotalDiff   string         `json:"totalDifficulty"`
+	Txs         int            `json:"transactions"`
+	Uncles      int            `json:"uncles"`
+	Propagation time.Duration  `json:"propagation"` // Delay after the first node reporting the block
+}
+
+// NodeSummary is the aggregated state of a reporting node exposed by the API.
+type NodeSummary struct {
+	ID        string        `json:"id"`
+	Info      nodeInfo      `json:"info"`
+	Addr      string        `json:"addr"`
+	Connected bool          `json:"connected"`
+	Stats     nodeStats     `json:"stats"`
+	Pending   int           `json:"pending"`
+	Latency   int           `json:"latency"` // Round trip time to the node in milliseconds
+	Uptime    float64       `json:"uptime"`  // Percentage of time the node was connected since first seen
+	Block     *BlockSummary `json:"block,omitempty"`
+	LastSeen  time.Time     `json:"lastSeen"`
+}
+
+// NodeDetails is a node summary along with the history of its recent blocks.
+type NodeDetails struct {
+	NodeSummary
+	History []*BlockSummary `json:"history"`
+}
+
+// node is the tracked state of a stats client.
+type node struct {
+	summary NodeSummary
+	history map[uint64]*BlockSummary // Recent blocks of the node by number
+
+	firstSeen time.Time     // Time the node logged in the first time
+	connected time.Time     // Time of the current login, zero if disconnected
+	online    time.Duration // Total time connected before the current login
+	conn      *websocket.Conn
+}
+
+// uptime returns the percentage of time the node was connected.
+func (n *node) uptime(now time.Time) float64 {
+	online := n.online
+	if !n.connected.IsZero() {
+		online += now.Sub(n.connected)
+	}
+	total := now.Sub(n.firstSeen)
+	if total <= 0 {
+		return 100
+	}
+	return 100 * float64(online) / float64(total)
+}
+
+// Server collects the reports of ethstats clients and aggregates them into an
+// overview of the fleet.
+type Server struct {
+	secret  string // Password the nodes need to log in with
+	history int    // Number of recent blocks to keep per node
+
+	nodes     map[string]*node
+	firstSeen map[common.Hash]time.Time // Time each block was first reported by any node
+	best      uint64                    // Highest block number reported by any node
+	lock      sync.RWMutex
+}
+
+// NewServer creates a stats collector accepting the nodes logging in with the
+// given secret, keeping the given number of recent blocks for each.
+func NewServer(secret string, history int) *Server {
+	return &Server{
+		secret:    secret,
+		history:   history,
+		nodes:     make(map[string]*node),
+		firstSeen: make(map[common.Hash]time.Time),
+	}
+}
+
+// Handler returns the websocket handler the stats clients connect to.
+func (s *Server) Handler() websocket.Handler {
+	return websocket.Handler(s.serveNode)
+}
+
+// serveNode authenticates a stats client and processes its reports until the
+// connection breaks.
+func (s *Server) serveNode(conn *websocket.Conn) {
+	defer conn.Close()
+
+	addr := conn.Request().RemoteAddr
+	logger := log.New("addr", addr)
+
+	conn.SetReadDeadline(time.Now().Add(loginTimeout))
+	id, err := s.login(conn)
+	if err != nil {
+		logger.Debug("Stats login failed", "err", err)
+		return
+	}
+	logger = logger.New("id", id)
+	logger.Info("Stats node connected")
+	defer func() {
+		s.disconnect(id, conn)
+		logger.Info("Stats node disconnected")
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		var msg emitMsg
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			logger.Debug("Failed to read stats message", "err", err)
+			return
+		}
+		if err := s.handle(id, conn, &msg); err != nil {
+			logger.Warn("Invalid stats message", "err", err)
+			return
+		}
+	}
+}
+
+// login waits for the hello message of a client, checks its secret and
+// registers the node.
+func (s *Server) login(conn *websocket.Conn) (string, error) {
+	var msg emitMsg
+	if err := websocket.JSON.Receive(conn, &msg); err != nil {
+		return "", err
+	}
+	if len(msg.Emit) != 2 || command(msg.Emit[0]) != "hello" {
+		return "", errInvalidLogin
+	}
+	var auth authMsg
+	if err := json.Unmarshal(msg.Emit[1], &auth); err != nil {
+		return "", err
+	}
+	if auth.Id == "" {
+		return "", errInvalidLogin
+	}
+	if auth.Secret != s.secret {
+		return "", errUnauthorized
+	}
+	now := time.Now()
+
+	s.lock.Lock()
+	n, ok := s.nodes[auth.Id]
+	if !ok {
+		n = &node{firstSeen: now, history: make(map[uint64]*BlockSummary)}
+		s.nodes[auth.Id] = n
+	}
+	if n.conn != nil {
+		// The node reconnected without the old connection noticing, replace it
+		n.conn.Close()
+		n.online += now.Sub(n.connected)
+	}
+	n.conn, n.connected = conn, now
+	n.summary.ID, n.summary.Info, n.summary.Addr = auth.Id, auth.Info, conn.Request().RemoteAddr
+	n.summary.Connected, n.summary.LastSeen = true, now
+	s.lock.Unlock()
+
+	return auth.Id, websocket.JSON.Send(conn, map[string][]string{"emit": {"ready"}})
+}
+
+// disconnect marks a node as offline, unless it has reconnected already.
+func (s *Server) disconnect(id string, conn *websocket.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if n := s.nodes[id]; n != nil && n.conn == conn {
+		n.online += time.Since(n.connected)
+		n.conn, n.connected = nil, time.Time{}
+		n.summary.Connected = false
+	}
+}
+
+// command extracts the command name of a message.
+func command(raw json.RawMessage) string {
+	var cmd string
+	json.Unmarshal(raw, &cmd)
+	return cmd
+}
+
+// handle processes a report of a logged in node.
+func (s *Server) handle(id string, conn *websocket.Conn, msg *emitMsg) error {
+	if len(msg.Emit) == 0 {
+		return errors.New("empty message")
+	}
+	cmd := command(msg.Emit[0])
+	if len(msg.Emit) != 2 {
+		return fmt.Errorf("malformed %q message", cmd)
+	}
+	payload := msg.Emit[1]
+
+	switch cmd {
+	case "node-ping":
+		var ping struct {
+			ClientTime string `json:"clientTime"`
+		}
+		if err := json.Unmarshal(payload, &ping); err != nil {
+			return err
+		}
+		pong := map[string][]interface{}{
+			"emit": {"node-pong", map[string]string{
+				"id":         id,
+				"clientTime": ping.ClientTime,
+				"serverTime": time.Now().String(),
+			}},
+		}
+		return websocket.JSON.Send(conn, pong)
+
+	case "latency":
+		var report struct {
+			Latency string `json:"latency"`
+		}
+		if err := json.Unmarshal(payload, &report); err != nil {
+			return err
+		}
+		latency, err := strconv.Atoi(report.Latency)
+		if err != nil {
+			return err
+		}
+		s.update(id, func(n *node) { n.summary.Latency = latency })
+
+	case "block":
+		var report struct {
+			Block *blockStats `json:"block"`
+		}
+		if err := json.Unmarshal(payload, &report); err != nil {
+			return err
+		}
+		if report.Block == nil || report.Block.Number == nil {
+			return errors.New("missing block")
+		}
+		if missing := s.reportBlock(id, report.Block); len(missing) > 0 {
+			request := map[string][]interface{}{
+				"emit": {"history", map[string][]uint64{"list": missing}},
+			}
+			return websocket.JSON.Send(conn, request)
+		}
+
+	case "pending":
+		var report struct {
+			Stats struct {
+				Pending int `json:"pending"`
+			} `json:"stats"`
+		}
+		if err := json.Unmarshal(payload, &report); err != nil {
+			return err
+		}
+		s.update(id, func(n *node) { n.summary.Pending = report.Stats.Pending })
+
+	case "stats":
+		var report struct {
+			Stats nodeStats `json:"stats"`
+		}
+		if err := json.Unmarshal(payload, &report); err != nil {
+			return err
+		}
+		s.update(id, func(n *node) { n.summary.Stats = report.Stats })
+
+	case "history":
+		var report struct {
+			History []*blockStats `json:"history"`
+		}
+		if err := json.Unmarshal(payload, &report); err != nil {
+			return err
+		}
+		now := time.Now()
+		s.update(id, func(n *node) {
+			for _, block := range report.History {
+				if block != nil && block.Number != nil {
+					s.addHistory(n, s.summarize(block, now))
+				}
+			}
+		})
+
+	default:
+		log.Debug("Unknown stats message", "id", id, "cmd", cmd)
+	}
+	return nil
+}
+
+// update runs fn on the state of a node while holding the lock.
+func (s *Server) update(id string, fn func(n *node)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if n := s.nodes[id]; n != nil {
+		fn(n)
+		n.summary.LastSeen = time.Now()
+	}
+}
+
+// reportBlock updates the head block of a node, returning the numbers of the
+// blocks missing from its history, if it can serve them.
+func (s *Server) reportBlock(id string, block *blockStats) []uint64 {
+	now := time.Now()
+
+	var missing []uint64
+	s.update(id, func(n *node) {
+		summary := s.summarize(block, now)
+		if n.summary.Block != nil && n.summary.Info.History {
+			from := n.summary.Block.Number + 1
+			if summary.Number > from+maxHistoryGap {
+				from = summary.Number - maxHistoryGap
+			}
+			for number := from; number < summary.Number; number++ {
+				if _, ok := n.history[number]; !ok {
+					missing = append(missing, number)
+				}
+			}
+		}
+		n.summary.Block = summary
+		s.addHistory(n, summary)
+
+		if summary.Number > s.best {
+			s.best = summary.Number
+			s.pruneFirstSeen()
+		}
+	})
+	return missing
+}
+
+// summarize condenses a reported block, tracking the time it was first seen by
+// the fleet. The caller must hold the lock.
+func (s *Server) summarize(block *blockStats, now time.Time) *BlockSummary {
+	seen, ok := s.firstSeen[block.Hash]
+	if !ok {
+		seen = now
+		s.firstSeen[block.Hash] = now
+	}
+	return &BlockSummary{
+		Number:      block.Number.Uint64(),
+		Hash:        block.Hash,
+		Timestamp:   bigUint64(block.Timestamp),
+		Miner:       block.Miner,
+		GasUsed:     bigUint64(block.GasUsed),
+		GasLimit:    bigUint64(block.GasLimit),
+		Difficulty:  block.Diff,
+		TotalDiff:   block.TotalDiff,
+		Txs:         len(block.Txs),
+		Uncles:      len(block.Uncles),
+		Propagation: now.Sub(seen),
+	}
+}
+
+// addHistory stores a block in the history of a node, dropping the blocks too
+// far behind the head. The caller must hold the lock.
+func (s *Server) addHistory(n *node, block *BlockSummary) {
+	n.history[block.Number] = block
+	if head := n.summary.Block; head != nil && head.Number >= uint64(s.history) {
+		for number := range n.history {
+			if number <= head.Number-uint64(s.history) {
+				delete(n.history, number)
+			}
+		}
+	}
+}
+
+// pruneFirstSeen drops the propagation tracking of blocks which no node will
+// report anymore. The caller must hold the lock.
+func (s *Server) pruneFirstSeen() {
+	if len(s.firstSeen) < 4*s.history {
+		return
+	}
+	cutoff := time.Now().Add(-time.Hour)
+	for hash, seen := range s.firstSeen {
+		if seen.Before(cutoff) {
+			delete(s.firstSeen, hash)
+		}
+	}
+}
+
+// Nodes returns the summaries of all the nodes that ever reported, sorted by
+// their identifiers.
+func (s *Server) Nodes() []*NodeSummary {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	now := time.Now()
+	nodes := make([]*NodeSummary, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		summary := n.summary
+		summary.Uptime = n.uptime(now)
+		nodes = append(nodes, &summary)
+	}
+	sort.Sort(nodesByID(nodes))
+	return nodes
+}
+
+// Node returns the details of a single node, nil if unknown.
+func (s *Server) Node(id string) *NodeDetails {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	n := s.nodes[id]
+	if n == nil {
+		return nil
+	}
+	details := &NodeDetails{NodeSummary: n.summary, History: make([]*BlockSummary, 0, len(n.history))}
+	details.Uptime = n.uptime(time.Now())
+	for _, block := range n.history {
+		details.History = append(details.History, block)
+	}
+	sort.Sort(blocksByNumber(details.History))
+	return details
+}
+
+// Best returns the highest block number reported by any node.
+func (s *Server) Best() uint64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.best
+}
+
+type nodesByID []*NodeSummary
+
+func (n nodesByID) Len() int           { return len(n) }
+func (n nodesByID) Swap(i, j int)      { n[i], n[j] = n[j], n[i] }
+func (n nodesByID) Less(i, j int) bool { return n[i].ID < n[j].ID }
+
+type blocksByNumber []*BlockSummary
+
+func (b blocksByNumber) Len() int           { return len(b) }
+func (b blocksByNumber) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b blocksByNumber) Less(i, j int) bool { return b[i].Number < b[j].Number }
+
+// bigUint64 converts an optional big integer to uint64.
+func bigUint64(n *big.Int) uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.Uint64()
+}
@@ -0,0 +1,177 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// statsClient is a minimal ethstats client speaking the reporting protocol.
+type statsClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dialStats(t *testing.T, server *httptest.Server) *statsClient {
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api", "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return &statsClient{t, conn}
+}
+
+func (c *statsClient) emit(cmd string, payload interface{}) {
+	if err := websocket.JSON.Send(c.conn, map[string][]interface{}{"emit": {cmd, payload}}); err != nil {
+		c.t.Fatalf("failed to send %s: %v", cmd, err)
+	}
+}
+
+func (c *statsClient) expect(cmd string) json.RawMessage {
+	var msg emitMsg
+	if err := websocket.JSON.Receive(c.conn, &msg); err != nil {
+		c.t.Fatalf("failed to receive %s: %v", cmd, err)
+	}
+	if len(msg.Emit) == 0 || command(msg.Emit[0]) != cmd {
+		c.t.Fatalf("message mismatch: have %v, want %s", msg.Emit, cmd)
+	}
+	if len(msg.Emit) > 1 {
+		return msg.Emit[1]
+	}
+	return nil
+}
+
+func (c *statsClient) login(secret string) {
+	c.emit("hello", map[string]interface{}{
+		"id":     "node-1",
+		"secret": secret,
+		"info":   map[string]interface{}{"name": "node-1", "node": "ged/v1.5.0", "net": "1", "protocol": "eth/63", "canUpdateHistory": true},
+	})
+}
+
+func block(number int) map[string]interface{} {
+	return map[string]interface{}{
+		"number":       number,
+		"hash":         "0x" + strings.Repeat("0", 62) + string('0'+rune(number/10)) + string('0'+rune(number%10)),
+		"timestamp":    1000 + number,
+		"gasUsed":      21000,
+		"gasLimit":     4712388,
+		"difficulty":   "131072",
+		"transactions": []interface{}{map[string]string{"hash": "0x01"}},
+		"uncles":       []interface{}{},
+	}
+}
+
+func TestLoginUnauthorized(t *testing.T) {
+	server := httptest.NewServer(newHandler(NewServer("secret", 10)))
+	defer server.Close()
+
+	client := dialStats(t, server)
+	defer client.conn.Close()
+
+	client.login("wrong")
+	var msg emitMsg
+	if err := websocket.JSON.Receive(client.conn, &msg); err == nil {
+		t.Fatalf("unauthorized node accepted: %v", msg.Emit)
+	}
+}
+
+func TestReporting(t *testing.T) {
+	stats := NewServer("secret", 10)
+	server := httptest.NewServer(newHandler(stats))
+	defer server.Close()
+
+	client := dialStats(t, server)
+	client.login("secret")
+	client.expect("ready")
+
+	// Ping the server the way the clients measure the latency
+	client.emit("node-ping", map[string]string{"id": "node-1", "clientTime": "now"})
+	var pong struct {
+		ClientTime string `json:"clientTime"`
+	}
+	if err := json.Unmarshal(client.expect("node-pong"), &pong); err != nil || pong.ClientTime != "now" {
+		t.Fatalf("invalid pong: %v, %+v", err, pong)
+	}
+	client.emit("latency", map[string]string{"id": "node-1", "latency": "12"})
+	client.emit("pending", map[string]interface{}{"id": "node-1", "stats": map[string]int{"pending": 3}})
+	client.emit("stats", map[string]interface{}{"id": "node-1", "stats": map[string]interface{}{"active": true, "peers": 5}})
+
+	// Report a block with a gap, expect the missing ones to be requested
+	client.emit("block", map[string]interface{}{"id": "node-1", "block": block(5)})
+	client.emit("block", map[string]interface{}{"id": "node-1", "block": block(8)})
+
+	var request struct {
+		List []uint64 `json:"list"`
+	}
+	if err := json.Unmarshal(client.expect("history"), &request); err != nil {
+		t.Fatalf("invalid history request: %v", err)
+	}
+	if len(request.List) != 2 || request.List[0] != 6 || request.List[1] != 7 {
+		t.Fatalf("history request mismatch: have %v, want [6 7]", request.List)
+	}
+	client.emit("history", map[string]interface{}{"id": "node-1", "history": []interface{}{block(6), block(7)}})
+
+	// Wait for the reports to be processed, then check the aggregated state
+	for i := 0; i < 100; i++ {
+		if details := stats.Node("node-1"); details != nil && len(details.History) == 4 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	res, err := http.Get(server.URL + "/api/nodes/node-1")
+	if err != nil {
+		t.Fatalf("failed to query node: %v", err)
+	}
+	var details NodeDetails
+	if err := json.NewDecoder(res.Body).Decode(&details); err != nil {
+		t.Fatalf("failed to decode node details: %v", err)
+	}
+	res.Body.Close()
+
+	if !details.Connected || details.Latency != 12 || details.Pending != 3 || details.Stats.Peers != 5 {
+		t.Errorf("node state mismatch: %+v", details.NodeSummary)
+	}
+	if details.Block == nil || details.Block.Number != 8 || details.Block.Txs != 1 {
+		t.Errorf("head block mismatch: %+v", details.Block)
+	}
+	if len(details.History) != 4 || details.History[0].Number != 5 || details.History[3].Number != 8 {
+		t.Errorf("history mismatch: %d blocks", len(details.History))
+	}
+	if stats.Best() != 8 {
+		t.Errorf("best block mismatch: have %d, want 8", stats.Best())
+	}
+	if res, err := http.Get(server.URL + "/"); err != nil || res.StatusCode != http.StatusOK {
+		t.Errorf("failed to render overview: %v", err)
+	}
+	// Disconnect and check that the node is kept as offline
+	client.conn.Close()
+	for i := 0; i < 100; i++ {
+		if nodes := stats.Nodes(); len(nodes) == 1 && !nodes[0].Connected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("node still connected after closing the connection")
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/log"
+)
+
+// newHandler assembles the HTTP routes of the collector: the websocket endpoint
+// the nodes report to, the JSON API and the HTML overview.
+func newHandler(server *Server) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/api", server.Handler())
+	mux.HandleFunc("/api/nodes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, server.Nodes())
+	})
+	mux.HandleFunc("/api/nodes/", func(w http.ResponseWriter, r *http.Request) {
+		details := server.Node(strings.TrimPrefix(r.URL.Path, "/api/nodes/"))
+		if details == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, details)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		data := struct {
+			Best  uint64
+			Nodes []*NodeSummary
+		}{server.Best(), server.Nodes()}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := overviewTemplate.Execute(w, data); err != nil {
+			log.Warn("Failed to render overview", "err", err)
+		}
+	})
+	return mux
+}
+
+// writeJSON sends a value as the JSON response of an API request.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warn("Failed to send API response", "err", err)
+	}
+}
+
+var overviewTemplate = template.Must(template.New("overview").Funcs(template.FuncMap{
+	"short": func(s string) string {
+		if len(s) > 14 {
+			return s[:8] + "…" + s[len(s)-4:]
+		}
+		return s
+	},
+	"ms": func(d time.Duration) int64 { return int64(d / time.Millisecond) },
+	"ago": func(t time.Time) string {
+		return (time.Since(t) / time.Second * time.Second).String()
+	},
+	"behind": func(best uint64, block *BlockSummary) uint64 {
+		if block == nil || block.Number >= best {
+			return 0
+		}
+		return best - block.Number
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<meta http-equiv="refresh" content="5">
+	<title>Network Stats</title>
+	<style>
+		body { font-family: sans-serif; background: #1e2129; color: #d5d8de; margin: 24px; }
+		table { border-collapse: collapse; width: 100%; font-size: 13px; }
+		th, td { padding: 6px 10px; text-align: left; border-bottom: 1px solid #353a46; }
+		th { color: #8d93a0; font-weight: normal; }
+		a { color: #4fc3f7; text-decoration: none; }
+		.offline { color: #6b7080; }
+		.behind { color: #ffb74d; }
+	</style>
+</head>
+<body>
+	<h1>Network Stats</h1>
+	<p>Best block #{{.Best}}, {{len .Nodes}} nodes</p>
+	<table>
+		<tr>
+			<th>Node</th><th>Client</th><th>Network</th><th>Peers</th><th>Pending</th><th>Latency</th>
+			<th>Block</th><th>Hash</th><th>Txs</th><th>Propagation</th><th>Mining</th><th>Uptime</th><th>Last seen</th>
+		</tr>
+		{{range .Nodes}}
+		<tr{{if not .Connected}} class="offline"{{end}}>
+			<td><a href="/api/nodes/{{.ID}}">{{.ID}}</a></td>
+			<td>{{.Info.Node}}</td>
+			<td>{{.Info.Protocol}} ({{.Info.Network}})</td>
+			<td>{{.Stats.Peers}}</td>
+			<td>{{.Pending}}</td>
+			<td>{{.Latency}} ms</td>
+			{{with .Block}}
+			<td{{if behind $.Best .}} class="behind"{{end}}>#{{.Number}}</td>
+			<td>{{short .Hash.Hex}}</td>
+			<td>{{.Txs}}</td>
+			<td>{{ms .Propagation}} ms</td>
+			{{else}}
+			<td>-</td><td>-</td><td>-</td><td>-</td>
+			{{end}}
+			<td>{{if .Stats.Mining}}{{.Stats.Hashrate}} H/s{{else}}no{{end}}</td>
+			<td>{{printf "%.1f" .Uptime}}%</td>
+			<td>{{ago .LastSeen}}</td>
+		</tr>
+		{{end}}
+	</table>
+</body>
+</html>
+`))
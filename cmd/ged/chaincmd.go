@@ -104,6 +104,18 @@ TODO: Please write this
 		Description: `
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.
+`,
+	}
+	addrTxIndexCommand = cli.Command{
+		Action:    buildAddrTxIndex,
+		Name:      "addrtxindex",
+		Usage:     "Index the transactions of an existing chain by address",
+		ArgsUsage: " ",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+The addrtxindex command builds the address transaction index used by
+eth_getTransactionsByAddress for all blocks already in the database. Once
+built, the index is kept up to date by running ged with --addrtxindex.
 `,
 	}
 )
@@ -290,6 +302,19 @@ func upgradeDB(ctx *cli.Context) error {
 	return nil
 }
 
+func buildAddrTxIndex(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	start := time.Now()
+	if err := core.BuildAddrTxIndex(chainDb, chain.Config(), chain.CurrentBlock().NumberU64()); err != nil {
+		utils.Fatalf("Indexing error: %v", err)
+	}
+	fmt.Printf("Indexing done in %v\n", time.Since(start))
+	return nil
+}
+
 func dbDirectory(db ethdb.Database) string {
 	ldb, ok := db.(*ethdb.LDBDatabase)
 	if !ok {
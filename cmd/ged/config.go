@@ -0,0 +1,293 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/eth"
+	"github.com/EarthDollar/go-earthdollar/internal/toml"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var dumpConfigCommand = cli.Command{
+	Action:    dumpConfig,
+	Name:      "dumpconfig",
+	Usage:     "Show configuration values",
+	ArgsUsage: " ",
+	Category:  "MISCELLANEOUS COMMANDS",
+	Description: `
+The dumpconfig command prints the effective configuration of the node, merged
+from the --config file and the command line flags, in TOML format. The output
+can be used as a starting point for a configuration file. RPC bearer tokens and
+JWT secrets are redacted and need to be filled in again.
+`,
+}
+
+// gedConfig is the layout of the TOML configuration file.
+type gedConfig struct {
+	Eth  eth.Config
+	Node node.Config
+}
+
+// loadConfig decodes a TOML configuration file on top of the values in cfg.
+func loadConfig(file string, cfg *gedConfig) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("%s, %v", file, err)
+	}
+	return nil
+}
+
+// defaultContext creates a CLI context in which none of the flags are set, used
+// to derive the default configuration values.
+func defaultContext() *cli.Context {
+	set := flag.NewFlagSet("defaults", flag.ContinueOnError)
+	for _, f := range app.Flags {
+		f.Apply(set)
+	}
+	return cli.NewContext(app, set, nil)
+}
+
+// configFlags maps the fields of a configuration section to the command line
+// flags they are derived from. Fields of nested structs are named by their path,
+// e.g. "HTTPLimits.Timeout".
+type configFlags map[string][]cli.Flag
+
+// nodeConfigFlags lists the flags of the node configuration fields.
+var nodeConfigFlags = configFlags{
+	"DataDir":            {utils.DataDirFlag, utils.TestNetFlag, utils.DevModeFlag},
+	"KeyStoreDir":        {utils.KeyStoreDirFlag},
+	"UseLightweightKDF":  {utils.LightKDFFlag, utils.DevModeFlag},
+	"PrivateKey":         {utils.NodeKeyHexFlag, utils.NodeKeyFileFlag},
+	"UserIdent":          {utils.IdentityFlag, utils.VMEnableJitFlag},
+	"NoDiscovery":        {utils.NoDiscoverFlag, utils.LightModeFlag},
+	"DiscoveryV5":        {utils.DiscoveryV5Flag, utils.LightModeFlag, utils.LightServFlag},
+	"DiscoveryV5Addr":    {utils.ListenPortFlag},
+	"BootstrapNodes":     {utils.BootnodesFlag, utils.TestNetFlag},
+	"BootstrapNodesV5":   {utils.BootnodesFlag},
+	"DNSDiscovery":       {utils.DNSDiscoveryFlag},
+	"ListenAddr":         {utils.ListenPortFlag, utils.DevModeFlag},
+	"NAT":                {utils.NATFlag},
+	"NetRestrict":        {utils.NetrestrictFlag},
+	"MsgRecordDir":       {utils.MsgRecordFlag},
+	"MsgRecordProtocols": {utils.MsgRecordFlag, utils.MsgRecordProtocolsFlag},
+	"MaxPeers":           {utils.MaxPeersFlag, utils.DevModeFlag},
+	"MaxPendingPeers":    {utils.MaxPendingPeersFlag},
+	"IPCPath":            {utils.IPCDisabledFlag, utils.IPCPathFlag},
+	"HTTPHost":           {utils.RPCEnabledFlag, utils.RPCListenAddrFlag},
+	"HTTPPort":           {utils.RPCPortFlag},
+	"HTTPCors":           {utils.RPCCORSDomainFlag},
+	"HTTPModules":        {utils.RPCApiFlag},
+	"WSHost":             {utils.WSEnabledFlag, utils.WSListenAddrFlag},
+	"WSPort":             {utils.WSPortFlag},
+	"WSOrigins":          {utils.WSAllowedOriginsFlag},
+	"WSModules":          {utils.WSApiFlag},
+}
+
+// ethConfigFlags lists the flags of the Ethereum configuration fields.
+var ethConfigFlags = configFlags{
+	"ChainConfig":             {utils.DevModeFlag},
+	"Genesis":                 {utils.TestNetFlag, utils.DevModeFlag},
+	"NetworkId":               {utils.NetworkIdFlag, utils.TestNetFlag},
+	"FastSync":                {utils.FastSyncFlag},
+	"LightMode":               {utils.LightModeFlag},
+	"LightServ":               {utils.LightServFlag},
+	"LightPeers":              {utils.LightPeersFlag},
+	"MaxPeers":                {utils.MaxPeersFlag},
+	"DatabaseCache":           {utils.CacheFlag},
+	"DocRoot":                 {utils.DocRootFlag},
+	"AutoDAG":                 {utils.AutoDAGFlag, utils.MiningEnabledFlag},
+	"PowFake":                 {utils.DevModeFlag},
+	"ExtraData":               {utils.ExtraDataFlag},
+	"DevMode":                 {utils.DevModeFlag},
+	"DevPeriod":               {utils.DevModeFlag, utils.DevPeriodFlag},
+	"Etherbase":               {utils.EtherbaseFlag, utils.DevModeFlag},
+	"GasPrice":                {utils.GasPriceFlag, utils.DevModeFlag},
+	"MinerThreads":            {utils.MinerThreadsFlag},
+	"SolcPath":                {utils.SolcPathFlag},
+	"GpoMinGasPrice":          {utils.GpoMinGasPriceFlag},
+	"GpoMaxGasPrice":          {utils.GpoMaxGasPriceFlag},
+	"GpoFullBlockRatio":       {utils.GpoFullBlockRatioFlag},
+	"GpobaseStepDown":         {utils.GpobaseStepDownFlag},
+	"GpobaseStepUp":           {utils.GpobaseStepUpFlag},
+	"GpobaseCorrectionFactor": {utils.GpobaseCorrectionFactorFlag},
+	"EnablePreimageRecording": {utils.VMEnableDebugFlag},
+	"AddrTxIndex":             {utils.AddrTxIndexFlag},
+}
+
+// mergeConfig copies the fields derived from flags set on the command line from
+// the flag derived config into the one loaded from the config file, so explicit
+// flags take precedence even if they repeat the default value. Both arguments
+// must be pointers to the same struct type.
+func mergeConfig(ctx *cli.Context, file, flags interface{}, fields configFlags) error {
+	fv := reflect.ValueOf(file).Elem()
+	lv := reflect.ValueOf(flags).Elem()
+	for path, list := range fields {
+		for _, f := range list {
+			if !ctx.GlobalIsSet(f.GetName()) {
+				continue
+			}
+			dst, err := configField(fv, path)
+			if err != nil {
+				return err
+			}
+			src, err := configField(lv, path)
+			if err != nil {
+				return err
+			}
+			dst.Set(src)
+			break
+		}
+	}
+	return nil
+}
+
+// configField resolves a dot separated field path within a struct, allocating
+// nil struct pointers along the way.
+func configField(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("unknown config field %q of %v", path, v.Type())
+		}
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			return reflect.Value{}, fmt.Errorf("unknown config field %q of %v", path, v.Type())
+		}
+		v = field
+	}
+	return v, nil
+}
+
+// makeNodeConfig assembles the node configuration from the --config file, if
+// given, overridden by the flags set on the command line.
+func makeNodeConfig(ctx *cli.Context) node.Config {
+	cfg := *utils.MakeNodeConfig(ctx, clientIdentifier, gitCommit)
+	file := ctx.GlobalString(utils.ConfigFileFlag.Name)
+	if file == "" {
+		return cfg
+	}
+	loaded := gedConfig{Node: *utils.MakeNodeConfig(defaultContext(), clientIdentifier, gitCommit)}
+	if err := loadConfig(file, &loaded); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	if err := mergeConfig(ctx, &loaded.Node, &cfg, nodeConfigFlags); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	return loaded.Node
+}
+
+// makeExtraData creates the default extradata of mined blocks, containing the
+// client version and runtime information.
+func makeExtraData() []byte {
+	var clientInfo = struct {
+		Version   uint
+		Name      string
+		GoVersion string
+		Os        string
+	}{uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch), clientIdentifier, runtime.Version(), runtime.GOOS}
+	extra, err := rlp.EncodeToBytes(clientInfo)
+	if err != nil {
+		glog.V(logger.Warn).Infoln("error setting canonical miner information:", err)
+	}
+	if uint64(len(extra)) > params.MaximumExtraDataSize.Uint64() {
+		glog.V(logger.Warn).Infoln("error setting canonical miner information: extra exceeds", params.MaximumExtraDataSize)
+		glog.V(logger.Debug).Infof("extra: %x\n", extra)
+		extra = nil
+	}
+	return extra
+}
+
+// makeConfigNode creates the protocol stack and assembles the Ethereum config
+// from the command line flags, layered on top of the --config file if given.
+func makeConfigNode(ctx *cli.Context) (*node.Node, gedConfig) {
+	cfg := gedConfig{Node: makeNodeConfig(ctx)}
+	stack, err := node.New(&cfg.Node)
+	if err != nil {
+		utils.Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	extra := makeExtraData()
+	cfg.Eth = *utils.MakeEthConfig(ctx, stack, extra)
+	if file := ctx.GlobalString(utils.ConfigFileFlag.Name); file != "" {
+		// The Ethereum defaults depend on the accounts of the node, so the file
+		// is decoded a second time on top of those.
+		loaded := gedConfig{Eth: *utils.MakeEthConfig(defaultContext(), stack, extra)}
+		if err := loadConfig(file, &loaded); err != nil {
+			utils.Fatalf("%v", err)
+		}
+		if err := mergeConfig(ctx, &loaded.Eth, &cfg.Eth, ethConfigFlags); err != nil {
+			utils.Fatalf("%v", err)
+		}
+		cfg.Eth = loaded.Eth
+	}
+	return stack, cfg
+}
+
+// dumpConfig is the dumpconfig command.
+func dumpConfig(ctx *cli.Context) error {
+	_, cfg := makeConfigNode(ctx)
+	redactAuth(&cfg.Node.HTTPAuth)
+	redactAuth(&cfg.Node.WSAuth)
+	out, err := toml.Marshal(&cfg)
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(out)
+	return nil
+}
+
+// redactedSecret replaces the JWT secrets in the dumped configuration. It isn't
+// valid hex, so a config file still carrying it fails to load.
+const redactedSecret = "<redacted>"
+
+// redactAuth removes the credentials of an RPC endpoint, keeping the API they
+// grant access to. Bearer tokens are cleared, which fails loading too.
+func redactAuth(auth *rpc.AuthConfig) {
+	if auth.JWTSecret != "" {
+		auth.JWTSecret = redactedSecret
+	}
+	if auth.Tokens != nil {
+		tokens := make([]rpc.AuthToken, len(auth.Tokens))
+		for i, token := range auth.Tokens {
+			token.Token = ""
+			tokens[i] = token
+		}
+		auth.Tokens = tokens
+	}
+}
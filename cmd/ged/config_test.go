@@ -0,0 +1,177 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/eth"
+	"github.com/EarthDollar/go-earthdollar/internal/toml"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// flagContext creates a CLI context with the given command line flags set.
+func flagContext(t *testing.T, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range app.Flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(app, set, nil)
+}
+
+// Tests that flags set on the command line override the config file, even if
+// they are set to their default value.
+func TestConfigFlagPrecedence(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.toml")
+	content := "[Node]\nHTTPPort = 9000\nMaxPeers = 10\nHTTPCors = \"file.example\"\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := flagContext(t,
+		"--datadir", dir,
+		"--config", file,
+		"--rpcport", fmt.Sprint(node.DefaultHTTPPort),
+		"--maxpeers", "7",
+	)
+	cfg := makeNodeConfig(ctx)
+	if cfg.HTTPPort != node.DefaultHTTPPort {
+		t.Errorf("HTTPPort mismatch: have %d, want %d", cfg.HTTPPort, node.DefaultHTTPPort)
+	}
+	if cfg.MaxPeers != 7 {
+		t.Errorf("MaxPeers mismatch: have %d, want 7", cfg.MaxPeers)
+	}
+	if cfg.HTTPCors != "file.example" {
+		t.Errorf("HTTPCors mismatch: have %q, want %q", cfg.HTTPCors, "file.example")
+	}
+	if cfg.DataDir != dir {
+		t.Errorf("DataDir mismatch: have %q, want %q", cfg.DataDir, dir)
+	}
+}
+
+// Tests that flags only replace the nested fields derived from them.
+func TestMergeConfigNested(t *testing.T) {
+	type section struct{ X, Y int }
+	type config struct {
+		Sub section
+		Ptr *section
+	}
+	fields := configFlags{
+		"Sub.X": {utils.RPCPortFlag},
+		"Ptr.Y": {utils.WSPortFlag},
+	}
+	file := config{Sub: section{1, 2}}
+	flags := config{Sub: section{3, 4}, Ptr: &section{5, 6}}
+	if err := mergeConfig(flagContext(t, "--rpcport", "3", "--wsport", "6"), &file, &flags, fields); err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{Sub: section{3, 2}, Ptr: &section{0, 6}}
+	if !reflect.DeepEqual(file, want) {
+		t.Errorf("merged config mismatch: have %+v (%+v), want %+v (%+v)", file, file.Ptr, want, want.Ptr)
+	}
+}
+
+// Tests that all fields listed in the flag tables exist.
+func TestConfigFlagFields(t *testing.T) {
+	for path := range nodeConfigFlags {
+		if _, err := configField(reflect.ValueOf(new(node.Config)).Elem(), path); err != nil {
+			t.Error(err)
+		}
+	}
+	for path := range ethConfigFlags {
+		if _, err := configField(reflect.ValueOf(new(eth.Config)).Elem(), path); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// Tests that unknown fields in a flag table are reported instead of merged.
+func TestMergeConfigUnknownField(t *testing.T) {
+	type config struct{ X int }
+	ctx := flagContext(t, "--rpcport", "3")
+	for _, path := range []string{"Y", "X.Y"} {
+		var file, flags config
+		if err := mergeConfig(ctx, &file, &flags, configFlags{path: {utils.RPCPortFlag}}); err == nil {
+			t.Errorf("field %q: expected error", path)
+		}
+	}
+	// Entries of flags which aren't set aren't resolved
+	var file, flags config
+	if err := mergeConfig(ctx, &file, &flags, configFlags{"Y": {utils.WSPortFlag}}); err != nil {
+		t.Errorf("unset flag: unexpected error: %v", err)
+	}
+}
+
+// Tests that the credentials of the RPC endpoints are not dumped.
+func TestRedactAuth(t *testing.T) {
+	dir := tmpdir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		file   = filepath.Join(dir, "config.toml")
+		secret = strings.Repeat("ab", 32)
+	)
+	content := fmt.Sprintf("[Node.HTTPAuth]\nJWTSecret = %q\n[[Node.HTTPAuth.Tokens]]\nToken = \"http-token\"\nModules = [\"eth\"]\n[[Node.WSAuth.Tokens]]\nToken = \"ws-token\"\n", secret)
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var cfg gedConfig
+	if err := loadConfig(file, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	tokens := cfg.Node.HTTPAuth.Tokens
+	redactAuth(&cfg.Node.HTTPAuth)
+	redactAuth(&cfg.Node.WSAuth)
+
+	out, err := toml.Marshal(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{secret, "http-token", "ws-token"} {
+		if strings.Contains(string(out), s) {
+			t.Errorf("dumped config contains %q:\n%s", s, out)
+		}
+	}
+	if have := cfg.Node.HTTPAuth.Tokens[0].Modules; !reflect.DeepEqual(have, []string{"eth"}) {
+		t.Errorf("token modules mismatch: have %v, want [eth]", have)
+	}
+	if tokens[0].Token != "http-token" {
+		t.Errorf("redaction modified the original tokens")
+	}
+	// Neither the redacted secret nor the cleared tokens must be accepted.
+	for _, auth := range []rpc.AuthConfig{{JWTSecret: cfg.Node.HTTPAuth.JWTSecret}, {Tokens: cfg.Node.WSAuth.Tokens}} {
+		if err := rpc.NewServer().SetAuth(&auth); err == nil {
+			t.Errorf("redacted credentials accepted: %+v", auth)
+		}
+	}
+}
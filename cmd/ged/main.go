@@ -31,12 +31,9 @@ import (
 	"github.com/EarthDollar/go-earthdollar/contracts/release"
 	"github.com/EarthDollar/go-earthdollar/eth"
 	"github.com/EarthDollar/go-earthdollar/internal/debug"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/metrics"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/params"
-	"github.com/EarthDollar/go-earthdollar/rlp"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -66,6 +63,7 @@ func init() {
 		upgradedbCommand,
 		removedbCommand,
 		dumpCommand,
+		addrTxIndexCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -79,6 +77,8 @@ func init() {
 		makedagCommand,
 		versionCommand,
 		licenseCommand,
+		// See config.go:
+		dumpConfigCommand,
 	}
 
 	app.Flags = []cli.Flag{
@@ -86,6 +86,7 @@ func init() {
 		utils.UnlockedAccountFlag,
 		utils.PasswordFileFlag,
 		utils.BootnodesFlag,
+		utils.ConfigFileFlag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.FastSyncFlag,
@@ -93,6 +94,7 @@ func init() {
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
+		utils.AddrTxIndexFlag,
 		utils.CacheFlag,
 		utils.TrieCacheGenFlag,
 		utils.JSpathFlag,
@@ -108,6 +110,7 @@ func init() {
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
+		utils.DNSDiscoveryFlag,
 		utils.NetrestrictFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
@@ -127,6 +130,7 @@ func init() {
 		utils.PreloadJSFlag,
 		utils.WhisperEnabledFlag,
 		utils.DevModeFlag,
+		utils.DevPeriodFlag,
 		utils.TestNetFlag,
 		utils.VMForceJitFlag,
 		utils.VMJitCacheFlag,
@@ -134,8 +138,19 @@ func init() {
 		utils.VMEnableDebugFlag,
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
+		utils.GraphQLEnabledFlag,
+		utils.GraphQLListenAddrFlag,
+		utils.GraphQLPortFlag,
+		utils.GraphQLCORSDomainFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
+		utils.MetricsHTTPFlag,
+		utils.DashboardEnabledFlag,
+		utils.DashboardAddrFlag,
+		utils.DashboardPortFlag,
+		utils.DashboardRefreshFlag,
+		utils.MsgRecordFlag,
+		utils.MsgRecordProtocolsFlag,
 		utils.FakePoWFlag,
 		utils.SolcPathFlag,
 		utils.GpoMinGasPriceFlag,
@@ -155,6 +170,7 @@ func init() {
 		}
 		// Start system runtime metrics collection
 		go metrics.CollectProcessMetrics(3 * time.Second)
+		utils.SetupMetrics(ctx)
 
 		// This should be the only place where reporting is enabled
 		// because it is not intended to run while testing.
@@ -191,24 +207,8 @@ func ged(ctx *cli.Context) error {
 }
 
 func makeFullNode(ctx *cli.Context) *node.Node {
-	// Create the default extradata and construct the base node
-	var clientInfo = struct {
-		Version   uint
-		Name      string
-		GoVersion string
-		Os        string
-	}{uint(params.VersionMajor<<16 | params.VersionMinor<<8 | params.VersionPatch), clientIdentifier, runtime.Version(), runtime.GOOS}
-	extra, err := rlp.EncodeToBytes(clientInfo)
-	if err != nil {
-		glog.V(logger.Warn).Infoln("error setting canonical miner information:", err)
-	}
-	if uint64(len(extra)) > params.MaximumExtraDataSize.Uint64() {
-		glog.V(logger.Warn).Infoln("error setting canonical miner information: extra exceeds", params.MaximumExtraDataSize)
-		glog.V(logger.Debug).Infof("extra: %x\n", extra)
-		extra = nil
-	}
-	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
-	utils.RegisterEthService(ctx, stack, extra)
+	stack, cfg := makeConfigNode(ctx)
+	utils.RegisterEthService(ctx, stack, &cfg.Eth)
 
 	// Whisper must be explicitly enabled, but is auto-enabled in --dev mode.
 	shhEnabled := ctx.GlobalBool(utils.WhisperEnabledFlag.Name)
@@ -220,6 +220,15 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	if url := ctx.GlobalString(utils.EthStatsURLFlag.Name); url != "" {
 		utils.RegisterEthStatsService(stack, url)
 	}
+	// Add the dashboard if requested
+	if ctx.GlobalBool(utils.DashboardEnabledFlag.Name) {
+		utils.RegisterDashboardService(stack, utils.MakeDashboardConfig(ctx))
+	}
+	// Add the GraphQL endpoint if requested
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		endpoint := fmt.Sprintf("%s:%d", ctx.GlobalString(utils.GraphQLListenAddrFlag.Name), ctx.GlobalInt(utils.GraphQLPortFlag.Name))
+		utils.RegisterGraphQLService(stack, endpoint, ctx.GlobalString(utils.GraphQLCORSDomainFlag.Name))
+	}
 	// Add the release oracle service so it boots along with node.
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		config := release.Config{
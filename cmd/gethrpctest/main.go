@@ -19,13 +19,13 @@ package main
 
 import (
 	"flag"
-	"log"
 	"os"
 	"os/signal"
 
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/eth"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/params"
@@ -47,33 +47,34 @@ func main() {
 	// Enable logging errors, we really do want to see those
 	glog.SetV(2)
 	glog.SetToStderr(true)
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
 
 	// Load the test suite to run the RPC against
 	tests, err := tests.LoadBlockTests(*testFile)
 	if err != nil {
-		log.Fatalf("Failed to load test suite: %v", err)
+		log.Crit("Failed to load test suite", "err", err)
 	}
 	test, found := tests[*testName]
 	if !found {
-		log.Fatalf("Requested test (%s) not found within suite", *testName)
+		log.Crit("Requested test not found within suite", "test", *testName)
 	}
 
 	stack, err := MakeSystemNode(*testKey, test)
 	if err != nil {
-		log.Fatalf("Failed to assemble test stack: %v", err)
+		log.Crit("Failed to assemble test stack", "err", err)
 	}
 	if err := stack.Start(); err != nil {
-		log.Fatalf("Failed to start test node: %v", err)
+		log.Crit("Failed to start test node", "err", err)
 	}
 	defer stack.Stop()
 
-	log.Println("Test node started...")
+	log.Info("Test node started")
 
 	// Make sure the tests contained within the suite pass
 	if err := RunTest(stack, test); err != nil {
-		log.Fatalf("Failed to run the pre-configured test: %v", err)
+		log.Crit("Failed to run the pre-configured test", "err", err)
 	}
-	log.Println("Initial test suite passed...")
+	log.Info("Initial test suite passed")
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
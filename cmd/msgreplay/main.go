@@ -0,0 +1,302 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+// msgreplay inspects p2p message recordings made with ged --msgrecord and
+// replays them against a fresh chain.
+//
+// The replay command feeds the recorded inbound messages into a new eth or les
+// protocol manager backed by an in-memory chain holding only the genesis block,
+// so the downloader and fetcher see the same sequence of messages as the
+// recorded node. The messages sent by the protocol manager are compared with
+// the recorded ones to find where its behaviour diverges.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/EarthDollar/go-earthdollar/cmd/utils"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/eth"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/les"
+	"github.com/EarthDollar/go-earthdollar/light"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/msgrec"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/pow"
+	"github.com/ethereum/ethash"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	gitCommit = "" // Git SHA1 commit hash of the release (set via linker flags)
+
+	app = utils.NewApp(gitCommit, "p2p message recording replay tool")
+)
+
+var (
+	genesisFlag = cli.StringFlag{
+		Name:  "genesis",
+		Usage: "Genesis JSON file of the recorded chain (default: main network genesis)",
+	}
+	networkIdFlag = cli.IntFlag{
+		Name:  "networkid",
+		Usage: "Network identifier of the recorded chain",
+		Value: eth.NetworkId,
+	}
+	fastSyncFlag = cli.BoolFlag{
+		Name:  "fast",
+		Usage: "Replay eth recordings with fast sync enabled",
+	}
+	fakePoWFlag = cli.BoolFlag{
+		Name:  "fakepow",
+		Usage: "Disable proof-of-work verification",
+	}
+	realtimeFlag = cli.BoolFlag{
+		Name:  "realtime",
+		Usage: "Reproduce the recorded delays between messages",
+	}
+	timeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "Time allowed for the protocol to send an expected message",
+		Value: msgrec.DefaultReplayTimeout,
+	}
+	verbosityFlag = cli.IntFlag{
+		Name:  "verbosity",
+		Usage: "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
+		Value: int(log.LvlWarn),
+	}
+)
+
+var (
+	dumpCommand = cli.Command{
+		Action:    dumpRecording,
+		Name:      "dump",
+		Usage:     "Prints the entries of a recording",
+		ArgsUsage: "<recording>",
+	}
+	replayCommand = cli.Command{
+		Action:    replayRecording,
+		Name:      "replay",
+		Usage:     "Replays a recording against a fresh chain",
+		ArgsUsage: "<recording>",
+		Flags:     []cli.Flag{genesisFlag, networkIdFlag, fastSyncFlag, fakePoWFlag, realtimeFlag, timeoutFlag},
+		Description: `
+Runs an eth or les protocol manager, depending on the recorded protocol, on an
+in-memory chain created from the genesis block and delivers the recorded inbound
+messages of every peer session to it. Before a message is delivered the protocol
+manager is given time to send the messages recorded before it. A summary of each
+session is printed, including the first sent message diverging from the
+recording.
+
+Recordings of les are replayed into a light client. The light fetcher needs the
+server pool of a running node and is not started, so announcements only affect
+the downloader.`,
+	}
+)
+
+func init() {
+	app.Flags = []cli.Flag{verbosityFlag}
+	app.Commands = []cli.Command{dumpCommand, replayCommand}
+	app.Before = func(ctx *cli.Context) error {
+		glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+		glogger.Verbosity(log.Lvl(ctx.GlobalInt(verbosityFlag.Name)))
+		log.Root().SetHandler(glogger)
+		return nil
+	}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// openRecording opens the recording named by the first argument.
+func openRecording(ctx *cli.Context) (*msgrec.Reader, *os.File, error) {
+	if ctx.NArg() != 1 {
+		return nil, nil, errors.New("need recording as the only argument")
+	}
+	f, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := msgrec.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return r, f, nil
+}
+
+// dumpRecording prints the entries of a recording.
+func dumpRecording(ctx *cli.Context) error {
+	r, f, err := openRecording(ctx)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Printf("protocol %s, started %v\n", r.Protocol, r.Start)
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		offset := rec.Time.Sub(r.Start)
+		switch rec.Kind {
+		case msgrec.RecordMsgIn, msgrec.RecordMsgOut:
+			fmt.Printf("%12v %x %-5v code %#02x, %d bytes\n", offset, rec.Peer[:8], rec.Kind, rec.Code, len(rec.Payload))
+		case msgrec.RecordPeerStart:
+			fmt.Printf("%12v %x %-5v %s/%d\n", offset, rec.Peer[:8], rec.Kind, r.Protocol, rec.Version)
+		case msgrec.RecordPeerEnd:
+			fmt.Printf("%12v %x %-5v %s\n", offset, rec.Peer[:8], rec.Kind, rec.Payload)
+		}
+	}
+}
+
+// replayRecording replays a recording into a new protocol manager.
+func replayRecording(ctx *cli.Context) error {
+	r, f, err := openRecording(ctx)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	chain, err := newChain(ctx)
+	if err != nil {
+		return err
+	}
+	var protocols []p2p.Protocol
+	switch r.Protocol {
+	case "eth":
+		pm, err := newEthProtocolManager(ctx, chain)
+		if err != nil {
+			return err
+		}
+		defer pm.Stop()
+		protocols = pm.SubProtocols
+	case "les":
+		pm, err := newLesProtocolManager(ctx, chain)
+		if err != nil {
+			return err
+		}
+		defer pm.Stop()
+		protocols = pm.SubProtocols
+	default:
+		return fmt.Errorf("can't replay recordings of protocol %q", r.Protocol)
+	}
+
+	config := msgrec.ReplayConfig{
+		Realtime: ctx.Bool(realtimeFlag.Name),
+		Timeout:  ctx.Duration(timeoutFlag.Name),
+	}
+	sessions, err := msgrec.Replay(r, protocols, config)
+	for _, s := range sessions {
+		fmt.Println(s)
+		if s.Err != nil {
+			fmt.Printf("  ended early: %v\n", s.Err)
+		}
+		if s.RecordErr != "" {
+			fmt.Printf("  recorded end: %s\n", s.RecordErr)
+		}
+	}
+	return err
+}
+
+// replayChain is the fresh chain a recording is replayed against.
+type replayChain struct {
+	db      ethdb.Database
+	genesis *types.Block
+	config  *params.ChainConfig
+	pow     pow.PoW
+	mux     *event.TypeMux
+}
+
+// newChain creates an in-memory database holding the genesis block.
+func newChain(ctx *cli.Context) (*replayChain, error) {
+	db, _ := ethdb.NewMemDatabase()
+	var (
+		genesis *types.Block
+		err     error
+	)
+	if file := ctx.String(genesisFlag.Name); file != "" {
+		f, ferr := os.Open(file)
+		if ferr != nil {
+			return nil, ferr
+		}
+		genesis, err = core.WriteGenesisBlock(db, f)
+		f.Close()
+	} else {
+		genesis, err = core.WriteDefaultGenesisBlock(db)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config, err := core.GetChainConfig(db, genesis.Hash())
+	if err == core.ChainConfigNotFoundErr {
+		config, err = params.MainnetChainConfig, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &replayChain{db: db, genesis: genesis, config: config, mux: new(event.TypeMux)}
+	if ctx.Bool(fakePoWFlag.Name) {
+		c.pow = core.FakePow{}
+	} else {
+		c.pow = ethash.New()
+	}
+	return c, nil
+}
+
+func newEthProtocolManager(ctx *cli.Context, c *replayChain) (*eth.ProtocolManager, error) {
+	blockchain, err := core.NewBlockChain(c.db, c.config, c.pow, c.mux, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	txpool := core.NewTxPool(c.config, c.mux, blockchain.State, blockchain.GasLimit)
+	pm, err := eth.NewProtocolManager(c.config, ctx.Bool(fastSyncFlag.Name), ctx.Int(networkIdFlag.Name), 25, c.mux, txpool, c.pow, blockchain, c.db)
+	if err != nil {
+		return nil, err
+	}
+	pm.Start()
+	return pm, nil
+}
+
+func newLesProtocolManager(ctx *cli.Context, c *replayChain) (*les.ProtocolManager, error) {
+	odr := les.NewLesOdr(c.db)
+	blockchain, err := light.NewLightChain(odr, c.config, c.pow, c.mux)
+	if err != nil {
+		return nil, err
+	}
+	pm, err := les.NewProtocolManager(c.config, true, ctx.Int(networkIdFlag.Name), c.mux, c.pow, blockchain, nil, c.db, odr, les.NewLesTxRelay())
+	if err != nil {
+		return nil, err
+	}
+	pm.Start(nil)
+	return pm, nil
+}
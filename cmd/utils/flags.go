@@ -19,14 +19,17 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/ethash"
 	"github.com/EarthDollar/go-earthdollar/accounts"
@@ -35,14 +38,17 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
 	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/dashboard"
 	"github.com/EarthDollar/go-earthdollar/eth"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/ethstats"
 	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/graphql"
 	"github.com/EarthDollar/go-earthdollar/les"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/metrics"
+	"github.com/EarthDollar/go-earthdollar/metrics/prometheus"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 	"github.com/EarthDollar/go-earthdollar/p2p/discv5"
@@ -52,6 +58,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/pow"
 	"github.com/EarthDollar/go-earthdollar/rpc"
 	whisper "github.com/EarthDollar/go-earthdollar/whisper/whisperv2"
+	gometrics "github.com/rcrowley/go-metrics"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -105,6 +112,10 @@ func NewApp(gitCommit, usage string) *cli.App {
 
 var (
 	// General settings
+	ConfigFileFlag = cli.StringFlag{
+		Name:  "config",
+		Usage: "TOML configuration file (command line flags take precedence)",
+	}
 	DataDirFlag = DirectoryFlag{
 		Name:  "datadir",
 		Usage: "Data directory for the databases and keystore",
@@ -125,7 +136,11 @@ var (
 	}
 	DevModeFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Developer mode: pre-configured private network with several debugging flags",
+		Usage: "Developer mode: instantly sealing private network with a prefunded developer account (in-memory unless --datadir is set)",
+	}
+	DevPeriodFlag = cli.IntFlag{
+		Name:  "dev.period",
+		Usage: "Block period to use in developer mode (0 = seal blocks as transactions arrive)",
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -158,6 +173,10 @@ var (
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	AddrTxIndexFlag = cli.BoolFlag{
+		Name:  "addrtxindex",
+		Usage: "Maintain an index of transactions by sender and recipient address",
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -240,6 +259,37 @@ var (
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
 	}
+	MetricsHTTPFlag = cli.StringFlag{
+		Name:  metrics.MetricsHTTPFlag,
+		Usage: "Enable metrics collection and serve them for Prometheus on the given address (e.g. 127.0.0.1:6061)",
+	}
+	DashboardEnabledFlag = cli.BoolFlag{
+		Name:  metrics.DashboardEnabledFlag,
+		Usage: "Enable the web dashboard of the node health (implies --metrics)",
+	}
+	DashboardAddrFlag = cli.StringFlag{
+		Name:  "dashboard.addr",
+		Usage: "Dashboard listening interface",
+		Value: dashboard.DefaultConfig.Host,
+	}
+	DashboardPortFlag = cli.IntFlag{
+		Name:  "dashboard.port",
+		Usage: "Dashboard listening port",
+		Value: dashboard.DefaultConfig.Port,
+	}
+	DashboardRefreshFlag = cli.DurationFlag{
+		Name:  "dashboard.refresh",
+		Usage: "Dashboard data refresh interval",
+		Value: dashboard.DefaultConfig.Refresh,
+	}
+	MsgRecordFlag = DirectoryFlag{
+		Name:  "msgrecord",
+		Usage: "Directory to record the p2p protocol messages to, for replay with msgreplay",
+	}
+	MsgRecordProtocolsFlag = cli.StringFlag{
+		Name:  "msgrecord.protocols",
+		Usage: "Comma separated names of the protocols to record (default = all)",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -295,7 +345,7 @@ var (
 	}
 	WSPortFlag = cli.IntFlag{
 		Name:  "wsport",
-		Usage: "WS-RPC server listening port",
+		Usage: "WS-RPC server listening port (same as --rpcport to share the HTTP-RPC listener)",
 		Value: node.DefaultWSPort,
 	}
 	WSApiFlag = cli.StringFlag{
@@ -308,6 +358,25 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL server",
+	}
+	GraphQLListenAddrFlag = cli.StringFlag{
+		Name:  "graphqladdr",
+		Usage: "GraphQL server listening interface",
+		Value: node.DefaultGraphQLHost,
+	}
+	GraphQLPortFlag = cli.IntFlag{
+		Name:  "graphqlport",
+		Usage: "GraphQL server listening port",
+		Value: node.DefaultGraphQLPort,
+	}
+	GraphQLCORSDomainFlag = cli.StringFlag{
+		Name:  "graphqlcorsdomain",
+		Usage: "Comma separated list of domains from which to accept cross origin GraphQL requests (browser enforced)",
+		Value: "",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement (only in combination with console/attach)",
@@ -359,6 +428,11 @@ var (
 		Name:  "v5disc",
 		Usage: "Enables the experimental RLPx V5 (Topic Discovery) mechanism",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "dnsdisc",
+		Usage: "Comma separated enrtree:// URLs of DNS node lists used to find peers",
+		Value: "",
+	}
 	NetrestrictFlag = cli.StringFlag{
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
@@ -649,6 +723,15 @@ func MakePasswordList(ctx *cli.Context) []string {
 
 // MakeNode configures a node with no services from command line flags.
 func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
+	stack, err := node.New(MakeNodeConfig(ctx, name, gitCommit))
+	if err != nil {
+		Fatalf("Failed to create the protocol stack: %v", err)
+	}
+	return stack
+}
+
+// MakeNodeConfig assembles the configuration of a node from command line flags.
+func MakeNodeConfig(ctx *cli.Context, name, gitCommit string) *node.Config {
 	vsn := params.Version
 	if gitCommit != "" {
 		vsn += "-" + gitCommit[:8]
@@ -682,13 +765,25 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		WSModules:         MakeRPCModules(ctx.GlobalString(WSApiFlag.Name)),
 	}
 	if ctx.GlobalBool(DevModeFlag.Name) {
+		// Run an ephemeral node with an in-memory database and a throwaway keystore,
+		// unless persistence was explicitly requested.
 		if !ctx.GlobalIsSet(DataDirFlag.Name) {
-			config.DataDir = filepath.Join(os.TempDir(), "/ethereum_dev_mode")
+			config.DataDir = ""
 		}
+		config.UseLightweightKDF = true
 		// --dev mode does not need p2p networking.
 		config.MaxPeers = 0
 		config.ListenAddr = ":0"
 	}
+	if dir := ctx.GlobalString(MsgRecordFlag.Name); dir != "" {
+		config.MsgRecordDir = expandPath(dir)
+		if protos := ctx.GlobalString(MsgRecordProtocolsFlag.Name); protos != "" {
+			config.MsgRecordProtocols = strings.Split(protos, ",")
+		}
+	}
+	if urls := ctx.GlobalString(DNSDiscoveryFlag.Name); urls != "" {
+		config.DNSDiscovery = strings.Split(urls, ",")
+	}
 	if netrestrict := ctx.GlobalString(NetrestrictFlag.Name); netrestrict != "" {
 		list, err := netutil.ParseNetlist(netrestrict)
 		if err != nil {
@@ -696,17 +791,13 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		}
 		config.NetRestrict = list
 	}
-
-	stack, err := node.New(config)
-	if err != nil {
-		Fatalf("Failed to create the protocol stack: %v", err)
-	}
-	return stack
+	return config
 }
 
-// RegisterEthService configures eth.Ethereum from command line flags and adds it to the
-// given node.
-func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
+// MakeEthConfig assembles the configuration of the Ethereum protocol from command
+// line flags. The chain configuration is left empty, to be filled in from the
+// chain database when the service is registered.
+func MakeEthConfig(ctx *cli.Context, stack *node.Node, extra []byte) *eth.Config {
 	// Avoid conflicting network flags
 	networks, netFlags := 0, []cli.BoolFlag{DevModeFlag, TestNetFlag}
 	for _, flag := range netFlags {
@@ -717,10 +808,14 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 	if networks > 1 {
 		Fatalf("The %v flags are mutually exclusive", netFlags)
 	}
+	// Create the developer account first, so it's the default etherbase too
+	var developer accounts.Account
+	if ctx.GlobalBool(DevModeFlag.Name) {
+		developer = MakeDeveloperAccount(stack.AccountManager(), ctx)
+	}
 
 	ethConf := &eth.Config{
 		Etherbase:               MakeEtherbase(stack.AccountManager(), ctx),
-		ChainConfig:             MakeChainConfig(ctx, stack),
 		FastSync:                ctx.GlobalBool(FastSyncFlag.Name),
 		LightMode:               ctx.GlobalBool(LightModeFlag.Name),
 		LightServ:               ctx.GlobalInt(LightServFlag.Name),
@@ -742,6 +837,7 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		SolcPath:                ctx.GlobalString(SolcPathFlag.Name),
 		AutoDAG:                 ctx.GlobalBool(AutoDAGFlag.Name) || ctx.GlobalBool(MiningEnabledFlag.Name),
 		EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name),
+		AddrTxIndex:             ctx.GlobalBool(AddrTxIndexFlag.Name),
 	}
 
 	// Override any default configs in dev mode or the test net
@@ -753,11 +849,52 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		ethConf.Genesis = core.DefaultTestnetGenesisBlock()
 
 	case ctx.GlobalBool(DevModeFlag.Name):
-		ethConf.Genesis = core.DevGenesisBlock()
+		ethConf.Genesis = core.DeveloperGenesisBlock(developer.Address)
+		ethConf.ChainConfig = params.DevChainConfig
 		if !ctx.GlobalIsSet(GasPriceFlag.Name) {
 			ethConf.GasPrice = new(big.Int)
 		}
-		ethConf.PowTest = true
+		ethConf.PowFake = true
+		ethConf.DevMode = true
+		ethConf.DevPeriod = time.Duration(ctx.GlobalInt(DevPeriodFlag.Name)) * time.Second
+	}
+	return ethConf
+}
+
+// MakeDeveloperAccount retrieves the first account of the keystore, creating a new
+// one if there's none, to fund the developer network. The account is unlocked with
+// the passphrase from --password (empty otherwise) if possible, existing accounts
+// with other passphrases can still be unlocked through --unlock.
+func MakeDeveloperAccount(accman *accounts.Manager, ctx *cli.Context) accounts.Account {
+	var passphrase string
+	if list := MakePasswordList(ctx); len(list) > 0 {
+		passphrase = list[0]
+	}
+	if accs := accman.Accounts(); len(accs) > 0 {
+		developer := accs[0]
+		if err := accman.Unlock(developer, passphrase); err != nil {
+			glog.V(logger.Info).Infof("Using locked developer account %x: %v", developer.Address, err)
+		} else {
+			glog.V(logger.Info).Infof("Using developer account %x", developer.Address)
+		}
+		return developer
+	}
+	developer, err := accman.NewAccount(passphrase)
+	if err != nil {
+		Fatalf("Failed to create developer account: %v", err)
+	}
+	if err := accman.Unlock(developer, passphrase); err != nil {
+		Fatalf("Failed to unlock developer account: %v", err)
+	}
+	glog.V(logger.Info).Infof("Created developer account %x", developer.Address)
+	return developer
+}
+
+// RegisterEthService adds an Ethereum client to the given node, filling in the
+// chain configuration if not yet set.
+func RegisterEthService(ctx *cli.Context, stack *node.Node, ethConf *eth.Config) {
+	if ethConf.ChainConfig == nil {
+		ethConf.ChainConfig = MakeChainConfig(ctx, stack)
 	}
 	// Override any global options pertaining to the Ethereum protocol
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
@@ -808,11 +945,72 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// MakeDashboardConfig creates the dashboard configuration from the command line
+// flags.
+func MakeDashboardConfig(ctx *cli.Context) *dashboard.Config {
+	return &dashboard.Config{
+		Host:    ctx.GlobalString(DashboardAddrFlag.Name),
+		Port:    ctx.GlobalInt(DashboardPortFlag.Name),
+		Refresh: ctx.GlobalDuration(DashboardRefreshFlag.Name),
+	}
+}
+
+// RegisterDashboardService adds a dashboard monitoring the Ethereum service to
+// the given node.
+func RegisterDashboardService(stack *node.Node, config *dashboard.Config) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Retrieve both eth and les services
+		var ethServ *eth.Ethereum
+		ctx.Service(&ethServ)
+
+		var lesServ *les.LightEthereum
+		ctx.Service(&lesServ)
+
+		return dashboard.New(config, ethServ, lesServ)
+	}); err != nil {
+		Fatalf("Failed to register the dashboard service: %v", err)
+	}
+}
+
+// RegisterGraphQLService adds the GraphQL endpoint serving the chain data of
+// the Ethereum service to the given node.
+func RegisterGraphQLService(stack *node.Node, endpoint, cors string) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Serve the data of whichever eth or les service is running
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(ethServ.ApiBackend, endpoint, cors)
+		}
+		var lesServ *les.LightEthereum
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend, endpoint, cors)
+		}
+		return nil, errors.New("no Ethereum service to serve GraphQL requests for")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	params.TargetGasLimit = common.String2Big(ctx.GlobalString(TargetGasLimitFlag.Name))
 }
 
+// SetupMetrics starts the HTTP server exporting the collected metrics to
+// Prometheus if an address was requested.
+func SetupMetrics(ctx *cli.Context) {
+	address := ctx.GlobalString(MetricsHTTPFlag.Name)
+	if address == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler(gometrics.DefaultRegistry))
+	go func() {
+		glog.V(logger.Info).Infof("starting metrics server at http://%s/metrics", address)
+		glog.Errorln(http.ListenAndServe(address, mux))
+	}()
+}
+
 // MakeChainConfig reads the chain configuration from the database in ctx.Datadir.
 func MakeChainConfig(ctx *cli.Context, stack *node.Node) *params.ChainConfig {
 	db := MakeChainDatabase(ctx, stack)
@@ -917,6 +1115,11 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("Could not start chainmanager: %v", err)
 	}
+	if ctx.GlobalBool(AddrTxIndexFlag.Name) {
+		if err := chain.EnableAddrTxIndex(); err != nil {
+			Fatalf("Could not enable address transaction index: %v", err)
+		}
+	}
 	return chain, chainDb
 }
 
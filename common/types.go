@@ -154,6 +154,20 @@ func (a *Address) UnmarshalJSON(input []byte) error {
 	return hexutil.UnmarshalJSON("Address", input, a[:])
 }
 
+// MarshalText encodes the address as hex, e.g. for configuration files.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText parses an address in hex syntax.
+func (a *Address) UnmarshalText(input []byte) error {
+	if !IsHexAddress(string(input)) {
+		return fmt.Errorf("invalid address %q", input)
+	}
+	*a = HexToAddress(string(input))
+	return nil
+}
+
 // PP Pretty Prints a byte slice in the following format:
 // 	hex(value[:4])...(hex[len(value)-4:])
 func PP(value []byte) string {
@@ -0,0 +1,256 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+// The address transaction index keeps, for every address, an append-only list
+// of the canonical transactions it sent or received. Entries are appended in
+// chain order, so the list for an address is always sorted by block number and
+// transaction index, which allows block ranges to be located with a binary
+// search and chain reorganisations to be undone by truncating the tail.
+
+// AddrTxEntry is a single position in the address transaction index.
+type AddrTxEntry struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Index       uint64
+}
+
+// less reports whether the entry is positioned before the given transaction.
+func (e *AddrTxEntry) less(number, index uint64) bool {
+	return e.BlockNumber < number || (e.BlockNumber == number && e.Index < index)
+}
+
+func addrTxCountKey(addr common.Address) []byte {
+	return append(append([]byte{}, addrTxPrefix...), addr.Bytes()...)
+}
+
+func addrTxEntryKey(addr common.Address, seq uint64) []byte {
+	return append(addrTxCountKey(addr), encodeBlockNumber(seq)...)
+}
+
+// GetAddrTxIndexHead retrieves the number of the last block included in the
+// address transaction index. The boolean is false if the index was never built.
+func GetAddrTxIndexHead(db ethdb.Database) (uint64, bool) {
+	data, _ := db.Get(addrTxIndexHeadKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteAddrTxIndexHead stores the number of the last block included in the
+// address transaction index.
+func WriteAddrTxIndexHead(db ethdb.Database, number uint64) error {
+	return db.Put(addrTxIndexHeadKey, encodeBlockNumber(number))
+}
+
+// GetAddrTxCount returns the number of index entries stored for an address.
+func GetAddrTxCount(db ethdb.Database, addr common.Address) uint64 {
+	data, _ := db.Get(addrTxCountKey(addr))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// GetAddrTxEntry retrieves the seq'th index entry of an address.
+func GetAddrTxEntry(db ethdb.Database, addr common.Address, seq uint64) *AddrTxEntry {
+	data, _ := db.Get(addrTxEntryKey(addr, seq))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(AddrTxEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid address tx index entry", "addr", addr, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// GetAddrTxEntries returns the index entries of an address that fall into the
+// [from, to] block range, skipping the first offset matches and returning at
+// most limit of them.
+func GetAddrTxEntries(db ethdb.Database, addr common.Address, from, to, offset, limit uint64) []*AddrTxEntry {
+	count := GetAddrTxCount(db, addr)
+
+	// Binary search the first entry at or above the starting block
+	start := uint64(sort.Search(int(count), func(i int) bool {
+		entry := GetAddrTxEntry(db, addr, uint64(i))
+		return entry == nil || entry.BlockNumber >= from
+	}))
+	var entries []*AddrTxEntry
+	for seq := start + offset; seq < count && uint64(len(entries)) < limit; seq++ {
+		entry := GetAddrTxEntry(db, addr, seq)
+		if entry == nil || entry.BlockNumber > to {
+			break
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// addrTxPositions groups the transaction indices of a block by the addresses
+// they were sent from or to, maintaining the order of first appearance.
+func addrTxPositions(config *params.ChainConfig, block *types.Block) ([]common.Address, map[common.Address][]uint64) {
+	var (
+		signer    = types.MakeSigner(config, block.Number())
+		addrs     []common.Address
+		positions = make(map[common.Address][]uint64)
+	)
+	add := func(addr common.Address, index uint64) {
+		list, ok := positions[addr]
+		if !ok {
+			addrs = append(addrs, addr)
+		}
+		if len(list) == 0 || list[len(list)-1] != index {
+			positions[addr] = append(list, index)
+		}
+	}
+	for i, tx := range block.Transactions() {
+		if from, err := types.Sender(signer, tx); err == nil {
+			add(from, uint64(i))
+		}
+		if to := tx.To(); to != nil {
+			add(*to, uint64(i))
+		}
+	}
+	return addrs, positions
+}
+
+// WriteAddrTxIndex appends the transactions of a canonical block to the index
+// entries of their senders and recipients and marks the block as the new index
+// head. Transactions already present in the index are skipped, so indexing the
+// same block twice is harmless.
+func WriteAddrTxIndex(db ethdb.Database, config *params.ChainConfig, block *types.Block) error {
+	var (
+		number = block.NumberU64()
+		hash   = block.Hash()
+		batch  = db.NewBatch()
+	)
+	addrs, positions := addrTxPositions(config, block)
+	for _, addr := range addrs {
+		count := GetAddrTxCount(db, addr)
+
+		var tail *AddrTxEntry
+		if count > 0 {
+			tail = GetAddrTxEntry(db, addr, count-1)
+		}
+		added := false
+		for _, index := range positions[addr] {
+			if tail != nil && !tail.less(number, index) {
+				continue
+			}
+			data, err := rlp.EncodeToBytes(&AddrTxEntry{BlockNumber: number, BlockHash: hash, Index: index})
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(addrTxEntryKey(addr, count), data); err != nil {
+				return err
+			}
+			count++
+			added = true
+		}
+		if added {
+			if err := batch.Put(addrTxCountKey(addr), encodeBlockNumber(count)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := batch.Put(addrTxIndexHeadKey, encodeBlockNumber(number)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("address tx index write fail for %d: %v", number, err)
+	}
+	return nil
+}
+
+// DeleteAddrTxIndex removes the index entries of a block that is being dropped
+// from the canonical chain, rewinding the index head to its parent. Blocks must
+// be removed from the highest downwards.
+func DeleteAddrTxIndex(db ethdb.Database, config *params.ChainConfig, block *types.Block) error {
+	number := block.NumberU64()
+
+	addrs, _ := addrTxPositions(config, block)
+	for _, addr := range addrs {
+		count := GetAddrTxCount(db, addr)
+		for count > 0 {
+			entry := GetAddrTxEntry(db, addr, count-1)
+			if entry != nil && entry.BlockNumber < number {
+				break
+			}
+			count--
+			db.Delete(addrTxEntryKey(addr, count))
+		}
+		if err := db.Put(addrTxCountKey(addr), encodeBlockNumber(count)); err != nil {
+			return err
+		}
+	}
+	if head, ok := GetAddrTxIndexHead(db); ok && head >= number && number > 0 {
+		return WriteAddrTxIndexHead(db, number-1)
+	}
+	return nil
+}
+
+// BuildAddrTxIndex indexes the canonical blocks following the current index
+// head up to and including the given block number. If no index exists yet it
+// is created starting from the genesis block.
+func BuildAddrTxIndex(db ethdb.Database, config *params.ChainConfig, last uint64) error {
+	first := uint64(0)
+	if head, ok := GetAddrTxIndexHead(db); ok {
+		first = head + 1
+	}
+	var (
+		start  = time.Now()
+		report = time.Now()
+	)
+	for number := first; number <= last; number++ {
+		hash := GetCanonicalHash(db, number)
+		if (hash == common.Hash{}) {
+			return fmt.Errorf("chain db corrupted. Could not find block %d.", number)
+		}
+		block := GetBlock(db, hash, number)
+		if block == nil {
+			return fmt.Errorf("block #%d [%x…] body missing", number, hash[:4])
+		}
+		if err := WriteAddrTxIndex(db, config, block); err != nil {
+			return err
+		}
+		if time.Since(report) > statsReportLimit {
+			log.Info("Indexing address transactions", "number", number, "hash", hash, "left", last-number)
+			report = time.Now()
+		}
+	}
+	if first <= last {
+		log.Info("Indexed address transactions", "blocks", last-first+1, "elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/params"
+)
+
+var (
+	addrTxKey1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addrTxKey2, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	addrTxAddr1   = crypto.PubkeyToAddress(addrTxKey1.PublicKey)
+	addrTxAddr2   = crypto.PubkeyToAddress(addrTxKey2.PublicKey)
+	addrTxAddr3   = common.HexToAddress("0x0000000000000000000000000000000000000003")
+)
+
+// makeAddrTxChain generates n blocks on top of parent, each containing one
+// transfer from addr1 to addr2 and, in every offset'th block, an extra transfer
+// from addr2 to addr3.
+func makeAddrTxChain(parent *types.Block, db ethdb.Database, n, offset int) []*types.Block {
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	chain, _ := GenerateChain(params.TestChainConfig, parent, db, n, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addrTxAddr1), addrTxAddr2, big.NewInt(1000), params.TxGas, nil, nil), signer, addrTxKey1)
+		gen.AddTx(tx)
+		if offset > 0 && i%offset == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(addrTxAddr2), addrTxAddr3, big.NewInt(1), params.TxGas, nil, nil), signer, addrTxKey2)
+			gen.AddTx(tx)
+		}
+	})
+	return chain
+}
+
+func newAddrTxTestChain(t *testing.T) (ethdb.Database, *types.Block, *BlockChain) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db,
+		GenesisAccount{addrTxAddr1, big.NewInt(1000000)},
+		GenesisAccount{addrTxAddr2, big.NewInt(1000000)},
+	)
+	blockchain, err := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	if err := blockchain.EnableAddrTxIndex(); err != nil {
+		t.Fatalf("failed to enable address tx index: %v", err)
+	}
+	return db, genesis, blockchain
+}
+
+// checkAddrTxIndex verifies that the index entries of an address point exactly
+// at the canonical transactions it took part in.
+func checkAddrTxIndex(t *testing.T, db ethdb.Database, addr common.Address) {
+	var want []AddrTxEntry
+	head := GetBlock(db, GetHeadBlockHash(db), GetBlockNumber(db, GetHeadBlockHash(db)))
+	for number := uint64(1); number <= head.NumberU64(); number++ {
+		block := GetBlock(db, GetCanonicalHash(db, number), number)
+		for i, tx := range block.Transactions() {
+			from, _ := types.Sender(types.MakeSigner(params.TestChainConfig, block.Number()), tx)
+			if from == addr || *tx.To() == addr {
+				want = append(want, AddrTxEntry{BlockNumber: number, BlockHash: block.Hash(), Index: uint64(i)})
+			}
+		}
+	}
+	have := GetAddrTxEntries(db, addr, 0, math.MaxUint64, 0, math.MaxUint64)
+	if len(have) != len(want) {
+		t.Fatalf("%x: entry count mismatch: have %d, want %d", addr[:4], len(have), len(want))
+	}
+	for i := range want {
+		if *have[i] != want[i] {
+			t.Errorf("%x: entry %d mismatch: have %+v, want %+v", addr[:4], i, *have[i], want[i])
+		}
+	}
+	if index, _ := GetAddrTxIndexHead(db); index != head.NumberU64() {
+		t.Errorf("index head mismatch: have %d, want %d", index, head.NumberU64())
+	}
+}
+
+// Tests that the address transaction index is maintained during chain import
+// and that range queries page through it correctly.
+func TestAddrTxIndexInsert(t *testing.T) {
+	db, genesis, blockchain := newAddrTxTestChain(t)
+
+	chain := makeAddrTxChain(genesis, db, 10, 3)
+	if i, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain[%d]: %v", i, err)
+	}
+	for _, addr := range []common.Address{addrTxAddr1, addrTxAddr2, addrTxAddr3} {
+		checkAddrTxIndex(t, db, addr)
+	}
+	// Query sub-ranges with paging
+	tests := []struct {
+		from, to, offset, limit uint64
+		want                    []uint64 // block numbers of the expected entries
+	}{
+		{1, 10, 0, 100, []uint64{1, 4, 7, 10}},
+		{2, 7, 0, 100, []uint64{4, 7}},
+		{1, 10, 1, 2, []uint64{4, 7}},
+		{1, 10, 3, 2, []uint64{10}},
+		{1, 10, 4, 2, nil},
+		{11, 20, 0, 100, nil},
+		{5, 6, 0, 100, nil},
+	}
+	for i, tt := range tests {
+		entries := GetAddrTxEntries(db, addrTxAddr3, tt.from, tt.to, tt.offset, tt.limit)
+		if len(entries) != len(tt.want) {
+			t.Errorf("test %d: entry count mismatch: have %d, want %d", i, len(entries), len(tt.want))
+			continue
+		}
+		for j, entry := range entries {
+			if entry.BlockNumber != tt.want[j] {
+				t.Errorf("test %d, entry %d: block mismatch: have %d, want %d", i, j, entry.BlockNumber, tt.want[j])
+			}
+		}
+	}
+}
+
+// Tests that a chain reorganisation removes the transactions of the dropped
+// blocks from the index and adds the ones of the new canonical chain.
+func TestAddrTxIndexReorg(t *testing.T) {
+	db, genesis, blockchain := newAddrTxTestChain(t)
+
+	if _, err := blockchain.InsertChain(makeAddrTxChain(genesis, db, 5, 1)); err != nil {
+		t.Fatalf("failed to insert original chain: %v", err)
+	}
+	if n := GetAddrTxCount(db, addrTxAddr3); n != 5 {
+		t.Fatalf("original chain entry count mismatch: have %d, want %d", n, 5)
+	}
+	// Overwrite the chain with a longer one without any addr3 transfers
+	if _, err := blockchain.InsertChain(makeAddrTxChain(genesis, db, 7, 0)); err != nil {
+		t.Fatalf("failed to insert forked chain: %v", err)
+	}
+	for _, addr := range []common.Address{addrTxAddr1, addrTxAddr2, addrTxAddr3} {
+		checkAddrTxIndex(t, db, addr)
+	}
+	// Rewinding the chain should also rewind the index
+	blockchain.SetHead(3)
+	for _, addr := range []common.Address{addrTxAddr1, addrTxAddr2, addrTxAddr3} {
+		checkAddrTxIndex(t, db, addr)
+	}
+}
+
+// Tests that the index can be built for a chain imported without indexing.
+func TestAddrTxIndexBuild(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db,
+		GenesisAccount{addrTxAddr1, big.NewInt(1000000)},
+		GenesisAccount{addrTxAddr2, big.NewInt(1000000)},
+	)
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), vm.Config{})
+	if _, err := blockchain.InsertChain(makeAddrTxChain(genesis, db, 8, 2)); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, ok := GetAddrTxIndexHead(db); ok {
+		t.Fatalf("index created without being enabled")
+	}
+	if err := BuildAddrTxIndex(db, testChainConfig(), blockchain.CurrentBlock().NumberU64()); err != nil {
+		t.Fatalf("failed to build index: %v", err)
+	}
+	for _, addr := range []common.Address{addrTxAddr1, addrTxAddr2, addrTxAddr3} {
+		checkAddrTxIndex(t, db, addr)
+	}
+	// Enabling the index afterwards should keep it up to date
+	if err := blockchain.EnableAddrTxIndex(); err != nil {
+		t.Fatalf("failed to enable index: %v", err)
+	}
+	if _, err := blockchain.InsertChain(makeAddrTxChain(blockchain.CurrentBlock(), db, 4, 2)); err != nil {
+		t.Fatalf("failed to extend chain: %v", err)
+	}
+	for _, addr := range []common.Address{addrTxAddr1, addrTxAddr2, addrTxAddr3} {
+		checkAddrTxIndex(t, db, addr)
+	}
+}
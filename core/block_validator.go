@@ -24,7 +24,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/types"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/pow"
 	"gopkg.in/fatih/set.v0"
@@ -165,11 +165,7 @@ func (v *BlockValidator) VerifyUncles(block, parent *types.Block) error {
 		uncles.Add(hash)
 
 		if ancestors[hash] != nil {
-			branch := fmt.Sprintf("  O - %x\n  |\n", block.Hash())
-			for h := range ancestors {
-				branch += fmt.Sprintf("  O - %x\n  |\n", h)
-			}
-			glog.Infoln(branch)
+			log.Warn("Uncle is an ancestor", "uncle", hash, "block", block.Hash(), "ancestors", len(ancestors))
 			return UncleError("uncle[%d](%x) is ancestor", i, hash[:4])
 		}
 
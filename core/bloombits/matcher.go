@@ -0,0 +1,293 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"golang.org/x/net/context"
+)
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given key.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8)&2047 + uint(b[2*i+1])
+	}
+	return idxs
+}
+
+// Fetcher retrieves the bit vector of a single bloom bit in a section. The
+// returned vector must be sectionSize/8 bytes long.
+type Fetcher func(ctx context.Context, bit uint, section uint64) ([]byte, error)
+
+// partialMatches is a bit vector of potential matches within a section,
+// flowing through the matcher pipeline from one filter stage to the next.
+type partialMatches struct {
+	section uint64
+	bitset  []byte
+}
+
+// Matcher is a pipelined system of bit vector fetchers and logical operations
+// that finds the blocks of a range which potentially contain logs matching a
+// set of address and topic criteria.
+//
+// The filter criteria are a list of groups: the clauses within a group are
+// OR-ed together and the groups are AND-ed. Every stage of the pipeline handles
+// one group, retrieving the bit vectors it needs only for sections that still
+// have potential matches after the preceding stages.
+type Matcher struct {
+	sectionSize uint64           // Size of the data batches to filter on
+	filters     [][]bloomIndexes // Filter groups the system is trying to match
+}
+
+// NewMatcher creates a new pipeline for retrieving bloom bit streams and doing
+// address and topic filtering on them. An empty group (or one containing a nil
+// clause) is a wildcard and does not restrict the results.
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{sectionSize: sectionSize}
+	for _, filter := range filters {
+		if len(filter) == 0 {
+			continue
+		}
+		bloomBits := make([]bloomIndexes, 0, len(filter))
+		for _, clause := range filter {
+			if clause == nil {
+				bloomBits = nil
+				break
+			}
+			bloomBits = append(bloomBits, calcBloomIndexes(clause))
+		}
+		if bloomBits != nil {
+			m.filters = append(m.filters, bloomBits)
+		}
+	}
+	return m
+}
+
+// MatcherSession is returned by a started matcher to be used as a terminator
+// for the actively running matching operation.
+type MatcherSession struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	err     error
+	errLock sync.Mutex
+	pend    sync.WaitGroup
+}
+
+// Close stops the matching process and waits for all subprocesses to terminate
+// before returning.
+func (s *MatcherSession) Close() {
+	s.cancel()
+	s.pend.Wait()
+}
+
+// Error returns any failure encountered during the matching session.
+func (s *MatcherSession) Error() error {
+	s.errLock.Lock()
+	defer s.errLock.Unlock()
+
+	return s.err
+}
+
+// fail records the first error of the session and aborts all processing.
+func (s *MatcherSession) fail(err error) {
+	s.errLock.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.errLock.Unlock()
+	s.cancel()
+}
+
+// Start starts the matching process and streams the numbers of all blocks in
+// the [begin, end] range that potentially match the filters into results. The
+// results channel is closed when matching finishes or the session is aborted.
+func (m *Matcher) Start(ctx context.Context, begin, end uint64, fetch Fetcher, results chan<- uint64) (*MatcherSession, error) {
+	if m.sectionSize == 0 || m.sectionSize%8 != 0 {
+		return nil, errors.New("section size not multiple of 8")
+	}
+	session := &MatcherSession{}
+	session.ctx, session.cancel = context.WithCancel(ctx)
+
+	// Feed all the sections of the range into the pipeline, then chain up the
+	// filter stages and the result distributor
+	source := make(chan *partialMatches, 8)
+	session.pend.Add(1)
+	go func() {
+		defer session.pend.Done()
+		defer close(source)
+
+		for section := begin / m.sectionSize; section <= end/m.sectionSize; section++ {
+			bitset := make([]byte, m.sectionSize/8)
+			for i := range bitset {
+				bitset[i] = 0xff
+			}
+			select {
+			case source <- &partialMatches{section, bitset}:
+			case <-session.ctx.Done():
+				return
+			}
+		}
+	}()
+	next := source
+	for _, bloom := range m.filters {
+		next = m.subMatch(session, next, bloom, fetch)
+	}
+	session.pend.Add(1)
+	go m.distributor(session, next, begin, end, results)
+
+	return session, nil
+}
+
+// subMatch creates a pipeline stage that AND-s the incoming partial matches of
+// each section with the OR of the filter clauses of a single group. Sections
+// without any remaining potential matches are forwarded without fetching any
+// bit vectors.
+func (m *Matcher) subMatch(session *MatcherSession, source chan *partialMatches, bloom []bloomIndexes, fetch Fetcher) chan *partialMatches {
+	results := make(chan *partialMatches, 8)
+
+	session.pend.Add(1)
+	go func() {
+		defer session.pend.Done()
+		defer close(results)
+
+		for {
+			var task *partialMatches
+			select {
+			case <-session.ctx.Done():
+				return
+			case task = <-source:
+				if task == nil {
+					return
+				}
+			}
+			if !isEmpty(task.bitset) {
+				orVector := make([]byte, len(task.bitset))
+				for _, clause := range bloom {
+					andVector, err := m.fetchClause(session, fetch, clause, task.section)
+					if err != nil {
+						session.fail(err)
+						return
+					}
+					orBits(orVector, orVector, andVector)
+				}
+				andBits(task.bitset, task.bitset, orVector)
+			}
+			select {
+			case results <- task:
+			case <-session.ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// fetchClause retrieves the three bit vectors of a single filter clause in a
+// section and AND-s them together.
+func (m *Matcher) fetchClause(session *MatcherSession, fetch Fetcher, clause bloomIndexes, section uint64) ([]byte, error) {
+	var andVector []byte
+	for _, bit := range clause {
+		vector, err := fetch(session.ctx, bit, section)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(vector)) != m.sectionSize/8 {
+			return nil, errors.New("invalid bloom bit vector length")
+		}
+		if andVector == nil {
+			andVector = make([]byte, len(vector))
+			copy(andVector, vector)
+		} else {
+			andBits(andVector, andVector, vector)
+		}
+	}
+	return andVector, nil
+}
+
+// distributor is the final stage of the pipeline, converting the bit vectors
+// of potential matches into block numbers within the requested range.
+func (m *Matcher) distributor(session *MatcherSession, source chan *partialMatches, begin, end uint64, results chan<- uint64) {
+	defer session.pend.Done()
+	defer close(results)
+
+	for {
+		var task *partialMatches
+		select {
+		case <-session.ctx.Done():
+			return
+		case task = <-source:
+			if task == nil {
+				return
+			}
+		}
+		sectionStart := task.section * m.sectionSize
+		for i, b := range task.bitset {
+			if b == 0 {
+				continue
+			}
+			for bit := uint(0); bit < 8; bit++ {
+				if b&(0x80>>bit) == 0 {
+					continue
+				}
+				number := sectionStart + uint64(i)*8 + uint64(bit)
+				if number < begin || number > end {
+					continue
+				}
+				select {
+				case results <- number:
+				case <-session.ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+// isEmpty reports whether a bit vector has no bits set.
+func isEmpty(bitset []byte) bool {
+	for _, b := range bitset {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// andBits computes dst = a & b over equal length byte slices.
+func andBits(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] & b[i]
+	}
+}
+
+// orBits computes dst = a | b over equal length byte slices.
+func orBits(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] | b[i]
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"golang.org/x/net/context"
+)
+
+const testSectionSize = 64
+
+// testChain is a set of header blooms rotated into bloom bit sections.
+type testChain struct {
+	blooms   []types.Bloom
+	sections [][types.BloomBitLength][]byte
+	fetches  int
+}
+
+// newTestChain creates a chain of blooms where the given keys are inserted in
+// the blocks they map to.
+func newTestChain(t *testing.T, blocks int, keys map[uint64][][]byte) *testChain {
+	chain := &testChain{blooms: make([]types.Bloom, blocks)}
+	for number, list := range keys {
+		for _, key := range list {
+			chain.blooms[number].Add(new(big.Int).SetBytes(key))
+		}
+	}
+	for section := 0; section*testSectionSize < blocks; section++ {
+		gen, _ := NewGenerator(testSectionSize)
+		for i := 0; i < testSectionSize; i++ {
+			var bloom types.Bloom
+			if number := section*testSectionSize + i; number < blocks {
+				bloom = chain.blooms[number]
+			}
+			if err := gen.AddBloom(uint(i), bloom); err != nil {
+				t.Fatalf("failed to add bloom: %v", err)
+			}
+		}
+		var bits [types.BloomBitLength][]byte
+		for i := range bits {
+			bits[i], _ = gen.Bitset(uint(i))
+		}
+		chain.sections = append(chain.sections, bits)
+	}
+	return chain
+}
+
+func (c *testChain) fetch(ctx context.Context, bit uint, section uint64) ([]byte, error) {
+	c.fetches++
+	if section >= uint64(len(c.sections)) {
+		return nil, errors.New("unknown section")
+	}
+	return c.sections[section][bit], nil
+}
+
+// run executes a matcher over the test chain and collects the results.
+func (c *testChain) run(t *testing.T, filters [][][]byte, begin, end uint64) ([]uint64, error) {
+	results := make(chan uint64)
+	session, err := NewMatcher(testSectionSize, filters).Start(context.Background(), begin, end, c.fetch, results)
+	if err != nil {
+		t.Fatalf("failed to start matcher: %v", err)
+	}
+	defer session.Close()
+
+	var matches []uint64
+	for number := range results {
+		matches = append(matches, number)
+	}
+	return matches, session.Error()
+}
+
+// Tests that the matcher finds exactly the blocks containing the filtered keys,
+// combining clauses within a group with OR and groups with AND.
+func TestMatcher(t *testing.T) {
+	var (
+		addr1  = []byte("address one.........")
+		addr2  = []byte("address two.........")
+		topic1 = []byte("topic one.......................")
+		topic2 = []byte("topic two.......................")
+	)
+	chain := newTestChain(t, 200, map[uint64][][]byte{
+		3:   {addr1, topic1},
+		70:  {addr2, topic1},
+		71:  {addr1, topic2},
+		130: {addr1},
+		199: {addr2, topic2},
+	})
+	tests := []struct {
+		filters    [][][]byte
+		begin, end uint64
+		want       []uint64
+	}{
+		{[][][]byte{{addr1}}, 0, 199, []uint64{3, 71, 130}},
+		{[][][]byte{{addr1, addr2}}, 0, 199, []uint64{3, 70, 71, 130, 199}},
+		{[][][]byte{{addr1}, {topic1}}, 0, 199, []uint64{3}},
+		{[][][]byte{{addr1, addr2}, {topic2}}, 0, 199, []uint64{71, 199}},
+		{[][][]byte{{addr1}}, 4, 130, []uint64{71, 130}},
+		{[][][]byte{{addr1}, nil, {topic2}}, 0, 199, []uint64{71}},
+		{[][][]byte{{addr1}, {nil, topic1}}, 0, 199, []uint64{3, 71, 130}},
+		{[][][]byte{{[]byte("missing")}}, 0, 199, nil},
+	}
+	for i, tt := range tests {
+		matches, err := chain.run(t, tt.filters, tt.begin, tt.end)
+		if err != nil {
+			t.Errorf("test %d: matching failed: %v", i, err)
+			continue
+		}
+		// Bloom filters may have false positives, the keys above are chosen to
+		// not collide, so results must match exactly
+		if !reflect.DeepEqual(matches, tt.want) {
+			t.Errorf("test %d: matches mismatch: have %v, want %v", i, matches, tt.want)
+		}
+	}
+}
+
+// Tests that sections ruled out by an earlier stage are not fetched by the
+// subsequent ones.
+func TestMatcherSkipsEmptySections(t *testing.T) {
+	var (
+		addr  = []byte("address.............")
+		topic = []byte("topic...........................")
+	)
+	chain := newTestChain(t, 4*testSectionSize, map[uint64][][]byte{10: {addr, topic}})
+
+	if _, err := chain.run(t, [][][]byte{{addr}, {topic}}, 0, 4*testSectionSize-1); err != nil {
+		t.Fatalf("matching failed: %v", err)
+	}
+	// Address stage fetches 3 bits for all 4 sections, topic stage only for 1
+	if chain.fetches != 3*4+3 {
+		t.Errorf("fetch count mismatch: have %d, want %d", chain.fetches, 3*4+3)
+	}
+}
+
+// Tests that retrieval failures abort the session and are reported.
+func TestMatcherFailure(t *testing.T) {
+	chain := newTestChain(t, testSectionSize, nil)
+	if _, err := chain.run(t, [][][]byte{{[]byte("key")}}, 0, 2*testSectionSize); err == nil {
+		t.Errorf("missing section did not fail the session")
+	}
+}
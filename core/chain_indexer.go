@@ -0,0 +1,322 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/log"
+)
+
+// ChainIndexerBackend defines the methods needed to process chain segments in
+// the background and write the segment results into the database. These can be
+// used to create filter blooms or CHTs.
+type ChainIndexerBackend interface {
+	// Reset initiates the processing of a new chain segment, potentially
+	// terminating any partially completed operations (in case of a reorg).
+	Reset(section uint64)
+
+	// Process crunches through the next header in the chain segment. The caller
+	// will ensure a sequential order of headers.
+	Process(header *types.Header)
+
+	// Commit finalizes the section metadata and stores it into the database.
+	Commit() error
+}
+
+// ChainIndexer does a post-processing job for equally sized sections of the
+// canonical chain (like bloom bits). A ChainIndexer is connected to the chain
+// through the chain head events posted on the event mux.
+//
+// Sections are only processed once they are followed by a given number of
+// confirmations, and are rolled back if a chain reorganisation reaches into
+// them, so the stored sections always belong to the canonical chain.
+type ChainIndexer struct {
+	chainDb ethdb.Database      // Chain database to index the data from
+	indexDb ethdb.Database      // Prefixed table-view of the db to write index metadata into
+	backend ChainIndexerBackend // Background processor generating the index data content
+
+	sectionSize uint64        // Number of blocks in a single chain segment to process
+	confirmsReq uint64        // Number of confirmations before processing a completed segment
+	throttling  time.Duration // Disk throttling to prevent a heavy upgrade from hogging resources
+	log         log.Logger    // Contextual logger with the index name injected
+
+	storedSections uint64 // Number of sections successfully indexed into the database
+	knownSections  uint64 // Number of sections known to be complete (block wise)
+
+	update chan struct{} // Notification channel that headers should be processed
+	quit   chan struct{} // Quit channel to tear down running goroutines
+	wg     sync.WaitGroup
+	sub    event.Subscription
+
+	lock sync.RWMutex
+}
+
+// NewChainIndexer creates a new chain indexer to do background processing on
+// chain segments of a given size after certain number of confirmations passed.
+// The throttling parameter might be used to prevent database thrashing.
+func NewChainIndexer(chainDb, indexDb ethdb.Database, backend ChainIndexerBackend, section, confirm uint64, throttling time.Duration, kind string) *ChainIndexer {
+	c := &ChainIndexer{
+		chainDb:     chainDb,
+		indexDb:     indexDb,
+		backend:     backend,
+		sectionSize: section,
+		confirmsReq: confirm,
+		throttling:  throttling,
+		log:         log.New("type", kind),
+		update:      make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+	}
+	c.storedSections = c.loadValidSections()
+	return c
+}
+
+// Start begins indexing the chain from the given current head, following the
+// chain head events posted on the mux to keep up with new blocks and
+// reorganisations.
+func (c *ChainIndexer) Start(current *types.Header, mux *event.TypeMux) {
+	c.sub = mux.Subscribe(ChainHeadEvent{})
+
+	c.wg.Add(2)
+	go c.eventLoop(current)
+	go c.updateLoop()
+}
+
+// Stop terminates the background indexing and waits for all goroutines to
+// return.
+func (c *ChainIndexer) Stop() {
+	close(c.quit)
+	if c.sub != nil {
+		c.sub.Unsubscribe()
+	}
+	c.wg.Wait()
+}
+
+// eventLoop tracks the chain head events, converting them into new head and
+// reorg notifications for the indexer.
+func (c *ChainIndexer) eventLoop(current *types.Header) {
+	defer c.wg.Done()
+
+	c.newHead(current.Number.Uint64(), false)
+	prevHeader := current
+
+	for {
+		select {
+		case <-c.quit:
+			return
+
+		case ev, ok := <-c.sub.Chan():
+			if !ok {
+				return
+			}
+			header := ev.Data.(ChainHeadEvent).Block.Header()
+			if header.ParentHash != prevHeader.Hash() {
+				// Reorg to the common ancestor (might not exist in light sync mode)
+				if ancestor := FindCommonAncestor(c.chainDb, prevHeader, header); ancestor != nil {
+					c.newHead(ancestor.Number.Uint64(), true)
+				}
+			}
+			c.newHead(header.Number.Uint64(), false)
+			prevHeader = header
+		}
+	}
+}
+
+// newHead notifies the indexer about new chain heads and/or reorgs.
+func (c *ChainIndexer) newHead(head uint64, reorg bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// If a reorg happened, invalidate all sections until that point
+	if reorg {
+		changed := head / c.sectionSize
+		if changed < c.knownSections {
+			c.knownSections = changed
+		}
+		if changed < c.storedSections {
+			c.log.Debug("Rolling back chain index", "section", changed, "stored", c.storedSections)
+			c.setValidSections(changed)
+		}
+		return
+	}
+	// No reorg, calculate the number of newly known sections and update if high enough
+	if head >= c.confirmsReq {
+		sections := (head + 1 - c.confirmsReq) / c.sectionSize
+		if sections > c.knownSections {
+			c.knownSections = sections
+
+			select {
+			case c.update <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// updateLoop is the main event loop of the indexer which pushes chain segments
+// down into the processing backend.
+func (c *ChainIndexer) updateLoop() {
+	defer c.wg.Done()
+
+	var updated time.Time
+	for {
+		select {
+		case <-c.quit:
+			return
+
+		case <-c.update:
+			// Section headers completed (or rolled back), update the index
+			c.lock.Lock()
+			if c.knownSections > c.storedSections {
+				// Periodically print an upgrade log message to the user
+				if time.Since(updated) > 8*time.Second {
+					if c.knownSections > c.storedSections+1 {
+						c.log.Info("Upgrading chain index", "percentage", c.storedSections*100/c.knownSections)
+					}
+					updated = time.Now()
+				}
+				// Cache the current section count and head to allow unlocking the mutex
+				section := c.storedSections
+				var oldHead common.Hash
+				if section > 0 {
+					oldHead = c.SectionHead(section - 1)
+				}
+				// Process the newly defined section in the background
+				c.lock.Unlock()
+				newHead, err := c.processSection(section, oldHead)
+				c.lock.Lock()
+
+				// If processing succeeded and no reorgs occurred, mark the section completed
+				switch {
+				case err != nil:
+					// If processing failed, don't retry until further notification
+					c.log.Debug("Section processing failed", "section", section, "err", err)
+				case section != c.storedSections || newHead != GetCanonicalHash(c.chainDb, (section+1)*c.sectionSize-1):
+					c.log.Debug("Chain reorged during section processing", "section", section)
+				default:
+					c.setSectionHead(section, newHead)
+					c.setValidSections(section + 1)
+					if c.storedSections == c.knownSections {
+						c.log.Debug("Finished upgrading chain index", "sections", c.storedSections)
+					}
+				}
+			}
+			// If there are still further sections to process, reschedule
+			if c.knownSections > c.storedSections {
+				time.AfterFunc(c.throttling, func() {
+					select {
+					case c.update <- struct{}{}:
+					default:
+					}
+				})
+			}
+			c.lock.Unlock()
+		}
+	}
+}
+
+// processSection processes an entire section by calling backend functions while
+// ensuring the continuity of the passed headers. Since the chain mutex is not
+// held while processing, the continuity can be broken by a long reorg, in which
+// case the function returns with an error.
+func (c *ChainIndexer) processSection(section uint64, lastHead common.Hash) (common.Hash, error) {
+	c.log.Trace("Processing new chain section", "section", section)
+
+	// Reset and partial processing
+	c.backend.Reset(section)
+
+	for number := section * c.sectionSize; number < (section+1)*c.sectionSize; number++ {
+		hash := GetCanonicalHash(c.chainDb, number)
+		if (hash == common.Hash{}) {
+			return common.Hash{}, fmt.Errorf("canonical block #%d unknown", number)
+		}
+		header := GetHeader(c.chainDb, hash, number)
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("block #%d [%x…] not found", number, hash[:4])
+		} else if header.ParentHash != lastHead && number > 0 {
+			return common.Hash{}, fmt.Errorf("chain reorged during section processing")
+		}
+		c.backend.Process(header)
+		lastHead = header.Hash()
+	}
+	if err := c.backend.Commit(); err != nil {
+		return common.Hash{}, err
+	}
+	return lastHead, nil
+}
+
+// Sections returns the number of processed sections maintained by the indexer
+// and the section size.
+func (c *ChainIndexer) Sections() (uint64, uint64) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.sectionSize, c.storedSections
+}
+
+// loadValidSections reads the number of valid sections from the index database
+// and caches it into the local state.
+func (c *ChainIndexer) loadValidSections() uint64 {
+	data, _ := c.indexDb.Get([]byte("count"))
+	if len(data) == 8 {
+		return binary.BigEndian.Uint64(data)
+	}
+	return 0
+}
+
+// setValidSections writes the number of valid sections to the index database.
+func (c *ChainIndexer) setValidSections(sections uint64) {
+	// Set the current number of valid sections in the database
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], sections)
+	c.indexDb.Put([]byte("count"), data[:])
+
+	// Remove any reorged sections, caching the valids in the mean time
+	for c.storedSections > sections {
+		c.storedSections--
+		c.indexDb.Delete(sectionHeadKey(c.storedSections))
+	}
+	c.storedSections = sections // needed if new > old
+}
+
+// SectionHead retrieves the last block hash of a processed section from the
+// index database.
+func (c *ChainIndexer) SectionHead(section uint64) common.Hash {
+	data, _ := c.indexDb.Get(sectionHeadKey(section))
+	if len(data) == len(common.Hash{}) {
+		return common.BytesToHash(data)
+	}
+	return common.Hash{}
+}
+
+// setSectionHead writes the last block hash of a processed section to the index
+// database.
+func (c *ChainIndexer) setSectionHead(section uint64, hash common.Hash) {
+	c.indexDb.Put(sectionHeadKey(section), hash.Bytes())
+}
+
+// sectionHeadKey returns the index database key of a section's head hash.
+func sectionHeadKey(section uint64) []byte {
+	return append([]byte("shead"), encodeBlockNumber(section)...)
+}
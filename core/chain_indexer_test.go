@@ -0,0 +1,139 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+)
+
+// testIndexBackend records the sections committed by a chain indexer.
+type testIndexBackend struct {
+	lock      sync.Mutex
+	section   uint64
+	headers   []*types.Header
+	committed map[uint64]common.Hash
+}
+
+func (b *testIndexBackend) Reset(section uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.section, b.headers = section, nil
+}
+
+func (b *testIndexBackend) Process(header *types.Header) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.headers = append(b.headers, header)
+}
+
+func (b *testIndexBackend) Commit() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for i, header := range b.headers {
+		if want := b.section*testIndexSectionSize + uint64(i); header.Number.Uint64() != want {
+			return fmt.Errorf("header %d: number mismatch: have %d, want %d", i, header.Number, want)
+		}
+	}
+	b.committed[b.section] = b.headers[len(b.headers)-1].Hash()
+	return nil
+}
+
+const testIndexSectionSize = 4
+
+// writeTestHeaders writes a canonical header chain of the given length on top of
+// the parent, returning the last header. The extra data allows forking.
+func writeTestHeaders(db ethdb.Database, parent *types.Header, n int, extra byte) *types.Header {
+	for i := 0; i < n; i++ {
+		header := &types.Header{Number: big.NewInt(0), Extra: []byte{extra}}
+		if parent != nil {
+			header.ParentHash = parent.Hash()
+			header.Number = new(big.Int).Add(parent.Number, common.Big1)
+		}
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		parent = header
+	}
+	return parent
+}
+
+// waitSections waits until the indexer stores the given number of sections.
+func waitSections(t *testing.T, indexer *ChainIndexer, want uint64) {
+	for i := 0; i < 100; i++ {
+		if _, sections := indexer.Sections(); sections == want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, sections := indexer.Sections()
+	t.Fatalf("section count mismatch: have %d, want %d", sections, want)
+}
+
+// Tests that the chain indexer processes confirmed sections of the canonical
+// chain and rolls them back on reorganisations.
+func TestChainIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	backend := &testIndexBackend{committed: make(map[uint64]common.Hash)}
+	indexer := NewChainIndexer(db, ethdb.NewTable(db, "i-"), backend, testIndexSectionSize, 2, 0, "test")
+
+	mux := new(event.TypeMux)
+	head := writeTestHeaders(db, nil, 11, 0)
+	indexer.Start(head, mux)
+	defer indexer.Stop()
+
+	// Blocks 0-10 with 2 confirmations required only allows two sections
+	waitSections(t, indexer, 2)
+	if hash := indexer.SectionHead(1); hash != GetCanonicalHash(db, 7) || backend.committed[1] != hash {
+		t.Fatalf("section head mismatch: have %x, want %x", hash, GetCanonicalHash(db, 7))
+	}
+	// Extend the chain, which should complete the third section
+	head = writeTestHeaders(db, head, 3, 0)
+	mux.Post(ChainHeadEvent{types.NewBlockWithHeader(head)})
+	waitSections(t, indexer, 3)
+
+	// Fork off at block 5, which should roll back everything above section 0
+	// and reindex the new chain
+	fork := writeTestHeaders(db, GetHeader(db, GetCanonicalHash(db, 5), 5), 1, 1)
+	mux.Post(ChainHeadEvent{types.NewBlockWithHeader(fork)})
+	waitSections(t, indexer, 1)
+
+	fork = writeTestHeaders(db, fork, 8, 1)
+	mux.Post(ChainHeadEvent{types.NewBlockWithHeader(fork)})
+	waitSections(t, indexer, 3)
+
+	for section := uint64(0); section < 3; section++ {
+		want := GetCanonicalHash(db, (section+1)*testIndexSectionSize-1)
+		if hash := indexer.SectionHead(section); hash != want {
+			t.Errorf("section %d: head mismatch: have %x, want %x", section, hash, want)
+		}
+	}
+	// A new indexer should pick up the stored sections
+	if _, sections := NewChainIndexer(db, ethdb.NewTable(db, "i-"), backend, testIndexSectionSize, 2, 0, "test").Sections(); sections != 3 {
+		t.Errorf("reloaded section count mismatch: have %d, want %d", sections, 3)
+	}
+}
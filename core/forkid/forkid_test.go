@@ -0,0 +1,243 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+// forkHeads are the block numbers around every fork transition of the main
+// network, used to cross check fork IDs of peers at any stage of the chain.
+var forkHeads = []uint64{0, 1149999, 1150000, 1919999, 1920000, 2462999, 2463000, 2674999, 2675000, 7987396}
+
+// Tests that the fork ID of the main and test networks is calculated correctly
+// at every fork transition.
+func TestCreation(t *testing.T) {
+	type testcase struct {
+		head uint64
+		want ID
+	}
+	tests := []struct {
+		config  *params.ChainConfig
+		genesis common.Hash
+		cases   []testcase
+	}{
+		// Main network
+		{
+			params.MainnetChainConfig,
+			params.MainNetGenesisHash,
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},       // Unsynced
+				{1149999, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}}, // Last Frontier block
+				{1150000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}}, // First Homestead block
+				{1919999, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}}, // Last Homestead block
+				{1920000, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}}, // First DAO block
+				{2462999, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}}, // Last DAO block
+				{2463000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}}, // First Tangerine block
+				{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}}, // Last Tangerine block
+				{2675000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}},       // First Spurious block
+				{7987396, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}},       // Future Spurious block
+			},
+		},
+		// Test network
+		{
+			params.TestnetChainConfig,
+			params.TestNetGenesisHash,
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0x30c7ddbc), Next: 10}}, // Unsynced, last Frontier, Homestead and first Tangerine block
+				{9, ID{Hash: checksumToBytes(0x30c7ddbc), Next: 10}}, // Last Tangerine block
+				{10, ID{Hash: checksumToBytes(0x63760190), Next: 0}}, // First Spurious block
+				{4230000, ID{Hash: checksumToBytes(0x63760190), Next: 0}},
+			},
+		},
+	}
+	for i, tt := range tests {
+		for j, ttt := range tt.cases {
+			if have := NewID(tt.config, tt.genesis, ttt.head); have != ttt.want {
+				t.Errorf("test %d, case %d: fork ID mismatch: have %x, want %x", i, j, have, ttt.want)
+			}
+		}
+	}
+}
+
+// Tests that nodes on the same chain accept each other's fork IDs at every
+// combination of fork transitions, whichever side is ahead.
+func TestValidationSameChain(t *testing.T) {
+	for _, local := range forkHeads {
+		filter := NewFilter(params.MainnetChainConfig, params.MainNetGenesisHash, func() uint64 { return local })
+		for _, remote := range forkHeads {
+			id := NewID(params.MainnetChainConfig, params.MainNetGenesisHash, remote)
+			if err := filter(id); err != nil {
+				t.Errorf("local head %d, remote head %d: fork ID %x rejected: %v", local, remote, id, err)
+			}
+		}
+	}
+}
+
+// Tests that a fork ID filter accepts and rejects remote fork IDs according to
+// the validation rules.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local is mainnet Spurious, remote announces the same. No future fork is announced.
+		{7987396, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, nil},
+
+		// Local is mainnet Spurious, remote announces the same. Remote also announces a next fork
+		// at block 8000000, but that is uncertain.
+		{7987396, ID{Hash: checksumToBytes(0x3edd5b10), Next: 8000000}, nil},
+
+		// Local is mainnet currently in Tangerine only (so it's aware of Spurious), remote announces
+		// also Tangerine, but it's not yet aware of Spurious (e.g. non updated node before the fork).
+		// In this case we don't know if Spurious passed yet or not.
+		{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 0}, nil},
+
+		// Local is mainnet currently in Tangerine only (so it's aware of Spurious), remote announces
+		// also Tangerine, and it's also aware of Spurious (e.g. updated node before the fork). We
+		// don't know if Spurious passed yet (will pass) or not.
+		{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}, nil},
+
+		// Local is mainnet currently in Tangerine only (so it's aware of Spurious), remote announces
+		// also Tangerine, and it's also aware of some random fork (e.g. misconfigured Spurious). As
+		// neither forks passed at neither nodes, they may mismatch, but we still connect for now.
+		{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: math.MaxUint64}, nil},
+
+		// Local is mainnet Spurious, remote announces Tangerine + knowledge about Spurious. Remote
+		// is simply out of sync, accept.
+		{2675000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}, nil},
+
+		// Local is mainnet Spurious, remote announces Homestead + knowledge about the DAO fork.
+		// Remote is definitely out of sync. It may or may not need the Spurious update, we don't
+		// know yet.
+		{7987396, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}, nil},
+
+		// Local is mainnet Tangerine, remote announces Spurious. Local is out of sync, accept.
+		{2463000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, nil},
+
+		// Local is mainnet Frontier, remote announces Spurious. Local is out of sync, accept.
+		{0, ID{Hash: checksumToBytes(0x3edd5b10), Next: 0}, nil},
+
+		// Local is mainnet Spurious, remote announces Tangerine but is not aware of Spurious.
+		// Remote needs software update.
+		{7987396, ID{Hash: checksumToBytes(0x7a64da13), Next: 0}, ErrRemoteStale},
+
+		// Local is mainnet Spurious, remote announces Homestead and skips the DAO fork.
+		// Remote runs a different fork schedule.
+		{7987396, ID{Hash: checksumToBytes(0x97c2c34c), Next: 2463000}, ErrRemoteStale},
+
+		// Local is mainnet Spurious, and isn't aware of more forks. Remote announces Spurious +
+		// a fork at block 7987396, which the local chain already passed. Local needs an update.
+		{7987396, ID{Hash: checksumToBytes(0x3edd5b10), Next: 7987396}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Spurious, remote announces an unknown checksum. The chains diverged.
+		{7987396, ID{Hash: checksumToBytes(0xafec6b27), Next: 0}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Spurious, remote is on the test network. The genesis differs.
+		{7987396, NewID(params.TestnetChainConfig, params.TestNetGenesisHash, 10), ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := NewFilter(params.MainnetChainConfig, params.MainNetGenesisHash, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that nodes with a diverging fork schedule are rejected as soon as the
+// local chain passes the point of divergence.
+func TestValidationDivergedSchedule(t *testing.T) {
+	// The remote chain skips the DAO fork, otherwise it's identical
+	config := *params.MainnetChainConfig
+	config.DAOForkBlock = nil
+
+	tests := []struct {
+		local, remote uint64
+		err           error
+	}{
+		// Before the divergence, both chains are identical
+		{1149999, 1149999, nil},
+		{1150000, 1150000, nil},
+
+		// Local didn't pass the DAO fork yet, remote announces Tangerine as next fork
+		{1919999, 1919999, nil},
+
+		// Local passed the DAO fork, remote is still in Homestead but skips the DAO fork
+		{1920000, 1919999, ErrRemoteStale},
+
+		// Remote passed Tangerine without the DAO fork, it's on a different chain
+		{1919999, 2463000, ErrLocalIncompatibleOrStale},
+		{2463000, 2463000, ErrLocalIncompatibleOrStale},
+		{2675000, 2675000, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := NewFilter(params.MainnetChainConfig, params.MainNetGenesisHash, func() uint64 { return tt.local })
+		id := NewID(&config, params.MainNetGenesisHash, tt.remote)
+		if err := filter(id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that IDs are properly RLP encoded (specifically important because we
+// use uint32 to store the hash, but we need to encode it as [4]byte).
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want []byte
+	}{
+		{ID{Hash: checksumToBytes(0), Next: 0}, common.Hex2Bytes("c6840000000080")},
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0xBADDCAFE}, common.Hex2Bytes("ca84deadbeef84baddcafe")},
+		{ID{Hash: checksumToBytes(math.MaxUint32), Next: math.MaxUint64}, common.Hex2Bytes("ce84ffffffff88ffffffffffffffff")},
+	}
+	for i, tt := range tests {
+		have, err := rlp.EncodeToBytes(tt.id)
+		if err != nil {
+			t.Errorf("test %d: failed to encode forkid: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: RLP mismatch: have %x, want %x", i, have, tt.want)
+		}
+		var dec ID
+		if err := rlp.DecodeBytes(have, &dec); err != nil || !reflect.DeepEqual(dec, tt.id) {
+			t.Errorf("test %d: decoded ID mismatch: have %x (%v), want %x", i, dec, err, tt.id)
+		}
+	}
+}
+
+// Tests that fork blocks are gathered in order, without duplicates and without
+// the forks active at genesis.
+func TestGatherForks(t *testing.T) {
+	if forks := gatherForks(params.MainnetChainConfig); !reflect.DeepEqual(forks, []uint64{1150000, 1920000, 2463000, 2675000}) {
+		t.Errorf("mainnet forks mismatch: %v", forks)
+	}
+	if forks := gatherForks(params.TestnetChainConfig); !reflect.DeepEqual(forks, []uint64{10}) {
+		t.Errorf("testnet forks mismatch: %v", forks)
+	}
+	if forks := gatherForks(params.DevChainConfig); len(forks) != 0 {
+		t.Errorf("expected no forks for genesis-activated config, got %v", forks)
+	}
+}
@@ -31,8 +31,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/params"
 )
 
@@ -90,7 +89,7 @@ func WriteGenesisBlock(chainDb ethdb.Database, reader io.Reader) (*types.Block,
 	}, nil, nil, nil)
 
 	if block := GetBlock(chainDb, block.Hash(), block.NumberU64()); block != nil {
-		glog.V(logger.Info).Infoln("Genesis block already in chain. Writing canonical number")
+		log.Info("Genesis block already in chain, writing canonical number")
 		err := WriteCanonicalHash(chainDb, block.Hash(), block.NumberU64())
 		if err != nil {
 			return nil, err
@@ -212,3 +211,20 @@ func DevGenesisBlock() string {
 	}
 	return string(blob)
 }
+
+// DeveloperGenesisBlock assembles a JSON string representing a developer network
+// genesis block, prefunding the given faucet account besides the precompiles.
+func DeveloperGenesisBlock(faucet common.Address) string {
+	return fmt.Sprintf(`{
+	"nonce":"0x%x",
+	"gasLimit":"0x%x",
+	"difficulty":"0x%x",
+	"alloc": {
+		"0000000000000000000000000000000000000001": {"balance": "1"},
+		"0000000000000000000000000000000000000002": {"balance": "1"},
+		"0000000000000000000000000000000000000003": {"balance": "1"},
+		"0000000000000000000000000000000000000004": {"balance": "1"},
+		"%x": {"balance": "0x%x"}
+	}
+}`, types.EncodeNonce(42), params.GenesisGasLimit.Bytes(), params.MinimumDifficulty.Bytes(), faucet, new(big.Int).Lsh(common.Big1, 200).Bytes())
+}
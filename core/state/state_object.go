@@ -24,8 +24,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 	"github.com/EarthDollar/go-earthdollar/trie"
 )
@@ -135,9 +134,7 @@ func (self *StateObject) markSuicided() {
 		self.onDirty(self.Address())
 		self.onDirty = nil
 	}
-	if glog.V(logger.Core) {
-		glog.Infof("%x: #%d %v X\n", self.Address(), self.Nonce(), self.Balance())
-	}
+	log.Trace("Deleted state object", "addr", self.Address(), "nonce", self.Nonce(), "balance", self.Balance())
 }
 
 func (c *StateObject) touch() {
@@ -253,9 +250,7 @@ func (c *StateObject) AddBalance(amount *big.Int) {
 	}
 	c.SetBalance(new(big.Int).Add(c.Balance(), amount))
 
-	if glog.V(logger.Core) {
-		glog.Infof("%x: #%d %v (+ %v)\n", c.Address(), c.Nonce(), c.Balance(), amount)
-	}
+	log.Trace("Added balance", "addr", c.Address(), "nonce", c.Nonce(), "balance", c.Balance(), "amount", amount)
 }
 
 // SubBalance removes amount from c's balance.
@@ -266,9 +261,7 @@ func (c *StateObject) SubBalance(amount *big.Int) {
 	}
 	c.SetBalance(new(big.Int).Sub(c.Balance(), amount))
 
-	if glog.V(logger.Core) {
-		glog.Infof("%x: #%d %v (- %v)\n", c.Address(), c.Nonce(), c.Balance(), amount)
-	}
+	log.Trace("Subtracted balance", "addr", c.Address(), "nonce", c.Nonce(), "balance", c.Balance(), "amount", amount)
 }
 
 func (self *StateObject) SetBalance(amount *big.Int) {
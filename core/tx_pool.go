@@ -28,8 +28,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/state"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/event"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/metrics"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
@@ -163,12 +162,12 @@ func (pool *TxPool) eventLoop() {
 func (pool *TxPool) resetState() {
 	currentState, err := pool.currentState()
 	if err != nil {
-		glog.V(logger.Error).Infof("Failed to get current state: %v", err)
+		log.Error("Failed to get current state", "err", err)
 		return
 	}
 	managedState := state.ManageState(currentState)
 	if err != nil {
-		glog.V(logger.Error).Infof("Failed to get managed state: %v", err)
+		log.Error("Failed to get managed state", "err", err)
 		return
 	}
 	pool.pendingState = managedState
@@ -193,7 +192,7 @@ func (pool *TxPool) Stop() {
 	pool.events.Unsubscribe()
 	close(pool.quit)
 	pool.wg.Wait()
-	glog.V(logger.Info).Infoln("Transaction pool stopped")
+	log.Info("Transaction pool stopped")
 }
 
 func (pool *TxPool) State() *state.ManagedState {
@@ -333,15 +332,9 @@ func (pool *TxPool) add(tx *types.Transaction) error {
 	}
 	pool.enqueueTx(hash, tx)
 
-	// Print a log message if low enough level is set
-	if glog.V(logger.Debug) {
-		rcpt := "[NEW_CONTRACT]"
-		if to := tx.To(); to != nil {
-			rcpt = common.Bytes2Hex(to[:4])
-		}
-		from, _ := types.Sender(pool.signer, tx) // from already verified during tx validation
-		glog.Infof("(t) 0x%x => %s (%v) %x\n", from[:4], rcpt, tx.Value, hash)
-	}
+	// Print a log message with the transaction details
+	from, _ := types.Sender(pool.signer, tx) // from already verified during tx validation
+	log.Debug("Queued new transaction", "hash", hash, "from", from, "to", tx.To(), "value", tx.Value())
 	return nil
 }
 
@@ -423,7 +416,7 @@ func (pool *TxPool) AddBatch(txs []*types.Transaction) error {
 
 	for _, tx := range txs {
 		if err := pool.add(tx); err != nil {
-			glog.V(logger.Debug).Infoln("tx error:", err)
+			log.Debug("Failed to add transaction", "err", err)
 		}
 	}
 
@@ -514,32 +507,24 @@ func (pool *TxPool) promoteExecutables(state *state.StateDB) {
 	for addr, list := range pool.queue {
 		// Drop all transactions that are deemed too old (low nonce)
 		for _, tx := range list.Forward(state.GetNonce(addr)) {
-			if glog.V(logger.Core) {
-				glog.Infof("Removed old queued transaction: %v", tx)
-			}
+			log.Trace("Removed old queued transaction", "hash", tx.Hash())
 			delete(pool.all, tx.Hash())
 		}
 		// Drop all transactions that are too costly (low balance)
 		drops, _ := list.Filter(state.GetBalance(addr))
 		for _, tx := range drops {
-			if glog.V(logger.Core) {
-				glog.Infof("Removed unpayable queued transaction: %v", tx)
-			}
+			log.Trace("Removed unpayable queued transaction", "hash", tx.Hash())
 			delete(pool.all, tx.Hash())
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
 		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
-			if glog.V(logger.Core) {
-				glog.Infof("Promoting queued transaction: %v", tx)
-			}
+			log.Trace("Promoting queued transaction", "hash", tx.Hash())
 			pool.promoteTx(addr, tx.Hash(), tx)
 		}
 		// Drop all transactions over the allowed limit
 		for _, tx := range list.Cap(int(maxQueuedPerAccount)) {
-			if glog.V(logger.Core) {
-				glog.Infof("Removed cap-exceeding queued transaction: %v", tx)
-			}
+			log.Trace("Removed cap-exceeding queued transaction", "hash", tx.Hash())
 			delete(pool.all, tx.Hash())
 			queuedRLCounter.Inc(1)
 		}
@@ -651,24 +636,18 @@ func (pool *TxPool) demoteUnexecutables(state *state.StateDB) {
 
 		// Drop all transactions that are deemed too old (low nonce)
 		for _, tx := range list.Forward(nonce) {
-			if glog.V(logger.Core) {
-				glog.Infof("Removed old pending transaction: %v", tx)
-			}
+			log.Trace("Removed old pending transaction", "hash", tx.Hash())
 			delete(pool.all, tx.Hash())
 		}
 		// Drop all transactions that are too costly (low balance), and queue any invalids back for later
 		drops, invalids := list.Filter(state.GetBalance(addr))
 		for _, tx := range drops {
-			if glog.V(logger.Core) {
-				glog.Infof("Removed unpayable pending transaction: %v", tx)
-			}
+			log.Trace("Removed unpayable pending transaction", "hash", tx.Hash())
 			delete(pool.all, tx.Hash())
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
-			if glog.V(logger.Core) {
-				glog.Infof("Demoting pending transaction: %v", tx)
-			}
+			log.Trace("Demoting pending transaction", "hash", tx.Hash())
 			pool.enqueueTx(tx.Hash(), tx)
 		}
 		// Delete the entire queue entry if it became empty.
@@ -29,9 +29,17 @@ type bytesBacked interface {
 	Bytes() []byte
 }
 
-const bloomLength = 256
+const (
+	// BloomByteLength represents the number of bytes used in a header log bloom.
+	BloomByteLength = 256
 
-// Bloom represents a 256 bit bloom filter.
+	// BloomBitLength represents the number of bits used in a header log bloom.
+	BloomBitLength = 8 * BloomByteLength
+
+	bloomLength = BloomByteLength
+)
+
+// Bloom represents a 2048 bit bloom filter.
 type Bloom [bloomLength]byte
 
 // BytesToBloom converts a byte slice to a bloom filter.
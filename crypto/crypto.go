@@ -123,6 +123,43 @@ func FromECDSAPub(pub *ecdsa.PublicKey) []byte {
 	return elliptic.Marshal(secp256k1.S256(), pub.X, pub.Y)
 }
 
+// CompressPubkey encodes a public key to the 33-byte compressed format.
+func CompressPubkey(pub *ecdsa.PublicKey) []byte {
+	if pub == nil || pub.X == nil || pub.Y == nil {
+		return nil
+	}
+	buf := make([]byte, 33)
+	buf[0] = byte(2 + pub.Y.Bit(0))
+	copy(buf[1:], common.LeftPadBytes(pub.X.Bytes(), 32))
+	return buf
+}
+
+// DecompressPubkey parses a public key in the 33-byte compressed format.
+func DecompressPubkey(pub []byte) (*ecdsa.PublicKey, error) {
+	if len(pub) != 33 || (pub[0] != 2 && pub[0] != 3) {
+		return nil, errors.New("invalid compressed public key")
+	}
+	curve := secp256k1.S256()
+	x := new(big.Int).SetBytes(pub[1:])
+	if x.Cmp(curve.P) >= 0 {
+		return nil, errors.New("invalid compressed public key")
+	}
+	// y² = x³ + b, the square root exists iff the point is on the curve
+	y := new(big.Int).Exp(x, big.NewInt(3), curve.P)
+	y.Add(y, curve.B)
+	y.Mod(y, curve.P)
+	if y.ModSqrt(y, curve.P) == nil {
+		return nil, errors.New("invalid compressed public key")
+	}
+	if y.Bit(0) != uint(pub[0]&1) {
+		y.Sub(curve.P, y)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("invalid compressed public key")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
 // HexToECDSA parses a secp256k1 private key.
 func HexToECDSA(hexkey string) (*ecdsa.PrivateKey, error) {
 	b, err := hex.DecodeString(hexkey)
@@ -111,6 +111,29 @@ func TestInvalidSign(t *testing.T) {
 	}
 }
 
+func TestCompressPubkey(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		key, _ := GenerateKey()
+		enc := CompressPubkey(&key.PublicKey)
+		if len(enc) != 33 {
+			t.Fatalf("wrong compressed key length: %d", len(enc))
+		}
+		pub, err := DecompressPubkey(enc)
+		if err != nil {
+			t.Fatalf("can't decompress key: %v", err)
+		}
+		if pub.X.Cmp(key.X) != 0 || pub.Y.Cmp(key.Y) != 0 {
+			t.Fatalf("decompressed key mismatch: have %x, want %x", FromECDSAPub(pub), FromECDSAPub(&key.PublicKey))
+		}
+	}
+	if _, err := DecompressPubkey(make([]byte, 33)); err == nil {
+		t.Errorf("expected invalid prefix to error")
+	}
+	if _, err := DecompressPubkey([]byte{0x02}); err == nil {
+		t.Errorf("expected short key to error")
+	}
+}
+
 func TestNewContractAddress(t *testing.T) {
 	key, _ := HexToECDSA(testPrivHex)
 	addr := common.HexToAddress(testAddrHex)
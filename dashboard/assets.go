@@ -0,0 +1,283 @@
+// Code generated by go-bindata.
+// sources:
+// assets/dashboard.css
+// assets/dashboard.js
+// assets/index.html
+// DO NOT EDIT!
+
+package dashboard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+func (fi bindataFileInfo) IsDir() bool {
+	return false
+}
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _dashboardCss = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x52\xdb\x6e\xab\x30\x10\x7c\x2e\x5f\x61\xa9\x3a\x8f\x44\x5c\x72\xa1\xe4\x6b\x16\x7b\x01\xb7\xc4\x46\xb6\x09\xe4\x1c\xf5\xdf\xcf\xda\x50\xd2\xa4\x54\x91\x88\x34\x2c\xb3\x33\xb3\x53\x69\x71\x63\xff\xa2\x97\x0b\x98\x46\xaa\x92\x25\xe7\xe8\xa5\xd6\xca\xc5\x35\x5c\x64\x77\x2b\x99\x05\x65\x63\x8b\x46\xd6\xf4\xa6\x02\xfe\xd1\x18\x3d\x28\x51\xb2\xd7\x14\xb3\x34\x7b\x23\x94\xeb\x4e\x1b\x02\xc4\x41\x14\x02\xcf\xd1\x67\xd4\x22\x08\x34\x9e\x57\x48\xdb\x77\x40\x3c\x75\x87\x13\xcd\x42\x27\x1b\x15\x4b\x87\x17\x5b\x32\x8e\xca\xa1\x21\xf4\x7d\xb0\x4e\xd6\xb7\x98\xd3\x66\xc2\x68\x6b\x0f\x1c\xe3\x0a\xdd\x88\xa8\x68\xa0\x07\x21\xa4\x6a\x48\x1f\xcb\xf6\xfd\xf4\x2c\x25\x2b\x32\x9e\x1f\xc3\xe6\xd4\x6f\x0d\x0e\xac\xfc\x8b\x25\xcb\x92\x30\x1e\x90\x11\x65\xd3\x12\xbb\xd2\xe6\x02\x5d\x18\xcf\x1e\xcc\xd3\xaf\xe8\xa7\x7b\x08\x33\x45\xba\xff\x9d\x62\x35\x5f\x88\xb7\x1c\x12\xcf\xf9\x6a\x1d\xb8\xc1\x7a\xe2\x55\x36\x31\xb0\x74\x56\x52\x69\x43\xd9\xc4\x06\x84\x1c\x28\x83\xfc\xce\xbd\x6c\xcb\x3c\xb2\xd2\xec\xb4\xea\xa4\x42\xcf\xf6\x68\x19\x4f\x22\xcf\x1e\x06\xeb\x7a\x73\xb2\x3a\xa5\x3c\xe5\x7e\xd2\x22\x77\x52\xab\x07\x65\xe9\x91\xa4\xcd\x99\x7e\x46\xbb\xde\xe8\xc6\xa0\x0d\xe2\xdb\xc5\x6a\xf1\x33\xef\xfc\x90\xc3\xfe\xf8\xd3\xcc\x1c\x94\xbe\xa2\xa9\x3b\x3d\x96\xac\x95\x42\xf8\xfb\x79\x91\x37\xc5\xe3\x0a\x42\x29\x46\x29\x5c\x3b\x77\xed\x6b\x49\x9a\x24\x7f\x9e\xb7\xec\x6b\x9e\xd7\x27\x42\x9d\xa1\x12\x4a\x2f\xbd\x64\xe1\x5b\x96\xec\x0e\xf6\x4e\xeb\x70\x72\x4f\x67\x4f\xf3\x25\x45\xde\x82\x71\x76\xab\x8a\xfe\x2f\x1e\x0d\xf4\x44\x4a\xcf\xe0\x3f\x4c\x87\x04\xf5\xe4\x99\x42\x44\x8b\x49\x82\xce\xab\xf6\x7c\xe9\xd5\xbd\x3a\x21\xc8\xf0\x48\xbe\x17\x76\x3e\xe7\x76\x5d\x37\xbb\xb0\xaa\xd8\x5d\xa1\x1b\xc2\x39\x29\x4c\xa0\x8c\x8c\x8f\xea\xbc\x61\x73\x2d\xe1\x57\x62\x2b\x07\x07\x75\x85\x47\xf7\x55\xa7\xf9\xc7\xdd\xc8\x12\xfc\xb7\x3b\xcc\x22\xfe\x03\xcc\xaf\xee\x35\x19\x04\x00\x00")
+
+func dashboardCssBytes() ([]byte, error) {
+	return bindataRead(
+		_dashboardCss,
+		"dashboard.css",
+	)
+}
+
+func dashboardCss() (*asset, error) {
+	bytes, err := dashboardCssBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "dashboard.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dashboardJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9d\x57\xdb\x72\xdb\x36\x10\x7d\x96\xbf\x62\x33\x9d\x84\x54\x2d\x53\xb2\x92\x4c\x26\x56\x94\x4e\x93\x49\xdb\xb4\xb9\x4d\x9c\x99\x3c\x78\xfc\x00\x91\x90\x84\x9a\x22\x59\x02\xb2\xac\x26\xfe\xf7\x9e\x05\x40\x12\x92\x9d\x5b\xc7\x36\x4d\x02\x8b\x83\xbd\x9c\xdd\x05\x86\x43\x7a\xa5\x2e\x25\xa5\x4b\x51\x1b\x4d\xe5\x9c\xcc\x52\x52\x51\x66\x92\x32\xa1\x97\xb3\x52\xd4\xd9\x80\xe6\x32\xc3\x78\x5d\xae\x17\x4b\x3b\x3f\x14\x95\xa2\x8d\x9c\xe9\x32\xbd\x90\x26\x39\x88\xe7\xeb\x22\x35\xaa\x2c\x28\xee\xd3\xa7\x83\x5e\xb4\xd6\x92\xb4\xa9\x55\x6a\xa2\xc9\xc1\x41\xef\x52\xd4\xa4\xc5\xaa\xca\xe5\x2b\xb5\x52\x86\xa6\x34\x1e\x8d\x26\x34\x1c\xd2\xeb\xb5\x36\xb4\x12\x26\x75\xc0\x99\x30\x82\xaa\x52\x15\xd0\xe5\x42\x56\x86\x66\x5b\x3b\xae\x65\x7d\x29\x6b\x07\xe4\x55\x9d\xd2\xa7\x6b\xc6\x06\xc8\xf3\x5a\x0a\x23\xad\x60\x2a\x8a\x4b\xa1\x49\x14\x19\x5d\x8a\x7c\x2d\x29\x17\x33\x99\xb3\x5d\x12\x00\x5b\xb7\x98\xd2\xb2\x30\x42\x15\xb2\x4e\x0e\x7a\xbf\xd6\xb5\xd8\x26\x55\x5d\x9a\xd2\x6c\x2b\x99\xcc\xcb\xfa\x85\x48\x97\x49\x2a\xf2\x3c\xce\xca\x74\xbd\x92\x85\x49\xfe\x59\x63\xf5\xa9\xcc\x65\x6a\xca\xfa\x57\xcc\x44\x89\x85\x8a\xfa\xf0\x4e\x6b\x3c\xe6\x57\xd6\x01\x56\x51\xa3\x4c\x2e\xa1\x67\x0b\x92\x5a\x3d\x5f\x40\x08\x5f\x71\xb4\x1c\x47\xfd\x89\x97\x75\xca\x7e\x59\x56\x57\xa2\xe8\xa4\xbd\x95\x5f\x16\x77\x02\xbc\xc0\xae\x00\x76\x92\xe6\x42\xeb\x37\x62\xc5\xbb\x44\x76\x28\x62\x38\xab\x64\x62\xe4\x95\x79\x0e\xa7\x60\x31\xa6\xd9\x8c\x84\x43\xa1\x11\x5c\x2b\xd0\x49\x8a\xaa\x92\x45\xf6\x7c\xa9\xf2\x2c\xb6\x28\x56\x29\xbb\x22\x9c\xb2\xc2\xb7\x4f\x39\xdd\x9c\x6a\x2e\x96\x67\x3b\x1b\xda\xb1\x73\x8e\xaf\x93\x3c\xf1\xe6\x0e\x9c\x93\x4e\xdc\xbf\x01\xad\x0b\x65\x4e\x76\x75\xe5\xa1\x81\x25\xd1\x09\x9d\x9d\x83\x1e\xbd\xeb\xbe\xe7\x08\xc2\x0a\x9a\x51\x0d\x35\x64\x0d\x82\x78\x8f\x6f\x94\x59\xe2\x63\x25\x99\xac\x54\xd5\x72\xae\xae\x58\x16\xbc\xa9\x17\x48\x84\xf5\x6a\x06\x71\xd0\xa4\x0d\xb2\x03\x8a\x03\x25\xba\x88\xbb\xf5\x92\x23\x73\x16\x45\x03\x8a\xfe\xe2\xc7\x6b\x7e\xfc\xce\x8f\x0f\xd1\x79\x13\x42\x05\x99\x11\x7f\xa8\x39\xc5\x8c\x42\xd3\x29\x02\xf3\x2c\xa2\xcf\x9f\x29\xf8\x1e\x22\x8a\x16\xbf\xc7\x5a\xc5\x13\x7a\x2d\xcc\x32\x11\x33\xed\xbd\x4f\x4f\xa7\x74\x3c\x1a\x3f\xa0\x7b\xf7\x80\xf9\xa4\x55\x21\xc9\x65\xb1\x80\x6d\x47\x74\x3c\x21\x75\x78\xe8\x41\x1c\x19\x68\xe8\x16\xf1\xfe\xbd\x6b\x3c\xae\xe1\x47\xa4\xec\x37\xf7\x19\x8d\xfe\xc7\x3e\xa3\x51\xb7\x8f\xb7\x3e\x53\x0b\x65\x73\xd8\xa1\xd3\x1d\xd8\x6a\xa1\xf7\x77\x7d\x82\xf5\x3c\xee\xc4\xee\xd2\xb1\x13\xed\xd3\x2f\x34\xa6\x13\xe7\xc1\x5a\x9a\x75\x5d\x38\x91\xc4\x94\xbf\x41\xad\x2c\x76\x3b\xf4\xe9\x90\x22\xfc\x1c\xb6\xfa\x9e\xa9\x73\x7c\xb1\x83\x99\x1d\x8e\x1b\x59\x2d\x36\x2d\x33\xf6\xcb\x10\x4a\x87\xf0\x65\x83\x0b\x0b\xcd\x55\x9e\xa3\x1c\xe6\x28\x1f\x21\x2d\x18\x23\xb6\x62\x1d\x1d\xda\x34\xb5\xe3\x89\xfb\x6c\x08\x50\x0b\xac\xc3\xdc\x46\x15\x59\xb9\x49\x32\x79\xa9\x52\xf9\x0e\x2a\xe6\xef\xed\x0c\x68\x70\xdc\xc8\x6e\x54\x06\x17\x4f\x3d\x20\x52\x59\x21\x51\x3f\xda\xc1\x9f\x1d\x50\x23\xb9\x94\x6a\xb1\x34\xfb\xa2\x7f\xb8\xd1\x40\xd6\x4f\x37\xc0\xf6\x7f\x30\xde\xc2\xb8\x17\x5f\x46\x60\x91\xb9\xea\xb0\x17\xd2\xd5\x8c\x2b\x14\x9c\x71\xe6\xaa\x13\x04\xb0\xa7\x14\xf5\x7b\x54\xca\x78\x34\x20\xfc\x5a\xf0\x81\x87\xea\xb7\x58\xd6\xc9\x8d\x6f\xf8\xa3\xc9\x06\x7e\x6f\x68\x35\x75\xd1\xb6\x9c\x72\x71\x9e\x78\x1a\xb9\x75\x3e\xe8\x3b\xe5\xcb\x67\x28\xc3\x9c\x85\x58\xa0\xe8\x79\xe2\xf3\xd6\xad\xb6\xd9\xdb\x2a\xb4\x12\x57\x4d\x52\xda\x65\xbe\x13\x04\xcd\x0d\xf8\xf5\xd6\x6b\xe3\xa4\x2d\x5f\xf1\x1a\xe3\x6f\x40\x76\x3e\xe9\xca\xe2\x75\xbf\xb1\xc9\x4a\x07\xb6\xb8\xd5\xc7\x93\x20\x27\xb4\x91\x55\x13\x0a\x1a\x52\x1c\x36\x4c\xa8\xde\x16\xff\x72\x3e\x47\xad\x6b\x25\x8f\x76\x1d\xc6\x92\x08\x34\x83\xb9\x0a\x8b\x80\xcc\xe4\x42\x15\xef\xa0\x69\xdc\xc6\x68\x55\x5e\xca\x0f\x65\xec\xb0\x82\xd8\x7c\xdd\xf4\x01\x29\xaf\x3f\x63\x70\x0a\xb4\x18\x48\x2a\xe5\xf7\x6d\xe0\x58\xb5\xc0\x23\xb0\x09\x56\xb3\x72\x71\x3b\x3f\x6e\x38\xd9\x0f\xfc\x15\x60\xef\x53\xa7\x21\x58\xa9\xe5\xae\x3d\x9c\x95\xa7\x66\x6b\xfb\x6d\x54\x2f\x66\x22\x7e\xf4\x78\x40\xc7\x8f\x1f\x0e\x68\xfc\xe0\x11\x68\x98\x8c\x1f\xf6\xa3\x50\x3a\xee\x7f\xc9\x3f\xdf\xe7\x01\x0e\x05\xc7\xf0\x16\xf3\xb7\x6d\xe2\xfc\x90\x0b\x6c\x95\x64\xb2\xa8\x1d\xaa\x84\xf1\x02\xc7\xb6\x4e\x2e\xac\xd8\xa1\xc7\x02\x89\x1d\x87\xe2\x34\x56\x5e\xc8\xd6\x45\x3f\x3d\x98\xa7\xf7\xe7\x8f\xa2\xd0\xdf\x1f\x7d\x31\xe8\x8a\x44\xbb\xce\x3a\xc6\x57\xcb\x75\x05\x07\xc9\xd3\x6d\x91\xa2\x8c\xeb\x2a\x17\x5b\x57\x34\x91\x52\xaa\x20\x8d\x71\x9c\x14\x0b\xa5\x85\x75\x1b\x0e\x56\x8b\x5a\xea\x9d\x16\xda\x21\xc4\x2c\xde\x15\xcc\x19\xfe\x82\x43\x0d\x0a\x8c\x3f\xd1\x3c\xdb\xbe\xcc\x70\x08\x82\xf0\x11\x64\xba\x83\x10\x27\xfe\x37\x57\xb0\x50\xd4\xa5\x79\x25\xeb\xd4\x15\x8a\xe3\x51\xdb\x81\x59\x30\x59\x22\x24\x52\x9b\x67\x39\x8e\xb5\xf4\xd4\x9a\x02\xfb\x51\x28\x54\xb1\xb0\x83\x3e\x22\x3b\x08\x1c\x4c\x2b\x99\xae\xeb\x9a\x37\xb6\xab\x8f\x6e\x5d\x3d\xbc\x6d\xa3\x5b\x45\x9b\xc2\x00\x6b\x31\x83\xa0\xb5\xa5\xda\x55\x1c\x55\xc4\xd8\x7b\xd0\x18\x63\xfb\xdc\xdd\xa8\xab\xad\x52\xa7\x90\x75\xc0\x78\x00\x17\x1d\x33\x3a\x75\xaf\x11\x3a\x67\xf4\x32\xcb\xdd\x09\xd0\xca\x1e\x82\x13\x03\x9a\x59\x85\xb8\x5d\xde\xb4\x88\x1b\x29\x7a\x61\x3b\x19\xda\xb0\xe3\xc5\x8b\xa2\xdc\x14\xa7\x06\x11\xd6\x70\x62\xc3\xe2\x60\x17\xed\xe6\x5a\xa4\x6a\xcd\x1d\xd5\xaf\xd8\xdf\x26\x40\x6b\x7c\xc2\xf1\xdc\x2b\xf9\x8c\xbe\xcf\x50\x1c\xea\x70\x84\xe3\x96\xed\x6e\x0f\xf8\xd6\x5a\xe0\x50\x87\xb6\x5e\x36\x8c\xc5\xe9\xf3\x26\x33\xe3\x95\x5e\x38\xb5\x6d\xe9\xd6\x8b\xa4\xa3\x69\x2f\x20\x6f\x3b\xd3\x68\xf6\x76\xf6\x37\xda\x5e\x72\x21\xb7\xda\x4e\xfa\xab\x0a\x5a\xf9\xa7\xeb\xfe\x2d\x25\xa5\xc0\x71\x3c\x28\x27\xee\x94\xe1\x5b\xa2\x3e\xe3\xd9\xf3\xb6\x28\xdc\x09\x0e\x17\x61\x2f\xb4\x1b\xf7\xba\x2e\xba\xd3\x52\x13\xdc\x74\x52\xf4\xc2\x4e\x19\x87\xda\x4f\x74\x8e\xe3\x46\x7c\x14\x34\x19\x57\x35\x82\x73\x4c\x5b\x91\xbd\x5b\x81\x55\xc0\x3c\x2a\x71\x96\x77\x29\xdf\x5e\x01\x71\x43\x94\xb8\x27\xd6\xd2\xcb\x30\xdf\xa0\x34\x3a\xd7\x0c\x17\x93\x8b\x1d\x1f\x7b\x91\xb8\x4b\x7b\x26\xc4\x5a\x7f\x35\x8f\xad\x44\x97\xf7\x3a\x5d\x4a\x7b\x93\xf1\xa7\x27\x90\xd0\x96\x1b\x77\x8f\x4b\xcb\xdc\x9d\x9e\x97\xc6\x54\xfa\x24\x62\xea\x6f\xb4\x3e\x19\x0e\x2d\xf3\x37\xf6\xad\x85\x72\xe4\x98\x52\x21\x37\xf4\x51\xce\x4e\xad\x41\xb1\xdf\xe1\xf0\xc6\x0e\xcb\x52\x73\xb5\x8f\xf8\x0e\xec\xab\x8a\x83\x48\xca\x82\x3d\xc3\x07\x90\xdd\xdb\x30\x04\xac\xfa\x7b\x9c\x8d\xbc\x23\x64\x66\x75\x69\x84\x76\xee\x69\x65\xc1\x85\xd9\xcd\x87\xe4\x72\xb1\xfc\x06\xa5\x7a\x61\xc4\x1b\x6e\x9c\x39\x46\xb9\xc6\xc0\x37\xa4\x4e\xfb\x26\x3f\x42\x03\x70\x6b\x2e\xcc\x0e\xf3\xe3\x3f\x4f\xdf\xbe\x49\x2a\x51\x6b\xe9\x66\x2d\x72\xff\x16\x3c\xdb\xa6\xbf\xdf\x1d\x68\x26\xdf\xe3\x91\xf9\xbc\x73\x09\x1a\xef\x07\xb5\x92\xe5\xda\xc4\x7e\xe9\x80\xee\xe3\xbe\xd1\x2a\xc3\xcc\xf5\x01\x14\x59\xf6\x82\xd5\x7d\xa5\xd0\xa4\x71\xf5\x8f\x23\xb4\x25\xf5\xaf\x8c\x06\x37\x14\xfc\x61\x4f\x77\x49\xd3\xe4\x57\x97\x3b\xfc\x68\x29\x3f\x39\xb8\xee\xf3\xf3\x3f\x6c\xb3\x31\xc6\x77\x11\x00\x00")
+
+func dashboardJsBytes() ([]byte, error) {
+	return bindataRead(
+		_dashboardJs,
+		"dashboard.js",
+	)
+}
+
+func dashboardJs() (*asset, error) {
+	bytes, err := dashboardJsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "dashboard.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _indexHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xad\x55\xc1\x72\xd3\x30\x10\x3d\xd3\xaf\x10\x9a\xe1\x46\xd0\xb4\x27\x0e\xb6\x0f\x69\x3a\x0c\x07\x20\x0c\x70\xe0\xb8\xb1\x36\xb1\x88\x2c\x19\xed\x3a\x69\xfe\x1e\x49\x4e\xdb\xd8\xed\xa1\x69\xb9\xd8\xd2\x7b\xab\xf7\x9e\x25\x65\x53\xbc\x5d\x7c\xbb\xfe\xf9\x7b\x79\x23\x1a\x6e\x6d\x75\x51\xa4\x97\xb0\xe0\x36\xa5\x44\x27\x13\x80\xa0\xab\x8b\x37\x45\x8b\x0c\xa2\x6e\x20\x10\x72\x29\x7b\x5e\xcf\x3e\xca\x7b\xdc\x41\x8b\xa5\xdc\x19\xdc\x77\x3e\xb0\x14\xb5\x77\x8c\x2e\xd6\xed\x8d\xe6\xa6\xd4\xb8\x33\x35\xce\xf2\xe4\xbd\x30\xce\xb0\x01\x3b\xa3\x1a\x2c\x96\x97\x59\x85\x0d\x5b\xac\x6e\x20\x70\xa3\xbd\xb5\x10\xc4\x57\xaf\x51\x2c\x80\x9a\x95\x87\xa0\x0b\x35\x54\xc4\x52\x6b\xdc\x56\x04\xb4\xa5\x24\x3e\x58\xa4\x06\x31\x3a\x36\x01\xd7\xa5\xd4\x77\xf5\x1f\x6a\xa2\x94\x5e\x0d\xf1\x8b\x95\xd7\x87\xb4\x38\x4d\x31\xc4\x51\x1c\x5e\x56\x53\x8f\x08\x25\x86\x3a\x70\xc2\xe8\x64\x00\xdc\x53\xfc\x1c\x0b\x44\xa5\xf4\xeb\x75\x34\x47\x59\xc5\xcf\x73\x58\xb3\x71\x9b\x42\xa5\xe2\xa4\xac\xee\xa5\x0b\x4a\x9c\x3f\x4a\x1c\x5c\x2d\x07\xbf\xab\xea\x47\x9c\x34\xc1\x3b\x43\x90\x0a\xe2\x9a\xab\x4c\x69\xb3\xbb\xf3\xe8\x82\xdf\x04\x4c\xe1\x33\x7a\x27\x31\x5b\x41\x88\x90\x8a\xd8\xf1\x99\xd6\x75\x0f\x3c\xe3\x2d\xcb\x6a\x0f\x26\xa5\x12\x6b\x1f\x84\x06\x86\x42\x75\x39\xdb\x31\xd1\x34\x5c\x3a\x4e\x26\x39\xcd\x90\x61\x99\x05\x66\x79\x9c\x21\xe3\xd4\xca\xfa\x7a\x4b\x47\x26\x1f\x48\x29\x3f\xb7\xe9\xc8\x51\x8b\x11\xd9\xc7\x33\x2e\xa5\x3c\xc9\xfa\x1c\x7d\xd3\x0e\xd7\xe7\x54\x7f\x9e\x64\xc5\xc0\x08\x36\x2d\x8e\x1c\x5a\x7a\xbe\x47\x87\x18\x26\xe1\x97\x27\xd0\xb9\x91\xf9\xb6\xf3\xde\xaa\x0e\x9d\x8e\x5b\x3e\xd5\xcd\xa0\xe0\x00\x8e\x20\x6f\xf8\x2b\x6d\xfe\xf6\xd8\xa3\x1e\xbb\x7c\xcf\xd8\xff\x30\xa1\x03\x31\xb6\xaa\xee\xfa\xb1\xc3\xf5\xf2\x97\xe8\x09\x36\xe3\x4d\x7f\x77\xb6\x70\x8b\xad\x0f\x07\x65\x5c\x4f\x38\x76\xf8\x92\x99\xd8\x13\xc4\x03\x35\xb8\xcc\x5f\xea\x02\x36\x5e\x19\x7a\xd2\x26\x53\xf0\x78\xa7\x14\x9d\x6d\xa6\x0d\x6d\x55\x88\xbf\xf9\xb1\xd1\x22\xc2\x22\xc1\x63\x83\xf9\x4b\x1d\xf6\xc1\x30\x3e\x61\x91\xf1\xd7\x7b\x6c\x7c\xf0\x7d\x6c\x19\x38\xd9\xaf\x4f\x53\x7c\x7a\xa3\xc6\x3d\xa5\x0e\xa6\x63\x41\xa1\x3e\x6d\xc1\x7f\x72\x9c\x81\x4b\xad\x78\xe8\xc1\xb1\xe9\xe5\x7f\x9a\x7f\xe3\x79\x8f\x45\x7a\x06\x00\x00")
+
+func indexHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_indexHtml,
+		"index.html",
+	)
+}
+
+func indexHtml() (*asset, error) {
+	bytes, err := indexHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "index.html", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"dashboard.css": dashboardCss,
+	"dashboard.js":  dashboardJs,
+	"index.html":    indexHtml,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"dashboard.css": &bintree{dashboardCss, map[string]*bintree{}},
+	"dashboard.js":  &bintree{dashboardJs, map[string]*bintree{}},
+	"index.html":    &bintree{indexHtml, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
@@ -0,0 +1,155 @@
+// Live charts of the node dashboard, fed through the /api websocket.
+(function () {
+	'use strict';
+
+	var sampleLimit = 200; // Must match the data points kept by the server
+	var charts = {};
+
+	// Create the canvas and value label of every chart container.
+	Array.prototype.forEach.call(document.querySelectorAll('.chart'), function (elem) {
+		var title = document.createElement('h2');
+		var value = document.createElement('span');
+		var canvas = document.createElement('canvas');
+
+		value.className = 'value';
+		title.textContent = elem.dataset.title;
+		title.appendChild(value);
+		elem.appendChild(title);
+		elem.appendChild(canvas);
+
+		charts[elem.dataset.chart] = {canvas: canvas, value: value, unit: elem.dataset.unit, data: []};
+	});
+
+	// format renders a value with a metric prefix for large numbers.
+	function format(value, unit) {
+		var prefixes = ['', 'K', 'M', 'G', 'T'];
+		var i = 0;
+		if (unit === 'B' || unit === 'B/s') {
+			for (; Math.abs(value) >= 1024 && i < prefixes.length - 1; i++) {
+				value /= 1024;
+			}
+		} else {
+			for (; Math.abs(value) >= 1000 && i < prefixes.length - 1; i++) {
+				value /= 1000;
+			}
+		}
+		var digits = (value !== 0 && Math.abs(value) < 10 && value % 1 !== 0) ? 2 : 0;
+		return value.toFixed(digits) + ' ' + prefixes[i] + unit;
+	}
+
+	// draw renders the data points of a chart as a filled line.
+	function draw(chart) {
+		var canvas = chart.canvas;
+		var ratio = window.devicePixelRatio || 1;
+		var width = canvas.clientWidth * ratio;
+		var height = canvas.clientHeight * ratio;
+		canvas.width = width;
+		canvas.height = height;
+
+		var ctx = canvas.getContext('2d');
+		ctx.clearRect(0, 0, width, height);
+
+		var data = chart.data;
+		if (data.length === 0) {
+			return;
+		}
+		chart.value.textContent = format(data[data.length - 1].value, chart.unit);
+
+		var max = 0;
+		data.forEach(function (entry) {
+			max = Math.max(max, entry.value);
+		});
+		if (max === 0) {
+			max = 1;
+		}
+		var step = width / (sampleLimit - 1);
+		var offset = width - (data.length - 1) * step;
+
+		ctx.beginPath();
+		ctx.moveTo(offset, height);
+		data.forEach(function (entry, i) {
+			ctx.lineTo(offset + i * step, height - (entry.value / max) * (height - 2 * ratio));
+		});
+		ctx.lineTo(width, height);
+		ctx.closePath();
+		ctx.fillStyle = 'rgba(79, 195, 247, 0.25)';
+		ctx.fill();
+
+		ctx.beginPath();
+		data.forEach(function (entry, i) {
+			var x = offset + i * step, y = height - (entry.value / max) * (height - 2 * ratio);
+			if (i === 0) {
+				ctx.moveTo(x, y);
+			} else {
+				ctx.lineTo(x, y);
+			}
+		});
+		ctx.strokeStyle = '#4fc3f7';
+		ctx.lineWidth = ratio;
+		ctx.stroke();
+	}
+
+	// updateSync displays the chain synchronisation progress.
+	function updateSync(sync) {
+		var bar = document.getElementById('sync-bar');
+		var text = document.getElementById('sync-text');
+
+		var percent = 100;
+		if (sync.highestBlock > sync.startingBlock) {
+			percent = 100 * (sync.currentBlock - sync.startingBlock) / (sync.highestBlock - sync.startingBlock);
+		}
+		bar.style.width = Math.min(100, percent) + '%';
+
+		var desc = sync.syncing ? 'Syncing' : 'Idle';
+		desc += ', block ' + sync.currentBlock + ' of ' + sync.highestBlock;
+		if (sync.knownStates > 0) {
+			desc += ', states ' + sync.pulledStates + ' of ' + sync.knownStates;
+		}
+		text.textContent = desc;
+	}
+
+	// update merges a server message into the charts.
+	function update(msg) {
+		if (msg.sync) {
+			updateSync(msg.sync);
+		}
+		Object.keys(msg.charts || {}).forEach(function (name) {
+			var chart = charts[name];
+			if (!chart) {
+				return;
+			}
+			chart.data = chart.data.concat(msg.charts[name]).slice(-sampleLimit);
+			draw(chart);
+		});
+	}
+
+	// connect opens the websocket feed, reconnecting if it breaks.
+	function connect() {
+		var status = document.getElementById('status');
+		var scheme = window.location.protocol === 'https:' ? 'wss://' : 'ws://';
+		var server = new WebSocket(scheme + window.location.host + '/api');
+
+		server.onopen = function () {
+			status.textContent = 'connected';
+			status.className = 'online';
+			Object.keys(charts).forEach(function (name) {
+				charts[name].data = [];
+			});
+		};
+		server.onmessage = function (event) {
+			update(JSON.parse(event.data));
+		};
+		server.onclose = function () {
+			status.textContent = 'disconnected';
+			status.className = 'offline';
+			setTimeout(connect, 3000);
+		};
+	}
+
+	window.addEventListener('resize', function () {
+		Object.keys(charts).forEach(function (name) {
+			draw(charts[name]);
+		});
+	});
+	connect();
+})();
@@ -0,0 +1,430 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dashboard implements a node service serving a web page with live
+// charts of the health of the node.
+//
+// The page and its scripts are embedded into the binary, the data is pushed to
+// the browser through a websocket connection. The charts are fed from the
+// metrics registry, the event mux of the Ethereum service and the p2p server.
+package dashboard
+
+//go:generate go-bindata -nometadata -o assets.go -prefix assets -pkg dashboard assets/...
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/eth"
+	"github.com/EarthDollar/go-earthdollar/eth/downloader"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/les"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"github.com/rcrowley/go-metrics"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	sampleLimit   = 200 // Maximum number of data points kept for every chart
+	clientBacklog = 16  // Number of updates buffered for a slow client before dropping it
+)
+
+// Names of the charts displayed on the dashboard.
+const (
+	chartPeers         = "peers"
+	chartPendingTxs    = "txpool/pending"
+	chartQueuedTxs     = "txpool/queued"
+	chartBlocks        = "chain/blocks"
+	chartImportTime    = "chain/import"
+	chartMemoryInuse   = "system/memory/inuse"
+	chartMemoryAllocs  = "system/memory/allocs"
+	chartCPU           = "system/cpu"
+	chartDiskRead      = "system/disk/read"
+	chartDiskWrite     = "system/disk/write"
+	chartGoroutines    = "system/goroutines"
+	metricsCPUTime     = "system/cpu/time"
+	metricsMemAllocs   = "system/memory/allocs"
+	metricsDiskRead    = "system/disk/readdata"
+	metricsDiskWrite   = "system/disk/writedata"
+	metricsBlockInsert = "chain/inserts"
+)
+
+// Dashboard is a node service serving a web page with live charts of the sync
+// progress, peers, transaction pool, block imports and system resources.
+type Dashboard struct {
+	config *Config
+
+	eth *eth.Ethereum      // Full Ethereum service if monitoring a full node
+	les *les.LightEthereum // Light Ethereum service if monitoring a light node
+
+	server   *p2p.Server  // Peer-to-peer server to retrieve the peer count
+	listener net.Listener // HTTP listener serving the page, nil if stopped
+
+	charts  map[string][]*ChartEntry // Recent data points of the charts
+	sync    *SyncMessage             // Last reported sync progress
+	clients map[uint32]*client       // Websocket connections receiving the updates
+	nextID  uint32                   // Identifier of the next client
+	lock    sync.RWMutex             // Lock protecting the charts and the clients
+
+	blocks uint32 // Number of blocks imported since the last data point (atomic)
+
+	quit chan struct{} // Channel closed to stop the data collection
+	wg   sync.WaitGroup
+}
+
+// client is a websocket connection receiving the dashboard updates.
+type client struct {
+	conn *websocket.Conn
+	msg  chan *Message // Buffered updates waiting to be sent
+	log  log.Logger
+}
+
+// New creates a dashboard for the given Ethereum service, one of which must be
+// set.
+func New(config *Config, ethServ *eth.Ethereum, lesServ *les.LightEthereum) (*Dashboard, error) {
+	if config.Refresh <= 0 {
+		return nil, fmt.Errorf("invalid dashboard refresh interval: %v", config.Refresh)
+	}
+	return &Dashboard{
+		config:  config,
+		eth:     ethServ,
+		les:     lesServ,
+		charts:  make(map[string][]*ChartEntry),
+		clients: make(map[uint32]*client),
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the dashboard (nil as it doesn't use the devp2p overlay network).
+func (db *Dashboard) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// dashboard (nil as it doesn't provide any user callable APIs).
+func (db *Dashboard) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting the data collection and the HTTP
+// server of the dashboard.
+func (db *Dashboard) Start(server *p2p.Server) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", db.config.Host, db.config.Port))
+	if err != nil {
+		return err
+	}
+	db.server, db.listener = server, listener
+
+	db.wg.Add(2)
+	go db.collectData()
+	go db.countBlocks()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", db.webHandler)
+	mux.Handle("/api", websocket.Handler(db.apiHandler))
+	go http.Serve(listener, mux)
+
+	log.Info("Dashboard started", "url", fmt.Sprintf("http://%s", listener.Addr()))
+	return nil
+}
+
+// Stop implements node.Service, closing the HTTP server and all websocket
+// connections, and stopping the data collection.
+func (db *Dashboard) Stop() error {
+	err := db.listener.Close()
+	close(db.quit)
+
+	db.lock.Lock()
+	for _, c := range db.clients {
+		if err := c.conn.Close(); err != nil {
+			c.log.Warn("Failed to close websocket connection", "err", err)
+		}
+	}
+	db.lock.Unlock()
+
+	db.wg.Wait()
+	log.Info("Dashboard stopped")
+	return err
+}
+
+// Addr returns the address the dashboard is listening on, nil if not running.
+func (db *Dashboard) Addr() net.Addr {
+	if db.listener == nil {
+		return nil
+	}
+	return db.listener.Addr()
+}
+
+// webHandler serves the embedded dashboard page and its assets.
+func (db *Dashboard) webHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	if name == "/" {
+		name = "/index.html"
+	}
+	blob, err := Asset(name[1:])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if contentType, ok := contentTypes[path.Ext(name)]; ok {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(blob)
+}
+
+// contentTypes are the MIME types of the embedded assets.
+var contentTypes = map[string]string{
+	".html": "text/html; charset=utf-8",
+	".js":   "application/javascript",
+	".css":  "text/css",
+}
+
+// apiHandler feeds the chart history and the subsequent updates to a websocket
+// connection until either side closes it.
+func (db *Dashboard) apiHandler(conn *websocket.Conn) {
+	id := atomic.AddUint32(&db.nextID, 1)
+	c := &client{
+		conn: conn,
+		msg:  make(chan *Message, clientBacklog),
+		log:  log.New("id", id, "addr", conn.Request().RemoteAddr),
+	}
+	done := make(chan struct{})
+
+	// Detect the closing of the connection by the browser, no data is expected
+	go func() {
+		var buf [1]byte
+		for {
+			if _, err := conn.Read(buf[:]); err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+	// Send the history of the charts and register for the updates
+	db.lock.Lock()
+	c.msg <- &Message{Sync: db.sync, Charts: db.history()}
+	db.clients[id] = c
+	db.lock.Unlock()
+
+	c.log.Debug("Dashboard client connected")
+	defer func() {
+		db.lock.Lock()
+		delete(db.clients, id)
+		db.lock.Unlock()
+		conn.Close()
+		c.log.Debug("Dashboard client disconnected")
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case msg, ok := <-c.msg:
+			if !ok {
+				c.log.Warn("Dashboard client too slow, dropping")
+				return
+			}
+			if err := websocket.JSON.Send(conn, msg); err != nil {
+				c.log.Debug("Failed to send dashboard update", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// history returns a copy of the recent data points of all charts. The caller
+// must hold the lock.
+func (db *Dashboard) history() map[string][]*ChartEntry {
+	charts := make(map[string][]*ChartEntry, len(db.charts))
+	for name, entries := range db.charts {
+		charts[name] = append([]*ChartEntry(nil), entries...)
+	}
+	return charts
+}
+
+// eventMux returns the event mux of the monitored Ethereum service.
+func (db *Dashboard) eventMux() *event.TypeMux {
+	switch {
+	case db.eth != nil:
+		return db.eth.EventMux()
+	case db.les != nil:
+		return db.les.EventMux()
+	default:
+		return nil
+	}
+}
+
+// downloader returns the downloader of the monitored Ethereum service.
+func (db *Dashboard) downloader() *downloader.Downloader {
+	switch {
+	case db.eth != nil:
+		return db.eth.Downloader()
+	case db.les != nil:
+		return db.les.Downloader()
+	default:
+		return nil
+	}
+}
+
+// countBlocks counts the chain head updates, which are sampled into the block
+// import chart.
+func (db *Dashboard) countBlocks() {
+	defer db.wg.Done()
+
+	mux := db.eventMux()
+	if mux == nil {
+		return
+	}
+	sub := mux.Subscribe(core.ChainHeadEvent{})
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case _, ok := <-sub.Chan():
+			if !ok {
+				return // Event mux stopped
+			}
+			atomic.AddUint32(&db.blocks, 1)
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+// collectData samples the data of the charts at the configured refresh rate and
+// pushes them to the connected clients.
+func (db *Dashboard) collectData() {
+	defer db.wg.Done()
+
+	var (
+		prevCPU    = meterCount(metricsCPUTime)
+		prevAllocs = meterCount(metricsMemAllocs)
+		prevRead   = meterCount(metricsDiskRead)
+		prevWrite  = meterCount(metricsDiskWrite)
+		prevTime   = time.Now()
+		memstats   runtime.MemStats
+		cpus       = float64(runtime.NumCPU())
+	)
+	perSecond := func(delta int64, elapsed time.Duration) float64 {
+		return float64(delta) / elapsed.Seconds()
+	}
+	refresh := time.NewTicker(db.config.Refresh)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-db.quit:
+			return
+
+		case now := <-refresh.C:
+			elapsed := now.Sub(prevTime)
+			prevTime = now
+
+			curCPU, curAllocs := meterCount(metricsCPUTime), meterCount(metricsMemAllocs)
+			curRead, curWrite := meterCount(metricsDiskRead), meterCount(metricsDiskWrite)
+			runtime.ReadMemStats(&memstats)
+
+			values := map[string]float64{
+				chartBlocks:       float64(atomic.SwapUint32(&db.blocks, 0)),
+				chartImportTime:   timerMean(metricsBlockInsert) / float64(time.Millisecond),
+				chartMemoryInuse:  float64(memstats.Alloc),
+				chartMemoryAllocs: perSecond(curAllocs-prevAllocs, elapsed),
+				chartCPU:          100 * float64(curCPU-prevCPU) / float64(elapsed) / cpus,
+				chartDiskRead:     perSecond(curRead-prevRead, elapsed),
+				chartDiskWrite:    perSecond(curWrite-prevWrite, elapsed),
+				chartGoroutines:   float64(runtime.NumGoroutine()),
+			}
+			prevCPU, prevAllocs, prevRead, prevWrite = curCPU, curAllocs, curRead, curWrite
+
+			if db.server != nil {
+				values[chartPeers] = float64(db.server.PeerCount())
+			}
+			switch {
+			case db.eth != nil:
+				pending, queued := db.eth.TxPool().Stats()
+				values[chartPendingTxs], values[chartQueuedTxs] = float64(pending), float64(queued)
+			case db.les != nil:
+				values[chartPendingTxs] = float64(db.les.TxPool().Stats())
+			}
+			var sync *SyncMessage
+			if d := db.downloader(); d != nil {
+				progress := d.Progress()
+				sync = &SyncMessage{
+					Syncing:       d.Synchronising(),
+					StartingBlock: progress.StartingBlock,
+					CurrentBlock:  progress.CurrentBlock,
+					HighestBlock:  progress.HighestBlock,
+					PulledStates:  progress.PulledStates,
+					KnownStates:   progress.KnownStates,
+				}
+			}
+			db.update(now, values, sync)
+		}
+	}
+}
+
+// update appends a data point to every chart and pushes them to the clients.
+func (db *Dashboard) update(now time.Time, values map[string]float64, sync *SyncMessage) {
+	msg := &Message{Sync: sync, Charts: make(map[string][]*ChartEntry, len(values))}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for name, value := range values {
+		entry := &ChartEntry{Time: now, Value: value}
+		entries := append(db.charts[name], entry)
+		if len(entries) > sampleLimit {
+			entries = entries[len(entries)-sampleLimit:]
+		}
+		db.charts[name] = entries
+		msg.Charts[name] = []*ChartEntry{entry}
+	}
+	if sync != nil {
+		db.sync = sync
+	}
+	for id, c := range db.clients {
+		select {
+		case c.msg <- msg:
+		default:
+			// The client can't keep up, make its handler drop the connection
+			close(c.msg)
+			delete(db.clients, id)
+		}
+	}
+}
+
+// meterCount returns the total count of a meter in the metrics registry, zero
+// if it's not registered (metrics collection disabled or not supported).
+func meterCount(name string) int64 {
+	if meter, ok := metrics.DefaultRegistry.Get(name).(metrics.Meter); ok {
+		return meter.Count()
+	}
+	return 0
+}
+
+// timerMean returns the mean of the recent samples of a timer in the metrics
+// registry in nanoseconds, zero if it's not registered.
+func timerMean(name string) float64 {
+	if timer, ok := metrics.DefaultRegistry.Get(name).(metrics.Timer); ok {
+		return timer.Mean()
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dashboard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// Tests that the dashboard serves its embedded assets and pushes the chart
+// history and updates over the websocket feed.
+func TestDashboard(t *testing.T) {
+	db, err := New(&Config{Host: "127.0.0.1", Refresh: 20 * time.Millisecond}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create dashboard: %v", err)
+	}
+	if err := db.Start(nil); err != nil {
+		t.Fatalf("failed to start dashboard: %v", err)
+	}
+	defer db.Stop()
+
+	// Check the static assets and their content types
+	for path, want := range map[string]string{"/": "text/html", "/dashboard.js": "application/javascript", "/dashboard.css": "text/css"} {
+		res, err := http.Get(fmt.Sprintf("http://%s%s", db.Addr(), path))
+		if err != nil {
+			t.Fatalf("failed to fetch %s: %v", path, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK || len(body) == 0 {
+			t.Errorf("%s: status %d, %d bytes", path, res.StatusCode, len(body))
+		}
+		if have := res.Header.Get("Content-Type"); !strings.HasPrefix(have, want) {
+			t.Errorf("%s: content type mismatch: have %q, want %q", path, have, want)
+		}
+	}
+	if res, err := http.Get(fmt.Sprintf("http://%s/missing.js", db.Addr())); err != nil || res.StatusCode != http.StatusNotFound {
+		t.Errorf("missing asset not rejected: %v", err)
+	}
+	// Wait for a few data points, then check they are sent as history
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := websocket.Dial(fmt.Sprintf("ws://%s/api", db.Addr()), "", "http://localhost/")
+	if err != nil {
+		t.Fatalf("failed to connect to feed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var history Message
+	if err := websocket.JSON.Receive(conn, &history); err != nil {
+		t.Fatalf("failed to receive history: %v", err)
+	}
+	if n := len(history.Charts[chartGoroutines]); n < 2 {
+		t.Errorf("history too short: have %d entries", n)
+	}
+	if _, ok := history.Charts[chartPeers]; ok {
+		t.Errorf("peer chart reported without p2p server")
+	}
+	var update Message
+	if err := websocket.JSON.Receive(conn, &update); err != nil {
+		t.Fatalf("failed to receive update: %v", err)
+	}
+	if n := len(update.Charts[chartGoroutines]); n != 1 {
+		t.Errorf("update entry count mismatch: have %d, want 1", n)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dashboard
+
+import "time"
+
+// Message is an update pushed to the dashboard page. The first message sent
+// on a connection contains the full history of the charts, the subsequent ones
+// the new data points only.
+type Message struct {
+	Sync   *SyncMessage             `json:"sync,omitempty"`
+	Charts map[string][]*ChartEntry `json:"charts,omitempty"`
+}
+
+// SyncMessage is the chain synchronisation progress of the node.
+type SyncMessage struct {
+	Syncing       bool   `json:"syncing"`
+	StartingBlock uint64 `json:"startingBlock"`
+	CurrentBlock  uint64 `json:"currentBlock"`
+	HighestBlock  uint64 `json:"highestBlock"`
+	PulledStates  uint64 `json:"pulledStates"`
+	KnownStates   uint64 `json:"knownStates"`
+}
+
+// ChartEntry is a data point of a chart.
+type ChartEntry struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
@@ -37,8 +37,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/core/vm"
 	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/miner"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/rlp"
@@ -46,7 +45,10 @@ import (
 	"golang.org/x/net/context"
 )
 
-const defaultTraceTimeout = 5 * time.Second
+const (
+	defaultTraceTimeout = 5 * time.Second
+	devSealTimeout      = 5 * time.Second // maximum time to wait for a developer block
+)
 
 // PublicEthereumAPI provides an API to access Ethereum full node-related
 // information.
@@ -113,7 +115,7 @@ func (s *PublicMinerAPI) GetWork() (work [3]string, err error) {
 	if work, err = s.agent.GetWork(); err == nil {
 		return
 	}
-	glog.V(logger.Debug).Infof("%v", err)
+	log.Debug("Failed to get work", "err", err)
 	return work, fmt.Errorf("mining not ready")
 }
 
@@ -196,6 +198,49 @@ func (s *PrivateMinerAPI) MakeDAG(blockNr rpc.BlockNumber) (bool, error) {
 	return true, nil
 }
 
+// PrivateDevAPI provides private RPC methods to control the sealing of a developer
+// network, available only in developer mode.
+type PrivateDevAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateDevAPI creates a new RPC service which controls the developer network.
+func NewPrivateDevAPI(e *Ethereum) *PrivateDevAPI {
+	return &PrivateDevAPI{e: e}
+}
+
+// IncreaseTime moves the timestamps of the upcoming blocks forward by the given
+// number of seconds, returning the total offset from the wall clock in seconds.
+func (api *PrivateDevAPI) IncreaseTime(seconds uint64) (hexutil.Uint64, error) {
+	offset, err := api.e.Miner().IncreaseTime(time.Duration(seconds) * time.Second)
+	return hexutil.Uint64(offset / time.Second), err
+}
+
+// Mine seals a new block right away, even without any pending transactions, and
+// returns its number once it became the head of the chain.
+func (api *PrivateDevAPI) Mine(ctx context.Context) (hexutil.Uint64, error) {
+	sub := api.e.EventMux().Subscribe(core.ChainHeadEvent{})
+	defer sub.Unsubscribe()
+
+	if err := api.e.Miner().Seal(); err != nil {
+		return 0, err
+	}
+	timeout := time.NewTimer(devSealTimeout)
+	defer timeout.Stop()
+
+	select {
+	case ev, ok := <-sub.Chan():
+		if !ok {
+			return 0, errors.New("node stopped")
+		}
+		return hexutil.Uint64(ev.Data.(core.ChainHeadEvent).Block.NumberU64()), nil
+	case <-timeout.C:
+		return 0, errors.New("block not sealed in time")
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
 // PrivateAdminAPI is the collection of Etheruem full node-related APIs
 // exposed over the private admin endpoint.
 type PrivateAdminAPI struct {
@@ -199,6 +199,10 @@ func (b *EthApiBackend) AccountManager() *accounts.Manager {
 	return b.eth.AccountManager()
 }
 
+func (b *EthApiBackend) BloomStatus() (uint64, uint64) {
+	return b.eth.bloomIndexer.Sections()
+}
+
 type EthApiState struct {
 	state *state.StateDB
 }
@@ -0,0 +1,163 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/accounts"
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/miner"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+)
+
+// newTestDevChain creates an in-memory developer network sealing blocks with
+// the given period, along with a client of its evm API.
+func newTestDevChain(t *testing.T, period time.Duration) (*Ethereum, *rpc.Client, func()) {
+	dir, err := ioutil.TempDir("", "eth-devchain-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		evmux         = new(event.TypeMux)
+		pow           = new(core.FakePow)
+		db, _         = ethdb.NewMemDatabase()
+		chainConfig   = params.DevChainConfig
+		_             = core.WriteGenesisBlockForTesting(db, testBank)
+		blockchain, _ = core.NewBlockChain(db, chainConfig, pow, evmux, vm.Config{})
+	)
+	e := &Ethereum{
+		chainConfig:    chainConfig,
+		chainDb:        db,
+		blockchain:     blockchain,
+		txPool:         core.NewTxPool(chainConfig, evmux, blockchain.State, blockchain.GasLimit),
+		eventMux:       evmux,
+		pow:            pow,
+		accountManager: accounts.NewPlaintextManager(dir),
+		devMode:        true,
+		devPeriod:      period,
+	}
+	e.miner = miner.New(e, chainConfig, evmux, pow)
+	e.miner.StartDevMode(common.Address{0x01}, period)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("evm", NewPrivateDevAPI(e)); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	return e, client, func() {
+		client.Close()
+		server.Stop()
+		e.miner.Stop()
+		blockchain.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+// waitHead waits until the head of the chain reaches the given block number.
+func waitHead(t *testing.T, e *Ethereum, number uint64) *types.Block {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if head := e.BlockChain().CurrentBlock(); head.NumberU64() >= number {
+			return head
+		}
+	}
+	t.Fatalf("head not reaching #%d, stuck at #%d", number, e.BlockChain().CurrentBlock().NumberU64())
+	return nil
+}
+
+// Tests that evm_mine seals blocks on request and evm_increaseTime moves their
+// timestamps forward.
+func TestDevAPIMineIncreaseTime(t *testing.T) {
+	e, client, stop := newTestDevChain(t, 0)
+	defer stop()
+
+	var number hexutil.Uint64
+	if err := client.Call(&number, "evm_mine"); err != nil {
+		t.Fatalf("evm_mine failed: %v", err)
+	}
+	if number != 1 || e.BlockChain().CurrentBlock().NumberU64() != 1 {
+		t.Fatalf("block number mismatch: have %d (head #%d), want 1", number, e.BlockChain().CurrentBlock().NumberU64())
+	}
+	var offset hexutil.Uint64
+	if err := client.Call(&offset, "evm_increaseTime", 3600); err != nil {
+		t.Fatalf("evm_increaseTime failed: %v", err)
+	}
+	if offset != 3600 {
+		t.Errorf("time offset mismatch: have %d, want 3600", offset)
+	}
+	now := time.Now().Unix()
+	if err := client.Call(&number, "evm_mine"); err != nil {
+		t.Fatalf("evm_mine failed: %v", err)
+	}
+	head := e.BlockChain().CurrentBlock()
+	if number != 2 || head.NumberU64() != 2 {
+		t.Fatalf("block number mismatch: have %d (head #%d), want 2", number, head.NumberU64())
+	}
+	if ts := head.Time().Int64(); ts < now+3600 || ts > now+3600+5 {
+		t.Errorf("block timestamp mismatch: have %d, want about %d", ts, now+3600)
+	}
+}
+
+// Tests that transactions are sealed right away, without waiting for blocks
+// pushed into the future by a time increase.
+func TestDevModeTransactionSeal(t *testing.T) {
+	e, client, stop := newTestDevChain(t, 0)
+	defer stop()
+
+	var offset hexutil.Uint64
+	if err := client.Call(&offset, "evm_increaseTime", 3600); err != nil {
+		t.Fatalf("evm_increaseTime failed: %v", err)
+	}
+	now := time.Now().Unix()
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{0x02}, big.NewInt(1), params.TxGas, new(big.Int), nil), types.NewEIP155Signer(params.DevChainConfig.ChainId), testBankKey)
+	if err := e.TxPool().Add(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	head := waitHead(t, e, 1)
+	if txs := head.Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Errorf("sealed transactions mismatch: have %v, want [%x]", txs, tx.Hash())
+	}
+	if ts := head.Time().Int64(); ts < now+3600 || ts > now+3600+5 {
+		t.Errorf("block timestamp mismatch: have %d, want about %d", ts, now+3600)
+	}
+}
+
+// Tests that a developer network with a block period seals empty blocks.
+func TestDevModePeriod(t *testing.T) {
+	e, _, stop := newTestDevChain(t, 100*time.Millisecond)
+	defer stop()
+
+	start := time.Now()
+	head := waitHead(t, e, 3)
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("blocks sealed too fast: #%d after %v", head.NumberU64(), elapsed)
+	}
+	if len(head.Transactions()) != 0 {
+		t.Errorf("unexpected transactions in block #%d", head.NumberU64())
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/ethereum/ethash"
+	ethereum "github.com/EarthDollar/go-earthdollar"
 	"github.com/EarthDollar/go-earthdollar/accounts"
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core"
@@ -40,14 +41,14 @@ import (
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/miner"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/pow"
 	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -64,43 +65,47 @@ var (
 )
 
 type Config struct {
-	ChainConfig *params.ChainConfig // chain configuration
+	ChainConfig *params.ChainConfig `toml:"-"` // chain configuration
 
 	NetworkId  int    // Network ID to use for selecting peers to connect to
-	Genesis    string // Genesis JSON to seed the chain database with
+	Genesis    string `toml:"-"` // Genesis JSON to seed the chain database with
 	FastSync   bool   // Enables the state download based fast synchronisation algorithm
 	LightMode  bool   // Running in light client mode
 	LightServ  int    // Maximum percentage of time allowed for serving LES requests
 	LightPeers int    // Maximum number of LES client peers
 	MaxPeers   int    // Maximum number of global peers
 
-	SkipBcVersionCheck bool // e.g. blockchain export
+	SkipBcVersionCheck bool `toml:"-"` // e.g. blockchain export
 	DatabaseCache      int
-	DatabaseHandles    int
+	DatabaseHandles    int `toml:"-"`
 
 	DocRoot   string
 	AutoDAG   bool
 	PowFake   bool
 	PowTest   bool
 	PowShared bool
-	ExtraData []byte
+	ExtraData []byte `toml:"-"`
+
+	DevMode   bool          // Seal blocks without proof-of-work for a developer network
+	DevPeriod time.Duration // Block period of the developer network (0 = seal on transactions)
 
 	Etherbase    common.Address
-	GasPrice     *big.Int
+	GasPrice     *big.Int `toml:",omitempty"`
 	MinerThreads int
 	SolcPath     string
 
-	GpoMinGasPrice          *big.Int
-	GpoMaxGasPrice          *big.Int
+	GpoMinGasPrice          *big.Int `toml:",omitempty"`
+	GpoMaxGasPrice          *big.Int `toml:",omitempty"`
 	GpoFullBlockRatio       int
 	GpobaseStepDown         int
 	GpobaseStepUp           int
 	GpobaseCorrectionFactor int
 
 	EnablePreimageRecording bool
+	AddrTxIndex             bool // Maintain an index of transactions by sender and recipient address
 
-	TestGenesisBlock *types.Block   // Genesis block to seed the chain database with (testing only!)
-	TestGenesisState ethdb.Database // Genesis state to seed the database with (testing only!)
+	TestGenesisBlock *types.Block   `toml:"-"` // Genesis block to seed the chain database with (testing only!)
+	TestGenesisState ethdb.Database `toml:"-"` // Genesis state to seed the database with (testing only!)
 }
 
 type LesServer interface {
@@ -125,6 +130,8 @@ type Ethereum struct {
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
 
+	bloomIndexer *core.ChainIndexer // Bloom indexer operating during block imports
+
 	eventMux       *event.TypeMux
 	pow            pow.PoW
 	accountManager *accounts.Manager
@@ -138,6 +145,8 @@ type Ethereum struct {
 	autodagquit  chan bool
 	etherbase    common.Address
 	solcPath     string
+	devMode      bool
+	devPeriod    time.Duration
 
 	netVersionId  int
 	netRPCService *ethapi.PublicNetAPI
@@ -176,16 +185,15 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		MinerThreads:   config.MinerThreads,
 		AutoDAG:        config.AutoDAG,
 		solcPath:       config.SolcPath,
+		devMode:        config.DevMode,
+		devPeriod:      config.DevPeriod,
 	}
 
 	if err := upgradeChainDatabase(chainDb); err != nil {
 		return nil, err
 	}
-	if err := addMipmapBloomBins(chainDb); err != nil {
-		return nil, err
-	}
 
-	glog.V(logger.Info).Infof("Protocol Versions: %v, Network Id: %v", ProtocolVersions, config.NetworkId)
+	log.Info("Initialising Ethereum protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
 	if !config.SkipBcVersionCheck {
 		bcVersion := core.GetBlockChainVersion(chainDb)
@@ -203,7 +211,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		if err != nil {
 			return nil, err
 		}
-		glog.V(logger.Info).Infoln("WARNING: Wrote default ethereum genesis block")
+		log.Warn("Wrote default ethereum genesis block")
 	}
 
 	if config.ChainConfig == nil {
@@ -213,7 +221,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 
 	eth.chainConfig = config.ChainConfig
 
-	glog.V(logger.Info).Infoln("Chain config:", eth.chainConfig)
+	log.Info("Initialised chain configuration", "config", eth.chainConfig)
 
 	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux(), vm.Config{EnablePreimageRecording: config.EnablePreimageRecording})
 	if err != nil {
@@ -222,6 +230,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 		return nil, err
 	}
+	if config.AddrTxIndex {
+		if err := eth.blockchain.EnableAddrTxIndex(); err != nil {
+			return nil, err
+		}
+	}
+	eth.bloomIndexer = NewBloomIndexer(chainDb, params.BloomBitsBlocks)
+	eth.bloomIndexer.Start(eth.blockchain.CurrentHeader(), eth.eventMux)
+
 	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
 
@@ -274,7 +290,7 @@ func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) error {
 		if err != nil {
 			return err
 		}
-		glog.V(logger.Info).Infof("Successfully wrote custom genesis block: %x", block.Hash())
+		log.Info("Successfully wrote custom genesis block", "hash", block.Hash())
 	}
 	// Load up a test setup if directly injected
 	if config.TestGenesisState != nil {
@@ -293,13 +309,13 @@ func SetupGenesisBlock(chainDb *ethdb.Database, config *Config) error {
 func CreatePoW(config *Config) (pow.PoW, error) {
 	switch {
 	case config.PowFake:
-		glog.V(logger.Info).Infof("ethash used in fake mode")
+		log.Info("Ethash used in fake mode")
 		return pow.PoW(core.FakePow{}), nil
 	case config.PowTest:
-		glog.V(logger.Info).Infof("ethash used in test mode")
+		log.Info("Ethash used in test mode")
 		return ethash.NewForTesting()
 	case config.PowShared:
-		glog.V(logger.Info).Infof("ethash used in shared mode")
+		log.Info("Ethash used in shared mode")
 		return ethash.NewShared(), nil
 	default:
 		return ethash.New(), nil
@@ -309,7 +325,7 @@ func CreatePoW(config *Config) (pow.PoW, error) {
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
-	return append(ethapi.GetAPIs(s.ApiBackend, s.solcPath), []rpc.API{
+	apis := append(ethapi.GetAPIs(s.ApiBackend, s.solcPath), []rpc.API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
@@ -355,6 +371,16 @@ func (s *Ethereum) APIs() []rpc.API {
 			Public:    true,
 		},
 	}...)
+
+	// Developer networks can also be driven through the test helpers
+	if s.devMode {
+		apis = append(apis, rpc.API{
+			Namespace: "evm",
+			Version:   "1.0",
+			Service:   NewPrivateDevAPI(s),
+		})
+	}
+	return apis
 }
 
 func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
@@ -383,7 +409,7 @@ func (s *Ethereum) StartMining(threads int) error {
 	eb, err := s.Etherbase()
 	if err != nil {
 		err = fmt.Errorf("Cannot start mining without etherbase address: %v", err)
-		glog.V(logger.Error).Infoln(err)
+		log.Error("Cannot start mining without etherbase", "err", err)
 		return err
 	}
 	go s.miner.Start(eb, threads)
@@ -405,6 +431,16 @@ func (s *Ethereum) EthVersion() int                    { return int(s.protocolMa
 func (s *Ethereum) NetVersion() int                    { return s.netVersionId }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
+// SyncProgress implements ethereum.ChainSyncReader, returning the progress of
+// the chain synchronisation, or nil if the chain is in sync.
+func (s *Ethereum) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	progress := s.protocolManager.downloader.Progress()
+	if progress.CurrentBlock >= progress.HighestBlock {
+		return nil, nil
+	}
+	return &progress, nil
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -423,9 +459,17 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 		s.StartAutoDAG()
 	}
 	s.protocolManager.Start()
+	s.protocolManager.startRecordUpdater(srvr)
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if s.devMode {
+		eb, err := s.Etherbase()
+		if err != nil {
+			return fmt.Errorf("developer mode needs an etherbase: %v", err)
+		}
+		s.miner.StartDevMode(eb, s.devPeriod)
+	}
 	return nil
 }
 
@@ -435,6 +479,7 @@ func (s *Ethereum) Stop() error {
 	if s.stopDbUpgrade != nil {
 		s.stopDbUpgrade()
 	}
+	s.bloomIndexer.Stop()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	if s.lesServer != nil {
@@ -471,14 +516,14 @@ func (self *Ethereum) StartAutoDAG() {
 		return // already started
 	}
 	go func() {
-		glog.V(logger.Info).Infof("Automatic pregeneration of ethash DAG ON (ethash dir: %s)", ethash.DefaultDir)
+		log.Info("Automatic pregeneration of ethash DAG on", "dir", ethash.DefaultDir)
 		var nextEpoch uint64
 		timer := time.After(0)
 		self.autodagquit = make(chan bool)
 		for {
 			select {
 			case <-timer:
-				glog.V(logger.Info).Infof("checking DAG (ethash dir: %s)", ethash.DefaultDir)
+				log.Info("Checking DAG availability", "dir", ethash.DefaultDir)
 				currentBlock := self.BlockChain().CurrentBlock().NumberU64()
 				thisEpoch := currentBlock / epochLength
 				if nextEpoch <= thisEpoch {
@@ -487,19 +532,19 @@ func (self *Ethereum) StartAutoDAG() {
 							previousDag, previousDagFull := dagFiles(thisEpoch - 1)
 							os.Remove(filepath.Join(ethash.DefaultDir, previousDag))
 							os.Remove(filepath.Join(ethash.DefaultDir, previousDagFull))
-							glog.V(logger.Info).Infof("removed DAG for epoch %d (%s)", thisEpoch-1, previousDag)
+							log.Info("Removed previous DAG", "epoch", thisEpoch-1, "dag", previousDag)
 						}
 						nextEpoch = thisEpoch + 1
 						dag, _ := dagFiles(nextEpoch)
 						if _, err := os.Stat(dag); os.IsNotExist(err) {
-							glog.V(logger.Info).Infof("Pregenerating DAG for epoch %d (%s)", nextEpoch, dag)
+							log.Info("Pregenerating next DAG", "epoch", nextEpoch, "dag", dag)
 							err := ethash.MakeDAG(nextEpoch*epochLength, "") // "" -> ethash.DefaultDir
 							if err != nil {
-								glog.V(logger.Error).Infof("Error generating DAG for epoch %d (%s)", nextEpoch, dag)
+								log.Error("Error generating DAG", "epoch", nextEpoch, "dag", dag, "err", err)
 								return
 							}
 						} else {
-							glog.V(logger.Error).Infof("DAG for epoch %d (%s)", nextEpoch, dag)
+							log.Warn("DAG already exists", "epoch", nextEpoch, "dag", dag)
 						}
 					}
 				}
@@ -517,7 +562,7 @@ func (self *Ethereum) StopAutoDAG() {
 		close(self.autodagquit)
 		self.autodagquit = nil
 	}
-	glog.V(logger.Info).Infof("Automatic pregeneration of ethash DAG OFF (ethash dir: %s)", ethash.DefaultDir)
+	log.Info("Automatic pregeneration of ethash DAG off", "dir", ethash.DefaultDir)
 }
 
 // dagFiles(epoch) returns the two alternative DAG filenames (not a path)
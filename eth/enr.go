@@ -0,0 +1,101 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/forkid"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+// ethEntry is the "eth" entry of the node record, advertising the chain the
+// node is on so that nodes of other networks or with incompatible fork rules
+// can be skipped before dialing.
+type ethEntry struct {
+	NetworkId uint64
+	Genesis   common.Hash
+	ForkID    forkid.ID
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ethEntry) ENRKey() string {
+	return "eth"
+}
+
+// nodeEntry assembles the "eth" node record entry of the local node.
+func (pm *ProtocolManager) nodeEntry() *ethEntry {
+	return &ethEntry{
+		NetworkId: uint64(pm.networkId),
+		Genesis:   pm.blockchain.Genesis().Hash(),
+		ForkID:    pm.forkID(),
+	}
+}
+
+// forkID calculates the fork ID of the local chain at its current head.
+func (pm *ProtocolManager) forkID() forkid.ID {
+	return forkid.NewID(pm.chainconfig, pm.blockchain.Genesis().Hash(), pm.blockchain.CurrentHeader().Number.Uint64())
+}
+
+// dialCandidate reports whether a node found by discovery is worth dialing
+// according to its node record. Nodes without a record or without an "eth"
+// entry are accepted, the status handshake will tell whether they match.
+func (pm *ProtocolManager) dialCandidate(r *enr.Record) bool {
+	if r == nil {
+		return true
+	}
+	var entry ethEntry
+	if err := r.Load(&entry); err != nil {
+		return enr.IsNotFound(err)
+	}
+	if entry.NetworkId != uint64(pm.networkId) || entry.Genesis != pm.blockchain.Genesis().Hash() {
+		return false
+	}
+	return pm.forkFilter(entry.ForkID) == nil
+}
+
+// startRecordUpdater keeps the "eth" entry of the local node record in sync
+// with the fork ID of the chain, which changes whenever the head passes a fork
+// block. The updater terminates when the protocol manager is stopped.
+func (pm *ProtocolManager) startRecordUpdater(srvr *p2p.Server) {
+	pm.headSub = pm.eventMux.Subscribe(core.ChainHeadEvent{})
+	go pm.recordUpdateLoop(srvr)
+}
+
+func (pm *ProtocolManager) recordUpdateLoop(srvr *p2p.Server) {
+	last := pm.forkID()
+
+	// automatically stops if unsubscribe
+	for range pm.headSub.Chan() {
+		id := pm.forkID()
+		if id == last {
+			continue
+		}
+		last = id
+		if err := srvr.SetRecordEntries(pm.nodeEntry()); err != nil {
+			log.Warn("Failed to update node record", "hash", common.Bytes2Hex(id.Hash[:]), "next", id.Next, "err", err)
+			continue
+		}
+		log.Info("Updated fork ID in node record", "hash", common.Bytes2Hex(id.Hash[:]), "next", id.Next)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/forkid"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+)
+
+// Tests that dial candidates are filtered by the "eth" entry of their node record,
+// including the fork ID it announces.
+func TestDialCandidate(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, false, 0, nil, nil)
+	defer pm.Stop()
+
+	genesis, forkID := pm.blockchain.Genesis().Hash(), pm.forkID()
+	record := func(entries ...enr.Entry) *enr.Record {
+		r := new(enr.Record)
+		for _, e := range entries {
+			r.Set(e)
+		}
+		return r
+	}
+	tests := []struct {
+		record *enr.Record
+		accept bool
+	}{
+		{nil, true},
+		{record(), true},
+		{record(pm.nodeEntry()), true},
+		{record(&ethEntry{NetworkId: NetworkId, Genesis: common.Hash{1}, ForkID: forkID}), false},
+		{record(&ethEntry{NetworkId: NetworkId + 1, Genesis: genesis, ForkID: forkID}), false},
+		{record(&ethEntry{NetworkId: NetworkId, Genesis: genesis, ForkID: forkid.ID{Hash: [4]byte{1}}}), false},
+		{record(&ethEntry{NetworkId: NetworkId, Genesis: genesis, ForkID: forkid.ID{Hash: forkID.Hash, Next: 1}}), true},
+		{record(enr.WithEntry("eth", "invalid")), false},
+	}
+	for i, tt := range tests {
+		if accept := pm.dialCandidate(tt.record); accept != tt.accept {
+			t.Errorf("test %d: accept mismatch: have %v, want %v", i, accept, tt.accept)
+		}
+	}
+}
@@ -17,13 +17,12 @@
 package filters
 
 import (
-	"math"
-	"time"
-
 	"math/big"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/bloombits"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
@@ -36,12 +35,15 @@ type Backend interface {
 	EventMux() *event.TypeMux
 	HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+
+	// BloomStatus returns the section size of the bloom bits index and the
+	// number of sections already indexed.
+	BloomStatus() (uint64, uint64)
 }
 
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
-	backend   Backend
-	useMipMap bool
+	backend Backend
 
 	created time.Time
 
@@ -51,15 +53,13 @@ type Filter struct {
 	topics     [][]common.Hash
 }
 
-// New creates a new filter which uses a bloom filter on blocks to figure out whether
-// a particular block is interesting or not.
-// MipMaps allow past blocks to be searched much more efficiently, but are not available
-// to light clients.
-func New(backend Backend, useMipMap bool) *Filter {
+// New creates a new filter which uses the bloom bits index to find the sections
+// of the chain that might contain matching logs, falling back to checking the
+// header bloom of each block for the part of the chain not yet indexed.
+func New(backend Backend) *Filter {
 	return &Filter{
-		backend:   backend,
-		useMipMap: useMipMap,
-		db:        backend.ChainDb(),
+		backend: backend,
+		db:      backend.ChainDb(),
 	}
 }
 
@@ -105,19 +105,28 @@ func (f *Filter) FindOnce(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		endBlockNo = headBlockNumber
 	}
-
-	// if no addresses are present we can't make use of fast search which
-	// uses the mipmap bloom filters to check for fast inclusion and uses
-	// higher range probability in order to ensure at least a false positive
-	if !f.useMipMap || len(f.addresses) == 0 {
-		logs, blockNumber, err := f.getLogs(ctx, beginBlockNo, endBlockNo)
+	if beginBlockNo > endBlockNo {
+		return nil, nil
+	}
+	// Search the indexed part of the range through the bloom bits, returning
+	// any results found there first
+	size, sections := f.backend.BloomStatus()
+	if indexed := sections * size; indexed > beginBlockNo {
+		last := endBlockNo
+		if last > indexed-1 {
+			last = indexed - 1
+		}
+		logs, blockNumber, err := f.indexedLogs(ctx, size, beginBlockNo, last)
 		f.begin = int64(blockNumber + 1)
-		return logs, err
+		if len(logs) > 0 || err != nil {
+			return logs, err
+		}
+		beginBlockNo = blockNumber + 1
 	}
-
-	logs, blockNumber := f.mipFind(beginBlockNo, endBlockNo, 0)
+	// Check the remaining, not yet indexed blocks one by one
+	logs, blockNumber, err := f.getLogs(ctx, beginBlockNo, endBlockNo)
 	f.begin = int64(blockNumber + 1)
-	return logs, nil
+	return logs, err
 }
 
 // Run filters logs with the current parameters set
@@ -131,66 +140,103 @@ func (f *Filter) Find(ctx context.Context) (logs []*types.Log, err error) {
 	}
 }
 
-func (f *Filter) mipFind(start, end uint64, depth int) (logs []*types.Log, blockNumber uint64) {
-	level := core.MIPMapLevels[depth]
-	// normalise numerator so we can work in level specific batches and
-	// work with the proper range checks
-	for num := start / level * level; num <= end; num += level {
-		// find addresses in bloom filters
-		bloom := core.GetMipmapBloom(f.db, num, level)
-		// Don't bother checking the first time through the loop - we're probably picking
-		// up where a previous run left off.
-		first := true
-		for _, addr := range f.addresses {
-			if first || bloom.TestBytes(addr[:]) {
-				first = false
-				// range check normalised values and make sure that
-				// we're resolving the correct range instead of the
-				// normalised values.
-				start := uint64(math.Max(float64(num), float64(start)))
-				end := uint64(math.Min(float64(num+level-1), float64(end)))
-				if depth+1 == len(core.MIPMapLevels) {
-					l, blockNumber, _ := f.getLogs(context.Background(), start, end)
-					if len(l) > 0 {
-						return l, blockNumber
-					}
-				} else {
-					l, blockNumber := f.mipFind(start, end, depth+1)
-					if len(l) > 0 {
-						return l, blockNumber
-					}
-				}
+// matcherFilters converts the address and topic criteria of the filter into the
+// groups of bloom keys the bloom bits matcher works with.
+func (f *Filter) matcherFilters() [][][]byte {
+	filters := make([][][]byte, 0, len(f.topics)+1)
+
+	addresses := make([][]byte, len(f.addresses))
+	for i, address := range f.addresses {
+		addresses[i] = address.Bytes()
+	}
+	filters = append(filters, addresses)
+
+	for _, topics := range f.topics {
+		filter := make([][]byte, len(topics))
+		for i, topic := range topics {
+			// common.Hash{} is a match all (wildcard)
+			if (topic != common.Hash{}) {
+				filter[i] = topic.Bytes()
 			}
 		}
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// fetchBloomBits retrieves a bit vector of the bloom bits index, belonging to the
+// section as currently present in the canonical chain.
+func (f *Filter) fetchBloomBits(ctx context.Context, bit uint, section uint64, size uint64) ([]byte, error) {
+	head := core.GetCanonicalHash(f.db, (section+1)*size-1)
+	return core.GetBloomBits(f.db, bit, section, head)
+}
+
+// indexedLogs returns the logs of the first block in the [start, end] range that
+// contains matches, using the bloom bits index to skip the uninteresting ones.
+// The returned block number is the one the logs were found in, or end if none.
+func (f *Filter) indexedLogs(ctx context.Context, size, start, end uint64) ([]*types.Log, uint64, error) {
+	fetch := func(ctx context.Context, bit uint, section uint64) ([]byte, error) {
+		return f.fetchBloomBits(ctx, bit, section, size)
+	}
+	matches := make(chan uint64, 64)
+	session, err := bloombits.NewMatcher(size, f.matcherFilters()).Start(ctx, start, end, fetch, matches)
+	if err != nil {
+		return nil, end, err
+	}
+	defer session.Close()
+
+	for number := range matches {
+		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
+		if header == nil || err != nil {
+			return nil, end, err
+		}
+		// The index might yield false positives, filter the actual logs
+		logs, err := f.checkMatches(ctx, header)
+		if err != nil {
+			return nil, end, err
+		}
+		if len(logs) > 0 {
+			return logs, number, nil
+		}
 	}
+	return nil, end, session.Error()
+}
 
-	return nil, end
+// checkMatches checks the header bloom of a block against the filter criteria
+// and if it potentially matches, returns the matching logs of the block.
+func (f *Filter) checkMatches(ctx context.Context, header *types.Header) ([]*types.Log, error) {
+	if !f.bloomFilter(header.Bloom) {
+		return nil, nil
+	}
+	receipts, err := f.backend.GetReceipts(ctx, header.Hash())
+	if err != nil {
+		return nil, err
+	}
+	var unfiltered []*types.Log
+	for _, receipt := range receipts {
+		unfiltered = append(unfiltered, ([]*types.Log)(receipt.Logs)...)
+	}
+	return filterLogs(unfiltered, nil, nil, f.addresses, f.topics), nil
 }
 
 func (f *Filter) getLogs(ctx context.Context, start, end uint64) (logs []*types.Log, blockNumber uint64, err error) {
 	for i := start; i <= end; i++ {
+		// Abort if the request was cancelled, e.g. by the RPC execution timeout
+		if err := ctx.Err(); err != nil {
+			return nil, end, err
+		}
 		blockNumber := rpc.BlockNumber(i)
 		header, err := f.backend.HeaderByNumber(ctx, blockNumber)
 		if header == nil || err != nil {
 			return logs, end, err
 		}
 
-		// Use bloom filtering to see if this block is interesting given the
-		// current parameters
-		if f.bloomFilter(header.Bloom) {
-			// Get the logs of the block
-			receipts, err := f.backend.GetReceipts(ctx, header.Hash())
-			if err != nil {
-				return nil, end, err
-			}
-			var unfiltered []*types.Log
-			for _, receipt := range receipts {
-				unfiltered = append(unfiltered, ([]*types.Log)(receipt.Logs)...)
-			}
-			logs = filterLogs(unfiltered, nil, nil, f.addresses, f.topics)
-			if len(logs) > 0 {
-				return logs, uint64(blockNumber), nil
-			}
+		logs, err = f.checkMatches(ctx, header)
+		if err != nil {
+			return nil, end, err
+		}
+		if len(logs) > 0 {
+			return logs, uint64(blockNumber), nil
 		}
 	}
 
@@ -26,6 +26,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/bloombits"
 	"github.com/EarthDollar/go-earthdollar/core/types"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
@@ -42,8 +43,52 @@ func makeReceipt(addr common.Address) *types.Receipt {
 	return receipt
 }
 
-func BenchmarkMipmaps(b *testing.B) {
-	dir, err := ioutil.TempDir("", "mipmap")
+// testBloomBitsBlocks is the bloom bits section size used by the tests.
+const testBloomBitsBlocks = 256
+
+// indexedTestBackend is a test backend with a bloom bits index covering the
+// first sections of the chain.
+type indexedTestBackend struct {
+	*testBackend
+	sections uint64
+}
+
+func (b *indexedTestBackend) BloomStatus() (uint64, uint64) {
+	return testBloomBitsBlocks, b.sections
+}
+
+// testBloomIndex generates the bloom bits index of all complete sections of the
+// canonical chain in the database, returning the number of indexed sections.
+func testBloomIndex(t testing.TB, db ethdb.Database, size uint64) uint64 {
+	head := core.GetBlockNumber(db, core.GetHeadBlockHash(db))
+
+	sections := (head + 1) / size
+	for section := uint64(0); section < sections; section++ {
+		gen, err := bloombits.NewGenerator(uint(size))
+		if err != nil {
+			t.Fatalf("failed to create bloom generator: %v", err)
+		}
+		var last common.Hash
+		for i := uint64(0); i < size; i++ {
+			number := section*size + i
+			header := core.GetHeader(db, core.GetCanonicalHash(db, number), number)
+			if err := gen.AddBloom(uint(i), header.Bloom); err != nil {
+				t.Fatalf("failed to add bloom of block #%d: %v", number, err)
+			}
+			last = header.Hash()
+		}
+		for bit := 0; bit < types.BloomBitLength; bit++ {
+			bits, _ := gen.Bitset(uint(bit))
+			if err := core.WriteBloomBits(db, uint(bit), section, last, bits); err != nil {
+				t.Fatalf("failed to write bloom bits: %v", err)
+			}
+		}
+	}
+	return sections
+}
+
+func BenchmarkFilters(b *testing.B) {
+	dir, err := ioutil.TempDir("", "filtertest")
 	if err != nil {
 		b.Fatal(err)
 	}
@@ -89,7 +134,6 @@ func BenchmarkMipmaps(b *testing.B) {
 		if err != nil {
 			b.Fatal(err)
 		}
-		core.WriteMipmapBloom(db, uint64(i+1), receipts)
 	})
 	for i, block := range chain {
 		core.WriteBlock(db, block)
@@ -103,9 +147,10 @@ func BenchmarkMipmaps(b *testing.B) {
 			b.Fatal("error writing block receipts:", err)
 		}
 	}
+	indexed := &indexedTestBackend{backend, testBloomIndex(b, db, params.BloomBitsBlocks)}
 	b.ResetTimer()
 
-	filter := New(backend, true)
+	filter := New(indexed)
 	filter.SetAddresses([]common.Address{addr1, addr2, addr3, addr4})
 	filter.SetBeginBlock(0)
 	filter.SetEndBlock(-1)
@@ -119,7 +164,7 @@ func BenchmarkMipmaps(b *testing.B) {
 }
 
 func TestFilters(t *testing.T) {
-	dir, err := ioutil.TempDir("", "mipmap")
+	dir, err := ioutil.TempDir("", "filtertest")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -190,10 +235,6 @@ func TestFilters(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		// i is used as block number for the writes but since the i
-		// starts at 0 and block 0 (genesis) is already present increment
-		// by one
-		core.WriteMipmapBloom(db, uint64(i+1), receipts)
 	})
 	for i, block := range chain {
 		core.WriteBlock(db, block)
@@ -208,82 +249,87 @@ func TestFilters(t *testing.T) {
 		}
 	}
 
-	filter := New(backend, true)
-	filter.SetAddresses([]common.Address{addr})
-	filter.SetTopics([][]common.Hash{{hash1, hash2, hash3, hash4}})
-	filter.SetBeginBlock(0)
-	filter.SetEndBlock(-1)
+	// Run the queries both by checking every header bloom and through the bloom
+	// bits index, with the last sections of the chain not indexed yet
+	indexed := &indexedTestBackend{backend, testBloomIndex(t, db, testBloomBitsBlocks)}
+	for _, backend := range []Backend{backend, indexed} {
+		filter := New(backend)
+		filter.SetAddresses([]common.Address{addr})
+		filter.SetTopics([][]common.Hash{{hash1, hash2, hash3, hash4}})
+		filter.SetBeginBlock(0)
+		filter.SetEndBlock(-1)
 
-	logs, _ := filter.Find(context.Background())
-	if len(logs) != 4 {
-		t.Error("expected 4 log, got", len(logs))
-	}
+		logs, _ := filter.Find(context.Background())
+		if len(logs) != 4 {
+			t.Error("expected 4 log, got", len(logs))
+		}
 
-	filter = New(backend, true)
-	filter.SetAddresses([]common.Address{addr})
-	filter.SetTopics([][]common.Hash{{hash3}})
-	filter.SetBeginBlock(900)
-	filter.SetEndBlock(999)
-	logs, _ = filter.Find(context.Background())
-	if len(logs) != 1 {
-		t.Error("expected 1 log, got", len(logs))
-	}
-	if len(logs) > 0 && logs[0].Topics[0] != hash3 {
-		t.Errorf("expected log[0].Topics[0] to be %x, got %x", hash3, logs[0].Topics[0])
-	}
+		filter = New(backend)
+		filter.SetAddresses([]common.Address{addr})
+		filter.SetTopics([][]common.Hash{{hash3}})
+		filter.SetBeginBlock(900)
+		filter.SetEndBlock(999)
+		logs, _ = filter.Find(context.Background())
+		if len(logs) != 1 {
+			t.Error("expected 1 log, got", len(logs))
+		}
+		if len(logs) > 0 && logs[0].Topics[0] != hash3 {
+			t.Errorf("expected log[0].Topics[0] to be %x, got %x", hash3, logs[0].Topics[0])
+		}
 
-	filter = New(backend, true)
-	filter.SetAddresses([]common.Address{addr})
-	filter.SetTopics([][]common.Hash{{hash3}})
-	filter.SetBeginBlock(990)
-	filter.SetEndBlock(-1)
-	logs, _ = filter.Find(context.Background())
-	if len(logs) != 1 {
-		t.Error("expected 1 log, got", len(logs))
-	}
-	if len(logs) > 0 && logs[0].Topics[0] != hash3 {
-		t.Errorf("expected log[0].Topics[0] to be %x, got %x", hash3, logs[0].Topics[0])
-	}
+		filter = New(backend)
+		filter.SetAddresses([]common.Address{addr})
+		filter.SetTopics([][]common.Hash{{hash3}})
+		filter.SetBeginBlock(990)
+		filter.SetEndBlock(-1)
+		logs, _ = filter.Find(context.Background())
+		if len(logs) != 1 {
+			t.Error("expected 1 log, got", len(logs))
+		}
+		if len(logs) > 0 && logs[0].Topics[0] != hash3 {
+			t.Errorf("expected log[0].Topics[0] to be %x, got %x", hash3, logs[0].Topics[0])
+		}
 
-	filter = New(backend, true)
-	filter.SetTopics([][]common.Hash{{hash1, hash2}})
-	filter.SetBeginBlock(1)
-	filter.SetEndBlock(10)
+		filter = New(backend)
+		filter.SetTopics([][]common.Hash{{hash1, hash2}})
+		filter.SetBeginBlock(1)
+		filter.SetEndBlock(10)
 
-	logs, _ = filter.Find(context.Background())
-	if len(logs) != 2 {
-		t.Error("expected 2 log, got", len(logs))
-	}
+		logs, _ = filter.Find(context.Background())
+		if len(logs) != 2 {
+			t.Error("expected 2 log, got", len(logs))
+		}
 
-	failHash := common.BytesToHash([]byte("fail"))
-	filter = New(backend, true)
-	filter.SetTopics([][]common.Hash{{failHash}})
-	filter.SetBeginBlock(0)
-	filter.SetEndBlock(-1)
+		failHash := common.BytesToHash([]byte("fail"))
+		filter = New(backend)
+		filter.SetTopics([][]common.Hash{{failHash}})
+		filter.SetBeginBlock(0)
+		filter.SetEndBlock(-1)
 
-	logs, _ = filter.Find(context.Background())
-	if len(logs) != 0 {
-		t.Error("expected 0 log, got", len(logs))
-	}
+		logs, _ = filter.Find(context.Background())
+		if len(logs) != 0 {
+			t.Error("expected 0 log, got", len(logs))
+		}
 
-	failAddr := common.BytesToAddress([]byte("failmenow"))
-	filter = New(backend, true)
-	filter.SetAddresses([]common.Address{failAddr})
-	filter.SetBeginBlock(0)
-	filter.SetEndBlock(-1)
+		failAddr := common.BytesToAddress([]byte("failmenow"))
+		filter = New(backend)
+		filter.SetAddresses([]common.Address{failAddr})
+		filter.SetBeginBlock(0)
+		filter.SetEndBlock(-1)
 
-	logs, _ = filter.Find(context.Background())
-	if len(logs) != 0 {
-		t.Error("expected 0 log, got", len(logs))
-	}
+		logs, _ = filter.Find(context.Background())
+		if len(logs) != 0 {
+			t.Error("expected 0 log, got", len(logs))
+		}
 
-	filter = New(backend, true)
-	filter.SetTopics([][]common.Hash{{failHash}, {hash1}})
-	filter.SetBeginBlock(0)
-	filter.SetEndBlock(-1)
+		filter = New(backend)
+		filter.SetTopics([][]common.Hash{{failHash}, {hash1}})
+		filter.SetBeginBlock(0)
+		filter.SetEndBlock(-1)
 
-	logs, _ = filter.Find(context.Background())
-	if len(logs) != 0 {
-		t.Error("expected 0 log, got", len(logs))
+		logs, _ = filter.Find(context.Background())
+		if len(logs) != 0 {
+			t.Error("expected 0 log, got", len(logs))
+		}
 	}
 }
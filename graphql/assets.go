@@ -0,0 +1,306 @@
+// Code generated by go-bindata.
+// sources:
+// assets/explorer.css
+// assets/explorer.js
+// assets/graphiql.html
+// assets/index.html
+// DO NOT EDIT!
+
+package graphql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("Read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes []byte
+	info  os.FileInfo
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+func (fi bindataFileInfo) IsDir() bool {
+	return false
+}
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var _explorerCss = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9d\x53\xcb\x72\xdb\x30\x0c\x3c\x47\x5f\xa1\x99\x4c\x6f\x51\x47\x0f\xc7\xb5\x95\xaf\x81\x44\x50\x66\x4b\x91\x2a\x48\xc5\x72\x3b\xf9\xf7\x82\x94\x25\x3f\xea\x1c\xda\x8b\x3d\x5a\x02\x8b\xdd\x25\x78\xf0\xbd\x7e\x49\x1b\x2b\x4e\xe9\xef\xe4\xe9\x80\xaa\x3b\xf8\x3a\x2d\xf2\xfc\xcb\x5b\xf2\x91\x2c\xb8\x50\x6e\xd0\x70\xaa\x53\xa9\x71\x7a\x4b\x9e\xc2\x5f\x26\x14\x61\xeb\x95\x35\x75\xda\x5a\x3d\xf6\x86\x0f\x7a\xa0\x4e\x31\x90\x87\x22\x6b\x7c\x26\xa1\x57\x9a\x1b\x1d\x18\x97\x39\x24\x25\xf9\xa4\x81\xf6\x47\x47\x76\x34\xa2\x4e\x9f\x0b\x2c\x8b\x72\xcf\x28\x93\x58\x62\x40\xbc\x8a\x9d\xc0\x30\xfe\x80\x20\x90\x1e\x09\x00\xad\x3a\x93\x29\x8f\xbd\xe3\xe9\x68\x3c\x12\xa3\xdf\x47\xe7\x95\x3c\x65\x2d\x4f\x66\x8c\xa7\x0e\xd0\x62\xd6\xa0\x3f\x22\x06\x79\x03\x08\xa1\x4c\xc7\xfa\xd2\x72\x33\x4c\xf7\x52\xca\x5d\xd9\x56\xdb\x38\xb9\x08\x53\xa3\x03\xa7\x7e\x61\x9d\x96\x79\x2c\x8f\xc8\xf1\x9c\x92\xb1\xd4\x83\x8e\xe5\x65\x28\x5f\xcc\xef\x86\xe9\x12\xc0\xdc\x5e\x6c\x3e\x6f\x5f\x8d\xef\x60\x9f\xef\xdb\x98\xfb\xe8\xbd\x35\x81\x73\x55\xbc\x65\xd2\xb3\x88\xc6\x12\xc7\x12\x08\x0c\xae\x9f\x19\x81\x50\x23\xa7\xf1\xc0\xd7\x06\xf6\x88\xe2\x6a\x92\x94\xf2\xa1\xbe\x76\x24\x17\x0a\x06\xab\xe6\x48\x17\x29\x35\x5f\x00\x34\x1a\x45\xd0\x74\xc3\x5d\x41\x25\x37\xcd\x55\xaf\x40\x09\xa3\xf6\xa1\xb7\x07\x65\x3e\x5b\x1f\x9e\x19\xf6\x45\x99\x6c\x59\xba\x3c\xb4\x3c\xa3\x50\xde\x92\xfb\xa7\xad\xbb\xf0\xad\x71\x15\xdb\x60\xe8\x23\xf1\x38\x79\x20\x84\x97\x74\x20\x8c\xe2\xed\x14\x3c\xc7\xa2\x73\x72\x0c\xdd\xad\xee\x85\xa6\xfc\xbf\xc4\x97\x4d\xfa\x6b\xa9\x6f\x5f\x45\x6f\x8d\x8d\x3b\x7a\x77\x1b\x55\x64\x24\x9c\x3f\xe7\xa9\x9c\xcd\xcf\x11\x29\xbe\xc7\xd9\x70\x15\xc1\x77\x20\x15\xae\xc6\x5d\x0e\x8a\x78\xc0\xed\x7c\x0f\x37\xe8\x6a\x32\xc4\x73\xf5\x13\x3c\xdb\x77\x24\xa9\xed\xb1\x4e\x61\xf4\xf6\x8a\xe1\x2b\x12\xd9\xf8\x0a\x17\x33\x98\x6f\xdb\x6f\xaf\xa1\xe4\x0f\xd1\xe6\x35\x79\x3f\x04\x00\x00")
+
+func explorerCssBytes() ([]byte, error) {
+	return bindataRead(
+		_explorerCss,
+		"explorer.css",
+	)
+}
+
+func explorerCss() (*asset, error) {
+	bytes, err := explorerCssBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "explorer.css", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _explorerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7d\x54\x4d\x6f\xdb\x30\x0c\x3d\x3b\xbf\x82\xbb\x54\x36\x96\x39\xc0\x8e\x0d\x82\x01\xdb\x0a\xb4\x5b\x3f\x86\xb5\x87\x5d\x55\x8b\x4e\xb4\x3a\x92\x6b\xd1\x4d\x83\x36\xff\x7d\xd4\x47\xe2\x34\x1d\x72\x08\x22\x9b\x8f\x14\xf9\xde\xa3\x27\x13\xb8\xd2\x46\x2f\x65\x03\x8f\x3d\x76\x6b\x40\xa5\xc9\x76\xd0\x5a\x47\xda\xcc\x81\x2c\xd0\x02\x61\x32\xef\x64\xbb\x78\x6c\x00\x8d\x6a\xad\x36\x04\xb6\x0e\x01\x63\x15\x96\xa3\xbc\xee\x4d\x45\xda\x1a\xc8\x0b\x78\x19\x65\xa2\x77\x08\x8e\x3a\x5d\x91\x98\x8e\x46\xd9\x93\xec\x52\xf9\x19\x28\x5b\xf5\x4b\x34\x54\xce\x91\xce\x1a\xf4\xc7\xaf\xeb\x0b\x95\x8b\x00\x10\xc5\x34\xc2\xf9\xa7\xe5\x7d\x83\xee\x58\xca\x0e\xb4\x4b\xeb\xd0\xf5\x0d\x1d\xcb\x89\x88\x5d\xc2\x7d\x4f\xc4\x7d\x1f\x4b\xe8\x8d\x47\x8f\xb2\xc9\x04\xdc\xc2\xae\xf8\x0e\xa3\xb0\x73\x20\xfd\x6d\xad\x35\x3c\x2b\x33\x26\x0d\x60\xd7\xf1\x61\x89\xce\xc9\x39\x82\x36\x81\xa1\xd4\x51\x2b\x0d\x13\x95\xed\x88\xf2\x95\x72\xc2\x67\x1a\x43\x2d\x75\x83\x2a\x10\x97\x45\x74\xe9\x03\xdf\xac\x21\x6e\x82\x5b\xf3\x4f\xd3\x21\x58\x35\xd2\xb9\x6b\xb9\x44\x0e\xc5\x5c\xf8\x02\x22\x5c\x2e\xe0\x14\x84\xf0\xd8\x38\x57\xa9\xb4\xf3\x04\xa9\x00\x6d\x1c\x72\x68\x13\x47\xe1\xb1\x00\x9f\xb1\xea\x89\x39\xf6\x8d\x46\x81\x52\xd7\xc9\x06\x2b\x4d\x8b\xf0\x3c\xd7\x4f\x68\x06\x51\xf6\x27\xe1\x42\x51\xf5\xad\x6e\x5e\x32\xd3\x37\x8d\x6f\x43\xd7\x90\x0f\x59\x4f\xb2\xe9\xb1\x64\x5f\x2c\x39\xe3\xc3\x6c\xc6\xbd\xc6\xcc\x8c\xf8\xea\x70\xc8\x52\x81\x1f\xb7\x37\xd7\x65\xcb\x67\x3c\xcc\xf7\xca\x65\xd9\x06\x2a\x49\xd5\x02\x72\x9e\x3b\xd5\xc8\x02\xa7\xe2\xc2\x30\x4c\xab\xa1\x59\xa6\x04\x3e\x7a\x71\xca\x24\xcd\x18\xa8\xdb\xd6\x61\x52\xa9\xef\x4c\xac\x39\x8a\xbf\xf7\xdc\x79\xbc\xb7\x40\x18\xf1\x79\xd1\xf9\x09\x71\x05\x7f\xae\x2e\xcf\x89\xda\xdf\xc8\xdc\x39\xca\x43\x45\x8e\x96\xb6\x45\x93\x8b\x5f\x37\xb7\x77\x62\x0c\x62\xbb\x3c\x62\x17\x77\x48\x29\xe7\x1c\x25\x7b\x29\x17\x49\xec\x4f\x77\xeb\x16\x7d\x8e\x6c\xdb\x46\xf3\x84\x4c\xf0\xe4\xaf\xb3\x66\xc8\xb5\xa6\xb1\x32\xe8\xf9\x76\xe7\x0e\x38\x0c\xde\x7c\xcb\xa3\xcf\xde\x3a\xf6\x8e\x2d\x95\x08\x08\xac\x05\x98\xdf\x58\x33\xd7\xf5\x3a\xf7\xb0\x71\x10\x71\x0c\x9f\x8b\x71\x28\x56\x06\x87\xb9\xa0\x5b\xcf\x1b\x50\x6b\xc3\xae\x3d\x2e\xc6\xe1\x95\xfb\xcc\x07\xb6\x87\xa9\xe2\xf2\xfc\x67\xac\xa8\x6a\xe2\x2b\x19\x5e\xec\x15\xda\x0c\xac\x1a\x75\x38\xc8\x4b\x70\xf5\x69\x34\x77\xf4\xcf\x78\xdf\x19\xde\x6d\x9b\xa2\x48\x6b\x91\x84\x97\x4a\x9d\xb1\xdd\xe9\x52\x3b\x16\xc5\xcb\x53\xb1\x18\x0f\x7c\x29\x7b\xdd\x63\x77\xdf\x8a\xf7\xc8\x07\x5c\x2b\xbb\x32\x8c\x1d\x06\x41\x0f\x89\xd3\xf8\x7d\x08\x8f\x25\x03\x61\xe6\x57\xe0\x8c\x95\xe7\xc5\x3d\x39\xd9\x46\x2a\xea\x9a\x9f\x1c\x7d\x7d\x85\xf8\x62\x89\x24\xf9\x45\x91\x08\x89\x2f\xdb\x2e\xfc\x7f\xc7\x5a\xf2\x47\x21\x7a\x2f\x0b\xbb\x38\x8d\x36\xde\xf0\x61\x53\xf8\xc7\x7f\x3b\xc0\xfd\xaf\xe5\x05\x00\x00")
+
+func explorerJsBytes() ([]byte, error) {
+	return bindataRead(
+		_explorerJs,
+		"explorer.js",
+	)
+}
+
+func explorerJs() (*asset, error) {
+	bytes, err := explorerJsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "explorer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _graphiqlHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x95\x54\x51\x6f\xd3\x30\x10\x7e\xee\x7e\xc5\x11\x84\x92\x4a\x6d\xb2\xbd\xa1\x34\xe9\x03\xdb\x84\x40\xb0\x0e\xb6\x17\x1e\x8d\x7d\x69\x3c\x12\x3b\xb3\xaf\x1d\xd5\xd4\xff\x8e\xed\xc4\x05\x21\x10\xe2\xa9\xbe\xbb\xef\xbe\xfb\xfa\xf9\x9c\xea\xc5\xd5\xe6\xf2\xfe\xcb\xed\x35\xb4\xd4\x77\xeb\xb3\xca\xff\x40\xc7\xd4\xb6\x4e\x50\x25\x3e\x81\x4c\xac\xcf\x66\x55\x8f\xc4\x80\xb7\xcc\x58\xa4\x3a\xd9\x51\xb3\x7c\x9d\x9c\xf2\x8a\xf5\x58\x27\x7b\x89\x4f\x83\x36\x94\x00\xd7\x8a\x50\x39\xdc\x93\x14\xd4\xd6\x02\xf7\x92\xe3\x32\x04\x0b\x90\x4a\x92\x64\xdd\xd2\x72\xd6\x61\x7d\x11\x58\x48\x52\x87\xeb\xb7\x86\x0d\xad\xfc\xf4\xa1\x2a\xc6\xd8\x15\x3a\xa9\xbe\x81\xc1\xae\x4e\x2c\x1d\x3a\xb4\x2d\xa2\xe3\x6f\x0d\x36\x75\x52\x6c\x03\xfe\xb1\x3b\x1d\x72\x6e\x6d\xe0\x0b\x60\x77\x98\xf9\xff\xb3\x80\xaf\x5a\x1c\x16\xf0\x32\xc2\xe0\x19\x5a\x94\xdb\x96\x4a\xb8\x38\x3f\x7f\xb5\x82\x9e\x99\xad\x54\x25\x9c\xaf\x40\xef\xd1\x34\x9d\x7e\x2a\xa1\x95\x42\xa0\x5a\xc1\xd1\xf1\x15\x13\x61\x55\x8c\x7e\x54\x9e\xd1\x0f\x12\x72\x0f\x52\xd4\x49\xa4\x4e\xd6\x55\xe1\x72\x41\x03\x37\x72\x20\xb0\x86\xff\x2a\xd5\x20\xe3\x94\xf7\x52\xe5\x0f\xd6\x83\x47\xd4\x3f\xf0\x4b\xa1\xfb\xff\xe8\x39\xd9\xf1\xf7\x16\xef\x4d\x51\x40\x83\xc4\x5b\x34\x30\x68\x4b\x16\xa8\x45\x78\xdc\xa1\x91\x68\x41\x37\x21\x44\x21\x49\x1b\x20\x1d\xa2\x91\xd9\x19\x88\x4a\x0c\x5a\x2a\x8a\x30\xa5\x05\xe6\x8e\xb2\xd9\x29\x4e\x52\xab\x48\x9c\x0d\xcc\xb0\xde\xce\xe1\xd9\x15\x67\x06\x69\x67\xa6\x5a\x96\x46\xb2\x74\x31\x56\x67\x6e\x95\x5a\x2d\x4a\x48\x6f\x37\x77\xf7\xe9\x22\xe4\xbc\xdd\x68\x6c\x09\xcf\xe9\xe5\xb8\x54\xcb\xfb\xc3\x80\xa9\x43\xb1\x61\xe8\x24\x67\x7e\x5c\xf1\x60\xb5\x4a\x8f\x63\x8b\xbf\x9a\x12\xde\xdf\x6d\x6e\x72\x4b\x46\xaa\xad\x6c\x0e\x51\x47\x40\x1c\xe7\xb9\xd3\xac\xb2\x93\xd8\xcc\xa0\x1d\xb4\xb2\x38\xe9\x8c\x42\x63\x3a\x27\xfc\x4e\xd9\x7c\xf5\xe7\x66\x3f\x2f\x36\x92\x39\x4c\xa7\xc8\x11\x74\x0c\xfe\xd9\x8c\xc0\x40\x32\x3b\x82\x13\xce\x5b\xc8\xd0\x98\xf9\x6f\x1d\x1e\x36\xa1\xc6\x81\x3e\xf0\xc7\xcf\x7e\x15\xae\x36\x1f\x73\xe3\xec\x77\xde\xfa\x6a\xc8\xe5\xdc\x6d\x09\xe1\x75\x87\xbd\x33\x28\x8b\xcf\xc8\xf9\x3a\x5d\x43\x19\xef\xe3\x38\x3a\x20\x34\xdf\x79\x68\xbe\x45\x9a\xba\xde\x1c\xde\x89\x2c\x8d\x9b\x93\xce\x1d\xcc\x0f\xfe\xb9\x39\x55\x31\xee\xbc\x7b\x02\xe1\x53\xf1\x03\xd7\x80\x7c\xad\x3b\x04\x00\x00")
+
+func graphiqlHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_graphiqlHtml,
+		"graphiql.html",
+	)
+}
+
+func graphiqlHtml() (*asset, error) {
+	bytes, err := graphiqlHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "graphiql.html", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _indexHtml = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6d\x52\xc1\x6e\xdb\x30\x0c\x3d\x37\x5f\xc1\xf1\xb4\x61\x4b\x8d\xf6\xb4\x83\xe4\x4b\x17\xec\x52\xa0\xdb\x30\x0c\xd8\x51\x96\x99\x49\x8b\x2c\xbb\x14\x9d\x36\x28\xf2\xef\x93\x64\xaf\xd8\x80\x9c\x4c\x3e\x52\xef\x3d\x92\x56\x6f\x3e\x3d\xdc\x7d\xff\xf9\x65\x07\x4e\x86\xd0\x6e\x54\xf9\x40\x30\xf1\x97\x46\x8a\x58\x00\x32\x7d\xbb\xb9\x52\x03\x89\x01\xeb\x0c\x27\x12\x8d\xb3\xec\xb7\x1f\xf1\x15\x8f\x66\x20\x8d\x47\x4f\x4f\xd3\xc8\x82\x60\xc7\x28\x14\x73\xdf\x93\xef\xc5\xe9\x9e\x8e\xde\xd2\xb6\x26\x1f\xc0\x47\x2f\xde\x84\x6d\xb2\x26\x90\xbe\xa9\x2c\xe2\x25\x50\xfb\x99\xcd\xe4\xbe\xde\xc3\xee\x79\x0a\x23\x13\xab\x66\xc1\x73\x43\xf0\xf1\x00\x4c\x41\x63\x92\x53\xa0\xe4\x88\xb2\x8e\x63\xda\x6b\x6c\x68\xed\xbf\xb6\x29\x15\xcf\xcd\x62\x5a\x75\x63\x7f\x2a\x8f\x4b\x4a\x9c\xa3\x1c\xde\x5c\x50\xc9\x60\xa9\x75\xb3\xc8\x18\xc1\xf7\x1a\x79\x8e\x08\x55\x5c\xe3\xb7\x39\xc2\xe3\x4c\x7c\x82\xb7\x77\xc2\xe1\xfd\x2e\xcf\xc6\xef\xb0\xcd\xb8\x6a\x96\x37\x45\xa4\x79\x55\x51\x83\xf1\xb1\x32\x26\xb2\xe2\x57\x4a\xea\xbd\x8c\x5c\xfc\x5d\xe5\x8a\xd0\xb3\x18\x26\x53\x4b\x95\x1d\x21\x4d\x14\x82\x75\x64\x0f\x1a\xf7\x26\x24\xc2\xf6\x65\x03\xd0\x85\xd1\x1e\xa0\x44\x00\x71\x1e\x3a\xe2\x1a\x3a\x93\x5c\x0d\xc4\x0f\x94\xc4\x0c\x53\xce\xce\x9b\x73\x5e\xda\xca\xbd\x28\xb9\xdb\xf6\x87\x61\x6f\xba\xbc\xb5\x6c\xf2\xf6\x82\xfe\xf1\x6f\xfd\x92\x07\x98\x82\xb1\xe4\xc6\x90\x87\xd3\xf8\x72\xc6\xf6\x7f\x05\xd5\xac\x53\xd6\x64\x62\x5a\x16\x48\x69\x0e\x52\x7a\x33\x52\xd7\xb3\x2e\x45\x25\xcb\x7e\x12\x48\x6c\xff\xbd\xdc\xef\x54\x7a\x97\x5a\xb9\xe0\x72\xba\xec\xb7\xfe\x96\x7f\x00\x81\x27\x92\x25\xa7\x02\x00\x00")
+
+func indexHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_indexHtml,
+		"index.html",
+	)
+}
+
+func indexHtml() (*asset, error) {
+	bytes, err := indexHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "index.html", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[cannonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"explorer.css":  explorerCss,
+	"explorer.js":   explorerJs,
+	"graphiql.html": graphiqlHtml,
+	"index.html":    indexHtml,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
+// then AssetDir("data") would return []string{"foo.txt", "img"}
+// AssetDir("data/img") would return []string{"a.png", "b.png"}
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		cannonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(cannonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"explorer.css":  &bintree{explorerCss, map[string]*bintree{}},
+	"explorer.js":   &bintree{explorerJs, map[string]*bintree{}},
+	"graphiql.html": &bintree{graphiqlHtml, map[string]*bintree{}},
+	"index.html":    &bintree{indexHtml, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	err = os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RestoreAssets restores an asset under the given directory recursively
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	cannonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(cannonicalName, "/")...)...)
+}
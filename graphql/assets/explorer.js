@@ -0,0 +1,54 @@
+// Minimal query editor posting to the /graphql endpoint of the node.
+(function () {
+	'use strict';
+
+	var query = document.getElementById('query');
+	var variables = document.getElementById('variables');
+	var result = document.getElementById('result');
+	var button = document.getElementById('run');
+
+	// show renders a response or an error message in the result pane.
+	function show(text, failed) {
+		result.textContent = text;
+		result.className = failed ? 'error' : '';
+		button.disabled = false;
+	}
+
+	// run executes the query in the editor with the given variables.
+	function run() {
+		var vars = null;
+		if (variables.value.trim() !== '') {
+			try {
+				vars = JSON.parse(variables.value);
+			} catch (err) {
+				show('Invalid variables: ' + err.message, true);
+				return;
+			}
+		}
+		button.disabled = true;
+
+		var xhr = new XMLHttpRequest();
+		xhr.open('POST', '/graphql');
+		xhr.setRequestHeader('Content-Type', 'application/json');
+		xhr.onload = function () {
+			try {
+				var resp = JSON.parse(xhr.responseText);
+				show(JSON.stringify(resp, null, 2), resp.errors !== undefined);
+			} catch (err) {
+				show(xhr.responseText, true);
+			}
+		};
+		xhr.onerror = function () {
+			show('Request failed', true);
+		};
+		xhr.send(JSON.stringify({query: query.value, variables: vars}));
+	}
+
+	button.addEventListener('click', run);
+	document.addEventListener('keydown', function (event) {
+		if (event.key === 'Enter' && (event.ctrlKey || event.metaKey)) {
+			event.preventDefault();
+			run();
+		}
+	});
+})();
@@ -0,0 +1,665 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"golang.org/x/net/context"
+)
+
+// gqlError is an error reported in a GraphQL response.
+type gqlError struct {
+	Message   string        `json:"message"`
+	Locations []location    `json:"locations,omitempty"`
+	Path      []interface{} `json:"path,omitempty"`
+}
+
+// response is the result of a GraphQL request.
+type response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*gqlError `json:"errors,omitempty"`
+}
+
+// maxQueryDepth is the maximum nesting of the fields selected by a query,
+// bounding the work of recursive selections like block{transactions{block}}.
+const maxQueryDepth = 16
+
+// errorResponse creates a response failing the whole request.
+func errorResponse(err error) *response {
+	gerr := &gqlError{Message: err.Error()}
+	if serr, ok := err.(*syntaxError); ok {
+		gerr.Message, gerr.Locations = serr.msg, []location{serr.loc}
+	}
+	return &response{Errors: []*gqlError{gerr}}
+}
+
+// resultMap is a JSON object retaining the order of its fields, as the
+// response fields must be in the order they were requested.
+type resultMap struct {
+	keys   []string
+	values []interface{}
+}
+
+func (m *resultMap) set(key string, value interface{}) {
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the fields in order.
+func (m *resultMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(m.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// execute runs a GraphQL request against the schema. Variables are expected as
+// decoded from JSON, with numbers as json.Number.
+func (s *schema) execute(ctx context.Context, query, opName string, variables map[string]interface{}) *response {
+	doc, err := parse(query)
+	if err != nil {
+		return errorResponse(err)
+	}
+	op, err := doc.operation(opName)
+	if err != nil {
+		return errorResponse(err)
+	}
+	depth, err := doc.depth(op.selections, make(map[string]bool))
+	if err != nil {
+		return errorResponse(err)
+	}
+	if depth > maxQueryDepth {
+		return errorResponse(fmt.Errorf("query depth %d exceeds limit %d", depth, maxQueryDepth))
+	}
+	root := s.query
+	if op.kind == "mutation" {
+		if root = s.mutation; root == nil {
+			return errorResponse(fmt.Errorf("schema does not support mutations"))
+		}
+	}
+	e := &executor{schema: s, doc: doc, op: op}
+	if e.vars, err = e.coerceVariables(op, variables); err != nil {
+		return errorResponse(err)
+	}
+	// Fields are resolved one by one, so mutations are executed serially as
+	// required by the spec
+	resp := new(response)
+	if data, propagate := e.selectionSet(ctx, root, nil, op.selections, nil); !propagate {
+		resp.Data = data
+	}
+	resp.Errors = e.errs
+	return resp
+}
+
+// operation selects the operation to execute from a document.
+func (doc *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, fmt.Errorf("operation name required for documents with multiple operations")
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// depth returns the nesting of the fields in a selection set, looking through
+// fragments. Fragments spreading themselves are rejected, as they would let a
+// query recurse without bounds.
+func (doc *document) depth(sels []selection, expanding map[string]bool) (int, error) {
+	max := 0
+	for _, sel := range sels {
+		var (
+			depth int
+			err   error
+		)
+		switch sel := sel.(type) {
+		case *astField:
+			depth, err = doc.depth(sel.selections, expanding)
+			depth++
+
+		case *fragmentSpread:
+			frag := doc.fragments[sel.name]
+			if frag == nil {
+				continue // reported by the executor if reached
+			}
+			if expanding[sel.name] {
+				return 0, fmt.Errorf("fragment %q spreads itself", sel.name)
+			}
+			expanding[sel.name] = true
+			depth, err = doc.depth(frag.selections, expanding)
+			delete(expanding, sel.name)
+
+		case *inlineFragment:
+			depth, err = doc.depth(sel.selections, expanding)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if depth > max {
+			max = depth
+		}
+	}
+	return max, nil
+}
+
+// executor holds the state of a single request execution.
+type executor struct {
+	schema *schema
+	doc    *document
+	op     *operation
+	vars   map[string]interface{}
+	errs   []*gqlError
+}
+
+// fieldError records an error raised while resolving a field.
+func (e *executor) fieldError(field *astField, path []interface{}, format string, args ...interface{}) {
+	e.errs = append(e.errs, &gqlError{
+		Message:   fmt.Sprintf(format, args...),
+		Locations: []location{field.loc},
+		Path:      path,
+	})
+}
+
+// resolveTypeRef looks up the input type referenced by a variable definition.
+func (e *executor) resolveTypeRef(ref *typeRef) (gqlType, error) {
+	var t gqlType
+	if ref.elem != nil {
+		elem, err := e.resolveTypeRef(ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = &list{elem}
+	} else {
+		switch named := e.schema.types[ref.name].(type) {
+		case *scalar, *enum, *inputObject:
+			t = named
+		case nil:
+			return nil, fmt.Errorf("unknown type %q", ref.name)
+		default:
+			return nil, fmt.Errorf("type %q is not an input type", ref.name)
+		}
+	}
+	if ref.nonNull {
+		t = &nonNull{t}
+	}
+	return t, nil
+}
+
+// coerceVariables validates the provided variable values against the variable
+// definitions of an operation, applying the defaults.
+func (e *executor) coerceVariables(op *operation, values map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for _, def := range op.vars {
+		typ, err := e.resolveTypeRef(def.typ)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", def.name, err)
+		}
+		value, ok := values[def.name]
+		if !ok {
+			if def.def == nil {
+				if _, required := typ.(*nonNull); required {
+					return nil, fmt.Errorf("variable $%s of type %s is required", def.name, def.typ)
+				}
+				continue
+			}
+			value = def.def
+		}
+		if vars[def.name], err = e.coerce(typ, value); err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", def.name, err)
+		}
+	}
+	return vars, nil
+}
+
+// coerce converts an input value, either a query literal or a value decoded from
+// JSON, into the Go representation of the given input type.
+func (e *executor) coerce(typ gqlType, value interface{}) (interface{}, error) {
+	if ref, ok := value.(varRef); ok {
+		v, ok := e.vars[string(ref)]
+		if !ok && e.vars != nil {
+			if _, declared := e.declared(string(ref)); !declared {
+				return nil, fmt.Errorf("undefined variable $%s", ref)
+			}
+		}
+		if _, required := typ.(*nonNull); required && v == nil {
+			return nil, fmt.Errorf("variable $%s must not be null", ref)
+		}
+		return v, nil
+	}
+	if t, ok := typ.(*nonNull); ok {
+		if value == nil {
+			return nil, fmt.Errorf("expected non-null %s", t.of)
+		}
+		return e.coerce(t.of, value)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	switch t := typ.(type) {
+	case *list:
+		var items []interface{}
+		switch v := value.(type) {
+		case []astValue:
+			for _, item := range v {
+				items = append(items, item)
+			}
+		case []interface{}:
+			items = v
+		default:
+			items = []interface{}{value}
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			coerced, err := e.coerce(t.of, item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = coerced
+		}
+		return result, nil
+
+	case *inputObject:
+		fields := make(map[string]interface{})
+		switch v := value.(type) {
+		case objLit:
+			for _, field := range v {
+				fields[field.name] = field.value
+			}
+		case map[string]interface{}:
+			fields = v
+		default:
+			return nil, fmt.Errorf("expected %s object, got %v", t.name, value)
+		}
+		for name := range fields {
+			if argByName(t.fields, name) == nil {
+				return nil, fmt.Errorf("unknown field %q of %s", name, t.name)
+			}
+		}
+		result := make(map[string]interface{})
+		for _, def := range t.fields {
+			v, ok := fields[def.name]
+			coerced, present, err := e.coerceArg(def, v, ok)
+			if err != nil {
+				return nil, fmt.Errorf("field %q of %s: %v", def.name, t.name, err)
+			}
+			if present {
+				result[def.name] = coerced
+			}
+		}
+		return result, nil
+
+	case *enum:
+		var name string
+		switch v := value.(type) {
+		case enumLit:
+			name = string(v)
+		case string:
+			name = v
+		}
+		for _, valid := range t.values {
+			if valid == name {
+				return name, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid %s value %v", t.name, value)
+
+	case *scalar:
+		switch v := value.(type) {
+		case intLit:
+			value = json.Number(v)
+		case floatLit:
+			value = json.Number(v)
+		case enumLit, objLit, []astValue, map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("expected %s, got %v", t.name, value)
+		}
+		return t.parse(value)
+	}
+	return nil, fmt.Errorf("%s is not an input type", typ)
+}
+
+// declared looks up the definition of a variable in the executed operation.
+func (e *executor) declared(name string) (*varDef, bool) {
+	for _, def := range e.op.vars {
+		if def.name == name {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// coerceArg coerces the value of an argument or input field, applying the
+// declared default if it was not provided. The returned flag reports whether
+// the argument has a value at all.
+func (e *executor) coerceArg(def *argDef, value interface{}, provided bool) (interface{}, bool, error) {
+	// An argument referring to an unset variable counts as not provided
+	if ref, ok := value.(varRef); ok && provided {
+		if _, set := e.vars[string(ref)]; !set {
+			if _, declared := e.declared(string(ref)); declared {
+				provided = false
+			}
+		}
+	}
+	if !provided {
+		if def.def != "" {
+			lit, err := parseValue(def.def)
+			if err != nil {
+				return nil, false, err
+			}
+			coerced, err := e.coerce(def.typ, lit)
+			return coerced, true, err
+		}
+		if _, required := def.typ.(*nonNull); required {
+			return nil, false, fmt.Errorf("required value of type %s missing", def.typ)
+		}
+		return nil, false, nil
+	}
+	coerced, err := e.coerce(def.typ, value)
+	return coerced, true, err
+}
+
+// coerceArgs coerces the arguments of a selected field.
+func (e *executor) coerceArgs(defs []*argDef, args []*argument) (map[string]interface{}, error) {
+	for _, arg := range args {
+		if argByName(defs, arg.name) == nil {
+			return nil, fmt.Errorf("unknown argument %q", arg.name)
+		}
+	}
+	result := make(map[string]interface{})
+	for _, def := range defs {
+		var (
+			value    interface{}
+			provided bool
+		)
+		for _, arg := range args {
+			if arg.name == def.name {
+				value, provided = arg.value, true
+			}
+		}
+		coerced, present, err := e.coerceArg(def, value, provided)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", def.name, err)
+		}
+		if present {
+			result[def.name] = coerced
+		}
+	}
+	return result, nil
+}
+
+func argByName(defs []*argDef, name string) *argDef {
+	for _, def := range defs {
+		if def.name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+// skipped evaluates the @skip and @include directives of a selection.
+func (e *executor) skipped(dirs []*directive) (bool, error) {
+	for _, dir := range dirs {
+		def := directiveByName(dir.name)
+		if def == nil {
+			return false, fmt.Errorf("unknown directive @%s", dir.name)
+		}
+		args, err := e.coerceArgs(def.args, dir.args)
+		if err != nil {
+			return false, fmt.Errorf("directive @%s: %v", dir.name, err)
+		}
+		if cond := args["if"].(bool); (dir.name == "skip") == cond {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fieldGroup is the list of fields selected under the same response key.
+type fieldGroup struct {
+	key    string
+	fields []*astField
+}
+
+// collectFields flattens the fragments of a selection set into the groups of
+// fields to resolve on an object.
+func (e *executor) collectFields(obj *object, sels []selection, groups []*fieldGroup, visited map[string]bool) ([]*fieldGroup, error) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *astField:
+			if skip, err := e.skipped(sel.directives); err != nil || skip {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			found := false
+			for _, group := range groups {
+				if group.key == sel.key() {
+					group.fields = append(group.fields, sel)
+					found = true
+					break
+				}
+			}
+			if !found {
+				groups = append(groups, &fieldGroup{key: sel.key(), fields: []*astField{sel}})
+			}
+
+		case *fragmentSpread:
+			if skip, err := e.skipped(sel.directives); err != nil || skip {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if visited[sel.name] {
+				continue
+			}
+			visited[sel.name] = true
+
+			frag := e.doc.fragments[sel.name]
+			if frag == nil {
+				return nil, fmt.Errorf("unknown fragment %q", sel.name)
+			}
+			if frag.on != obj.name {
+				continue
+			}
+			var err error
+			if groups, err = e.collectFields(obj, frag.selections, groups, visited); err != nil {
+				return nil, err
+			}
+
+		case *inlineFragment:
+			if skip, err := e.skipped(sel.directives); err != nil || skip {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if sel.on != "" && sel.on != obj.name {
+				continue
+			}
+			var err error
+			if groups, err = e.collectFields(obj, sel.selections, groups, visited); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return groups, nil
+}
+
+// selectionSet resolves the selected fields of an object. The returned flag is
+// set if a non-null field resolved to null, nulling out the whole object.
+func (e *executor) selectionSet(ctx context.Context, obj *object, source interface{}, sels []selection, path []interface{}) (*resultMap, bool) {
+	groups, err := e.collectFields(obj, sels, nil, make(map[string]bool))
+	if err != nil {
+		e.errs = append(e.errs, &gqlError{Message: err.Error(), Path: path})
+		return nil, true
+	}
+	result := new(resultMap)
+	for _, group := range groups {
+		value, propagate := e.field(ctx, obj, source, group.fields, extendPath(path, group.key))
+		if propagate {
+			return nil, true
+		}
+		result.set(group.key, value)
+	}
+	return result, false
+}
+
+// field resolves a single field of an object. The returned flag is set if the
+// field is non-null but could not be resolved.
+func (e *executor) field(ctx context.Context, obj *object, source interface{}, fields []*astField, path []interface{}) (interface{}, bool) {
+	field := fields[0]
+	if field.name == "__typename" {
+		return obj.name, false
+	}
+	def := obj.field(field.name)
+	if def == nil && obj == e.schema.query {
+		def = e.schema.metaField(field.name)
+	}
+	if def == nil {
+		e.fieldError(field, path, "cannot query field %q on type %q", field.name, obj.name)
+		return nil, false
+	}
+	_, required := def.typ.(*nonNull)
+
+	args, err := e.coerceArgs(def.args, field.args)
+	if err != nil {
+		e.fieldError(field, path, "%v", err)
+		return nil, required
+	}
+	value, err := def.resolve(ctx, source, args)
+	if err != nil {
+		e.fieldError(field, path, "%v", err)
+		return nil, required
+	}
+	return e.complete(ctx, def.typ, fields, value, path)
+}
+
+// complete converts a resolved value into its response representation according
+// to the field type, resolving sub-selections of objects.
+func (e *executor) complete(ctx context.Context, typ gqlType, fields []*astField, value interface{}, path []interface{}) (interface{}, bool) {
+	if t, ok := typ.(*nonNull); ok {
+		errs := len(e.errs)
+		result, _ := e.complete(ctx, t.of, fields, value, path)
+		if result == nil {
+			if len(e.errs) == errs {
+				e.fieldError(fields[0], path, "cannot return null for non-null field")
+			}
+			return nil, true
+		}
+		return result, false
+	}
+	if isNil(value) {
+		return nil, false
+	}
+	switch t := typ.(type) {
+	case *scalar:
+		result, err := t.serialize(value)
+		if err != nil {
+			e.fieldError(fields[0], path, "%v", err)
+			return nil, false
+		}
+		return result, false
+
+	case *enum:
+		if name, ok := value.(string); ok {
+			for _, valid := range t.values {
+				if name == valid {
+					return name, false
+				}
+			}
+		}
+		e.fieldError(fields[0], path, "invalid %s value %v", t.name, value)
+		return nil, false
+
+	case *list:
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice {
+			e.fieldError(fields[0], path, "expected list, got %T", value)
+			return nil, false
+		}
+		result := make([]interface{}, items.Len())
+		for i := range result {
+			item, propagate := e.complete(ctx, t.of, fields, items.Index(i).Interface(), extendPath(path, i))
+			if propagate {
+				return nil, false
+			}
+			result[i] = item
+		}
+		return result, false
+
+	case *object:
+		var sels []selection
+		for _, field := range fields {
+			sels = append(sels, field.selections...)
+		}
+		if len(sels) == 0 {
+			e.fieldError(fields[0], path, "field of type %s must have a selection of subfields", t.name)
+			return nil, false
+		}
+		result, propagate := e.selectionSet(ctx, t, value, sels, path)
+		if propagate {
+			return nil, false
+		}
+		return result, false
+	}
+	e.fieldError(fields[0], path, "invalid output type %s", typ)
+	return nil, false
+}
+
+// isNil reports whether a resolved value is null. Nil slices are not null, they
+// are empty lists.
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Interface, reflect.Func:
+		return v.IsNil()
+	}
+	return false
+}
+
+// extendPath returns a copy of the response path with a new element appended.
+func extendPath(path []interface{}, elem interface{}) []interface{} {
+	extended := make([]interface{}, len(path)+1)
+	copy(extended, path)
+	extended[len(path)] = elem
+	return extended
+}
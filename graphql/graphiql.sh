@@ -0,0 +1,22 @@
+#!/bin/sh
+#
+# Downloads the pinned GraphiQL release and its React dependencies into the
+# embedded assets. The page served by the node switches from the minimal
+# explorer to GraphiQL once these are present, after rerunning go generate.
+
+set -e
+
+GRAPHIQL_VERSION=0.11.11
+REACT_VERSION=15.6.2
+
+dir=$(dirname "$0")/assets/graphiql
+mkdir -p "$dir"
+
+fetch() {
+	curl -fsSL -o "$dir/$2" "https://unpkg.com/$1"
+}
+
+fetch "graphiql@$GRAPHIQL_VERSION/graphiql.min.js" graphiql.min.js
+fetch "graphiql@$GRAPHIQL_VERSION/graphiql.css" graphiql.css
+fetch "react@$REACT_VERSION/dist/react.min.js" react.min.js
+fetch "react-dom@$REACT_VERSION/dist/react-dom.min.js" react-dom.min.js
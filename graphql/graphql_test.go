@@ -0,0 +1,370 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// testItem is the source value of the Item type of the test schema.
+type testItem struct {
+	id       int
+	name     string
+	children []*testItem
+}
+
+// newTestSchema creates a small schema exercising the features of the executor.
+func newTestSchema() *schema {
+	items := map[int]*testItem{
+		1: {id: 1, name: "one"},
+		2: {id: 2, name: "two"},
+	}
+	items[3] = &testItem{id: 3, name: "three", children: []*testItem{items[1], items[2]}}
+
+	kind := &enum{name: "Kind", values: []string{"SMALL", "LARGE"}}
+	item := &object{name: "Item", desc: "Item is a test item."}
+	item.fields = []*fieldDef{
+		{name: "id", typ: &nonNull{intType}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testItem).id, nil
+		}},
+		{name: "name", typ: stringType, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testItem).name, nil
+		}},
+		{name: "kind", typ: kind, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			if len(source.(*testItem).children) > 0 {
+				return "LARGE", nil
+			}
+			return "SMALL", nil
+		}},
+		{name: "children", typ: &nonNull{&list{&nonNull{item}}}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return source.(*testItem).children, nil
+		}},
+		{name: "broken", typ: &nonNull{stringType}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return nil, errors.New("broken")
+		}},
+	}
+	filter := &inputObject{name: "Filter", fields: []*argDef{
+		{name: "ids", typ: &list{&nonNull{intType}}},
+		{name: "limit", typ: intType, def: "10"},
+	}}
+	query := &object{name: "Query", fields: []*fieldDef{
+		{name: "item", typ: item, args: []*argDef{{name: "id", typ: &nonNull{intType}}}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			if it, ok := items[args["id"].(int)]; ok {
+				return it, nil
+			}
+			return nil, nil
+		}},
+		{name: "items", typ: &list{item}, args: []*argDef{{name: "filter", typ: filter}}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			var result []*testItem
+			f, _ := args["filter"].(map[string]interface{})
+			if f == nil || f["ids"] == nil {
+				return []*testItem{items[1], items[2], items[3]}, nil
+			}
+			for _, id := range f["ids"].([]interface{}) {
+				if len(result) < f["limit"].(int) {
+					result = append(result, items[id.(int)])
+				}
+			}
+			return result, nil
+		}},
+		{name: "echo", typ: stringType, args: []*argDef{{name: "text", typ: stringType, def: `"default"`}}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return args["text"], nil
+		}},
+	}}
+	mutation := &object{name: "Mutation", fields: []*fieldDef{
+		{name: "rename", typ: &nonNull{item}, args: []*argDef{{name: "id", typ: &nonNull{intType}}, {name: "name", typ: &nonNull{stringType}}}, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			it := items[args["id"].(int)]
+			it.name = args["name"].(string)
+			return it, nil
+		}},
+	}}
+	return newSchema(query, mutation)
+}
+
+// execTest runs a query against the test schema, returning the JSON response.
+func execTest(t *testing.T, query string, vars string) string {
+	var variables map[string]interface{}
+	if vars != "" {
+		if err := decodeJSON([]byte(vars), &variables); err != nil {
+			t.Fatalf("invalid variables: %v", err)
+		}
+	}
+	out, err := json.Marshal(newTestSchema().execute(context.Background(), query, "", variables))
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	return string(out)
+}
+
+var executionTests = []struct {
+	query string
+	vars  string
+	want  string
+}{
+	// Simple queries, aliases and ordering
+	{
+		query: `{ item(id: 1) { name id } }`,
+		want:  `{"data":{"item":{"name":"one","id":1}}}`,
+	},
+	{
+		query: `query { first: item(id: 1) { name } second: item(id: 2) { name, kind } missing: item(id: 9) { name } }`,
+		want:  `{"data":{"first":{"name":"one"},"second":{"name":"two","kind":"SMALL"},"missing":null}}`,
+	},
+	// Nested lists
+	{
+		query: `{ item(id: 3) { kind children { id children { id } } } }`,
+		want:  `{"data":{"item":{"kind":"LARGE","children":[{"id":1,"children":[]},{"id":2,"children":[]}]}}}`,
+	},
+	// Variables, defaults and input objects
+	{
+		query: `query($id: Int!) { item(id: $id) { name } }`,
+		vars:  `{"id": 2}`,
+		want:  `{"data":{"item":{"name":"two"}}}`,
+	},
+	{
+		query: `query($id: Int = 3) { item(id: $id) { name } }`,
+		want:  `{"data":{"item":{"name":"three"}}}`,
+	},
+	{
+		query: `query($text: String) { a: echo b: echo(text: "lit") c: echo(text: $text) }`,
+		want:  `{"data":{"a":"default","b":"lit","c":"default"}}`,
+	},
+	{
+		query: `{ items(filter: {ids: [2, 1], limit: 1}) { id } }`,
+		want:  `{"data":{"items":[{"id":2}]}}`,
+	},
+	{
+		query: `query($f: Filter) { items(filter: $f) { id } }`,
+		vars:  `{"f": {"ids": 3}}`,
+		want:  `{"data":{"items":[{"id":3}]}}`,
+	},
+	// Fragments and directives
+	{
+		query: `{ item(id: 1) { ...names ... on Item { id } } } fragment names on Item { name __typename }`,
+		want:  `{"data":{"item":{"name":"one","__typename":"Item","id":1}}}`,
+	},
+	{
+		query: `query($yes: Boolean!) { item(id: 1) { id @skip(if: $yes) name @include(if: $yes) kind @include(if: false) } }`,
+		vars:  `{"yes": true}`,
+		want:  `{"data":{"item":{"name":"one"}}}`,
+	},
+	// Mutations
+	{
+		query: `mutation { rename(id: 1, name: "uno") { name } }`,
+		want:  `{"data":{"rename":{"name":"uno"}}}`,
+	},
+	// Resolver errors and null propagation
+	{
+		query: `{ item(id: 1) { id broken } other: item(id: 2) { id } }`,
+		want:  `{"data":{"item":null,"other":{"id":2}},"errors":[{"message":"broken","locations":[{"line":1,"column":20}],"path":["item","broken"]}]}`,
+	},
+	{
+		query: `{ item(id: 3) { children { broken } } }`,
+		want:  `{"data":{"item":null},"errors":[{"message":"broken","locations":[{"line":1,"column":28}],"path":["item","children",0,"broken"]}]}`,
+	},
+	// Request errors
+	{
+		query: `{ item(id: 1) { id }`,
+		want:  `{"errors":[{"message":"expected name, found end of input","locations":[{"line":1,"column":21}]}]}`,
+	},
+	{
+		query: `query($id: Int!) { item(id: $id) { id } }`,
+		want:  `{"errors":[{"message":"variable $id of type Int! is required"}]}`,
+	},
+	{
+		query: `query($id: Int!) { item(id: $id) { id } }`,
+		vars:  `{"id": "one"}`,
+		want:  `{"errors":[{"message":"variable $id: expected Int, got one"}]}`,
+	},
+	{
+		query: `{ item(id: 3) { ...nested } } fragment nested on Item { children { ... on Item { ...nested } } }`,
+		want:  `{"errors":[{"message":"fragment \"nested\" spreads itself"}]}`,
+	},
+	{
+		query: `{ item(id: 1) { unknown } }`,
+		want:  `{"data":{"item":{"unknown":null}},"errors":[{"message":"cannot query field \"unknown\" on type \"Item\"","locations":[{"line":1,"column":17}],"path":["item","unknown"]}]}`,
+	},
+	// Introspection
+	{
+		query: `{ __type(name: "Filter") { kind name inputFields { name defaultValue type { kind ofType { kind name } } } } }`,
+		want:  `{"data":{"__type":{"kind":"INPUT_OBJECT","name":"Filter","inputFields":[{"name":"ids","defaultValue":null,"type":{"kind":"LIST","ofType":{"kind":"NON_NULL","name":null}}},{"name":"limit","defaultValue":"10","type":{"kind":"SCALAR","ofType":null}}]}}}`,
+	},
+	{
+		query: `{ __schema { queryType { name } mutationType { name } directives { name } } }`,
+		want:  `{"data":{"__schema":{"queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},"directives":[{"name":"skip"},{"name":"include"}]}}}`,
+	},
+	{
+		query: `{ __type(name: "Kind") { enumValues { name } } }`,
+		want:  `{"data":{"__type":{"enumValues":[{"name":"SMALL"},{"name":"LARGE"}]}}}`,
+	},
+}
+
+func TestExecution(t *testing.T) {
+	for i, tt := range executionTests {
+		if have := execTest(t, tt.query, tt.vars); have != tt.want {
+			t.Errorf("test %d: response mismatch\nquery: %s\nhave:  %s\nwant:  %s", i, tt.query, have, tt.want)
+		}
+	}
+}
+
+// Tests that queries nesting fields beyond the depth limit are rejected, also
+// when the nesting is hidden in fragments.
+func TestQueryDepth(t *testing.T) {
+	nest := func(depth int) string {
+		return "{ item(id: 3) " + strings.Repeat("{ children ", depth-2) + "{ id }" + strings.Repeat(" }", depth-2) + " }"
+	}
+	if have, want := execTest(t, nest(maxQueryDepth), ""), `{"data":{"item":`; !strings.HasPrefix(have, want) {
+		t.Errorf("query at depth limit failed: %s", have)
+	}
+	want := fmt.Sprintf(`{"errors":[{"message":"query depth %d exceeds limit %d"}]}`, maxQueryDepth+1, maxQueryDepth)
+	if have := execTest(t, nest(maxQueryDepth+1), ""); have != want {
+		t.Errorf("query beyond depth limit: have %s, want %s", have, want)
+	}
+	frags := "{ item(id: 3) { ...f0 } }"
+	for i := 0; i < maxQueryDepth-1; i++ {
+		frags += fmt.Sprintf(" fragment f%d on Item { children { ...f%d } }", i, i+1)
+	}
+	frags += fmt.Sprintf(" fragment f%d on Item { id }", maxQueryDepth-1)
+	if have := execTest(t, frags, ""); have != want {
+		t.Errorf("fragments beyond depth limit: have %s, want %s", have, want)
+	}
+}
+
+// Tests that the introspection query used by GraphiQL and other tools can be
+// executed against the Ethereum schema.
+func TestIntrospection(t *testing.T) {
+	query := `query IntrospectionQuery {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			types { ...FullType }
+			directives { name description locations args { ...InputValue } }
+		}
+	}
+	fragment FullType on __Type {
+		kind name description
+		fields(includeDeprecated: true) { name description args { ...InputValue } type { ...TypeRef } isDeprecated deprecationReason }
+		inputFields { ...InputValue }
+		interfaces { ...TypeRef }
+		enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+		possibleTypes { ...TypeRef }
+	}
+	fragment InputValue on __InputValue { name description type { ...TypeRef } defaultValue }
+	fragment TypeRef on __Type { kind name ofType { kind name ofType { kind name ofType { kind name } } } }`
+
+	resp := newEthSchema(nil).execute(context.Background(), query, "IntrospectionQuery", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("introspection failed: %s", resp.Errors[0].Message)
+	}
+	out, _ := json.Marshal(resp)
+	for _, name := range []string{"Block", "Transaction", "Account", "Log", "Pending", "FilterCriteria", "BigInt"} {
+		if !strings.Contains(string(out), `"name":"`+name+`"`) {
+			t.Errorf("type %s missing from schema", name)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(newHandler(newTestSchema()))
+	defer server.Close()
+
+	tests := []struct {
+		method, contentType, body, query string
+		code                             int
+		want                             string
+	}{
+		{"POST", "application/json", `{"query": "query($id: Int!) { item(id: $id) { name } }", "variables": {"id": 1}}`, "", 200, `{"data":{"item":{"name":"one"}}}`},
+		{"POST", "application/graphql", `{ item(id: 2) { name } }`, "", 200, `{"data":{"item":{"name":"two"}}}`},
+		{"GET", "", "", "?query=%7Bitem(id:3)%7Bname%7D%7D", 200, `{"data":{"item":{"name":"three"}}}`},
+		{"POST", "application/json", `{"query": "{"}`, "", 400, `{"errors":[{"message":"expected name, found end of input","locations":[{"line":1,"column":2}]}]}`},
+		{"PUT", "", "", "", 405, "method not allowed\n"},
+	}
+	for i, tt := range tests {
+		req, _ := http.NewRequest(tt.method, server.URL+"/graphql"+tt.query, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("test %d: request failed: %v", i, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != tt.code {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, res.StatusCode, tt.code)
+		}
+		if string(body) != tt.want {
+			t.Errorf("test %d: response mismatch: have %s, want %s", i, string(body), tt.want)
+		}
+	}
+}
+
+// Tests that the explorer page and its assets are served from the binary.
+func TestWebHandler(t *testing.T) {
+	server := httptest.NewServer(newHandler(newTestSchema()))
+	defer server.Close()
+
+	tests := []struct {
+		path, accept string
+		code         int
+		contentType  string
+	}{
+		{"/", "", 200, "text/html; charset=utf-8"},
+		{"/graphql/ui", "", 200, "text/html; charset=utf-8"},
+		{"/graphql", "text/html", 200, "text/html; charset=utf-8"},
+		{"/explorer.js", "", 200, "application/javascript"},
+		{"/explorer.css", "", 200, "text/css"},
+		{"/graphiql.html", "", 200, "text/html; charset=utf-8"},
+		{"/missing.js", "", 404, ""},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", server.URL+tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", tt.path, err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.StatusCode != tt.code {
+			t.Errorf("%s: status mismatch: have %d, want %d", tt.path, res.StatusCode, tt.code)
+			continue
+		}
+		if tt.code != 200 {
+			continue
+		}
+		if have := res.Header.Get("Content-Type"); have != tt.contentType {
+			t.Errorf("%s: content type mismatch: have %q, want %q", tt.path, have, tt.contentType)
+		}
+		if strings.Contains(string(body), "://") {
+			t.Errorf("%s: references external resources", tt.path)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"golang.org/x/net/context"
+)
+
+// directiveDef is a directive supported by the executor.
+type directiveDef struct {
+	name, desc string
+	locations  []string
+	args       []*argDef
+}
+
+// directives are the directives supported by the executor, @skip and @include.
+var directives = []*directiveDef{
+	{
+		name:      "skip",
+		desc:      "Directs the executor to skip this field or fragment when the `if` argument is true.",
+		locations: []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:      []*argDef{{name: "if", desc: "Skipped when true.", typ: &nonNull{booleanType}}},
+	},
+	{
+		name:      "include",
+		desc:      "Directs the executor to include this field or fragment only when the `if` argument is true.",
+		locations: []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:      []*argDef{{name: "if", desc: "Included when true.", typ: &nonNull{booleanType}}},
+	},
+}
+
+func directiveByName(name string) *directiveDef {
+	for _, dir := range directives {
+		if dir.name == name {
+			return dir
+		}
+	}
+	return nil
+}
+
+// The introspection types, describing a schema through GraphQL itself.
+var (
+	schemaMeta     = &object{name: "__Schema", desc: "A GraphQL Schema defines the capabilities of a GraphQL server."}
+	typeMeta       = &object{name: "__Type", desc: "The fundamental unit of any GraphQL Schema is the type."}
+	fieldMeta      = &object{name: "__Field", desc: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	inputValueMeta = &object{name: "__InputValue", desc: "Arguments provided to Fields or Directives and the input fields of an InputObject are represented as Input Values."}
+	enumValueMeta  = &object{name: "__EnumValue", desc: "One possible value for a given Enum."}
+	directiveMeta  = &object{name: "__Directive", desc: "A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document."}
+
+	typeKindMeta = &enum{
+		name:   "__TypeKind",
+		desc:   "An enum describing what kind of type a given `__Type` is.",
+		values: []string{"SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL"},
+	}
+	directiveLocationMeta = &enum{
+		name:   "__DirectiveLocation",
+		desc:   "A Directive can be adjacent to many parts of the GraphQL language.",
+		values: []string{"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+	}
+)
+
+// The fields are assigned in init, as the introspection types are self-referential.
+func init() {
+	includeDeprecated := []*argDef{{name: "includeDeprecated", typ: booleanType, def: "false"}}
+
+	schemaMeta.fields = []*fieldDef{
+		{
+			name: "types",
+			desc: "A list of all types supported by this server.",
+			typ:  &nonNull{&list{&nonNull{typeMeta}}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				s := source.(*schema)
+				types := make([]gqlType, len(s.names))
+				for i, name := range s.names {
+					types[i] = s.types[name]
+				}
+				return types, nil
+			},
+		},
+		{
+			name: "queryType",
+			desc: "The type that query operations will be rooted at.",
+			typ:  &nonNull{typeMeta},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*schema).query, nil
+			},
+		},
+		{
+			name: "mutationType",
+			desc: "If this server supports mutation, the type that mutation operations will be rooted at.",
+			typ:  typeMeta,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if s := source.(*schema); s.mutation != nil {
+					return s.mutation, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			name: "subscriptionType",
+			desc: "If this server supports subscription, the type that subscription operations will be rooted at.",
+			typ:  typeMeta,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			name: "directives",
+			desc: "A list of all directives supported by this server.",
+			typ:  &nonNull{&list{&nonNull{directiveMeta}}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return directives, nil
+			},
+		},
+	}
+
+	typeMeta.fields = []*fieldDef{
+		{
+			name: "kind",
+			typ:  &nonNull{typeKindMeta},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				switch source.(type) {
+				case *scalar:
+					return "SCALAR", nil
+				case *object:
+					return "OBJECT", nil
+				case *enum:
+					return "ENUM", nil
+				case *inputObject:
+					return "INPUT_OBJECT", nil
+				case *list:
+					return "LIST", nil
+				default:
+					return "NON_NULL", nil
+				}
+			},
+		},
+		{
+			name: "name",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				switch source.(type) {
+				case *list, *nonNull:
+					return nil, nil
+				}
+				return source.(gqlType).String(), nil
+			},
+		},
+		{
+			name: "description",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				switch t := source.(type) {
+				case *scalar:
+					return description(t.desc), nil
+				case *object:
+					return description(t.desc), nil
+				case *enum:
+					return description(t.desc), nil
+				case *inputObject:
+					return description(t.desc), nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			name: "fields",
+			args: includeDeprecated,
+			typ:  &list{&nonNull{fieldMeta}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if t, ok := source.(*object); ok {
+					return t.fields, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			name: "interfaces",
+			typ:  &list{&nonNull{typeMeta}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if _, ok := source.(*object); ok {
+					return []gqlType{}, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			name: "possibleTypes",
+			typ:  &list{&nonNull{typeMeta}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			name: "enumValues",
+			args: includeDeprecated,
+			typ:  &list{&nonNull{enumValueMeta}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if t, ok := source.(*enum); ok {
+					return t.values, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			name: "inputFields",
+			typ:  &list{&nonNull{inputValueMeta}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if t, ok := source.(*inputObject); ok {
+					return t.fields, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			name: "ofType",
+			typ:  typeMeta,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				switch t := source.(type) {
+				case *list:
+					return t.of, nil
+				case *nonNull:
+					return t.of, nil
+				}
+				return nil, nil
+			},
+		},
+	}
+
+	fieldMeta.fields = []*fieldDef{
+		{
+			name: "name",
+			typ:  &nonNull{stringType},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*fieldDef).name, nil
+			},
+		},
+		{
+			name: "description",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return description(source.(*fieldDef).desc), nil
+			},
+		},
+		{
+			name: "args",
+			typ:  &nonNull{&list{&nonNull{inputValueMeta}}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*fieldDef).args, nil
+			},
+		},
+		{
+			name: "type",
+			typ:  &nonNull{typeMeta},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*fieldDef).typ, nil
+			},
+		},
+		notDeprecated,
+		noDeprecationReason,
+	}
+
+	inputValueMeta.fields = []*fieldDef{
+		{
+			name: "name",
+			typ:  &nonNull{stringType},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*argDef).name, nil
+			},
+		},
+		{
+			name: "description",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return description(source.(*argDef).desc), nil
+			},
+		},
+		{
+			name: "type",
+			typ:  &nonNull{typeMeta},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*argDef).typ, nil
+			},
+		},
+		{
+			name: "defaultValue",
+			desc: "A GraphQL-formatted string representing the default value for this input value.",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return description(source.(*argDef).def), nil
+			},
+		},
+	}
+
+	enumValueMeta.fields = []*fieldDef{
+		{
+			name: "name",
+			typ:  &nonNull{stringType},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source, nil
+			},
+		},
+		{
+			name: "description",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return nil, nil
+			},
+		},
+		notDeprecated,
+		noDeprecationReason,
+	}
+
+	directiveMeta.fields = []*fieldDef{
+		{
+			name: "name",
+			typ:  &nonNull{stringType},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*directiveDef).name, nil
+			},
+		},
+		{
+			name: "description",
+			typ:  stringType,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return description(source.(*directiveDef).desc), nil
+			},
+		},
+		{
+			name: "locations",
+			typ:  &nonNull{&list{&nonNull{directiveLocationMeta}}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*directiveDef).locations, nil
+			},
+		},
+		{
+			name: "args",
+			typ:  &nonNull{&list{&nonNull{inputValueMeta}}},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return source.(*directiveDef).args, nil
+			},
+		},
+	}
+}
+
+// Nothing in the schemas served is deprecated, but clients expect the fields.
+var (
+	notDeprecated = &fieldDef{
+		name: "isDeprecated",
+		typ:  &nonNull{booleanType},
+		resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return false, nil
+		},
+	}
+	noDeprecationReason = &fieldDef{
+		name: "deprecationReason",
+		typ:  stringType,
+		resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+			return nil, nil
+		},
+	}
+)
+
+// description converts an empty description into null.
+func description(desc string) interface{} {
+	if desc == "" {
+		return nil
+	}
+	return desc
+}
+
+// introspectionTypes returns the types describing a schema, registered into
+// every schema.
+func introspectionTypes() []gqlType {
+	return []gqlType{schemaMeta, typeMeta, fieldMeta, inputValueMeta, enumValueMeta, directiveMeta, typeKindMeta, directiveLocationMeta}
+}
+
+// metaField returns the __schema and __type fields implicitly available on the
+// query root of a schema.
+func (s *schema) metaField(name string) *fieldDef {
+	switch name {
+	case "__schema":
+		return &fieldDef{
+			name: "__schema",
+			typ:  &nonNull{schemaMeta},
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				return s, nil
+			},
+		}
+	case "__type":
+		return &fieldDef{
+			name: "__type",
+			args: []*argDef{{name: "name", typ: &nonNull{stringType}}},
+			typ:  typeMeta,
+			resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+				if t, ok := s.types[args["name"].(string)]; ok {
+					return t, nil
+				}
+				return nil, nil
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,652 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// This file contains a parser for the executable subset of the GraphQL query
+// language: operations, fragments, selections, arguments, variables and
+// directives. Schema definitions are not parsed, the schema is assembled in Go.
+
+// location is a position within the query source, reported in errors.
+type location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// syntaxError is returned if the query source cannot be parsed.
+type syntaxError struct {
+	msg string
+	loc location
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("syntax error at %d:%d: %s", e.loc.Line, e.loc.Column, e.msg)
+}
+
+// tokenKind enumerates the lexical tokens of the query language.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// token is a single lexical token along with its position.
+type token struct {
+	kind tokenKind
+	text string // punctuator, name, numeric literal or unescaped string value
+	loc  location
+}
+
+// lexer splits a query source into tokens.
+type lexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// advance moves the cursor n bytes forward, keeping track of the position.
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line, l.col = l.line+1, 1
+		} else {
+			l.col++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) errorf(format string, args ...interface{}) error {
+	return &syntaxError{msg: fmt.Sprintf(format, args...), loc: location{l.line, l.col}}
+}
+
+// next returns the next token from the source, skipping ignored characters.
+func (l *lexer) next() (token, error) {
+	// Skip whitespace, commas and comments
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.advance(1)
+			continue
+		}
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.advance(1)
+			}
+			continue
+		}
+		break
+	}
+	loc := location{l.line, l.col}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, loc: loc}, nil
+	}
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunct, text: "...", loc: loc}, nil
+
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		l.advance(1)
+		return token{kind: tokenPunct, text: string(c), loc: loc}, nil
+
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, text: l.src[start:l.pos], loc: loc}, nil
+
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+
+	case c == '"':
+		text, err := l.str()
+		if err != nil {
+			return token{}, err
+		}
+		return token{kind: tokenString, text: text, loc: loc}, nil
+	}
+	return token{}, l.errorf("unexpected character %q", c)
+}
+
+// number lexes an integer or float literal.
+func (l *lexer) number(loc location) (token, error) {
+	start, kind := l.pos, tokenInt
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	digits := func() int {
+		n := 0
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.advance(1)
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		return token{}, l.errorf("invalid number")
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if digits() == 0 {
+			return token{}, l.errorf("invalid number")
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if digits() == 0 {
+			return token{}, l.errorf("invalid number")
+		}
+	}
+	return token{kind: kind, text: l.src[start:l.pos], loc: loc}, nil
+}
+
+// str lexes a quoted string literal, returning its unescaped value.
+func (l *lexer) str() (string, error) {
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		l.advance(3)
+		end := strings.Index(l.src[l.pos:], `"""`)
+		if end < 0 {
+			return "", l.errorf("unterminated string")
+		}
+		value := l.src[l.pos : l.pos+end]
+		l.advance(end + 3)
+		return value, nil
+	}
+	l.advance(1)
+
+	var buf []byte
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return "", l.errorf("unterminated string")
+		}
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.advance(1)
+			return string(buf), nil
+
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return "", l.errorf("unterminated string")
+			}
+			esc := l.src[l.pos+1]
+			switch esc {
+			case '"', '\\', '/':
+				buf = append(buf, esc)
+			case 'b':
+				buf = append(buf, '\b')
+			case 'f':
+				buf = append(buf, '\f')
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'u':
+				if l.pos+6 > len(l.src) {
+					return "", l.errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 16)
+				if err != nil {
+					return "", l.errorf("invalid unicode escape")
+				}
+				var enc [utf8.UTFMax]byte
+				buf = append(buf, enc[:utf8.EncodeRune(enc[:], rune(code))]...)
+				l.advance(4)
+			default:
+				return "", l.errorf("invalid escape sequence \\%c", esc)
+			}
+			l.advance(2)
+
+		default:
+			buf = append(buf, c)
+			l.advance(1)
+		}
+	}
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// document is a parsed executable GraphQL document.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation is a single query or mutation in a document.
+type operation struct {
+	kind       string // "query" or "mutation"
+	name       string
+	vars       []*varDef
+	selections []selection
+	loc        location
+}
+
+// varDef is a variable declared by an operation.
+type varDef struct {
+	name string
+	typ  *typeRef
+	def  astValue // default value, nil if none declared
+	loc  location
+}
+
+// typeRef is a reference to a schema type within a query.
+type typeRef struct {
+	name    string   // named type, empty for lists
+	elem    *typeRef // element type of lists
+	nonNull bool
+}
+
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// selection is one of *astField, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+// astField is a field selected in a query.
+type astField struct {
+	alias, name string
+	args        []*argument
+	directives  []*directive
+	selections  []selection
+	loc         location
+}
+
+// key returns the response key of a field.
+func (f *astField) key() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// argument is a named argument of a field or directive.
+type argument struct {
+	name  string
+	value astValue
+	loc   location
+}
+
+// directive is a directive applied to a selection.
+type directive struct {
+	name string
+	args []*argument
+	loc  location
+}
+
+// fragmentSpread is a named fragment included into a selection set.
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        location
+}
+
+// inlineFragment is an anonymous fragment within a selection set.
+type inlineFragment struct {
+	on         string // type condition, empty if none
+	directives []*directive
+	selections []selection
+}
+
+// fragment is a named fragment definition.
+type fragment struct {
+	name       string
+	on         string
+	selections []selection
+	loc        location
+}
+
+// astValue is a literal input value in a query. It is one of nil, bool,
+// string, intLit, floatLit, enumLit, varRef, []astValue or objLit.
+type astValue interface{}
+
+type (
+	intLit   string
+	floatLit string
+	enumLit  string
+	varRef   string
+	objLit   []*argument
+)
+
+// parser is a recursive descent parser for executable documents.
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// parse parses a query source into a document.
+func parse(src string) (doc *document, err error) {
+	p := &parser{lex: newLexer(src)}
+	defer func() {
+		if r := recover(); r != nil {
+			if perr, ok := r.(*syntaxError); ok {
+				doc, err = nil, perr
+				return
+			}
+			panic(r)
+		}
+	}()
+	p.advance()
+	return p.document(), nil
+}
+
+// advance reads the next token, aborting the parse on lexical errors.
+func (p *parser) advance() {
+	tok, err := p.lex.next()
+	if err != nil {
+		panic(err)
+	}
+	p.tok = tok
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	panic(&syntaxError{msg: fmt.Sprintf(format, args...), loc: p.tok.loc})
+}
+
+// peek reports whether the current token is the given punctuator.
+func (p *parser) peek(punct string) bool {
+	return p.tok.kind == tokenPunct && p.tok.text == punct
+}
+
+// skip consumes the current token if it's the given punctuator.
+func (p *parser) skip(punct string) bool {
+	if p.peek(punct) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+// expect consumes the given punctuator or fails.
+func (p *parser) expect(punct string) {
+	if !p.skip(punct) {
+		p.fail("expected %q, found %s", punct, p.describe())
+	}
+}
+
+// name consumes a name token or fails.
+func (p *parser) name() string {
+	if p.tok.kind != tokenName {
+		p.fail("expected name, found %s", p.describe())
+	}
+	name := p.tok.text
+	p.advance()
+	return name
+}
+
+// describe returns a human readable description of the current token.
+func (p *parser) describe() string {
+	if p.tok.kind == tokenEOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", p.tok.text)
+}
+
+func (p *parser) document() *document {
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek("{"):
+			doc.operations = append(doc.operations, &operation{kind: "query", loc: p.tok.loc, selections: p.selectionSet()})
+
+		case p.tok.kind == tokenName && (p.tok.text == "query" || p.tok.text == "mutation"):
+			doc.operations = append(doc.operations, p.operation())
+
+		case p.tok.kind == tokenName && p.tok.text == "fragment":
+			frag := p.fragment()
+			if _, ok := doc.fragments[frag.name]; ok {
+				panic(&syntaxError{msg: fmt.Sprintf("duplicate fragment %q", frag.name), loc: frag.loc})
+			}
+			doc.fragments[frag.name] = frag
+
+		default:
+			p.fail("unexpected %s", p.describe())
+		}
+	}
+	if len(doc.operations) == 0 {
+		p.fail("no operations in document")
+	}
+	return doc
+}
+
+func (p *parser) operation() *operation {
+	op := &operation{kind: p.tok.text, loc: p.tok.loc}
+	p.advance()
+
+	if p.tok.kind == tokenName {
+		op.name = p.name()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			def := &varDef{loc: p.tok.loc}
+			p.expect("$")
+			def.name = p.name()
+			p.expect(":")
+			def.typ = p.typeRef()
+			if p.skip("=") {
+				def.def = p.value(true)
+			}
+			op.vars = append(op.vars, def)
+		}
+	}
+	p.directives() // operation directives are accepted but unused
+	op.selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) fragment() *fragment {
+	frag := &fragment{loc: p.tok.loc}
+	p.advance()
+
+	if frag.name = p.name(); frag.name == "on" {
+		p.fail("invalid fragment name \"on\"")
+	}
+	if p.tok.kind != tokenName || p.tok.text != "on" {
+		p.fail("expected \"on\", found %s", p.describe())
+	}
+	p.advance()
+	frag.on = p.name()
+	p.directives()
+	frag.selections = p.selectionSet()
+	return frag
+}
+
+func (p *parser) typeRef() *typeRef {
+	var t *typeRef
+	if p.skip("[") {
+		t = &typeRef{elem: p.typeRef()}
+		p.expect("]")
+	} else {
+		t = &typeRef{name: p.name()}
+	}
+	t.nonNull = p.skip("!")
+	return t
+}
+
+func (p *parser) selectionSet() []selection {
+	p.expect("{")
+	var sels []selection
+	for !p.skip("}") {
+		sels = append(sels, p.selection())
+	}
+	if len(sels) == 0 {
+		p.fail("empty selection set")
+	}
+	return sels
+}
+
+func (p *parser) selection() selection {
+	if p.peek("...") {
+		loc := p.tok.loc
+		p.advance()
+		if p.tok.kind == tokenName && p.tok.text != "on" {
+			return &fragmentSpread{name: p.name(), directives: p.directives(), loc: loc}
+		}
+		frag := new(inlineFragment)
+		if p.tok.kind == tokenName {
+			p.advance()
+			frag.on = p.name()
+		}
+		frag.directives = p.directives()
+		frag.selections = p.selectionSet()
+		return frag
+	}
+	field := &astField{loc: p.tok.loc}
+	field.name = p.name()
+	if p.skip(":") {
+		field.alias, field.name = field.name, p.name()
+	}
+	field.args = p.arguments(false)
+	field.directives = p.directives()
+	if p.peek("{") {
+		field.selections = p.selectionSet()
+	}
+	return field
+}
+
+func (p *parser) arguments(constant bool) []*argument {
+	var args []*argument
+	if p.skip("(") {
+		for !p.skip(")") {
+			arg := &argument{loc: p.tok.loc}
+			arg.name = p.name()
+			p.expect(":")
+			arg.value = p.value(constant)
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+func (p *parser) directives() []*directive {
+	var dirs []*directive
+	for p.peek("@") {
+		loc := p.tok.loc
+		p.advance()
+		dirs = append(dirs, &directive{name: p.name(), args: p.arguments(false), loc: loc})
+	}
+	return dirs
+}
+
+// value parses an input value literal. Variables are rejected in constant
+// contexts, i.e. in variable default values.
+func (p *parser) value(constant bool) astValue {
+	tok := p.tok
+	switch tok.kind {
+	case tokenInt:
+		p.advance()
+		return intLit(tok.text)
+	case tokenFloat:
+		p.advance()
+		return floatLit(tok.text)
+	case tokenString:
+		p.advance()
+		return tok.text
+	case tokenName:
+		p.advance()
+		switch tok.text {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return enumLit(tok.text)
+	}
+	switch {
+	case p.skip("$"):
+		if constant {
+			p.fail("variable not allowed in constant value")
+		}
+		return varRef(p.name())
+
+	case p.skip("["):
+		list := []astValue{}
+		for !p.skip("]") {
+			list = append(list, p.value(constant))
+		}
+		return list
+
+	case p.skip("{"):
+		obj := objLit{}
+		for !p.skip("}") {
+			field := &argument{loc: p.tok.loc}
+			field.name = p.name()
+			p.expect(":")
+			field.value = p.value(constant)
+			obj = append(obj, field)
+		}
+		return obj
+	}
+	p.fail("unexpected %s", p.describe())
+	return nil
+}
+
+// parseValue parses a standalone constant value literal, as used for the default
+// values of arguments in the schema definitions.
+func parseValue(src string) (value astValue, err error) {
+	p := &parser{lex: newLexer(src)}
+	defer func() {
+		if r := recover(); r != nil {
+			if perr, ok := r.(*syntaxError); ok {
+				value, err = nil, perr
+				return
+			}
+			panic(r)
+		}
+	}()
+	p.advance()
+	value = p.value(true)
+	if p.tok.kind != tokenEOF {
+		p.fail("unexpected %s", p.describe())
+	}
+	return value, nil
+}
@@ -0,0 +1,810 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/eth/filters"
+	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
+)
+
+// maxBlockRange is the maximum number of blocks a single blocks or logs query
+// may span.
+const maxBlockRange = 1024
+
+var errBlockRangeTooLarge = fmt.Errorf("block range exceeds %d blocks", maxBlockRange)
+
+// The argument accessors below return the value of an argument, reporting
+// whether it is set. Explicit nulls count as unset, values of the wrong type
+// (e.g. passed through a variable declared with another type) as errors.
+
+func argLong(args map[string]interface{}, name string) (uint64, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	n, ok := v.(uint64)
+	if !ok {
+		return 0, false, argTypeError(name, "Long", v)
+	}
+	return n, true, nil
+}
+
+func argInt(args map[string]interface{}, name string) (int, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return 0, false, nil
+	}
+	n, ok := v.(int)
+	if !ok {
+		return 0, false, argTypeError(name, "Int", v)
+	}
+	return n, true, nil
+}
+
+func argHash(args map[string]interface{}, name string) (common.Hash, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return common.Hash{}, false, nil
+	}
+	hash, ok := v.(common.Hash)
+	if !ok {
+		return common.Hash{}, false, argTypeError(name, "Bytes32", v)
+	}
+	return hash, true, nil
+}
+
+func argAddress(args map[string]interface{}, name string) (common.Address, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return common.Address{}, false, nil
+	}
+	addr, ok := v.(common.Address)
+	if !ok {
+		return common.Address{}, false, argTypeError(name, "Address", v)
+	}
+	return addr, true, nil
+}
+
+func argBytes(args map[string]interface{}, name string) ([]byte, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return nil, false, nil
+	}
+	data, ok := v.([]byte)
+	if !ok {
+		return nil, false, argTypeError(name, "Bytes", v)
+	}
+	return data, true, nil
+}
+
+func argObject(args map[string]interface{}, name string) (map[string]interface{}, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return nil, false, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false, argTypeError(name, "object", v)
+	}
+	return obj, true, nil
+}
+
+func argList(args map[string]interface{}, name string) ([]interface{}, bool, error) {
+	v, ok := args[name]
+	if !ok || v == nil {
+		return nil, false, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, false, argTypeError(name, "list", v)
+	}
+	return items, true, nil
+}
+
+func argTypeError(name, typ string, v interface{}) error {
+	return fmt.Errorf("argument %q: expected %s, got %v", name, typ, v)
+}
+
+// requiredArg returns the error of a required argument that is not set.
+func requiredArg(name string, err error) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("argument %q must not be null", name)
+}
+
+// account is the state of an account at a given block.
+type account struct {
+	backend ethapi.Backend
+	address common.Address
+	number  rpc.BlockNumber
+}
+
+// newAccount returns the state of an account at the block requested by the
+// block argument, if set, or the given default block.
+func newAccount(backend ethapi.Backend, address common.Address, number rpc.BlockNumber, args map[string]interface{}) (*account, error) {
+	n, ok, err := argLong(args, "block")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		number = rpc.BlockNumber(n)
+	}
+	return &account{backend: backend, address: address, number: number}, nil
+}
+
+func (a *account) state(ctx context.Context) (ethapi.State, error) {
+	state, _, err := a.backend.StateAndHeaderByNumber(ctx, a.number)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("state of block %d not available", a.number)
+	}
+	return state, nil
+}
+
+// block is a block of the chain, or an ommer.
+type block struct {
+	backend ethapi.Backend
+	block   *types.Block
+}
+
+// account returns the state of an account at an explicitly requested block,
+// defaulting to this block.
+func (b *block) account(address common.Address, args map[string]interface{}) (*account, error) {
+	return newAccount(b.backend, address, rpc.BlockNumber(b.block.NumberU64()), args)
+}
+
+// transaction is a transaction, either included in a block or pending.
+type transaction struct {
+	backend ethapi.Backend
+	tx      *types.Transaction
+	block   *types.Block // nil for pending transactions
+	index   uint64
+}
+
+// account returns the state of an account at an explicitly requested block,
+// defaulting to the block containing the transaction, or the pending state.
+func (t *transaction) account(address common.Address, args map[string]interface{}) (*account, error) {
+	number := rpc.PendingBlockNumber
+	if t.block != nil {
+		number = rpc.BlockNumber(t.block.NumberU64())
+	}
+	return newAccount(t.backend, address, number, args)
+}
+
+func (t *transaction) sender() (common.Address, error) {
+	var signer types.Signer = types.FrontierSigner{}
+	if t.tx.Protected() {
+		signer = types.NewEIP155Signer(t.tx.ChainId())
+	}
+	return types.Sender(signer, t.tx)
+}
+
+// receipt retrieves the receipt of an included transaction, nil if pending.
+func (t *transaction) receipt(ctx context.Context) (*types.Receipt, error) {
+	if t.block == nil {
+		return nil, nil
+	}
+	receipts, err := t.backend.GetReceipts(ctx, t.block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if t.index >= uint64(len(receipts)) {
+		return nil, fmt.Errorf("receipt of transaction %x not found", t.tx.Hash())
+	}
+	return receipts[t.index], nil
+}
+
+// log is a log entry emitted by a transaction.
+type log struct {
+	backend ethapi.Backend
+	log     *types.Log
+}
+
+func newTransactions(backend ethapi.Backend, b *types.Block) []*transaction {
+	txs := make([]*transaction, len(b.Transactions()))
+	for i, tx := range b.Transactions() {
+		txs[i] = &transaction{backend: backend, tx: tx, block: b, index: uint64(i)}
+	}
+	return txs
+}
+
+func newLogs(backend ethapi.Backend, logs []*types.Log) []*log {
+	result := make([]*log, len(logs))
+	for i, l := range logs {
+		result[i] = &log{backend: backend, log: l}
+	}
+	return result
+}
+
+// filterLogs runs a log filter over a block range, converting the filter
+// criteria argument.
+func filterLogs(ctx context.Context, backend ethapi.Backend, begin, end int64, criteria map[string]interface{}) ([]*log, error) {
+	filter := filters.New(backend)
+	filter.SetBeginBlock(begin)
+	filter.SetEndBlock(end)
+
+	addresses, ok, err := argList(criteria, "addresses")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		var addrs []common.Address
+		for _, addr := range addresses {
+			a, ok := addr.(common.Address)
+			if !ok {
+				return nil, fmt.Errorf("invalid address %v", addr)
+			}
+			addrs = append(addrs, a)
+		}
+		filter.SetAddresses(addrs)
+	}
+	topics, ok, err := argList(criteria, "topics")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		sets := make([][]common.Hash, len(topics))
+		for i, set := range topics {
+			sets[i], err = topicSet(set)
+			if err != nil {
+				return nil, err
+			}
+		}
+		filter.SetTopics(sets)
+	}
+	logs, err := filter.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newLogs(backend, logs), nil
+}
+
+// topicSet converts the alternatives of a topic position. Like the RPC filter
+// API, null values are converted to common.Hash{} which matches any topic.
+func topicSet(set interface{}) ([]common.Hash, error) {
+	if set == nil {
+		return []common.Hash{{}}, nil
+	}
+	items, ok := set.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid topic set %v", set)
+	}
+	var hashes []common.Hash
+	for _, topic := range items {
+		if topic == nil {
+			hashes = append(hashes, common.Hash{})
+			continue
+		}
+		hash, ok := topic.(common.Hash)
+		if !ok {
+			return nil, fmt.Errorf("invalid topic %v", topic)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// The resolver adapters below unpack the source values of the schema objects.
+
+func accountField(name, desc string, typ gqlType, fn func(ctx context.Context, a *account, args map[string]interface{}) (interface{}, error), args ...*argDef) *fieldDef {
+	return &fieldDef{name: name, desc: desc, typ: typ, args: args, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return fn(ctx, source.(*account), args)
+	}}
+}
+
+func blockField(name, desc string, typ gqlType, fn func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error), args ...*argDef) *fieldDef {
+	return &fieldDef{name: name, desc: desc, typ: typ, args: args, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return fn(ctx, source.(*block), args)
+	}}
+}
+
+func txField(name, desc string, typ gqlType, fn func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error), args ...*argDef) *fieldDef {
+	return &fieldDef{name: name, desc: desc, typ: typ, args: args, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return fn(ctx, source.(*transaction), args)
+	}}
+}
+
+func logField(name, desc string, typ gqlType, fn func(ctx context.Context, l *log, args map[string]interface{}) (interface{}, error), args ...*argDef) *fieldDef {
+	return &fieldDef{name: name, desc: desc, typ: typ, args: args, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return fn(ctx, source.(*log), args)
+	}}
+}
+
+func rootField(name, desc string, typ gqlType, fn func(ctx context.Context, args map[string]interface{}) (interface{}, error), args ...*argDef) *fieldDef {
+	return &fieldDef{name: name, desc: desc, typ: typ, args: args, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return fn(ctx, args)
+	}}
+}
+
+// newEthSchema creates the schema exposing the chain data of an Ethereum backend.
+func newEthSchema(backend ethapi.Backend) *schema {
+	var (
+		accountObj = &object{name: "Account", desc: "Account is an Ethereum account at a particular block."}
+		logObj     = &object{name: "Log", desc: "Log is an Ethereum event log."}
+		txObj      = &object{name: "Transaction", desc: "Transaction is an Ethereum transaction."}
+		blockObj   = &object{name: "Block", desc: "Block is an Ethereum block."}
+		pendingObj = &object{name: "Pending", desc: "Pending represents the current pending state."}
+		queryObj   = &object{name: "Query"}
+		mutateObj  = &object{name: "Mutation"}
+
+		topicsArg = &argDef{
+			name: "topics",
+			desc: "Topics list restricts matches to particular event topics. Each event has a list of topics. Topics matches a prefix of that list. An empty element array matches any topic. Non-empty elements represent an alternative that matches any of the contained topics.",
+			typ:  &list{&list{&nonNull{bytes32Type}}},
+		}
+		addressesArg = &argDef{
+			name: "addresses",
+			desc: "Addresses is a list of addresses that are of interest. If this list is empty, results will not be filtered by address.",
+			typ:  &list{&nonNull{addressType}},
+		}
+		blockFilterObj = &inputObject{
+			name:   "BlockFilterCriteria",
+			desc:   "BlockFilterCriteria encapsulates log filter criteria for a filter applied to a single block.",
+			fields: []*argDef{addressesArg, topicsArg},
+		}
+		filterObj = &inputObject{
+			name: "FilterCriteria",
+			desc: "FilterCriteria encapsulates log filter criteria for searching log entries.",
+			fields: []*argDef{
+				{name: "fromBlock", desc: "FromBlock is the block at which to start searching, inclusive. Defaults to the latest block if not supplied.", typ: longType},
+				{name: "toBlock", desc: "ToBlock is the block at which to stop searching, inclusive. Defaults to the latest block if not supplied.", typ: longType},
+				addressesArg,
+				topicsArg,
+			},
+		}
+		blockArg   = &argDef{name: "block", desc: "Block number to retrieve the state at, defaults to the containing block.", typ: longType}
+		addressArg = &argDef{name: "address", typ: &nonNull{addressType}}
+	)
+
+	accountObj.fields = []*fieldDef{
+		accountField("address", "Address is the address owning the account.", &nonNull{addressType}, func(ctx context.Context, a *account, args map[string]interface{}) (interface{}, error) {
+			return a.address, nil
+		}),
+		accountField("balance", "Balance is the balance of the account, in wei.", &nonNull{bigIntType}, func(ctx context.Context, a *account, args map[string]interface{}) (interface{}, error) {
+			state, err := a.state(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return state.GetBalance(ctx, a.address)
+		}),
+		accountField("transactionCount", "TransactionCount is the number of transactions sent from this account, or in the case of a contract, the number of contracts created.", &nonNull{longType}, func(ctx context.Context, a *account, args map[string]interface{}) (interface{}, error) {
+			state, err := a.state(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return state.GetNonce(ctx, a.address)
+		}),
+		accountField("code", "Code contains the smart contract code for this account, if the account is a (non-self-destructed) contract.", &nonNull{bytesType}, func(ctx context.Context, a *account, args map[string]interface{}) (interface{}, error) {
+			state, err := a.state(ctx)
+			if err != nil {
+				return nil, err
+			}
+			code, err := state.GetCode(ctx, a.address)
+			if code == nil && err == nil {
+				code = []byte{}
+			}
+			return code, err
+		}),
+		accountField("storage", "Storage provides access to the storage of a contract account, indexed by its 32 byte slot identifier.", &nonNull{bytes32Type}, func(ctx context.Context, a *account, args map[string]interface{}) (interface{}, error) {
+			state, err := a.state(ctx)
+			if err != nil {
+				return nil, err
+			}
+			slot, ok, err := argHash(args, "slot")
+			if !ok || err != nil {
+				return nil, requiredArg("slot", err)
+			}
+			return state.GetState(ctx, a.address, slot)
+		}, &argDef{name: "slot", typ: &nonNull{bytes32Type}}),
+	}
+
+	logObj.fields = []*fieldDef{
+		logField("index", "Index is the index of this log in the block.", &nonNull{intType}, func(ctx context.Context, l *log, args map[string]interface{}) (interface{}, error) {
+			return l.log.Index, nil
+		}),
+		logField("account", "Account is the account which generated this log - this will always be a contract account.", &nonNull{accountObj}, func(ctx context.Context, l *log, args map[string]interface{}) (interface{}, error) {
+			return newAccount(l.backend, l.log.Address, rpc.BlockNumber(l.log.BlockNumber), args)
+		}, blockArg),
+		logField("topics", "Topics is a list of 0-4 indexed topics for the log.", &nonNull{&list{&nonNull{bytes32Type}}}, func(ctx context.Context, l *log, args map[string]interface{}) (interface{}, error) {
+			return l.log.Topics, nil
+		}),
+		logField("data", "Data is unindexed data for this log.", &nonNull{bytesType}, func(ctx context.Context, l *log, args map[string]interface{}) (interface{}, error) {
+			if l.log.Data == nil {
+				return []byte{}, nil
+			}
+			return l.log.Data, nil
+		}),
+		logField("transaction", "Transaction is the transaction that generated this log entry.", &nonNull{txObj}, func(ctx context.Context, l *log, args map[string]interface{}) (interface{}, error) {
+			b, err := l.backend.GetBlock(ctx, l.log.BlockHash)
+			if err != nil {
+				return nil, err
+			}
+			if b == nil || uint64(l.log.TxIndex) >= uint64(len(b.Transactions())) {
+				return nil, fmt.Errorf("transaction %x not found", l.log.TxHash)
+			}
+			return &transaction{backend: l.backend, tx: b.Transactions()[l.log.TxIndex], block: b, index: uint64(l.log.TxIndex)}, nil
+		}),
+	}
+
+	txObj.fields = []*fieldDef{
+		txField("hash", "Hash is the hash of this transaction.", &nonNull{bytes32Type}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			return t.tx.Hash(), nil
+		}),
+		txField("nonce", "Nonce is the nonce of the account this transaction was generated with.", &nonNull{longType}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			return t.tx.Nonce(), nil
+		}),
+		txField("index", "Index is the index of this transaction in the parent block. This will be null if the transaction has not yet been mined.", intType, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			if t.block == nil {
+				return nil, nil
+			}
+			return int(t.index), nil
+		}),
+		txField("from", "From is the account that sent this transaction - this will always be an externally owned account.", &nonNull{accountObj}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			from, err := t.sender()
+			if err != nil {
+				return nil, err
+			}
+			return t.account(from, args)
+		}, blockArg),
+		txField("to", "To is the account the transaction was sent to. This is null for contract-creating transactions.", accountObj, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			if t.tx.To() == nil {
+				return nil, nil
+			}
+			return t.account(*t.tx.To(), args)
+		}, blockArg),
+		txField("value", "Value is the value, in wei, sent along with this transaction.", &nonNull{bigIntType}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			return t.tx.Value(), nil
+		}),
+		txField("gasPrice", "GasPrice is the price offered to miners for gas, in wei per unit.", &nonNull{bigIntType}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			return t.tx.GasPrice(), nil
+		}),
+		txField("gas", "Gas is the maximum amount of gas this transaction can consume.", &nonNull{bigIntType}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			return t.tx.Gas(), nil
+		}),
+		txField("inputData", "InputData is the data supplied to the target of the transaction.", &nonNull{bytesType}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			if data := t.tx.Data(); data != nil {
+				return data, nil
+			}
+			return []byte{}, nil
+		}),
+		txField("block", "Block is the block this transaction was mined in. This will be null if the transaction has not yet been mined.", blockObj, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			if t.block == nil {
+				return nil, nil
+			}
+			return &block{backend: t.backend, block: t.block}, nil
+		}),
+		txField("gasUsed", "GasUsed is the amount of gas that was used processing this transaction. If the transaction has not yet been mined, this field will be null.", bigIntType, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			receipt, err := t.receipt(ctx)
+			if receipt == nil || err != nil {
+				return nil, err
+			}
+			return receipt.GasUsed, nil
+		}),
+		txField("cumulativeGasUsed", "CumulativeGasUsed is the total gas used in the block up to and including this transaction. If the transaction has not yet been mined, this field will be null.", bigIntType, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			receipt, err := t.receipt(ctx)
+			if receipt == nil || err != nil {
+				return nil, err
+			}
+			return receipt.CumulativeGasUsed, nil
+		}),
+		txField("createdContract", "CreatedContract is the account that was created by a contract creation transaction. If the transaction was not a contract creation transaction, or it has not yet been mined, this field will be null.", accountObj, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			if t.tx.To() != nil {
+				return nil, nil
+			}
+			receipt, err := t.receipt(ctx)
+			if receipt == nil || err != nil {
+				return nil, err
+			}
+			return t.account(receipt.ContractAddress, args)
+		}, blockArg),
+		txField("logs", "Logs is a list of log entries emitted by this transaction. If the transaction has not yet been mined, this field will be null.", &list{&nonNull{logObj}}, func(ctx context.Context, t *transaction, args map[string]interface{}) (interface{}, error) {
+			receipt, err := t.receipt(ctx)
+			if receipt == nil || err != nil {
+				return nil, err
+			}
+			return newLogs(t.backend, receipt.Logs), nil
+		}),
+	}
+
+	blockObj.fields = []*fieldDef{
+		blockField("number", "Number is the number of this block, starting at 0 for the genesis block.", &nonNull{longType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.NumberU64(), nil
+		}),
+		blockField("hash", "Hash is the block hash of this block.", &nonNull{bytes32Type}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.Hash(), nil
+		}),
+		blockField("parent", "Parent is the parent block of this block. This is null for the genesis block.", blockObj, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			if b.block.NumberU64() == 0 {
+				return nil, nil
+			}
+			parent, err := b.backend.GetBlock(ctx, b.block.ParentHash())
+			if parent == nil || err != nil {
+				return nil, err
+			}
+			return &block{backend: b.backend, block: parent}, nil
+		}),
+		blockField("nonce", "Nonce is the block nonce, an 8 byte sequence determined by the miner.", &nonNull{bytesType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			nonce := b.block.Nonce()
+			return []byte{byte(nonce >> 56), byte(nonce >> 48), byte(nonce >> 40), byte(nonce >> 32), byte(nonce >> 24), byte(nonce >> 16), byte(nonce >> 8), byte(nonce)}, nil
+		}),
+		blockField("transactionsRoot", "TransactionsRoot is the keccak256 hash of the root of the trie of transactions in this block.", &nonNull{bytes32Type}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.TxHash(), nil
+		}),
+		blockField("stateRoot", "StateRoot is the keccak256 hash of the state trie after this block was processed.", &nonNull{bytes32Type}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.Root(), nil
+		}),
+		blockField("receiptsRoot", "ReceiptsRoot is the keccak256 hash of the trie of transaction receipts in this block.", &nonNull{bytes32Type}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.ReceiptHash(), nil
+		}),
+		blockField("miner", "Miner is the account that mined this block.", &nonNull{accountObj}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.account(b.block.Coinbase(), args)
+		}, blockArg),
+		blockField("extraData", "ExtraData is an arbitrary data field supplied by the miner.", &nonNull{bytesType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			if extra := b.block.Extra(); extra != nil {
+				return extra, nil
+			}
+			return []byte{}, nil
+		}),
+		blockField("gasLimit", "GasLimit is the maximum amount of gas that was available to transactions in this block.", &nonNull{bigIntType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.GasLimit(), nil
+		}),
+		blockField("gasUsed", "GasUsed is the amount of gas that was used executing transactions in this block.", &nonNull{bigIntType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.GasUsed(), nil
+		}),
+		blockField("timestamp", "Timestamp is the unix timestamp at which this block was mined.", &nonNull{bigIntType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.Time(), nil
+		}),
+		blockField("logsBloom", "LogsBloom is a bloom filter that can be used to check if a block may contain log entries matching a filter.", &nonNull{bytesType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.Bloom().Bytes(), nil
+		}),
+		blockField("mixHash", "MixHash is the hash that was used as an input to the PoW process.", &nonNull{bytes32Type}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.MixDigest(), nil
+		}),
+		blockField("difficulty", "Difficulty is a measure of the difficulty of mining this block.", &nonNull{bigIntType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return b.block.Difficulty(), nil
+		}),
+		blockField("totalDifficulty", "TotalDifficulty is the sum of all difficulty values up to and including this block.", &nonNull{bigIntType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			if td := b.backend.GetTd(b.block.Hash()); td != nil {
+				return td, nil
+			}
+			return nil, fmt.Errorf("total difficulty of block %x not found", b.block.Hash())
+		}),
+		blockField("ommerCount", "OmmerCount is the number of ommers (AKA uncles) associated with this block.", &nonNull{intType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return len(b.block.Uncles()), nil
+		}),
+		blockField("ommers", "Ommers is a list of ommer (AKA uncle) blocks associated with this block. Ommers are returned with their header fields only.", &nonNull{&list{&nonNull{blockObj}}}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			ommers := make([]*block, len(b.block.Uncles()))
+			for i, uncle := range b.block.Uncles() {
+				ommers[i] = &block{backend: b.backend, block: types.NewBlockWithHeader(uncle)}
+			}
+			return ommers, nil
+		}),
+		blockField("transactionCount", "TransactionCount is the number of transactions in this block.", &nonNull{intType}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return len(b.block.Transactions()), nil
+		}),
+		blockField("transactions", "Transactions is a list of transactions associated with this block.", &nonNull{&list{&nonNull{txObj}}}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			return newTransactions(b.backend, b.block), nil
+		}),
+		blockField("transactionAt", "TransactionAt returns the transaction at the specified index. If the index is out of bounds, null is returned.", txObj, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			index, ok, err := argInt(args, "index")
+			if !ok || err != nil {
+				return nil, requiredArg("index", err)
+			}
+			if index < 0 || index >= len(b.block.Transactions()) {
+				return nil, nil
+			}
+			return &transaction{backend: b.backend, tx: b.block.Transactions()[index], block: b.block, index: uint64(index)}, nil
+		}, &argDef{name: "index", typ: &nonNull{intType}}),
+		blockField("logs", "Logs returns a filtered set of logs from this block.", &nonNull{&list{&nonNull{logObj}}}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			criteria, ok, err := argObject(args, "filter")
+			if !ok || err != nil {
+				return nil, requiredArg("filter", err)
+			}
+			number := int64(b.block.NumberU64())
+			return filterLogs(ctx, b.backend, number, number, criteria)
+		}, &argDef{name: "filter", typ: &nonNull{blockFilterObj}}),
+		blockField("account", "Account returns the state of an account at this block.", &nonNull{accountObj}, func(ctx context.Context, b *block, args map[string]interface{}) (interface{}, error) {
+			address, ok, err := argAddress(args, "address")
+			if !ok || err != nil {
+				return nil, requiredArg("address", err)
+			}
+			return b.account(address, nil)
+		}, addressArg),
+	}
+
+	pendingObj.fields = []*fieldDef{
+		rootField("transactionCount", "TransactionCount is the number of transactions in the pending state.", &nonNull{intType}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			txs, err := backend.GetPoolTransactions()
+			return len(txs), err
+		}),
+		rootField("transactions", "Transactions is a list of transactions in the current pending state.", &list{&nonNull{txObj}}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			txs, err := backend.GetPoolTransactions()
+			if err != nil {
+				return nil, err
+			}
+			result := make([]*transaction, len(txs))
+			for i, tx := range txs {
+				result[i] = &transaction{backend: backend, tx: tx}
+			}
+			return result, nil
+		}),
+		rootField("account", "Account fetches an Ethereum account for the pending state.", &nonNull{accountObj}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			address, ok, err := argAddress(args, "address")
+			if !ok || err != nil {
+				return nil, requiredArg("address", err)
+			}
+			return &account{backend: backend, address: address, number: rpc.PendingBlockNumber}, nil
+		}, addressArg),
+	}
+
+	queryObj.fields = []*fieldDef{
+		rootField("block", "Block fetches an Ethereum block by number or by hash. If neither is supplied, the most recent known block is returned.", blockObj, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			number, byNumber, err := argLong(args, "number")
+			if err != nil {
+				return nil, err
+			}
+			hash, byHash, err := argHash(args, "hash")
+			if err != nil {
+				return nil, err
+			}
+			var b *types.Block
+			switch {
+			case byNumber && byHash:
+				return nil, errors.New("only one of number or hash must be specified")
+			case byHash:
+				b, err = backend.GetBlock(ctx, hash)
+			case byNumber:
+				b, err = backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+			default:
+				b, err = backend.BlockByNumber(ctx, rpc.LatestBlockNumber)
+			}
+			if b == nil || err != nil {
+				return nil, err
+			}
+			return &block{backend: backend, block: b}, nil
+		}, &argDef{name: "number", typ: longType}, &argDef{name: "hash", typ: bytes32Type}),
+
+		rootField("blocks", "Blocks returns all the blocks between two numbers, inclusive. If to is not supplied, it defaults to the most recent known block.", &nonNull{&list{&nonNull{blockObj}}}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			from, ok, err := argLong(args, "from")
+			if !ok || err != nil {
+				return nil, requiredArg("from", err)
+			}
+			to, ok, err := argLong(args, "to")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				to = backend.CurrentBlock().NumberU64()
+			}
+			if to < from {
+				return []*block{}, nil
+			}
+			if to-from >= maxBlockRange {
+				return nil, errBlockRangeTooLarge
+			}
+			var blocks []*block
+			for number := from; number <= to; number++ {
+				b, err := backend.BlockByNumber(ctx, rpc.BlockNumber(number))
+				if err != nil {
+					return nil, err
+				}
+				if b == nil {
+					break
+				}
+				blocks = append(blocks, &block{backend: backend, block: b})
+			}
+			return blocks, nil
+		}, &argDef{name: "from", typ: &nonNull{longType}}, &argDef{name: "to", typ: longType}),
+
+		rootField("pending", "Pending returns the current pending state.", &nonNull{pendingObj}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return struct{}{}, nil
+		}),
+
+		rootField("transaction", "Transaction returns a transaction specified by its hash. Mined transactions are only found on full nodes, light clients return pending transactions only.", txObj, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			hash, ok, err := argHash(args, "hash")
+			if !ok || err != nil {
+				return nil, requiredArg("hash", err)
+			}
+			// Mined transactions are found through the transaction index of the
+			// chain database. Light clients don't maintain it and have no way of
+			// retrieving it on demand, so they only find pending transactions.
+			if tx, blockHash, _, index := core.GetTransaction(backend.ChainDb(), hash); tx != nil {
+				b, err := backend.GetBlock(ctx, blockHash)
+				if b == nil || err != nil {
+					return nil, err
+				}
+				return &transaction{backend: backend, tx: tx, block: b, index: index}, nil
+			}
+			if tx := backend.GetPoolTransaction(hash); tx != nil {
+				return &transaction{backend: backend, tx: tx}, nil
+			}
+			return nil, nil
+		}, &argDef{name: "hash", typ: &nonNull{bytes32Type}}),
+
+		rootField("logs", "Logs returns log entries matching the provided filter.", &nonNull{&list{&nonNull{logObj}}}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			criteria, ok, err := argObject(args, "filter")
+			if !ok || err != nil {
+				return nil, requiredArg("filter", err)
+			}
+			// Resolve the range against the head, so it can be capped
+			head := backend.CurrentBlock().NumberU64()
+			begin, ok, err := argLong(criteria, "fromBlock")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				begin = head
+			}
+			end, ok, err := argLong(criteria, "toBlock")
+			if err != nil {
+				return nil, err
+			}
+			if !ok || end > head {
+				end = head
+			}
+			if end < begin {
+				return []*log{}, nil
+			}
+			if end-begin >= maxBlockRange {
+				return nil, errBlockRangeTooLarge
+			}
+			return filterLogs(ctx, backend, int64(begin), int64(end), criteria)
+		}, &argDef{name: "filter", typ: &nonNull{filterObj}}),
+
+		rootField("gasPrice", "GasPrice returns the node's estimate of a gas price sufficient to ensure a transaction is mined in a timely fashion.", &nonNull{bigIntType}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return backend.SuggestPrice(ctx)
+		}),
+
+		rootField("protocolVersion", "ProtocolVersion returns the current wire protocol version number.", &nonNull{intType}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			return backend.ProtocolVersion(), nil
+		}),
+	}
+
+	mutateObj.fields = []*fieldDef{
+		rootField("sendRawTransaction", "SendRawTransaction sends an RLP-encoded transaction to the network, returning its hash.", &nonNull{bytes32Type}, func(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+			data, ok, err := argBytes(args, "data")
+			if !ok || err != nil {
+				return nil, requiredArg("data", err)
+			}
+			tx := new(types.Transaction)
+			if err := rlp.DecodeBytes(data, tx); err != nil {
+				return nil, err
+			}
+			if err := backend.SendTx(ctx, tx); err != nil {
+				return nil, err
+			}
+			glog.V(logger.Info).Infof("Tx(%x) submitted via GraphQL", tx.Hash())
+			return tx.Hash(), nil
+		}, &argDef{name: "data", typ: &nonNull{bytesType}}),
+	}
+
+	return newSchema(queryObj, mutateObj)
+}
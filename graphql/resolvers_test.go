@@ -0,0 +1,372 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/accounts"
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+	"github.com/EarthDollar/go-earthdollar/core"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/core/vm"
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/eth/downloader"
+	"github.com/EarthDollar/go-earthdollar/ethdb"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
+	"github.com/EarthDollar/go-earthdollar/params"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
+)
+
+var (
+	testBankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testBankAddress = crypto.PubkeyToAddress(testBankKey.PublicKey)
+	testBankFunds   = big.NewInt(1000000000)
+	testSigner      = types.NewEIP155Signer(params.TestChainConfig.ChainId)
+
+	// testLogCode is the init code of a contract emitting a log with topic 0x01.
+	testLogCode  = common.FromHex("0x600160006000a100")
+	testLogTopic = common.BigToHash(big.NewInt(1))
+)
+
+// testBackend is an ethapi.Backend serving an in-memory chain and transaction
+// pool.
+type testBackend struct {
+	db    ethdb.Database
+	mux   *event.TypeMux
+	chain *core.BlockChain
+	pool  *core.TxPool
+}
+
+// newTestBackend creates a chain of two blocks: the first creating a contract
+// which emits a log and receives a wei, the second transferring to 0x02.
+func newTestBackend(t *testing.T) *testBackend {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		mux     = new(event.TypeMux)
+		pow     = new(core.FakePow)
+		config  = params.TestChainConfig
+		genesis = core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: testBankAddress, Balance: testBankFunds})
+	)
+	blocks, _ := core.GenerateChain(config, genesis, db, 2, func(i int, gen *core.BlockGen) {
+		var tx *types.Transaction
+		switch i {
+		case 0:
+			tx = types.NewContractCreation(gen.TxNonce(testBankAddress), big.NewInt(1), big.NewInt(100000), new(big.Int), testLogCode)
+		case 1:
+			tx = types.NewTransaction(gen.TxNonce(testBankAddress), common.Address{0x02}, big.NewInt(1000), params.TxGas, new(big.Int), nil)
+		}
+		tx, _ = types.SignTx(tx, testSigner, testBankKey)
+		gen.AddTx(tx)
+	})
+	chain, err := core.NewBlockChain(db, config, pow, mux, vm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	return &testBackend{
+		db:    db,
+		mux:   mux,
+		chain: chain,
+		pool:  core.NewTxPool(config, mux, chain.State, chain.GasLimit),
+	}
+}
+
+func (b *testBackend) close() {
+	b.pool.Stop()
+	b.chain.Stop()
+}
+
+func (b *testBackend) Downloader() *downloader.Downloader                 { return nil }
+func (b *testBackend) ProtocolVersion() int                               { return 63 }
+func (b *testBackend) SuggestPrice(ctx context.Context) (*big.Int, error) { return big.NewInt(1), nil }
+func (b *testBackend) ChainDb() ethdb.Database                            { return b.db }
+func (b *testBackend) EventMux() *event.TypeMux                           { return b.mux }
+func (b *testBackend) AccountManager() *accounts.Manager                  { return nil }
+func (b *testBackend) SetHead(number uint64)                              { b.chain.SetHead(number) }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int                    { return b.chain.GetTdByHash(hash) }
+func (b *testBackend) ChainConfig() *params.ChainConfig                   { return params.TestChainConfig }
+func (b *testBackend) CurrentBlock() *types.Block                         { return b.chain.CurrentBlock() }
+func (b *testBackend) BloomStatus() (uint64, uint64)                      { return params.BloomBitsBlocks, 0 }
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.chain.CurrentBlock(), nil
+	}
+	return b.chain.GetBlockByNumber(uint64(number)), nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (ethapi.State, *types.Header, error) {
+	if number == rpc.PendingBlockNumber {
+		return testState{b.pool.State()}, b.chain.CurrentHeader(), nil
+	}
+	header, err := b.HeaderByNumber(ctx, number)
+	if header == nil || err != nil {
+		return nil, nil, err
+	}
+	statedb, err := b.chain.StateAt(header.Root)
+	return testState{statedb}, header, err
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.chain.GetBlockByHash(hash), nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(b.db, hash, core.GetBlockNumber(b.db, hash)), nil
+}
+
+func (b *testBackend) GetVMEnv(ctx context.Context, msg core.Message, state ethapi.State, header *types.Header) (*vm.EVM, func() error, error) {
+	return nil, nil, fmt.Errorf("not supported")
+}
+
+func (b *testBackend) SendTx(ctx context.Context, tx *types.Transaction) error { return b.pool.Add(tx) }
+func (b *testBackend) RemoveTx(hash common.Hash)                               { b.pool.Remove(hash) }
+
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) {
+	pending, err := b.pool.Pending()
+	if err != nil {
+		return nil, err
+	}
+	var txs types.Transactions
+	for _, batch := range pending {
+		txs = append(txs, batch...)
+	}
+	return txs, nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction {
+	return b.pool.Get(hash)
+}
+
+func (b *testBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return b.pool.State().GetNonce(addr), nil
+}
+
+func (b *testBackend) Stats() (int, int) { return b.pool.Stats() }
+
+func (b *testBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	return b.pool.Content()
+}
+
+// testState adapts a state database to ethapi.State.
+type testState struct {
+	state interface {
+		GetBalance(common.Address) *big.Int
+		GetCode(common.Address) []byte
+		GetState(common.Address, common.Hash) common.Hash
+		GetNonce(common.Address) uint64
+	}
+}
+
+func (s testState) GetBalance(ctx context.Context, addr common.Address) (*big.Int, error) {
+	return s.state.GetBalance(addr), nil
+}
+
+func (s testState) GetCode(ctx context.Context, addr common.Address) ([]byte, error) {
+	return s.state.GetCode(addr), nil
+}
+
+func (s testState) GetState(ctx context.Context, addr common.Address, key common.Hash) (common.Hash, error) {
+	return s.state.GetState(addr, key), nil
+}
+
+func (s testState) GetNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return s.state.GetNonce(addr), nil
+}
+
+// lightBackend mimics a light client, which doesn't maintain the transaction
+// index of the chain database.
+type lightBackend struct {
+	*testBackend
+	db ethdb.Database
+}
+
+func (b *lightBackend) ChainDb() ethdb.Database { return b.db }
+
+// execBackend runs a query against the schema of a backend, returning the JSON
+// encoded response.
+func execBackend(t *testing.T, backend ethapi.Backend, query string) string {
+	out, err := json.Marshal(newEthSchema(backend).execute(context.Background(), query, "", nil))
+	if err != nil {
+		t.Fatalf("failed to encode response: %v", err)
+	}
+	return string(out)
+}
+
+// Tests the nested query of a block's transactions, their receipt logs and the
+// balance of the logging account at that block.
+func TestResolveNested(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.close()
+
+	contract := crypto.CreateAddress(testBankAddress, 0)
+	have := execBackend(t, backend, `{ block(number: 1) { number transactions { index from { address } createdContract { address } logs { topics account { address balance } } } } }`)
+	want := fmt.Sprintf(`{"data":{"block":{"number":1,"transactions":[{"index":0,"from":{"address":"%s"},"createdContract":{"address":"%s"},"logs":[{"topics":["%s"],"account":{"address":"%s","balance":"0x1"}}]}]}}}`,
+		strings.ToLower(testBankAddress.Hex()), strings.ToLower(contract.Hex()), testLogTopic.Hex(), strings.ToLower(contract.Hex()))
+	if have != want {
+		t.Errorf("response mismatch:\nhave %s\nwant %s", have, want)
+	}
+	// Accounts are read at the block of the transaction unless overridden
+	head, _ := backend.chain.State()
+	have = execBackend(t, backend, `{ block(number: 2) { transactions { from { balance } } } }`)
+	if want := fmt.Sprintf(`{"data":{"block":{"transactions":[{"from":{"balance":"%s"}}]}}}`, hexutil.EncodeBig(head.GetBalance(testBankAddress))); have != want {
+		t.Errorf("balance at block 2 mismatch:\nhave %s\nwant %s", have, want)
+	}
+	have = execBackend(t, backend, `{ block(number: 2) { transactions { from(block: 0) { balance } } } }`)
+	if want := fmt.Sprintf(`{"data":{"block":{"transactions":[{"from":{"balance":"%s"}}]}}}`, hexutil.EncodeBig(testBankFunds)); have != want {
+		t.Errorf("balance at block 0 mismatch:\nhave %s\nwant %s", have, want)
+	}
+}
+
+// Tests that transactions sent through the mutation show up in the pending
+// state.
+func TestResolveSendRawTransaction(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.close()
+
+	tx, _ := types.SignTx(types.NewTransaction(2, common.Address{0x03}, big.NewInt(1), params.TxGas, new(big.Int), nil), testSigner, testBankKey)
+	data, _ := rlp.EncodeToBytes(tx)
+	query := fmt.Sprintf(`mutation { sendRawTransaction(data: "%s") }`, hexutil.Encode(data))
+	if have, want := execBackend(t, backend, query), fmt.Sprintf(`{"data":{"sendRawTransaction":"%s"}}`, tx.Hash().Hex()); have != want {
+		t.Fatalf("response mismatch:\nhave %s\nwant %s", have, want)
+	}
+	have := execBackend(t, backend, fmt.Sprintf(`{ pending { transactionCount transactions { hash block { number } } account(address: "%s") { transactionCount } } }`, testBankAddress.Hex()))
+	want := fmt.Sprintf(`{"data":{"pending":{"transactionCount":1,"transactions":[{"hash":"%s","block":null}],"account":{"transactionCount":3}}}}`, tx.Hash().Hex())
+	if have != want {
+		t.Errorf("pending state mismatch:\nhave %s\nwant %s", have, want)
+	}
+	// Invalid transactions are rejected
+	query = fmt.Sprintf(`mutation { sendRawTransaction(data: "%s") }`, hexutil.Encode(data[:len(data)-1]))
+	if have := execBackend(t, backend, query); !strings.Contains(have, `"errors"`) {
+		t.Errorf("truncated transaction accepted: %s", have)
+	}
+}
+
+// Tests that mined transactions are looked up on full nodes, while light
+// clients only find pending ones.
+func TestResolveTransaction(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.close()
+
+	mined := backend.chain.GetBlockByNumber(2).Transactions()[0]
+	pending, _ := types.SignTx(types.NewTransaction(2, common.Address{0x03}, big.NewInt(1), params.TxGas, new(big.Int), nil), testSigner, testBankKey)
+	if err := backend.SendTx(context.Background(), pending); err != nil {
+		t.Fatal(err)
+	}
+	lightdb, _ := ethdb.NewMemDatabase()
+	light := &lightBackend{backend, lightdb}
+
+	tests := []struct {
+		backend ethapi.Backend
+		hash    common.Hash
+		want    string
+	}{
+		{backend, mined.Hash(), `{"data":{"transaction":{"block":{"number":2}}}}`},
+		{backend, pending.Hash(), `{"data":{"transaction":{"block":null}}}`},
+		{light, mined.Hash(), `{"data":{"transaction":null}}`},
+		{light, pending.Hash(), `{"data":{"transaction":{"block":null}}}`},
+		{backend, common.Hash{0x01}, `{"data":{"transaction":null}}`},
+	}
+	for i, tt := range tests {
+		if have := execBackend(t, tt.backend, fmt.Sprintf(`{ transaction(hash: "%s") { block { number } } }`, tt.hash.Hex())); have != tt.want {
+			t.Errorf("test %d: response mismatch:\nhave %s\nwant %s", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that explicit nulls are treated as absent arguments and that arguments
+// of the wrong type are reported instead of crashing the resolvers.
+func TestResolveNullArguments(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.close()
+
+	tests := []struct {
+		query, vars, want string
+	}{
+		{`{ block(number: null) { number } }`, "", `{"data":{"block":{"number":2}}}`},
+		{`{ block(hash: null, number: 1) { number } }`, "", `{"data":{"block":{"number":1}}}`},
+		{`{ blocks(from: 1, to: null) { number } }`, "", `{"data":{"blocks":[{"number":1},{"number":2}]}}`},
+		{`{ block(number: 1) { miner(block: null) { address } } }`, "", `{"data":{"block":{"miner":{"address":"0x0000000000000000000000000000000000000000"}}}}`},
+		{`{ logs(filter: {fromBlock: 1, toBlock: null, topics: [[null]]}) { topics } }`, "", `{"errors":[{"message":"argument \"filter\": field \"topics\" of FilterCriteria: expected non-null Bytes32","locations":[{"line":1,"column":3}],"path":["logs"]}]}`},
+		{`{ logs(filter: {fromBlock: 1, toBlock: null, topics: null}) { topics } }`, "", fmt.Sprintf(`{"data":{"logs":[{"topics":["%s"]}]}}`, testLogTopic.Hex())},
+		{`query($t: [[Bytes32]]) { logs(filter: {fromBlock: 1, topics: $t}) { topics } }`, `{"t": [[null]]}`, fmt.Sprintf(`{"data":{"logs":[{"topics":["%s"]}]}}`, testLogTopic.Hex())},
+		{`query($n: Int) { block(number: $n) { number } }`, `{"n": 1}`, `{"data":{"block":null},"errors":[{"message":"argument \"number\": expected Long, got 1","locations":[{"line":1,"column":18}],"path":["block"]}]}`},
+	}
+	for i, tt := range tests {
+		var vars map[string]interface{}
+		if tt.vars != "" {
+			if err := decodeJSON([]byte(tt.vars), &vars); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out, _ := json.Marshal(newEthSchema(backend).execute(context.Background(), tt.query, "", vars))
+		if have := string(out); have != tt.want {
+			t.Errorf("test %d: response mismatch\nquery: %s\nhave:  %s\nwant:  %s", i, tt.query, have, tt.want)
+		}
+	}
+}
+
+// Tests that log queries spanning too many blocks are rejected, with the range
+// resolved against the head of the chain.
+func TestResolveLogsRange(t *testing.T) {
+	backend := newTestBackend(t)
+	defer backend.close()
+
+	// Extend the chain beyond the range limit
+	blocks, _ := core.GenerateChain(params.TestChainConfig, backend.chain.CurrentBlock(), backend.db, maxBlockRange, nil)
+	if _, err := backend.chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	head := backend.chain.CurrentBlock().NumberU64()
+
+	tests := []struct {
+		filter string
+		err    bool
+	}{
+		{`{fromBlock: 0}`, true},
+		{fmt.Sprintf(`{fromBlock: 0, toBlock: %d}`, maxBlockRange-1), false},
+		{fmt.Sprintf(`{fromBlock: 0, toBlock: %d}`, maxBlockRange), true},
+		{fmt.Sprintf(`{fromBlock: %d}`, head-maxBlockRange+1), false},
+		{fmt.Sprintf(`{fromBlock: %d, toBlock: %d}`, head-10, head+maxBlockRange), false},
+		{`{}`, false},
+	}
+	for i, tt := range tests {
+		have := execBackend(t, backend, fmt.Sprintf(`{ logs(filter: %s) { index } }`, tt.filter))
+		if failed := strings.Contains(have, errBlockRangeTooLarge.Error()); failed != tt.err {
+			t.Errorf("test %d: filter %s: response %s", i, tt.filter, have)
+		}
+	}
+}
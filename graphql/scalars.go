@@ -0,0 +1,136 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/hexutil"
+)
+
+// The scalar types of the Ethereum schema.
+var (
+	bytes32Type = &scalar{
+		name: "Bytes32",
+		desc: "Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if h, ok := v.(common.Hash); ok {
+				return h.Hex(), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Bytes32", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				if b, err := hexutil.Decode(s); err == nil && len(b) == common.HashLength {
+					return common.BytesToHash(b), nil
+				}
+			}
+			return nil, fmt.Errorf("expected 32 byte hex string, got %v", v)
+		},
+	}
+	addressType = &scalar{
+		name: "Address",
+		desc: "Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if a, ok := v.(common.Address); ok {
+				return a.Hex(), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Address", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				if b, err := hexutil.Decode(s); err == nil && len(b) == common.AddressLength {
+					return common.BytesToAddress(b), nil
+				}
+			}
+			return nil, fmt.Errorf("expected 20 byte hex address, got %v", v)
+		},
+	}
+	bytesType = &scalar{
+		name: "Bytes",
+		desc: "Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal. An empty byte string is represented as '0x'.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.([]byte); ok {
+				return hexutil.Encode(b), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Bytes", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				if b, err := hexutil.Decode(s); err == nil {
+					return b, nil
+				}
+			}
+			return nil, fmt.Errorf("expected hex byte string, got %v", v)
+		},
+	}
+	bigIntType = &scalar{
+		name: "BigInt",
+		desc: "BigInt is a large integer. Input is accepted as either a JSON number or as a string, in decimal or 0x-prefixed hexadecimal. Output values are all 0x-prefixed hexadecimal.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if n, ok := v.(*big.Int); ok {
+				return hexutil.EncodeBig(n), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as BigInt", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			var s string
+			switch v := v.(type) {
+			case string:
+				s = v
+			case json.Number:
+				s = string(v)
+			}
+			if n, ok := new(big.Int).SetString(s, 0); ok {
+				return n, nil
+			}
+			return nil, fmt.Errorf("expected integer, got %v", v)
+		},
+	}
+	longType = &scalar{
+		name: "Long",
+		desc: "Long is a 64 bit unsigned integer.",
+		serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case uint64:
+				return v, nil
+			case int64:
+				return v, nil
+			case int:
+				return v, nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Long", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			var s string
+			switch v := v.(type) {
+			case string:
+				s = v
+			case json.Number:
+				s = string(v)
+			}
+			if n, err := strconv.ParseUint(s, 0, 64); err == nil {
+				return n, nil
+			}
+			return nil, fmt.Errorf("expected 64 bit unsigned integer, got %v", v)
+		},
+	}
+)
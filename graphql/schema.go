@@ -0,0 +1,280 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+// gqlType is a type of the GraphQL type system: one of *scalar, *enum, *object,
+// *inputObject, *list or *nonNull.
+type gqlType interface {
+	String() string
+}
+
+// resolveFunc resolves the value of a field from its parent object's value and
+// the coerced field arguments.
+type resolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// scalar is a leaf type with custom input parsing and output serialization.
+type scalar struct {
+	name, desc string
+
+	// serialize converts a resolved Go value into its JSON representation.
+	serialize func(value interface{}) (interface{}, error)
+
+	// parse converts an input value (string, bool or json.Number) into the Go
+	// value handed to the resolvers.
+	parse func(value interface{}) (interface{}, error)
+}
+
+func (t *scalar) String() string { return t.name }
+
+// enum is a leaf type restricted to a set of names, represented as strings.
+type enum struct {
+	name, desc string
+	values     []string
+}
+
+func (t *enum) String() string { return t.name }
+
+// object is an output type made up of resolvable fields.
+type object struct {
+	name, desc string
+	fields     []*fieldDef
+	fieldMap   map[string]*fieldDef
+}
+
+func (t *object) String() string { return t.name }
+
+// field looks up a field definition by name.
+func (t *object) field(name string) *fieldDef {
+	if t.fieldMap == nil {
+		t.fieldMap = make(map[string]*fieldDef, len(t.fields))
+		for _, f := range t.fields {
+			t.fieldMap[f.name] = f
+		}
+	}
+	return t.fieldMap[name]
+}
+
+// fieldDef is a field of an object type.
+type fieldDef struct {
+	name, desc string
+	args       []*argDef
+	typ        gqlType
+	resolve    resolveFunc
+}
+
+// argDef is an argument of a field or a field of an input object.
+type argDef struct {
+	name, desc string
+	typ        gqlType
+	def        string // default value in query language syntax, empty if none
+}
+
+// inputObject is a composite input type.
+type inputObject struct {
+	name, desc string
+	fields     []*argDef
+}
+
+func (t *inputObject) String() string { return t.name }
+
+// list is a list of values of the element type.
+type list struct{ of gqlType }
+
+func (t *list) String() string { return "[" + t.of.String() + "]" }
+
+// nonNull is a type that never resolves to null.
+type nonNull struct{ of gqlType }
+
+func (t *nonNull) String() string { return t.of.String() + "!" }
+
+// schema is a complete GraphQL schema with its named types indexed.
+type schema struct {
+	query    *object
+	mutation *object
+	types    map[string]gqlType
+	names    []string // type names in registration order
+}
+
+// newSchema creates a schema from its root operation types, collecting all the
+// named types reachable from them.
+func newSchema(query, mutation *object) *schema {
+	s := &schema{query: query, mutation: mutation, types: make(map[string]gqlType)}
+	for _, t := range []gqlType{stringType, intType, floatType, booleanType, idType} {
+		s.register(t)
+	}
+	s.register(query)
+	if mutation != nil {
+		s.register(mutation)
+	}
+	for _, t := range introspectionTypes() {
+		s.register(t)
+	}
+	return s
+}
+
+// register adds a type and all the types it references to the schema.
+func (s *schema) register(t gqlType) {
+	switch t := t.(type) {
+	case *list:
+		s.register(t.of)
+		return
+	case *nonNull:
+		s.register(t.of)
+		return
+	}
+	name := t.String()
+	if _, ok := s.types[name]; ok {
+		return
+	}
+	s.types[name] = t
+	s.names = append(s.names, name)
+
+	switch t := t.(type) {
+	case *object:
+		for _, f := range t.fields {
+			s.register(f.typ)
+			for _, arg := range f.args {
+				s.register(arg.typ)
+			}
+		}
+	case *inputObject:
+		for _, f := range t.fields {
+			s.register(f.typ)
+		}
+	}
+}
+
+// unwrap strips the list and non-null wrappers of a type.
+func unwrap(t gqlType) gqlType {
+	for {
+		switch w := t.(type) {
+		case *list:
+			t = w.of
+		case *nonNull:
+			t = w.of
+		default:
+			return t
+		}
+	}
+}
+
+// The built-in scalar types of the GraphQL specification.
+var (
+	stringType = &scalar{
+		name: "String",
+		desc: "The `String` scalar type represents textual data, represented as UTF-8 character sequences.",
+		serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as String", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("expected String, got %v", v)
+		},
+	}
+	intType = &scalar{
+		name: "Int",
+		desc: "The `Int` scalar type represents non-fractional signed whole numeric values between -(2^31) and 2^31 - 1.",
+		serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case int:
+				return v, nil
+			case int32:
+				return v, nil
+			case uint:
+				return v, nil
+			case uint32:
+				return v, nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Int", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if n, ok := v.(json.Number); ok {
+				if i, err := strconv.ParseInt(string(n), 10, 32); err == nil {
+					return int(i), nil
+				}
+			}
+			return nil, fmt.Errorf("expected Int, got %v", v)
+		},
+	}
+	floatType = &scalar{
+		name: "Float",
+		desc: "The `Float` scalar type represents signed double-precision fractional values as specified by IEEE 754.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if f, ok := v.(float64); ok && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return f, nil
+			}
+			return nil, fmt.Errorf("cannot serialize %v as Float", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if n, ok := v.(json.Number); ok {
+				if f, err := n.Float64(); err == nil {
+					return f, nil
+				}
+			}
+			return nil, fmt.Errorf("expected Float, got %v", v)
+		},
+	}
+	booleanType = &scalar{
+		name: "Boolean",
+		desc: "The `Boolean` scalar type represents `true` or `false`.",
+		serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("cannot serialize %T as Boolean", v)
+		},
+		parse: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("expected Boolean, got %v", v)
+		},
+	}
+	idType = &scalar{
+		name:      "ID",
+		desc:      "The `ID` scalar type represents a unique identifier.",
+		serialize: stringType.serialize,
+		parse: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case json.Number:
+				if _, err := v.Int64(); err == nil {
+					return string(v), nil
+				}
+			}
+			return nil, fmt.Errorf("expected ID, got %v", v)
+		},
+	}
+)
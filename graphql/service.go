@@ -0,0 +1,225 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package graphql provides a GraphQL interface to Ethereum node data.
+package graphql
+
+//go:generate sh graphiql.sh
+//go:generate go-bindata -nometadata -o assets.go -prefix assets -pkg graphql assets/...
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"github.com/rs/cors"
+)
+
+// maxRequestContentLength is the maximum size of a GraphQL request body.
+const maxRequestContentLength = 1024 * 128
+
+// Service is a node service exposing the chain data of an Ethereum backend as
+// a GraphQL endpoint over HTTP, along with an embedded page for exploring it.
+type Service struct {
+	endpoint string // HTTP endpoint to listen on
+	cors     string // comma separated list of allowed CORS domains
+	handler  http.Handler
+
+	listener net.Listener // Listener serving the GraphQL requests, nil if stopped
+}
+
+// New creates a GraphQL service for the given backend, listening on the endpoint
+// once started.
+func New(backend ethapi.Backend, endpoint, cors string) (*Service, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("no GraphQL endpoint specified")
+	}
+	return &Service{
+		endpoint: endpoint,
+		cors:     cors,
+		handler:  newHandler(newEthSchema(backend)),
+	}, nil
+}
+
+// Protocols implements node.Service, returning the P2P network protocols used
+// by the GraphQL service (nil as it doesn't use the devp2p overlay network).
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints provided by the
+// GraphQL service (nil as it provides none).
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, opening the HTTP listener serving the GraphQL
+// requests.
+func (s *Service) Start(server *p2p.Server) error {
+	listener, err := net.Listen("tcp", s.endpoint)
+	if err != nil {
+		return err
+	}
+	go (&http.Server{Handler: newCorsHandler(s.handler, s.cors)}).Serve(listener)
+	glog.V(logger.Info).Infof("GraphQL endpoint opened: http://%s/graphql", s.endpoint)
+
+	s.listener = listener
+	return nil
+}
+
+// Stop implements node.Service, closing the HTTP listener.
+func (s *Service) Stop() error {
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+
+		glog.V(logger.Info).Infof("GraphQL endpoint closed: http://%s/graphql", s.endpoint)
+	}
+	return nil
+}
+
+func newCorsHandler(handler http.Handler, corsString string) http.Handler {
+	var allowedOrigins []string
+	for _, domain := range strings.Split(corsString, ",") {
+		allowedOrigins = append(allowedOrigins, strings.TrimSpace(domain))
+	}
+	c := cors.New(cors.Options{
+		AllowedOrigins: allowedOrigins,
+		AllowedMethods: []string{"POST", "GET"},
+		AllowedHeaders: []string{"Content-Type"},
+		MaxAge:         600,
+	})
+	return c.Handler(handler)
+}
+
+// request is a GraphQL request as posted in JSON.
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// handler serves GraphQL requests on /graphql and the explorer page on /.
+type handler struct {
+	schema *schema
+}
+
+func newHandler(s *schema) http.Handler {
+	h := &handler{schema: s}
+
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", h)
+	mux.HandleFunc("/", webHandler)
+	return mux
+}
+
+// webHandler serves the embedded GraphiQL page, or the minimal explorer if the
+// GraphiQL bundle is not embedded, along with their assets.
+func webHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Path
+	if name == "/" || name == "/graphql/ui" {
+		name = "/index.html"
+		// Serve GraphiQL if its bundle was fetched before generating the assets
+		if _, err := Asset("graphiql/graphiql.min.js"); err == nil {
+			name = "/graphiql.html"
+		}
+	}
+	blob, err := Asset(name[1:])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	if contentType, ok := contentTypes[path.Ext(name)]; ok {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Write(blob)
+}
+
+// contentTypes are the MIME types of the embedded assets.
+var contentTypes = map[string]string{
+	".html": "text/html; charset=utf-8",
+	".js":   "application/javascript",
+	".css":  "text/css",
+}
+
+// ServeHTTP executes a GraphQL request, accepting GET requests with URL encoded
+// parameters and POST requests with either a JSON or a raw GraphQL body.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case "GET":
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := decodeJSON([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		// Let a browser navigating to the endpoint explore it instead
+		if req.Query == "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			r.URL.Path = "/"
+			webHandler(w, r)
+			return
+		}
+
+	case "POST":
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestContentLength))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+			req.Query = string(body)
+		} else if err := decodeJSON(body, &req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "no query specified", http.StatusBadRequest)
+		return
+	}
+	resp := h.schema.execute(r.Context(), req.Query, req.OperationName, req.Variables)
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	w.Write(out)
+}
+
+// decodeJSON decodes a JSON value, retaining numbers as json.Number.
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
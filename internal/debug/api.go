@@ -33,6 +33,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 )
@@ -40,6 +41,78 @@ import (
 // Handler is the global debugging handler.
 var Handler = new(HandlerT)
 
+// sinks are the outputs of the structured logs, the terminal and an optional
+// log file.
+var sinks = &logSinks{
+	terminal: log.StreamHandler(os.Stderr, log.TerminalFormat(false)),
+	format:   log.TerminalFormat(false),
+}
+
+// glogger is the verbosity filter of the structured logs, installed as the root
+// log handler by Setup.
+var glogger = log.NewGlogHandler(sinks)
+
+// logSinks passes the records allowed by the verbosity filter to the terminal
+// and to the log file, which may be replaced or rotated at runtime.
+type logSinks struct {
+	terminal log.Handler
+	format   log.Format       // format of the records written to the log file
+	config   log.RotateConfig // rotation settings of new log files
+
+	file    *log.RotatingFile // current log file, nil if logging to the terminal only
+	handler log.Handler       // handler writing to the current log file
+	lock    sync.RWMutex
+}
+
+// Log implements log.Handler.
+func (s *logSinks) Log(r *log.Record) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	err := s.terminal.Log(r)
+	if s.handler != nil {
+		if ferr := s.handler.Log(r); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
+// setFile starts logging to a new file, closing the previous one. An empty path
+// disables file logging.
+func (s *logSinks) setFile(path string) error {
+	var (
+		handler log.Handler
+		file    *log.RotatingFile
+		err     error
+	)
+	if path != "" {
+		if handler, file, err = log.RotatingFileHandler(path, s.config, s.format); err != nil {
+			return err
+		}
+	}
+	s.lock.Lock()
+	old := s.file
+	s.file, s.handler = file, handler
+	s.lock.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// rotate moves the current log file aside and continues in a fresh one.
+func (s *logSinks) rotate() error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.file == nil {
+		return errors.New("not logging to a file")
+	}
+	return s.file.Rotate()
+}
+
 // HandlerT implements the debugging API.
 // Do not create values of this type, use the one
 // in the Handler variable instead.
@@ -51,19 +124,45 @@ type HandlerT struct {
 	traceFile string
 }
 
-// Verbosity sets the glog verbosity ceiling.
+// Verbosity sets the log verbosity ceiling.
 // The verbosity of individual packages and source files
 // can be raised using Vmodule.
 func (*HandlerT) Verbosity(level int) {
 	glog.SetV(level)
+	glogger.Verbosity(log.Lvl(level))
 }
 
-// Vmodule sets the glog verbosity pattern. See package
+// Vmodule sets the log verbosity pattern. See package
 // glog for details on pattern syntax.
 func (*HandlerT) Vmodule(pattern string) error {
+	if err := glogger.Vmodule(pattern); err != nil {
+		return err
+	}
 	return glog.GetVModule().Set(pattern)
 }
 
+// SetLogFile starts writing the logs to the given file besides the terminal,
+// replacing the current log file. The file is rotated according to the limits
+// given on the command line. An empty path stops logging to a file.
+func (*HandlerT) SetLogFile(path string) error {
+	if path != "" {
+		path = expandHome(path)
+	}
+	if err := sinks.setFile(path); err != nil {
+		return err
+	}
+	if path != "" {
+		log.Info("Writing logs to file", "path", path)
+	}
+	return nil
+}
+
+// RotateLog moves the current log file aside and continues in a fresh one,
+// regardless of the rotation limits.
+func (*HandlerT) RotateLog() error {
+	return sinks.rotate()
+}
+
 // BacktraceAt sets the glog backtrace location.
 // See package glog for details on pattern syntax.
 func (*HandlerT) BacktraceAt(location string) error {
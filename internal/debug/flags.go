@@ -18,25 +18,57 @@ package debug
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"runtime"
+	"time"
 
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/mattn/go-colorable"
+	"github.com/mattn/go-isatty"
 	"gopkg.in/urfave/cli.v1"
 )
 
 var (
-	verbosityFlag = cli.GenericFlag{
+	verbosityFlag = cli.IntFlag{
 		Name:  "verbosity",
 		Usage: "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=core, 5=debug, 6=detail",
-		Value: glog.GetVerbosity(),
+		Value: int(log.LvlInfo),
 	}
-	vmoduleFlag = cli.GenericFlag{
+	vmoduleFlag = cli.StringFlag{
 		Name:  "vmodule",
 		Usage: "Per-module verbosity: comma-separated list of <pattern>=<level> (e.g. eth/*=6,p2p=5)",
-		Value: glog.GetVModule(),
+	}
+	logFormatFlag = cli.StringFlag{
+		Name:  "logformat",
+		Usage: "Output format of structured logs: terminal, logfmt or json",
+		Value: "terminal",
+	}
+	logFileFlag = cli.StringFlag{
+		Name:  "log.file",
+		Usage: "Write logs to the given file besides the terminal",
+	}
+	logMaxSizeFlag = cli.IntFlag{
+		Name:  "log.maxsize",
+		Usage: "Rotate the log file after it grows to the given size in megabytes (0 = unlimited)",
+		Value: 100,
+	}
+	logMaxAgeFlag = cli.DurationFlag{
+		Name:  "log.maxage",
+		Usage: "Rotate the log file after the given time (e.g. 24h, 0 = unlimited)",
+	}
+	logMaxBackupsFlag = cli.IntFlag{
+		Name:  "log.maxbackups",
+		Usage: "Number of rotated log files to retain (0 = all)",
+		Value: 10,
+	}
+	logCompressFlag = cli.BoolFlag{
+		Name:  "log.compress",
+		Usage: "Compress rotated log files with gzip",
 	}
 	backtraceAtFlag = cli.GenericFlag{
 		Name:  "backtrace",
@@ -78,7 +110,8 @@ var (
 
 // Flags holds all command-line flags required for debugging.
 var Flags = []cli.Flag{
-	verbosityFlag, vmoduleFlag, backtraceAtFlag,
+	verbosityFlag, vmoduleFlag, logFormatFlag, backtraceAtFlag,
+	logFileFlag, logMaxSizeFlag, logMaxAgeFlag, logMaxBackupsFlag, logCompressFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	memprofilerateFlag, blockprofilerateFlag, cpuprofileFlag, traceFlag,
 }
@@ -89,6 +122,9 @@ func Setup(ctx *cli.Context) error {
 	// logging
 	glog.CopyStandardLogTo("INFO")
 	glog.SetToStderr(true)
+	if err := setupLogging(ctx); err != nil {
+		return err
+	}
 
 	// profiling, tracing
 	runtime.MemProfileRate = ctx.GlobalInt(memprofilerateFlag.Name)
@@ -115,9 +151,75 @@ func Setup(ctx *cli.Context) error {
 	return nil
 }
 
-// Exit stops all running profiles, flushing their output to the
-// respective file.
+// setupLogging configures both the glog and the structured loggers from the
+// verbosity and format flags.
+func setupLogging(ctx *cli.Context) error {
+	verbosity, vmodule := ctx.GlobalInt(verbosityFlag.Name), ctx.GlobalString(vmoduleFlag.Name)
+
+	glog.SetV(verbosity)
+	if err := glog.GetVModule().Set(vmodule); err != nil {
+		return fmt.Errorf("invalid --%s: %v", vmoduleFlag.Name, err)
+	}
+	var (
+		output   io.Writer = os.Stderr
+		usecolor           = isatty.IsTerminal(os.Stderr.Fd()) && os.Getenv("TERM") != "dumb"
+	)
+	if usecolor {
+		output = colorable.NewColorableStderr()
+	}
+	format, err := log.ParseFormat(ctx.GlobalString(logFormatFlag.Name), usecolor)
+	if err != nil {
+		return fmt.Errorf("invalid --%s: %v", logFormatFlag.Name, err)
+	}
+	sinks.terminal = log.StreamHandler(output, format)
+	if sinks.format, err = log.ParseFormat(ctx.GlobalString(logFormatFlag.Name), false); err != nil {
+		return err
+	}
+	sinks.config = log.RotateConfig{
+		MaxSize:    int64(ctx.GlobalInt(logMaxSizeFlag.Name)) * 1024 * 1024,
+		MaxAge:     ctx.GlobalDuration(logMaxAgeFlag.Name),
+		MaxBackups: ctx.GlobalInt(logMaxBackupsFlag.Name),
+		Compress:   ctx.GlobalBool(logCompressFlag.Name),
+	}
+	if path := ctx.GlobalString(logFileFlag.Name); path != "" {
+		if err := sinks.setFile(expandHome(path)); err != nil {
+			return fmt.Errorf("invalid --%s: %v", logFileFlag.Name, err)
+		}
+	}
+	glogger.Verbosity(log.Lvl(verbosity))
+	if err := glogger.Vmodule(vmodule); err != nil {
+		return fmt.Errorf("invalid --%s: %v", vmoduleFlag.Name, err)
+	}
+	log.Root().SetHandler(glogger)
+	glog.SetHandler(glogBridge)
+	return nil
+}
+
+// glogBridge passes the lines of the packages still logging through glog to
+// the structured log sinks, so they reach the log file too. The lines have been
+// filtered by the glog verbosity already.
+func glogBridge(severity, file string, line int, msg string) {
+	lvl := log.LvlInfo
+	switch severity {
+	case "WARNING":
+		lvl = log.LvlWarn
+	case "ERROR":
+		lvl = log.LvlError
+	case "FATAL":
+		lvl = log.LvlCrit
+	}
+	sinks.Log(&log.Record{
+		Time: time.Now(),
+		Lvl:  lvl,
+		Msg:  msg,
+		Ctx:  []interface{}{"caller", fmt.Sprintf("%s:%d", file, line)},
+	})
+}
+
+// Exit stops all running profiles and closes the log file, flushing their
+// output to the respective file.
 func Exit() {
 	Handler.StopCPUProfile()
 	Handler.StopGoTrace()
+	sinks.setFile("")
 }
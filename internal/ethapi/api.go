@@ -28,6 +28,7 @@ import (
 
 	"github.com/ethereum/ethash"
 	"github.com/EarthDollar/go-earthdollar/accounts"
+	"github.com/EarthDollar/go-earthdollar/accounts/abi"
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/common/hexutil"
 	"github.com/EarthDollar/go-earthdollar/core"
@@ -38,6 +39,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 	"github.com/EarthDollar/go-earthdollar/rpc"
 	"github.com/syndtr/goleveldb/leveldb"
@@ -45,7 +47,13 @@ import (
 	"golang.org/x/net/context"
 )
 
-const defaultGas = 90000
+const (
+	defaultGas = 90000
+
+	// maxAddrTxLimit is the maximum number of transactions returned by a
+	// single eth_getTransactionsByAddress call.
+	maxAddrTxLimit = 1000
+)
 
 // PublicEthereumAPI provides an API to access Ethereum related information.
 // It offers only methods that operate on public data that is freely available to anyone.
@@ -78,17 +86,25 @@ func (s *PublicEthereumAPI) ProtocolVersion() hexutil.Uint {
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	progress := s.b.Downloader().Progress()
 
-	// Return not syncing if the synchronisation already completed
-	if progress.CurrentBlock >= progress.HighestBlock {
+	// Calculate how far the log filtering index is behind the chain
+	size, sections := s.b.BloomStatus()
+	total := sections
+	if head := s.b.CurrentBlock().NumberU64(); size > 0 && head+1 >= params.BloomConfirms {
+		total = (head + 1 - params.BloomConfirms) / size
+	}
+	// Return not syncing if the synchronisation and the indexing already completed
+	if progress.CurrentBlock >= progress.HighestBlock && sections+1 >= total {
 		return false, nil
 	}
 	// Otherwise gather the block sync stats
 	return map[string]interface{}{
-		"startingBlock": hexutil.Uint64(progress.StartingBlock),
-		"currentBlock":  hexutil.Uint64(progress.CurrentBlock),
-		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
-		"pulledStates":  hexutil.Uint64(progress.PulledStates),
-		"knownStates":   hexutil.Uint64(progress.KnownStates),
+		"startingBlock":      hexutil.Uint64(progress.StartingBlock),
+		"currentBlock":       hexutil.Uint64(progress.CurrentBlock),
+		"highestBlock":       hexutil.Uint64(progress.HighestBlock),
+		"pulledStates":       hexutil.Uint64(progress.PulledStates),
+		"knownStates":        hexutil.Uint64(progress.KnownStates),
+		"bloomSections":      hexutil.Uint64(sections),
+		"bloomSectionsTotal": hexutil.Uint64(total),
 	}, nil
 }
 
@@ -305,6 +321,27 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	return signature, nil
 }
 
+// SignTypedData calculates an Ethereum ECDSA signature for the EIP-712 digest of
+// the given typed structured data:
+// keccak256("\x19\x01" + domainSeparator + hashStruct(message))
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The key used to calculate the signature is decrypted with the given password.
+func (s *PrivateAccountAPI) SignTypedData(ctx context.Context, data abi.TypedData, addr common.Address, passwd string) (hexutil.Bytes, error) {
+	hash, err := data.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.b.AccountManager().SignWithPassphrase(accounts.Account{Address: addr}, passwd, hash)
+	if err != nil {
+		return nil, err
+	}
+	signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	return signature, nil
+}
+
 // EcRecover returns the address for the account that was used to create the signature.
 // Note, this function is compatible with eth_sign and personal_sign. As such it recovers
 // the address of:
@@ -925,6 +962,59 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 	return rlp.EncodeToBytes(tx)
 }
 
+// GetTransactionsByAddress returns the transactions sent from or to the given
+// address in the canonical blocks between fromBlock and toBlock, ordered by their
+// position in the chain. The first offset matches are skipped and at most limit
+// transactions are returned (defaulting to the maximum if zero). The method is
+// only available if the address transaction index was enabled (--addrtxindex).
+func (s *PublicTransactionPoolAPI) GetTransactionsByAddress(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber, offset, limit hexutil.Uint64) ([]*RPCTransaction, error) {
+	chainDb := s.b.ChainDb()
+
+	head, ok := core.GetAddrTxIndexHead(chainDb)
+	if !ok {
+		return nil, errors.New("address transaction index not available")
+	}
+	if limit > maxAddrTxLimit {
+		return nil, fmt.Errorf("limit %d exceeds maximum of %d", limit, maxAddrTxLimit)
+	}
+	if limit == 0 {
+		limit = maxAddrTxLimit
+	}
+	// Resolve the block range, capping it at the last indexed block
+	from, to := uint64(fromBlock), uint64(toBlock)
+	if fromBlock < 0 {
+		from = head
+	}
+	if toBlock < 0 || to > head {
+		to = head
+	}
+	if from > to {
+		return []*RPCTransaction{}, nil
+	}
+	txs := make([]*RPCTransaction, 0)
+	for _, entry := range core.GetAddrTxEntries(chainDb, address, from, to, uint64(offset), uint64(limit)) {
+		// Skip any stale entries left behind by an interrupted reorg
+		if core.GetCanonicalHash(chainDb, entry.BlockNumber) != entry.BlockHash {
+			continue
+		}
+		block, err := s.b.GetBlock(ctx, entry.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block #%d [%x…] not found", entry.BlockNumber, entry.BlockHash[:4])
+		}
+		tx, err := newRPCTransactionFromBlockIndex(block, uint(entry.Index))
+		if err != nil {
+			return nil, err
+		}
+		if tx != nil {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (map[string]interface{}, error) {
 	receipt := core.GetReceipt(s.b.ChainDb(), txHash)
@@ -1112,6 +1202,37 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 	return signature, err
 }
 
+// HashTypedData calculates the EIP-712 digest of the given typed structured data,
+// as signed by SignTypedData:
+// keccak256("\x19\x01" + domainSeparator + hashStruct(message)).
+func (s *PublicTransactionPoolAPI) HashTypedData(data abi.TypedData) (common.Hash, error) {
+	hash, err := data.SigHash()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// SignTypedData calculates an ECDSA signature for the EIP-712 digest of the given
+// typed structured data:
+// keccak256("\x19\x01" + domainSeparator + hashStruct(message)).
+//
+// Note, the produced signature conforms to the secp256k1 curve R, S and V values,
+// where the V value will be 27 or 28 for legacy reasons.
+//
+// The account associated with addr must be unlocked.
+func (s *PublicTransactionPoolAPI) SignTypedData(addr common.Address, data abi.TypedData) (hexutil.Bytes, error) {
+	hash, err := data.SigHash()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.b.AccountManager().Sign(addr, hash)
+	if err == nil {
+		signature[64] += 27 // Transform V from 0/1 to 27/28 according to the yellow paper
+	}
+	return signature, err
+}
+
 // SignTransactionResult represents a RLP encoded signed transaction.
 type SignTransactionResult struct {
 	Raw hexutil.Bytes      `json:"raw"`
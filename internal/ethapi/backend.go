@@ -63,6 +63,10 @@ type Backend interface {
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
+
+	// BloomStatus returns the section size of the bloom bits log filtering index
+	// and the number of sections already indexed.
+	BloomStatus() (uint64, uint64)
 }
 
 type State interface {
@@ -0,0 +1,197 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package toml configures github.com/naoina/toml for configuration files.
+//
+// Keys are mapped onto the exported fields of Go structs by field name, which
+// may be overridden or suppressed (with "-") using a `toml` struct tag. Decoding
+// errors point at the line and the dotted path of the offending key, and
+// time.Duration values may be given as strings like "1m30s".
+package toml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/naoina/toml"
+	"github.com/naoina/toml/ast"
+)
+
+// Error is a decoding error, pointing at the line and, if known, the key of
+// the offending input.
+type Error struct {
+	Line int    // Line number of the input the error occurred on
+	Key  string // Dotted path of the offending key, if any
+	Msg  string // Description of the error
+}
+
+func (e *Error) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Msg)
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	// settings matches keys to field names verbatim, like the Go field names
+	// appearing in dumped configurations.
+	settings = toml.Config{
+		NormFieldName: func(rt reflect.Type, key string) string { return key },
+		FieldToKey:    func(rt reflect.Type, field string) string { return field },
+		MissingField:  func(rt reflect.Type, key string) error { return errUnknownField },
+	}
+	errUnknownField = errors.New("unknown field")
+)
+
+// Marshal returns the TOML encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	return settings.Marshal(v)
+}
+
+// Unmarshal parses the TOML document and stores the result in the struct
+// pointed to by v. Fields not present in the document are left untouched,
+// while keys not matching any field are reported as errors.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("toml: Unmarshal needs a non-nil pointer")
+	}
+	root, err := toml.Parse(data)
+	if err != nil {
+		return err
+	}
+	err = prepareTable(root, rv.Elem())
+	if err == nil {
+		err = settings.UnmarshalTable(root, v)
+	}
+	if lerr, ok := err.(*toml.LineError); ok {
+		key, _ := keyPath(root, lerr.Line, "")
+		return &Error{Line: lerr.Line, Key: key, Msg: lerr.Err.Error()}
+	}
+	return err
+}
+
+// fieldByKey looks up the struct field a key maps to.
+func fieldByKey(rv reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		name := strings.TrimSpace(strings.SplitN(field.Tag.Get("toml"), ",", 2)[0])
+		if name == "" {
+			name = field.Name
+		}
+		if name == key {
+			return rv.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// prepareTable readies a struct for decoding a table onto it. The decoder
+// stores into existing pointers and big integers, so the fields set by the
+// document are reset first to leave values shared with the caller untouched.
+// Strings assigned to time.Duration fields are replaced by their value in
+// nanoseconds, which is what the decoder expects.
+func prepareTable(t *ast.Table, rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		// Decode onto a copy of the pointed to struct
+		cpy := reflect.New(rv.Type().Elem())
+		cpy.Elem().Set(rv.Elem())
+		rv.Set(cpy)
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil // reported by the decoder
+	}
+	for key, field := range t.Fields {
+		fv, ok := fieldByKey(rv, key)
+		if !ok {
+			continue // reported by the decoder
+		}
+		switch f := field.(type) {
+		case *ast.KeyValue:
+			fv.Set(reflect.Zero(fv.Type()))
+			if s, ok := f.Value.(*ast.String); ok && fv.Type() == durationType {
+				d, err := time.ParseDuration(s.Value)
+				if err != nil {
+					return &toml.LineError{Line: f.Line, Err: err}
+				}
+				value := strconv.FormatInt(int64(d), 10)
+				f.Value = &ast.Integer{Position: s.Position, Value: value, Data: []rune(value)}
+			}
+		case *ast.Table:
+			if err := prepareTable(f, fv); err != nil {
+				return err
+			}
+		case []*ast.Table:
+			if fv.Kind() != reflect.Slice {
+				continue
+			}
+			// The decoder creates a new slice, prepare the tables on scratch items
+			for _, sub := range f {
+				if err := prepareTable(sub, reflect.New(fv.Type().Elem()).Elem()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// keyPath finds the dotted path of the key or table defined on a line.
+func keyPath(t *ast.Table, line int, path string) (string, bool) {
+	for key, field := range t.Fields {
+		name := key
+		if path != "" {
+			name = path + "." + key
+		}
+		switch f := field.(type) {
+		case *ast.KeyValue:
+			if f.Line == line {
+				return name, true
+			}
+		case *ast.Table:
+			if found, ok := keyPath(f, line, name); ok {
+				return found, true
+			}
+			if f.Line == line {
+				return name, true
+			}
+		case []*ast.Table:
+			for i, sub := range f {
+				elem := fmt.Sprintf("%s[%d]", name, i)
+				if found, ok := keyPath(sub, line, elem); ok {
+					return found, true
+				}
+				if sub.Line == line {
+					return elem, true
+				}
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package toml
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testInner struct {
+	Name    string
+	Enabled bool
+	Ratio   float64
+	Timeout time.Duration
+	Ignored string `toml:"-"`
+}
+
+type testConfig struct {
+	Port    uint16
+	Peers   int
+	Hosts   []string
+	Price   *big.Int
+	Limit   *big.Int
+	Renamed string `toml:"other-name"`
+	Inner   testInner
+	Nested  *testInner
+	List    []testInner
+}
+
+const testDocument = `
+# Comments and blank lines are ignored
+Port = 30303
+Peers = -1_000 # trailing comment
+Hosts = [
+	"a\tb",   # comments inside arrays
+	'c:\d',
+]
+Price = 18000000000
+Limit = "0x10"
+other-name = "\u00e9"
+
+[Inner]
+Name = "inner"
+Enabled = true
+Ratio = 0.5
+Timeout = "1m30s"
+
+[ Nested ]
+Ratio = 1.0
+
+[[List]]
+Name = "first"
+
+[[List]]
+Name = "second"
+Timeout = 1000
+`
+
+// Tests that a document is decoded onto a struct, and that encoding the result
+// yields an equivalent document.
+func TestRoundTrip(t *testing.T) {
+	var (
+		price  = big.NewInt(1)
+		limit  = big.NewInt(1)
+		config = testConfig{Price: price, Limit: limit, Inner: testInner{Ignored: "keep"}}
+	)
+	if err := Unmarshal([]byte(testDocument), &config); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+	want := testConfig{
+		Port:    30303,
+		Peers:   -1000,
+		Hosts:   []string{"a\tb", `c:\d`},
+		Price:   big.NewInt(18000000000),
+		Limit:   big.NewInt(16),
+		Renamed: "é",
+		Inner:   testInner{Name: "inner", Enabled: true, Ratio: 0.5, Timeout: 90 * time.Second, Ignored: "keep"},
+		Nested:  &testInner{Ratio: 1},
+		List:    []testInner{{Name: "first"}, {Name: "second", Timeout: time.Microsecond}},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Fatalf("decoded config mismatch:\nhave %+v\nwant %+v", config, want)
+	}
+	if price.Int64() != 1 || limit.Int64() != 1 {
+		t.Fatalf("original values modified: price %v, limit %v", price, limit)
+	}
+	enc, err := Marshal(config)
+	if err != nil {
+		t.Fatalf("failed to encode config: %v", err)
+	}
+	var dec testConfig
+	if err := Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode encoded config: %v\n%s", err, enc)
+	}
+	want.Inner.Ignored = ""
+	if !reflect.DeepEqual(dec, want) {
+		t.Fatalf("round trip mismatch:\nhave %+v\nwant %+v", dec, want)
+	}
+}
+
+// Tests that invalid documents are rejected, pointing at the offending key if
+// the document could be parsed.
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{"Port = 1\nPeerz = 2", "line 2: Peerz: unknown field"},
+		{"[Inner]\nName = 1", "line 2: Inner.Name: cannot unmarshal TOML integer into string"},
+		{"[Inner]\nIgnored = \"x\"", "line 2: Inner.Ignored: field corresponding to `Ignored' in toml.testInner cannot be set through TOML"},
+		{"Port = 70000", "line 1: Port: value 70000 is out of range for uint16"},
+		{"Hosts = [\n\"a\",\n1]", "line 3: Hosts: array can't contain multiple types"},
+		{"Price = \"abc\"", "line 1: Price: math/big: cannot unmarshal \"abc\" into a *big.Int"},
+		{"Port = 1\nPort = 2", "line 2: key `Port' is in conflict with line 1d"},
+		{"[Inner]\n[Inner]", "line 2: table `Inner' is in conflict with table in line 1"},
+		{"Port = 1\n[Port]", "line 2: table `Port' is in conflict with line 1"},
+		{"Inner = 1", "line 1: Inner: cannot unmarshal TOML integer into toml.testInner"},
+		{"[Inner]\nTimeout = true", "line 2: Inner.Timeout: cannot unmarshal TOML boolean into time.Duration"},
+		{"Port = 1 2", "line 1: invalid TOML syntax"},
+		{"Renamed = \"x", "line 1: invalid TOML syntax"},
+		{"[[Inner]]", "line 1: Inner[0]: cannot unmarshal TOML array table into toml.testInner (need slice)"},
+		{"[[List]]\nName = 1", "line 2: List[0].Name: cannot unmarshal TOML integer into string"},
+		{"[[List]]\n[[List]]\nNames = 1", "line 3: List[1].Names: unknown field"},
+		{"[Inner]\nTimeout = \"1x\"", "line 2: Inner.Timeout: time: unknown unit \"x\" in duration \"1x\""},
+		{"[[List]]\n[List]", "line 2: table `List' is in conflict with array table in line 1"},
+		{"Hosts = []\n[[Hosts]]", "line 2: array table `Hosts' is in conflict with line 1"},
+	}
+	for i, tt := range tests {
+		var config testConfig
+		err := Unmarshal([]byte(tt.doc), &config)
+		if err == nil {
+			t.Errorf("test %d: expected error %q, got none", i, tt.err)
+			continue
+		}
+		if err.Error() != tt.err {
+			t.Errorf("test %d: error mismatch: have %q, want %q", i, err, tt.err)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"time"
 
+	ethereum "github.com/EarthDollar/go-earthdollar"
 	"github.com/EarthDollar/go-earthdollar/accounts"
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/common/compiler"
@@ -36,13 +37,13 @@ import (
 	"github.com/EarthDollar/go-earthdollar/event"
 	"github.com/EarthDollar/go-earthdollar/internal/ethapi"
 	"github.com/EarthDollar/go-earthdollar/light"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/node"
 	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/params"
 	"github.com/EarthDollar/go-earthdollar/pow"
 	rpc "github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
 )
 
 type LightEthereum struct {
@@ -58,6 +59,8 @@ type LightEthereum struct {
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
 
+	bloomIndexer *core.ChainIndexer // Bloom indexer operating during header imports
+
 	ApiBackend *LesApiBackend
 
 	eventMux       *event.TypeMux
@@ -89,6 +92,7 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		odr:            odr,
 		relay:          relay,
 		chainDb:        chainDb,
+		bloomIndexer:   eth.NewBloomIndexer(chainDb, params.BloomBitsBlocks),
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
 		pow:            pow,
@@ -109,6 +113,8 @@ func New(ctx *node.ServiceContext, config *eth.Config) (*LightEthereum, error) {
 		return nil, err
 	}
 
+	eth.bloomIndexer.Start(eth.blockchain.CurrentHeader(), eth.eventMux)
+
 	eth.txPool = light.NewTxPool(eth.chainConfig, eth.eventMux, eth.blockchain, eth.relay)
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.LightMode, config.NetworkId, eth.eventMux, eth.pow, eth.blockchain, nil, chainDb, odr, relay); err != nil {
 		return nil, err
@@ -179,6 +185,16 @@ func (s *LightEthereum) LesVersion() int                    { return int(s.proto
 func (s *LightEthereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 func (s *LightEthereum) EventMux() *event.TypeMux           { return s.eventMux }
 
+// SyncProgress implements ethereum.ChainSyncReader, returning the progress of
+// the chain synchronisation, or nil if the chain is in sync.
+func (s *LightEthereum) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	progress := s.protocolManager.downloader.Progress()
+	if progress.CurrentBlock >= progress.HighestBlock {
+		return nil, nil
+	}
+	return &progress, nil
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *LightEthereum) Protocols() []p2p.Protocol {
@@ -188,7 +204,7 @@ func (s *LightEthereum) Protocols() []p2p.Protocol {
 // Start implements node.Service, starting all internal goroutines needed by the
 // Ethereum protocol implementation.
 func (s *LightEthereum) Start(srvr *p2p.Server) error {
-	glog.V(logger.Info).Infof("WARNING: light client mode is an experimental feature")
+	log.Warn("Light client mode is an experimental feature")
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.netVersionId)
 	s.protocolManager.Start(srvr)
 	return nil
@@ -198,6 +214,7 @@ func (s *LightEthereum) Start(srvr *p2p.Server) error {
 // Ethereum protocol.
 func (s *LightEthereum) Stop() error {
 	s.odr.Stop()
+	s.bloomIndexer.Stop()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	s.txPool.Stop()
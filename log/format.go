@@ -0,0 +1,236 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	termTimeFormat = "01-02|15:04:05"
+	timeFormat     = "2006-01-02T15:04:05-0700"
+	termMsgJust    = 40
+)
+
+// Format serializes a record.
+type Format interface {
+	Format(r *Record) []byte
+}
+
+// FormatFunc returns a Format calling the given function.
+func FormatFunc(f func(*Record) []byte) Format {
+	return formatFunc(f)
+}
+
+type formatFunc func(*Record) []byte
+
+func (f formatFunc) Format(r *Record) []byte { return f(r) }
+
+// ParseFormat returns the format with the given name: "terminal", "logfmt" or
+// "json". Terminal output is colored if requested.
+func ParseFormat(name string, color bool) (Format, error) {
+	switch name {
+	case "terminal", "":
+		return TerminalFormat(color), nil
+	case "logfmt":
+		return LogfmtFormat(), nil
+	case "json":
+		return JSONFormat(), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", name)
+	}
+}
+
+// TerminalFormat formats records for human readers, aligning the messages and
+// optionally coloring the levels:
+//
+//	INFO [10-18|12:30:00] Imported new chain segment               blocks=1 number=4021
+func TerminalFormat(color bool) Format {
+	return FormatFunc(func(r *Record) []byte {
+		b := new(bytes.Buffer)
+		lvl := r.Lvl.String()
+		if color {
+			if code := levelColor(r.Lvl); code != 0 {
+				lvl = fmt.Sprintf("\x1b[%dm%s\x1b[0m", code, lvl)
+			}
+		}
+		fmt.Fprintf(b, "%s[%s] %s ", lvl, r.Time.Format(termTimeFormat), r.Msg)
+
+		// Align the context of short messages for readability
+		if len(r.Ctx) > 0 && len(r.Msg) < termMsgJust {
+			b.Write(bytes.Repeat([]byte{' '}, termMsgJust-len(r.Msg)))
+		}
+		writeLogfmt(b, r.Ctx, color, levelColor(r.Lvl))
+		return b.Bytes()
+	})
+}
+
+// levelColor returns the ANSI color code of a level, 0 if uncolored.
+func levelColor(lvl Lvl) int {
+	switch lvl {
+	case LvlCrit:
+		return 35
+	case LvlError:
+		return 31
+	case LvlWarn:
+		return 33
+	case LvlInfo:
+		return 32
+	case LvlDebug:
+		return 36
+	default:
+		return 0
+	}
+}
+
+// LogfmtFormat formats records as logfmt key=value lines:
+//
+//	t=2017-10-18T12:30:00+0000 lvl=info msg="Imported new chain segment" blocks=1
+func LogfmtFormat() Format {
+	return FormatFunc(func(r *Record) []byte {
+		common := []interface{}{"t", r.Time, "lvl", r.Lvl.name(), "msg", r.Msg}
+		b := new(bytes.Buffer)
+		writeLogfmt(b, append(common, r.Ctx...), false, 0)
+		return b.Bytes()
+	})
+}
+
+// writeLogfmt writes the key/value pairs of a context, terminated by a newline.
+func writeLogfmt(b *bytes.Buffer, ctx []interface{}, color bool, code int) {
+	for i := 0; i < len(ctx); i += 2 {
+		if i != 0 {
+			b.WriteByte(' ')
+		}
+		k, ok := ctx[i].(string)
+		if !ok {
+			k, ctx[i+1] = errorKey, formatValue(ctx[i])
+		}
+		if color && code != 0 {
+			fmt.Fprintf(b, "\x1b[%dm%s\x1b[0m=", code, k)
+		} else {
+			b.WriteString(k)
+			b.WriteByte('=')
+		}
+		b.WriteString(formatValue(ctx[i+1]))
+	}
+	b.WriteByte('\n')
+}
+
+// JSONFormat formats records as JSON objects, one per line:
+//
+//	{"t":"2017-10-18T12:30:00+0000","lvl":"info","msg":"Imported new chain segment","blocks":1}
+func JSONFormat() Format {
+	return FormatFunc(func(r *Record) []byte {
+		props := map[string]interface{}{
+			"t":   r.Time.Format(timeFormat),
+			"lvl": r.Lvl.name(),
+			"msg": r.Msg,
+		}
+		for i := 0; i < len(r.Ctx); i += 2 {
+			k, ok := r.Ctx[i].(string)
+			if !ok {
+				props[errorKey] = fmt.Sprintf("%+v is not a string key", r.Ctx[i])
+				continue
+			}
+			props[k] = jsonValue(r.Ctx[i+1])
+		}
+		out, err := json.Marshal(props)
+		if err != nil {
+			out, _ = json.Marshal(map[string]string{errorKey: err.Error()})
+		}
+		return append(out, '\n')
+	})
+}
+
+// jsonValue converts values without a meaningful JSON encoding to strings.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(timeFormat)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		if _, ok := v.(json.Marshaler); ok {
+			return v
+		}
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// formatValue formats a context value for the text formats, quoting it if it
+// contains whitespace or special characters.
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "nil"
+	}
+	switch v := value.(type) {
+	case time.Time:
+		return v.Format(timeFormat)
+	case time.Duration:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', 3, 64)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v)
+	case string:
+		return escape(v)
+	case error:
+		return escape(v.Error())
+	case fmt.Stringer:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return "nil"
+		}
+		return escape(v.String())
+	default:
+		// Hashes, addresses and other byte arrays and slices are printed as hex
+		// instead of lists of decimal numbers.
+		rv := reflect.ValueOf(value)
+		if (rv.Kind() == reflect.Array || rv.Kind() == reflect.Slice) && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return "0x" + hex.EncodeToString(b)
+		}
+		return escape(fmt.Sprintf("%+v", value))
+	}
+}
+
+// escape quotes a string if it is empty or contains characters which would
+// break the key=value syntax.
+func escape(s string) string {
+	if s != "" && !strings.ContainsAny(s, " =\"\t\r\n\\") {
+		for _, r := range s {
+			if r < ' ' || r > '~' {
+				return strconv.Quote(s)
+			}
+		}
+		return s
+	}
+	return strconv.Quote(s)
+}
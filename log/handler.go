@@ -0,0 +1,76 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"io"
+	"sync"
+)
+
+// Handler processes the records written to a logger.
+type Handler interface {
+	Log(r *Record) error
+}
+
+// FuncHandler returns a handler calling the given function for every record.
+func FuncHandler(fn func(r *Record) error) Handler {
+	return funcHandler(fn)
+}
+
+type funcHandler func(r *Record) error
+
+func (h funcHandler) Log(r *Record) error { return h(r) }
+
+// StreamHandler returns a handler writing records to w in the given format.
+// Writes are serialized, so the handler may be used concurrently.
+func StreamHandler(w io.Writer, format Format) Handler {
+	var lock sync.Mutex
+	return FuncHandler(func(r *Record) error {
+		out := format.Format(r)
+
+		lock.Lock()
+		defer lock.Unlock()
+		_, err := w.Write(out)
+		return err
+	})
+}
+
+// LvlFilterHandler returns a handler passing the records at or above the given
+// severity to h, discarding all others.
+func LvlFilterHandler(max Lvl, h Handler) Handler {
+	return FuncHandler(func(r *Record) error {
+		if r.Lvl > max {
+			return nil
+		}
+		return h.Log(r)
+	})
+}
+
+// MultiHandler returns a handler passing every record to all given handlers.
+func MultiHandler(hs ...Handler) Handler {
+	return FuncHandler(func(r *Record) error {
+		for _, h := range hs {
+			h.Log(r)
+		}
+		return nil
+	})
+}
+
+// DiscardHandler returns a handler dropping all records.
+func DiscardHandler() Handler {
+	return FuncHandler(func(r *Record) error { return nil })
+}
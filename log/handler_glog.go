@@ -0,0 +1,173 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// errVmoduleSyntax is returned when a user vmodule pattern is invalid.
+var errVmoduleSyntax = errors.New("expect comma-separated list of filename=N")
+
+// GlogHandler filters records by severity like glog does, based on a global
+// verbosity and per source file overrides matching the --vmodule patterns.
+type GlogHandler struct {
+	origin Handler
+
+	level    uint32 // current global verbosity
+	override uint32 // whether any vmodule patterns are set
+
+	lock      sync.RWMutex
+	patterns  []pattern
+	siteCache map[uintptr]Lvl // verbosity of the call sites seen so far
+}
+
+// pattern is a vmodule rule, the verbosity of the source files matching a
+// regular expression.
+type pattern struct {
+	re    *regexp.Regexp
+	level Lvl
+}
+
+// NewGlogHandler creates a handler passing the records allowed by its verbosity
+// settings to h. Only critical records pass until a verbosity is set.
+func NewGlogHandler(h Handler) *GlogHandler {
+	return &GlogHandler{
+		origin:    h,
+		siteCache: make(map[uintptr]Lvl),
+	}
+}
+
+// Verbosity sets the global severity threshold of the records to pass on.
+// Higher values are accepted for compatibility with the glog levels, anything
+// above LvlTrace allowing all records.
+func (h *GlogHandler) Verbosity(level Lvl) {
+	if level < 0 {
+		level = 0
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	atomic.StoreUint32(&h.level, uint32(level))
+	h.siteCache = make(map[uintptr]Lvl)
+}
+
+// Vmodule sets the per source file verbosity overrides. The syntax is the same
+// as glog's: a comma-separated list of pattern=N, where the pattern is a file
+// (e.g. "core/blockchain.go"), a package (e.g. "p2p") or a package subtree
+// (e.g. "eth/*"), matched against the end of the import path of the source.
+func (h *GlogHandler) Vmodule(ruleset string) error {
+	var patterns []pattern
+	for _, rule := range strings.Split(ruleset, ",") {
+		if len(rule) == 0 {
+			continue // Ignore trailing commas
+		}
+		parts := strings.Split(rule, "=")
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return errVmoduleSyntax
+		}
+		level, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return errVmoduleSyntax
+		}
+		if level < 0 {
+			return errors.New("negative value for vmodule level")
+		}
+		if level == 0 {
+			continue // Ignore, it's harmless but pointless
+		}
+		re, err := compileModulePattern(parts[0])
+		if err != nil {
+			return err
+		}
+		patterns = append(patterns, pattern{re, Lvl(level)})
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.patterns = patterns
+	h.siteCache = make(map[uintptr]Lvl)
+	if len(patterns) > 0 {
+		atomic.StoreUint32(&h.override, 1)
+	} else {
+		atomic.StoreUint32(&h.override, 0)
+	}
+	return nil
+}
+
+// compileModulePattern converts a vmodule pattern to a regular expression
+// matching source file paths.
+func compileModulePattern(pat string) (*regexp.Regexp, error) {
+	re := ".*"
+	for _, comp := range strings.Split(pat, "/") {
+		if comp == "*" {
+			re += "(/.*)?"
+		} else if comp != "" {
+			re += "/" + regexp.QuoteMeta(comp)
+		}
+	}
+	if !strings.HasSuffix(pat, ".go") {
+		re += "/[^/]+\\.go"
+	}
+	return regexp.Compile(re + "$")
+}
+
+// Log implements Handler, passing the record on if allowed by the global
+// verbosity or the vmodule override of its source file.
+func (h *GlogHandler) Log(r *Record) error {
+	if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
+		return h.origin.Log(r)
+	}
+	if atomic.LoadUint32(&h.override) == 0 {
+		return nil
+	}
+	h.lock.RLock()
+	lvl, ok := h.siteCache[r.PC]
+	h.lock.RUnlock()
+
+	if !ok {
+		h.lock.Lock()
+		lvl = Lvl(atomic.LoadUint32(&h.level))
+		file, _ := r.Location()
+		file = trimToImportPath(file)
+		for _, rule := range h.patterns {
+			if rule.re.MatchString(file) {
+				lvl = rule.level
+				break
+			}
+		}
+		h.siteCache[r.PC] = lvl
+		h.lock.Unlock()
+	}
+	if lvl >= r.Lvl {
+		return h.origin.Log(r)
+	}
+	return nil
+}
+
+// trimToImportPath strips the GOPATH prefix of a source file path.
+func trimToImportPath(file string) string {
+	if root := strings.LastIndex(file, "src/"); root != -1 {
+		file = file[root+3:]
+	}
+	return file
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+)
+
+// recorder is a handler storing all records.
+type recorder struct {
+	records []*Record
+}
+
+func (r *recorder) Log(rec *Record) error {
+	r.records = append(r.records, rec)
+	return nil
+}
+
+func TestLoggerContext(t *testing.T) {
+	rec := new(recorder)
+	l := &logger{h: new(swapHandler)}
+	l.SetHandler(rec)
+
+	child := l.New("peer", "abcd")
+	child.Info("Message", "number", 1, "odd")
+
+	if len(rec.records) != 1 {
+		t.Fatalf("record count mismatch: have %d, want 1", len(rec.records))
+	}
+	r := rec.records[0]
+	if r.Msg != "Message" || r.Lvl != LvlInfo {
+		t.Errorf("record mismatch: have %q at %v", r.Msg, r.Lvl)
+	}
+	want := []interface{}{"peer", "abcd", "number", 1, "odd", nil, errorKey, "Normalized odd number of arguments by adding nil"}
+	if len(r.Ctx) != len(want) {
+		t.Fatalf("context mismatch: have %v, want %v", r.Ctx, want)
+	}
+	for i := range want {
+		if r.Ctx[i] != want[i] {
+			t.Errorf("context item %d mismatch: have %v, want %v", i, r.Ctx[i], want[i])
+		}
+	}
+	if file, _ := r.Location(); !strings.HasSuffix(file, "log_test.go") {
+		t.Errorf("call site mismatch: have %s", file)
+	}
+	// Handlers set on the parent apply to existing children
+	rec2 := new(recorder)
+	l.SetHandler(rec2)
+	child.Debug("Other")
+	if len(rec2.records) != 1 {
+		t.Errorf("child not using replaced parent handler")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	r := &Record{
+		Time: time.Date(2017, 10, 18, 12, 30, 0, 0, time.UTC),
+		Lvl:  LvlWarn,
+		Msg:  "Peer dropped",
+		Ctx:  []interface{}{"peer", "abcd", "err", errors.New("too many peers"), "elapsed", 1500 * time.Millisecond, "ok", true},
+	}
+	term := string(TerminalFormat(false).Format(r))
+	if want := "WARN [10-18|12:30:00] Peer dropped                             peer=abcd err=\"too many peers\" elapsed=1.5s ok=true\n"; term != want {
+		t.Errorf("terminal format mismatch:\nhave %q\nwant %q", term, want)
+	}
+	logfmt := string(LogfmtFormat().Format(r))
+	if want := "t=2017-10-18T12:30:00+0000 lvl=warn msg=\"Peer dropped\" peer=abcd err=\"too many peers\" elapsed=1.5s ok=true\n"; logfmt != want {
+		t.Errorf("logfmt format mismatch:\nhave %q\nwant %q", logfmt, want)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(JSONFormat().Format(r), &obj); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if obj["lvl"] != "warn" || obj["msg"] != "Peer dropped" || obj["err"] != "too many peers" || obj["peer"] != "abcd" || obj["ok"] != true {
+		t.Errorf("JSON output mismatch: %v", obj)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	r := &Record{
+		Time: time.Date(2017, 10, 18, 12, 30, 0, 0, time.UTC),
+		Lvl:  LvlInfo,
+		Msg:  "Imported block",
+		Ctx: []interface{}{
+			"hash", common.HexToHash("0x4194102a7b5a2b1c"),
+			"miner", common.HexToAddress("0x8888f1f195afa192cfee860698584c030f4c9db1"),
+			"extra", []byte{0xde, 0xad},
+		},
+	}
+	want := "hash=0x0000000000000000000000000000000000000000000000004194102a7b5a2b1c miner=0x8888f1f195afa192cfee860698584c030f4c9db1 extra=0xdead\n"
+	if logfmt := string(LogfmtFormat().Format(r)); !strings.HasSuffix(logfmt, want) {
+		t.Errorf("logfmt format mismatch:\nhave %q\nwant suffix %q", logfmt, want)
+	}
+	if term := string(TerminalFormat(false).Format(r)); !strings.HasSuffix(term, want) {
+		t.Errorf("terminal format mismatch:\nhave %q\nwant suffix %q", term, want)
+	}
+}
+
+func TestGlogHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	glogger := NewGlogHandler(StreamHandler(buf, LogfmtFormat()))
+	l := &logger{h: new(swapHandler)}
+	l.SetHandler(glogger)
+
+	glogger.Verbosity(LvlInfo)
+	l.Info("visible")
+	l.Debug("hidden")
+	if out := buf.String(); !strings.Contains(out, "visible") || strings.Contains(out, "hidden") {
+		t.Errorf("verbosity filtering failed:\n%s", out)
+	}
+	// Raise the verbosity of this package through vmodule
+	buf.Reset()
+	if err := glogger.Vmodule("log=5"); err != nil {
+		t.Fatalf("failed to set vmodule: %v", err)
+	}
+	l.Trace("traced")
+	if !strings.Contains(buf.String(), "traced") {
+		t.Errorf("vmodule override not applied")
+	}
+	buf.Reset()
+	if err := glogger.Vmodule("p2p/*=5,other.go=5"); err != nil {
+		t.Fatalf("failed to set vmodule: %v", err)
+	}
+	l.Trace("traced")
+	if buf.Len() != 0 {
+		t.Errorf("vmodule override applied to other files: %s", buf.String())
+	}
+	for _, invalid := range []string{"log", "log=x", "log=-1"} {
+		if err := glogger.Vmodule(invalid); err == nil {
+			t.Errorf("invalid vmodule %q accepted", invalid)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package log implements structured, leveled logging.
+//
+// Log records consist of a message and a list of alternating keys and values
+// giving its context, e.g.
+//
+//	log.Info("Imported new chain segment", "blocks", 10, "number", 4021, "hash", hash)
+//
+// Loggers created with New attach context to all their records, which is useful
+// to identify the subsystem or the peer a record originates from. Records are
+// passed to the Handler of the logger, which filters and formats them. The root
+// logger discards all records until a handler is set, usually a GlogHandler
+// filtering on the verbosity and vmodule settings of the command line.
+package log
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+const errorKey = "LOG_ERROR"
+
+// Lvl is the severity of a log record.
+type Lvl int
+
+const (
+	LvlCrit Lvl = iota
+	LvlError
+	LvlWarn
+	LvlInfo
+	LvlDebug
+	LvlTrace
+)
+
+// String returns the name of a level, padded to five characters for aligned
+// output.
+func (l Lvl) String() string {
+	switch l {
+	case LvlCrit:
+		return "CRIT "
+	case LvlError:
+		return "ERROR"
+	case LvlWarn:
+		return "WARN "
+	case LvlInfo:
+		return "INFO "
+	case LvlDebug:
+		return "DEBUG"
+	case LvlTrace:
+		return "TRACE"
+	default:
+		return fmt.Sprintf("LVL%d", int(l))
+	}
+}
+
+// name returns the lowercase name of a level, used by the machine readable
+// formats.
+func (l Lvl) name() string {
+	switch l {
+	case LvlCrit:
+		return "crit"
+	case LvlError:
+		return "error"
+	case LvlWarn:
+		return "warn"
+	case LvlInfo:
+		return "info"
+	case LvlDebug:
+		return "debug"
+	case LvlTrace:
+		return "trace"
+	default:
+		return fmt.Sprintf("lvl%d", int(l))
+	}
+}
+
+// Record is a single log entry.
+type Record struct {
+	Time time.Time
+	Lvl  Lvl
+	Msg  string
+	Ctx  []interface{} // alternating keys and values
+	PC   uintptr       // program counter of the logging call site, 0 if unknown
+}
+
+// Location returns the source file and line of the logging call site.
+func (r *Record) Location() (string, int) {
+	if r.PC == 0 {
+		return "???", 0
+	}
+	fn := runtime.FuncForPC(r.PC - 1)
+	if fn == nil {
+		return "???", 0
+	}
+	return fn.FileLine(r.PC - 1)
+}
+
+// Logger writes records with a fixed context to a handler.
+type Logger interface {
+	// New returns a logger adding the given key/value pairs to the context of
+	// all its records, and writing them to the handler of this logger.
+	New(ctx ...interface{}) Logger
+
+	// GetHandler returns the handler of the logger.
+	GetHandler() Handler
+
+	// SetHandler replaces the handler of the logger.
+	SetHandler(h Handler)
+
+	// Log methods for the different levels. Crit terminates the process after
+	// writing the record.
+	Trace(msg string, ctx ...interface{})
+	Debug(msg string, ctx ...interface{})
+	Info(msg string, ctx ...interface{})
+	Warn(msg string, ctx ...interface{})
+	Error(msg string, ctx ...interface{})
+	Crit(msg string, ctx ...interface{})
+}
+
+type logger struct {
+	ctx []interface{}
+	h   *swapHandler
+}
+
+// write creates a record and passes it to the handler. It must be called
+// directly from the exported logging functions, so the call site is found at a
+// fixed depth of the stack.
+func (l *logger) write(msg string, lvl Lvl, ctx []interface{}) {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+
+	l.h.Log(&Record{
+		Time: time.Now(),
+		Lvl:  lvl,
+		Msg:  msg,
+		Ctx:  newContext(l.ctx, ctx),
+		PC:   pcs[0],
+	})
+}
+
+func (l *logger) New(ctx ...interface{}) Logger {
+	child := &logger{ctx: newContext(l.ctx, ctx), h: new(swapHandler)}
+	child.SetHandler(l.h)
+	return child
+}
+
+func (l *logger) GetHandler() Handler  { return l.h.Get() }
+func (l *logger) SetHandler(h Handler) { l.h.Swap(h) }
+
+func (l *logger) Trace(msg string, ctx ...interface{}) { l.write(msg, LvlTrace, ctx) }
+func (l *logger) Debug(msg string, ctx ...interface{}) { l.write(msg, LvlDebug, ctx) }
+func (l *logger) Info(msg string, ctx ...interface{})  { l.write(msg, LvlInfo, ctx) }
+func (l *logger) Warn(msg string, ctx ...interface{})  { l.write(msg, LvlWarn, ctx) }
+func (l *logger) Error(msg string, ctx ...interface{}) { l.write(msg, LvlError, ctx) }
+
+func (l *logger) Crit(msg string, ctx ...interface{}) {
+	l.write(msg, LvlCrit, ctx)
+	os.Exit(1)
+}
+
+// newContext concatenates two contexts, fixing up a missing value.
+func newContext(prefix []interface{}, suffix []interface{}) []interface{} {
+	ctx := make([]interface{}, len(prefix), len(prefix)+len(suffix)+2)
+	copy(ctx, prefix)
+	ctx = append(ctx, suffix...)
+	if len(ctx)%2 != 0 {
+		ctx = append(ctx, nil, errorKey, "Normalized odd number of arguments by adding nil")
+	}
+	return ctx
+}
+
+// swapHandler wraps a handler which may be replaced concurrently with logging.
+type swapHandler struct {
+	handler atomic.Value
+}
+
+func (h *swapHandler) Log(r *Record) error { return h.Get().Log(r) }
+func (h *swapHandler) Get() Handler        { return h.handler.Load().(*handlerBox).Handler }
+func (h *swapHandler) Swap(handler Handler) {
+	h.handler.Store(&handlerBox{handler})
+}
+
+// handlerBox gives the handlers stored in a swapHandler the same concrete type,
+// as required by atomic.Value.
+type handlerBox struct {
+	Handler
+}
+
+var root = &logger{h: new(swapHandler)}
+
+func init() {
+	root.SetHandler(DiscardHandler())
+}
+
+// Root returns the root logger.
+func Root() Logger { return root }
+
+// New returns a logger with the given context, writing to the root handler.
+func New(ctx ...interface{}) Logger { return root.New(ctx...) }
+
+// Trace writes a record at trace level through the root logger.
+func Trace(msg string, ctx ...interface{}) { root.write(msg, LvlTrace, ctx) }
+
+// Debug writes a record at debug level through the root logger.
+func Debug(msg string, ctx ...interface{}) { root.write(msg, LvlDebug, ctx) }
+
+// Info writes a record at info level through the root logger.
+func Info(msg string, ctx ...interface{}) { root.write(msg, LvlInfo, ctx) }
+
+// Warn writes a record at warning level through the root logger.
+func Warn(msg string, ctx ...interface{}) { root.write(msg, LvlWarn, ctx) }
+
+// Error writes a record at error level through the root logger.
+func Error(msg string, ctx ...interface{}) { root.write(msg, LvlError, ctx) }
+
+// Crit writes a record at critical level through the root logger and
+// terminates the process.
+func Crit(msg string, ctx ...interface{}) {
+	root.write(msg, LvlCrit, ctx)
+	os.Exit(1)
+}
@@ -0,0 +1,286 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateTimeFormat is the timestamp suffix of rotated log files. It sorts
+// lexically in chronological order.
+const rotateTimeFormat = "2006-01-02T15-04-05.000"
+
+var errFileClosed = errors.New("log file closed")
+
+// RotateConfig are the rotation settings of a log file.
+type RotateConfig struct {
+	MaxSize    int64         // Size in bytes after which the file is rotated (0 = unlimited)
+	MaxAge     time.Duration // Time after which the file is rotated (0 = unlimited)
+	MaxBackups int           // Number of rotated files to retain (0 = all)
+	Compress   bool          // Whether to gzip the rotated files
+}
+
+// RotatingFile is a log file writer which moves the file aside once it grows
+// beyond a size or age limit and continues in a fresh one. Rotated files are
+// suffixed with the time of rotation, optionally compressed in the background
+// and pruned to the configured number of backups.
+type RotatingFile struct {
+	path   string
+	config RotateConfig
+
+	file   *os.File
+	size   int64     // bytes written to the current file
+	opened time.Time // time the current file was started
+	lock   sync.Mutex
+
+	archive sync.Mutex     // serializes the compression and pruning of backups
+	wg      sync.WaitGroup // background archivers still running
+}
+
+// NewRotatingFile opens the log file at path for appending, creating it and
+// its parent directories if necessary.
+func NewRotatingFile(path string, config RotateConfig) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f := &RotatingFile{path: path, config: config}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// RotatingFileHandler returns a handler writing records in the given format to
+// a rotating log file, along with the file for rotating or closing it.
+func RotatingFileHandler(path string, config RotateConfig, format Format) (Handler, *RotatingFile, error) {
+	f, err := NewRotatingFile(path, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return StreamHandler(f, format), f, nil
+}
+
+// Path returns the location of the active log file.
+func (f *RotatingFile) Path() string {
+	return f.path
+}
+
+// open opens the log file, continuing an existing one. The age of an existing
+// file is counted from its last modification, so restarts don't delay the time
+// based rotation indefinitely.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), time.Now()
+	if info.Size() > 0 {
+		f.opened = info.ModTime()
+	}
+	return nil
+}
+
+// Write implements io.Writer, rotating the file first if the data would push
+// it over the size limit or the file exceeded its maximum age.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return 0, errFileClosed
+	}
+	if f.size > 0 && f.expired(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// expired reports whether writing n more bytes requires a rotation.
+func (f *RotatingFile) expired(n int64) bool {
+	if f.config.MaxSize > 0 && f.size+n > f.config.MaxSize {
+		return true
+	}
+	return f.config.MaxAge > 0 && time.Since(f.opened) >= f.config.MaxAge
+}
+
+// Rotate moves the current log file aside and starts a new one, regardless of
+// the rotation limits.
+func (f *RotatingFile) Rotate() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return errFileClosed
+	}
+	return f.rotate()
+}
+
+// rotate closes and renames the current log file, then opens a fresh one and
+// schedules archiving the old. The caller must hold the lock.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.path, backup); err != nil {
+		// Keep logging into the old file rather than losing records
+		if oerr := f.open(); oerr != nil {
+			return oerr
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.wg.Add(1)
+	go f.archiveBackups(backup)
+	return nil
+}
+
+// backupName returns an unused name for a rotated log file.
+func (f *RotatingFile) backupName(now time.Time) string {
+	base := f.path + "." + now.Format(rotateTimeFormat)
+	name := base
+	for i := 1; ; i++ {
+		_, err := os.Stat(name)
+		_, gzerr := os.Stat(name + ".gz")
+		if os.IsNotExist(err) && os.IsNotExist(gzerr) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// archiveBackups compresses a freshly rotated file if requested and removes
+// the backups beyond the retention limit.
+func (f *RotatingFile) archiveBackups(backup string) {
+	defer f.wg.Done()
+
+	f.archive.Lock()
+	defer f.archive.Unlock()
+
+	if f.config.Compress {
+		if err := compressFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to compress rotated log file %s: %v\n", backup, err)
+		}
+	}
+	if f.config.MaxBackups > 0 {
+		backups, err := f.Backups()
+		if err != nil {
+			return
+		}
+		for len(backups) > f.config.MaxBackups {
+			os.Remove(backups[0])
+			backups = backups[1:]
+		}
+	}
+}
+
+// Backups returns the rotated files of the log, oldest first.
+func (f *RotatingFile) Backups() ([]string, error) {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, match := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(match, f.path+"."), ".gz")
+		if len(suffix) < len(rotateTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(rotateTimeFormat, suffix[:len(rotateTimeFormat)]); err != nil {
+			continue
+		}
+		backups = append(backups, match)
+	}
+	sort.Sort(backupsByTime(backups))
+	return backups, nil
+}
+
+// backupsByTime sorts rotated files chronologically, ignoring whether they have
+// been compressed already.
+type backupsByTime []string
+
+func (b backupsByTime) Len() int      { return len(b) }
+func (b backupsByTime) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b backupsByTime) Less(i, j int) bool {
+	return strings.TrimSuffix(b[i], ".gz") < strings.TrimSuffix(b[j], ".gz")
+}
+
+// compressFile gzips a file, replacing it with the compressed version.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil // Already pruned
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Close closes the log file and waits for the background archiving of rotated
+// files to finish.
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.lock.Unlock()
+
+	f.wg.Wait()
+	return err
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logs", "ged.log")
+	f, err := NewRotatingFile(path, RotateConfig{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("failed to write %q: %v", line, err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close log file: %v", err)
+	}
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backup count mismatch: have %d, want 2 (%v)", len(backups), backups)
+	}
+	for i, want := range []string{"first\n", "second\n"} {
+		if have, _ := ioutil.ReadFile(backups[i]); string(have) != want {
+			t.Errorf("backup %d content mismatch: have %q, want %q", i, have, want)
+		}
+	}
+	if have, _ := ioutil.ReadFile(path); string(have) != "third\n" {
+		t.Errorf("active file content mismatch: have %q, want %q", have, "third\n")
+	}
+	if _, err := f.Write([]byte("late\n")); err == nil {
+		t.Errorf("write to closed file succeeded")
+	}
+}
+
+func TestRotatingFileAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := NewRotatingFile(filepath.Join(dir, "ged.log"), RotateConfig{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("old\n"))
+	f.opened = f.opened.Add(-2 * time.Hour)
+	f.Write([]byte("new\n"))
+
+	if backups, _ := f.Backups(); len(backups) != 1 {
+		t.Fatalf("backup count mismatch: have %d, want 1", len(backups))
+	}
+}
+
+func TestRotatingFileCompressPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logrotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "ged.log")
+	f, err := NewRotatingFile(path, RotateConfig{MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("failed to open log file: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		f.Write([]byte("record\n"))
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("rotation %d failed: %v", i, err)
+		}
+	}
+	f.Close()
+
+	backups, err := f.Backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("backup count mismatch: have %d, want 2 (%v)", len(backups), backups)
+	}
+	for _, backup := range backups {
+		if !strings.HasSuffix(backup, ".gz") {
+			t.Errorf("backup %s not compressed", backup)
+			continue
+		}
+		file, err := os.Open(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatalf("invalid gzip backup %s: %v", backup, err)
+		}
+		if have, _ := ioutil.ReadAll(zr); string(have) != "record\n" {
+			t.Errorf("backup %s content mismatch: have %q", backup, have)
+		}
+		file.Close()
+	}
+}
@@ -145,6 +145,19 @@ func SetToStderr(toStderr bool) {
 	logging.mu.Unlock()
 }
 
+// Handler receives the log lines instead of standard error and the log files.
+// The severity is one of "INFO", "WARNING", "ERROR" and "FATAL", the message has
+// no header and no trailing newline.
+type Handler func(severity string, file string, line int, msg string)
+
+// SetHandler routes the log lines to the given handler, or back to the regular
+// outputs if nil. The handler must not log through glog itself.
+func SetHandler(h Handler) {
+	logging.mu.Lock()
+	logging.handler = h
+	logging.mu.Unlock()
+}
+
 // GetTraceLocation returns the global TraceLocation flag.
 func GetTraceLocation() *TraceLocation {
 	return &logging.traceLocation
@@ -473,6 +486,9 @@ type loggingT struct {
 	toStderr     bool // The -logtostderr flag.
 	alsoToStderr bool // The -alsologtostderr flag.
 
+	// handler replaces the regular outputs if set.
+	handler Handler
+
 	// Level flag. Handled atomically.
 	stderrThreshold severity // The -stderrthreshold flag.
 
@@ -728,7 +744,17 @@ func (l *loggingT) output(s severity, buf *buffer, file string, line int, alsoTo
 		}
 	}
 	data := buf.Bytes()
-	if l.toStderr {
+	if h := l.handler; h != nil {
+		// The handler is called without holding the lock, so it may take
+		// its time writing the line.
+		msg := data
+		if i := bytes.Index(msg, []byte("] ")); i >= 0 {
+			msg = msg[i+2:]
+		}
+		l.mu.Unlock()
+		h(severityName[s], file, line, string(bytes.TrimRight(msg, "\n")))
+		l.mu.Lock()
+	} else if l.toStderr {
 		os.Stderr.Write(data)
 	} else {
 		if alsoToStderr || l.alsoToStderr || s >= l.stderrThreshold.get() {
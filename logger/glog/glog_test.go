@@ -193,6 +193,26 @@ func TestHeader(t *testing.T) {
 	}
 }
 
+// Test that a handler receives the lines instead of the log files.
+func TestHandler(t *testing.T) {
+	setFlags()
+	defer logging.swap(logging.newBuffers())
+
+	var lines []string
+	SetHandler(func(severity, file string, line int, msg string) {
+		lines = append(lines, fmt.Sprintf("%s %s %q", severity, file, msg))
+	})
+	defer SetHandler(nil)
+
+	Warningf("test %d\n", 1)
+	if contents(infoLog) != "" {
+		t.Errorf("line written to log file: %q", contents(infoLog))
+	}
+	if want := `WARNING logger/glog/glog_test.go "test 1"`; len(lines) != 1 || lines[0] != want {
+		t.Errorf("handled lines mismatch: have %q, want [%q]", lines, want)
+	}
+}
+
 // Test that an Error log goes to Warning and Info.
 // Even in the Info log, the source character will be E, so the data should
 // all be identical.
@@ -32,6 +32,14 @@ import (
 // MetricsEnabledFlag is the CLI flag name to use to enable metrics collections.
 var MetricsEnabledFlag = "metrics"
 
+// MetricsHTTPFlag is the CLI flag name of the metrics exporter address, which
+// also enables metrics collection.
+var MetricsHTTPFlag = "metrics.addr"
+
+// DashboardEnabledFlag is the CLI flag name of the web dashboard, which also
+// enables metrics collection as the dashboard charts are fed from the registry.
+var DashboardEnabledFlag = "dashboard"
+
 // Enabled is the flag specifying if metrics are enable or not.
 var Enabled = false
 
@@ -40,7 +48,8 @@ var Enabled = false
 // and peek into the command line args for the metrics flag.
 func init() {
 	for _, arg := range os.Args {
-		if strings.TrimLeft(arg, "-") == MetricsEnabledFlag {
+		flag := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if flag == MetricsEnabledFlag || flag == DashboardEnabledFlag || flag == MetricsHTTPFlag {
 			glog.V(logger.Info).Infof("Enabling metrics collection")
 			Enabled = true
 		}
@@ -75,6 +84,15 @@ func NewTimer(name string) metrics.Timer {
 	return metrics.GetOrRegisterTimer(name, metrics.DefaultRegistry)
 }
 
+// NewHistogram create a new metrics Histogram over an exponentially decaying
+// sample, either a real one of a NOP stub depending on the metrics flag.
+func NewHistogram(name string) metrics.Histogram {
+	if !Enabled {
+		return new(metrics.NilHistogram)
+	}
+	return metrics.GetOrRegisterHistogram(name, metrics.DefaultRegistry, metrics.NewExpDecaySample(1028, 0.015))
+}
+
 // CollectProcessMetrics periodically collects various metrics about the running
 // process.
 func CollectProcessMetrics(refresh time.Duration) {
@@ -85,9 +103,11 @@ func CollectProcessMetrics(refresh time.Duration) {
 	// Create the various data collectors
 	memstats := make([]*runtime.MemStats, 2)
 	diskstats := make([]*DiskStats, 2)
+	cpustats := make([]*CPUStats, 2)
 	for i := 0; i < len(memstats); i++ {
 		memstats[i] = new(runtime.MemStats)
 		diskstats[i] = new(DiskStats)
+		cpustats[i] = new(CPUStats)
 	}
 	// Define the various metrics to collect
 	memAllocs := metrics.GetOrRegisterMeter("system/memory/allocs", metrics.DefaultRegistry)
@@ -95,6 +115,12 @@ func CollectProcessMetrics(refresh time.Duration) {
 	memInuse := metrics.GetOrRegisterMeter("system/memory/inuse", metrics.DefaultRegistry)
 	memPauses := metrics.GetOrRegisterMeter("system/memory/pauses", metrics.DefaultRegistry)
 
+	var cpuTime metrics.Meter
+	if err := ReadCPUStats(cpustats[0]); err == nil {
+		cpuTime = metrics.GetOrRegisterMeter("system/cpu/time", metrics.DefaultRegistry)
+	} else {
+		glog.V(logger.Debug).Infof("failed to read cpu metrics: %v", err)
+	}
 	var diskReads, diskReadBytes, diskWrites, diskWriteBytes metrics.Meter
 	if err := ReadDiskStats(diskstats[0]); err == nil {
 		diskReads = metrics.GetOrRegisterMeter("system/disk/readcount", metrics.DefaultRegistry)
@@ -112,6 +138,9 @@ func CollectProcessMetrics(refresh time.Duration) {
 		memInuse.Mark(int64(memstats[i%2].Alloc - memstats[(i-1)%2].Alloc))
 		memPauses.Mark(int64(memstats[i%2].PauseTotalNs - memstats[(i-1)%2].PauseTotalNs))
 
+		if cpuTime != nil && ReadCPUStats(cpustats[i%2]) == nil {
+			cpuTime.Mark((cpustats[i%2].UserTime + cpustats[i%2].SystemTime) - (cpustats[(i-1)%2].UserTime + cpustats[(i-1)%2].SystemTime))
+		}
 		if ReadDiskStats(diskstats[i%2]) == nil {
 			diskReads.Mark(int64(diskstats[i%2].ReadCount - diskstats[(i-1)%2].ReadCount))
 			diskReadBytes.Mark(int64(diskstats[i%2].ReadBytes - diskstats[(i-1)%2].ReadBytes))
@@ -0,0 +1,203 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package prometheus exposes the metrics of a go-metrics registry in the
+// Prometheus text exposition format.
+//
+// Registry names are converted to Prometheus names by replacing path separators
+// and other invalid characters with underscores and splitting camel case words,
+// e.g. "p2p/InboundTraffic" becomes "p2p_inbound_traffic". Metrics are rendered
+// as follows:
+//
+//   - counters and gauges as gauges, as go-metrics counters may be decremented
+//   - meters as a "_total" counter and a "_rate" gauge per moving average window
+//   - histograms as summaries with a fixed set of quantiles
+//   - timers as summaries with a "_seconds" suffix, converted from nanoseconds
+//
+// If the series of a metric collide with those of a metric sorted before it,
+// e.g. "p2p/inbound" and "p2p.inbound", its name is suffixed with "_2", "_3"
+// and so on until it is unique.
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// quantiles are the percentiles reported for histograms and timers.
+var quantiles = []float64{0.5, 0.75, 0.95, 0.99, 0.999}
+
+// Handler returns an HTTP handler rendering all metrics of a registry.
+func Handler(reg metrics.Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := new(bytes.Buffer)
+		Write(buf, reg)
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(buf.Bytes())
+	})
+}
+
+// Write renders all metrics of a registry in the Prometheus text format, sorted
+// by name so the output is stable.
+func Write(w io.Writer, reg metrics.Registry) error {
+	entries := make(map[string]interface{})
+	reg.Each(func(name string, metric interface{}) {
+		entries[name] = metric
+	})
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		buf  = new(bytes.Buffer)
+		used = make(map[string]bool)
+	)
+	for _, name := range names {
+		metric := entries[name]
+		base := Name(name)
+		for i := 2; collides(used, seriesNames(base, metric)); i++ {
+			base = Name(name) + "_" + strconv.Itoa(i)
+		}
+		for _, series := range seriesNames(base, metric) {
+			used[series] = true
+		}
+		writeMetric(buf, base, metric)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// seriesNames returns the names of the series rendered for a metric.
+func seriesNames(name string, metric interface{}) []string {
+	switch metric.(type) {
+	case metrics.Counter, metrics.Gauge, metrics.GaugeFloat64:
+		return []string{name}
+	case metrics.Meter:
+		return []string{name + "_total", name + "_rate"}
+	case metrics.Histogram:
+		return []string{name, name + "_sum", name + "_count"}
+	case metrics.Timer:
+		return []string{name + "_seconds", name + "_seconds_sum", name + "_seconds_count"}
+	}
+	return nil
+}
+
+// collides reports whether any of the series names is already used.
+func collides(used map[string]bool, series []string) bool {
+	for _, name := range series {
+		if used[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMetric renders a single metric under the given Prometheus name.
+func writeMetric(buf *bytes.Buffer, name string, metric interface{}) {
+	switch metric := metric.(type) {
+	case metrics.Counter:
+		writeType(buf, name, "gauge")
+		writeValue(buf, name, "", float64(metric.Count()))
+
+	case metrics.Gauge:
+		writeType(buf, name, "gauge")
+		writeValue(buf, name, "", float64(metric.Value()))
+
+	case metrics.GaugeFloat64:
+		writeType(buf, name, "gauge")
+		writeValue(buf, name, "", metric.Value())
+
+	case metrics.Meter:
+		m := metric.Snapshot()
+		writeType(buf, name+"_total", "counter")
+		writeValue(buf, name+"_total", "", float64(m.Count()))
+		writeType(buf, name+"_rate", "gauge")
+		writeValue(buf, name+"_rate", `window="1m"`, m.Rate1())
+		writeValue(buf, name+"_rate", `window="5m"`, m.Rate5())
+		writeValue(buf, name+"_rate", `window="15m"`, m.Rate15())
+		writeValue(buf, name+"_rate", `window="mean"`, m.RateMean())
+
+	case metrics.Histogram:
+		h := metric.Snapshot()
+		writeSummary(buf, name, h.Count(), h.Mean(), h.Percentiles(quantiles), 1)
+
+	case metrics.Timer:
+		t := metric.Snapshot()
+		writeSummary(buf, name+"_seconds", t.Count(), t.Mean(), t.Percentiles(quantiles), float64(time.Second))
+	}
+}
+
+// writeSummary renders a summary from sampled percentiles, dividing the values
+// by the given unit. As the samples only cover part of the observations, the
+// sum is estimated from the sample mean.
+func writeSummary(buf *bytes.Buffer, name string, count int64, mean float64, percentiles []float64, unit float64) {
+	writeType(buf, name, "summary")
+	for i, q := range quantiles {
+		writeValue(buf, name, `quantile="`+strconv.FormatFloat(q, 'g', -1, 64)+`"`, percentiles[i]/unit)
+	}
+	writeValue(buf, name+"_sum", "", mean*float64(count)/unit)
+	writeValue(buf, name+"_count", "", float64(count))
+}
+
+func writeType(buf *bytes.Buffer, name, kind string) {
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, kind)
+}
+
+func writeValue(buf *bytes.Buffer, name, labels string, value float64) {
+	buf.WriteString(name)
+	if labels != "" {
+		buf.WriteString("{" + labels + "}")
+	}
+	buf.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+// Name converts a go-metrics registry name into a valid Prometheus metric name.
+func Name(name string) string {
+	out := make([]byte, 0, len(name)+8)
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'A' && c <= 'Z':
+			// Split camel case words, keeping acronyms together
+			if i > 0 && (isLower(name[i-1]) || isDigit(name[i-1]) || (isUpper(name[i-1]) && i+1 < len(name) && isLower(name[i+1]))) {
+				out = append(out, '_')
+			}
+			out = append(out, c-'A'+'a')
+		case isLower(c) || isDigit(c):
+			out = append(out, c)
+		default:
+			out = append(out, '_')
+		}
+	}
+	if len(out) > 0 && isDigit(out[0]) {
+		out = append([]byte{'_'}, out...)
+	}
+	return string(out)
+}
+
+func isLower(c byte) bool { return c >= 'a' && c <= 'z' }
+func isUpper(c byte) bool { return c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
@@ -0,0 +1,116 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package prometheus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"chain/inserts":                    "chain_inserts",
+		"p2p/InboundTraffic":               "p2p_inbound_traffic",
+		"eth/db/chaindata/compact/time":    "eth_db_chaindata_compact_time",
+		"les/HTTPRequests":                 "les_http_requests",
+		"system/memory/pauses-total.count": "system_memory_pauses_total_count",
+		"1st/metric":                       "_1st_metric",
+	}
+	for name, want := range tests {
+		if have := Name(name); have != want {
+			t.Errorf("%q: name mismatch: have %q, want %q", name, have, want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	metrics.GetOrRegisterCounter("p2p/Peers", reg).Inc(3)
+	metrics.GetOrRegisterGauge("txpool/pending", reg).Update(7)
+	metrics.GetOrRegisterMeter("eth/db/chaindata/compact/input", reg).Mark(10)
+	metrics.GetOrRegisterHistogram("rpc/batch", reg, metrics.NewUniformSample(100)).Update(4)
+	metrics.GetOrRegisterTimer("chain/inserts", reg).Update(2 * time.Second)
+
+	buf := new(bytes.Buffer)
+	if err := Write(buf, reg); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	want := []string{
+		"# TYPE chain_inserts_seconds summary",
+		`chain_inserts_seconds{quantile="0.5"} 2`,
+		"chain_inserts_seconds_sum 2",
+		"chain_inserts_seconds_count 1",
+		"# TYPE eth_db_chaindata_compact_input_total counter",
+		"eth_db_chaindata_compact_input_total 10",
+		"# TYPE eth_db_chaindata_compact_input_rate gauge",
+		`eth_db_chaindata_compact_input_rate{window="1m"} `,
+		"# TYPE p2p_peers gauge",
+		"p2p_peers 3",
+		`rpc_batch{quantile="0.999"} 4`,
+		"rpc_batch_count 1",
+		"# TYPE txpool_pending gauge",
+		"txpool_pending 7",
+	}
+	output := buf.String()
+	for _, line := range want {
+		if !strings.Contains(output, line) {
+			t.Errorf("missing %q in output:\n%s", line, output)
+		}
+	}
+	// Metrics must be sorted by name for stable output
+	if strings.Index(output, "chain_inserts") > strings.Index(output, "txpool_pending") {
+		t.Errorf("metrics not sorted:\n%s", output)
+	}
+}
+
+// Tests that metrics whose names collide after sanitization, either directly
+// or through the suffixes of their series, are written under unique names.
+func TestWriteCollisions(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	metrics.GetOrRegisterGauge("p2p/peers", reg).Update(1)
+	metrics.GetOrRegisterGauge("p2p.peers", reg).Update(2)
+	metrics.GetOrRegisterGauge("p2p-peers", reg).Update(3)
+	metrics.GetOrRegisterMeter("eth/inserts", reg).Mark(4)
+	metrics.GetOrRegisterCounter("eth/inserts/total", reg).Inc(5)
+
+	buf := new(bytes.Buffer)
+	if err := Write(buf, reg); err != nil {
+		t.Fatalf("failed to write metrics: %v", err)
+	}
+	want := []string{
+		"p2p_peers 3",
+		"p2p_peers_2 2",
+		"p2p_peers_3 1",
+		"eth_inserts_total 4",
+		"eth_inserts_total_2 5",
+	}
+	output := buf.String()
+	for _, line := range want {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("missing %q in output:\n%s", line, output)
+		}
+	}
+	if n := strings.Count(output, "# TYPE p2p_peers gauge"); n != 1 {
+		t.Errorf("p2p_peers declared %d times:\n%s", n, output)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+	"github.com/EarthDollar/go-earthdollar/log"
+)
+
+// DevAgent is a mining agent for developer networks. Instead of searching for a
+// proof-of-work, it seals the work packages as they are: either as soon as they
+// contain transactions, or periodically if a block period is configured.
+type DevAgent struct {
+	mu sync.Mutex
+
+	workCh   chan *Work
+	sealCh   chan struct{}
+	quit     chan struct{}
+	returnCh chan<- *Result
+
+	period time.Duration // Block period, zero to seal as soon as transactions arrive
+	sealed common.Hash   // Parent of the last sealed block, work on it is stale
+}
+
+// NewDevAgent creates a sealer for developer networks with the given block period.
+func NewDevAgent(period time.Duration) *DevAgent {
+	return &DevAgent{
+		workCh: make(chan *Work, 1),
+		sealCh: make(chan struct{}, 1),
+		period: period,
+	}
+}
+
+func (self *DevAgent) Work() chan<- *Work            { return self.workCh }
+func (self *DevAgent) SetReturnCh(ch chan<- *Result) { self.returnCh = ch }
+func (self *DevAgent) GetHashRate() int64            { return 0 }
+
+// Start launches the sealing loop, unless it's already running.
+func (self *DevAgent) Start() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.quit != nil {
+		return
+	}
+	self.quit = make(chan struct{})
+	go self.update(self.quit)
+}
+
+// Stop terminates the sealing loop. The agent may be started again afterwards.
+func (self *DevAgent) Stop() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.quit != nil {
+		close(self.quit)
+		self.quit = nil
+	}
+}
+
+// Seal requests the next block to be sealed immediately, even if it's empty.
+func (self *DevAgent) Seal() {
+	select {
+	case self.sealCh <- struct{}{}:
+	default:
+	}
+}
+
+// rejected is called by the worker if a sealed block failed to import, so work
+// on its parent is accepted again instead of being dropped as stale.
+func (self *DevAgent) rejected(block *types.Block) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.sealed == block.ParentHash() {
+		self.sealed = common.Hash{}
+	}
+}
+
+// stale reports whether a work package is built on top of a parent that already
+// got a sealed child (e.g. recreated because of a transaction that the sealed
+// block already contains).
+func (self *DevAgent) stale(work *Work) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return work.Block.ParentHash() == self.sealed
+}
+
+// update waits for work packages and seals them when due, dropping stale ones.
+func (self *DevAgent) update(quit chan struct{}) {
+	var ticker <-chan time.Time
+	if self.period > 0 {
+		t := time.NewTicker(self.period)
+		defer t.Stop()
+		ticker = t.C
+	}
+	var (
+		current *Work // Latest work package not sealed yet
+		force   bool  // Whether to seal the next work package regardless of its content
+	)
+	for {
+		select {
+		case work := <-self.workCh:
+			if self.stale(work) {
+				continue
+			}
+			current = work
+		case <-self.sealCh:
+			force = true
+		case <-ticker:
+			force = true
+		case <-quit:
+			return
+		}
+		if current == nil {
+			continue
+		}
+		if force || (self.period == 0 && len(current.Block.Transactions()) > 0) {
+			log.Debug("Sealing developer block", "number", current.Block.Number(), "txs", len(current.Block.Transactions()))
+			self.mu.Lock()
+			self.sealed = current.Block.ParentHash()
+			self.mu.Unlock()
+
+			self.returnCh <- &Result{current, current.Block}
+			current, force = nil, false
+		}
+	}
+}
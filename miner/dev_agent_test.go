@@ -0,0 +1,95 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/core/types"
+)
+
+// newDevWork creates a work package on top of the given parent, with the given
+// number of transactions.
+func newDevWork(parent common.Hash, number int64, txs int) *Work {
+	header := &types.Header{ParentHash: parent, Number: big.NewInt(number)}
+	var list []*types.Transaction
+	for i := 0; i < txs; i++ {
+		list = append(list, types.NewTransaction(uint64(i), common.Address{}, new(big.Int), new(big.Int), new(big.Int), nil))
+	}
+	return &Work{Block: types.NewBlock(header, list, nil, nil), header: header}
+}
+
+// Tests that the developer agent seals work as soon as it contains transactions,
+// drops stale work and seals empty blocks only on request.
+func TestDevAgentInstantSeal(t *testing.T) {
+	results := make(chan *Result, 1)
+
+	agent := NewDevAgent(0)
+	agent.SetReturnCh(results)
+	agent.Start()
+	defer agent.Stop()
+
+	expect := func(work *Work) {
+		select {
+		case res := <-results:
+			if res.Block != work.Block {
+				t.Fatalf("sealed block mismatch: have #%d, want #%d", res.Block.NumberU64(), work.Block.NumberU64())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("block #%d not sealed", work.Block.NumberU64())
+		}
+	}
+	expectNone := func() {
+		select {
+		case res := <-results:
+			t.Fatalf("unexpected block #%d sealed", res.Block.NumberU64())
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	// Empty work must be held back, work with transactions sealed right away
+	genesis := common.Hash{0x01}
+	agent.Work() <- newDevWork(genesis, 1, 0)
+	expectNone()
+
+	work := newDevWork(genesis, 1, 1)
+	agent.Work() <- work
+	expect(work)
+
+	// Work recreated on the same parent is stale and must be dropped
+	agent.Work() <- newDevWork(genesis, 1, 2)
+	expectNone()
+
+	// Work on the parent of a block which failed to import must be sealed
+	agent.rejected(work.Block)
+	retry := newDevWork(genesis, 1, 2)
+	agent.Work() <- retry
+	expect(retry)
+
+	// Explicit seal requests must seal empty blocks too
+	empty := newDevWork(retry.Block.Hash(), 2, 0)
+	agent.Work() <- empty
+	agent.Seal()
+	expect(empty)
+
+	// Rejecting a block sealed earlier must not reset the current parent
+	agent.rejected(retry.Block)
+	agent.Work() <- newDevWork(retry.Block.Hash(), 2, 1)
+	expectNone()
+}
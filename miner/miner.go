@@ -18,9 +18,11 @@
 package miner
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/accounts"
 	"github.com/EarthDollar/go-earthdollar/common"
@@ -55,6 +57,7 @@ type Miner struct {
 	mining   int32
 	eth      Backend
 	pow      pow.PoW
+	dev      *DevAgent // sealer of the developer network, nil otherwise
 
 	canStart    int32 // can start indicates whether we can start the mining operation
 	shouldStart int32 // should start indicates whether we should start after sync
@@ -144,6 +147,40 @@ func (self *Miner) Stop() {
 	atomic.StoreInt32(&self.shouldStart, 0)
 }
 
+// StartDevMode starts sealing blocks without proof-of-work for a developer network.
+// Blocks are sealed as soon as transactions arrive, or every period if non-zero.
+func (self *Miner) StartDevMode(coinbase common.Address, period time.Duration) {
+	if self.dev == nil {
+		self.dev = NewDevAgent(period)
+		self.worker.register(self.dev)
+		atomic.StoreInt32(&self.worker.devMode, 1)
+	}
+	glog.V(logger.Info).Infof("Starting developer mode sealing (period=%v)\n", period)
+	self.Start(coinbase, 0)
+}
+
+// Seal makes the developer network seal a new block right away, even if empty.
+func (self *Miner) Seal() error {
+	if self.dev == nil {
+		return errors.New("developer mode not enabled")
+	}
+	self.dev.Seal()
+	return nil
+}
+
+// IncreaseTime moves the timestamps of the developer network's new blocks forward
+// by the given amount, returning the total offset from the wall clock.
+func (self *Miner) IncreaseTime(delta time.Duration) (time.Duration, error) {
+	if self.dev == nil {
+		return 0, errors.New("developer mode not enabled")
+	}
+	offset := self.worker.increaseTime(int64(delta / time.Second))
+
+	// Recreate the pending work so the next sealed block already has the new time
+	self.worker.commitNewWork()
+	return time.Duration(offset) * time.Second, nil
+}
+
 func (self *Miner) Register(agent Agent) {
 	if self.Mining() {
 		agent.Start()
@@ -102,9 +102,10 @@ type worker struct {
 	proc    core.Validator
 	chainDb ethdb.Database
 
-	coinbase common.Address
-	gasPrice *big.Int
-	extra    []byte
+	coinbase   common.Address
+	gasPrice   *big.Int
+	extra      []byte
+	timeOffset int64 // seconds added to the timestamps of new blocks (developer mode only)
 
 	currentMu sync.Mutex
 	current   *Work
@@ -121,6 +122,8 @@ type worker struct {
 	mining int32
 	atWork int32
 
+	devMode int32 // whether work is recreated on every new transaction for instant sealing
+
 	fullValidation bool
 }
 
@@ -162,6 +165,15 @@ func (self *worker) setExtra(extra []byte) {
 	self.extra = extra
 }
 
+// increaseTime moves the timestamps of new blocks forward, returning the total
+// offset from the wall clock.
+func (self *worker) increaseTime(seconds int64) int64 {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.timeOffset += seconds
+	return self.timeOffset
+}
+
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
@@ -259,6 +271,9 @@ func (self *worker) update() {
 
 				self.current.commitTransactions(self.mux, txset, self.gasPrice, self.chain)
 				self.currentMu.Unlock()
+			} else if atomic.LoadInt32(&self.devMode) == 1 {
+				// Developer networks seal transactions right away, recreate the work
+				self.commitNewWork()
 			}
 		}
 	}
@@ -279,6 +294,7 @@ func (self *worker) wait() {
 			if self.fullValidation {
 				if _, err := self.chain.InsertChain(types.Blocks{block}); err != nil {
 					glog.V(logger.Error).Infoln("mining err", err)
+					self.rejected(block)
 					continue
 				}
 				go self.mux.Post(core.NewMinedBlockEvent{Block: block})
@@ -287,18 +303,21 @@ func (self *worker) wait() {
 				parent := self.chain.GetBlock(block.ParentHash(), block.NumberU64()-1)
 				if parent == nil {
 					glog.V(logger.Error).Infoln("Invalid block found during mining")
+					self.rejected(block)
 					continue
 				}
 
 				auxValidator := self.eth.BlockChain().AuxValidator()
 				if err := core.ValidateHeader(self.config, auxValidator, block.Header(), parent.Header(), true, false); err != nil && err != core.BlockFutureErr {
 					glog.V(logger.Error).Infoln("Invalid header on mined block:", err)
+					self.rejected(block)
 					continue
 				}
 
 				stat, err := self.chain.WriteBlock(block)
 				if err != nil {
 					glog.V(logger.Error).Infoln("error writing block to chain", err)
+					self.rejected(block)
 					continue
 				}
 
@@ -318,8 +337,6 @@ func (self *worker) wait() {
 					core.WriteTransactions(self.chainDb, block)
 					// store the receipts
 					core.WriteReceipts(self.chainDb, work.receipts)
-					// Write map map bloom filters
-					core.WriteMipmapBloom(self.chainDb, block.NumberU64(), work.receipts)
 					// implicit by posting ChainHeadEvent
 					mustCommitNewWork = false
 				}
@@ -348,6 +365,19 @@ func (self *worker) wait() {
 	}
 }
 
+// rejected notifies the developer mode agent of a sealed block which failed to
+// import, so it doesn't drop the following work on the same parent.
+func (self *worker) rejected(block *types.Block) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	for agent := range self.agents {
+		if dev, ok := agent.(*DevAgent); ok {
+			dev.rejected(block)
+		}
+	}
+}
+
 // push sends a new work task to currently live miner agents.
 func (self *worker) push(work *Work) {
 	if atomic.LoadInt32(&self.mining) != 1 {
@@ -417,12 +447,13 @@ func (self *worker) commitNewWork() {
 	tstart := time.Now()
 	parent := self.chain.CurrentBlock()
 
-	tstamp := tstart.Unix()
+	tstamp := tstart.Unix() + self.timeOffset
 	if parent.Time().Cmp(new(big.Int).SetInt64(tstamp)) >= 0 {
 		tstamp = parent.Time().Int64() + 1
 	}
-	// this will ensure we're not going off too far in the future
-	if now := time.Now().Unix(); tstamp > now+4 {
+	// this will ensure we're not going off too far in the future (developer
+	// networks have no peers to reject such blocks, don't throttle them)
+	if now := time.Now().Unix(); tstamp > now+4 && atomic.LoadInt32(&self.devMode) == 0 {
 		wait := time.Duration(tstamp-now) * time.Second
 		glog.V(logger.Info).Infoln("We are too far in the future. Waiting for", wait)
 		time.Sleep(wait)
@@ -19,8 +19,10 @@
 package ged
 
 import (
+	"os"
 	"runtime"
 
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 )
@@ -29,6 +31,7 @@ func init() {
 	// Initialize the logger
 	glog.SetV(logger.Info)
 	glog.SetToStderr(true)
+	log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
 
 	// Initialize the goroutine count
 	runtime.GOMAXPROCS(runtime.NumCPU())
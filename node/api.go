@@ -41,11 +41,12 @@ func NewPrivateAdminAPI(node *Node) *PrivateAdminAPI {
 }
 
 // AddPeer requests connecting to a remote node, and also maintaining the new
-// connection at all times, even reconnecting if it is lost.
+// connection at all times, even reconnecting if it is lost. The node is added
+// to the static nodes of the data directory.
 func (api *PrivateAdminAPI) AddPeer(url string) (bool, error) {
 	// Make sure the server is running, fail otherwise
-	server := api.node.Server()
-	if server == nil {
+	peers := api.node.peers()
+	if peers == nil {
 		return false, ErrNodeStopped
 	}
 	// Try to add the url as a static peer and return
@@ -53,26 +54,118 @@ func (api *PrivateAdminAPI) AddPeer(url string) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("invalid enode: %v", err)
 	}
-	server.AddPeer(node)
+	if err := peers.addStatic(node); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
-// RemovePeer disconnects from a a remote node if the connection exists
+// RemovePeer disconnects from a a remote node if the connection exists, and
+// removes it from the static nodes.
 func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	peers := api.node.peers()
+	if peers == nil {
+		return false, ErrNodeStopped
+	}
+	// Try to remove the url as a static peer and return
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if err := peers.removeStatic(node); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AddTrustedPeer allows a remote node to always connect, even if the peer slots
+// are full. The node is added to the trusted nodes of the data directory.
+func (api *PrivateAdminAPI) AddTrustedPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	peers := api.node.peers()
+	if peers == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if err := peers.addTrusted(node); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveTrustedPeer removes a remote node from the trusted nodes. It doesn't
+// disconnect the node.
+func (api *PrivateAdminAPI) RemoveTrustedPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	peers := api.node.peers()
+	if peers == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	if err := peers.removeTrusted(node); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// defaultBanDuration is the duration of the bans requested through the admin
+// API without an explicit duration.
+const defaultBanDuration = 24 * time.Hour
+
+// BanPeer disconnects a remote node and bans it by its ID and IP address for the
+// given number of seconds, a day by default. The node may be given by its enode
+// URL or its bare ID.
+func (api *PrivateAdminAPI) BanPeer(url string, seconds *uint64) (bool, error) {
 	// Make sure the server is running, fail otherwise
 	server := api.node.Server()
 	if server == nil {
 		return false, ErrNodeStopped
 	}
-	// Try to remove the url as a static peer and return
 	node, err := discover.ParseNode(url)
 	if err != nil {
 		return false, fmt.Errorf("invalid enode: %v", err)
 	}
-	server.RemovePeer(node)
+	duration := defaultBanDuration
+	if seconds != nil {
+		duration = time.Duration(*seconds) * time.Second
+	}
+	if err := server.BanPeer(node, duration, "admin request"); err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
+// UnbanPeer lifts the ban of a remote node, given by its enode URL or bare ID.
+// It returns whether the node was banned.
+func (api *PrivateAdminAPI) UnbanPeer(url string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	return server.UnbanPeer(node.ID)
+}
+
+// ListBans retrieves all the active bans of remote nodes.
+func (api *PrivateAdminAPI) ListBans() ([]*p2p.BanInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Bans(), nil
+}
+
 // StartRPC starts the HTTP RPC API server.
 func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
@@ -104,7 +197,7 @@ func (api *PrivateAdminAPI) StartRPC(host *string, port *int, cors *string, apis
 		}
 	}
 
-	if err := api.node.startHTTP(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, *cors); err != nil {
+	if err := api.node.startHTTP(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, *cors, &api.node.config.HTTPAuth, &api.node.config.HTTPLimits); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -153,7 +246,7 @@ func (api *PrivateAdminAPI) StartWS(host *string, port *int, allowedOrigins *str
 		}
 	}
 
-	if err := api.node.startWS(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, *allowedOrigins); err != nil {
+	if err := api.node.startWS(fmt.Sprintf("%s:%d", *host, *port), api.node.rpcAPIs, modules, *allowedOrigins, &api.node.config.WSAuth, &api.node.config.WSLimits); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -275,6 +368,21 @@ func (api *PublicDebugAPI) Metrics(raw bool) (map[string]interface{}, error) {
 					},
 				}
 
+			case metrics.Histogram:
+				root[name] = map[string]interface{}{
+					"Count":   float64(metric.Count()),
+					"Mean":    metric.Mean(),
+					"Maximum": float64(metric.Max()),
+					"Minimum": float64(metric.Min()),
+					"Percentiles": map[string]interface{}{
+						"5":  metric.Percentile(0.05),
+						"20": metric.Percentile(0.2),
+						"50": metric.Percentile(0.5),
+						"80": metric.Percentile(0.8),
+						"95": metric.Percentile(0.95),
+					},
+				}
+
 			default:
 				root[name] = "Unknown metric type"
 			}
@@ -305,6 +413,21 @@ func (api *PublicDebugAPI) Metrics(raw bool) (map[string]interface{}, error) {
 					},
 				}
 
+			case metrics.Histogram:
+				root[name] = map[string]interface{}{
+					"Count":   round(float64(metric.Count()), 0),
+					"Mean":    round(metric.Mean(), 2),
+					"Maximum": round(float64(metric.Max()), 0),
+					"Minimum": round(float64(metric.Min()), 0),
+					"Percentiles": map[string]interface{}{
+						"5":  round(metric.Percentile(0.05), 2),
+						"20": round(metric.Percentile(0.2), 2),
+						"50": round(metric.Percentile(0.5), 2),
+						"80": round(metric.Percentile(0.8), 2),
+						"95": round(metric.Percentile(0.95), 2),
+					},
+				}
+
 			default:
 				root[name] = "Unknown metric type"
 			}
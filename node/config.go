@@ -31,10 +31,12 @@ import (
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 	"github.com/EarthDollar/go-earthdollar/p2p/discv5"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
+	"github.com/EarthDollar/go-earthdollar/rpc"
 )
 
 var (
@@ -52,14 +54,14 @@ type Config struct {
 	// Name sets the instance name of the node. It must not contain the / character and is
 	// used in the devp2p node identifier. The instance name of ged is "ged". If no
 	// value is specified, the basename of the current executable is used.
-	Name string
+	Name string `toml:"-"`
 
 	// UserIdent, if set, is used as an additional component in the devp2p node identifier.
 	UserIdent string
 
 	// Version should be set to the version number of the program. It is used
 	// in the devp2p node identifier.
-	Version string
+	Version string `toml:"-"`
 
 	// DataDir is the file system folder the node should use for any data storage
 	// requirements. The configured data directory will not be directly shared with
@@ -91,7 +93,7 @@ type Config struct {
 	// remote peer identification as well as network traffic encryption. If no key
 	// is configured, the preset one is loaded from the data dir, generating it if
 	// needed.
-	PrivateKey *ecdsa.PrivateKey
+	PrivateKey *ecdsa.PrivateKey `toml:"-"`
 
 	// NoDiscovery specifies whether the peer discovery mechanism should be started
 	// or not. Disabling is usually useful for protocol debugging (manual topology).
@@ -106,7 +108,7 @@ type Config struct {
 
 	// Restrict communication to white listed IP networks.
 	// The whitelist only applies when non-nil.
-	NetRestrict *netutil.Netlist
+	NetRestrict *netutil.Netlist `toml:",omitempty"`
 
 	// BootstrapNodes used to establish connectivity with the rest of the network.
 	BootstrapNodes []*discover.Node
@@ -115,20 +117,41 @@ type Config struct {
 	// using the V5 discovery protocol.
 	BootstrapNodesV5 []*discv5.Node
 
+	// DNSDiscovery lists the enrtree:// URLs of DNS node lists used to find peers
+	// alongside the discovery protocol.
+	DNSDiscovery []string
+
 	// Network interface address on which the node should listen for inbound peers.
 	ListenAddr string
 
 	// If set to a non-nil value, the given NAT port mapper is used to make the
 	// listening port available to the Internet.
-	NAT nat.Interface
+	NAT nat.Interface `toml:"-"`
 
 	// If Dialer is set to a non-nil value, the given Dialer is used to dial outbound
 	// peer connections.
-	Dialer *net.Dialer
+	Dialer *net.Dialer `toml:"-"`
+
+	// If NodeDialer is set to a non-nil value, it is used instead of Dialer to open
+	// outbound peer connections, e.g. over in-memory pipes in network simulations.
+	NodeDialer p2p.NodeDialer `toml:"-"`
 
 	// If NoDial is true, the node will not dial any peers.
 	NoDial bool
 
+	// If EnableMsgEvents is set, the p2p server emits an event for every
+	// message exchanged with peers, used by network simulations.
+	EnableMsgEvents bool `toml:"-"`
+
+	// MsgRecordDir is the directory to which the messages of the protocols listed
+	// in MsgRecordProtocols are recorded for later replay, one file per protocol
+	// and run of the node. Recording is disabled if it is empty.
+	MsgRecordDir string
+
+	// MsgRecordProtocols lists the names of the protocols to record. If it is
+	// empty, all protocols are recorded.
+	MsgRecordProtocols []string
+
 	// MaxPeers is the maximum number of peers that can be connected. If this is
 	// set to zero, then only the configured static and trusted peers can connect.
 	MaxPeers int
@@ -138,6 +161,15 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// DialRatio is the ratio of inbound to dialed peers, reserving a part of
+	// MaxPeers for dialed connections. Zero defaults to preset values.
+	DialRatio int
+
+	// MaxInboundPerIP and MaxInboundPerSubnet limit the inbound peers sharing
+	// an IP address or subnet. Zero defaults to preset values.
+	MaxInboundPerIP     int
+	MaxInboundPerSubnet int
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -157,13 +189,22 @@ type Config struct {
 	// exposed.
 	HTTPModules []string
 
+	// HTTPAuth restricts access to the HTTP RPC interface to requests carrying
+	// one of the configured bearer tokens or JWTs and a whitelisted Host header.
+	HTTPAuth rpc.AuthConfig
+
+	// HTTPLimits caps the batch and request sizes, execution times and request
+	// rates of the clients of the HTTP RPC interface.
+	HTTPLimits rpc.Limits
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string
 
 	// WSPort is the TCP port number on which to start the websocket RPC server. The
 	// default zero value is/ valid and will pick a port number randomly (useful for
-	// ephemeral nodes).
+	// ephemeral nodes). If the websocket endpoint is the same as the HTTP one, both
+	// are served on a single listener, telling websocket upgrade requests apart.
 	WSPort int
 
 	// WSOrigins is the list of domain to accept websocket requests from. Please be
@@ -175,6 +216,15 @@ type Config struct {
 	// If the module list is empty, all RPC API endpoints designated public will be
 	// exposed.
 	WSModules []string
+
+	// WSAuth restricts access to the websocket RPC interface to connections
+	// carrying one of the configured bearer tokens or JWTs and a whitelisted Host
+	// header.
+	WSAuth rpc.AuthConfig
+
+	// WSLimits caps the batch and message sizes, execution times and request
+	// rates of the clients of the websocket RPC interface.
+	WSLimits rpc.Limits
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -210,6 +260,14 @@ func (c *Config) NodeDB() string {
 	return c.resolvePath("nodes")
 }
 
+// NodeDBV5 returns the path to the discovery v5 node database.
+func (c *Config) NodeDBV5() string {
+	if c.DataDir == "" {
+		return "" // ephemeral
+	}
+	return c.resolvePath("nodes-v5")
+}
+
 // DefaultIPCEndpoint returns the IPC path used by default.
 func DefaultIPCEndpoint(clientIdentifier string) string {
 	if clientIdentifier == "" {
@@ -378,26 +436,52 @@ func (c *Config) parsePersistentNodes(path string) []*discover.Node {
 	if _, err := os.Stat(path); err != nil {
 		return nil
 	}
-	// Load the nodes from the config file.
+	nodes, err := loadNodeFile(path)
+	if err != nil {
+		glog.V(logger.Error).Infof("Can't load node file %s: %v", path, err)
+	}
+	return nodes
+}
+
+// nodeURLError is returned by loadNodeFile for invalid node URLs in the list.
+type nodeURLError struct {
+	url string
+	err error
+}
+
+func (e *nodeURLError) Error() string {
+	return fmt.Sprintf("invalid node URL %s: %v", e.url, e.err)
+}
+
+// loadNodeFile loads a JSON list of node URLs. Invalid URLs are skipped, but
+// reported in the error along with the nodes which could be parsed.
+func loadNodeFile(path string) ([]*discover.Node, error) {
 	var nodelist []string
 	if err := common.LoadJSON(path, &nodelist); err != nil {
-		glog.V(logger.Error).Infof("Can't load node file %s: %v", path, err)
-		return nil
+		return nil, err
 	}
 	// Interpret the list as a discovery node array
-	var nodes []*discover.Node
+	var (
+		nodes []*discover.Node
+		bad   *nodeURLError
+	)
 	for _, url := range nodelist {
 		if url == "" {
 			continue
 		}
 		node, err := discover.ParseNode(url)
 		if err != nil {
-			glog.V(logger.Error).Infof("Node URL %s: %v\n", url, err)
+			if bad == nil {
+				bad = &nodeURLError{url, err}
+			}
 			continue
 		}
 		nodes = append(nodes, node)
 	}
-	return nodes
+	if bad != nil {
+		return nodes, bad
+	}
+	return nodes, nil
 }
 
 func makeAccountManager(conf *Config) (am *accounts.Manager, ephemeralKeystore string, err error) {
@@ -25,7 +25,9 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
+	ethereum "github.com/EarthDollar/go-earthdollar"
 	"github.com/EarthDollar/go-earthdollar/accounts"
 	"github.com/EarthDollar/go-earthdollar/ethdb"
 	"github.com/EarthDollar/go-earthdollar/event"
@@ -33,6 +35,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
 	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/msgrec"
 	"github.com/EarthDollar/go-earthdollar/rpc"
 	"github.com/syndtr/goleveldb/leveldb/storage"
 )
@@ -56,7 +59,9 @@ type Node struct {
 	instanceDirLock   storage.Storage // prevents concurrent use of instance directory
 
 	serverConfig p2p.Config
-	server       *p2p.Server // Currently running P2P networking layer
+	server       *p2p.Server        // Currently running P2P networking layer
+	recorders    []*msgrec.Recorder // Message recorders of the running protocols
+	peerLists    *peerLists         // Static and trusted nodes of the running server
 
 	serviceFuncs []ServiceConstructor     // Service constructors (in dependency order)
 	services     map[reflect.Type]Service // Currently running services
@@ -68,14 +73,18 @@ type Node struct {
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
 	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
 
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpEndpoint  string      // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpWhitelist []string    // HTTP RPC modules to allow through this endpoint
+	httpServer    *httpServer // HTTP RPC listener to server API requests
+	httpHandler   *rpc.Server // HTTP RPC request handler to process the API requests
 
-	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
-	wsListener net.Listener // Websocket RPC listener socket to server API requests
-	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
+	wsEndpoint string      // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
+	wsServer   *httpServer // Websocket RPC listener to server API requests, shared with HTTP if on the same endpoint
+	wsHandler  *rpc.Server // Websocket RPC request handler to process the API requests
+
+	healthLock    sync.RWMutex               // Protects the sources of the health check
+	healthServer  *p2p.Server                // P2P server reported on by the health check, nil if stopped
+	healthSyncers []ethereum.ChainSyncReader // Services reporting their sync status to the health check
 
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
@@ -154,23 +163,30 @@ func (n *Node) Start() error {
 	// Initialize the p2p server. This creates the node key and
 	// discovery databases.
 	n.serverConfig = p2p.Config{
-		PrivateKey:       n.config.NodeKey(),
-		Name:             n.config.NodeName(),
-		Discovery:        !n.config.NoDiscovery,
-		DiscoveryV5:      n.config.DiscoveryV5,
-		DiscoveryV5Addr:  n.config.DiscoveryV5Addr,
-		BootstrapNodes:   n.config.BootstrapNodes,
-		BootstrapNodesV5: n.config.BootstrapNodesV5,
-		StaticNodes:      n.config.StaticNodes(),
-		TrustedNodes:     n.config.TrusterNodes(),
-		NodeDatabase:     n.config.NodeDB(),
-		ListenAddr:       n.config.ListenAddr,
-		NetRestrict:      n.config.NetRestrict,
-		NAT:              n.config.NAT,
-		Dialer:           n.config.Dialer,
-		NoDial:           n.config.NoDial,
-		MaxPeers:         n.config.MaxPeers,
-		MaxPendingPeers:  n.config.MaxPendingPeers,
+		PrivateKey:          n.config.NodeKey(),
+		Name:                n.config.NodeName(),
+		Discovery:           !n.config.NoDiscovery,
+		DiscoveryV5:         n.config.DiscoveryV5,
+		DiscoveryV5Addr:     n.config.DiscoveryV5Addr,
+		BootstrapNodes:      n.config.BootstrapNodes,
+		BootstrapNodesV5:    n.config.BootstrapNodesV5,
+		DNSDiscovery:        n.config.DNSDiscovery,
+		StaticNodes:         n.config.StaticNodes(),
+		TrustedNodes:        n.config.TrusterNodes(),
+		NodeDatabase:        n.config.NodeDB(),
+		NodeDatabaseV5:      n.config.NodeDBV5(),
+		ListenAddr:          n.config.ListenAddr,
+		NetRestrict:         n.config.NetRestrict,
+		NAT:                 n.config.NAT,
+		Dialer:              n.config.Dialer,
+		NodeDialer:          n.config.NodeDialer,
+		NoDial:              n.config.NoDial,
+		EnableMsgEvents:     n.config.EnableMsgEvents,
+		MaxPeers:            n.config.MaxPeers,
+		MaxPendingPeers:     n.config.MaxPendingPeers,
+		DialRatio:           n.config.DialRatio,
+		MaxInboundPerIP:     n.config.MaxInboundPerIP,
+		MaxInboundPerSubnet: n.config.MaxInboundPerSubnet,
 	}
 	running := &p2p.Server{Config: n.serverConfig}
 	glog.V(logger.Info).Infoln("instance:", n.serverConfig.Name)
@@ -203,7 +219,11 @@ func (n *Node) Start() error {
 	for _, service := range services {
 		running.Protocols = append(running.Protocols, service.Protocols()...)
 	}
+	if err := n.startRecording(running); err != nil {
+		return err
+	}
 	if err := running.Start(); err != nil {
+		n.stopRecording()
 		if errno, ok := err.(syscall.Errno); ok && datadirInUseErrnos[uint(errno)] {
 			return ErrDatadirUsed
 		}
@@ -218,6 +238,7 @@ func (n *Node) Start() error {
 				services[kind].Stop()
 			}
 			running.Stop()
+			n.stopRecording()
 
 			return err
 		}
@@ -230,16 +251,71 @@ func (n *Node) Start() error {
 			service.Stop()
 		}
 		running.Stop()
+		n.stopRecording()
 		return err
 	}
 	// Finish initializing the startup
 	n.services = services
 	n.server = running
+	n.peerLists = newPeerLists(running,
+		n.config.resolvePath(datadirStaticNodes), n.config.resolvePath(datadirTrustedNodes),
+		n.serverConfig.StaticNodes, n.serverConfig.TrustedNodes)
 	n.stop = make(chan struct{})
+	n.setHealthSources(running, services)
 
 	return nil
 }
 
+// startRecording wraps the protocols of the server selected for recording with
+// message recorders, creating one recording file per protocol name.
+func (n *Node) startRecording(server *p2p.Server) error {
+	if n.config.MsgRecordDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(n.config.MsgRecordDir, 0700); err != nil {
+		return err
+	}
+	selected := make(map[string]bool)
+	for _, name := range n.config.MsgRecordProtocols {
+		selected[name] = true
+	}
+	recorders := make(map[string]*msgrec.Recorder)
+	stamp := time.Now().Format("20060102-150405")
+	for i, proto := range server.Protocols {
+		if len(selected) > 0 && !selected[proto.Name] {
+			continue
+		}
+		rec := recorders[proto.Name]
+		if rec == nil {
+			file := filepath.Join(n.config.MsgRecordDir, proto.Name+"-"+stamp+".rec")
+			var err error
+			if rec, err = msgrec.CreateRecorder(file, proto.Name); err != nil {
+				n.stopRecording()
+				return err
+			}
+			recorders[proto.Name] = rec
+			n.recorders = append(n.recorders, rec)
+			glog.V(logger.Info).Infof("Recording %s messages to %s", proto.Name, file)
+		}
+		server.Protocols[i] = rec.Protocol(proto)
+	}
+	return nil
+}
+
+// stopRecording closes the message recorders.
+func (n *Node) stopRecording() {
+	for _, rec := range n.recorders {
+		err := rec.Err()
+		if cerr := rec.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			glog.V(logger.Warn).Infof("Message recording failed: %v", err)
+		}
+	}
+	n.recorders = nil
+}
+
 func (n *Node) openDataDir() error {
 	if n.config.DataDir == "" {
 		return nil // ephemeral
@@ -277,12 +353,12 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 		n.stopInProc()
 		return err
 	}
-	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors); err != nil {
+	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors, &n.config.HTTPAuth, &n.config.HTTPLimits); err != nil {
 		n.stopIPC()
 		n.stopInProc()
 		return err
 	}
-	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins); err != nil {
+	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins, &n.config.WSAuth, &n.config.WSLimits); err != nil {
 		n.stopHTTP()
 		n.stopIPC()
 		n.stopInProc()
@@ -379,7 +455,7 @@ func (n *Node) stopIPC() {
 }
 
 // startHTTP initializes and starts the HTTP RPC endpoint.
-func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors string) error {
+func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors string, auth *rpc.AuthConfig, limits *rpc.Limits) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
@@ -399,30 +475,46 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 			glog.V(logger.Debug).Infof("HTTP registered %T under '%s'", api.Service, api.Namespace)
 		}
 	}
-	// All APIs registered, start the HTTP listener
-	var (
-		listener net.Listener
-		err      error
-	)
-	if listener, err = net.Listen("tcp", endpoint); err != nil {
+	if err := handler.SetAuth(auth); err != nil {
+		return err
+	}
+	if err := handler.SetLimits(limits); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	// All APIs registered, start the HTTP listener unless shared with websockets
+	server := n.wsServer
+	if !server.serves(endpoint) {
+		var err error
+		if server, err = startHTTPServer(endpoint, n.healthHandler()); err != nil {
+			return err
+		}
+	}
+	_, ws := server.handlers()
+	server.setHandlers(handler.HTTPHandler(cors), ws)
 	glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", endpoint)
 
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
-	n.httpListener = listener
+	n.httpServer = server
 	n.httpHandler = handler
 
 	return nil
 }
 
-// stopHTTP terminates the HTTP RPC endpoint.
+// stopHTTP terminates the HTTP RPC endpoint, allowing in-flight requests to
+// finish. The listener is kept open if shared with the websocket endpoint.
 func (n *Node) stopHTTP() {
-	if n.httpListener != nil {
-		n.httpListener.Close()
-		n.httpListener = nil
+	if n.httpServer != nil {
+		_, ws := n.httpServer.handlers()
+		if ws == nil {
+			n.httpServer.stopAccepting()
+		}
+		n.httpHandler.Shutdown(rpcDrainTimeout)
+		n.httpServer.setHandlers(nil, ws)
+		if ws == nil {
+			n.httpServer.close(rpcDrainTimeout)
+		}
+		n.httpServer = nil
 
 		glog.V(logger.Info).Infof("HTTP endpoint closed: http://%s", n.httpEndpoint)
 	}
@@ -433,7 +525,7 @@ func (n *Node) stopHTTP() {
 }
 
 // startWS initializes and starts the websocket RPC endpoint.
-func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins string) error {
+func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins string, auth *rpc.AuthConfig, limits *rpc.Limits) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
@@ -453,30 +545,47 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 			glog.V(logger.Debug).Infof("WebSocket registered %T under '%s'", api.Service, api.Namespace)
 		}
 	}
-	// All APIs registered, start the HTTP listener
-	var (
-		listener net.Listener
-		err      error
-	)
-	if listener, err = net.Listen("tcp", endpoint); err != nil {
+	if err := handler.SetAuth(auth); err != nil {
 		return err
 	}
-	go rpc.NewWSServer(wsOrigins, handler).Serve(listener)
+	if err := handler.SetLimits(limits); err != nil {
+		return err
+	}
+	// All APIs registered, start the HTTP listener unless shared with HTTP RPC
+	server := n.httpServer
+	if !server.serves(endpoint) {
+		var err error
+		if server, err = startHTTPServer(endpoint, n.healthHandler()); err != nil {
+			return err
+		}
+	}
+	rpc, _ := server.handlers()
+	server.setHandlers(rpc, handler.WebsocketHandler(wsOrigins))
 	glog.V(logger.Info).Infof("WebSocket endpoint opened: ws://%s", endpoint)
 
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
-	n.wsListener = listener
+	n.wsServer = server
 	n.wsHandler = handler
 
 	return nil
 }
 
-// stopWS terminates the websocket RPC endpoint.
+// stopWS terminates the websocket RPC endpoint, allowing in-flight requests to
+// finish before closing the connections along with their subscriptions. The
+// listener is kept open if shared with the HTTP endpoint.
 func (n *Node) stopWS() {
-	if n.wsListener != nil {
-		n.wsListener.Close()
-		n.wsListener = nil
+	if n.wsServer != nil {
+		rpc, _ := n.wsServer.handlers()
+		if rpc == nil {
+			n.wsServer.stopAccepting()
+		}
+		n.wsHandler.Shutdown(rpcDrainTimeout)
+		n.wsServer.setHandlers(rpc, nil)
+		if rpc == nil {
+			n.wsServer.close(rpcDrainTimeout)
+		}
+		n.wsServer = nil
 
 		glog.V(logger.Info).Infof("WebSocket endpoint closed: ws://%s", n.wsEndpoint)
 	}
@@ -498,6 +607,7 @@ func (n *Node) Stop() error {
 	}
 
 	// Terminate the API, services and the p2p server.
+	n.setHealthSources(nil, nil)
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
@@ -510,7 +620,10 @@ func (n *Node) Stop() error {
 			failure.Services[kind] = err
 		}
 	}
+	n.peerLists.close()
+	n.peerLists = nil
 	n.server.Stop()
+	n.stopRecording()
 	n.services = nil
 	n.server = nil
 
@@ -538,6 +651,15 @@ func (n *Node) Stop() error {
 	return nil
 }
 
+// peers retrieves the static and trusted nodes of the running server, nil if
+// the node is stopped.
+func (n *Node) peers() *peerLists {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.peerLists
+}
+
 // Wait blocks the thread until the node is stopped. If the node is not running
 // at the time of invocation, the method immediately returns.
 func (n *Node) Wait() {
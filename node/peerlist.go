@@ -0,0 +1,280 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/logger"
+	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// nodeFileCheckInterval is the interval at which the static and trusted node
+// files are checked for modifications.
+const nodeFileCheckInterval = 3 * time.Second
+
+// peerServer is the part of the p2p server maintaining static and trusted
+// nodes.
+type peerServer interface {
+	AddPeer(node *discover.Node)
+	RemovePeer(node *discover.Node)
+	AddTrustedPeer(node *discover.Node)
+	RemoveTrustedPeer(node *discover.Node)
+}
+
+// peerLists keeps the static and trusted nodes of the p2p server in sync with
+// the static-nodes.json and trusted-nodes.json files of the instance directory.
+// Changes made through the admin API are written to the files, and edits of
+// the files are applied while the node is running.
+type peerLists struct {
+	mu      sync.Mutex // serializes changes of the lists
+	static  *nodeFile
+	trusted *nodeFile
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// nodeFile is a node list stored in a JSON file, mirroring a set of nodes
+// maintained by the p2p server.
+type nodeFile struct {
+	name        string
+	path        string // empty for ephemeral nodes, nothing is stored
+	nodes       []*discover.Node
+	modTime     time.Time // modification time of the file when last read or written
+	size        int64
+	invalid     error // parse error of the file, which isn't overwritten while set
+	add, remove func(*discover.Node)
+}
+
+// newPeerLists creates the lists of static and trusted nodes, which have been
+// loaded from the given files.
+func newPeerLists(srv peerServer, staticPath, trustedPath string, static, trusted []*discover.Node) *peerLists {
+	l := &peerLists{
+		static:  newNodeFile("static", staticPath, static, srv.AddPeer, srv.RemovePeer),
+		trusted: newNodeFile("trusted", trustedPath, trusted, srv.AddTrustedPeer, srv.RemoveTrustedPeer),
+		quit:    make(chan struct{}),
+	}
+	if staticPath != "" || trustedPath != "" {
+		l.wg.Add(1)
+		go l.loop()
+	}
+	return l
+}
+
+func newNodeFile(name, path string, nodes []*discover.Node, add, remove func(*discover.Node)) *nodeFile {
+	f := &nodeFile{name: name, path: path, nodes: nodes, add: add, remove: remove}
+	if path != "" {
+		f.modTime, f.size = f.stat()
+		if !f.modTime.IsZero() {
+			_, f.invalid = loadNodeFile(path)
+		}
+	}
+	return f
+}
+
+// close stops watching the files.
+func (l *peerLists) close() {
+	close(l.quit)
+	l.wg.Wait()
+}
+
+// addStatic adds a static node, keeping it connected.
+func (l *peerLists) addStatic(n *discover.Node) error {
+	return l.update(l.static, n, true)
+}
+
+// removeStatic removes a static node and disconnects it.
+func (l *peerLists) removeStatic(n *discover.Node) error {
+	return l.update(l.static, n, false)
+}
+
+// addTrusted adds a trusted node, which may connect above the peer limit.
+func (l *peerLists) addTrusted(n *discover.Node) error {
+	return l.update(l.trusted, n, true)
+}
+
+// removeTrusted removes a trusted node.
+func (l *peerLists) removeTrusted(n *discover.Node) error {
+	return l.update(l.trusted, n, false)
+}
+
+func (l *peerLists) update(f *nodeFile, n *discover.Node, add bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var changed bool
+	if add {
+		f.add(n)
+		changed = f.insert(n)
+	} else {
+		f.remove(n)
+		changed = f.delete(n.ID)
+	}
+	if !changed {
+		return nil
+	}
+	return f.save()
+}
+
+// loop checks the files for modifications until the lists are closed.
+func (l *peerLists) loop() {
+	defer l.wg.Done()
+
+	check := time.NewTicker(nodeFileCheckInterval)
+	defer check.Stop()
+	for {
+		select {
+		case <-check.C:
+			l.check()
+		case <-l.quit:
+			return
+		}
+	}
+}
+
+// check reloads the files modified since they were last read or written.
+func (l *peerLists) check() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.static.reload()
+	l.trusted.reload()
+}
+
+// insert adds a node to the list or updates its endpoint. It reports whether
+// the list changed.
+func (f *nodeFile) insert(n *discover.Node) bool {
+	for i, old := range f.nodes {
+		if old.ID == n.ID {
+			if old.String() == n.String() {
+				return false
+			}
+			f.nodes[i] = n
+			return true
+		}
+	}
+	f.nodes = append(f.nodes, n)
+	return true
+}
+
+// delete removes a node from the list, reporting whether it was listed.
+func (f *nodeFile) delete(id discover.NodeID) bool {
+	for i, old := range f.nodes {
+		if old.ID == id {
+			f.nodes = append(f.nodes[:i], f.nodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the list to the file. A file which can't be parsed is left alone,
+// as it may hold nodes the user still wants to fix up.
+func (f *nodeFile) save() error {
+	if f.path == "" {
+		return nil
+	}
+	if f.invalid != nil {
+		return fmt.Errorf("%s nodes not saved, %s is invalid: %v", f.name, f.path, f.invalid)
+	}
+	urls := make([]string, len(f.nodes))
+	for i, n := range f.nodes {
+		urls[i] = n.String()
+	}
+	content, err := json.MarshalIndent(urls, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so the list is never seen half-written.
+	tmp := f.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(content, '\n'), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	f.modTime, f.size = f.stat()
+	return nil
+}
+
+// stat returns the modification time and size of the file, zero values if it
+// doesn't exist.
+func (f *nodeFile) stat() (time.Time, int64) {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return fi.ModTime(), fi.Size()
+}
+
+// reload reads the file if it was modified, applying the difference to the
+// current list. A removed file empties the list, a file which can't be parsed
+// is ignored, and not saved over, until it is modified again. Of a file with
+// invalid node URLs the valid ones are applied, but it is not saved over either.
+func (f *nodeFile) reload() {
+	if f.path == "" {
+		return
+	}
+	modTime, size := f.stat()
+	if modTime.Equal(f.modTime) && size == f.size {
+		return
+	}
+	f.modTime, f.size = modTime, size
+
+	var nodes []*discover.Node
+	f.invalid = nil
+	if !modTime.IsZero() {
+		var err error
+		if nodes, err = loadNodeFile(f.path); err != nil {
+			glog.V(logger.Warn).Infof("Can't reload %s nodes: %v", f.name, err)
+			f.invalid = err
+			if _, ok := err.(*nodeURLError); !ok {
+				return
+			}
+		}
+	}
+	current := make(map[discover.NodeID]*discover.Node, len(f.nodes))
+	for _, n := range f.nodes {
+		current[n.ID] = n
+	}
+	var added, removed int
+	for _, n := range nodes {
+		if old := current[n.ID]; old == nil || old.String() != n.String() {
+			f.add(n)
+			added++
+		}
+		delete(current, n.ID)
+	}
+	for _, n := range current {
+		f.remove(n)
+		removed++
+	}
+	f.nodes = nodes
+	glog.V(logger.Info).Infof("Reloaded %s nodes from %s: %d added or updated, %d removed", f.name, f.path, added, removed)
+}
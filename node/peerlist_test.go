@@ -0,0 +1,251 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// testPeerServer records the calls made to maintain the peer lists.
+type testPeerServer struct {
+	calls []string
+}
+
+func (s *testPeerServer) record(op string, n *discover.Node) {
+	s.calls = append(s.calls, fmt.Sprintf("%s %x", op, n.ID[:1]))
+}
+
+func (s *testPeerServer) AddPeer(n *discover.Node)           { s.record("addpeer", n) }
+func (s *testPeerServer) RemovePeer(n *discover.Node)        { s.record("removepeer", n) }
+func (s *testPeerServer) AddTrustedPeer(n *discover.Node)    { s.record("addtrusted", n) }
+func (s *testPeerServer) RemoveTrustedPeer(n *discover.Node) { s.record("removetrusted", n) }
+
+func (s *testPeerServer) expect(t *testing.T, want ...string) {
+	if !reflect.DeepEqual(s.calls, want) {
+		t.Errorf("server calls mismatch:\nhave %q\nwant %q", s.calls, want)
+	}
+	s.calls = nil
+}
+
+func testNode(id byte, port int) *discover.Node {
+	return discover.NewNode(discover.NodeID{id}, []byte{127, 0, 0, 1}, uint16(port), uint16(port))
+}
+
+// writeNodeFile replaces the content of a node file, moving its modification
+// time so the change is noticed.
+func writeNodeFile(t *testing.T, path string, content string, age time.Duration) {
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Tests that changes made through the peer lists are persisted.
+func TestPeerListsPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerlist-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		srv         = new(testPeerServer)
+		staticPath  = filepath.Join(dir, datadirStaticNodes)
+		trustedPath = filepath.Join(dir, datadirTrustedNodes)
+		lists       = newPeerLists(srv, staticPath, trustedPath, nil, nil)
+	)
+	defer lists.close()
+
+	lists.addStatic(testNode(1, 30303))
+	lists.addStatic(testNode(2, 30303))
+	lists.addTrusted(testNode(3, 30303))
+	lists.removeStatic(testNode(1, 30303))
+	lists.removeTrusted(testNode(4, 30303))
+	srv.expect(t, "addpeer 01", "addpeer 02", "addtrusted 03", "removepeer 01", "removetrusted 04")
+
+	if nodes, err := loadNodeFile(staticPath); err != nil {
+		t.Errorf("can't load static nodes: %v", err)
+	} else if len(nodes) != 1 || nodes[0].ID != (discover.NodeID{2}) {
+		t.Errorf("static nodes mismatch: %v", nodes)
+	}
+	if nodes, err := loadNodeFile(trustedPath); err != nil {
+		t.Errorf("can't load trusted nodes: %v", err)
+	} else if len(nodes) != 1 || nodes[0].ID != (discover.NodeID{3}) {
+		t.Errorf("trusted nodes mismatch: %v", nodes)
+	}
+
+	// Writing the lists must not be mistaken for an edit.
+	lists.check()
+	srv.expect(t)
+}
+
+// Tests that edits of the node files are applied to the server.
+func TestPeerListsReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerlist-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		srv         = new(testPeerServer)
+		staticPath  = filepath.Join(dir, datadirStaticNodes)
+		trustedPath = filepath.Join(dir, datadirTrustedNodes)
+	)
+	writeNodeFile(t, staticPath, fmt.Sprintf("[%q, %q]", testNode(1, 30303), testNode(2, 30303)), time.Hour)
+	static, err := loadNodeFile(staticPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lists := newPeerLists(srv, staticPath, trustedPath, static, nil)
+	defer lists.close()
+
+	// Unmodified files are not reloaded.
+	lists.check()
+	srv.expect(t)
+
+	// Added, changed and removed nodes are applied.
+	writeNodeFile(t, staticPath, fmt.Sprintf("[%q, %q]", testNode(2, 30304), testNode(3, 30303)), 2*time.Hour)
+	writeNodeFile(t, trustedPath, fmt.Sprintf("[%q]", testNode(4, 30303)), time.Hour)
+	lists.check()
+	srv.expect(t, "addpeer 02", "addpeer 03", "removepeer 01", "addtrusted 04")
+
+	// Malformed files are ignored.
+	writeNodeFile(t, staticPath, "[", time.Hour)
+	lists.check()
+	srv.expect(t)
+
+	// Removing a file removes its nodes.
+	if err := os.Remove(trustedPath); err != nil {
+		t.Fatal(err)
+	}
+	lists.check()
+	srv.expect(t, "removetrusted 04")
+
+	// Once fixed, the malformed file is diffed against the last good list.
+	writeNodeFile(t, staticPath, fmt.Sprintf("[%q]", testNode(3, 30303)), 3*time.Hour)
+	lists.check()
+	srv.expect(t, "removepeer 02")
+}
+
+// Tests that node files which can't be parsed are not overwritten by changes
+// made through the peer lists, until they are fixed.
+func TestPeerListsInvalidFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerlist-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		srv         = new(testPeerServer)
+		staticPath  = filepath.Join(dir, datadirStaticNodes)
+		trustedPath = filepath.Join(dir, datadirTrustedNodes)
+		content     = fmt.Sprintf("[%q,", testNode(1, 30303))
+	)
+	writeNodeFile(t, staticPath, content, time.Hour)
+	lists := newPeerLists(srv, staticPath, trustedPath, nil, nil)
+	defer lists.close()
+
+	// The file failing to load at startup must not be replaced.
+	if err := lists.addStatic(testNode(2, 30303)); err == nil {
+		t.Error("node added over invalid static nodes file")
+	}
+	if data, _ := ioutil.ReadFile(staticPath); string(data) != content {
+		t.Errorf("invalid static nodes file overwritten: %s", data)
+	}
+	// Nor a file broken while running.
+	writeNodeFile(t, trustedPath, fmt.Sprintf("[%q]", testNode(3, 30303)), time.Hour)
+	lists.check()
+	writeNodeFile(t, trustedPath, "[", 2*time.Hour)
+	lists.check()
+	if err := lists.addTrusted(testNode(4, 30303)); err == nil {
+		t.Error("node added over invalid trusted nodes file")
+	}
+	if data, _ := ioutil.ReadFile(trustedPath); string(data) != "[" {
+		t.Errorf("invalid trusted nodes file overwritten: %s", data)
+	}
+	srv.expect(t, "addpeer 02", "addtrusted 03", "addtrusted 04")
+
+	// Once fixed, changes are saved again.
+	writeNodeFile(t, staticPath, fmt.Sprintf("[%q]", testNode(1, 30303)), 3*time.Hour)
+	lists.check()
+	if err := lists.addStatic(testNode(5, 30303)); err != nil {
+		t.Errorf("can't add node to fixed static nodes file: %v", err)
+	}
+	if nodes, err := loadNodeFile(staticPath); err != nil {
+		t.Errorf("can't load static nodes: %v", err)
+	} else if len(nodes) != 2 || nodes[0].ID != (discover.NodeID{1}) || nodes[1].ID != (discover.NodeID{5}) {
+		t.Errorf("static nodes mismatch: %v", nodes)
+	}
+}
+
+// Tests that files with invalid node URLs aren't overwritten, which would drop
+// the invalid entries, while the valid ones are still used.
+func TestPeerListsInvalidURL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerlist-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		srv         = new(testPeerServer)
+		staticPath  = filepath.Join(dir, datadirStaticNodes)
+		trustedPath = filepath.Join(dir, datadirTrustedNodes)
+		content     = fmt.Sprintf("[%q, \"enode://bad\"]", testNode(1, 30303))
+	)
+	writeNodeFile(t, staticPath, content, time.Hour)
+	nodes, err := loadNodeFile(staticPath)
+	if err == nil {
+		t.Fatal("invalid node URL not reported")
+	}
+	if len(nodes) != 1 || nodes[0].ID != (discover.NodeID{1}) {
+		t.Fatalf("valid nodes not loaded: %v", nodes)
+	}
+	lists := newPeerLists(srv, staticPath, trustedPath, nodes, nil)
+	defer lists.close()
+
+	if err := lists.addStatic(testNode(2, 30303)); err == nil {
+		t.Error("node added over static nodes file with invalid URL")
+	}
+	if data, _ := ioutil.ReadFile(staticPath); string(data) != content {
+		t.Errorf("static nodes file with invalid URL overwritten: %s", data)
+	}
+	// Reloading applies the valid nodes, but doesn't overwrite either.
+	content = fmt.Sprintf("[%q, \"enode://bad\"]", testNode(3, 30303))
+	writeNodeFile(t, trustedPath, content, time.Hour)
+	lists.check()
+	if err := lists.addTrusted(testNode(4, 30303)); err == nil {
+		t.Error("node added over trusted nodes file with invalid URL")
+	}
+	if data, _ := ioutil.ReadFile(trustedPath); string(data) != content {
+		t.Errorf("trusted nodes file with invalid URL overwritten: %s", data)
+	}
+	srv.expect(t, "addpeer 02", "addtrusted 03", "addtrusted 04")
+}
@@ -0,0 +1,238 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	ethereum "github.com/EarthDollar/go-earthdollar"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"golang.org/x/net/context"
+)
+
+const (
+	// rpcDrainTimeout is the time allowed for in-flight RPC requests to finish
+	// when an HTTP or WebSocket endpoint is stopped.
+	rpcDrainTimeout = 5 * time.Second
+
+	// healthPath is the URL path of the health check served by the HTTP and
+	// WebSocket endpoints.
+	healthPath = "/health"
+)
+
+// httpServer is an HTTP listener serving the HTTP and/or WebSocket RPC handlers
+// of the node, along with the health check. WebSocket upgrade requests are
+// routed to the WebSocket handler and all others to the HTTP one, allowing both
+// to share a single port.
+type httpServer struct {
+	endpoint string
+	listener net.Listener
+	server   *http.Server
+	health   http.Handler
+
+	lock  sync.RWMutex
+	rpc   http.Handler // HTTP JSON-RPC handler, nil if not served
+	ws    http.Handler // WebSocket JSON-RPC handler, nil if not served
+	conns map[net.Conn]http.ConnState
+}
+
+// startHTTPServer opens a listener on the given endpoint and starts serving
+// requests on it.
+func startHTTPServer(endpoint string, health http.Handler) (*httpServer, error) {
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	srv := &httpServer{
+		endpoint: endpoint,
+		listener: listener,
+		health:   health,
+		conns:    make(map[net.Conn]http.ConnState),
+	}
+	srv.server = &http.Server{Handler: srv, ConnState: srv.trackConn}
+	go srv.server.Serve(listener)
+	return srv, nil
+}
+
+// serves reports whether the listener is opened on the given endpoint and can be
+// shared with it. Endpoints with random ports are never shared.
+func (srv *httpServer) serves(endpoint string) bool {
+	if srv == nil {
+		return false
+	}
+	_, port, _ := net.SplitHostPort(endpoint)
+	return srv.endpoint == endpoint && port != "0"
+}
+
+// ServeHTTP dispatches a request to the health check or the RPC handlers.
+func (srv *httpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.lock.RLock()
+	rpc, ws := srv.rpc, srv.ws
+	srv.lock.RUnlock()
+
+	switch {
+	case r.URL.Path == healthPath && r.Method == "GET":
+		srv.health.ServeHTTP(w, r)
+	case ws != nil && (rpc == nil || isWebsocket(r)):
+		ws.ServeHTTP(w, r)
+	case rpc != nil:
+		rpc.ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// isWebsocket reports whether a request asks for a WebSocket upgrade.
+func isWebsocket(r *http.Request) bool {
+	return strings.ToLower(r.Header.Get("Upgrade")) == "websocket" &&
+		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
+}
+
+// setHandlers replaces the RPC handlers served by the listener.
+func (srv *httpServer) setHandlers(rpc, ws http.Handler) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	srv.rpc, srv.ws = rpc, ws
+}
+
+// handlers returns the RPC handlers served by the listener.
+func (srv *httpServer) handlers() (rpc, ws http.Handler) {
+	srv.lock.RLock()
+	defer srv.lock.RUnlock()
+
+	return srv.rpc, srv.ws
+}
+
+// trackConn records the state of the connections of the listener, so idle ones
+// can be closed on shutdown. Hijacked (WebSocket) connections are closed along
+// with their RPC codecs.
+func (srv *httpServer) trackConn(conn net.Conn, state http.ConnState) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(srv.conns, conn)
+	default:
+		srv.conns[conn] = state
+	}
+}
+
+// stopAccepting closes the listener and disables keep-alives, so connections
+// are closed once their in-flight requests have been answered.
+func (srv *httpServer) stopAccepting() {
+	srv.listener.Close()
+	srv.server.SetKeepAlivesEnabled(false)
+}
+
+// close waits up to the given timeout for the active connections to finish
+// their requests, then closes all remaining connections.
+func (srv *httpServer) close(timeout time.Duration) {
+	srv.stopAccepting()
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if !srv.active() {
+			break
+		}
+	}
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	for conn := range srv.conns {
+		conn.Close()
+	}
+	srv.conns = make(map[net.Conn]http.ConnState)
+}
+
+// active reports whether any connection is serving a request.
+func (srv *httpServer) active() bool {
+	srv.lock.RLock()
+	defer srv.lock.RUnlock()
+
+	for _, state := range srv.conns {
+		if state == http.StateActive {
+			return true
+		}
+	}
+	return false
+}
+
+// healthStatus is the response of the health check.
+type healthStatus struct {
+	Healthy      bool   `json:"healthy"`
+	Syncing      bool   `json:"syncing"`
+	CurrentBlock uint64 `json:"currentBlock,omitempty"`
+	HighestBlock uint64 `json:"highestBlock,omitempty"`
+	Peers        int    `json:"peers"`
+}
+
+// healthHandler returns the handler of the health check, reporting the sync
+// status of the services and the number of connected peers. The node is
+// reported unhealthy with status 503 while syncing, or when it has no peers
+// despite being allowed to connect to some.
+func (n *Node) healthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.healthLock.RLock()
+		server, syncers := n.healthServer, n.healthSyncers
+		n.healthLock.RUnlock()
+
+		status := healthStatus{Healthy: server != nil}
+		if server != nil {
+			status.Peers = server.PeerCount()
+			if status.Peers == 0 && server.MaxPeers > 0 {
+				status.Healthy = false
+			}
+		}
+		for _, syncer := range syncers {
+			progress, err := syncer.SyncProgress(context.Background())
+			if err != nil || progress != nil {
+				status.Healthy, status.Syncing = false, true
+			}
+			if progress != nil {
+				status.CurrentBlock, status.HighestBlock = progress.CurrentBlock, progress.HighestBlock
+			}
+		}
+		w.Header().Set("content-type", "application/json")
+		w.Header().Set("cache-control", "no-cache")
+		if !status.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+}
+
+// setHealthSources sets the p2p server and services reported on by the health
+// check, nil while the node is not running.
+func (n *Node) setHealthSources(server *p2p.Server, services map[reflect.Type]Service) {
+	var syncers []ethereum.ChainSyncReader
+	for _, service := range services {
+		if syncer, ok := service.(ethereum.ChainSyncReader); ok {
+			syncers = append(syncers, syncer)
+		}
+	}
+	n.healthLock.Lock()
+	defer n.healthLock.Unlock()
+
+	n.healthServer, n.healthSyncers = server, syncers
+}
@@ -0,0 +1,147 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	ethereum "github.com/EarthDollar/go-earthdollar"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
+)
+
+// SyncingService is a test service reporting a fixed sync progress.
+type SyncingService struct {
+	NoopService
+	progress *ethereum.SyncProgress
+}
+
+func (s *SyncingService) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return s.progress, nil
+}
+
+// freePort returns a TCP port currently unused on the loopback interface.
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// startSharedEndpointNode starts a node serving HTTP and websocket RPC on the
+// same port, returning the endpoint.
+func startSharedEndpointNode(t *testing.T, services ...ServiceConstructor) (*Node, string) {
+	port := freePort(t)
+	config := testNodeConfig()
+	config.HTTPHost, config.HTTPPort = "127.0.0.1", port
+	config.WSHost, config.WSPort, config.WSOrigins = "127.0.0.1", port, "*"
+
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	for _, service := range services {
+		if err := stack.Register(service); err != nil {
+			t.Fatalf("failed to register service: %v", err)
+		}
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start node: %v", err)
+	}
+	return stack, fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+// Tests that HTTP and websocket RPC can be served on the same port, and stopped
+// independently.
+func TestSharedHTTPWSEndpoint(t *testing.T) {
+	stack, endpoint := startSharedEndpointNode(t)
+	defer stack.Stop()
+
+	if stack.httpServer != stack.wsServer {
+		t.Fatalf("listener not shared")
+	}
+	call := func(client *rpc.Client) error {
+		var modules map[string]string
+		return client.Call(&modules, "rpc_modules")
+	}
+	httpClient, err := rpc.DialHTTP("http://" + endpoint)
+	if err != nil {
+		t.Fatalf("failed to dial HTTP: %v", err)
+	}
+	if err := call(httpClient); err != nil {
+		t.Fatalf("HTTP call failed: %v", err)
+	}
+	wsClient, err := rpc.DialWebsocket(context.Background(), "ws://"+endpoint, "")
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer wsClient.Close()
+	if err := call(wsClient); err != nil {
+		t.Fatalf("websocket call failed: %v", err)
+	}
+	// Stop HTTP RPC and ensure websockets are still served
+	if _, err := NewPrivateAdminAPI(stack).StopRPC(); err != nil {
+		t.Fatalf("failed to stop HTTP RPC: %v", err)
+	}
+	if err := call(httpClient); err == nil {
+		t.Fatalf("HTTP call succeeded after stopping the endpoint")
+	}
+	if err := call(wsClient); err != nil {
+		t.Fatalf("websocket call failed after stopping HTTP RPC: %v", err)
+	}
+}
+
+// Tests that the health check reports the sync status and peer count.
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		progress *ethereum.SyncProgress
+		status   int
+		health   healthStatus
+	}{
+		{nil, http.StatusOK, healthStatus{Healthy: true}},
+		{&ethereum.SyncProgress{CurrentBlock: 10, HighestBlock: 100}, http.StatusServiceUnavailable, healthStatus{Syncing: true, CurrentBlock: 10, HighestBlock: 100}},
+	}
+	for i, tt := range tests {
+		service := &SyncingService{progress: tt.progress}
+		stack, endpoint := startSharedEndpointNode(t, func(*ServiceContext) (Service, error) { return service, nil })
+
+		resp, err := http.Get("http://" + endpoint + healthPath)
+		if err != nil {
+			t.Fatalf("test %d: health check failed: %v", i, err)
+		}
+		var health healthStatus
+		err = json.NewDecoder(resp.Body).Decode(&health)
+		resp.Body.Close()
+		stack.Stop()
+
+		if err != nil {
+			t.Fatalf("test %d: invalid health response: %v", i, err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, resp.StatusCode, tt.status)
+		}
+		if health != tt.health {
+			t.Errorf("test %d: health mismatch: have %+v, want %+v", i, health, tt.health)
+		}
+	}
+}
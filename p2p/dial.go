@@ -22,11 +22,12 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
 )
 
@@ -35,6 +36,14 @@ const (
 	// redialing a certain node.
 	dialHistoryExpiration = 30 * time.Second
 
+	// Failed dials are retried with exponential backoff, starting at
+	// dialHistoryExpiration. Static nodes are retried more eagerly.
+	// The failure count of a node is forgotten if it hasn't failed
+	// for dialFailureExpiration.
+	maxDialBackoff        = 10 * time.Minute
+	maxStaticDialBackoff  = 2 * time.Minute
+	dialFailureExpiration = 2 * maxDialBackoff
+
 	// Discovery lookups are throttled and can only run
 	// once every few seconds.
 	lookupInterval = 4 * time.Second
@@ -51,13 +60,18 @@ type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
 	netrestrict *netutil.Netlist
+	candidate   func(*enr.Record) bool    // filters dynamic dial candidates, nil accepts all
+	banned      func(*discover.Node) bool // reports banned nodes, nil if there are none
+	dns         nodeSource                // DNS node lists, nil if not configured
 
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
 	lookupBuf     []*discover.Node // current discovery lookup results
-	randomNodes   []*discover.Node // filled from Table
+	randomNodes   []*discover.Node // filled from Table and DNS node lists
 	static        map[discover.NodeID]*dialTask
 	hist          *dialHistory
+	failures      map[discover.NodeID]*dialFailure // nodes whose last dial failed
+	nextPrune     time.Time                        // next time expired failures are removed
 }
 
 type discoverTable interface {
@@ -66,6 +80,17 @@ type discoverTable interface {
 	Resolve(target discover.NodeID) *discover.Node
 	Lookup(target discover.NodeID) []*discover.Node
 	ReadRandomNodes([]*discover.Node) int
+	Record() *enr.Record
+	SetRecordEntries(entries ...enr.Entry) error
+	NodeRecord(id discover.NodeID) *enr.Record
+	RequestENR(n *discover.Node) (*enr.Record, error)
+}
+
+// nodeSource is a source of dynamic dial candidates besides the discovery
+// table, such as DNS node lists.
+type nodeSource interface {
+	ReadRandomNodes([]*discover.Node) int
+	NodeRecord(id discover.NodeID) *enr.Record
 }
 
 // the dial history remembers recent dials.
@@ -77,6 +102,12 @@ type pastDial struct {
 	exp time.Time
 }
 
+// dialFailure counts the consecutive failed dials of a node.
+type dialFailure struct {
+	count uint
+	last  time.Time
+}
+
 type task interface {
 	Do(*Server)
 }
@@ -88,6 +119,7 @@ type dialTask struct {
 	dest         *discover.Node
 	lastResolved time.Time
 	resolveDelay time.Duration
+	err          error // outcome of the last run, nil if the node was added as a peer
 }
 
 // discoverTask runs discovery table operations.
@@ -112,6 +144,7 @@ func newDialState(static []*discover.Node, ntab discoverTable, maxdyn int, netre
 		dialing:     make(map[discover.NodeID]connFlag),
 		randomNodes: make([]*discover.Node, maxdyn/2),
 		hist:        new(dialHistory),
+		failures:    make(map[discover.NodeID]*dialFailure),
 	}
 	for _, n := range static {
 		s.addStatic(n)
@@ -134,7 +167,11 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	var newtasks []task
 	addDial := func(flag connFlag, n *discover.Node) bool {
 		if err := s.checkDial(n, peers); err != nil {
-			glog.V(logger.Debug).Infof("skipping dial candidate %x@%v:%d: %v", n.ID[:8], n.IP, n.TCP, err)
+			log.Trace("Skipping dial candidate", "id", n.ID, "addr", &net.TCPAddr{IP: n.IP, Port: int(n.TCP)}, "err", err)
+			return false
+		}
+		if s.candidate != nil && !s.candidate(s.nodeRecord(n.ID)) {
+			log.Trace("Skipping dial candidate", "id", n.ID, "addr", &net.TCPAddr{IP: n.IP, Port: int(n.TCP)}, "err", errRejectedRecord)
 			return false
 		}
 		s.dialing[n.ID] = flag
@@ -163,7 +200,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 		err := s.checkDial(t.dest, peers)
 		switch err {
 		case errNotWhitelisted, errSelf:
-			glog.V(logger.Debug).Infof("removing static dial candidate %x@%v:%d: %v", t.dest.ID[:8], t.dest.IP, t.dest.TCP, err)
+			log.Warn("Removing static dial candidate", "id", t.dest.ID, "addr", &net.TCPAddr{IP: t.dest.IP, Port: int(t.dest.TCP)}, "err", err)
 			delete(s.static, t.dest.ID)
 		case nil:
 			s.dialing[id] = t.flags
@@ -171,10 +208,10 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 		}
 	}
 
-	// Use random nodes from the table for half of the necessary
-	// dynamic dials.
+	// Use random nodes from the table and the DNS node lists for half
+	// of the necessary dynamic dials.
 	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
+	if randomCandidates > 0 && s.ntab != nil {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDial(dynDialedConn, s.randomNodes[i]) {
@@ -182,6 +219,14 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 			}
 		}
 	}
+	if randomCandidates > 0 && s.dns != nil {
+		n := s.dns.ReadRandomNodes(s.randomNodes)
+		for i := 0; i < randomCandidates && i < n; i++ {
+			if addDial(dynDialedConn, s.randomNodes[i]) {
+				needDynDials--
+			}
+		}
+	}
 	// Create dynamic dials from random lookup results, removing tried
 	// items from the result buffer.
 	i := 0
@@ -192,11 +237,16 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 	s.lookupBuf = s.lookupBuf[:copy(s.lookupBuf, s.lookupBuf[i:])]
 	// Launch a discovery lookup if more candidates are needed.
-	if len(s.lookupBuf) < needDynDials && !s.lookupRunning {
+	if len(s.lookupBuf) < needDynDials && !s.lookupRunning && s.ntab != nil {
 		s.lookupRunning = true
 		newtasks = append(newtasks, &discoverTask{})
 	}
 
+	// Without a discovery table nothing triggers new dials when the DNS
+	// node lists are updated, so poll them while dynamic dials are needed.
+	if nRunning == 0 && len(newtasks) == 0 && needDynDials > 0 && s.ntab == nil && s.dns != nil {
+		newtasks = append(newtasks, &waitExpireTask{lookupInterval})
+	}
 	// Launch a timer to wait for the next node to expire if all
 	// candidates have been tried and no task is currently active.
 	// This should prevent cases where the dialer logic is not ticked
@@ -208,12 +258,29 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	return newtasks
 }
 
+// nodeRecord returns the known node record of a dial candidate, or nil if
+// neither the discovery table nor the DNS node lists have one.
+func (s *dialstate) nodeRecord(id discover.NodeID) *enr.Record {
+	if s.ntab != nil {
+		if r := s.ntab.NodeRecord(id); r != nil {
+			return r
+		}
+	}
+	if s.dns != nil {
+		return s.dns.NodeRecord(id)
+	}
+	return nil
+}
+
 var (
 	errSelf             = errors.New("is self")
 	errAlreadyDialing   = errors.New("already dialing")
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errRejectedRecord   = errors.New("node record rejected by protocols")
+	errBanned           = errors.New("node is banned")
+	errUnresolved       = errors.New("node endpoint unknown")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -229,6 +296,8 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 		return errNotWhitelisted
 	case s.hist.contains(n.ID):
 		return errRecentlyDialed
+	case s.banned != nil && s.banned(n):
+		return errBanned
 	}
 	return nil
 }
@@ -236,7 +305,13 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 func (s *dialstate) taskDone(t task, now time.Time) {
 	switch t := t.(type) {
 	case *dialTask:
-		s.hist.add(t.dest.ID, now.Add(dialHistoryExpiration))
+		delay := dialHistoryExpiration
+		if t.err != nil {
+			delay = s.backoff(t, now)
+		} else {
+			delete(s.failures, t.dest.ID)
+		}
+		s.hist.add(t.dest.ID, now.Add(delay))
 		delete(s.dialing, t.dest.ID)
 	case *discoverTask:
 		s.lookupRunning = false
@@ -244,17 +319,53 @@ func (s *dialstate) taskDone(t task, now time.Time) {
 	}
 }
 
+// backoff records a failed dial and returns the time until the node may be
+// dialed again, which doubles with every consecutive failure.
+func (s *dialstate) backoff(t *dialTask, now time.Time) time.Duration {
+	if now.After(s.nextPrune) {
+		for id, f := range s.failures {
+			if now.Sub(f.last) > dialFailureExpiration {
+				delete(s.failures, id)
+			}
+		}
+		s.nextPrune = now.Add(dialFailureExpiration)
+	}
+	f := s.failures[t.dest.ID]
+	if f == nil {
+		f = new(dialFailure)
+		s.failures[t.dest.ID] = f
+	}
+	f.count++
+	f.last = now
+
+	max := maxDialBackoff
+	if t.flags&staticDialedConn != 0 {
+		max = maxStaticDialBackoff
+	}
+	delay := dialHistoryExpiration
+	for i := uint(1); i < f.count && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	log.Trace("Backing off failed dial", "id", t.dest.ID, "failures", f.count, "delay", delay, "err", t.err)
+	return delay
+}
+
 func (t *dialTask) Do(srv *Server) {
 	if t.dest.Incomplete() {
 		if !t.resolve(srv) {
+			t.err = errUnresolved
+			markDialResult(t.err)
 			return
 		}
 	}
-	success := t.dial(srv, t.dest)
+	t.err = t.dial(srv, t.dest)
 	// Try resolving the ID of static nodes if dialing failed.
-	if !success && t.flags&staticDialedConn != 0 {
+	if t.err != nil && t.flags&staticDialedConn != 0 {
 		if t.resolve(srv) {
-			t.dial(srv, t.dest)
+			t.err = t.dial(srv, t.dest)
 		}
 	}
 }
@@ -267,7 +378,7 @@ func (t *dialTask) Do(srv *Server) {
 // The backoff delay resets when the node is found.
 func (t *dialTask) resolve(srv *Server) bool {
 	if srv.ntab == nil {
-		glog.V(logger.Debug).Infof("can't resolve node %x: discovery is disabled", t.dest.ID[:6])
+		log.Debug("Can't resolve node", "id", t.dest.ID, "err", "discovery is disabled")
 		return false
 	}
 	if t.resolveDelay == 0 {
@@ -283,28 +394,45 @@ func (t *dialTask) resolve(srv *Server) bool {
 		if t.resolveDelay > maxResolveDelay {
 			t.resolveDelay = maxResolveDelay
 		}
-		glog.V(logger.Debug).Infof("resolving node %x failed (new delay: %v)", t.dest.ID[:6], t.resolveDelay)
+		log.Debug("Resolving node failed", "id", t.dest.ID, "newdelay", t.resolveDelay)
 		return false
 	}
 	// The node was found.
 	t.resolveDelay = initialResolveDelay
 	t.dest = resolved
-	glog.V(logger.Debug).Infof("resolved node %x: %v:%d", t.dest.ID[:6], t.dest.IP, t.dest.TCP)
+	log.Debug("Resolved node", "id", t.dest.ID, "addr", &net.TCPAddr{IP: t.dest.IP, Port: int(t.dest.TCP)})
 	return true
 }
 
-// dial performs the actual connection attempt.
-func (t *dialTask) dial(srv *Server, dest *discover.Node) bool {
+// dial performs the actual connection attempt. It returns nil if the node was
+// added as a peer.
+func (t *dialTask) dial(srv *Server, dest *discover.Node) error {
 	addr := &net.TCPAddr{IP: dest.IP, Port: int(dest.TCP)}
-	glog.V(logger.Debug).Infof("dial tcp %v (%x)\n", addr, dest.ID[:6])
-	fd, err := srv.Dialer.Dial("tcp", addr.String())
+	log.Trace("Dialing node", "id", dest.ID, "addr", addr)
+	var (
+		fd  net.Conn
+		err error
+	)
+	if srv.NodeDialer != nil {
+		fd, err = srv.NodeDialer.Dial(dest)
+	} else {
+		fd, err = srv.Dialer.Dial("tcp", addr.String())
+	}
 	if err != nil {
-		glog.V(logger.Detail).Infof("%v", err)
-		return false
+		log.Trace("Dial error", "id", dest.ID, "addr", addr, "err", err)
+		err = &dialError{err}
+	} else {
+		mfd := newMeteredConn(fd, false)
+		err = srv.setupConn(mfd, t.flags, dest)
 	}
-	mfd := newMeteredConn(fd, false)
-	srv.setupConn(mfd, t.flags, dest)
-	return true
+	markDialResult(err)
+	return err
+}
+
+// dialError wraps the error of a failed connection attempt, telling it apart
+// from failed handshakes.
+type dialError struct {
+	error
 }
 
 func (t *dialTask) String() string {
@@ -323,6 +451,36 @@ func (t *discoverTask) Do(srv *Server) {
 	var target discover.NodeID
 	rand.Read(target[:])
 	t.results = srv.ntab.Lookup(target)
+	if srv.candidate != nil {
+		t.results = filterCandidates(srv.ntab, t.results, srv.candidate)
+	}
+}
+
+// filterCandidates fetches the node records of the given dial candidates and
+// removes the nodes rejected by accept. Records are requested concurrently,
+// nodes failing to deliver one are passed to accept as nil.
+func filterCandidates(ntab discoverTable, nodes []*discover.Node, accept func(*enr.Record) bool) []*discover.Node {
+	records := make([]*enr.Record, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n *discover.Node) {
+			defer wg.Done()
+			records[i], _ = ntab.RequestENR(n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	filtered := nodes[:0]
+	for i, n := range nodes {
+		if accept(records[i]) {
+			filtered = append(filtered, n)
+		} else {
+			log.Trace("Dropping dial candidate", "id", n.ID, "err", errRejectedRecord)
+		}
+	}
+	return filtered
 }
 
 func (t *discoverTask) String() string {
@@ -18,6 +18,7 @@ package p2p
 
 import (
 	"encoding/binary"
+	"errors"
 	"net"
 	"reflect"
 	"testing"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
 )
 
@@ -83,6 +85,12 @@ func (t fakeTable) Close()                                   {}
 func (t fakeTable) Lookup(discover.NodeID) []*discover.Node  { return nil }
 func (t fakeTable) Resolve(discover.NodeID) *discover.Node   { return nil }
 func (t fakeTable) ReadRandomNodes(buf []*discover.Node) int { return copy(buf, t) }
+func (t fakeTable) Record() *enr.Record                      { return nil }
+func (t fakeTable) SetRecordEntries(...enr.Entry) error      { return nil }
+func (t fakeTable) NodeRecord(discover.NodeID) *enr.Record   { return nil }
+func (t fakeTable) RequestENR(*discover.Node) (*enr.Record, error) {
+	return nil, errors.New("not supported")
+}
 
 // This test checks that dynamic dials are launched from discovery results.
 func TestDialStateDynDial(t *testing.T) {
@@ -315,6 +323,106 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 }
 
 // This test checks that candidates that do not match the netrestrict list are not dialed.
+// recordTable is a fakeTable which also knows the records of some nodes.
+type recordTable struct {
+	fakeTable
+	records map[discover.NodeID]*enr.Record
+}
+
+func (t recordTable) NodeRecord(id discover.NodeID) *enr.Record { return t.records[id] }
+func (t recordTable) RequestENR(n *discover.Node) (*enr.Record, error) {
+	if r := t.records[n.ID]; r != nil {
+		return r, nil
+	}
+	return nil, errors.New("no record")
+}
+
+// rejectBad is a dial candidate filter refusing nodes with a "bad" record entry.
+func rejectBad(r *enr.Record) bool {
+	return r == nil || enr.IsNotFound(r.Load(enr.WithEntry("bad", new(bool))))
+}
+
+func newRecordTable() recordTable {
+	var good, bad enr.Record
+	good.Set(enr.WithEntry("good", true))
+	bad.Set(enr.WithEntry("bad", true))
+
+	return recordTable{
+		fakeTable: fakeTable{
+			{ID: uintID(1)},
+			{ID: uintID(2)},
+			{ID: uintID(3)},
+			{ID: uintID(4)},
+		},
+		records: map[discover.NodeID]*enr.Record{
+			uintID(1): &bad,
+			uintID(2): &good,
+			uintID(4): &bad,
+		},
+	}
+}
+
+// This test checks that dynamic dial candidates rejected by their node
+// record are not dialed.
+func TestDialStateRecordFilter(t *testing.T) {
+	state := newDialState(nil, newRecordTable(), 8, nil)
+	state.candidate = rejectBad
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+					&discoverTask{},
+				},
+			},
+		},
+	})
+}
+
+// This test checks that dynamic dials are launched from DNS node lists, even
+// without a discovery table, and that the lists are polled while idle.
+func TestDialStateDNS(t *testing.T) {
+	state := newDialState(nil, nil, 8, nil)
+	state.dns = newRecordTable()
+	state.candidate = rejectBad
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+				},
+			},
+			{
+				done: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(2)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+				},
+				new: []task{
+					&waitExpireTask{Duration: lookupInterval},
+				},
+			},
+		},
+	})
+}
+
+func TestFilterCandidates(t *testing.T) {
+	table := newRecordTable()
+	nodes := make([]*discover.Node, len(table.fakeTable))
+	copy(nodes, table.fakeTable)
+
+	filtered := filterCandidates(table, nodes, rejectBad)
+	want := []*discover.Node{{ID: uintID(2)}, {ID: uintID(3)}}
+	if !reflect.DeepEqual(filtered, want) {
+		t.Errorf("filtered candidates mismatch:\ngot  %v\nwant %v", filtered, want)
+	}
+}
+
 func TestDialStateNetRestrict(t *testing.T) {
 	// This table always returns the same random nodes
 	// in the order given below.
@@ -495,6 +603,73 @@ func TestDialStateCache(t *testing.T) {
 	})
 }
 
+// This test checks that failed dials are retried with exponential backoff.
+func TestDialStateBackoff(t *testing.T) {
+	static := &discover.Node{ID: uintID(1)}
+	failed := &dialTask{flags: staticDialedConn, dest: static, err: errors.New("dial failed")}
+
+	runDialTest(t, dialtest{
+		init: newDialState([]*discover.Node{static}, fakeTable{}, 0, nil),
+		rounds: []round{
+			{
+				new: []task{&dialTask{flags: staticDialedConn, dest: static}},
+			},
+			// The first failure delays the next attempt by 30s.
+			{
+				done: []task{failed},
+				new:  []task{&waitExpireTask{Duration: 30 * time.Second}},
+			},
+			{},
+			{
+				done: []task{&waitExpireTask{Duration: 30 * time.Second}},
+				new:  []task{&dialTask{flags: staticDialedConn, dest: static}},
+			},
+			// The second failure doubles the delay.
+			{
+				done: []task{failed},
+				new:  []task{&waitExpireTask{Duration: 60 * time.Second}},
+			},
+			{},
+			{},
+			{},
+			{
+				done: []task{&waitExpireTask{Duration: 60 * time.Second}},
+				new:  []task{&dialTask{flags: staticDialedConn, dest: static}},
+			},
+			// A successful dial resets the backoff.
+			{
+				peers: []*Peer{{rw: &conn{flags: staticDialedConn, id: uintID(1)}}},
+				done:  []task{&dialTask{flags: staticDialedConn, dest: static}},
+				new:   []task{&waitExpireTask{Duration: 30 * time.Second}},
+			},
+		},
+	})
+}
+
+func TestDialBackoffDelay(t *testing.T) {
+	s := newDialState(nil, nil, 0, nil)
+	dyn := &dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(1)}, err: errors.New("dial failed")}
+	static := &dialTask{flags: staticDialedConn, dest: &discover.Node{ID: uintID(2)}, err: errors.New("dial failed")}
+
+	var now time.Time
+	for i := 0; i < 20; i++ {
+		now = now.Add(time.Second)
+		s.backoff(dyn, now)
+		s.backoff(static, now)
+	}
+	if d := s.backoff(dyn, now); d != maxDialBackoff {
+		t.Errorf("dynamic dial backoff not capped: got %v, want %v", d, maxDialBackoff)
+	}
+	if d := s.backoff(static, now); d != maxStaticDialBackoff {
+		t.Errorf("static dial backoff not capped: got %v, want %v", d, maxStaticDialBackoff)
+	}
+	// Failures are forgotten after a while.
+	now = now.Add(dialFailureExpiration + time.Second)
+	if d := s.backoff(dyn, now); d != dialHistoryExpiration {
+		t.Errorf("backoff not reset after expiration: got %v, want %v", d, dialHistoryExpiration)
+	}
+}
+
 func TestDialResolve(t *testing.T) {
 	resolved := discover.NewNode(uintID(1), net.IP{127, 0, 55, 234}, 3333, 4444)
 	table := &resolveMock{answer: resolved}
@@ -562,3 +737,9 @@ func (t *resolveMock) Close()                                   {}
 func (t *resolveMock) Bootstrap([]*discover.Node)               {}
 func (t *resolveMock) Lookup(discover.NodeID) []*discover.Node  { return nil }
 func (t *resolveMock) ReadRandomNodes(buf []*discover.Node) int { return 0 }
+func (t *resolveMock) Record() *enr.Record                      { return nil }
+func (t *resolveMock) SetRecordEntries(...enr.Entry) error      { return nil }
+func (t *resolveMock) NodeRecord(discover.NodeID) *enr.Record   { return nil }
+func (t *resolveMock) RequestENR(*discover.Node) (*enr.Record, error) {
+	return nil, errors.New("not supported")
+}
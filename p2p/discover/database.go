@@ -23,13 +23,14 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"net"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/EarthDollar/go-earthdollar/crypto"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
@@ -57,11 +58,15 @@ type nodeDB struct {
 var (
 	nodeDBVersionKey = []byte("version") // Version of the database to flush if changes
 	nodeDBItemPrefix = []byte("n:")      // Identifier to prefix node entries with
+	nodeDBBanPrefix  = []byte("ban:")    // Identifier to prefix node bans with (kept apart from expiring node entries)
 
 	nodeDBDiscoverRoot      = ":discover"
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+	nodeDBDiscoverENR       = nodeDBDiscoverRoot + ":enr"
+	nodeDBDiscoverENRSeq    = nodeDBDiscoverRoot + ":enrseq"
+	nodeDBDiscoverLocalSeq  = nodeDBDiscoverRoot + ":localseq"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -153,6 +158,12 @@ func splitKey(key []byte) (id NodeID, field string) {
 	return id, field
 }
 
+// banKey generates the leveldb key-blob of a node ban.
+func banKey(id NodeID) []byte {
+	key := make([]byte, 0, len(nodeDBBanPrefix)+len(id))
+	return append(append(key, nodeDBBanPrefix...), id[:]...)
+}
+
 // fetchInt64 retrieves an integer instance associated with a particular
 // database key.
 func (db *nodeDB) fetchInt64(key []byte) int64 {
@@ -180,12 +191,12 @@ func (db *nodeDB) storeInt64(key []byte, n int64) error {
 func (db *nodeDB) node(id NodeID) *Node {
 	blob, err := db.lvl.Get(makeKey(id, nodeDBDiscoverRoot), nil)
 	if err != nil {
-		glog.V(logger.Detail).Infof("failed to retrieve node %v: %v", id, err)
+		log.Trace("Failed to retrieve node", "id", id, "err", err)
 		return nil
 	}
 	node := new(Node)
 	if err := rlp.DecodeBytes(blob, node); err != nil {
-		glog.V(logger.Warn).Infof("failed to decode node RLP: %v", err)
+		log.Error("Failed to decode node RLP", "err", err)
 		return nil
 	}
 	node.sha = crypto.Keccak256Hash(node.ID[:])
@@ -233,7 +244,7 @@ func (db *nodeDB) expirer() {
 		select {
 		case <-tick:
 			if err := db.expireNodes(); err != nil {
-				glog.V(logger.Error).Infof("Failed to expire nodedb items: %v", err)
+				log.Error("Failed to expire nodedb items", "err", err)
 			}
 
 		case <-db.quit:
@@ -300,6 +311,98 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// record retrieves the last node record fetched from a remote node.
+func (db *nodeDB) record(id NodeID) *enr.Record {
+	blob, err := db.lvl.Get(makeKey(id, nodeDBDiscoverENR), nil)
+	if err != nil {
+		return nil
+	}
+	r := new(enr.Record)
+	if err := rlp.DecodeBytes(blob, r); err != nil {
+		log.Error("Failed to decode node record RLP", "id", id, "err", err)
+		return nil
+	}
+	return r
+}
+
+// updateRecord stores the node record fetched from a remote node.
+func (db *nodeDB) updateRecord(id NodeID, r *enr.Record) error {
+	blob, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		return err
+	}
+	return db.lvl.Put(makeKey(id, nodeDBDiscoverENR), blob, nil)
+}
+
+// enrSeq retrieves the latest record sequence number announced by a remote node.
+func (db *nodeDB) enrSeq(id NodeID) uint64 {
+	return uint64(db.fetchInt64(makeKey(id, nodeDBDiscoverENRSeq)))
+}
+
+// updateENRSeq updates the latest record sequence number announced by a remote node.
+func (db *nodeDB) updateENRSeq(id NodeID, seq uint64) error {
+	return db.storeInt64(makeKey(id, nodeDBDiscoverENRSeq), int64(seq))
+}
+
+// localSeq retrieves the sequence number of the last local node record.
+func (db *nodeDB) localSeq() uint64 {
+	return uint64(db.fetchInt64(makeKey(db.self, nodeDBDiscoverLocalSeq)))
+}
+
+// storeLocalSeq stores the sequence number of the local node record, so that
+// records signed after a restart supersede the ones handed out before.
+func (db *nodeDB) storeLocalSeq(seq uint64) error {
+	return db.storeInt64(makeKey(db.self, nodeDBDiscoverLocalSeq), int64(seq))
+}
+
+// Ban is a ban of a remote node, persisted in the node database until it
+// expires.
+type Ban struct {
+	ID      NodeID
+	IP      net.IP // IP address banned alongside the node, if known
+	Reason  string
+	Expires uint64 // Unix time at which the ban is lifted
+}
+
+// bans retrieves all node bans which haven't expired yet, deleting the expired
+// ones from the database.
+func (db *nodeDB) bans() []Ban {
+	var (
+		now  = uint64(time.Now().Unix())
+		bans []Ban
+		it   = db.lvl.NewIterator(util.BytesPrefix(nodeDBBanPrefix), nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		var ban Ban
+		if err := rlp.DecodeBytes(it.Value(), &ban); err != nil {
+			log.Error("Failed to decode node ban RLP", "err", err)
+			continue
+		}
+		if ban.Expires <= now {
+			db.lvl.Delete(it.Key(), nil)
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	return bans
+}
+
+// updateBan inserts - potentially overwriting - a node ban into the database.
+func (db *nodeDB) updateBan(ban Ban) error {
+	blob, err := rlp.EncodeToBytes(&ban)
+	if err != nil {
+		return err
+	}
+	return db.lvl.Put(banKey(ban.ID), blob, nil)
+}
+
+// deleteBan removes the ban of a node from the database.
+func (db *nodeDB) deleteBan(id NodeID) error {
+	return db.lvl.Delete(banKey(id), nil)
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
@@ -352,9 +455,7 @@ func nextNode(it iterator.Iterator) *Node {
 		}
 		var n Node
 		if err := rlp.DecodeBytes(it.Value(), &n); err != nil {
-			if glog.V(logger.Warn) {
-				glog.Errorf("invalid node %x: %v", id, err)
-			}
+			log.Warn("Invalid node in database", "id", id, "err", err)
 			continue
 		}
 		return &n
@@ -25,6 +25,9 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 )
 
 var nodeDBKeyTests = []struct {
@@ -215,6 +218,86 @@ var nodeDBSeedQueryNodes = []struct {
 	},
 }
 
+func TestNodeDBRecord(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	id := PubkeyID(&key.PublicKey)
+
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	if stored := db.record(id); stored != nil {
+		t.Errorf("record: non-existing object: %v", stored)
+	}
+	var r enr.Record
+	r.Set(enr.UDP(30303))
+	if err := enr.SignV4(&r, key); err != nil {
+		t.Fatalf("failed to sign record: %v", err)
+	}
+	if err := db.updateRecord(id, &r); err != nil {
+		t.Errorf("record: failed to update: %v", err)
+	}
+	if stored := db.record(id); stored == nil {
+		t.Errorf("record: not found")
+	} else if !reflect.DeepEqual(stored, &r) {
+		t.Errorf("record: data mismatch: have %v, want %v", stored, &r)
+	}
+	// Check fetch/store operations on the announced sequence numbers
+	if stored := db.enrSeq(id); stored != 0 {
+		t.Errorf("enrseq: non-existing object: %v", stored)
+	}
+	if err := db.updateENRSeq(id, 7); err != nil {
+		t.Errorf("enrseq: failed to update: %v", err)
+	}
+	if stored := db.enrSeq(id); stored != 7 {
+		t.Errorf("enrseq: value mismatch: have %v, want %v", stored, 7)
+	}
+	if err := db.storeLocalSeq(3); err != nil {
+		t.Errorf("localseq: failed to update: %v", err)
+	}
+	if stored := db.localSeq(); stored != 3 {
+		t.Errorf("localseq: value mismatch: have %v, want %v", stored, 3)
+	}
+}
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	var (
+		now     = uint64(time.Now().Unix())
+		active  = Ban{ID: NodeID{0x01}, IP: net.IP{10, 0, 0, 1}, Reason: "test", Expires: now + 3600}
+		expired = Ban{ID: NodeID{0x02}, Reason: "old", Expires: now - 1}
+	)
+	for _, ban := range []Ban{active, expired} {
+		if err := db.updateBan(ban); err != nil {
+			t.Fatalf("failed to store ban: %v", err)
+		}
+	}
+	// Make sure bans survive the expiration of the banned node's discovery data
+	if err := db.updateNode(NewNode(active.ID, active.IP, 30303, 30303)); err != nil {
+		t.Fatalf("failed to insert node: %v", err)
+	}
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if node := db.node(active.ID); node != nil {
+		t.Errorf("unseen node not expired: %v", node)
+	}
+	// Only the active ban should be returned, the expired one is dropped
+	if bans := db.bans(); !reflect.DeepEqual(bans, []Ban{active}) {
+		t.Errorf("bans mismatch: have %v, want %v", bans, []Ban{active})
+	}
+	if _, err := db.lvl.Get(banKey(expired.ID), nil); err == nil {
+		t.Errorf("expired ban not deleted")
+	}
+	if err := db.deleteBan(active.ID); err != nil {
+		t.Errorf("failed to delete ban: %v", err)
+	}
+	if bans := db.bans(); len(bans) != 0 {
+		t.Errorf("bans left after deletion: %v", bans)
+	}
+}
+
 func TestNodeDBSeedQuery(t *testing.T) {
 	db, _ := newNodeDB("", Version, nodeDBSeedQueryNodes[1].node.ID)
 	defer db.close()
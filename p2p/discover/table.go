@@ -23,6 +23,7 @@
 package discover
 
 import (
+	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/binary"
 	"errors"
@@ -34,8 +35,8 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 )
 
 const (
@@ -70,6 +71,10 @@ type Table struct {
 
 	net  transport
 	self *Node // metadata of the local node
+
+	recordMu sync.Mutex
+	record   *enr.Record       // signed record of the local node
+	priv     *ecdsa.PrivateKey // key signing the local record
 }
 
 type bondproc struct {
@@ -85,6 +90,7 @@ type transport interface {
 	ping(NodeID, *net.UDPAddr) error
 	waitping(NodeID) error
 	findnode(toid NodeID, addr *net.UDPAddr, target NodeID) ([]*Node, error)
+	requestENR(toid NodeID, addr *net.UDPAddr) (*enr.Record, error)
 	close()
 }
 
@@ -124,6 +130,93 @@ func (tab *Table) Self() *Node {
 	return tab.self
 }
 
+// initRecord creates the signed record of the local node. The sequence number
+// continues from the last record signed with the same node database, so remote
+// nodes caching an earlier record will pick up the new one.
+func (tab *Table) initRecord(priv *ecdsa.PrivateKey) error {
+	var r enr.Record
+	r.SetSeq(tab.db.localSeq() + 1)
+	if !tab.self.IP.IsUnspecified() {
+		r.Set(enr.IP(tab.self.IP))
+	}
+	r.Set(enr.UDP(tab.self.UDP))
+	r.Set(enr.TCP(tab.self.TCP))
+	if err := enr.SignV4(&r, priv); err != nil {
+		return err
+	}
+	tab.recordMu.Lock()
+	tab.priv, tab.record = priv, &r
+	tab.recordMu.Unlock()
+
+	return tab.db.storeLocalSeq(r.Seq())
+}
+
+// Record returns the signed record of the local node, or nil if the table
+// has no signing key. The returned record should not be modified.
+func (tab *Table) Record() *enr.Record {
+	tab.recordMu.Lock()
+	defer tab.recordMu.Unlock()
+
+	return tab.record
+}
+
+// SetRecordEntries adds or updates entries of the local node record and signs
+// it again, incrementing its sequence number. Remote nodes learn about the new
+// record through the sequence number announced in ping and pong packets.
+func (tab *Table) SetRecordEntries(entries ...enr.Entry) error {
+	tab.recordMu.Lock()
+	defer tab.recordMu.Unlock()
+
+	if tab.record == nil {
+		return errors.New("no key for signing the node record")
+	}
+	r := *tab.record
+	for _, e := range entries {
+		r.Set(e)
+	}
+	if err := enr.SignV4(&r, tab.priv); err != nil {
+		return err
+	}
+	tab.record = &r
+	return tab.db.storeLocalSeq(r.Seq())
+}
+
+// NodeRecord returns the last record fetched from the given node, or nil if
+// none is known.
+func (tab *Table) NodeRecord(id NodeID) *enr.Record {
+	return tab.db.record(id)
+}
+
+// Bans returns the node bans stored in the node database which haven't expired.
+func (tab *Table) Bans() []Ban {
+	return tab.db.bans()
+}
+
+// StoreBan persists a node ban in the node database.
+func (tab *Table) StoreBan(ban Ban) error {
+	return tab.db.updateBan(ban)
+}
+
+// DeleteBan removes a node ban from the node database.
+func (tab *Table) DeleteBan(id NodeID) error {
+	return tab.db.deleteBan(id)
+}
+
+// RequestENR returns the record of the given node. A record fetched earlier is
+// returned if the node hasn't announced a newer one since, otherwise the record
+// is requested from the node and stored in the node database.
+func (tab *Table) RequestENR(n *Node) (*enr.Record, error) {
+	if r := tab.db.record(n.ID); r != nil && r.Seq() >= tab.db.enrSeq(n.ID) {
+		return r, nil
+	}
+	r, err := tab.net.requestENR(n.ID, &net.UDPAddr{IP: n.IP, Port: int(n.UDP)})
+	if err != nil {
+		return nil, err
+	}
+	tab.db.updateRecord(n.ID, r)
+	return r, nil
+}
+
 // ReadRandomNodes fills the given slice with random nodes from the
 // table. It will not write the same node more than once. The nodes in
 // the slice are copies and can be modified by the caller.
@@ -278,10 +371,10 @@ func (tab *Table) lookup(targetID NodeID, refreshIfEmpty bool) []*Node {
 						// Bump the failure counter to detect and evacuate non-bonded entries
 						fails := tab.db.findFails(n.ID) + 1
 						tab.db.updateFindFails(n.ID, fails)
-						glog.V(logger.Detail).Infof("Bumping failures for %x: %d", n.ID[:8], fails)
+						log.Trace("Bumping findnode failure counter", "id", n.ID, "failcount", fails)
 
 						if fails >= maxFindnodeFailures {
-							glog.V(logger.Detail).Infof("Evacuating node %x: %d findnode failures", n.ID[:8], fails)
+							log.Trace("Too many findnode failures, dropping", "id", n.ID, "failcount", fails)
 							tab.delete(n)
 						}
 					}
@@ -384,14 +477,12 @@ func (tab *Table) doRefresh(done chan struct{}) {
 	// (hopefully) still alive.
 	seeds := tab.db.querySeeds(seedCount, seedMaxAge)
 	seeds = tab.bondall(append(seeds, tab.nursery...))
-	if glog.V(logger.Debug) {
-		if len(seeds) == 0 {
-			glog.Infof("no seed nodes found")
-		}
-		for _, n := range seeds {
-			age := time.Since(tab.db.lastPong(n.ID))
-			glog.Infof("seed node (age %v): %v", age, n)
-		}
+	if len(seeds) == 0 {
+		log.Debug("No discv4 seed nodes found")
+	}
+	for _, n := range seeds {
+		age := time.Since(tab.db.lastPong(n.ID))
+		log.Trace("Found seed node in database", "id", n.ID, "addr", n.addr(), "age", age)
 	}
 	tab.mutex.Lock()
 	tab.stuff(seeds)
@@ -470,7 +561,7 @@ func (tab *Table) bond(pinged bool, id NodeID, addr *net.UDPAddr, tcpPort uint16
 	var result error
 	age := time.Since(tab.db.lastPong(id))
 	if node == nil || fails > 0 || age > nodeDBNodeExpiration {
-		glog.V(logger.Detail).Infof("Bonding %x: known=%t, fails=%d age=%v", id[:8], node != nil, fails, age)
+		log.Trace("Starting bonding ping/pong", "id", id, "known", node != nil, "failcount", fails, "age", age)
 
 		tab.bondmu.Lock()
 		w := tab.bonding[id]
@@ -29,6 +29,7 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 )
 
 func TestTable_pingReplace(t *testing.T) {
@@ -160,6 +161,9 @@ func newPingRecorder() *pingRecorder {
 func (t *pingRecorder) findnode(toid NodeID, toaddr *net.UDPAddr, target NodeID) ([]*Node, error) {
 	panic("findnode called on pingRecorder")
 }
+func (t *pingRecorder) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	panic("requestENR called on pingRecorder")
+}
 func (t *pingRecorder) close() {}
 func (t *pingRecorder) waitping(from NodeID) error {
 	return nil // remote always pings
@@ -278,6 +282,45 @@ func (*closeTest) Generate(rand *rand.Rand, size int) reflect.Value {
 	return reflect.ValueOf(t)
 }
 
+func TestTable_Record(t *testing.T) {
+	key := newkey()
+	tab, _ := newTable(newPingRecorder(), PubkeyID(&key.PublicKey), &net.UDPAddr{IP: net.IP{127, 0, 0, 1}, Port: 30303}, "")
+	defer tab.Close()
+
+	if tab.Record() != nil {
+		t.Fatal("table has a record before initialization")
+	}
+	if err := tab.initRecord(key); err != nil {
+		t.Fatalf("can't create record: %v", err)
+	}
+	r := tab.Record()
+	var udp enr.UDP
+	if err := r.Load(&udp); err != nil || udp != 30303 {
+		t.Errorf("wrong UDP port in record: %d (%v)", udp, err)
+	}
+	// Updating the record must sign it again with a higher sequence number.
+	if err := tab.SetRecordEntries(enr.WithEntry("test", uint(1))); err != nil {
+		t.Fatalf("can't update record: %v", err)
+	}
+	updated := tab.Record()
+	if !updated.Signed() {
+		t.Error("updated record is not signed")
+	}
+	if updated.Seq() != r.Seq()+1 {
+		t.Errorf("wrong sequence number after update: got %d, want %d", updated.Seq(), r.Seq()+1)
+	}
+	if err := r.Load(enr.WithEntry("test", new(uint))); !enr.IsNotFound(err) {
+		t.Errorf("update modified the previous record")
+	}
+	// Recreating the record must continue from the stored sequence number.
+	if err := tab.initRecord(key); err != nil {
+		t.Fatalf("can't create record: %v", err)
+	}
+	if seq := tab.Record().Seq(); seq != updated.Seq()+1 {
+		t.Errorf("wrong sequence number after restart: got %d, want %d", seq, updated.Seq()+1)
+	}
+}
+
 func TestTable_Lookup(t *testing.T) {
 	self := nodeAtDistance(common.Hash{}, 0)
 	tab, _ := newTable(lookupTestnet, self.ID, &net.UDPAddr{}, "")
@@ -526,6 +569,9 @@ func (tn *preminedTestnet) findnode(toid NodeID, toaddr *net.UDPAddr, target Nod
 func (*preminedTestnet) close()                                      {}
 func (*preminedTestnet) waitping(from NodeID) error                  { return nil }
 func (*preminedTestnet) ping(toid NodeID, toaddr *net.UDPAddr) error { return nil }
+func (*preminedTestnet) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	return nil, errTimeout
+}
 
 // mine generates a testnet struct literal with nodes at
 // various distances to the given target.
@@ -36,6 +36,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
@@ -76,7 +77,7 @@ func newUDPTest(t *testing.T) *udpTest {
 
 // handles a packet as if it had been sent to the transport.
 func (test *udpTest) packetIn(wantError error, ptype byte, data packet) error {
-	enc, err := encodePacket(test.remotekey, ptype, data)
+	enc, _, err := encodePacket(test.remotekey, ptype, data)
 	if err != nil {
 		return test.errorf("packet (%d) encode error: %v", ptype, err)
 	}
@@ -126,6 +127,7 @@ func TestUDP_packetErrors(t *testing.T) {
 	test.packetIn(errUnsolicitedReply, pongPacket, &pong{ReplyTok: []byte{}, Expiration: futureExp})
 	test.packetIn(errUnknownNode, findnodePacket, &findnode{Expiration: futureExp})
 	test.packetIn(errUnsolicitedReply, neighborsPacket, &neighbors{Expiration: futureExp})
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
 }
 
 func TestUDP_pingTimeout(t *testing.T) {
@@ -388,6 +390,78 @@ func TestUDP_successfulPing(t *testing.T) {
 	}
 }
 
+func TestUDP_enrRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// ensure there's a bond with the test node,
+	// the request won't be accepted otherwise.
+	test.table.db.updateNode(NewNode(
+		PubkeyID(&test.remotekey.PublicKey),
+		test.remoteaddr.IP,
+		uint16(test.remoteaddr.Port),
+		99,
+	))
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.waitPacketOut(func(p *enrResponse) {
+		reqhash := test.sent[0][:macSize]
+		if !bytes.Equal(p.ReplyTok, reqhash) {
+			t.Errorf("got enrResponse.ReplyTok %x, want %x", p.ReplyTok, reqhash)
+		}
+		if !reflect.DeepEqual(&p.Record, test.table.Record()) {
+			t.Errorf("wrong record in response:\n  got:  %v\n  want: %v", &p.Record, test.table.Record())
+		}
+	})
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	var remoteRecord, otherRecord enr.Record
+	remoteRecord.Set(enr.UDP(test.remoteaddr.Port))
+	enr.SignV4(&remoteRecord, test.remotekey)
+	enr.SignV4(&otherRecord, newkey())
+
+	for _, tt := range []struct {
+		record  *enr.Record
+		wantErr error
+	}{
+		{record: &remoteRecord, wantErr: nil},
+		{record: &otherRecord, wantErr: errRecordMismatch},
+	} {
+		type result struct {
+			r   *enr.Record
+			err error
+		}
+		done := make(chan result, 1)
+		go func() {
+			r, err := test.udp.requestENR(PubkeyID(&test.remotekey.PublicKey), test.remoteaddr)
+			done <- result{r, err}
+		}()
+
+		// Reply to the request with the record.
+		dgram := test.pipe.waitPacketOut()
+		if _, _, _, err := decodePacket(dgram); err != nil {
+			t.Fatalf("sent packet decode error: %v", err)
+		}
+		if dgram[headSize] != enrRequestPacket {
+			t.Fatalf("sent packet type mismatch, got %d, want %d", dgram[headSize], enrRequestPacket)
+		}
+		// A reply to a different request must be ignored.
+		test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: make([]byte, macSize), Record: *tt.record})
+		test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: dgram[:macSize], Record: *tt.record})
+
+		res := <-done
+		if res.err != tt.wantErr {
+			t.Fatalf("error mismatch: got %v, want %v", res.err, tt.wantErr)
+		}
+		if tt.wantErr == nil && !reflect.DeepEqual(res.r, tt.record) {
+			t.Errorf("wrong record returned:\n  got:  %v\n  want: %v", res.r, tt.record)
+		}
+	}
+}
+
 var testPackets = []struct {
 	input      string
 	wantPacket interface{}
@@ -23,13 +23,15 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/EarthDollar/go-earthdollar/common/mclock"
 	"github.com/EarthDollar/go-earthdollar/crypto"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
@@ -64,6 +66,8 @@ var (
 	nodeDBDiscoverFindFails     = nodeDBDiscoverRoot + ":findfail"
 	nodeDBDiscoverLocalEndpoint = nodeDBDiscoverRoot + ":localendpoint"
 	nodeDBTopicRegTickets       = ":tickets"
+	nodeDBTopicRegistration     = ":topicreg:" // followed by the topic
+	nodeDBTicket                = ":ticket"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -192,7 +196,7 @@ func (db *nodeDB) fetchRLP(key []byte, val interface{}) error {
 	}
 	err = rlp.DecodeBytes(blob, val)
 	if err != nil {
-		glog.V(logger.Warn).Infof("key %x (%T) %v", key, val, err)
+		log.Warn("Failed to decode node database entry", "key", fmt.Sprintf("%x", key), "type", fmt.Sprintf("%T", val), "err", err)
 	}
 	return err
 }
@@ -244,7 +248,7 @@ func (db *nodeDB) expirer() {
 		select {
 		case <-tick:
 			if err := db.expireNodes(); err != nil {
-				glog.V(logger.Error).Infof("Failed to expire nodedb items: %v", err)
+				log.Error("Failed to expire nodedb items", "err", err)
 			}
 
 		case <-db.quit:
@@ -386,6 +390,96 @@ func (db *nodeDB) updateTopicRegTickets(id NodeID, issued, used uint32) error {
 	return db.lvl.Put(key, blob, nil)
 }
 
+// topicRegRecord is a registration of a node's topic at the local node.
+type topicRegRecord struct {
+	Node   rpcNode
+	Topic  Topic
+	Expire uint64 // unix time in milliseconds
+}
+
+// topicRegistrations retrieves all stored topic registrations.
+func (db *nodeDB) topicRegistrations() []topicRegRecord {
+	var recs []topicRegRecord
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBItemPrefix), nil)
+	defer it.Release()
+	for it.Next() {
+		id, field := splitKey(it.Key())
+		if !strings.HasPrefix(field, nodeDBTopicRegistration) {
+			continue
+		}
+		var rec topicRegRecord
+		if err := rlp.DecodeBytes(it.Value(), &rec); err != nil || rec.Node.ID != id {
+			log.Warn("Invalid topic registration in database", "id", id, "err", err)
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// updateTopicRegistration stores a topic registration, replacing an earlier one
+// of the same node and topic.
+func (db *nodeDB) updateTopicRegistration(rec topicRegRecord) error {
+	return db.storeRLP(makeKey(rec.Node.ID, nodeDBTopicRegistration+string(rec.Topic)), &rec)
+}
+
+// deleteTopicRegistration removes a topic registration.
+func (db *nodeDB) deleteTopicRegistration(id NodeID, topic Topic) error {
+	return db.lvl.Delete(makeKey(id, nodeDBTopicRegistration+string(topic)), nil)
+}
+
+// ticketRecord is a ticket issued by a node, which is kept for registering
+// local topics.
+type ticketRecord struct {
+	Node      rpcNode
+	Topics    []Topic
+	RegTime   []uint64 // unix times in milliseconds, per topic
+	IssueTime uint64
+	Serial    uint32
+	Pong      []byte // the pong packet containing the ticket
+}
+
+// tickets retrieves all stored tickets.
+func (db *nodeDB) tickets() []ticketRecord {
+	var recs []ticketRecord
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBItemPrefix), nil)
+	defer it.Release()
+	for it.Next() {
+		id, field := splitKey(it.Key())
+		if field != nodeDBTicket {
+			continue
+		}
+		var rec ticketRecord
+		if err := rlp.DecodeBytes(it.Value(), &rec); err != nil || rec.Node.ID != id || len(rec.RegTime) != len(rec.Topics) {
+			log.Warn("Invalid ticket in database", "id", id, "err", err)
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// updateTicket stores the ticket of a node, replacing an earlier one.
+func (db *nodeDB) updateTicket(rec ticketRecord) error {
+	return db.storeRLP(makeKey(rec.Node.ID, nodeDBTicket), &rec)
+}
+
+// deleteTicket removes the ticket of a node.
+func (db *nodeDB) deleteTicket(id NodeID) error {
+	return db.lvl.Delete(makeKey(id, nodeDBTicket), nil)
+}
+
+// absTimeToUnix converts a local monotonic time to unix time in milliseconds,
+// which remains meaningful after a restart.
+func absTimeToUnix(t mclock.AbsTime) uint64 {
+	return uint64(time.Now().Add(time.Duration(t-mclock.Now())).UnixNano() / int64(time.Millisecond))
+}
+
+// unixToAbsTime converts a unix time in milliseconds to local monotonic time.
+func unixToAbsTime(ms uint64) mclock.AbsTime {
+	return mclock.Now() + mclock.AbsTime(time.Unix(0, int64(ms)*int64(time.Millisecond)).Sub(time.Now()))
+}
+
 // reads the next node record from the iterator, skipping over other
 // database entries.
 func nextNode(it iterator.Iterator) *Node {
@@ -396,9 +490,7 @@ func nextNode(it iterator.Iterator) *Node {
 		}
 		var n Node
 		if err := rlp.DecodeBytes(it.Value(), &n); err != nil {
-			if glog.V(logger.Warn) {
-				glog.Errorf("invalid node %x: %v", id, err)
-			}
+			log.Warn("Invalid node in database", "id", id, "err", err)
 			continue
 		}
 		return &n
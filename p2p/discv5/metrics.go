@@ -0,0 +1,44 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains the meters used by the discovery v5 protocol.
+
+package discv5
+
+import "github.com/EarthDollar/go-earthdollar/metrics"
+
+var (
+	ingressTrafficMeter = metrics.NewMeter("discv5/InboundTraffic")
+	egressTrafficMeter  = metrics.NewMeter("discv5/OutboundTraffic")
+
+	// Registrar side of topic advertisement: tickets handed out in pongs,
+	// registrations accepted or refused and topic queries answered or dropped
+	// by the rate limit.
+	ticketIssueMeter        = metrics.NewMeter("discv5/TicketsIssued")
+	registrationAcceptMeter = metrics.NewMeter("discv5/RegistrationsAccepted")
+	registrationRejectMeter = metrics.NewMeter("discv5/RegistrationsRejected")
+	topicQueryServeMeter    = metrics.NewMeter("discv5/TopicQueriesServed")
+	topicQueryDropMeter     = metrics.NewMeter("discv5/TopicQueriesDropped")
+
+	// Advertiser side: tickets kept for registering local topics and the
+	// registrations sent with them.
+	ticketCollectMeter = metrics.NewMeter("discv5/TicketsCollected")
+	registrationMeter  = metrics.NewMeter("discv5/Registrations")
+
+	// Searcher side: topic queries sent and the nodes found by them.
+	topicQuerySendMeter = metrics.NewMeter("discv5/TopicQueriesSent")
+	topicNodesMeter     = metrics.NewMeter("discv5/TopicNodesFound")
+)
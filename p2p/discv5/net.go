@@ -28,8 +28,7 @@ import (
 	"github.com/EarthDollar/go-earthdollar/common/mclock"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 	"github.com/EarthDollar/go-earthdollar/crypto/sha3"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
 	"github.com/EarthDollar/go-earthdollar/rlp"
@@ -39,6 +38,7 @@ var (
 	errInvalidEvent = errors.New("invalid in current state")
 	errNoQuery      = errors.New("no pending query")
 	errWrongAddress = errors.New("unknown sender address")
+	errRateLimited  = errors.New("rate limit exceeded")
 )
 
 const (
@@ -88,6 +88,8 @@ type Network struct {
 	nodes         map[NodeID]*Node // tracks active nodes with state != known
 	timeoutTimers map[timeoutEvent]*time.Timer
 
+	topicQueryLimit rateLimit // limits the topic queries answered in total
+
 	// Revalidation queues.
 	// Nodes put on these queues will be pinged eventually.
 	slowRevalidateQueue []*Node
@@ -160,7 +162,7 @@ func newNetwork(conn transport, ourPubkey ecdsa.PublicKey, natm nat.Interface, d
 		netrestrict:      netrestrict,
 		tab:              tab,
 		topictab:         newTopicTable(db, tab.self),
-		ticketStore:      newTicketStore(),
+		ticketStore:      newTicketStore(db),
 		refreshReq:       make(chan []*Node),
 		refreshResp:      make(chan (<-chan struct{})),
 		closed:           make(chan struct{}),
@@ -175,10 +177,27 @@ func newNetwork(conn transport, ourPubkey ecdsa.PublicKey, natm nat.Interface, d
 		topicSearchReq:   make(chan topicSearchReq),
 		nodes:            make(map[NodeID]*Node),
 	}
+	if db != nil {
+		net.loadTopicState()
+	}
 	go net.loop()
 	return net, nil
 }
 
+// loadTopicState restores the registrations of other nodes' topics and the
+// tickets collected for registering local topics, which were stored in the
+// database before a restart.
+func (net *Network) loadTopicState() {
+	for _, rec := range net.db.topicRegistrations() {
+		n := net.internNodeFromDB(NewNode(rec.Node.ID, rec.Node.IP, rec.Node.UDP, rec.Node.TCP))
+		net.topictab.restoreEntry(n, rec.Topic, unixToAbsTime(rec.Expire))
+	}
+	for _, rec := range net.db.tickets() {
+		n := net.internNodeFromDB(NewNode(rec.Node.ID, rec.Node.IP, rec.Node.UDP, rec.Node.TCP))
+		net.ticketStore.restoreTicket(n, rec)
+	}
+}
+
 // Close terminates the network listener and flushes the node database.
 func (net *Network) Close() {
 	net.conn.Close()
@@ -437,10 +456,7 @@ loop:
 			if err := net.handle(n, pkt.ev, &pkt); err != nil {
 				status = err.Error()
 			}
-			if glog.V(logger.Detail) {
-				glog.Infof("<<< (%d) %v from %x@%v: %v -> %v (%v)",
-					net.tab.count, pkt.ev, pkt.remoteID[:8], pkt.remoteAddr, prestate, n.state, status)
-			}
+			log.Trace("<<< "+pkt.ev.String(), "id", n.ID, "addr", pkt.remoteAddr, "count", net.tab.count, "from", prestate, "to", n.state, "status", status)
 			// TODO: persist state if n.state goes >= known, delete if it goes <= known
 
 		// State transition timeouts.
@@ -456,10 +472,7 @@ loop:
 			if err := net.handle(timeout.node, timeout.ev, nil); err != nil {
 				status = err.Error()
 			}
-			if glog.V(logger.Detail) {
-				glog.Infof("--- (%d) %v for %x@%v: %v -> %v (%v)",
-					net.tab.count, timeout.ev, timeout.node.ID[:8], timeout.node.addr(), prestate, timeout.node.state, status)
-			}
+			log.Trace("--- "+timeout.ev.String(), "id", timeout.node.ID, "addr", timeout.node.addr(), "count", net.tab.count, "from", prestate, "to", timeout.node.state, "status", status)
 
 		// Querying.
 		case q := <-net.queryReq:
@@ -522,6 +535,7 @@ loop:
 
 		case <-nextRegisterTime:
 			debugLog("<-nextRegisterTime")
+			registrationMeter.Mark(1)
 			net.ticketStore.ticketRegistered(*nextTicket)
 			//fmt.Println("sendTopicRegister", nextTicket.t.node.addr().String(), nextTicket.t.topics, nextTicket.idx, nextTicket.t.pong)
 			net.conn.sendTopicRegister(nextTicket.t.node, nextTicket.t.topics, nextTicket.idx, nextTicket.t.pong)
@@ -579,7 +593,11 @@ loop:
 				return n.pingEcho
 			}, func(n *Node, topic Topic) []byte {
 				if n.state == known {
-					return net.conn.send(n, topicQueryPacket, topicQuery{Topic: topic}) // TODO: set expiration
+					topicQuerySendMeter.Mark(1)
+					return net.conn.send(n, topicQueryPacket, &topicQuery{
+						Topic:      topic,
+						Expiration: uint64(time.Now().Add(expiration).Unix()),
+					})
 				} else {
 					if n.state == unknown {
 						net.ping(n, n.addr())
@@ -655,7 +673,7 @@ loop:
 	}
 	debugLog("loop stopped")
 
-	glog.V(logger.Debug).Infof("shutting down")
+	log.Debug("Shutting down discv5 network")
 	if net.conn != nil {
 		net.conn.Close()
 	}
@@ -685,19 +703,15 @@ func (net *Network) refresh(done chan<- struct{}) {
 		seeds = net.nursery
 	}
 	if len(seeds) == 0 {
-		glog.V(logger.Detail).Info("no seed nodes found")
+		log.Debug("No discv5 seed nodes found")
 		close(done)
 		return
 	}
 	for _, n := range seeds {
-		if glog.V(logger.Debug) {
-			var age string
-			if net.db != nil {
-				age = time.Since(net.db.lastPong(n.ID)).String()
-			} else {
-				age = "unknown"
-			}
-			glog.Infof("seed node (age %s): %v", age, n)
+		if net.db != nil {
+			log.Trace("Found seed node in database", "id", n.ID, "addr", n.addr(), "age", time.Since(net.db.lastPong(n.ID)))
+		} else {
+			log.Trace("Found seed node", "id", n.ID, "addr", n.addr())
 		}
 		n = net.internNodeFromDB(n)
 		if n.state == unknown {
@@ -782,6 +796,7 @@ type nodeNetGuts struct {
 	deferredQueries   []*findnodeQuery // queries that can't be sent yet
 	pendingNeighbours *findnodeQuery   // current query, waiting for reply
 	queryTimeouts     int
+	topicQueries      rateLimit // limits the topic queries of the node answered
 }
 
 func (n *nodeNetGuts) deferQuery(q *findnodeQuery) {
@@ -963,6 +978,10 @@ func init() {
 		enter: func(net *Network, n *Node) {
 			n.queryTimeouts = 0
 			n.startNextQuery(net)
+			// Remember the node as a seed for the next start.
+			if net.db != nil {
+				net.db.updateNode(n)
+			}
 			// Insert into the table and start revalidation of the last node
 			// in the bucket if it is full.
 			last := net.tab.add(n)
@@ -1055,10 +1074,28 @@ func (net *Network) handle(n *Node, ev nodeEvent, pkt *ingressPacket) error {
 
 func (net *Network) checkPacket(n *Node, ev nodeEvent, pkt *ingressPacket) error {
 	// Replay prevention checks.
+	// TODO: check date is > last date seen
 	switch ev {
-	case pingPacket, findnodeHashPacket, neighborsPacket:
-		// TODO: check date is > last date seen
-		// TODO: check ping version
+	case pingPacket:
+		p := pkt.data.(*ping)
+		if p.Version < Version {
+			return errOldVersion
+		}
+		if expired(p.Expiration) {
+			return errExpired
+		}
+	case findnodePacket:
+		if expired(pkt.data.(*findnode).Expiration) {
+			return errExpired
+		}
+	case findnodeHashPacket:
+		if expired(pkt.data.(*findnodeHash).Expiration) {
+			return errExpired
+		}
+	case topicQueryPacket:
+		if expired(pkt.data.(*topicQuery).Expiration) {
+			return errExpired
+		}
 	case pongPacket:
 		if !bytes.Equal(pkt.data.(*pong).ReplyTok, n.pingEcho) {
 			// fmt.Println("pong reply token mismatch")
@@ -1121,6 +1158,9 @@ func (net *Network) handlePing(n *Node, pkt *ingressPacket) {
 	ping := pkt.data.(*ping)
 	n.TCP = ping.From.TCP
 	t := net.topictab.getTicket(n, ping.Topics)
+	if len(ping.Topics) > 0 {
+		ticketIssueMeter.Mark(1)
+	}
 
 	pong := &pong{
 		To:         makeEndpoint(n.addr(), n.TCP), // TODO: maybe use known TCP port from DB
@@ -1134,6 +1174,9 @@ func (net *Network) handlePing(n *Node, pkt *ingressPacket) {
 func (net *Network) handleKnownPong(n *Node, pkt *ingressPacket) error {
 	debugLog(fmt.Sprintf("handleKnownPong(node = %x)", n.ID[:8]))
 	net.abortTimedEvent(n, pongTimeout)
+	if net.db != nil {
+		net.db.updateLastPong(n.ID, time.Now())
+	}
 	now := mclock.Now()
 	ticket, err := pongToTicket(now, n.pingTopics, n, pkt)
 	if err == nil {
@@ -1181,12 +1224,21 @@ func (net *Network) handleQueryEvent(n *Node, ev nodeEvent, pkt *ingressPacket)
 		pong, err := net.checkTopicRegister(regdata)
 		if err != nil {
 			//fmt.Println(err)
+			registrationRejectMeter.Mark(1)
 			return n.state, fmt.Errorf("bad waiting ticket: %v", err)
 		}
-		net.topictab.useTicket(n, pong.TicketSerial, regdata.Topics, int(regdata.Idx), pong.Expiration, pong.WaitPeriods)
+		if net.topictab.useTicket(n, pong.TicketSerial, regdata.Topics, int(regdata.Idx), pong.Expiration, pong.WaitPeriods) {
+			registrationAcceptMeter.Mark(1)
+		} else {
+			registrationRejectMeter.Mark(1)
+		}
 		return n.state, nil
 	case topicQueryPacket:
-		// TODO: handle expiration
+		if !net.allowTopicQuery(n) {
+			topicQueryDropMeter.Mark(1)
+			return n.state, errRateLimited
+		}
+		topicQueryServeMeter.Mark(1)
 		topic := pkt.data.(*topicQuery).Topic
 		results := net.topictab.getEntries(topic)
 		if _, ok := net.ticketStore.tickets[topic]; ok {
@@ -1236,6 +1288,14 @@ func (net *Network) checkTopicRegister(data *topicRegister) (*pong, error) {
 	return pongpkt.data.(*pong), nil
 }
 
+// allowTopicQuery applies the rate limits of answered topic queries, per node
+// and in total, to a query sent by n.
+func (net *Network) allowTopicQuery(n *Node) bool {
+	now := mclock.Now()
+	return n.topicQueries.allow(now, topicQueryNodeInterval, topicQueryNodeBurst) &&
+		net.topicQueryLimit.allow(now, topicQueryTotalInterval, topicQueryTotalBurst)
+}
+
 func rlpHash(x interface{}) (h common.Hash) {
 	hw := sha3.NewKeccak256()
 	rlp.Encode(hw, x)
@@ -1254,7 +1314,7 @@ func (net *Network) handleNeighboursPacket(n *Node, pkt *ingressPacket) error {
 	for i, rn := range req.Nodes {
 		nn, err := net.internNodeFromNeighbours(pkt.remoteAddr, rn)
 		if err != nil {
-			glog.V(logger.Debug).Infof("invalid neighbour (%v) from %x@%v: %v", rn.IP, n.ID[:8], pkt.remoteAddr, err)
+			log.Debug("Invalid neighbour node received", "ip", rn.IP, "addr", pkt.remoteAddr, "err", err)
 			continue
 		}
 		nodes[i] = nn
@@ -17,12 +17,16 @@
 package discv5
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/EarthDollar/go-earthdollar/common"
+	"github.com/EarthDollar/go-earthdollar/common/mclock"
 	"github.com/EarthDollar/go-earthdollar/crypto"
 )
 
@@ -258,6 +262,81 @@ var lookupTestnet = &preminedTestnet{
 	},
 }
 
+// Tests that topic registrations of other nodes and the tickets collected for
+// registering local topics survive a restart.
+func TestNetworkTopicStatePersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discv5-topics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := newkey()
+	var (
+		registrant = NewNode(NodeID{1}, net.IP{10, 0, 0, 1}, 30304, 30303)
+		registrar  = NewNode(NodeID{2}, net.IP{10, 0, 0, 2}, 30304, 30303)
+		pingHash   = []byte{1, 2, 3}
+		regTime    mclock.AbsTime
+	)
+	network, err := newNetwork(&preminedTestnet{}, key.PublicKey, nil, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collected bool
+	network.reqTableOp(func() {
+		network.topictab.addEntry(network.internNodeFromDB(registrant), "foo")
+
+		// Collect a ticket for topic bar as if requested by a lookup.
+		node := network.internNodeFromDB(registrar)
+		store := network.ticketStore
+		store.addTopic("bar", true)
+		store.nodeLastReq[node] = reqInfo{pingHash: pingHash, lookup: lookupInfo{topic: "bar"}}
+		now := mclock.Now()
+		regTime = now + mclock.AbsTime(time.Minute)
+		store.addTicket(now, pingHash, &ticket{
+			topics:    []Topic{"bar"},
+			regTime:   []mclock.AbsTime{regTime},
+			serial:    5,
+			issueTime: now,
+			node:      node,
+			pong:      []byte{4, 5, 6},
+		})
+		collected = store.nodes[node] != nil
+	})
+	network.Close()
+	if !collected {
+		t.Fatal("ticket not collected")
+	}
+
+	network, err = newNetwork(&preminedTestnet{}, key.PublicKey, nil, dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Close()
+	var (
+		entries []*Node
+		ref     *ticketRef
+	)
+	network.reqTableOp(func() {
+		entries = network.topictab.getEntries("foo")
+		// The ticket is used once its topic is registered again.
+		network.ticketStore.addTopic("bar", true)
+		ref, _ = network.ticketStore.nextRegisterableTicket()
+	})
+	if len(entries) != 1 || entries[0].ID != registrant.ID || entries[0].UDP != registrant.UDP {
+		t.Errorf("restored registrations mismatch: %v", entries)
+	}
+	if ref == nil {
+		t.Fatal("ticket not restored")
+	}
+	if ref.t.node.ID != registrar.ID || ref.t.serial != 5 || ref.topic() != "bar" || !bytes.Equal(ref.t.pong, []byte{4, 5, 6}) {
+		t.Errorf("restored ticket mismatch: node %x serial %d topic %q pong %x", ref.t.node.ID[:8], ref.t.serial, ref.topic(), ref.t.pong)
+	}
+	if d := time.Duration(ref.topicRegTime() - regTime); d < -time.Second || d > time.Second {
+		t.Errorf("restored registration time off by %v", d)
+	}
+}
+
 type preminedTestnet struct {
 	target    NodeID
 	targetSha common.Hash // sha3(target)
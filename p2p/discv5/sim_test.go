@@ -66,8 +66,7 @@ func TestSimTopics(t *testing.T) {
 		return
 	}
 
-	// glog.SetV(6)
-	// glog.SetToStderr(true)
+	// log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
 
 	sim := newSimulation()
 	bootnode := sim.launchNode(false)
@@ -135,6 +134,102 @@ func TestSimTopics(t *testing.T) {
 	//printNet.log.printLogs()
 }
 
+// In this test, a few nodes of the network advertise a topic like LES servers
+// do and the other nodes search for it like light clients. Every searcher must
+// find an advertiser within a bounded time.
+func TestSimTopicSearch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping simulation in short mode")
+	}
+	const (
+		nodeCount     = 100
+		serverCount   = 5
+		maxSearchTime = 90 * time.Second
+		topic         = Topic("LES@test")
+	)
+
+	sim := newSimulation()
+	defer sim.shutdown()
+	bootnode := sim.launchNode(false)
+
+	nets := make([]*Network, nodeCount)
+	servers := make(map[NodeID]bool)
+	for i := range nets {
+		nets[i] = sim.launchNode(false)
+		if err := nets[i].SetFallbackNodes([]*Node{bootnode.Self()}); err != nil {
+			t.Fatal(err)
+		}
+		if i < serverCount {
+			servers[nets[i].Self().ID] = true
+		}
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	for _, net := range nets[:serverCount] {
+		go net.RegisterTopic(topic, stop)
+	}
+
+	// Start searching on all other nodes, measuring the time until the first
+	// advertiser is found.
+	start := time.Now()
+	results := make(chan time.Duration, nodeCount)
+	for _, net := range nets[serverCount:] {
+		go func(net *Network) {
+			found, ok := simSearchTopic(net, topic, servers, start.Add(maxSearchTime), stop)
+			if !ok {
+				results <- -1
+				return
+			}
+			results <- found.Sub(start)
+		}(net)
+	}
+	var max, sum time.Duration
+	failed := 0
+	for i := serverCount; i < nodeCount; i++ {
+		d := <-results
+		if d < 0 {
+			failed++
+			continue
+		}
+		sum += d
+		if d > max {
+			max = d
+		}
+	}
+	if failed > 0 {
+		t.Fatalf("%d of %d searchers did not find an advertiser within %v", failed, nodeCount-serverCount, maxSearchTime)
+	}
+	t.Logf("all searchers found an advertiser, average %v, max %v", sum/time.Duration(nodeCount-serverCount), max)
+}
+
+// simSearchTopic searches for nodes advertising a topic the way the light client
+// server pool does, returning when one of the given nodes is found. The second
+// return value is false if none is found until the deadline.
+func simSearchTopic(net *Network, topic Topic, want map[NodeID]bool, deadline time.Time, stop <-chan struct{}) (time.Time, bool) {
+	setPeriod := make(chan time.Duration, 1)
+	found := make(chan *Node, 100)
+	lookups := make(chan bool, 100)
+	go net.SearchTopic(topic, setPeriod, found, lookups)
+	defer close(setPeriod)
+	setPeriod <- 100 * time.Millisecond
+
+	timeout := time.NewTimer(deadline.Sub(time.Now()))
+	defer timeout.Stop()
+	for {
+		select {
+		case n := <-found:
+			if want[n.ID] {
+				return time.Now(), true
+			}
+		case <-lookups:
+		case <-timeout.C:
+			return time.Time{}, false
+		case <-stop:
+			return time.Time{}, false
+		}
+	}
+}
+
 /*func testHierarchicalTopics(i int) []Topic {
 	digits := strconv.FormatInt(int64(256+i/4), 4)
 	res := make([]Topic, 5)
@@ -159,8 +254,7 @@ func TestSimTopicHierarchy(t *testing.T) {
 		return
 	}
 
-	// glog.SetV(6)
-	// glog.SetToStderr(true)
+	// log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
 
 	sim := newSimulation()
 	bootnode := sim.launchNode(false)
@@ -356,7 +450,7 @@ func (st *simTransport) sendPing(remote *Node, remoteAddr *net.UDPAddr, topics [
 		hash:       hash,
 		ev:         pingPacket,
 		data: &ping{
-			Version:    4,
+			Version:    Version,
 			From:       rpcEndpoint{IP: st.senderAddr.IP, UDP: uint16(st.senderAddr.Port), TCP: 20203},
 			To:         rpcEndpoint{IP: remoteAddr.IP, UDP: uint16(remoteAddr.Port), TCP: 20203},
 			Expiration: uint64(time.Now().Unix() + int64(expiration)),
@@ -121,6 +121,8 @@ func ticketToPong(t *ticket, pong *pong) {
 }
 
 type ticketStore struct {
+	db *nodeDB // stores the collected tickets, may be nil
+
 	// radius detector and target address generator
 	// exists for both searched and registered topics
 	radius map[Topic]*topicRadius
@@ -140,6 +142,9 @@ type ticketStore struct {
 	searchTopicMap        map[Topic]searchTopic
 	nextTopicQueryCleanup mclock.AbsTime
 	queriesSent           map[*Node]map[common.Hash]sentQuery
+
+	// Tickets loaded from the database, used when their topics are registered.
+	restored []*ticket
 }
 
 type searchTopic struct {
@@ -156,8 +161,9 @@ type topicTickets struct {
 	nextLookup, nextReg mclock.AbsTime
 }
 
-func newTicketStore() *ticketStore {
+func newTicketStore(db *nodeDB) *ticketStore {
 	return &ticketStore{
+		db:             db,
 		radius:         make(map[Topic]*topicRadius),
 		tickets:        make(map[Topic]topicTickets),
 		nodes:          make(map[*Node]*ticket),
@@ -176,6 +182,92 @@ func (s *ticketStore) addTopic(t Topic, register bool) {
 	}
 	if register && s.tickets[t].buckets == nil {
 		s.tickets[t] = topicTickets{buckets: make(map[timeBucket][]ticketRef)}
+		s.restoreTickets(t)
+	}
+}
+
+// restoreTicket adds a ticket loaded from the database. It is used for
+// registering its topics once they are added again. The stored copy is deleted,
+// the ticket is stored again if it is used.
+func (s *ticketStore) restoreTicket(node *Node, rec ticketRecord) {
+	t := &ticket{
+		topics:    rec.Topics,
+		regTime:   make([]mclock.AbsTime, len(rec.RegTime)),
+		serial:    rec.Serial,
+		issueTime: unixToAbsTime(rec.IssueTime),
+		node:      node,
+		pong:      rec.Pong,
+	}
+	for i, rt := range rec.RegTime {
+		t.regTime[i] = unixToAbsTime(rt)
+	}
+	s.restored = append(s.restored, t)
+	if s.db != nil {
+		s.db.deleteTicket(node.ID)
+	}
+}
+
+// restoreTickets adds the restored tickets which can still be used to register
+// the given topic.
+func (s *ticketStore) restoreTickets(topic Topic) {
+	now := mclock.Now()
+	window := mclock.AbsTime(regTimeWindow * time.Second)
+	live := s.restored[:0]
+	for _, t := range s.restored {
+		// Forget the tickets which can't be used for any topic anymore.
+		usable := false
+		for _, rt := range t.regTime {
+			usable = usable || rt+window >= now
+		}
+		if !usable {
+			continue
+		}
+		live = append(live, t)
+
+		idx := t.findIdx(topic)
+		if idx == -1 || t.regTime[idx]+window < now {
+			continue
+		}
+		if old := s.nodes[t.node]; old != nil && old != t {
+			continue
+		}
+		bucket := timeBucket(t.regTime[idx] / mclock.AbsTime(ticketTimeBucketLen))
+		if s.lastBucketFetched == 0 || bucket < s.lastBucketFetched {
+			s.lastBucketFetched = bucket
+		}
+		s.addTicketRef(ticketRef{t, idx})
+		s.nextTicketCached = nil
+		s.nodes[t.node] = t
+		s.storeTicket(t)
+	}
+	s.restored = live
+}
+
+// storeTicket persists a ticket, so it can be used after a restart.
+func (s *ticketStore) storeTicket(t *ticket) {
+	if s.db == nil {
+		return
+	}
+	rec := ticketRecord{
+		Node:      nodeToRPC(t.node),
+		Topics:    t.topics,
+		RegTime:   make([]uint64, len(t.regTime)),
+		IssueTime: absTimeToUnix(t.issueTime),
+		Serial:    t.serial,
+		Pong:      t.pong,
+	}
+	for i, rt := range t.regTime {
+		rec.RegTime[i] = absTimeToUnix(rt)
+	}
+	s.db.updateTicket(rec)
+}
+
+// dropTicket forgets a ticket which is no longer used for any topic.
+func (s *ticketStore) dropTicket(t *ticket) {
+	delete(s.nodes, t.node)
+	delete(s.nodeLastReq, t.node)
+	if s.db != nil {
+		s.db.deleteTicket(t.node.ID)
 	}
 }
 
@@ -199,8 +291,7 @@ func (s *ticketStore) removeRegisterTopic(topic Topic) {
 		for _, ref := range list {
 			ref.t.refCnt--
 			if ref.t.refCnt == 0 {
-				delete(s.nodes, ref.t.node)
-				delete(s.nodeLastReq, ref.t.node)
+				s.dropTicket(ref.t)
 			}
 		}
 	}
@@ -448,8 +539,7 @@ func (s *ticketStore) removeTicketRef(ref ticketRef) {
 	}
 	ref.t.refCnt--
 	if ref.t.refCnt == 0 {
-		delete(s.nodes, ref.t.node)
-		delete(s.nodeLastReq, ref.t.node)
+		s.dropTicket(ref.t)
 	}
 
 	// Make nextRegisterableTicket return the next available ticket.
@@ -563,6 +653,8 @@ func (s *ticketStore) addTicket(localTime mclock.AbsTime, pingHash []byte, t *ti
 	if t.refCnt > 0 {
 		s.nextTicketCached = nil
 		s.nodes[t.node] = t
+		s.storeTicket(t)
+		ticketCollectMeter.Mark(1)
 	}
 }
 
@@ -646,6 +738,7 @@ func (s *ticketStore) gotTopicNodes(from *Node, hash common.Hash, nodes []rpcNod
 		n := NewNode(node.ID, ip, node.UDP-1, node.TCP-1) // subtract one from port while discv5 is running in test mode on UDPport+1
 		select {
 		case chn <- n:
+			topicNodesMeter.Mark(1)
 		default:
 			return false
 		}
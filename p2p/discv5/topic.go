@@ -168,6 +168,24 @@ func (t *topicTable) getEntries(topic Topic) []*Node {
 }
 
 func (t *topicTable) addEntry(node *Node, topic Topic) {
+	tm := mclock.Now()
+	t.insertEntry(node, topic, tm+mclock.AbsTime(fallbackRegistrationExpiry))
+	t.topics[topic].wcl.registered(tm)
+}
+
+// restoreEntry adds a registration loaded from the database, which expires at
+// the given time. It doesn't affect the wait periods of the topic.
+func (t *topicTable) restoreEntry(node *Node, topic Topic, expire mclock.AbsTime) {
+	if expire <= mclock.Now() {
+		if t.db != nil {
+			t.db.deleteTopicRegistration(node.ID, topic)
+		}
+		return
+	}
+	t.insertEntry(node, topic, expire)
+}
+
+func (t *topicTable) insertEntry(node *Node, topic Topic, expire mclock.AbsTime) {
 	n := t.getOrNewNode(node)
 	// clear previous entries by the same node
 	for _, e := range n.entries {
@@ -193,7 +211,7 @@ func (t *topicTable) addEntry(node *Node, topic Topic) {
 		topic:   topic,
 		fifoIdx: fifoIdx,
 		node:    node,
-		expire:  tm + mclock.AbsTime(fallbackRegistrationExpiry),
+		expire:  expire,
 	}
 	if printTestImgLogs {
 		fmt.Printf("*+ %d %v %016x %016x\n", tm/1000000, topic, t.self.sha[:8], node.sha[:8])
@@ -201,7 +219,14 @@ func (t *topicTable) addEntry(node *Node, topic Topic) {
 	te.entries[fifoIdx] = entry
 	n.entries[topic] = entry
 	t.globalEntries++
-	te.wcl.registered(tm)
+	t.storeEntry(entry)
+}
+
+// storeEntry persists a registration, so it is restored after a restart.
+func (t *topicTable) storeEntry(e *topicEntry) {
+	if t.db != nil {
+		t.db.updateTopicRegistration(topicRegRecord{Node: nodeToRPC(e.node), Topic: e.topic, Expire: absTimeToUnix(e.expire)})
+	}
 }
 
 // removes least requested element from the fifo
@@ -231,6 +256,9 @@ func (t *topicTable) deleteEntry(e *topicEntry) {
 		t.checkDeleteTopic(e.topic)
 	}
 	t.globalEntries--
+	if t.db != nil {
+		t.db.deleteTopicRegistration(e.node.ID, e.topic)
+	}
 }
 
 // It is assumed that topics and waitPeriods have the same length.
@@ -263,6 +291,7 @@ func (t *topicTable) useTicket(node *Node, serialNo uint32, topics []Topic, idx
 		} else {
 			// if there is an active entry, don't move to the front of the FIFO but prolong expire time
 			e.expire = tm + mclock.AbsTime(fallbackRegistrationExpiry)
+			t.storeEntry(e)
 		}
 		return true
 	}
@@ -355,6 +384,44 @@ func (w *waitControlLoop) hasMinimumWaitPeriod() bool {
 	return w.nextWaitPeriod(mclock.Now()) == minWaitPeriod
 }
 
+// Rate limits of answered topic queries. Searchers query a node for the same
+// topic again once it answered, at most once per lookup, the limit per node
+// leaves room for frequent lookups of several topics. The total limit bounds
+// the traffic generated by answers, which are larger than the queries.
+const (
+	topicQueryNodeInterval  = time.Second
+	topicQueryNodeBurst     = 10
+	topicQueryTotalInterval = 10 * time.Millisecond
+	topicQueryTotalBurst    = 200
+)
+
+// rateLimit is a token bucket. It allows bursts of events up to its capacity,
+// after which events are allowed at the rate the bucket is refilled. The zero
+// value is a full bucket.
+type rateLimit struct {
+	tokens float64
+	last   mclock.AbsTime
+}
+
+// allow takes a token from the bucket if one is available at time now. A token
+// is added every interval, up to capacity.
+func (r *rateLimit) allow(now mclock.AbsTime, interval time.Duration, capacity float64) bool {
+	if r.last == 0 {
+		r.tokens = capacity
+	} else {
+		r.tokens += float64(now-r.last) / float64(interval)
+		if r.tokens > capacity {
+			r.tokens = capacity
+		}
+	}
+	r.last = now
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
 func noRegTimeout() time.Duration {
 	e := rand.ExpFloat64()
 	if e > 100 {
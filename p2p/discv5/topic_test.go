@@ -69,3 +69,33 @@ func TestTopicRadius(t *testing.T) {
 		t.Errorf("Average/target ratio is too far from 1 (%v)", avgRel)
 	}
 }
+
+func TestRateLimit(t *testing.T) {
+	var (
+		r   rateLimit
+		now = mclock.AbsTime(time.Hour)
+	)
+	for i := 0; i < 5; i++ {
+		if !r.allow(now, time.Second, 5) {
+			t.Fatalf("event %d of burst refused", i)
+		}
+	}
+	if r.allow(now, time.Second, 5) {
+		t.Fatal("event above burst allowed")
+	}
+	now += mclock.AbsTime(1500 * time.Millisecond)
+	if !r.allow(now, time.Second, 5) {
+		t.Fatal("event refused after refill")
+	}
+	if r.allow(now, time.Second, 5) {
+		t.Fatal("event above refill allowed")
+	}
+	// The bucket doesn't fill up above its capacity.
+	now += mclock.AbsTime(time.Hour)
+	for i := 0; i < 5; i++ {
+		r.allow(now, time.Second, 5)
+	}
+	if r.allow(now, time.Second, 5) {
+		t.Fatal("event above capacity allowed")
+	}
+}
@@ -26,19 +26,29 @@ import (
 
 	"github.com/EarthDollar/go-earthdollar/common"
 	"github.com/EarthDollar/go-earthdollar/crypto"
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
-const Version = 4
+// Version is the protocol version sent in ping packets. Pings of older versions
+// are not answered.
+const Version = 5
+
+// versionPrefix starts every discv5 packet, separating them from the packets of
+// the v4 discovery protocol and of incompatible revisions of this one. It must
+// be changed whenever the packet framing or the encoding of existing fields
+// changes. Packets can be extended with new fields at their end without
+// changing the prefix, the tail of unknown fields is ignored by older nodes.
+const versionPrefix = "discv5 wire 1"
 
 // Errors
 var (
 	errPacketTooSmall   = errors.New("too small")
+	errBadPrefix        = errors.New("unknown version prefix")
 	errBadHash          = errors.New("bad hash")
+	errOldVersion       = errors.New("protocol version too old")
 	errExpired          = errors.New("expired")
 	errUnsolicitedReply = errors.New("unsolicited reply")
 	errUnknownNode      = errors.New("unknown node")
@@ -119,17 +129,23 @@ type (
 		Topics []Topic
 		Idx    uint
 		Pong   []byte
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
 	topicQuery struct {
 		Topic      Topic
 		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
 	// reply to topicQuery
 	topicNodes struct {
 		Echo  common.Hash
 		Nodes []rpcNode
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
 	rpcNode struct {
@@ -147,9 +163,10 @@ type (
 )
 
 const (
-	macSize  = 256 / 8
-	sigSize  = 520 / 8
-	headSize = macSize + sigSize // space of packet frame data
+	versionPrefixSize = len(versionPrefix)
+	macSize           = 256 / 8
+	sigSize           = 520 / 8
+	headSize          = versionPrefixSize + macSize + sigSize // space of packet frame data
 )
 
 // Neighbors replies are sent across multiple packets to
@@ -195,6 +212,11 @@ func makeEndpoint(addr *net.UDPAddr, tcpPort uint16) rpcEndpoint {
 	return rpcEndpoint{IP: ip, UDP: uint16(addr.Port), TCP: tcpPort}
 }
 
+// expired reports whether the expiration timestamp of a packet has passed.
+func expired(ts uint64) bool {
+	return time.Unix(int64(ts), 0).Before(time.Now())
+}
+
 func (e1 rpcEndpoint) equal(e2 rpcEndpoint) bool {
 	return e1.UDP == e2.UDP && e1.TCP == e2.TCP && e1.IP.Equal(e2.IP)
 }
@@ -348,9 +370,11 @@ func (t *udp) sendPacket(toid NodeID, toaddr *net.UDPAddr, ptype byte, req inter
 		//fmt.Println(err)
 		return hash, err
 	}
-	glog.V(logger.Detail).Infof(">>> %v to %x@%v\n", nodeEvent(ptype), toid[:8], toaddr)
+	log.Trace(">>> "+nodeEvent(ptype).String(), "id", toid, "addr", toaddr)
 	if _, err = t.conn.WriteToUDP(packet, toaddr); err != nil {
-		glog.V(logger.Detail).Infoln("UDP send failed:", err)
+		log.Trace("UDP send failed", "addr", toaddr, "err", err)
+	} else {
+		egressTrafficMeter.Mark(int64(len(packet)))
 	}
 	//fmt.Println(err)
 	return hash, err
@@ -364,20 +388,21 @@ func encodePacket(priv *ecdsa.PrivateKey, ptype byte, req interface{}) (p, hash
 	b.Write(headSpace)
 	b.WriteByte(ptype)
 	if err := rlp.Encode(b, req); err != nil {
-		glog.V(logger.Error).Infoln("error encoding packet:", err)
+		log.Error("Can't encode discv5 packet", "err", err)
 		return nil, nil, err
 	}
 	packet := b.Bytes()
 	sig, err := crypto.Sign(crypto.Keccak256(packet[headSize:]), priv)
 	if err != nil {
-		glog.V(logger.Error).Infoln("could not sign packet:", err)
+		log.Error("Can't sign discv5 packet", "err", err)
 		return nil, nil, err
 	}
-	copy(packet[macSize:], sig)
-	// add the hash to the front. Note: this doesn't protect the
+	copy(packet, versionPrefix)
+	copy(packet[versionPrefixSize+macSize:], sig)
+	// add the hash after the prefix. Note: this doesn't protect the
 	// packet in any way.
-	hash = crypto.Keccak256(packet[macSize:])
-	copy(packet, hash)
+	hash = crypto.Keccak256(packet[versionPrefixSize+macSize:])
+	copy(packet[versionPrefixSize:], hash)
 	return packet, hash, nil
 }
 
@@ -393,13 +418,14 @@ func (t *udp) readLoop() {
 		nbytes, from, err := t.conn.ReadFromUDP(buf)
 		if netutil.IsTemporaryError(err) {
 			// Ignore temporary read errors.
-			glog.V(logger.Debug).Infof("Temporary read error: %v", err)
+			log.Debug("Temporary UDP read error", "err", err)
 			continue
 		} else if err != nil {
 			// Shut down the loop for permament errors.
-			glog.V(logger.Debug).Infof("Read error: %v", err)
+			log.Debug("UDP read error", "err", err)
 			return
 		}
+		ingressTrafficMeter.Mark(int64(nbytes))
 		t.handlePacket(from, buf[:nbytes])
 	}
 }
@@ -407,7 +433,7 @@ func (t *udp) readLoop() {
 func (t *udp) handlePacket(from *net.UDPAddr, buf []byte) error {
 	pkt := ingressPacket{remoteAddr: from}
 	if err := decodePacket(buf, &pkt); err != nil {
-		glog.V(logger.Debug).Infof("Bad packet from %v: %v\n", from, err)
+		log.Debug("Bad discv5 packet", "addr", from, "err", err)
 		//fmt.Println("bad packet", err)
 		return err
 	}
@@ -419,10 +445,13 @@ func decodePacket(buffer []byte, pkt *ingressPacket) error {
 	if len(buffer) < headSize+1 {
 		return errPacketTooSmall
 	}
+	if string(buffer[:versionPrefixSize]) != versionPrefix {
+		return errBadPrefix
+	}
 	buf := make([]byte, len(buffer))
 	copy(buf, buffer)
-	hash, sig, sigdata := buf[:macSize], buf[macSize:headSize], buf[headSize:]
-	shouldhash := crypto.Keccak256(buf[macSize:])
+	hash, sig, sigdata := buf[versionPrefixSize:versionPrefixSize+macSize], buf[versionPrefixSize+macSize:headSize], buf[headSize:]
+	shouldhash := crypto.Keccak256(buf[versionPrefixSize+macSize:])
 	if !bytes.Equal(hash, shouldhash) {
 		return errBadHash
 	}
@@ -284,46 +284,52 @@ var testPackets = []struct {
 	wantPacket interface{}
 }{
 	{
-		input: "71dbda3a79554728d4f94411e42ee1f8b0d561c10e1e5f5893367948c6a7d70bb87b235fa28a77070271b6c164a2dce8c7e13a5739b53b5e96f2e5acb0e458a02902f5965d55ecbeb2ebb6cabb8b2b232896a36b737666c55265ad0a68412f250001ea04cb847f000001820cfa8215a8d790000000000000000000000000000000018208ae820d058443b9a355",
+		input: "6469736376352077697265203118b143064bbaa66d7dffb3b0ef22e98b2069c1210359e4316819d1ca9a8160f8dddb95f87750089d7ff05019462a1c98fb26ad807b49f50cc98d66d07c49a1e861bad8da54649faf03e20231c6d623cd92812d4e82fab10f5383e54c2e4269670101eb05cb847f000001820cfa8215a8d790000000000000000000000000000000018208ae820d058443b9a355c0",
 		wantPacket: &ping{
-			Version:    4,
+			Version:    5,
 			From:       rpcEndpoint{net.ParseIP("127.0.0.1").To4(), 3322, 5544},
 			To:         rpcEndpoint{net.ParseIP("::1"), 2222, 3333},
 			Expiration: 1136239445,
+			Topics:     []Topic{},
 			Rest:       []rlp.RawValue{},
 		},
 	},
 	{
-		input: "e9614ccfd9fc3e74360018522d30e1419a143407ffcce748de3e22116b7e8dc92ff74788c0b6663aaa3d67d641936511c8f8d6ad8698b820a7cf9e1be7155e9a241f556658c55428ec0563514365799a4be2be5a685a80971ddcfa80cb422cdd0101ec04cb847f000001820cfa8215a8d790000000000000000000000000000000018208ae820d058443b9a3550102",
+		input: "64697363763520776972652031f1b9f9aaee3c5970f75f252c3c4a01feea80a935f18d872f788353be3c439f9558fd2a7001a5728a4a51216fd908840393fc898e97824790bf0f609f6886d1f34fa0d100aa34e40ce911facfb85eac9b4a5e3792f523a0900a904eb27c946a5d0101f505cb847f000001820cfa8215a8d790000000000000000000000000000000018208ae820d058443b9a355c883666f6f836261720102",
 		wantPacket: &ping{
-			Version:    4,
+			Version:    5,
 			From:       rpcEndpoint{net.ParseIP("127.0.0.1").To4(), 3322, 5544},
 			To:         rpcEndpoint{net.ParseIP("::1"), 2222, 3333},
 			Expiration: 1136239445,
+			Topics:     []Topic{"foo", "bar"},
 			Rest:       []rlp.RawValue{{0x01}, {0x02}},
 		},
 	},
 	{
-		input: "577be4349c4dd26768081f58de4c6f375a7a22f3f7adda654d1428637412c3d7fe917cadc56d4e5e7ffae1dbe3efffb9849feb71b262de37977e7c7a44e677295680e9e38ab26bee2fcbae207fba3ff3d74069a50b902a82c9903ed37cc993c50001f83e82022bd79020010db83c4d001500000000abcdef12820cfa8215a8d79020010db885a308d313198a2e037073488208ae82823a8443b9a355c5010203040531b9019afde696e582a78fa8d95ea13ce3297d4afb8ba6433e4154caa5ac6431af1b80ba76023fa4090c408f6b4bc3701562c031041d4702971d102c9ab7fa5eed4cd6bab8f7af956f7d565ee1917084a95398b6a21eac920fe3dd1345ec0a7ef39367ee69ddf092cbfe5b93e5e568ebc491983c09c76d922dc3",
+		input: "6469736376352077697265203187ce05a53b4edfe15cbcdd9ef21c787d2389a1b136822907bfdde1f3d8726ffac1b7a59ea7deb70f5da1cb22f94efbeacee4002daafcd8f0f030d067edbab69064759aa8ad0079c47e1e5073c16abeb9817aa636b5d3a11e92fe8d9af127e4d20001f84382022bd79020010db83c4d001500000000abcdef12820cfa8215a8d79020010db885a308d313198a2e037073488208ae82823a8443b9a355c483666f6fc50102030405",
 		wantPacket: &ping{
 			Version:    555,
 			From:       rpcEndpoint{net.ParseIP("2001:db8:3c4d:15::abcd:ef12"), 3322, 5544},
 			To:         rpcEndpoint{net.ParseIP("2001:db8:85a3:8d3:1319:8a2e:370:7348"), 2222, 33338},
 			Expiration: 1136239445,
+			Topics:     []Topic{"foo"},
 			Rest:       []rlp.RawValue{{0xC5, 0x01, 0x02, 0x03, 0x04, 0x05}},
 		},
 	},
 	{
-		input: "09b2428d83348d27cdf7064ad9024f526cebc19e4958f0fdad87c15eb598dd61d08423e0bf66b2069869e1724125f820d851c136684082774f870e614d95a2855d000f05d1648b2d5945470bc187c2d2216fbe870f43ed0909009882e176a46b0102f846d79020010db885a308d313198a2e037073488208ae82823aa0fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c9548443b9a355c6010203c2040506a0c969a58f6f9095004c0177a6b47f451530cab38966a25cca5cb58f055542124e",
+		input: "64697363763520776972652031f05a3b08c00f990c526f6e5e475287ec885a047f5b3a2e4faffa935bb39e81a954911dd7464b752c9e9fcd4557db8abd5c03c8875a9380f2918a6db4188e07ce74e165d867ef35729875b18b9f32c46a32ebf3d6234976561393f34c35dd5af70102f86dd79020010db885a308d313198a2e037073488208ae82823aa0fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c9548443b9a355a02ee5a6e4e5ed6b1a5c3db2ef4fd2c6b4d7f4ea4b11a1e8e1e8b9a1c0b5f2a9d407c43c820258c6010203c2040506",
 		wantPacket: &pong{
-			To:         rpcEndpoint{net.ParseIP("2001:db8:85a3:8d3:1319:8a2e:370:7348"), 2222, 33338},
-			ReplyTok:   common.Hex2Bytes("fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c954"),
-			Expiration: 1136239445,
-			Rest:       []rlp.RawValue{{0xC6, 0x01, 0x02, 0x03, 0xC2, 0x04, 0x05}, {0x06}},
+			To:           rpcEndpoint{net.ParseIP("2001:db8:85a3:8d3:1319:8a2e:370:7348"), 2222, 33338},
+			ReplyTok:     common.Hex2Bytes("fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c954"),
+			Expiration:   1136239445,
+			TopicHash:    common.HexToHash("0x2ee5a6e4e5ed6b1a5c3db2ef4fd2c6b4d7f4ea4b11a1e8e1e8b9a1c0b5f2a9d4"),
+			TicketSerial: 7,
+			WaitPeriods:  []uint32{60, 600},
+			Rest:         []rlp.RawValue{{0xC6, 0x01, 0x02, 0x03, 0xC2, 0x04, 0x05}, {0x06}},
 		},
 	},
 	{
-		input: "c7c44041b9f7c7e41934417ebac9a8e1a4c6298f74553f2fcfdcae6ed6fe53163eb3d2b52e39fe91831b8a927bf4fc222c3902202027e5e9eb812195f95d20061ef5cd31d502e47ecb61183f74a504fe04c51e73df81f25c4d506b26db4517490103f84eb840ca634cae0d49acb401d8a4c6b6fe8c55b70d115bf400769cc1400f3258cd31387574077f301b421bc84df7266c44e9e6d569fc56be00812904767bf5ccd1fc7f8443b9a35582999983999999280dc62cc8255c73471e0a61da0c89acdc0e035e260add7fc0c04ad9ebf3919644c91cb247affc82b69bd2ca235c71eab8e49737c937a2c396",
+		input: "646973637635207769726520316b52e2ca82312ac62aebaeaa5eff8ee117f3007efea23840ce00d582c77b470c20120da487e55fbc123ad0de3ce84b86129ea612e833a7985a3979e4e666463717e6f91bab79f85b70af271dc424faa582c297432d0d1e0df7e4f22d07f5c7370103f84eb840ca634cae0d49acb401d8a4c6b6fe8c55b70d115bf400769cc1400f3258cd31387574077f301b421bc84df7266c44e9e6d569fc56be00812904767bf5ccd1fc7f8443b9a35582999983999999",
 		wantPacket: &findnode{
 			Target:     MustHexID("ca634cae0d49acb401d8a4c6b6fe8c55b70d115bf400769cc1400f3258cd31387574077f301b421bc84df7266c44e9e6d569fc56be00812904767bf5ccd1fc7f"),
 			Expiration: 1136239445,
@@ -331,7 +337,15 @@ var testPackets = []struct {
 		},
 	},
 	{
-		input: "c679fc8fe0b8b12f06577f2e802d34f6fa257e6137a995f6f4cbfc9ee50ed3710faf6e66f932c4c8d81d64343f429651328758b47d3dbc02c4042f0fff6946a50f4a49037a72bb550f3a7872363a83e1b9ee6469856c24eb4ef80b7535bcf99c0004f9015bf90150f84d846321163782115c82115db8403155e1427f85f10a5c9a7755877748041af1bcd8d474ec065eb33df57a97babf54bfd2103575fa829115d224c523596b401065a97f74010610fce76382c0bf32f84984010203040101b840312c55512422cf9b8a4097e9a6ad79402e87a15ae909a4bfefa22398f03d20951933beea1e4dfa6f968212385e829f04c2d314fc2d4e255e0d3bc08792b069dbf8599020010db83c4d001500000000abcdef12820d05820d05b84038643200b172dcfef857492156971f0e6aa2c538d8b74010f8e140811d53b98c765dd2d96126051913f44582e8c199ad7c6d6819e9a56483f637feaac9448aacf8599020010db885a308d313198a2e037073488203e78203e8b8408dcab8618c3253b558d459da53bd8fa68935a719aff8b811197101a4b2b47dd2d47295286fc00cc081bb542d760717d1bdd6bec2c37cd72eca367d6dd3b9df738443b9a355010203b525a138aa34383fec3d2719a0",
+		input: "6469736376352077697265203195345eb7201c943196530bc5d59c8c83187d8ca0f9c710229ef9f63dc6bbfa9d18b43356b858641a1a87f9c8a4f9b325cb46c294be7fcab553c84bc73d27797638801441273cff18c13b5c6bce9f77756169a93d6483dcf63546e043d65fd2b50005e7a09d5b2b2fd8d1ce3a4e6b8b8b3cdd14ab2a9a0ba2b39c0b8b8f0a1b7e8f1ec6b18443b9a35501",
+		wantPacket: &findnodeHash{
+			Target:     common.HexToHash("0x9d5b2b2fd8d1ce3a4e6b8b8b3cdd14ab2a9a0ba2b39c0b8b8f0a1b7e8f1ec6b1"),
+			Expiration: 1136239445,
+			Rest:       []rlp.RawValue{{0x01}},
+		},
+	},
+	{
+		input: "646973637635207769726520314cf05b80021752ee826da321aa70267208703449a39825d4bd6d70c07555baa7165fef5f56fa977b3fa90fc9848c1b083eaecf946647328599002410915e47ea756fd4ef2b5d08956ef7d335881867fc5b7b1e5a1b6f1f418c7ac02d02eed8950104f9015bf90150f84d846321163782115c82115db8403155e1427f85f10a5c9a7755877748041af1bcd8d474ec065eb33df57a97babf54bfd2103575fa829115d224c523596b401065a97f74010610fce76382c0bf32f84984010203040101b840312c55512422cf9b8a4097e9a6ad79402e87a15ae909a4bfefa22398f03d20951933beea1e4dfa6f968212385e829f04c2d314fc2d4e255e0d3bc08792b069dbf8599020010db83c4d001500000000abcdef12820d05820d05b84038643200b172dcfef857492156971f0e6aa2c538d8b74010f8e140811d53b98c765dd2d96126051913f44582e8c199ad7c6d6819e9a56483f637feaac9448aacf8599020010db885a308d313198a2e037073488203e78203e8b8408dcab8618c3253b558d459da53bd8fa68935a719aff8b811197101a4b2b47dd2d47295286fc00cc081bb542d760717d1bdd6bec2c37cd72eca367d6dd3b9df738443b9a355010203",
 		wantPacket: &neighbors{
 			Nodes: []rpcNode{
 				{
@@ -363,11 +377,41 @@ var testPackets = []struct {
 			Rest:       []rlp.RawValue{{0x01}, {0x02}, {0x03}},
 		},
 	},
+	{
+		input: "64697363763520776972652031b925aa8e23b3241c5ba3304063163ddac14857978c9f52dcd83ee5e329f2f21c4f6d5412f427736a0ec7186dbf33c156c62942d7fc843b4a4b6219cdda194ebb25c4300e7c0a1ab4be0cfa1f980186062930a4a269b62a9d926e0933dfcb1f510006d0c883666f6f8362617201840102030401",
+		wantPacket: &topicRegister{
+			Topics: []Topic{"foo", "bar"},
+			Idx:    1,
+			Pong:   common.Hex2Bytes("01020304"),
+			Rest:   []rlp.RawValue{{0x01}},
+		},
+	},
+	{
+		input: "646973637635207769726520312701d35a8b1ebd9e33a72c68d7c75fcb4fd8bb805c9d5c484771420d23885ad64e9008fa5b80c830abd8f1177349d14c1d7e0b42f15b82037f8efb20f11b2c1c4375fc345dc40f2b682ec01fefd806cec1676c08be7fc96a9d8ded6e89b728a00107cc83666f6f8443b9a355829999",
+		wantPacket: &topicQuery{
+			Topic:      "foo",
+			Expiration: 1136239445,
+			Rest:       []rlp.RawValue{{0x82, 0x99, 0x99}},
+		},
+	},
+	{
+		input: "64697363763520776972652031e35aa37d422d477205f8214e62748545dab574bbdb4cc10013d2f362b7fb3d7c3e0c626fb2190de8428c982fdc64c1f73cc70746cd08f0afc55aa9ecf3875d7e56509bb7db64c3cf87ffdb56c338ed6a4c7d0dddeba524e31dfcec200568261e0108f874a0fbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c954f84ff84d846321163782115c82115db8403155e1427f85f10a5c9a7755877748041af1bcd8d474ec065eb33df57a97babf54bfd2103575fa829115d224c523596b401065a97f74010610fce76382c0bf320102",
+		wantPacket: &topicNodes{
+			Echo: common.HexToHash("0xfbc914b16819237dcd8801d7e53f69e9719adecb3cc0e790c57e91ca4461c954"),
+			Nodes: []rpcNode{
+				{
+					ID:  MustHexID("3155e1427f85f10a5c9a7755877748041af1bcd8d474ec065eb33df57a97babf54bfd2103575fa829115d224c523596b401065a97f74010610fce76382c0bf32"),
+					IP:  net.ParseIP("99.33.22.55").To4(),
+					UDP: 4444,
+					TCP: 4445,
+				},
+			},
+			Rest: []rlp.RawValue{{0x01}, {0x02}},
+		},
+	},
 }
 
 func TestForwardCompatibility(t *testing.T) {
-	t.Skip("skipped while working on discovery v5")
-
 	testkey, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 	wantNodeID := PubkeyID(&testkey.PublicKey)
 
@@ -390,6 +434,26 @@ func TestForwardCompatibility(t *testing.T) {
 	}
 }
 
+// Tests that packets without the version prefix of the wire format are dropped.
+func TestPacketVersionPrefix(t *testing.T) {
+	packet, _, err := encodePacket(newkey(), byte(findnodePacket), &findnode{Expiration: futureExp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pkt ingressPacket
+	if err := decodePacket(packet, &pkt); err != nil {
+		t.Fatalf("valid packet rejected: %v", err)
+	}
+	// A v4 packet consists of the frame without prefix.
+	if err := decodePacket(packet[versionPrefixSize:], &pkt); err != errBadPrefix {
+		t.Errorf("packet without prefix: error mismatch: have %v, want %v", err, errBadPrefix)
+	}
+	packet[0]++
+	if err := decodePacket(packet, &pkt); err != errBadPrefix {
+		t.Errorf("packet with unknown prefix: error mismatch: have %v, want %v", err, errBadPrefix)
+	}
+}
+
 // dgramPipe is a fake UDP socket. It queues all sent datagrams.
 type dgramPipe struct {
 	mu      *sync.Mutex
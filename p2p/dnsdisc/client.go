@@ -0,0 +1,397 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via DNS (EIP-1459).
+//
+// Node lists are published as a merkle tree of TXT records below a DNS domain.
+// The root of the tree is signed by a known key, its branches refer to their
+// children by hash and its leaves are node records or links to other trees.
+// Trees are identified by URLs of the form enrtree://<key>@<domain>.
+package dnsdisc
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+)
+
+var (
+	errNoRoot       = errors.New("no valid root found")
+	errHashMismatch = errors.New("hash mismatch")
+	errNoEntry      = errors.New("no valid tree entry found")
+	errTimeout      = errors.New("DNS lookup timeout")
+	errLinkInENR    = errors.New("link in node record subtree")
+	errENRInLink    = errors.New("node record in link subtree")
+	errSeqRollback  = errors.New("tree sequence number decreased")
+)
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(name string) ([]string, error)
+}
+
+// Config holds the settings of a DNS discovery client.
+type Config struct {
+	Timeout         time.Duration // Timeout of DNS lookups (default 5s)
+	RecheckInterval time.Duration // Time between tree root update checks (default 30min)
+	CacheLimit      int           // Maximum number of cached tree entries (default 1000)
+	Resolver        Resolver      // DNS resolver to use (default system DNS)
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.RecheckInterval == 0 {
+		cfg.RecheckInterval = 30 * time.Minute
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = 1000
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = systemResolver{cfg.Timeout}
+	}
+	return cfg
+}
+
+// systemResolver resolves TXT records through the system DNS configuration.
+type systemResolver struct {
+	timeout time.Duration
+}
+
+func (r systemResolver) LookupTXT(name string) ([]string, error) {
+	type result struct {
+		txts []string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		txts, err := net.LookupTXT(name)
+		done <- result{txts, err}
+	}()
+	select {
+	case res := <-done:
+		return res.txts, res.err
+	case <-time.After(r.timeout):
+		return nil, errTimeout
+	}
+}
+
+// Client discovers nodes by syncing DNS trees. Trees linked from the configured
+// ones are followed as well.
+type Client struct {
+	cfg   Config
+	roots []*linkEntry
+
+	lock    sync.Mutex
+	entries map[string]entry                // cache of resolved entries, keyed by hash
+	trees   map[string]*Tree                // last synced tree of every domain
+	records map[discover.NodeID]*enr.Record // records of all nodes in the synced trees
+	nodes   []*discover.Node                // dial candidates derived from records
+
+	quit    chan struct{}
+	closing sync.Once
+}
+
+// NewClient creates a client for the trees at the given enrtree:// URLs. Call
+// Start to sync the trees in the background.
+func NewClient(cfg Config, urls ...string) (*Client, error) {
+	c := &Client{
+		cfg:     cfg.withDefaults(),
+		entries: make(map[string]entry),
+		trees:   make(map[string]*Tree),
+		records: make(map[discover.NodeID]*enr.Record),
+		quit:    make(chan struct{}),
+	}
+	for _, url := range urls {
+		link, err := parseLink(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enrtree URL %q: %v", url, err)
+		}
+		c.roots = append(c.roots, link)
+	}
+	return c, nil
+}
+
+// Start launches the background sync of the configured trees.
+func (c *Client) Start() {
+	go c.loop()
+}
+
+// Close stops the background sync.
+func (c *Client) Close() {
+	c.closing.Do(func() { close(c.quit) })
+}
+
+func (c *Client) loop() {
+	for {
+		c.refresh()
+		select {
+		case <-time.After(c.cfg.RecheckInterval):
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// refresh syncs all configured trees and the trees linked from them, updating
+// the set of known nodes. Trees failing to sync keep their previous content.
+func (c *Client) refresh() {
+	var (
+		queue = append([]*linkEntry{}, c.roots...)
+		trees = make(map[string]*Tree)
+	)
+	for len(queue) > 0 {
+		link := queue[0]
+		queue = queue[1:]
+		if _, ok := trees[link.domain]; ok {
+			continue
+		}
+		c.lock.Lock()
+		prev := c.trees[link.domain]
+		c.lock.Unlock()
+
+		t, err := c.syncTree(link, prev)
+		if err != nil {
+			log.Debug("Failed to sync DNS tree", "domain", link.domain, "err", err)
+			if t = prev; t == nil {
+				continue
+			}
+		}
+		trees[link.domain] = t
+		for _, url := range t.Links() {
+			if next, err := parseLink(url); err == nil {
+				queue = append(queue, next)
+			}
+		}
+		select {
+		case <-c.quit:
+			return
+		default:
+		}
+	}
+	c.setTrees(trees)
+}
+
+// setTrees replaces the synced trees and the set of nodes derived from them.
+func (c *Client) setTrees(trees map[string]*Tree) {
+	records := make(map[discover.NodeID]*enr.Record)
+	var nodes []*discover.Node
+	for _, t := range trees {
+		for _, r := range t.Nodes() {
+			n, err := nodeFromRecord(r)
+			if err != nil {
+				continue
+			}
+			if _, ok := records[n.ID]; !ok {
+				nodes = append(nodes, n)
+			}
+			records[n.ID] = r
+		}
+	}
+	c.lock.Lock()
+	c.trees, c.records, c.nodes = trees, records, nodes
+	c.lock.Unlock()
+	log.Debug("Updated DNS discovery nodes", "trees", len(trees), "nodes", len(nodes))
+}
+
+// ReadRandomNodes fills the given slice with random nodes of the synced trees.
+// It returns the number of nodes written and does not block.
+func (c *Client) ReadRandomNodes(buf []*discover.Node) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	n := 0
+	for _, i := range rand.Perm(len(c.nodes)) {
+		if n == len(buf) {
+			break
+		}
+		buf[n] = c.nodes[i]
+		n++
+	}
+	return n
+}
+
+// NodeRecord returns the node record of the given node, if it is contained in
+// one of the synced trees.
+func (c *Client) NodeRecord(id discover.NodeID) *enr.Record {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.records[id]
+}
+
+// SyncTree downloads the complete tree at the given enrtree:// URL. Linked
+// trees are not synced.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	link, err := parseLink(url)
+	if err != nil {
+		return nil, err
+	}
+	return c.syncTree(link, nil)
+}
+
+// syncTree downloads the tree at the given location. If the root didn't change
+// since the previous sync, prev is returned as is.
+func (c *Client) syncTree(link *linkEntry, prev *Tree) (*Tree, error) {
+	root, err := c.resolveRoot(link)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		if root.seq < prev.root.seq {
+			return nil, errSeqRollback
+		}
+		if prev.root.eroot == root.eroot && prev.root.lroot == root.lroot {
+			return prev, nil
+		}
+	}
+	t := &Tree{root: root, entries: make(map[string]entry)}
+	if err := c.syncSubtree(t, link.domain, root.eroot, false); err != nil {
+		return nil, err
+	}
+	if err := c.syncSubtree(t, link.domain, root.lroot, true); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncSubtree downloads the subtree below the given hash into t. Node records
+// and links may not be mixed in the same subtree.
+func (c *Client) syncSubtree(t *Tree, domain, hash string, links bool) error {
+	e, err := c.resolveEntry(domain, hash)
+	if err != nil {
+		return err
+	}
+	t.entries[hash] = e
+
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := c.syncSubtree(t, domain, child, links); err != nil {
+				return err
+			}
+		}
+	case *linkEntry:
+		if !links {
+			return errLinkInENR
+		}
+	case *enrEntry:
+		if links {
+			return errENRInLink
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves the root entry of a tree and verifies its signature.
+func (c *Client) resolveRoot(link *linkEntry) (*rootEntry, error) {
+	txts, err := c.cfg.Resolver.LookupTXT(link.domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		if !strings.HasPrefix(txt, rootPrefix) {
+			continue
+		}
+		root, err := parseRoot(txt)
+		if err != nil {
+			return nil, err
+		}
+		if !root.verifySignature(link.pubkey) {
+			return nil, entryError{"root", errInvalidSig}
+		}
+		return root, nil
+	}
+	return nil, errNoRoot
+}
+
+// resolveEntry retrieves the entry with the given hash, from the cache if
+// possible. The content of the entry is checked against the hash.
+func (c *Client) resolveEntry(domain, hash string) (entry, error) {
+	c.lock.Lock()
+	e, ok := c.entries[hash]
+	c.lock.Unlock()
+	if ok {
+		return e, nil
+	}
+
+	txts, err := c.cfg.Resolver.LookupTXT(hash + "." + domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt)
+		if err == errUnknownEntry {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !matchesHash(txt, hash) {
+			return nil, errHashMismatch
+		}
+		c.cacheEntry(hash, e)
+		return e, nil
+	}
+	return nil, errNoEntry
+}
+
+// cacheEntry adds an entry to the cache, evicting a random entry if the cache
+// is full.
+func (c *Client) cacheEntry(hash string, e entry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.entries) >= c.cfg.CacheLimit {
+		for h := range c.entries {
+			delete(c.entries, h)
+			break
+		}
+	}
+	c.entries[hash] = e
+}
+
+// nodeFromRecord converts a node record into a dialable node.
+func nodeFromRecord(r *enr.Record) (*discover.Node, error) {
+	var (
+		pubkey enr.Secp256k1
+		ip     enr.IP
+		tcp    enr.TCP
+		udp    enr.UDP
+	)
+	if err := r.Load(&pubkey); err != nil {
+		return nil, err
+	}
+	if err := r.Load(&ip); err != nil {
+		return nil, err
+	}
+	if err := r.Load(&tcp); err != nil {
+		return nil, err
+	}
+	r.Load(&udp)
+	id := discover.PubkeyID((*ecdsa.PublicKey)(&pubkey))
+	return discover.NewNode(id, net.IP(ip), uint16(udp), uint16(tcp)), nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+)
+
+// mapResolver is an in-memory Resolver serving the TXT records of a map.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, errors.New("not found")
+}
+
+// add publishes a tree below the given domain.
+func (mr mapResolver) add(t *testing.T, tree *Tree, key *ecdsa.PrivateKey, domain string) string {
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txts, err := tree.ToTXT(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, txt := range txts {
+		mr[name] = txt
+	}
+	return url
+}
+
+// Tests that trees with a root signed by the wrong key are rejected.
+func TestClientSyncBadSignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	tree, _ := MakeTree(1, testRecords(t, 3), nil)
+	r := make(mapResolver)
+	r.add(t, tree, key, "n")
+
+	c, _ := NewClient(Config{Resolver: r})
+	wrongURL := (&linkEntry{"n", &other.PublicKey}).String()
+	if _, err := c.SyncTree(wrongURL); err != (entryError{"root", errInvalidSig}) {
+		t.Errorf("error mismatch: have %v, want invalid signature", err)
+	}
+}
+
+// Tests that entries not matching their hash are rejected.
+func TestClientSyncHashMismatch(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, _ := MakeTree(1, testRecords(t, 3), nil)
+	r := make(mapResolver)
+	url := r.add(t, tree, key, "n")
+
+	// Swap the contents of two leaves
+	var names []string
+	for name, txt := range r {
+		if strings.HasPrefix(txt, enrPrefix) {
+			names = append(names, name)
+		}
+	}
+	r[names[0]], r[names[1]] = r[names[1]], r[names[0]]
+
+	c, _ := NewClient(Config{Resolver: r})
+	if _, err := c.SyncTree(url); err != errHashMismatch {
+		t.Errorf("error mismatch: have %v, want %v", err, errHashMismatch)
+	}
+}
+
+// Tests that the hashes are checked against the raw text of the records, which
+// may differ from the text form of the parsed entries.
+func TestClientSyncRawHash(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, _ := MakeTree(1, testRecords(t, 1), nil)
+	r := make(mapResolver)
+	url := r.add(t, tree, key, "n")
+
+	// Line breaks are skipped when decoding the record, but change the hash
+	for name, txt := range r {
+		if strings.HasPrefix(txt, enrPrefix) {
+			r[name] = txt[:10] + "\n" + txt[10:]
+			if e, err := parseEntry(r[name]); err != nil || e.String() != txt {
+				t.Fatalf("modified record not parsed to the original: %v", err)
+			}
+		}
+	}
+	c, _ := NewClient(Config{Resolver: r})
+	if _, err := c.SyncTree(url); err != errHashMismatch {
+		t.Errorf("error mismatch: have %v, want %v", err, errHashMismatch)
+	}
+}
+
+// Tests that node records are not accepted in the link subtree and vice versa.
+func TestClientSyncMixedSubtree(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, _ := MakeTree(1, testRecords(t, 2), nil)
+	tree.root.lroot, tree.root.eroot = tree.root.eroot, tree.root.lroot
+	r := make(mapResolver)
+	url := r.add(t, tree, key, "n")
+
+	c, _ := NewClient(Config{Resolver: r})
+	if _, err := c.SyncTree(url); err != errENRInLink {
+		t.Errorf("error mismatch: have %v, want %v", err, errENRInLink)
+	}
+}
+
+// Tests that the client follows links to other trees, provides the nodes of all
+// trees as dial candidates and keeps the content of trees failing to sync.
+func TestClientRefresh(t *testing.T) {
+	var (
+		k1, _    = crypto.GenerateKey()
+		k2, _    = crypto.GenerateKey()
+		records1 = testRecords(t, 5)
+		records2 = testRecords(t, 4)
+		r        = make(mapResolver)
+	)
+	tree2, _ := MakeTree(1, records2, nil)
+	url2 := r.add(t, tree2, k2, "b.example.org")
+	tree1, _ := MakeTree(1, records1, []string{url2})
+	url1 := r.add(t, tree1, k1, "a.example.org")
+
+	c, err := NewClient(Config{Resolver: r}, url1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.refresh()
+
+	buf := make([]*discover.Node, 20)
+	if n := c.ReadRandomNodes(buf); n != 9 {
+		t.Fatalf("wrong number of nodes: have %d, want 9", n)
+	}
+	want := make(map[discover.NodeID]bool)
+	for _, rec := range append(records1, records2...) {
+		n, _ := nodeFromRecord(rec)
+		want[n.ID] = true
+	}
+	for _, n := range buf[:9] {
+		if !want[n.ID] {
+			t.Errorf("unexpected node %v", n)
+		}
+		if c.NodeRecord(n.ID) == nil {
+			t.Errorf("missing record of node %x", n.ID[:8])
+		}
+		delete(want, n.ID)
+	}
+	if n := c.ReadRandomNodes(buf[:3]); n != 3 {
+		t.Errorf("partial read mismatch: have %d, want 3", n)
+	}
+
+	// Break the linked tree, its nodes must be kept
+	delete(r, "b.example.org")
+	c.refresh()
+	if n := c.ReadRandomNodes(buf); n != 9 {
+		t.Errorf("nodes of failing tree dropped: have %d, want 9", n)
+	}
+
+	// Publish an update of the first tree without the link
+	tree1, _ = MakeTree(2, records1[:2], nil)
+	r.add(t, tree1, k1, "a.example.org")
+	c.refresh()
+	if n := c.ReadRandomNodes(buf); n != 2 {
+		t.Errorf("wrong number of nodes after update: have %d, want 2", n)
+	}
+
+	// Rolling back to an older version must fail
+	old, _ := MakeTree(1, records1, nil)
+	r.add(t, old, k1, "a.example.org")
+	c.lock.Lock()
+	prev := c.trees["a.example.org"]
+	c.lock.Unlock()
+	link, _ := parseLink(url1)
+	if _, err := c.syncTree(link, prev); err != errSeqRollback {
+		t.Errorf("error mismatch: have %v, want %v", err, errSeqRollback)
+	}
+}
+
+// Tests that node records without an endpoint are not used as dial candidates.
+func TestNodeFromRecord(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	r := new(enr.Record)
+	r.Set(enr.UDP(30303))
+	enr.SignV4(r, key)
+	if _, err := nodeFromRecord(r); err == nil {
+		t.Error("record without IP accepted")
+	}
+	r.Set(enr.IP{127, 0, 0, 1})
+	r.Set(enr.TCP(30304))
+	enr.SignV4(r, key)
+	n, err := nodeFromRecord(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.ID != discover.PubkeyID(&key.PublicKey) || n.TCP != 30304 || n.UDP != 30303 || !n.IP.Equal(net.IP{127, 0, 0, 1}) {
+		t.Errorf("node mismatch: %v", n)
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+)
+
+// Tree is a merkle tree of node records and links to other trees, in the form
+// it is published as TXT records under a DNS domain.
+type Tree struct {
+	root    *rootEntry
+	entries map[string]entry
+}
+
+// Entry prefixes of the TXT record formats.
+const (
+	rootPrefix   = "enrtree-root:v1"
+	linkPrefix   = "enrtree://"
+	branchPrefix = "enrtree-branch:"
+	enrPrefix    = enr.TextPrefix
+)
+
+const (
+	hashAbbrev    = 16 // Number of hash bytes used in subdomain names
+	minHashLength = 12 // Shortest hash accepted from remote trees
+	maxTXTLength  = 370
+)
+
+// maxChildren is the maximum number of child hashes fitting into a single
+// branch entry.
+var maxChildren = maxTXTLength / (b32format.EncodedLen(hashAbbrev) + 1)
+
+var (
+	b32format = base32.StdEncoding.WithPadding(base32.NoPadding)
+	b64format = base64.RawURLEncoding
+)
+
+var (
+	errUnknownEntry = errors.New("unknown entry type")
+	errNoPubkey     = errors.New("missing public key")
+	errBadPubkey    = errors.New("invalid public key")
+	errInvalidENR   = errors.New("invalid node record")
+	errInvalidChild = errors.New("invalid child hash")
+	errInvalidSig   = errors.New("invalid root signature")
+	errSyntax       = errors.New("invalid syntax")
+	errUnsigned     = errors.New("tree is not signed")
+)
+
+// entryError wraps the errors of entry parsing.
+type entryError struct {
+	typ string
+	err error
+}
+
+func (err entryError) Error() string {
+	return fmt.Sprintf("invalid %s entry: %v", err.typ, err.err)
+}
+
+// MakeTree creates a tree containing the given node records and links to other
+// trees. The tree is unsigned, see Sign.
+func MakeTree(seq uint, records []*enr.Record, links []string) (*Tree, error) {
+	nodes := make(recordsByID, len(records))
+	copy(nodes, records)
+	sort.Sort(nodes)
+
+	enrEntries := make([]entry, len(nodes))
+	for i, r := range nodes {
+		if !r.Signed() {
+			return nil, fmt.Errorf("record %d: %v", i, errInvalidENR)
+		}
+		enrEntries[i] = &enrEntry{r}
+	}
+	linkEntries := make([]entry, len(links))
+	for i, l := range links {
+		le, err := parseLink(l)
+		if err != nil {
+			return nil, err
+		}
+		linkEntries[i] = le
+	}
+
+	t := &Tree{entries: make(map[string]entry)}
+	eroot := t.build(enrEntries)
+	t.entries[subdomain(eroot)] = eroot
+	lroot := t.build(linkEntries)
+	t.entries[subdomain(lroot)] = lroot
+	t.root = &rootEntry{seq: seq, eroot: subdomain(eroot), lroot: subdomain(lroot)}
+	return t, nil
+}
+
+// build creates the subtree of the given entries, returning its root. All
+// entries below the root are added to the tree.
+func (t *Tree) build(entries []entry) entry {
+	if len(entries) == 1 {
+		return entries[0]
+	}
+	if len(entries) <= maxChildren {
+		hashes := make([]string, len(entries))
+		for i, e := range entries {
+			hashes[i] = subdomain(e)
+			t.entries[hashes[i]] = e
+		}
+		return &branchEntry{hashes}
+	}
+	var subtrees []entry
+	for len(entries) > 0 {
+		n := maxChildren
+		if len(entries) < n {
+			n = len(entries)
+		}
+		sub := t.build(entries[:n])
+		entries = entries[n:]
+		subtrees = append(subtrees, sub)
+		t.entries[subdomain(sub)] = sub
+	}
+	return t.build(subtrees)
+}
+
+// Sign signs the tree root with the given key and returns the enrtree:// URL
+// under which the tree can be found once it is deployed to domain.
+func (t *Tree) Sign(key *ecdsa.PrivateKey, domain string) (string, error) {
+	root := *t.root
+	sig, err := crypto.Sign(root.sigHash(), key)
+	if err != nil {
+		return "", err
+	}
+	root.sig = sig
+	t.root = &root
+	link := &linkEntry{domain: domain, pubkey: &key.PublicKey}
+	return link.String(), nil
+}
+
+// Seq returns the sequence number of the tree.
+func (t *Tree) Seq() uint {
+	return t.root.seq
+}
+
+// Signature returns the base64 encoded signature of the tree root, or the
+// empty string if the tree is unsigned.
+func (t *Tree) Signature() string {
+	if t.root.sig == nil {
+		return ""
+	}
+	return b64format.EncodeToString(t.root.sig)
+}
+
+// ToTXT returns all TXT records of the tree, keyed by their name below the
+// given domain. The root entry is stored under the domain itself.
+func (t *Tree) ToTXT(domain string) (map[string]string, error) {
+	if t.root.sig == nil {
+		return nil, errUnsigned
+	}
+	records := map[string]string{domain: t.root.String()}
+	for hash, e := range t.entries {
+		name := hash
+		if domain != "" {
+			name = hash + "." + domain
+		}
+		records[name] = e.String()
+	}
+	return records, nil
+}
+
+// Links returns all links contained in the tree.
+func (t *Tree) Links() []string {
+	var links []string
+	for _, e := range t.entries {
+		if le, ok := e.(*linkEntry); ok {
+			links = append(links, le.String())
+		}
+	}
+	sort.Strings(links)
+	return links
+}
+
+// Nodes returns all node records contained in the tree.
+func (t *Tree) Nodes() []*enr.Record {
+	var nodes recordsByID
+	for _, e := range t.entries {
+		if ee, ok := e.(*enrEntry); ok {
+			nodes = append(nodes, ee.node)
+		}
+	}
+	sort.Sort(nodes)
+	return nodes
+}
+
+// entry is a single TXT record of a tree.
+type entry interface {
+	fmt.Stringer
+}
+
+type (
+	rootEntry struct {
+		eroot string // Hash of the root of the node record subtree
+		lroot string // Hash of the root of the link subtree
+		seq   uint
+		sig   []byte
+	}
+	branchEntry struct {
+		children []string
+	}
+	enrEntry struct {
+		node *enr.Record
+	}
+	linkEntry struct {
+		domain string
+		pubkey *ecdsa.PublicKey
+	}
+)
+
+// subdomain returns the name under which an entry is published, the
+// abbreviated hash of its text form.
+func subdomain(e entry) string {
+	h := crypto.Keccak256([]byte(e.String()))
+	return b32format.EncodeToString(h[:hashAbbrev])
+}
+
+func (e *rootEntry) String() string {
+	return fmt.Sprintf(rootPrefix+" e=%s l=%s seq=%d sig=%s", e.eroot, e.lroot, e.seq, b64format.EncodeToString(e.sig))
+}
+
+// sigHash returns the hash covered by the root signature.
+func (e *rootEntry) sigHash() []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf(rootPrefix+" e=%s l=%s seq=%d", e.eroot, e.lroot, e.seq)))
+}
+
+// verifySignature checks the root signature against the given public key.
+func (e *rootEntry) verifySignature(pubkey *ecdsa.PublicKey) bool {
+	if len(e.sig) != 65 {
+		return false
+	}
+	have, err := crypto.Ecrecover(e.sigHash(), e.sig)
+	return err == nil && bytes.Equal(have, crypto.FromECDSAPub(pubkey))
+}
+
+func (e *branchEntry) String() string {
+	return branchPrefix + strings.Join(e.children, ",")
+}
+
+func (e *enrEntry) String() string {
+	return e.node.String()
+}
+
+func (e *linkEntry) String() string {
+	return linkPrefix + b32format.EncodeToString(crypto.CompressPubkey(e.pubkey)) + "@" + e.domain
+}
+
+// parseRoot parses the text of a root entry.
+func parseRoot(e string) (*rootEntry, error) {
+	var (
+		eroot, lroot, sig string
+		seq               uint
+	)
+	if _, err := fmt.Sscanf(e, rootPrefix+" e=%s l=%s seq=%d sig=%s", &eroot, &lroot, &seq, &sig); err != nil {
+		return nil, entryError{"root", errSyntax}
+	}
+	if !isValidHash(eroot) || !isValidHash(lroot) {
+		return nil, entryError{"root", errInvalidChild}
+	}
+	sigb, err := b64format.DecodeString(sig)
+	if err != nil || len(sigb) != 65 {
+		return nil, entryError{"root", errInvalidSig}
+	}
+	return &rootEntry{eroot: eroot, lroot: lroot, seq: seq, sig: sigb}, nil
+}
+
+// parseEntry parses the text of a branch, node record or link entry.
+func parseEntry(e string) (entry, error) {
+	switch {
+	case strings.HasPrefix(e, linkPrefix):
+		link, err := parseLink(e)
+		if err != nil {
+			return nil, err
+		}
+		return link, nil
+	case strings.HasPrefix(e, branchPrefix):
+		return parseBranch(e)
+	case strings.HasPrefix(e, enrPrefix):
+		return parseENR(e)
+	default:
+		return nil, errUnknownEntry
+	}
+}
+
+func parseBranch(e string) (entry, error) {
+	e = e[len(branchPrefix):]
+	if e == "" {
+		return &branchEntry{}, nil // empty entry is OK
+	}
+	hashes := strings.Split(e, ",")
+	for _, c := range hashes {
+		if !isValidHash(c) {
+			return nil, entryError{"branch", errInvalidChild}
+		}
+	}
+	return &branchEntry{hashes}, nil
+}
+
+func parseENR(e string) (entry, error) {
+	r := new(enr.Record)
+	if err := r.UnmarshalText([]byte(e)); err != nil {
+		return nil, entryError{"enr", errInvalidENR}
+	}
+	return &enrEntry{r}, nil
+}
+
+// parseLink parses an enrtree:// URL.
+func parseLink(e string) (*linkEntry, error) {
+	if !strings.HasPrefix(e, linkPrefix) {
+		return nil, fmt.Errorf("wrong/missing scheme 'enrtree' in URL")
+	}
+	e = e[len(linkPrefix):]
+	pos := strings.IndexByte(e, '@')
+	if pos == -1 {
+		return nil, entryError{"link", errNoPubkey}
+	}
+	keystring, domain := e[:pos], e[pos+1:]
+	keybytes, err := b32format.DecodeString(keystring)
+	if err != nil {
+		return nil, entryError{"link", errBadPubkey}
+	}
+	key, err := crypto.DecompressPubkey(keybytes)
+	if err != nil {
+		return nil, entryError{"link", errBadPubkey}
+	}
+	if domain == "" {
+		return nil, entryError{"link", errSyntax}
+	}
+	return &linkEntry{domain, key}, nil
+}
+
+// matchesHash reports whether the hash of the TXT record content matches the
+// given (possibly shorter) hash. The raw text is hashed, as re-encoding a parsed
+// entry needn't reproduce it byte for byte.
+func matchesHash(txt string, hash string) bool {
+	want, err := b32format.DecodeString(hash)
+	if err != nil {
+		return false
+	}
+	have := crypto.Keccak256([]byte(txt))
+	return bytes.HasPrefix(have, want)
+}
+
+// isValidHash reports whether s is a plausible subdomain hash.
+func isValidHash(s string) bool {
+	dec, err := b32format.DecodeString(s)
+	return err == nil && len(dec) >= minHashLength && len(dec) <= 32
+}
+
+// recordsByID implements sort.Interface to order node records by node ID.
+type recordsByID []*enr.Record
+
+func (r recordsByID) Len() int           { return len(r) }
+func (r recordsByID) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r recordsByID) Less(i, j int) bool { return bytes.Compare(recordID(r[i]), recordID(r[j])) < 0 }
+
+// recordID returns the node ID of a record, nil if it has no public key.
+func recordID(r *enr.Record) []byte {
+	var pubkey enr.Secp256k1
+	if err := r.Load(&pubkey); err != nil {
+		return nil
+	}
+	id := discover.PubkeyID((*ecdsa.PublicKey)(&pubkey))
+	return id[:]
+}
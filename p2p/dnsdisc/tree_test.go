@@ -0,0 +1,182 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
+)
+
+// Tests that trees of various sizes can be split into TXT records and parsed
+// back into the same content.
+func TestTreeRoundtrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	for _, n := range []int{0, 1, 2, maxChildren, maxChildren + 1, 3*maxChildren*maxChildren + 5} {
+		records := testRecords(t, n)
+		tree, err := MakeTree(3, records, nil)
+		if err != nil {
+			t.Fatalf("%d records: failed to make tree: %v", n, err)
+		}
+		url, err := tree.Sign(key, "nodes.example.org")
+		if err != nil {
+			t.Fatalf("%d records: failed to sign: %v", n, err)
+		}
+		txts, err := tree.ToTXT("nodes.example.org")
+		if err != nil {
+			t.Fatalf("%d records: failed to create TXT records: %v", n, err)
+		}
+		for name, txt := range txts {
+			if len(txt) > maxTXTLength && !strings.HasPrefix(txt, enrPrefix) {
+				t.Errorf("%d records: TXT record %s too long (%d bytes)", n, name, len(txt))
+			}
+		}
+		c, _ := NewClient(Config{Resolver: mapResolver(txts)})
+		synced, err := c.SyncTree(url)
+		if err != nil {
+			t.Fatalf("%d records: sync failed: %v", n, err)
+		}
+		if synced.Seq() != 3 || synced.Signature() != tree.Signature() {
+			t.Errorf("%d records: root mismatch: seq %d, sig %s", n, synced.Seq(), synced.Signature())
+		}
+		if have, want := recordStrings(synced.Nodes()), recordStrings(tree.Nodes()); !reflect.DeepEqual(have, want) || len(have) != n {
+			t.Errorf("%d records: synced node mismatch: have %d, want %d", n, len(have), len(want))
+		}
+	}
+}
+
+// Tests that trees must be signed before they can be published.
+func TestTreeUnsigned(t *testing.T) {
+	tree, err := MakeTree(1, testRecords(t, 2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.ToTXT("example.org"); err != errUnsigned {
+		t.Errorf("error mismatch: have %v, want %v", err, errUnsigned)
+	}
+}
+
+// Tests that links survive a roundtrip through MakeTree.
+func TestTreeLinks(t *testing.T) {
+	k1, _ := crypto.GenerateKey()
+	k2, _ := crypto.GenerateKey()
+	links := []string{
+		(&linkEntry{"a.example.org", &k1.PublicKey}).String(),
+		(&linkEntry{"b.example.org", &k2.PublicKey}).String(),
+	}
+	tree, err := MakeTree(1, nil, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]string{}, links...)
+	sort.Strings(want)
+	if have := tree.Links(); !reflect.DeepEqual(have, want) {
+		t.Errorf("link mismatch:\nhave %v\nwant %v", have, want)
+	}
+	if _, err := MakeTree(1, nil, []string{"enrtree://nokey.example.org"}); err == nil {
+		t.Error("invalid link accepted")
+	}
+}
+
+func TestParseEntry(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tests := []struct {
+		input string
+		e     entry
+		err   error
+	}{
+		// Branches
+		{input: "enrtree-branch:", e: &branchEntry{}},
+		{
+			input: "enrtree-branch:2XS2367YHAXJFGLZHVAWLQD4ZY,H4FHT4B454P6UXFD7JCYQ5PWDY",
+			e:     &branchEntry{[]string{"2XS2367YHAXJFGLZHVAWLQD4ZY", "H4FHT4B454P6UXFD7JCYQ5PWDY"}},
+		},
+		{input: "enrtree-branch:AAAA", err: entryError{"branch", errInvalidChild}},
+		{input: "enrtree-branch:2XS2367YHAXJFGLZHVAWLQD4ZY,!!", err: entryError{"branch", errInvalidChild}},
+		// Links
+		{
+			input: (&linkEntry{"nodes.example.org", &key.PublicKey}).String(),
+			e:     &linkEntry{"nodes.example.org", &key.PublicKey},
+		},
+		{input: "enrtree://nodes.example.org", err: entryError{"link", errNoPubkey}},
+		{input: "enrtree://AP62DT7WOTEQZGQZOU474PP3KMEGVTTE7A7NPRXKX3DUD57@nodes.example.org", err: entryError{"link", errBadPubkey}},
+		// ENRs
+		{input: "enr:-invalid", err: entryError{"enr", errInvalidENR}},
+		// Invalid
+		{input: "", err: errUnknownEntry},
+		{input: "foo", err: errUnknownEntry},
+		{input: "enrtree", err: errUnknownEntry},
+	}
+	for i, test := range tests {
+		e, err := parseEntry(test.input)
+		if !reflect.DeepEqual(e, test.e) {
+			t.Errorf("test %d: wrong entry %#v, want %#v", i, e, test.e)
+		}
+		if err != test.err {
+			t.Errorf("test %d: wrong error %q, want %q", i, err, test.err)
+		}
+	}
+}
+
+func TestParseRoot(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"enrtree-root:v1 e=TO4Q75OQ2N7DX4EOOR7X66A6OM l=TO4Q75OQ2N7DX4EOOR7X66A6OM seq=3", entryError{"root", errSyntax}},
+		{"enrtree-root:v1 e=TO4Q75OQ2N7DX4EOOR7X66A6OM l=TO4Q75OQ2N7DX4EOOR7X66A6OM seq=3 sig=N-YY6UB9xD0hFx1Gmnt7v0RfSxch5tKyry2SRDoLx7B4GfPXagwLxQqyf7gAMvApFn_ORwZQekMWa_pXrcGCtwE", nil},
+		{"enrtree-root:v1 e=TO4Q75OQ2N7DX4EOOR7X66A6OM l=TO4Q75OQ2N7DX4EOOR7X66A6OM seq=3 sig=N-YY6UB9xD0hFx1Gmnt7v0RfSxch5tKyry2SRDoLx7B4GfPXagwLxQ", entryError{"root", errInvalidSig}},
+		{"enrtree-root:v1 e=TO4Q l=TO4Q75OQ2N7DX4EOOR7X66A6OM seq=3 sig=N-YY6UB9xD0hFx1Gmnt7v0RfSxch5tKyry2SRDoLx7B4GfPXagwLxQqyf7gAMvApFn_ORwZQekMWa_pXrcGCtwE", entryError{"root", errInvalidChild}},
+	}
+	for i, test := range tests {
+		if _, err := parseRoot(test.input); err != test.err {
+			t.Errorf("test %d: wrong error %q, want %q", i, err, test.err)
+		}
+	}
+}
+
+// testRecords creates n signed node records with distinct keys and endpoints.
+func testRecords(t *testing.T, n int) []*enr.Record {
+	records := make([]*enr.Record, n)
+	for i := range records {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := new(enr.Record)
+		r.Set(enr.IP{10, byte(i >> 16), byte(i >> 8), byte(i)})
+		r.Set(enr.TCP(30303))
+		r.Set(enr.UDP(30303))
+		if err := enr.SignV4(r, key); err != nil {
+			t.Fatal(err)
+		}
+		records[i] = r
+	}
+	return records
+}
+
+func recordStrings(records []*enr.Record) []string {
+	s := make([]string, len(records))
+	for i, r := range records {
+		s[i] = r.String()
+	}
+	return s
+}
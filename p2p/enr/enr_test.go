@@ -0,0 +1,341 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package enr
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+var (
+	privkey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	pubkey     = &privkey.PublicKey
+)
+
+// TestGetSetIP tests encoding/decoding and setting/getting of the IP key.
+func TestGetSetIP(t *testing.T) {
+	for _, addr := range []string{"192.168.0.3", "2001:db8::1"} {
+		var r Record
+		ip := IP(net.ParseIP(addr))
+		r.Set(ip)
+
+		var ip2 IP
+		if err := r.Load(&ip2); err != nil {
+			t.Fatalf("can't load %s: %v", addr, err)
+		}
+		if !net.IP(ip).Equal(net.IP(ip2)) {
+			t.Errorf("IP mismatch: have %v, want %v", ip2, ip)
+		}
+	}
+}
+
+// TestGetSetPorts tests encoding/decoding and setting/getting of the UDP and TCP keys.
+func TestGetSetPorts(t *testing.T) {
+	var r Record
+	r.Set(UDP(30309))
+	r.Set(TCP(30303))
+
+	var udp UDP
+	var tcp TCP
+	if err := r.Load(&udp); err != nil || udp != 30309 {
+		t.Errorf("UDP mismatch: have %d (%v), want 30309", udp, err)
+	}
+	if err := r.Load(&tcp); err != nil || tcp != 30303 {
+		t.Errorf("TCP mismatch: have %d (%v), want 30303", tcp, err)
+	}
+}
+
+// TestGetSetSecp256k1 tests encoding/decoding and setting/getting of the Secp256k1 key.
+func TestGetSetSecp256k1(t *testing.T) {
+	var r Record
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+	var pk Secp256k1
+	if err := r.Load(&pk); err != nil {
+		t.Fatal(err)
+	}
+	if pk.X.Cmp(pubkey.X) != 0 || pk.Y.Cmp(pubkey.Y) != 0 {
+		t.Errorf("public key mismatch")
+	}
+}
+
+// TestLoadErrors tests the errors returned by Load.
+func TestLoadErrors(t *testing.T) {
+	var r Record
+	ip := IP{127, 0, 0, 1}
+	r.Set(ip)
+
+	// Check error for missing keys.
+	var udp UDP
+	err := r.Load(&udp)
+	if !IsNotFound(err) {
+		t.Error("IsNotFound should return true for missing key")
+	}
+	if !reflect.DeepEqual(err, &KeyError{Key: udp.ENRKey(), Err: errNotFound}) {
+		t.Errorf("wrong error for missing key: %v", err)
+	}
+
+	// Check error for invalid keys.
+	var list []uint
+	err = r.Load(WithEntry(ip.ENRKey(), &list))
+	kerr, ok := err.(*KeyError)
+	if !ok {
+		t.Fatalf("expected KeyError, got %T", err)
+	}
+	if kerr.Key != ip.ENRKey() {
+		t.Errorf("wrong key in KeyError: have %q, want %q", kerr.Key, ip.ENRKey())
+	}
+	if IsNotFound(err) {
+		t.Error("IsNotFound should return false for decoding errors")
+	}
+}
+
+// TestSortedGetAndSet tests that Set produces a sorted pairs slice.
+func TestSortedGetAndSet(t *testing.T) {
+	type pair struct {
+		k string
+		v uint32
+	}
+
+	for _, tt := range []struct {
+		input []pair
+		want  []pair
+	}{
+		{
+			input: []pair{{"a", 1}, {"c", 2}, {"b", 3}},
+			want:  []pair{{"a", 1}, {"b", 3}, {"c", 2}},
+		},
+		{
+			input: []pair{{"a", 1}, {"c", 2}, {"b", 3}, {"d", 4}, {"a", 5}, {"bb", 6}},
+			want:  []pair{{"a", 5}, {"b", 3}, {"bb", 6}, {"c", 2}, {"d", 4}},
+		},
+		{
+			input: []pair{{"c", 2}, {"b", 3}, {"d", 4}, {"a", 5}, {"bb", 6}},
+			want:  []pair{{"a", 5}, {"b", 3}, {"bb", 6}, {"c", 2}, {"d", 4}},
+		},
+	} {
+		var r Record
+		for _, i := range tt.input {
+			r.Set(WithEntry(i.k, &i.v))
+		}
+		for i, w := range tt.want {
+			// set got's key from r.pair[i], so that we preserve order of pairs
+			got := pair{k: r.pairs[i].k}
+			if err := r.Load(WithEntry(w.k, &got.v)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, w) {
+				t.Errorf("pair %d mismatch: have %v, want %v", i, got, w)
+			}
+		}
+	}
+}
+
+// TestDirty tests record signature removal on setting of new key/value pair in record.
+func TestDirty(t *testing.T) {
+	var r Record
+
+	if r.Signed() {
+		t.Error("Signed returned true for zero record")
+	}
+	if _, err := rlp.EncodeToBytes(r); err != errEncodeUnsigned {
+		t.Errorf("expected errEncodeUnsigned, got %#v", err)
+	}
+
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Signed() {
+		t.Error("Signed return false for signed record")
+	}
+	if _, err := rlp.EncodeToBytes(r); err != nil {
+		t.Fatal(err)
+	}
+
+	r.SetSeq(3)
+	if r.Signed() {
+		t.Error("Signed returned true for modified record")
+	}
+	if _, err := rlp.EncodeToBytes(r); err != errEncodeUnsigned {
+		t.Errorf("expected errEncodeUnsigned, got %#v", err)
+	}
+}
+
+// TestSeqIncrement tests that modifying a signed record increments the sequence number.
+func TestSeqIncrement(t *testing.T) {
+	var r Record
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq() != 0 {
+		t.Errorf("wrong initial seq: %d", r.Seq())
+	}
+	r.Set(UDP(1))
+	if r.Seq() != 1 {
+		t.Errorf("wrong seq after Set: %d", r.Seq())
+	}
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq() != 1 {
+		t.Errorf("signing an unsigned record must not change seq, have %d", r.Seq())
+	}
+}
+
+// TestRecordTooBig tests that records bigger than SizeLimit bytes cannot be signed.
+func TestRecordTooBig(t *testing.T) {
+	var r Record
+	key := randomString(10)
+
+	// set a big value for random key, expect error
+	r.Set(WithEntry(key, randomString(SizeLimit)))
+	if err := SignV4(&r, privkey); err != errTooBig {
+		t.Fatalf("expected to get errTooBig, got %#v", err)
+	}
+
+	// set an acceptable value for random key, expect no error
+	r.Set(WithEntry(key, randomString(100)))
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+// TestSignEncodeAndDecode tests signing, RLP encoding and RLP decoding of a record.
+func TestSignEncodeAndDecode(t *testing.T) {
+	var r Record
+	r.Set(UDP(30303))
+	r.Set(IP(net.IPv4(127, 0, 0, 1)))
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r2 Record
+	if err := rlp.DecodeBytes(blob, &r2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("decoded record mismatch:\nhave %#v\nwant %#v", r2, r)
+	}
+
+	blob2, err := rlp.EncodeToBytes(r2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(blob, blob2) {
+		t.Errorf("re-encoded record mismatch:\nhave %x\nwant %x", blob2, blob)
+	}
+}
+
+// TestTextEncoding tests the base64 text form of records.
+func TestTextEncoding(t *testing.T) {
+	var r Record
+	r.Set(TCP(30303))
+	if _, err := r.MarshalText(); err != errEncodeUnsigned {
+		t.Errorf("expected errEncodeUnsigned, got %#v", err)
+	}
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+	text, err := r.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(text, []byte(TextPrefix)) {
+		t.Errorf("text encoding lacks prefix: %s", text)
+	}
+	var r2 Record
+	if err := r2.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, r2) {
+		t.Errorf("decoded record mismatch:\nhave %#v\nwant %#v", r2, r)
+	}
+	if err := r2.UnmarshalText(text[len(TextPrefix):]); err == nil {
+		t.Errorf("expected error for missing prefix")
+	}
+}
+
+// TestDecodeInvalid tests that records with bad signatures or layout are rejected.
+func TestDecodeInvalid(t *testing.T) {
+	var r Record
+	r.Set(UDP(30303))
+	if err := SignV4(&r, privkey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flip a bit in the signature.
+	sig := make([]byte, len(r.signature))
+	copy(sig, r.signature)
+	sig[10] ^= 0x01
+	blob, _ := r.encode(sig)
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errInvalidSig {
+		t.Errorf("expected errInvalidSig for bad signature, got %v", err)
+	}
+
+	// Change the content without re-signing.
+	r2 := r
+	r2.pairs = append([]pair{}, r.pairs...)
+	r2.seq++
+	blob, _ = r2.encode(r.signature)
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errInvalidSig {
+		t.Errorf("expected errInvalidSig for modified record, got %v", err)
+	}
+
+	// Unsorted keys.
+	blob, _ = rlp.EncodeToBytes([]interface{}{r.signature, uint64(0), "b", uint(1), "a", uint(2)})
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errNotSorted {
+		t.Errorf("expected errNotSorted, got %v", err)
+	}
+	// Duplicate keys.
+	blob, _ = rlp.EncodeToBytes([]interface{}{r.signature, uint64(0), "a", uint(1), "a", uint(2)})
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errDuplicateKey {
+		t.Errorf("expected errDuplicateKey, got %v", err)
+	}
+	// Incomplete pair.
+	blob, _ = rlp.EncodeToBytes([]interface{}{r.signature, uint64(0), "a"})
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errIncompletePair {
+		t.Errorf("expected errIncompletePair, got %v", err)
+	}
+	// No identity scheme.
+	blob, _ = rlp.EncodeToBytes([]interface{}{r.signature, uint64(0), "udp", uint(1)})
+	if err := rlp.DecodeBytes(blob, new(Record)); !IsNotFound(err) {
+		t.Errorf("expected missing id error, got %v", err)
+	}
+	// Unknown identity scheme.
+	blob, _ = rlp.EncodeToBytes([]interface{}{r.signature, uint64(0), "id", "v9"})
+	if err := rlp.DecodeBytes(blob, new(Record)); err != errNoID {
+		t.Errorf("expected errNoID, got %v", err)
+	}
+}
+
+func randomString(strlen int) string {
+	b := make([]byte, strlen)
+	rand.Read(b)
+	return string(b)
+}
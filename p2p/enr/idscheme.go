@@ -0,0 +1,74 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package enr
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+// SignV4 sets the identity scheme of the record to "v4", stores the public key of privkey
+// in the "secp256k1" entry and signs the record with it. The sequence number is incremented
+// if the record was signed before.
+func SignV4(r *Record, privkey *ecdsa.PrivateKey) error {
+	// Copy r to avoid modifying it if signing fails.
+	cpy := *r
+	cpy.Set(IDv4)
+	cpy.Set(Secp256k1(privkey.PublicKey))
+
+	sig, err := crypto.Sign(cpy.sigHash(), privkey)
+	if err != nil {
+		return err
+	}
+	sig = sig[:len(sig)-1] // remove v
+	if cpy.raw, err = cpy.encode(sig); err != nil {
+		return err
+	}
+	cpy.signature = sig
+	*r = cpy
+	return nil
+}
+
+// sigHash returns the hash covered by the "v4" signature.
+func (r *Record) sigHash() []byte {
+	content, _ := rlp.EncodeToBytes(r.appendPairs(nil))
+	return crypto.Keccak256(content)
+}
+
+// verifySignature checks the "v4" signature against the public key in the record.
+func (r *Record) verifySignature() error {
+	var pubkey Secp256k1
+	if err := r.Load(&pubkey); err != nil {
+		return err
+	}
+	if len(r.signature) != 64 {
+		return errInvalidSig
+	}
+	// The signature doesn't contain the recovery id, so try both of them.
+	want := crypto.FromECDSAPub((*ecdsa.PublicKey)(&pubkey))
+	hash := r.sigHash()
+	for v := byte(0); v < 2; v++ {
+		have, err := crypto.Ecrecover(hash, append(r.signature[:64:64], v))
+		if err == nil && bytes.Equal(have, want) {
+			return nil
+		}
+	}
+	return errInvalidSig
+}
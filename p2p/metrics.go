@@ -29,8 +29,54 @@ var (
 	ingressTrafficMeter = metrics.NewMeter("p2p/InboundTraffic")
 	egressConnectMeter  = metrics.NewMeter("p2p/OutboundConnects")
 	egressTrafficMeter  = metrics.NewMeter("p2p/OutboundTraffic")
+
+	// Snappy compression of the message payloads: the payload volume before
+	// compression and the compressed size in percent of the original one.
+	ingressUncompressedMeter = metrics.NewMeter("p2p/InboundUncompressed")
+	ingressCompressionHist   = metrics.NewHistogram("p2p/InboundCompression")
+	egressUncompressedMeter  = metrics.NewMeter("p2p/OutboundUncompressed")
+	egressCompressionHist    = metrics.NewHistogram("p2p/OutboundCompression")
+
+	// Outcomes of dial attempts.
+	dialSuccessMeter         = metrics.NewMeter("p2p/DialSuccess")
+	dialUnresolvedMeter      = metrics.NewMeter("p2p/DialUnresolved")
+	dialConnectionErrorMeter = metrics.NewMeter("p2p/DialConnectionError")
+	dialHandshakeErrorMeter  = metrics.NewMeter("p2p/DialHandshakeError")
+	dialTooManyPeersMeter    = metrics.NewMeter("p2p/DialTooManyPeers")
+	dialRejectedMeter        = metrics.NewMeter("p2p/DialRejected")
 )
 
+// markDialResult bumps the meter counting dial attempts with the outcome err.
+func markDialResult(err error) {
+	switch reason := err.(type) {
+	case nil:
+		dialSuccessMeter.Mark(1)
+	case *dialError:
+		dialConnectionErrorMeter.Mark(1)
+	case DiscReason:
+		if reason == DiscTooManyPeers {
+			dialTooManyPeersMeter.Mark(1)
+		} else {
+			dialRejectedMeter.Mark(1)
+		}
+	default:
+		if err == errUnresolved {
+			dialUnresolvedMeter.Mark(1)
+		} else {
+			dialHandshakeErrorMeter.Mark(1)
+		}
+	}
+}
+
+// compressionRatio returns the size of a compressed payload in percent of the
+// original one, used for the compression histograms.
+func compressionRatio(compressed, original int) int64 {
+	if original == 0 {
+		return 100
+	}
+	return int64(compressed) * 100 / int64(original)
+}
+
 // meteredConn is a wrapper around a network TCP connection that meters both the
 // inbound and outbound network traffic.
 type meteredConn struct {
@@ -41,8 +87,9 @@ type meteredConn struct {
 // egress connection meter. If the metrics system is disabled, this function
 // returns the original object.
 func newMeteredConn(conn net.Conn, ingress bool) net.Conn {
-	// Short circuit if metrics are disabled
-	if !metrics.Enabled {
+	// Short circuit if metrics are disabled or the connection is not a TCP one
+	tcp, ok := conn.(*net.TCPConn)
+	if !metrics.Enabled || !ok {
 		return conn
 	}
 	// Otherwise bump the connection counters and wrap the connection
@@ -51,7 +98,7 @@ func newMeteredConn(conn net.Conn, ingress bool) net.Conn {
 	} else {
 		egressConnectMeter.Mark(1)
 	}
-	return &meteredConn{conn.(*net.TCPConn)}
+	return &meteredConn{tcp}
 }
 
 // Read delegates a network read to the underlying connection, bumping the ingress
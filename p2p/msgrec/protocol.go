@@ -0,0 +1,81 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package msgrec
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// Protocol returns a copy of proto whose sessions are recorded. The protocol
+// is expected to have the name the recorder was created with.
+func (r *Recorder) Protocol(proto p2p.Protocol) p2p.Protocol {
+	run, version := proto.Run, proto.Version
+	proto.Run = func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+		id := p.ID()
+		r.peerStart(id, version)
+		err := run(p, &recordingRW{rw: rw, rec: r, id: id})
+		r.peerEnd(id, err)
+		return err
+	}
+	return proto
+}
+
+// recordingRW is a MsgReadWriter recording all messages passing it.
+type recordingRW struct {
+	rw  p2p.MsgReadWriter
+	rec *Recorder
+	id  discover.NodeID
+}
+
+// ReadMsg reads a message and records it with the time it was received. The
+// payload is buffered so it can be read by the protocol as usual.
+func (rw *recordingRW) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.rw.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	payload := make([]byte, msg.Size)
+	if _, err := io.ReadFull(msg.Payload, payload); err != nil {
+		return msg, err
+	}
+	received := msg.ReceivedAt
+	if received.IsZero() {
+		received = time.Now()
+	}
+	rw.rec.message(RecordMsgIn, rw.id, msg.Code, payload, received)
+
+	msg.Payload = bytes.NewReader(payload)
+	return msg, nil
+}
+
+// WriteMsg records a message and sends it. Messages are recorded before they
+// are sent so a response can never precede its request in the recording.
+func (rw *recordingRW) WriteMsg(msg p2p.Msg) error {
+	payload := make([]byte, msg.Size)
+	if _, err := io.ReadFull(msg.Payload, payload); err != nil {
+		return err
+	}
+	rw.rec.message(RecordMsgOut, rw.id, msg.Code, payload, time.Now())
+
+	msg.Payload = bytes.NewReader(payload)
+	return rw.rw.WriteMsg(msg)
+}
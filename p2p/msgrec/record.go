@@ -0,0 +1,304 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package msgrec records the messages of a p2p protocol and replays them.
+//
+// A Recorder wraps a p2p.Protocol and writes every message sent or received by
+// it, along with the peer and time, to a recording. Replay feeds the inbound
+// messages of a recording back into a protocol implementation in their
+// original order, comparing the messages it sends with the recorded ones.
+//
+// A recording is a stream of RLP lists: a header naming the protocol, followed
+// by one entry per peer connect, message and peer disconnect. Peers are
+// referred to by the index of their first connect entry to keep entries small.
+package msgrec
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+const (
+	formatMagic   = "p2p-msgrec"
+	formatVersion = 1
+)
+
+var (
+	errBadMagic   = errors.New("not a message recording")
+	errBadVersion = errors.New("unsupported recording version")
+	errBadPeer    = errors.New("entry refers to unknown peer")
+	errBadKind    = errors.New("unknown entry kind")
+
+	errRecorderClosed = errors.New("recorder closed")
+)
+
+// RecordKind is the type of a recording entry.
+type RecordKind uint
+
+const (
+	// RecordPeerStart marks the start of a protocol session with a peer.
+	RecordPeerStart RecordKind = iota
+
+	// RecordMsgIn is a message received from a peer.
+	RecordMsgIn
+
+	// RecordMsgOut is a message sent to a peer.
+	RecordMsgOut
+
+	// RecordPeerEnd marks the end of a protocol session with a peer.
+	RecordPeerEnd
+)
+
+func (k RecordKind) String() string {
+	switch k {
+	case RecordPeerStart:
+		return "start"
+	case RecordMsgIn:
+		return "in"
+	case RecordMsgOut:
+		return "out"
+	case RecordPeerEnd:
+		return "end"
+	default:
+		return fmt.Sprintf("kind(%d)", uint(k))
+	}
+}
+
+// Record is an entry of a recording.
+type Record struct {
+	Kind    RecordKind
+	Time    time.Time
+	Peer    discover.NodeID
+	Version uint   // protocol version negotiated with the peer
+	Code    uint64 // message code, only set for messages
+	Payload []byte // message payload, or the session error for RecordPeerEnd
+}
+
+// header is the first RLP list of a recording.
+type header struct {
+	Magic    string
+	Version  uint
+	Protocol string
+	Start    uint64 // unix time in nanoseconds
+}
+
+// entry is the encoding of a Record. For RecordPeerStart entries, Code holds
+// the protocol version and Payload the node ID of the peer.
+type entry struct {
+	Kind    uint
+	Offset  uint64 // nanoseconds since the start of the recording
+	Peer    uint
+	Code    uint64
+	Payload []byte
+}
+
+// Recorder writes the messages of a protocol to a recording. It is safe for
+// concurrent use by the sessions of multiple peers.
+type Recorder struct {
+	protocol string
+	start    time.Time
+
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	peers  map[discover.NodeID]uint
+	err    error // sticky write error, recording stops after the first one
+}
+
+// NewRecorder creates a recorder of the given protocol writing to w.
+func NewRecorder(w io.Writer, protocol string) (*Recorder, error) {
+	r := &Recorder{
+		protocol: protocol,
+		start:    time.Now(),
+		w:        bufio.NewWriter(w),
+		peers:    make(map[discover.NodeID]uint),
+	}
+	if c, ok := w.(io.Closer); ok {
+		r.closer = c
+	}
+	hdr := header{Magic: formatMagic, Version: formatVersion, Protocol: protocol, Start: uint64(r.start.UnixNano())}
+	if err := rlp.Encode(r.w, &hdr); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateRecorder creates a recorder of the given protocol writing to a new
+// file.
+func CreateRecorder(file string, protocol string) (*Recorder, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewRecorder(f, protocol)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close flushes the recording and closes the underlying writer if it is an
+// io.Closer. Messages passing the recorder afterwards are not recorded.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == errRecorderClosed {
+		return nil
+	}
+	err := r.w.Flush()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	r.err = errRecorderClosed
+	return err
+}
+
+// Err returns the error which stopped the recording, if any.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == errRecorderClosed {
+		return nil
+	}
+	return r.err
+}
+
+// peerStart writes the start of a session, assigning an index to new peers.
+func (r *Recorder) peerStart(id discover.NodeID, version uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index, ok := r.peers[id]
+	if !ok {
+		index = uint(len(r.peers))
+		r.peers[id] = index
+	}
+	r.writeEntry(&entry{Kind: uint(RecordPeerStart), Peer: index, Code: uint64(version), Payload: id[:]}, time.Now())
+}
+
+// peerEnd writes the end of a session and flushes the recording.
+func (r *Recorder) peerEnd(id discover.NodeID, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reason []byte
+	if err != nil {
+		reason = []byte(err.Error())
+	}
+	r.writeEntry(&entry{Kind: uint(RecordPeerEnd), Peer: r.peers[id], Payload: reason}, time.Now())
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+}
+
+// message writes a message entry.
+func (r *Recorder) message(kind RecordKind, id discover.NodeID, code uint64, payload []byte, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writeEntry(&entry{Kind: uint(kind), Peer: r.peers[id], Code: code, Payload: payload}, t)
+}
+
+func (r *Recorder) writeEntry(e *entry, t time.Time) {
+	if r.err != nil {
+		return
+	}
+	if t.After(r.start) {
+		e.Offset = uint64(t.Sub(r.start))
+	}
+	r.err = rlp.Encode(r.w, e)
+}
+
+// Reader reads the entries of a recording.
+type Reader struct {
+	Protocol string    // protocol of the recording
+	Start    time.Time // time the recording was started
+
+	s        *rlp.Stream
+	peers    []discover.NodeID
+	versions []uint
+}
+
+// NewReader creates a reader of the recording in r, reading its header.
+func NewReader(r io.Reader) (*Reader, error) {
+	s := rlp.NewStream(bufio.NewReader(r), 0)
+	var hdr header
+	if err := s.Decode(&hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Magic != formatMagic {
+		return nil, errBadMagic
+	}
+	if hdr.Version != formatVersion {
+		return nil, errBadVersion
+	}
+	return &Reader{
+		Protocol: hdr.Protocol,
+		Start:    time.Unix(0, int64(hdr.Start)),
+		s:        s,
+	}, nil
+}
+
+// Next returns the next entry of the recording. At the end of the recording
+// it returns io.EOF.
+func (r *Reader) Next() (*Record, error) {
+	var e entry
+	if err := r.s.Decode(&e); err != nil {
+		return nil, err
+	}
+	rec := &Record{
+		Kind: RecordKind(e.Kind),
+		Time: r.Start.Add(time.Duration(e.Offset)),
+	}
+	switch rec.Kind {
+	case RecordPeerStart:
+		if len(e.Payload) != len(rec.Peer) {
+			return nil, fmt.Errorf("invalid peer ID length %d", len(e.Payload))
+		}
+		copy(rec.Peer[:], e.Payload)
+		switch {
+		case e.Peer == uint(len(r.peers)):
+			r.peers = append(r.peers, rec.Peer)
+			r.versions = append(r.versions, uint(e.Code))
+		case e.Peer < uint(len(r.peers)) && r.peers[e.Peer] == rec.Peer:
+			r.versions[e.Peer] = uint(e.Code)
+		default:
+			return nil, errBadPeer
+		}
+	case RecordMsgIn, RecordMsgOut, RecordPeerEnd:
+		if e.Peer >= uint(len(r.peers)) {
+			return nil, errBadPeer
+		}
+		rec.Peer = r.peers[e.Peer]
+		rec.Code = e.Code
+		rec.Payload = e.Payload
+	default:
+		return nil, errBadKind
+	}
+	rec.Version = r.versions[e.Peer]
+	return rec, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package msgrec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/rlp"
+)
+
+const (
+	helloMsg = iota
+	requestMsg
+	responseMsg
+	quitMsg
+)
+
+var errQuit = errors.New("quit")
+
+// pingProtocol greets every peer and answers requests for a number with its
+// successor, plus the given offset.
+func pingProtocol(offset uint) p2p.Protocol {
+	return p2p.Protocol{
+		Name:    "ping",
+		Version: 1,
+		Length:  4,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			if err := p2p.Send(rw, helloMsg, "hello"); err != nil {
+				return err
+			}
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				switch msg.Code {
+				case requestMsg:
+					var n uint
+					if err := msg.Decode(&n); err != nil {
+						return err
+					}
+					if err := p2p.Send(rw, responseMsg, n+1+offset); err != nil {
+						return err
+					}
+				case quitMsg:
+					return errQuit
+				default:
+					msg.Discard()
+				}
+			}
+		},
+	}
+}
+
+// recordPingSession records a session of the ping protocol in which the peer
+// sends the given requests.
+func recordPingSession(t *testing.T, id discover.NodeID, requests ...uint) []byte {
+	buf := new(bytes.Buffer)
+	rec, err := NewRecorder(buf, "ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto := rec.Protocol(pingProtocol(0))
+
+	local, remote := p2p.MsgPipe()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- proto.Run(p2p.NewPeer(id, "test", nil), local)
+	}()
+	if err := p2p.ExpectMsg(remote, helloMsg, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range requests {
+		p2p.Send(remote, requestMsg, n)
+		if err := p2p.ExpectMsg(remote, responseMsg, n+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p2p.Send(remote, quitMsg, []interface{}{})
+	if err := <-errc; err != errQuit {
+		t.Fatalf("protocol error mismatch: have %v, want %v", err, errQuit)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRecordSession(t *testing.T) {
+	id := discover.NodeID{1}
+	r, err := NewReader(bytes.NewReader(recordPingSession(t, id, 5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Protocol != "ping" {
+		t.Errorf("protocol mismatch: have %q, want %q", r.Protocol, "ping")
+	}
+	want := []struct {
+		kind    RecordKind
+		code    uint64
+		payload interface{}
+	}{
+		{RecordPeerStart, 0, nil},
+		{RecordMsgOut, helloMsg, "hello"},
+		{RecordMsgIn, requestMsg, uint(5)},
+		{RecordMsgOut, responseMsg, uint(6)},
+		{RecordMsgIn, quitMsg, []interface{}{}},
+		{RecordPeerEnd, 0, nil},
+	}
+	var last *Record
+	for i, w := range want {
+		rec, err := r.Next()
+		if err != nil {
+			t.Fatalf("entry %d: %v", i, err)
+		}
+		if rec.Kind != w.kind || rec.Code != w.code || rec.Peer != id || rec.Version != 1 {
+			t.Errorf("entry %d mismatch: have %v code %d peer %x v%d, want %v code %d", i, rec.Kind, rec.Code, rec.Peer[:8], rec.Version, w.kind, w.code)
+		}
+		if w.payload != nil {
+			enc, _ := rlp.EncodeToBytes(w.payload)
+			if !bytes.Equal(rec.Payload, enc) {
+				t.Errorf("entry %d payload mismatch: have %x, want %x", i, rec.Payload, enc)
+			}
+		}
+		if last != nil && rec.Time.Before(last.Time) {
+			t.Errorf("entry %d recorded before its predecessor", i)
+		}
+		last = rec
+	}
+	if string(last.Payload) != errQuit.Error() {
+		t.Errorf("session error mismatch: have %q, want %q", last.Payload, errQuit.Error())
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected EOF after last entry, got %v", err)
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	if _, err := NewReader(strings.NewReader("")); err == nil {
+		t.Error("empty recording accepted")
+	}
+	blob, _ := rlp.EncodeToBytes(&header{Magic: "foo", Version: formatVersion})
+	if _, err := NewReader(bytes.NewReader(blob)); err != errBadMagic {
+		t.Errorf("error mismatch: have %v, want %v", err, errBadMagic)
+	}
+
+	// Messages of peers without a start entry are rejected
+	buf := new(bytes.Buffer)
+	rlp.Encode(buf, &header{Magic: formatMagic, Version: formatVersion, Protocol: "ping"})
+	rlp.Encode(buf, &entry{Kind: uint(RecordMsgIn), Peer: 0})
+	r, err := NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Next(); err != errBadPeer {
+		t.Errorf("error mismatch: have %v, want %v", err, errBadPeer)
+	}
+}
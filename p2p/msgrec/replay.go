@@ -0,0 +1,342 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package msgrec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// DefaultReplayTimeout is the default value of ReplayConfig.Timeout.
+const DefaultReplayTimeout = 5 * time.Second
+
+var errDeliveryTimeout = errors.New("timed out delivering message")
+
+// ReplayConfig are the settings of a replay.
+type ReplayConfig struct {
+	// Realtime reproduces the recorded delays between messages. Otherwise
+	// inbound messages are delivered as soon as the protocol has sent the
+	// messages preceding them in the recording.
+	Realtime bool
+
+	// Timeout is the time allowed for the protocol to send an expected message
+	// or to accept an inbound one. The default is five seconds.
+	Timeout time.Duration
+}
+
+// Session is the result of replaying the session of a single peer.
+type Session struct {
+	Peer    discover.NodeID
+	Version uint
+
+	Delivered int      // inbound messages accepted by the protocol
+	Expected  []uint64 // codes of the recorded outbound messages
+	Sent      []uint64 // codes of the messages sent by the protocol during replay
+
+	Err       error  // error which ended the session early, nil if it ran to the end
+	RecordErr string // error which ended the recorded session
+}
+
+// Diverged returns the index of the first sent message which doesn't match the
+// recording, or -1 if the protocol behaved as recorded.
+func (s *Session) Diverged() int {
+	for i := 0; i < len(s.Expected) && i < len(s.Sent); i++ {
+		if s.Expected[i] != s.Sent[i] {
+			return i
+		}
+	}
+	if len(s.Expected) != len(s.Sent) {
+		if len(s.Expected) < len(s.Sent) {
+			return len(s.Expected)
+		}
+		return len(s.Sent)
+	}
+	return -1
+}
+
+// String returns a summary of the session.
+func (s *Session) String() string {
+	status := "as recorded"
+	if i := s.Diverged(); i >= 0 {
+		status = fmt.Sprintf("diverged at sent message %d", i)
+	}
+	return fmt.Sprintf("peer %x v%d: delivered %d, sent %d/%d, %s", s.Peer[:8], s.Version, s.Delivered, len(s.Sent), len(s.Expected), status)
+}
+
+// Replay feeds the inbound messages of a recording into the protocols. A
+// session of the protocol with the recorded version is run for every recorded
+// peer session, over a message pipe on which the recorded messages of the
+// peer are delivered in their original order.
+//
+// Before a message is delivered, the protocol is given time to send the
+// messages which preceded it in the recording, so requests and responses
+// happen in the recorded order regardless of timing. The messages sent by
+// the protocol are collected and can be compared with the recording.
+func Replay(r *Reader, protocols []p2p.Protocol, config ReplayConfig) ([]*Session, error) {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultReplayTimeout
+	}
+	rp := &replay{
+		config:    config,
+		protocols: protocols,
+		protoName: r.Protocol,
+		active:    make(map[discover.NodeID]*replaySession),
+	}
+	err := rp.run(r)
+	return rp.sessions(), err
+}
+
+type replay struct {
+	config    ReplayConfig
+	protocols []p2p.Protocol
+	protoName string
+
+	started time.Time // time the first entry was replayed
+	first   time.Time // time of the first entry in the recording
+
+	active map[discover.NodeID]*replaySession
+	all    []*replaySession
+}
+
+func (rp *replay) run(r *Reader) error {
+	var err error
+	for {
+		var rec *Record
+		if rec, err = r.Next(); err != nil {
+			break
+		}
+		rp.pace(rec.Time)
+		switch rec.Kind {
+		case RecordPeerStart:
+			if s := rp.active[rec.Peer]; s != nil {
+				s.end()
+			}
+			s, err := rp.startSession(rec.Peer, rec.Version)
+			if err != nil {
+				rp.endAll()
+				return err
+			}
+			rp.active[rec.Peer] = s
+		case RecordMsgIn:
+			if s := rp.active[rec.Peer]; s != nil {
+				s.deliver(rec.Code, rec.Payload)
+			}
+		case RecordMsgOut:
+			if s := rp.active[rec.Peer]; s != nil {
+				s.expect(rec.Code)
+			}
+		case RecordPeerEnd:
+			if s := rp.active[rec.Peer]; s != nil {
+				s.mu.Lock()
+				s.result.RecordErr = string(rec.Payload)
+				s.mu.Unlock()
+				s.end()
+				delete(rp.active, rec.Peer)
+			}
+		}
+	}
+	rp.endAll()
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// pace waits until the recorded time of an entry has passed in realtime mode.
+func (rp *replay) pace(t time.Time) {
+	if rp.started.IsZero() {
+		rp.started, rp.first = time.Now(), t
+		return
+	}
+	if rp.config.Realtime {
+		if wait := t.Sub(rp.first) - time.Since(rp.started); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+}
+
+// startSession runs the protocol for a recorded peer session.
+func (rp *replay) startSession(id discover.NodeID, version uint) (*replaySession, error) {
+	var proto *p2p.Protocol
+	for i := range rp.protocols {
+		if rp.protocols[i].Name == rp.protoName && rp.protocols[i].Version == version {
+			proto = &rp.protocols[i]
+			break
+		}
+	}
+	if proto == nil {
+		return nil, fmt.Errorf("no protocol implementation for %s/%d", rp.protoName, version)
+	}
+	local, remote := p2p.MsgPipe()
+	s := &replaySession{
+		result:  &Session{Peer: id, Version: version},
+		timeout: rp.config.Timeout,
+		remote:  remote,
+		sent:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	peer := p2p.NewPeer(id, "replay", []p2p.Cap{{Name: proto.Name, Version: proto.Version}})
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		err := proto.Run(peer, local)
+		s.mu.Lock()
+		if !s.ended {
+			s.result.Err = err
+		}
+		s.mu.Unlock()
+		close(s.done)
+		local.Close()
+	}()
+	go s.collect()
+
+	rp.all = append(rp.all, s)
+	log.Debug("Replaying peer session", "id", id, "proto", fmt.Sprintf("%s/%d", proto.Name, proto.Version))
+	return s, nil
+}
+
+// endAll ends the running sessions.
+func (rp *replay) endAll() {
+	for id, s := range rp.active {
+		s.end()
+		delete(rp.active, id)
+	}
+}
+
+func (rp *replay) sessions() []*Session {
+	sessions := make([]*Session, len(rp.all))
+	for i, s := range rp.all {
+		sessions[i] = s.result
+	}
+	return sessions
+}
+
+// replaySession is a protocol session of a recorded peer.
+type replaySession struct {
+	timeout time.Duration
+	remote  *p2p.MsgPipeRW
+	sent    chan struct{} // signalled whenever the protocol sends a message
+	done    chan struct{} // closed when the protocol returns
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	result *Session
+	ended  bool // set when the replay closes the session
+}
+
+// collect reads the messages sent by the protocol until the pipe is closed.
+func (s *replaySession) collect() {
+	defer s.wg.Done()
+	for {
+		msg, err := s.remote.ReadMsg()
+		if err != nil {
+			return
+		}
+		msg.Discard()
+
+		s.mu.Lock()
+		s.result.Sent = append(s.result.Sent, msg.Code)
+		s.mu.Unlock()
+		select {
+		case s.sent <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// expect adds a recorded outbound message.
+func (s *replaySession) expect(code uint64) {
+	s.mu.Lock()
+	s.result.Expected = append(s.result.Expected, code)
+	s.mu.Unlock()
+}
+
+// awaitSent waits until the protocol has sent as many messages as expected,
+// the protocol returns or the timeout expires.
+func (s *replaySession) awaitSent() {
+	timeout := time.NewTimer(s.timeout)
+	defer timeout.Stop()
+	for {
+		s.mu.Lock()
+		caughtUp := len(s.result.Sent) >= len(s.result.Expected)
+		s.mu.Unlock()
+		if caughtUp {
+			return
+		}
+		select {
+		case <-s.sent:
+		case <-s.done:
+			return
+		case <-timeout.C:
+			return
+		}
+	}
+}
+
+// deliver feeds an inbound message to the protocol once it has sent the
+// messages preceding it.
+func (s *replaySession) deliver(code uint64, payload []byte) {
+	s.awaitSent()
+
+	msg := p2p.Msg{
+		Code:       code,
+		Size:       uint32(len(payload)),
+		Payload:    bytes.NewReader(payload),
+		ReceivedAt: time.Now(),
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- s.remote.WriteMsg(msg) }()
+	select {
+	case err := <-errc:
+		if err == nil {
+			s.mu.Lock()
+			s.result.Delivered++
+			s.mu.Unlock()
+		}
+	case <-time.After(s.timeout):
+		log.Debug("Replayed message not accepted", "id", s.result.Peer, "code", code)
+		s.close(errDeliveryTimeout)
+		<-errc
+	}
+}
+
+// end waits for the remaining expected messages, then closes the session and
+// waits for the protocol to return.
+func (s *replaySession) end() {
+	s.awaitSent()
+	s.close(nil)
+	s.wg.Wait()
+}
+
+// close closes the pipe of the session, recording err as the reason.
+func (s *replaySession) close(err error) {
+	s.mu.Lock()
+	if !s.ended && s.result.Err == nil {
+		s.result.Err = err
+	}
+	s.ended = true
+	s.mu.Unlock()
+	s.remote.Close()
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package msgrec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+func replayPing(t *testing.T, recording []byte, protocols ...p2p.Protocol) ([]*Session, error) {
+	r, err := NewReader(bytes.NewReader(recording))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Replay(r, protocols, ReplayConfig{Timeout: time.Second})
+}
+
+// Tests that replaying a recording into the recorded implementation
+// reproduces the recorded session.
+func TestReplaySession(t *testing.T) {
+	id := discover.NodeID{1}
+	sessions, err := replayPing(t, recordPingSession(t, id, 1, 2, 3), pingProtocol(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("wrong number of sessions: have %d, want 1", len(sessions))
+	}
+	s := sessions[0]
+	if s.Peer != id || s.Version != 1 {
+		t.Errorf("session peer mismatch: %v", s)
+	}
+	want := []uint64{helloMsg, responseMsg, responseMsg, responseMsg}
+	if !reflect.DeepEqual(s.Sent, want) || !reflect.DeepEqual(s.Expected, want) {
+		t.Errorf("sent messages mismatch: have %v, recorded %v, want %v", s.Sent, s.Expected, want)
+	}
+	if s.Delivered != 4 {
+		t.Errorf("wrong number of delivered messages: have %d, want 4", s.Delivered)
+	}
+	if s.Diverged() != -1 {
+		t.Errorf("replay diverged: %v", s)
+	}
+	if s.Err != errQuit || s.RecordErr != errQuit.Error() {
+		t.Errorf("session error mismatch: have %v (recorded %q), want %v", s.Err, s.RecordErr, errQuit)
+	}
+}
+
+// Tests that the replay reports implementations behaving differently from the
+// recorded one.
+func TestReplayDivergence(t *testing.T) {
+	recording := recordPingSession(t, discover.NodeID{1}, 1)
+
+	// Replies with a different code
+	diverging := pingProtocol(0)
+	run := diverging.Run
+	diverging.Run = func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+		p2p.Send(rw, quitMsg, []interface{}{})
+		return run(p, rw)
+	}
+	sessions, err := replayPing(t, recording, diverging)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := sessions[0].Diverged(); i != 0 {
+		t.Errorf("divergence mismatch: have %d, want 0", i)
+	}
+
+	// No implementation of the recorded version
+	other := pingProtocol(0)
+	other.Version = 2
+	if _, err := replayPing(t, recording, other); err == nil {
+		t.Error("replay without matching protocol version succeeded")
+	}
+}
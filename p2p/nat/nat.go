@@ -25,8 +25,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/jackpal/go-nat-pmp"
 )
 
@@ -102,13 +101,13 @@ func Map(m Interface, c chan struct{}, protocol string, extport, intport int, na
 	refresh := time.NewTimer(mapUpdateInterval)
 	defer func() {
 		refresh.Stop()
-		glog.V(logger.Debug).Infof("deleting port mapping: %s %d -> %d (%s) using %s\n", protocol, extport, intport, name, m)
+		log.Debug("Deleting port mapping", "proto", protocol, "extport", extport, "intport", intport, "interface", m)
 		m.DeleteMapping(protocol, extport, intport)
 	}()
 	if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-		glog.V(logger.Debug).Infof("network port %s:%d could not be mapped: %v\n", protocol, intport, err)
+		log.Debug("Couldn't add port mapping", "proto", protocol, "extport", extport, "intport", intport, "interface", m, "err", err)
 	} else {
-		glog.V(logger.Info).Infof("mapped network port %s:%d -> %d (%s) using %s\n", protocol, extport, intport, name, m)
+		log.Info("Mapped network port", "proto", protocol, "extport", extport, "intport", intport, "interface", m)
 	}
 	for {
 		select {
@@ -117,9 +116,9 @@ func Map(m Interface, c chan struct{}, protocol string, extport, intport int, na
 				return
 			}
 		case <-refresh.C:
-			glog.V(logger.Detail).Infof("refresh port mapping %s:%d -> %d (%s) using %s\n", protocol, extport, intport, name, m)
+			log.Trace("Refreshing port mapping", "proto", protocol, "extport", extport, "intport", intport, "interface", m)
 			if err := m.AddMapping(protocol, extport, intport, name, mapTimeout); err != nil {
-				glog.V(logger.Debug).Infof("network port %s:%d could not be mapped: %v\n", protocol, intport, err)
+				log.Debug("Couldn't add port mapping", "proto", protocol, "extport", extport, "intport", intport, "interface", m, "err", err)
 			}
 			refresh.Reset(mapUpdateInterval)
 		}
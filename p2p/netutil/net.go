@@ -84,6 +84,25 @@ func ParseNetlist(s string) (*Netlist, error) {
 	return &l, nil
 }
 
+// MarshalText encodes the list as comma-separated CIDR masks.
+func (l Netlist) MarshalText() ([]byte, error) {
+	masks := make([]string, len(l))
+	for i, n := range l {
+		masks[i] = n.String()
+	}
+	return []byte(strings.Join(masks, ",")), nil
+}
+
+// UnmarshalText parses a comma-separated list of CIDR masks.
+func (l *Netlist) UnmarshalText(text []byte) error {
+	dec, err := ParseNetlist(string(text))
+	if err != nil {
+		return err
+	}
+	*l = *dec
+	return nil
+}
+
 // Add parses a CIDR mask and appends it to the list. It panics for invalid masks and is
 // intended to be used for setting up static lists.
 func (l *Netlist) Add(cidr string) {
@@ -25,17 +25,19 @@ import (
 	"sync"
 	"time"
 
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 	"github.com/EarthDollar/go-earthdollar/rlp"
 )
 
 const (
-	baseProtocolVersion    = 4
+	baseProtocolVersion    = 5
 	baseProtocolLength     = uint64(16)
 	baseProtocolMaxMsgSize = 2 * 1024
 
+	snappyProtocolVersion = 5 // first base protocol version compressing the message payloads
+
 	pingInterval = 15 * time.Second
 )
 
@@ -65,11 +67,15 @@ type protoHandshake struct {
 type Peer struct {
 	rw      *conn
 	running map[string]*protoRW
+	log     log.Logger
 
 	wg       sync.WaitGroup
 	protoErr chan error
 	closed   chan struct{}
 	disc     chan DiscReason
+
+	report func(delta int, reason string) // reputation tracker of the server, nil for test peers
+	events *event.TypeMux                 // receives message events, nil unless enabled by Config.EnableMsgEvents
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -116,6 +122,16 @@ func (p *Peer) Disconnect(reason DiscReason) {
 	}
 }
 
+// Report adjusts the reputation of the peer by delta, which should be one of
+// the Rep constants or a multiple of them. Protocols report useful and harmful
+// behaviour through it; peers whose reputation drops too low are disconnected
+// and banned for a while.
+func (p *Peer) Report(delta int, reason string) {
+	if p.report != nil {
+		p.report(delta, reason)
+	}
+}
+
 // String implements fmt.Stringer.
 func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x %v", p.rw.id[:8], p.RemoteAddr())
@@ -129,10 +145,17 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 		disc:     make(chan DiscReason),
 		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
 		closed:   make(chan struct{}),
+		log:      log.New("id", fmt.Sprintf("%x", conn.id[:8]), "conn", conn.loadFlags()),
 	}
 	return p
 }
 
+// Log returns the logger of the peer, which adds the peer id and connection
+// type to all records.
+func (p *Peer) Log() log.Logger {
+	return p.log
+}
+
 func (p *Peer) run() DiscReason {
 	var (
 		writeStart = make(chan struct{}, 1)
@@ -157,27 +180,27 @@ loop:
 			// A write finished. Allow the next write to start if
 			// there was no error.
 			if err != nil {
-				glog.V(logger.Detail).Infof("%v: write error: %v\n", p, err)
+				p.log.Trace("Write error", "err", err)
 				reason = DiscNetworkError
 				break loop
 			}
 			writeStart <- struct{}{}
 		case err := <-readErr:
 			if r, ok := err.(DiscReason); ok {
-				glog.V(logger.Debug).Infof("%v: remote requested disconnect: %v\n", p, r)
+				p.log.Debug("Remote requested disconnect", "reason", r)
 				requested = true
 				reason = r
 			} else {
-				glog.V(logger.Detail).Infof("%v: read error: %v\n", p, err)
+				p.log.Trace("Read error", "err", err)
 				reason = DiscNetworkError
 			}
 			break loop
 		case err := <-p.protoErr:
 			reason = discReasonForError(err)
-			glog.V(logger.Debug).Infof("%v: protocol error: %v (%v)\n", p, err, reason)
+			p.log.Debug("Protocol error", "err", err, "reason", reason)
 			break loop
 		case reason = <-p.disc:
-			glog.V(logger.Debug).Infof("%v: locally requested disconnect: %v\n", p, reason)
+			p.log.Debug("Local requested disconnect", "reason", reason)
 			break loop
 		}
 	}
@@ -298,14 +321,18 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
-		glog.V(logger.Detail).Infof("%v: Starting protocol %s/%d\n", p, proto.Name, proto.Version)
+		p.log.Trace("Starting protocol", "name", proto.Name, "version", proto.Version)
+		var rw MsgReadWriter = proto
+		if p.events != nil {
+			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name)
+		}
 		go func() {
-			err := proto.Run(p, proto)
+			err := proto.Run(p, rw)
 			if err == nil {
-				glog.V(logger.Detail).Infof("%v: Protocol %s/%d returned\n", p, proto.Name, proto.Version)
+				p.log.Trace("Protocol returned", "name", proto.Name, "version", proto.Version)
 				err = errors.New("protocol returned")
 			} else if err != io.EOF {
-				glog.V(logger.Detail).Infof("%v: Protocol %s/%d error: %v\n", p, proto.Name, proto.Version, err)
+				p.log.Trace("Protocol failed", "name", proto.Name, "version", proto.Version, "err", err)
 			}
 			p.protoErr <- err
 			p.wg.Done()
@@ -367,9 +394,10 @@ func (rw *protoRW) ReadMsg() (Msg, error) {
 // peer. Sub-protocol independent fields are contained and initialized here, with
 // protocol specifics delegated to all connected sub-protocols.
 type PeerInfo struct {
-	ID      string   `json:"id"`   // Unique node identifier (also the encryption key)
-	Name    string   `json:"name"` // Name of the node, including client type, version, OS, custom data
-	Caps    []string `json:"caps"` // Sum-protocols advertised by this particular peer
+	ID      string   `json:"id"`            // Unique node identifier (also the encryption key)
+	Name    string   `json:"name"`          // Name of the node, including client type, version, OS, custom data
+	Caps    []string `json:"caps"`          // Sum-protocols advertised by this particular peer
+	ENR     string   `json:"enr,omitempty"` // Node record of the peer, if fetched through discovery
 	Network struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 )
 
 // Protocol represents a P2P subprotocol implementation.
@@ -52,6 +53,16 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.NodeID) interface{}
+
+	// Attributes contains protocol specific entries for the node record,
+	// which remote nodes can inspect before dialing us.
+	Attributes []enr.Entry
+
+	// DialCandidate is an optional filter for dynamically dialed nodes. It is
+	// called with the node record of each candidate found by discovery (nil if
+	// the record is not available) and should return false for nodes that
+	// can't run the protocol, saving the dial slot for other nodes.
+	DialCandidate func(r *enr.Record) bool
 }
 
 func (p Protocol) cap() Cap {
@@ -0,0 +1,277 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// Reputation deltas of common events, reported by protocols through Peer.Report.
+const (
+	RepUseful         = 1    // The peer delivered useful data
+	RepUseless        = -5   // The peer stalled, timed out or delivered nothing useful
+	RepInvalidMessage = -25  // The peer sent a malformed or unexpected message
+	RepMalicious      = -150 // The peer misbehaved beyond doubt, e.g. propagated an invalid block
+)
+
+const (
+	reputationHalfLife = 10 * time.Minute // Time it takes for a score to decay to half its value
+	maxReputation      = 50               // Upper bound of scores, so goodwill can't offset malicious behaviour
+	banThreshold       = -100             // Score at or below which a peer is disconnected and banned
+	reputationBanTime  = 12 * time.Hour   // Duration of the bans caused by a low reputation
+	maxTrackedScores   = 4096             // Number of scores above which insignificant ones are dropped
+)
+
+// reputation tracks the behaviour scores of remote nodes. Scores decay towards
+// zero over time, so that past misbehaviour is eventually forgiven and past
+// usefulness eventually forgotten.
+type reputation struct {
+	lock   sync.Mutex
+	scores map[discover.NodeID]*score
+	now    func() time.Time // Overridable for testing
+}
+
+// score is the reputation of a single node at the time it was last updated.
+type score struct {
+	value   float64
+	updated time.Time
+}
+
+func newReputation() *reputation {
+	return &reputation{
+		scores: make(map[discover.NodeID]*score),
+		now:    time.Now,
+	}
+}
+
+// decayed returns the value of the score at the given time.
+func (s *score) decayed(now time.Time) float64 {
+	elapsed := now.Sub(s.updated)
+	if elapsed <= 0 {
+		return s.value
+	}
+	return s.value * math.Pow(0.5, float64(elapsed)/float64(reputationHalfLife))
+}
+
+// add adjusts the score of a node by delta and returns the new score.
+func (r *reputation) add(id discover.NodeID, delta int) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	s := r.scores[id]
+	if s == nil {
+		if len(r.scores) >= maxTrackedScores {
+			r.prune(now)
+		}
+		s = new(score)
+		r.scores[id] = s
+	}
+	s.value = math.Min(s.decayed(now)+float64(delta), maxReputation)
+	s.updated = now
+	return s.value
+}
+
+// get returns the current score of a node.
+func (r *reputation) get(id discover.NodeID) float64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if s := r.scores[id]; s != nil {
+		return s.decayed(r.now())
+	}
+	return 0
+}
+
+// reset forgets the score of a node.
+func (r *reputation) reset(id discover.NodeID) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.scores, id)
+}
+
+// prune drops the scores which decayed to insignificance. The lock must be held.
+func (r *reputation) prune(now time.Time) {
+	for id, s := range r.scores {
+		if math.Abs(s.decayed(now)) < 1 {
+			delete(r.scores, id)
+		}
+	}
+}
+
+// BanInfo represents a short summary of a node ban.
+type BanInfo struct {
+	ID      string    `json:"id"`           // Unique node identifier of the banned node
+	IP      string    `json:"ip,omitempty"` // IP address banned alongside the node, if known
+	Reason  string    `json:"reason"`       // Reason of the ban
+	Expires time.Time `json:"expires"`      // Time at which the ban is lifted
+}
+
+// banStore is implemented by discovery tables able to persist bans across
+// restarts in the node database.
+type banStore interface {
+	Bans() []discover.Ban
+	StoreBan(ban discover.Ban) error
+	DeleteBan(id discover.NodeID) error
+}
+
+// banList is the set of nodes and IP addresses which may not connect to the
+// server until their ban expires.
+type banList struct {
+	lock  sync.Mutex
+	bans  map[discover.NodeID]*discover.Ban
+	store banStore         // Persistent storage of the bans, nil if discovery is disabled
+	now   func() time.Time // Overridable for testing
+}
+
+// newBanList creates a ban list, loading the bans persisted in store (if any).
+func newBanList(store banStore) *banList {
+	b := &banList{
+		bans:  make(map[discover.NodeID]*discover.Ban),
+		store: store,
+		now:   time.Now,
+	}
+	if store != nil {
+		for _, ban := range store.Bans() {
+			ban := ban
+			b.bans[ban.ID] = &ban
+		}
+	}
+	return b
+}
+
+// add bans a node and, if given, its IP address for the given duration. An
+// existing ban of the node is replaced.
+func (b *banList) add(id discover.NodeID, ip net.IP, reason string, duration time.Duration) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if ip != nil && ip.IsUnspecified() {
+		ip = nil
+	}
+	ban := &discover.Ban{
+		ID:      id,
+		IP:      ip,
+		Reason:  reason,
+		Expires: uint64(b.now().Add(duration).Unix()),
+	}
+	b.bans[id] = ban
+	if b.store != nil {
+		return b.store.StoreBan(*ban)
+	}
+	return nil
+}
+
+// remove lifts the ban of a node, reporting whether it was banned.
+func (b *banList) remove(id discover.NodeID) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if _, ok := b.bans[id]; !ok {
+		return false, nil
+	}
+	delete(b.bans, id)
+	if b.store != nil {
+		return true, b.store.DeleteBan(id)
+	}
+	return true, nil
+}
+
+// banned reports whether a node is banned, either by its ID or by the given
+// IP address (which may be nil if unknown).
+func (b *banList) banned(id discover.NodeID, ip net.IP) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.expire()
+	if _, ok := b.bans[id]; ok {
+		return true
+	}
+	return ip != nil && b.bannedIP(ip)
+}
+
+// isBannedIP reports whether the given IP address is banned.
+func (b *banList) isBannedIP(ip net.IP) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.expire()
+	return b.bannedIP(ip)
+}
+
+// bannedIP reports whether any ban covers the given IP address. The lock must
+// be held.
+func (b *banList) bannedIP(ip net.IP) bool {
+	for _, ban := range b.bans {
+		if ban.IP != nil && ban.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// list returns a summary of all active bans, ordered by expiration.
+func (b *banList) list() []*BanInfo {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.expire()
+	infos := make(bansByExpiry, 0, len(b.bans))
+	for _, ban := range b.bans {
+		info := &BanInfo{
+			ID:      ban.ID.String(),
+			Reason:  ban.Reason,
+			Expires: time.Unix(int64(ban.Expires), 0),
+		}
+		if ban.IP != nil {
+			info.IP = ban.IP.String()
+		}
+		infos = append(infos, info)
+	}
+	sort.Sort(infos)
+	return infos
+}
+
+// expire drops the bans which have run out. The lock must be held. Expired bans
+// are left in the store, the node database drops them on its own.
+func (b *banList) expire() {
+	now := uint64(b.now().Unix())
+	for id, ban := range b.bans {
+		if ban.Expires <= now {
+			delete(b.bans, id)
+		}
+	}
+}
+
+// bansByExpiry implements sort.Interface to order bans by their expiration.
+type bansByExpiry []*BanInfo
+
+func (b bansByExpiry) Len() int      { return len(b) }
+func (b bansByExpiry) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b bansByExpiry) Less(i, j int) bool {
+	if !b[i].Expires.Equal(b[j].Expires) {
+		return b[i].Expires.Before(b[j].Expires)
+	}
+	return b[i].ID < b[j].ID
+}
@@ -0,0 +1,277 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+// Tests that reputation scores decay with the configured half-life and are
+// capped from above.
+func TestReputationDecay(t *testing.T) {
+	var (
+		r   = newReputation()
+		id  = randomID()
+		now = time.Unix(1000000, 0)
+	)
+	r.now = func() time.Time { return now }
+
+	if score := r.add(id, RepInvalidMessage*2); score != -50 {
+		t.Fatalf("score mismatch: have %v, want -50", score)
+	}
+	now = now.Add(reputationHalfLife)
+	if score := r.get(id); math.Abs(score+25) > 1e-9 {
+		t.Errorf("decayed score mismatch: have %v, want -25", score)
+	}
+	now = now.Add(reputationHalfLife)
+	if score := r.add(id, RepUseful); math.Abs(score+11.5) > 1e-9 {
+		t.Errorf("updated score mismatch: have %v, want -11.5", score)
+	}
+	for i := 0; i < 2*maxReputation; i++ {
+		r.add(id, RepUseful)
+	}
+	if score := r.get(id); score != maxReputation {
+		t.Errorf("score not capped: have %v, want %v", score, maxReputation)
+	}
+	// Malicious behaviour must cross the ban threshold even with full goodwill
+	if score := r.add(id, RepMalicious); score > banThreshold {
+		t.Errorf("malicious peer not below ban threshold: score %v", score)
+	}
+	r.reset(id)
+	if score := r.get(id); score != 0 {
+		t.Errorf("score not reset: have %v", score)
+	}
+}
+
+// Tests that insignificant scores are pruned once too many nodes are tracked.
+func TestReputationPrune(t *testing.T) {
+	var (
+		r   = newReputation()
+		now = time.Unix(1000000, 0)
+	)
+	r.now = func() time.Time { return now }
+
+	bad := randomID()
+	r.add(bad, RepMalicious)
+	for i := 0; i < maxTrackedScores-1; i++ {
+		r.add(randomID(), RepUseful)
+	}
+	now = now.Add(2 * reputationHalfLife)
+	r.add(randomID(), RepUseful)
+
+	if len(r.scores) != 2 {
+		t.Errorf("tracked scores mismatch after pruning: have %d, want 2", len(r.scores))
+	}
+	if r.get(bad) >= 0 {
+		t.Errorf("significant score pruned")
+	}
+}
+
+// memBanStore is an in-memory banStore.
+type memBanStore map[discover.NodeID]discover.Ban
+
+func (s memBanStore) Bans() []discover.Ban {
+	var bans []discover.Ban
+	for _, ban := range s {
+		bans = append(bans, ban)
+	}
+	return bans
+}
+func (s memBanStore) StoreBan(ban discover.Ban) error    { s[ban.ID] = ban; return nil }
+func (s memBanStore) DeleteBan(id discover.NodeID) error { delete(s, id); return nil }
+
+// Tests that bans apply to node IDs and IPs, expire and are persisted.
+func TestBanList(t *testing.T) {
+	var (
+		store   = make(memBanStore)
+		b       = newBanList(store)
+		now     = time.Unix(1000000, 0)
+		id      = randomID()
+		ip      = net.IP{10, 0, 0, 1}
+		other   = randomID()
+		otherIP = net.IP{10, 0, 0, 2}
+	)
+	b.now = func() time.Time { return now }
+
+	if err := b.add(id, ip, "test", time.Hour); err != nil {
+		t.Fatalf("failed to ban: %v", err)
+	}
+	if err := b.add(other, net.IPv4zero, "test", 2*time.Hour); err != nil {
+		t.Fatalf("failed to ban: %v", err)
+	}
+	switch {
+	case !b.banned(id, nil):
+		t.Error("node not banned by ID")
+	case !b.banned(randomID(), ip):
+		t.Error("node not banned by IP")
+	case !b.isBannedIP(ip):
+		t.Error("IP not banned")
+	case b.isBannedIP(otherIP) || b.isBannedIP(net.IPv4zero):
+		t.Error("unspecified or unrelated IP banned")
+	case b.banned(randomID(), otherIP):
+		t.Error("unrelated node banned")
+	}
+	if list := b.list(); len(list) != 2 || list[0].ID != id.String() || list[0].IP != ip.String() || list[1].IP != "" {
+		t.Errorf("ban list mismatch: %v", list)
+	}
+	// Bans must be restored from the store
+	restored := newBanList(store)
+	restored.now = b.now
+	if !restored.banned(id, nil) || !restored.banned(other, nil) {
+		t.Error("bans not restored from store")
+	}
+	// Expired bans must be lifted
+	now = now.Add(time.Hour)
+	if b.banned(id, ip) {
+		t.Error("expired ban still active")
+	}
+	if !b.banned(other, nil) {
+		t.Error("active ban lifted")
+	}
+	// Removed bans must be lifted and deleted from the store
+	if removed, err := b.remove(other); !removed || err != nil {
+		t.Errorf("failed to remove ban: removed %v, err %v", removed, err)
+	}
+	if removed, _ := b.remove(other); removed {
+		t.Error("ban removed twice")
+	}
+	if b.banned(other, nil) || len(store) != 1 {
+		t.Errorf("removed ban still active or stored: %v", store)
+	}
+}
+
+// Tests that banned nodes are not dialed.
+func TestDialStateBanned(t *testing.T) {
+	n := &discover.Node{ID: randomID(), IP: net.IP{10, 0, 0, 1}}
+	s := newDialState(nil, fakeTable{}, 0, nil)
+	if err := s.checkDial(n, nil); err != nil {
+		t.Fatalf("unexpected dial error: %v", err)
+	}
+	s.banned = func(*discover.Node) bool { return true }
+	if err := s.checkDial(n, nil); err != errBanned {
+		t.Errorf("dial error mismatch: have %v, want %v", err, errBanned)
+	}
+}
+
+// Tests that a peer whose reputation drops too low is disconnected and banned,
+// and that it can't reconnect until unbanned.
+func TestServerReputationBan(t *testing.T) {
+	connected := make(chan *Peer, 1)
+	remid := randomID()
+	srv := startTestServer(t, remid, func(p *Peer) { connected <- p })
+	defer srv.Stop()
+
+	// Connect the peer and report it as misbehaving
+	conn, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	var peer *Peer
+	select {
+	case peer = <-connected:
+	case <-time.After(time.Second):
+		t.Fatal("server did not accept connection")
+	}
+	peer.Report(RepInvalidMessage, "test")
+	if bans := srv.Bans(); len(bans) != 0 {
+		t.Fatalf("peer banned too early: %v", bans)
+	}
+	peer.Report(RepMalicious, "test")
+
+	bans := srv.Bans()
+	if len(bans) != 1 || bans[0].ID != remid.String() || bans[0].IP != "127.0.0.1" {
+		t.Fatalf("ban list mismatch: %v", bans)
+	}
+	if err := waitPeerCount(srv, 0); err != nil {
+		t.Fatal(err)
+	}
+	// The peer must not be able to reconnect
+	conn2, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn2.Close()
+
+	select {
+	case <-connected:
+		t.Fatal("banned peer reconnected")
+	case <-time.After(200 * time.Millisecond):
+	}
+	// Lift the ban and check that the peer can connect again
+	if unbanned, err := srv.UnbanPeer(remid); !unbanned || err != nil {
+		t.Fatalf("failed to unban: unbanned %v, err %v", unbanned, err)
+	}
+	conn3, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn3.Close()
+
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Fatal("unbanned peer could not reconnect")
+	}
+}
+
+// Tests that BanPeer disconnects and bans a connected peer.
+func TestServerBanPeer(t *testing.T) {
+	connected := make(chan *Peer, 1)
+	remid := randomID()
+	srv := startTestServer(t, remid, func(p *Peer) { connected <- p })
+	defer srv.Stop()
+
+	conn, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case <-connected:
+	case <-time.After(time.Second):
+		t.Fatal("server did not accept connection")
+	}
+	if err := srv.BanPeer(discover.NewNode(remid, nil, 0, 0), time.Hour, "admin"); err != nil {
+		t.Fatalf("failed to ban peer: %v", err)
+	}
+	if err := waitPeerCount(srv, 0); err != nil {
+		t.Fatal(err)
+	}
+	bans := srv.Bans()
+	if len(bans) != 1 || bans[0].Reason != "admin" || bans[0].IP != "127.0.0.1" {
+		t.Errorf("ban list mismatch: %v", bans)
+	}
+}
+
+// waitPeerCount waits until the server has the given number of peers.
+func waitPeerCount(srv *Server, n int) error {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if srv.PeerCount() == n {
+			return nil
+		}
+	}
+	return errors.New("peer count mismatch")
+}
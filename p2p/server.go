@@ -23,12 +23,15 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/EarthDollar/go-earthdollar/logger"
-	"github.com/EarthDollar/go-earthdollar/logger/glog"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/log"
 	"github.com/EarthDollar/go-earthdollar/p2p/discover"
 	"github.com/EarthDollar/go-earthdollar/p2p/discv5"
+	"github.com/EarthDollar/go-earthdollar/p2p/dnsdisc"
+	"github.com/EarthDollar/go-earthdollar/p2p/enr"
 	"github.com/EarthDollar/go-earthdollar/p2p/nat"
 	"github.com/EarthDollar/go-earthdollar/p2p/netutil"
 )
@@ -44,6 +47,11 @@ const (
 	// Maximum number of concurrently dialing outbound connections.
 	maxActiveDialTasks = 16
 
+	// Defaults of the inbound connection limits.
+	defaultDialRatio           = 3
+	defaultMaxInboundPerIP     = 2
+	defaultMaxInboundPerSubnet = 4
+
 	// Maximum time allowed for reading a complete message.
 	// This is effectively the amount of time a connection can be idle.
 	frameReadTimeout = 30 * time.Second
@@ -68,6 +76,19 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// DialRatio controls the ratio of inbound to dialed connections. A DialRatio
+	// of 3 reserves a third of MaxPeers for dialed peers, inbound connections
+	// can only take the remaining slots. At least one slot is reserved unless
+	// MaxPeers is 1, leaving the slot to whichever peer connects first. Zero
+	// defaults to 3.
+	DialRatio int
+
+	// MaxInboundPerIP and MaxInboundPerSubnet limit the number of inbound peers
+	// sharing an IP address or a /24 (IPv4) or /64 (IPv6) subnet. Trusted peers
+	// and peers on the local network are exempt. Zero defaults to preset values.
+	MaxInboundPerIP     int
+	MaxInboundPerSubnet int
+
 	// Discovery specifies whether the peer discovery mechanism should be started
 	// or not. Disabling is usually useful for protocol debugging (manual topology).
 	Discovery bool
@@ -79,6 +100,10 @@ type Config struct {
 	// Listener address for the V5 discovery protocol UDP traffic.
 	DiscoveryV5Addr string
 
+	// DNSDiscovery lists the enrtree:// URLs of DNS node lists, which are
+	// used as a source of dial candidates alongside the discovery table.
+	DNSDiscovery []string
+
 	// Name sets the node name of this server.
 	// Use common.MakeName to create a name that follows existing conventions.
 	Name string
@@ -97,7 +122,9 @@ type Config struct {
 	StaticNodes []*discover.Node
 
 	// Trusted nodes are used as pre-configured connections which are always
-	// allowed to connect, even above the peer limit.
+	// allowed to connect, even above the peer limit. They are not dialed unless
+	// they are static nodes too or found through discovery, but their dials are
+	// started ahead of all others.
 	TrustedNodes []*discover.Node
 
 	// Connectivity can be restricted to certain IP networks.
@@ -109,6 +136,10 @@ type Config struct {
 	// live nodes in the network.
 	NodeDatabase string
 
+	// NodeDatabaseV5 is the path to the database of the V5 discovery protocol,
+	// containing the previously seen nodes and the state of topic advertisement.
+	NodeDatabaseV5 string
+
 	// Protocols should contain the protocols supported
 	// by the server. Matching protocols are launched for
 	// each peer.
@@ -131,8 +162,23 @@ type Config struct {
 	// is used to dial outbound peer connections.
 	Dialer *net.Dialer
 
+	// If NodeDialer is set to a non-nil value, it is used instead of
+	// Dialer to open outbound peer connections. This allows connecting
+	// nodes over transports other than TCP, e.g. in-memory pipes.
+	NodeDialer NodeDialer
+
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool
+
+	// If EnableMsgEvents is set, the server emits an event for every message
+	// sent to or received from a peer. Wrapping all protocol messages is only
+	// meant for network simulations.
+	EnableMsgEvents bool
+}
+
+// NodeDialer is used to connect to nodes in the network.
+type NodeDialer interface {
+	Dial(dest *discover.Node) (net.Conn, error)
 }
 
 // Server manages all peer connections.
@@ -149,10 +195,15 @@ type Server struct {
 	running bool
 
 	ntab         discoverTable
+	dnsdisc      *dnsdisc.Client
+	candidate    func(*enr.Record) bool // dial candidate filter of all protocols
+	reputation   *reputation            // behaviour scores of remote nodes
+	bans         *banList               // nodes and IPs which may not connect
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
+	peerFeed     event.TypeMux // peer and message events
 
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
@@ -161,6 +212,8 @@ type Server struct {
 	quit          chan struct{}
 	addstatic     chan *discover.Node
 	removestatic  chan *discover.Node
+	addtrusted    chan *discover.Node
+	removetrusted chan *discover.Node
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan *Peer
@@ -169,7 +222,7 @@ type Server struct {
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
 
-type connFlag int
+type connFlag int32
 
 const (
 	dynDialedConn connFlag = 1 << iota
@@ -183,7 +236,7 @@ const (
 type conn struct {
 	fd net.Conn
 	transport
-	flags connFlag
+	flags connFlag        // accessed atomically, the trusted flag can change at runtime
 	cont  chan error      // The run loop uses cont to signal errors to setupConn.
 	id    discover.NodeID // valid after the encryption handshake
 	caps  []Cap           // valid after the protocol handshake
@@ -205,7 +258,7 @@ type transport interface {
 }
 
 func (c *conn) String() string {
-	s := c.flags.String() + " conn"
+	s := c.loadFlags().String() + " conn"
 	if (c.id != discover.NodeID{}) {
 		s += fmt.Sprintf(" %x", c.id[:8])
 	}
@@ -234,7 +287,25 @@ func (f connFlag) String() string {
 }
 
 func (c *conn) is(f connFlag) bool {
-	return c.flags&f != 0
+	return c.loadFlags()&f != 0
+}
+
+func (c *conn) loadFlags() connFlag {
+	return connFlag(atomic.LoadInt32((*int32)(&c.flags)))
+}
+
+// set sets or clears the given flags.
+func (c *conn) set(f connFlag, val bool) {
+	for {
+		oldFlags := c.loadFlags()
+		newFlags := oldFlags &^ f
+		if val {
+			newFlags |= f
+		}
+		if atomic.CompareAndSwapInt32((*int32)(&c.flags), int32(oldFlags), int32(newFlags)) {
+			return
+		}
+	}
 }
 
 // Peers returns all connected peers.
@@ -284,6 +355,25 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// AddTrustedPeer adds the given node to the trusted peers, which are allowed to
+// connect even above the peer limit. An existing connection to the node is
+// marked as trusted.
+func (srv *Server) AddTrustedPeer(node *discover.Node) {
+	select {
+	case srv.addtrusted <- node:
+	case <-srv.quit:
+	}
+}
+
+// RemoveTrustedPeer removes the given node from the trusted peers. An existing
+// connection to the node is kept but no longer marked as trusted.
+func (srv *Server) RemoveTrustedPeer(node *discover.Node) {
+	select {
+	case srv.removetrusted <- node:
+	case <-srv.quit:
+	}
+}
+
 // Self returns the local node's endpoint information.
 func (srv *Server) Self() *discover.Node {
 	srv.lock.Lock()
@@ -311,6 +401,29 @@ func (srv *Server) Self() *discover.Node {
 	return srv.ntab.Self()
 }
 
+// dialCandidate assembles the dial candidate filters of all protocols into a
+// single one, accepting the nodes that all of the protocols accept. It returns
+// nil if no protocol filters candidates.
+func (srv *Server) dialCandidate() func(*enr.Record) bool {
+	var filters []func(*enr.Record) bool
+	for _, p := range srv.Protocols {
+		if p.DialCandidate != nil {
+			filters = append(filters, p.DialCandidate)
+		}
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return func(r *enr.Record) bool {
+		for _, accept := range filters {
+			if !accept(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
@@ -326,6 +439,21 @@ func (srv *Server) Stop() {
 	}
 	close(srv.quit)
 	srv.loopWG.Wait()
+	srv.peerFeed.Stop()
+}
+
+// SubscribeEvents subscribes to the events of the server's peers. The events
+// are delivered as PeerEvent values, the subscription ends when the server is
+// stopped. Message events are only delivered if EnableMsgEvents is set.
+func (srv *Server) SubscribeEvents() event.Subscription {
+	return srv.peerFeed.Subscribe(PeerEvent{})
+}
+
+// AcceptConn runs the handshakes on an inbound connection which wasn't
+// accepted through the listener, adding it as a peer if they succeed. It
+// returns when the connection has been added or the handshakes failed.
+func (srv *Server) AcceptConn(fd net.Conn) {
+	srv.setupConn(fd, inboundConn, nil)
 }
 
 // Start starts running the server.
@@ -337,7 +465,7 @@ func (srv *Server) Start() (err error) {
 		return errors.New("server already running")
 	}
 	srv.running = true
-	glog.V(logger.Info).Infoln("Starting Server")
+	log.Info("Starting P2P networking")
 
 	// static fields
 	if srv.PrivateKey == nil {
@@ -355,6 +483,8 @@ func (srv *Server) Start() (err error) {
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *discover.Node)
 	srv.removestatic = make(chan *discover.Node)
+	srv.addtrusted = make(chan *discover.Node)
+	srv.removetrusted = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
@@ -367,11 +497,31 @@ func (srv *Server) Start() (err error) {
 		if err := ntab.SetFallbackNodes(srv.BootstrapNodes); err != nil {
 			return err
 		}
+		var attrs []enr.Entry
+		for _, p := range srv.Protocols {
+			attrs = append(attrs, p.Attributes...)
+		}
+		if len(attrs) > 0 {
+			if err := ntab.SetRecordEntries(attrs...); err != nil {
+				return err
+			}
+		}
 		srv.ntab = ntab
 	}
+	if len(srv.DNSDiscovery) > 0 {
+		client, err := dnsdisc.NewClient(dnsdisc.Config{}, srv.DNSDiscovery...)
+		if err != nil {
+			return err
+		}
+		client.Start()
+		srv.dnsdisc = client
+	}
+	if srv.ntab != nil || srv.dnsdisc != nil {
+		srv.candidate = srv.dialCandidate()
+	}
 
 	if srv.DiscoveryV5 {
-		ntab, err := discv5.ListenUDP(srv.PrivateKey, srv.DiscoveryV5Addr, srv.NAT, "", srv.NetRestrict) //srv.NodeDatabase)
+		ntab, err := discv5.ListenUDP(srv.PrivateKey, srv.DiscoveryV5Addr, srv.NAT, srv.NodeDatabaseV5, srv.NetRestrict)
 		if err != nil {
 			return err
 		}
@@ -381,11 +531,20 @@ func (srv *Server) Start() (err error) {
 		srv.DiscV5 = ntab
 	}
 
-	dynPeers := (srv.MaxPeers + 1) / 2
-	if !srv.Discovery {
-		dynPeers = 0
+	// peer reputation, bans are persisted in the node database if available
+	var store banStore
+	if ntab, ok := srv.ntab.(banStore); ok {
+		store = ntab
+	}
+	srv.reputation = newReputation()
+	srv.bans = newBanList(store)
+
+	dialer := newDialState(srv.StaticNodes, srv.ntab, srv.maxDialedConns(), srv.NetRestrict)
+	dialer.candidate = srv.candidate
+	dialer.banned = func(n *discover.Node) bool { return srv.bans.banned(n.ID, n.IP) }
+	if srv.dnsdisc != nil {
+		dialer.dns = srv.dnsdisc
 	}
-	dialer := newDialState(srv.StaticNodes, srv.ntab, dynPeers, srv.NetRestrict)
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -399,7 +558,7 @@ func (srv *Server) Start() (err error) {
 		}
 	}
 	if srv.NoDial && srv.ListenAddr == "" {
-		glog.V(logger.Warn).Infoln("I will be kind-of useless, neither dialing nor listening.")
+		log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
 
 	srv.loopWG.Add(1)
@@ -441,14 +600,15 @@ func (srv *Server) run(dialstate dialer) {
 	defer srv.loopWG.Done()
 	var (
 		peers        = make(map[discover.NodeID]*Peer)
+		inboundCount = 0
 		trusted      = make(map[discover.NodeID]bool, len(srv.TrustedNodes))
 		taskdone     = make(chan task, maxActiveDialTasks)
 		runningTasks []task
 		queuedTasks  []task // tasks that can't run yet
 	)
 	// Put trusted nodes into a map to speed up checks.
-	// Trusted peers are loaded on startup and cannot be
-	// modified while the server is running.
+	// Trusted peers are loaded on startup and can be
+	// modified with AddTrustedPeer and RemoveTrustedPeer.
 	for _, n := range srv.TrustedNodes {
 		trusted[n.ID] = true
 	}
@@ -467,7 +627,7 @@ func (srv *Server) run(dialstate dialer) {
 		i := 0
 		for ; len(runningTasks) < maxActiveDialTasks && i < len(ts); i++ {
 			t := ts[i]
-			glog.V(logger.Detail).Infoln("new task:", t)
+			log.Trace("New dial task", "task", t)
 			go func() { t.Do(srv); taskdone <- t }()
 			runningTasks = append(runningTasks, t)
 		}
@@ -479,7 +639,7 @@ func (srv *Server) run(dialstate dialer) {
 		// Query dialer for new tasks and start as many as possible now.
 		if len(runningTasks) < maxActiveDialTasks {
 			nt := dialstate.newTasks(len(runningTasks)+len(queuedTasks), peers, time.Now())
-			queuedTasks = append(queuedTasks, startTasks(nt)...)
+			queuedTasks = prioritizeDials(append(queuedTasks, startTasks(nt)...), trusted)
 		}
 	}
 
@@ -490,23 +650,39 @@ running:
 		select {
 		case <-srv.quit:
 			// The server was stopped. Run the cleanup logic.
-			glog.V(logger.Detail).Infoln("<-quit: spinning down")
+			log.Trace("P2P networking is spinning down")
 			break running
 		case n := <-srv.addstatic:
 			// This channel is used by AddPeer to add to the
 			// ephemeral static peer list. Add it to the dialer,
 			// it will keep the node connected.
-			glog.V(logger.Detail).Infoln("<-addstatic:", n)
+			log.Debug("Adding static node", "node", n)
 			dialstate.addStatic(n)
 		case n := <-srv.removestatic:
 			// This channel is used by RemovePeer to send a
 			// disconnect request to a peer and begin the
 			// stop keeping the node connected
-			glog.V(logger.Detail).Infoln("<-removestatic:", n)
+			log.Debug("Removing static node", "node", n)
 			dialstate.removeStatic(n)
 			if p, ok := peers[n.ID]; ok {
 				p.Disconnect(DiscRequested)
 			}
+		case n := <-srv.addtrusted:
+			// This channel is used by AddTrustedPeer to add a node
+			// to the trusted node set.
+			log.Debug("Adding trusted node", "node", n)
+			trusted[n.ID] = true
+			if p, ok := peers[n.ID]; ok {
+				p.rw.set(trustedConn, true)
+			}
+		case n := <-srv.removetrusted:
+			// This channel is used by RemoveTrustedPeer to remove a
+			// node from the trusted node set.
+			log.Debug("Removing trusted node", "node", n)
+			delete(trusted, n.ID)
+			if p, ok := peers[n.ID]; ok {
+				p.rw.set(trustedConn, false)
+			}
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -515,7 +691,7 @@ running:
 			// A task got done. Tell dialstate about it so it
 			// can update its state and remove it from the active
 			// tasks list.
-			glog.V(logger.Detail).Infoln("<-taskdone:", t)
+			log.Trace("Dial task done", "task", t)
 			dialstate.taskDone(t, time.Now())
 			delTask(t)
 		case c := <-srv.posthandshake:
@@ -523,22 +699,29 @@ running:
 			// the remote identity is known (but hasn't been verified yet).
 			if trusted[c.id] {
 				// Ensure that the trusted flag is set before checking against MaxPeers.
-				c.flags |= trustedConn
+				c.set(trustedConn, true)
 			}
-			glog.V(logger.Detail).Infoln("<-posthandshake:", c)
+			log.Trace("Checking connection handshake", "conn", c)
 			// TODO: track in-progress inbound node IDs (pre-Peer) to avoid dialing them.
-			c.cont <- srv.encHandshakeChecks(peers, c)
+			c.cont <- srv.encHandshakeChecks(peers, inboundCount, c)
 		case c := <-srv.addpeer:
 			// At this point the connection is past the protocol handshake.
 			// Its capabilities are known and the remote identity is verified.
-			glog.V(logger.Detail).Infoln("<-addpeer:", c)
-			err := srv.protoHandshakeChecks(peers, c)
+			log.Trace("Adding peer connection", "conn", c)
+			err := srv.protoHandshakeChecks(peers, inboundCount, c)
 			if err != nil {
-				glog.V(logger.Detail).Infof("Not adding %v as peer: %v", c, err)
+				log.Trace("Not adding peer", "conn", c, "err", err)
 			} else {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				p.report = func(delta int, reason string) { srv.reportPeer(p, delta, reason) }
+				if srv.EnableMsgEvents {
+					p.events = &srv.peerFeed
+				}
 				peers[c.id] = p
+				if p.rw.is(inboundConn) {
+					inboundCount++
+				}
 				go srv.runPeer(p)
 			}
 			// The dialer logic relies on the assumption that
@@ -547,8 +730,11 @@ running:
 			c.cont <- err
 		case p := <-srv.delpeer:
 			// A peer disconnected.
-			glog.V(logger.Detail).Infoln("<-delpeer:", p)
+			p.log.Trace("Removing peer")
 			delete(peers, p.ID())
+			if p.rw.is(inboundConn) {
+				inboundCount--
+			}
 		}
 	}
 
@@ -556,6 +742,9 @@ running:
 	if srv.ntab != nil {
 		srv.ntab.Close()
 	}
+	if srv.dnsdisc != nil {
+		srv.dnsdisc.Close()
+	}
 	if srv.DiscV5 != nil {
 		srv.DiscV5.Close()
 	}
@@ -566,37 +755,149 @@ running:
 	// Wait for peers to shut down. Pending connections and tasks are
 	// not handled here and will terminate soon-ish because srv.quit
 	// is closed.
-	glog.V(logger.Detail).Infof("ignoring %d pending tasks at spindown", len(runningTasks))
+	log.Trace("Ignoring pending tasks at spindown", "count", len(runningTasks))
 	for len(peers) > 0 {
 		p := <-srv.delpeer
-		glog.V(logger.Detail).Infoln("<-delpeer (spindown):", p)
+		p.log.Trace("Removing peer at spindown")
 		delete(peers, p.ID())
 	}
 }
 
-func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
+func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCount int, c *conn) error {
 	// Drop connections with no matching protocols.
 	if len(srv.Protocols) > 0 && countMatchingProtocols(srv.Protocols, c.caps) == 0 {
 		return DiscUselessPeer
 	}
 	// Repeat the encryption handshake checks because the
 	// peer set might have changed between the handshakes.
-	return srv.encHandshakeChecks(peers, c)
+	return srv.encHandshakeChecks(peers, inboundCount, c)
 }
 
-func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
+func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCount int, c *conn) error {
 	switch {
 	case !c.is(trustedConn|staticDialedConn) && len(peers) >= srv.MaxPeers:
 		return DiscTooManyPeers
+	case !c.is(trustedConn) && c.is(inboundConn) && inboundCount >= srv.maxInboundConns():
+		return DiscTooManyPeers
+	case !c.is(trustedConn) && c.is(inboundConn) && !srv.inboundIPAllowed(peers, remoteIP(c.fd)):
+		return DiscTooManyPeers
 	case peers[c.id] != nil:
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case srv.bans.banned(c.id, remoteIP(c.fd)):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
 }
 
+// maxDialedConns returns the number of dynamically dialed peers, at least one
+// if there are peer slots at all. Dialing is disabled without a source of dial
+// candidates.
+func (srv *Server) maxDialedConns() int {
+	if srv.NoDial || srv.MaxPeers <= 0 || (!srv.Discovery && len(srv.DNSDiscovery) == 0) {
+		return 0
+	}
+	r := srv.DialRatio
+	if r <= 0 {
+		r = defaultDialRatio
+	}
+	limit := srv.MaxPeers / r
+	if limit == 0 {
+		limit = 1
+	}
+	return limit
+}
+
+// maxInboundConns returns the number of peer slots available to inbound
+// connections, leaving the others to dialed peers. A single slot can't be
+// split, it is taken by whichever peer connects first.
+func (srv *Server) maxInboundConns() int {
+	if srv.MaxPeers == 1 {
+		return 1
+	}
+	return srv.MaxPeers - srv.maxDialedConns()
+}
+
+var (
+	inboundSubnetV4 = net.CIDRMask(24, 32)
+	inboundSubnetV6 = net.CIDRMask(64, 128)
+)
+
+// inboundIPAllowed reports whether another inbound peer may connect from ip
+// without exceeding the per-IP and per-subnet limits.
+func (srv *Server) inboundIPAllowed(peers map[discover.NodeID]*Peer, ip net.IP) bool {
+	if ip == nil || netutil.IsLAN(ip) {
+		return true
+	}
+	maxIP, maxSubnet := srv.MaxInboundPerIP, srv.MaxInboundPerSubnet
+	if maxIP == 0 {
+		maxIP = defaultMaxInboundPerIP
+	}
+	if maxSubnet == 0 {
+		maxSubnet = defaultMaxInboundPerSubnet
+	}
+	var sameIP, sameSubnet int
+	for _, p := range peers {
+		if !p.rw.is(inboundConn) || p.rw.is(trustedConn) {
+			continue
+		}
+		pip := remoteIP(p.rw.fd)
+		if pip == nil {
+			continue
+		}
+		if pip.Equal(ip) {
+			sameIP++
+		}
+		if inSameSubnet(pip, ip) {
+			sameSubnet++
+		}
+	}
+	return sameIP < maxIP && sameSubnet < maxSubnet
+}
+
+// inSameSubnet reports whether a and b share a /24 (IPv4) or /64 (IPv6) subnet.
+func inSameSubnet(a, b net.IP) bool {
+	a4, b4 := a.To4(), b.To4()
+	switch {
+	case a4 != nil && b4 != nil:
+		return a4.Mask(inboundSubnetV4).Equal(b4.Mask(inboundSubnetV4))
+	case a4 == nil && b4 == nil:
+		return a.Mask(inboundSubnetV6).Equal(b.Mask(inboundSubnetV6))
+	default:
+		return false
+	}
+}
+
+// prioritizeDials moves the dials of trusted nodes ahead of the other queued
+// tasks, followed by the dials of static nodes, keeping their order otherwise.
+func prioritizeDials(tasks []task, trusted map[discover.NodeID]bool) []task {
+	if len(tasks) < 2 {
+		return tasks
+	}
+	rank := func(t task) int {
+		dt, ok := t.(*dialTask)
+		switch {
+		case ok && trusted[dt.dest.ID]:
+			return 0
+		case ok && dt.flags&staticDialedConn != 0:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sorted := make([]task, 0, len(tasks))
+	for r := 0; r <= 2; r++ {
+		for _, t := range tasks {
+			if rank(t) == r {
+				sorted = append(sorted, t)
+			}
+		}
+	}
+	return sorted
+}
+
 type tempError interface {
 	Temporary() bool
 }
@@ -605,7 +906,7 @@ type tempError interface {
 // inbound connections.
 func (srv *Server) listenLoop() {
 	defer srv.loopWG.Done()
-	glog.V(logger.Info).Infoln("Listening on", srv.listener.Addr())
+	log.Info("RLPx listener up", "addr", srv.listener.Addr())
 
 	// This channel acts as a semaphore limiting
 	// active inbound connections that are lingering pre-handshake.
@@ -630,10 +931,10 @@ func (srv *Server) listenLoop() {
 		for {
 			fd, err = srv.listener.Accept()
 			if tempErr, ok := err.(tempError); ok && tempErr.Temporary() {
-				glog.V(logger.Debug).Infof("Temporary read error: %v", err)
+				log.Debug("Temporary read error", "err", err)
 				continue
 			} else if err != nil {
-				glog.V(logger.Debug).Infof("Read error: %v", err)
+				log.Debug("Read error", "err", err)
 				return
 			}
 			break
@@ -642,15 +943,23 @@ func (srv *Server) listenLoop() {
 		// Reject connections that do not match NetRestrict.
 		if srv.NetRestrict != nil {
 			if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok && !srv.NetRestrict.Contains(tcp.IP) {
-				glog.V(logger.Debug).Infof("Rejected conn %v because it is not whitelisted in NetRestrict", fd.RemoteAddr())
+				log.Debug("Rejected conn (not whitelisted in NetRestrict)", "addr", fd.RemoteAddr())
 				fd.Close()
 				slots <- struct{}{}
 				continue
 			}
 		}
 
+		// Reject connections from banned IP addresses.
+		if ip := remoteIP(fd); ip != nil && srv.bans.isBannedIP(ip) {
+			log.Debug("Rejected conn (banned IP)", "addr", fd.RemoteAddr())
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
+
 		fd = newMeteredConn(fd, true)
-		glog.V(logger.Debug).Infof("Accepted conn %v", fd.RemoteAddr())
+		log.Trace("Accepted connection", "addr", fd.RemoteAddr())
 
 		// Spawn the handler. It will give the slot back when the connection
 		// has been established.
@@ -663,55 +972,58 @@ func (srv *Server) listenLoop() {
 
 // setupConn runs the handshakes and attempts to add the connection
 // as a peer. It returns when the connection has been added as a peer
-// or the handshakes have failed.
-func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Node) {
+// or the handshakes have failed, reporting the reason of failure.
+func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Node) error {
 	// Prevent leftover pending conns from entering the handshake.
 	srv.lock.Lock()
 	running := srv.running
 	srv.lock.Unlock()
 	c := &conn{fd: fd, transport: srv.newTransport(fd), flags: flags, cont: make(chan error)}
+	clog := log.New("addr", fd.RemoteAddr(), "conn", flags)
 	if !running {
 		c.close(errServerStopped)
-		return
+		return errServerStopped
 	}
 	// Run the encryption handshake.
 	var err error
 	if c.id, err = c.doEncHandshake(srv.PrivateKey, dialDest); err != nil {
-		glog.V(logger.Debug).Infof("%v faild enc handshake: %v", c, err)
+		clog.Trace("Failed RLPx handshake", "err", err)
 		c.close(err)
-		return
+		return err
 	}
+	clog = clog.New("id", fmt.Sprintf("%x", c.id[:8]))
 	// For dialed connections, check that the remote public key matches.
 	if dialDest != nil && c.id != dialDest.ID {
 		c.close(DiscUnexpectedIdentity)
-		glog.V(logger.Debug).Infof("%v dialed identity mismatch, want %x", c, dialDest.ID[:8])
-		return
+		clog.Trace("Dialed identity mismatch", "want", dialDest.ID)
+		return DiscUnexpectedIdentity
 	}
 	if err := srv.checkpoint(c, srv.posthandshake); err != nil {
-		glog.V(logger.Debug).Infof("%v failed checkpoint posthandshake: %v", c, err)
+		clog.Trace("Rejected peer before protocol handshake", "err", err)
 		c.close(err)
-		return
+		return err
 	}
 	// Run the protocol handshake
 	phs, err := c.doProtoHandshake(srv.ourHandshake)
 	if err != nil {
-		glog.V(logger.Debug).Infof("%v failed proto handshake: %v", c, err)
+		clog.Trace("Failed proto handshake", "err", err)
 		c.close(err)
-		return
+		return err
 	}
 	if phs.ID != c.id {
-		glog.V(logger.Debug).Infof("%v wrong proto handshake identity: %x", c, phs.ID[:8])
+		clog.Trace("Wrong devp2p handshake identity", "got", phs.ID)
 		c.close(DiscUnexpectedIdentity)
-		return
+		return DiscUnexpectedIdentity
 	}
 	c.caps, c.name = phs.Caps, phs.Name
 	if err := srv.checkpoint(c, srv.addpeer); err != nil {
-		glog.V(logger.Debug).Infof("%v failed checkpoint addpeer: %v", c, err)
+		clog.Trace("Rejected peer", "err", err)
 		c.close(err)
-		return
+		return err
 	}
 	// If the checks completed successfully, runPeer has now been
 	// launched by run.
+	return nil
 }
 
 // checkpoint sends the conn to run, which performs the
@@ -734,17 +1046,118 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 // it waits until the Peer logic returns and removes
 // the peer.
 func (srv *Server) runPeer(p *Peer) {
-	glog.V(logger.Debug).Infof("Added %v\n", p)
+	p.log.Debug("Adding p2p peer", "name", p.Name(), "caps", p.Caps(), "addr", p.RemoteAddr())
 
 	if srv.newPeerHook != nil {
 		srv.newPeerHook(p)
 	}
+	srv.peerFeed.Post(PeerEvent{Type: PeerEventTypeAdd, Peer: p.ID()})
+
 	discreason := p.run()
+	srv.peerFeed.Post(PeerEvent{Type: PeerEventTypeDrop, Peer: p.ID(), Error: discreason.Error()})
+
 	// Note: run waits for existing peers to be sent on srv.delpeer
 	// before returning, so this send should not select on srv.quit.
 	srv.delpeer <- p
 
-	glog.V(logger.Debug).Infof("Removed %v (%v)\n", p, discreason)
+	p.log.Debug("Removing p2p peer", "reason", discreason)
+}
+
+// reportPeer adjusts the reputation of a peer, disconnecting and banning it if
+// the reputation drops to the ban threshold. Trusted peers are never banned
+// automatically.
+func (srv *Server) reportPeer(p *Peer, delta int, reason string) {
+	score := srv.reputation.add(p.ID(), delta)
+	if delta < 0 {
+		p.log.Debug("Peer misbehaved", "reason", reason, "delta", delta, "score", score)
+	}
+	if score > banThreshold || p.rw.is(trustedConn) {
+		return
+	}
+	ip := remoteIP(p.rw.fd)
+	if srv.bans.banned(p.ID(), ip) {
+		return // banned by an earlier report, already disconnecting
+	}
+	p.log.Info("Banning misbehaving peer", "reason", reason, "score", score, "duration", reputationBanTime)
+	if err := srv.bans.add(p.ID(), ip, reason, reputationBanTime); err != nil {
+		p.log.Warn("Failed to store peer ban", "err", err)
+	}
+	p.Disconnect(DiscUselessPeer)
+}
+
+// BanPeer bans a node by its ID and, if known, its IP address for the given
+// duration, disconnecting it if connected. The IP address of a connected peer
+// takes precedence over the one of the given node.
+func (srv *Server) BanPeer(node *discover.Node, duration time.Duration, reason string) error {
+	bans := srv.banList()
+	if bans == nil {
+		return errServerStopped
+	}
+	ip, peer := node.IP, srv.peer(node.ID)
+	if peer != nil {
+		if addr := remoteIP(peer.rw.fd); addr != nil {
+			ip = addr
+		}
+	}
+	if err := bans.add(node.ID, ip, reason, duration); err != nil {
+		return err
+	}
+	if peer != nil {
+		peer.Disconnect(DiscRequested)
+	}
+	return nil
+}
+
+// UnbanPeer lifts the ban of a node and resets its reputation, reporting whether
+// the node was banned.
+func (srv *Server) UnbanPeer(id discover.NodeID) (bool, error) {
+	bans := srv.banList()
+	if bans == nil {
+		return false, errServerStopped
+	}
+	srv.reputation.reset(id)
+	return bans.remove(id)
+}
+
+// Bans returns a summary of all active node bans.
+func (srv *Server) Bans() []*BanInfo {
+	if bans := srv.banList(); bans != nil {
+		return bans.list()
+	}
+	return nil
+}
+
+// banList returns the ban list of a running server, or nil if it isn't running.
+func (srv *Server) banList() *banList {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return nil
+	}
+	return srv.bans
+}
+
+// peer returns the connected peer with the given ID, or nil if not connected.
+func (srv *Server) peer(id discover.NodeID) *Peer {
+	for _, p := range srv.Peers() {
+		if p.ID() == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// remoteIP returns the IP address of the remote end of a connection, or nil if
+// it isn't a TCP connection.
+func remoteIP(fd net.Conn) net.IP {
+	if fd == nil {
+		return nil
+	}
+	if addr, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
 }
 
 // NodeInfo represents a short summary of the information known about the host.
@@ -757,6 +1170,7 @@ type NodeInfo struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
+	ENR        string                 `json:"enr,omitempty"` // Signed node record advertised through discovery
 	ListenAddr string                 `json:"listenAddr"`
 	Protocols  map[string]interface{} `json:"protocols"`
 }
@@ -776,6 +1190,11 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
+	if ntab := srv.discoverTable(); ntab != nil {
+		if r := ntab.Record(); r != nil {
+			info.ENR = r.String()
+		}
+	}
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
@@ -790,13 +1209,41 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	return info
 }
 
+// SetRecordEntries updates entries of the local node record advertised through
+// discovery. It is a no-op if the server isn't running or discovery is disabled.
+func (srv *Server) SetRecordEntries(entries ...enr.Entry) error {
+	if ntab := srv.discoverTable(); ntab != nil {
+		return ntab.SetRecordEntries(entries...)
+	}
+	return nil
+}
+
+// discoverTable returns the discovery table of a running server, or nil if
+// discovery is disabled.
+func (srv *Server) discoverTable() discoverTable {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return nil
+	}
+	return srv.ntab
+}
+
 // PeersInfo returns an array of metadata objects describing connected peers.
 func (srv *Server) PeersInfo() []*PeerInfo {
 	// Gather all the generic and sub-protocol specific infos
 	infos := make([]*PeerInfo, 0, srv.PeerCount())
+	ntab := srv.discoverTable()
 	for _, peer := range srv.Peers() {
 		if peer != nil {
-			infos = append(infos, peer.Info())
+			info := peer.Info()
+			if ntab != nil {
+				if r := ntab.NodeRecord(peer.ID()); r != nil {
+					info.ENR = r.String()
+				}
+			}
+			infos = append(infos, info)
 		}
 	}
 	// Sort the result array alphabetically by node identifier
@@ -31,8 +31,7 @@ import (
 )
 
 func init() {
-	// glog.SetV(6)
-	// glog.SetToStderr(true)
+	// log.Root().SetHandler(log.LvlFilterHandler(log.LvlTrace, log.StreamHandler(os.Stderr, log.TerminalFormat(false))))
 }
 
 type testTransport struct {
@@ -181,6 +180,72 @@ func TestServerDial(t *testing.T) {
 
 // This test checks that tasks generated by dialstate are
 // actually executed and taskdone is called for them.
+// This test checks that connections handed to AcceptConn are added as peers
+// and that peer events are posted for them.
+func TestServerPeerEvents(t *testing.T) {
+	remid := randomID()
+	srv := startTestServer(t, remid, nil)
+	defer srv.Stop()
+	sub := srv.SubscribeEvents()
+	defer sub.Unsubscribe()
+
+	fd, remote := net.Pipe()
+	go srv.AcceptConn(fd)
+
+	wantEvent := func(typ PeerEventType) {
+		select {
+		case ev := <-sub.Chan():
+			pe := ev.Data.(PeerEvent)
+			if pe.Type != typ || pe.Peer != remid {
+				t.Fatalf("event mismatch: got %s of %x, want %s of %x", pe.Type, pe.Peer[:8], typ, remid[:8])
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("no %s event within one second", typ)
+		}
+	}
+	wantEvent(PeerEventTypeAdd)
+	remote.Close()
+	wantEvent(PeerEventTypeDrop)
+}
+
+// This test checks that protocol messages are only wrapped to emit message
+// events if enabled in the config.
+func TestServerMsgEventsConfig(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var (
+			remid     = randomID()
+			connected = make(chan *Peer, 1)
+			srv       = &Server{
+				Config: Config{
+					Name:            "test",
+					MaxPeers:        10,
+					PrivateKey:      newkey(),
+					NoDial:          true,
+					EnableMsgEvents: enabled,
+				},
+				newPeerHook:  func(p *Peer) { connected <- p },
+				newTransport: func(fd net.Conn) transport { return newTestTransport(remid, fd) },
+			}
+		)
+		if err := srv.Start(); err != nil {
+			t.Fatalf("could not start server: %v", err)
+		}
+		fd, remote := net.Pipe()
+		go srv.AcceptConn(fd)
+
+		select {
+		case p := <-connected:
+			if have := p.events != nil; have != enabled {
+				t.Errorf("EnableMsgEvents %v: message events enabled: %v", enabled, have)
+			}
+		case <-time.After(1 * time.Second):
+			t.Errorf("EnableMsgEvents %v: peer not added within one second", enabled)
+		}
+		remote.Close()
+		srv.Stop()
+	}
+}
+
 func TestServerTaskScheduling(t *testing.T) {
 	var (
 		done           = make(chan *testTask)
@@ -358,8 +423,131 @@ func TestServerAtCap(t *testing.T) {
 		t.Error("Server did not set trusted flag")
 	}
 
+	// Remove the trusted node and check it is treated like any other.
+	srv.RemoveTrustedPeer(&discover.Node{ID: trustedID})
+	c = newconn(trustedID)
+	if err := srv.checkpoint(c, srv.posthandshake); err != DiscTooManyPeers {
+		t.Error("wrong error for insert of removed trusted node:", err)
+	}
+	// Nodes added as trusted at runtime are accepted.
+	anotherID := randomID()
+	srv.AddTrustedPeer(&discover.Node{ID: anotherID})
+	c = newconn(anotherID)
+	if err := srv.checkpoint(c, srv.posthandshake); err != nil {
+		t.Error("unexpected error for runtime trusted conn @posthandshake:", err)
+	}
+	if !c.is(trustedConn) {
+		t.Error("Server did not set trusted flag for runtime trusted conn")
+	}
 }
 
+// This test checks that inbound connections are limited to their share of the
+// peer slots and per IP address and subnet.
+func TestServerInboundLimits(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			PrivateKey:          newkey(),
+			MaxPeers:            9,
+			Discovery:           true,
+			MaxInboundPerIP:     1,
+			MaxInboundPerSubnet: 2,
+		},
+		bans: newBanList(nil),
+	}
+	var (
+		peers        = make(map[discover.NodeID]*Peer)
+		inboundCount = 0
+	)
+	newconn := func(ip string, flags connFlag) *conn {
+		fd := &addrConn{addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 30303}}
+		return &conn{fd: fd, flags: flags, id: randomID()}
+	}
+	check := func(c *conn, want error) {
+		if err := srv.encHandshakeChecks(peers, inboundCount, c); err != want {
+			t.Fatalf("%v conn from %v: got error %v, want %v", c.flags, remoteIP(c.fd), err, want)
+		}
+		if want == nil {
+			peers[c.id] = &Peer{rw: c}
+			if c.is(inboundConn) {
+				inboundCount++
+			}
+		}
+	}
+
+	// Limits per IP address and subnet.
+	check(newconn("1.2.3.4", inboundConn), nil)
+	check(newconn("1.2.3.4", inboundConn), DiscTooManyPeers)
+	check(newconn("1.2.3.5", inboundConn), nil)
+	check(newconn("1.2.3.6", inboundConn), DiscTooManyPeers)
+	check(newconn("1.2.3.6", inboundConn|trustedConn), nil)
+	check(newconn("1.2.3.4", dynDialedConn), nil)
+	check(newconn("192.168.0.1", inboundConn), nil)
+	check(newconn("192.168.0.1", inboundConn), nil)
+
+	// Six of nine slots are available to inbound connections.
+	if n := srv.maxInboundConns(); n != 6 {
+		t.Fatalf("wrong number of inbound slots: got %d, want 6", n)
+	}
+	check(newconn("5.6.7.8", inboundConn), nil)
+	check(newconn("9.9.9.9", inboundConn), DiscTooManyPeers)
+	check(newconn("9.9.9.9", inboundConn|trustedConn), nil)
+	check(newconn("9.9.9.9", dynDialedConn), nil)
+}
+
+// Tests the split of the peer slots between dialed and inbound peers for small
+// peer limits.
+func TestServerSlotLimits(t *testing.T) {
+	tests := []struct {
+		maxPeers, dialRatio int
+		noDiscovery         bool
+		dialed, inbound     int
+	}{
+		{maxPeers: 0, dialed: 0, inbound: 0},
+		{maxPeers: 1, dialed: 1, inbound: 1},
+		{maxPeers: 2, dialed: 1, inbound: 1},
+		{maxPeers: 3, dialed: 1, inbound: 2},
+		{maxPeers: 9, dialed: 3, inbound: 6},
+		{maxPeers: 9, dialRatio: 1, dialed: 9, inbound: 0},
+		{maxPeers: 9, dialRatio: -1, dialed: 3, inbound: 6},
+		{maxPeers: 1, noDiscovery: true, dialed: 0, inbound: 1},
+		{maxPeers: 9, noDiscovery: true, dialed: 0, inbound: 9},
+	}
+	for i, tt := range tests {
+		srv := &Server{Config: Config{MaxPeers: tt.maxPeers, DialRatio: tt.dialRatio, Discovery: !tt.noDiscovery}}
+		if n := srv.maxDialedConns(); n != tt.dialed {
+			t.Errorf("test %d: dialed slots mismatch: have %d, want %d", i, n, tt.dialed)
+		}
+		if n := srv.maxInboundConns(); n != tt.inbound {
+			t.Errorf("test %d: inbound slots mismatch: have %d, want %d", i, n, tt.inbound)
+		}
+	}
+}
+
+// Tests that dials of trusted and static nodes are started first.
+func TestPrioritizeDials(t *testing.T) {
+	node := func(id byte) *discover.Node { return &discover.Node{ID: discover.NodeID{id}} }
+	var (
+		dyn1    = &dialTask{flags: dynDialedConn, dest: node(1)}
+		static  = &dialTask{flags: staticDialedConn, dest: node(2)}
+		dyn2    = &dialTask{flags: dynDialedConn, dest: node(3)}
+		trusted = &dialTask{flags: dynDialedConn, dest: node(4)}
+		lookup  = &discoverTask{}
+	)
+	tasks := prioritizeDials([]task{dyn1, lookup, static, dyn2, trusted}, map[discover.NodeID]bool{{4}: true})
+	want := []task{trusted, static, dyn1, lookup, dyn2}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("task order mismatch:\nhave %v\nwant %v", tasks, want)
+	}
+}
+
+// addrConn is a connection reporting a fixed remote address.
+type addrConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *addrConn) RemoteAddr() net.Addr { return c.addr }
+
 func TestServerSetupConn(t *testing.T) {
 	id := randomID()
 	srvkey := newkey()
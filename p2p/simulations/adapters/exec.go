@@ -0,0 +1,303 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+	"golang.org/x/net/context"
+)
+
+const (
+	execStartTimeout = 20 * time.Second       // time allowed for a process to open its IPC endpoint
+	execStopTimeout  = 5 * time.Second        // time allowed for a process to exit after an interrupt
+	execPollInterval = 200 * time.Millisecond // interval of peer polls generating peer events
+)
+
+var errNodeNotRunning = errors.New("node not running")
+
+// ExecAdapter is a NodeAdapter which runs simulation nodes as local ged
+// processes. Each node gets its own data directory below BaseDir and talks
+// to the simulation through IPC.
+//
+// ged processes run their default protocols, the Services of the node
+// configuration are ignored. Peer events are synthesized by polling the
+// peers of the process, message events are not available.
+type ExecAdapter struct {
+	BaseDir string   // directory holding the data directories of the nodes
+	Binary  string   // path of the ged executable, "ged" is looked up in $PATH if empty
+	Args    []string // additional command line arguments of every node
+}
+
+// NewExecAdapter creates an ExecAdapter keeping node data below baseDir.
+func NewExecAdapter(baseDir string) *ExecAdapter {
+	return &ExecAdapter{BaseDir: baseDir}
+}
+
+// Name returns the name of the adapter for logging purposes.
+func (e *ExecAdapter) Name() string {
+	return "exec-adapter"
+}
+
+// NewNode creates a node whose process is launched by Start.
+func (e *ExecAdapter) NewNode(config *NodeConfig) (Node, error) {
+	if config.PrivateKey == nil {
+		return nil, errors.New("node private key missing")
+	}
+	dir := filepath.Join(e.BaseDir, config.ID.String()[:12])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating node directory: %s", err)
+	}
+	return &ExecNode{
+		ID:      config.ID,
+		Dir:     dir,
+		config:  config,
+		adapter: e,
+	}, nil
+}
+
+// ExecNode is a simulation node running as a separate ged process.
+type ExecNode struct {
+	ID  discover.NodeID
+	Dir string
+
+	config  *NodeConfig
+	adapter *ExecAdapter
+
+	mtx    sync.Mutex
+	cmd    *exec.Cmd
+	port   int
+	client *rpc.Client
+	events *event.TypeMux
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// Addr returns the node's enode URL.
+func (n *ExecNode) Addr() []byte {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	return []byte(discover.NewNode(n.ID, net.IP{127, 0, 0, 1}, 0, uint16(n.port)).String())
+}
+
+// Client returns an RPC client connected to the node's IPC endpoint.
+func (n *ExecNode) Client() (*rpc.Client, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if n.client == nil {
+		return nil, errNodeNotRunning
+	}
+	return n.client, nil
+}
+
+// Start launches the ged process and waits for its IPC endpoint.
+func (n *ExecNode) Start() (err error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if n.cmd != nil {
+		return errors.New("already started")
+	}
+	port, err := freePort()
+	if err != nil {
+		return err
+	}
+	binary := n.adapter.Binary
+	if binary == "" {
+		binary = "ged"
+	}
+	ipcPath := filepath.Join(n.Dir, "ged.ipc")
+	args := []string{
+		"--datadir", filepath.Join(n.Dir, "data"),
+		"--nodekeyhex", hex.EncodeToString(crypto.FromECDSA(n.config.PrivateKey)),
+		"--port", strconv.Itoa(port),
+		"--nodiscover",
+		"--nat", "none",
+		"--ipcpath", ipcPath,
+		"--maxpeers", "25",
+	}
+	args = append(args, n.adapter.Args...)
+
+	logfile, err := os.OpenFile(filepath.Join(n.Dir, "output.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = logfile
+	cmd.Stderr = logfile
+	if err := cmd.Start(); err != nil {
+		logfile.Close()
+		return fmt.Errorf("error starting node: %s", err)
+	}
+	// The log file is inherited by the process, our handle isn't needed.
+	logfile.Close()
+	defer func() {
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}()
+
+	client, err := dialIPC(ipcPath, execStartTimeout)
+	if err != nil {
+		return fmt.Errorf("can't connect to node IPC endpoint: %v", err)
+	}
+	n.cmd = cmd
+	n.port = port
+	n.client = client
+	n.events = new(event.TypeMux)
+	n.quit = make(chan struct{})
+	n.wg.Add(1)
+	go n.pollPeers(client, n.events, n.quit)
+	log.Debug("Started simulation node process", "id", n.ID, "pid", cmd.Process.Pid, "port", port)
+	return nil
+}
+
+// Stop interrupts the ged process and waits for it to exit.
+func (n *ExecNode) Stop() error {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	if n.cmd == nil {
+		return nil
+	}
+	close(n.quit)
+	n.wg.Wait()
+	n.events.Stop()
+	n.client.Close()
+
+	cmd := n.cmd
+	n.cmd, n.client, n.events = nil, nil, nil
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		return cmd.Process.Kill()
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(execStopTimeout):
+		return cmd.Process.Kill()
+	}
+}
+
+// NodeInfo returns information about the running node, or nil if the node
+// is not running or doesn't respond.
+func (n *ExecNode) NodeInfo() *p2p.NodeInfo {
+	client, err := n.Client()
+	if err != nil {
+		return nil
+	}
+	info := new(p2p.NodeInfo)
+	if err := client.Call(info, "admin_nodeInfo"); err != nil {
+		return nil
+	}
+	return info
+}
+
+// SubscribeEvents subscribes to the peer events of the running node.
+func (n *ExecNode) SubscribeEvents() (event.Subscription, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if n.events == nil {
+		return nil, errNodeNotRunning
+	}
+	return n.events.Subscribe(p2p.PeerEvent{}), nil
+}
+
+// pollPeers periodically retrieves the peers of the process and posts add and
+// drop events for the changes.
+func (n *ExecNode) pollPeers(client *rpc.Client, events *event.TypeMux, quit chan struct{}) {
+	defer n.wg.Done()
+
+	ticker := time.NewTicker(execPollInterval)
+	defer ticker.Stop()
+
+	peers := make(map[discover.NodeID]bool)
+	for {
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+		var infos []*p2p.PeerInfo
+		if err := client.Call(&infos, "admin_peers"); err != nil {
+			log.Trace("Failed to poll simulation node peers", "id", n.ID, "err", err)
+			continue
+		}
+		current := make(map[discover.NodeID]bool, len(infos))
+		for _, info := range infos {
+			id, err := discover.HexID(info.ID)
+			if err != nil {
+				continue
+			}
+			current[id] = true
+			if !peers[id] {
+				events.Post(p2p.PeerEvent{Type: p2p.PeerEventTypeAdd, Peer: id})
+			}
+		}
+		for id := range peers {
+			if !current[id] {
+				events.Post(p2p.PeerEvent{Type: p2p.PeerEventTypeDrop, Peer: id})
+			}
+		}
+		peers = current
+	}
+}
+
+// dialIPC connects to an IPC endpoint, retrying until it becomes available.
+func dialIPC(path string, timeout time.Duration) (*rpc.Client, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		client, err := rpc.DialIPC(ctx, path)
+		cancel()
+		if err == nil {
+			return client, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// freePort returns a TCP port which is currently unused on the loopback
+// interface.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+)
+
+// SimAdapter is a NodeAdapter which creates in-memory nodes. The nodes run
+// node.Services and connect to each other over net.Pipe, no sockets are used.
+type SimAdapter struct {
+	mtx      sync.RWMutex
+	nodes    map[discover.NodeID]*SimNode
+	services Services
+}
+
+// NewSimAdapter creates a SimAdapter which is capable of running the given
+// services in memory.
+func NewSimAdapter(services Services) *SimAdapter {
+	return &SimAdapter{
+		nodes:    make(map[discover.NodeID]*SimNode),
+		services: services,
+	}
+}
+
+// Name returns the name of the adapter for logging purposes.
+func (s *SimAdapter) Name() string {
+	return "sim-adapter"
+}
+
+// NewNode creates a new in-memory node running the configured services.
+func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if config.PrivateKey == nil {
+		return nil, errors.New("node private key missing")
+	}
+	id := config.ID
+	if _, exists := s.nodes[id]; exists {
+		return nil, fmt.Errorf("node already exists: %s", id)
+	}
+	if len(config.Services) == 0 {
+		return nil, errors.New("node must have at least one service")
+	}
+	for _, service := range config.Services {
+		if _, exists := s.services[service]; !exists {
+			return nil, fmt.Errorf("unknown node service %q", service)
+		}
+	}
+
+	n, err := node.New(&node.Config{
+		Name:            config.Name,
+		PrivateKey:      config.PrivateKey,
+		NoDiscovery:     true,
+		NodeDialer:      s,
+		MaxPeers:        25,
+		EnableMsgEvents: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	simNode := &SimNode{
+		ID:      id,
+		config:  config,
+		node:    n,
+		adapter: s,
+	}
+	s.nodes[id] = simNode
+	return simNode, nil
+}
+
+// Dial implements p2p.NodeDialer by connecting to the node through a pipe.
+func (s *SimAdapter) Dial(dest *discover.Node) (net.Conn, error) {
+	node, ok := s.GetNode(dest.ID)
+	if !ok {
+		return nil, fmt.Errorf("unknown node: %s", dest.ID)
+	}
+	srv := node.Server()
+	if srv == nil {
+		return nil, fmt.Errorf("node not running: %s", dest.ID)
+	}
+	pipe1, pipe2 := net.Pipe()
+	go srv.AcceptConn(pipe2)
+	return pipe1, nil
+}
+
+// GetNode returns the node with the given ID if it exists.
+func (s *SimAdapter) GetNode(id discover.NodeID) (*SimNode, bool) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	node, ok := s.nodes[id]
+	return node, ok
+}
+
+// SimNode is an in-memory simulation node which connects to other nodes of
+// its adapter through net.Pipe.
+type SimNode struct {
+	ID      discover.NodeID
+	config  *NodeConfig
+	node    *node.Node
+	adapter *SimAdapter
+
+	registerOnce sync.Once
+	registerErr  error
+}
+
+// Addr returns the node's enode URL.
+func (sn *SimNode) Addr() []byte {
+	return []byte(sn.Node().String())
+}
+
+// Node returns a discover.Node representing the SimNode. The address is a
+// placeholder, connections are made by node ID only.
+func (sn *SimNode) Node() *discover.Node {
+	return discover.NewNode(sn.ID, net.IP{127, 0, 0, 1}, 0, 0)
+}
+
+// Client returns an in-process RPC client of the running node.
+func (sn *SimNode) Client() (*rpc.Client, error) {
+	return sn.node.Attach()
+}
+
+// Start registers the node's services and starts the node.
+func (sn *SimNode) Start() error {
+	// Services can only be registered once, the node creates fresh
+	// instances of them on every start.
+	sn.registerOnce.Do(func() {
+		for _, name := range sn.config.Services {
+			serviceFunc := sn.adapter.services[name]
+			constructor := func(nodeCtx *node.ServiceContext) (node.Service, error) {
+				return serviceFunc(&ServiceContext{
+					Config:      sn.config,
+					NodeContext: nodeCtx,
+				})
+			}
+			if err := sn.node.Register(constructor); err != nil {
+				sn.registerErr = err
+				return
+			}
+		}
+	})
+	if sn.registerErr != nil {
+		return sn.registerErr
+	}
+	return sn.node.Start()
+}
+
+// Stop stops the node.
+func (sn *SimNode) Stop() error {
+	return sn.node.Stop()
+}
+
+// Server returns the node's running p2p server, or nil if the node is stopped.
+func (sn *SimNode) Server() *p2p.Server {
+	return sn.node.Server()
+}
+
+// NodeInfo returns information about the running node, or nil if the node
+// is stopped.
+func (sn *SimNode) NodeInfo() *p2p.NodeInfo {
+	server := sn.Server()
+	if server == nil {
+		return nil
+	}
+	return server.NodeInfo()
+}
+
+// SubscribeEvents subscribes to the peer events of the running node.
+func (sn *SimNode) SubscribeEvents() (event.Subscription, error) {
+	server := sn.Server()
+	if server == nil {
+		return nil, node.ErrNodeStopped
+	}
+	return server.SubscribeEvents(), nil
+}
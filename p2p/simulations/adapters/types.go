@@ -0,0 +1,155 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package adapters implements the ways simulation nodes can be run: in memory,
+// running node.Services over pipes, or as separate ged processes.
+package adapters
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/EarthDollar/go-earthdollar/crypto"
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+)
+
+// NodeAdapter is used to create nodes of a simulation network.
+type NodeAdapter interface {
+	// Name returns the name of the adapter for logging purposes.
+	Name() string
+
+	// NewNode creates a new node with the given configuration.
+	NewNode(config *NodeConfig) (Node, error)
+}
+
+// Node represents a node of a simulation network created by a NodeAdapter.
+type Node interface {
+	// Addr returns the enode URL of the node.
+	Addr() []byte
+
+	// Client returns an RPC client of the running node.
+	Client() (*rpc.Client, error)
+
+	// Start starts the node.
+	Start() error
+
+	// Stop stops the node.
+	Stop() error
+
+	// NodeInfo returns information about the running node.
+	NodeInfo() *p2p.NodeInfo
+
+	// SubscribeEvents subscribes to the peer events of the running node. The
+	// events are delivered as p2p.PeerEvent values, the subscription ends when
+	// the node is stopped.
+	SubscribeEvents() (event.Subscription, error)
+}
+
+// NodeConfig is the configuration used to create a simulation node.
+type NodeConfig struct {
+	// ID is the node's ID, derived from PrivateKey.
+	ID discover.NodeID
+
+	// PrivateKey is the node's private key used to identify it in the network.
+	PrivateKey *ecdsa.PrivateKey
+
+	// Name is a human friendly name of the node, e.g. "node01".
+	Name string
+
+	// Services are the names of the services run by the node. Only used by
+	// adapters running services in memory, ged processes run their default
+	// protocols.
+	Services []string
+}
+
+// nodeConfigJSON is the JSON representation of NodeConfig, which encodes the
+// private key as hex.
+type nodeConfigJSON struct {
+	ID         string   `json:"id"`
+	PrivateKey string   `json:"private_key"`
+	Name       string   `json:"name"`
+	Services   []string `json:"services"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *NodeConfig) MarshalJSON() ([]byte, error) {
+	confJSON := nodeConfigJSON{
+		ID:       n.ID.String(),
+		Name:     n.Name,
+		Services: n.Services,
+	}
+	if n.PrivateKey != nil {
+		confJSON.PrivateKey = hex.EncodeToString(crypto.FromECDSA(n.PrivateKey))
+	}
+	return json.Marshal(confJSON)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *NodeConfig) UnmarshalJSON(data []byte) error {
+	var confJSON nodeConfigJSON
+	if err := json.Unmarshal(data, &confJSON); err != nil {
+		return err
+	}
+	if confJSON.ID != "" {
+		id, err := discover.HexID(confJSON.ID)
+		if err != nil {
+			return err
+		}
+		n.ID = id
+	}
+	if confJSON.PrivateKey != "" {
+		key, err := crypto.HexToECDSA(confJSON.PrivateKey)
+		if err != nil {
+			return err
+		}
+		n.PrivateKey = key
+	}
+	n.Name = confJSON.Name
+	n.Services = confJSON.Services
+	return nil
+}
+
+// RandomNodeConfig returns a node configuration with a random private key.
+func RandomNodeConfig() *NodeConfig {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		panic("unable to generate key")
+	}
+	id := discover.PubkeyID(&key.PublicKey)
+	return &NodeConfig{
+		ID:         id,
+		PrivateKey: key,
+		Name:       fmt.Sprintf("node-%x", id[:4]),
+	}
+}
+
+// ServiceContext is passed to the constructors of simulation services.
+type ServiceContext struct {
+	Config      *NodeConfig
+	NodeContext *node.ServiceContext
+}
+
+// ServiceFunc creates a node.Service of a simulation node.
+type ServiceFunc func(ctx *ServiceContext) (node.Service, error)
+
+// Services maps service names to their constructors.
+type Services map[string]ServiceFunc
@@ -0,0 +1,491 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/simulations/adapters"
+)
+
+// Server is an HTTP server providing an API to manage a simulation network.
+//
+// The API consists of the following endpoints:
+//
+//	GET    /                          network nodes and connections
+//	GET    /events                    stream of network events (server-sent events)
+//	GET    /snapshot                  snapshot of the network
+//	POST   /snapshot                  load a snapshot into the network
+//	GET    /nodes                     info of all nodes
+//	POST   /nodes                     create a node, the body may hold its config
+//	GET    /nodes/<node>              info of a node
+//	POST   /nodes/<node>/start        start a node
+//	POST   /nodes/<node>/stop         stop a node
+//	POST   /nodes/<node>/conn/<peer>  connect a node to a peer
+//	DELETE /nodes/<node>/conn/<peer>  disconnect a node from a peer
+//
+// Nodes are referred to by their hex ID or name.
+type Server struct {
+	network *Network
+}
+
+// NewServer returns a Server serving the API of the given network.
+func NewServer(network *Network) *Server {
+	return &Server{network: network}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(path) == 1 && path[0] == "":
+		s.route(w, req, s.getNetwork, nil, nil)
+	case len(path) == 1 && path[0] == "events":
+		s.route(w, req, s.streamEvents, nil, nil)
+	case len(path) == 1 && path[0] == "snapshot":
+		s.route(w, req, s.createSnapshot, s.loadSnapshot, nil)
+	case len(path) == 1 && path[0] == "nodes":
+		s.route(w, req, s.getNodes, s.createNode, nil)
+	case len(path) >= 2 && path[0] == "nodes":
+		node := s.network.lookupNode(path[1])
+		if node == nil {
+			http.Error(w, errNodeNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		s.serveNode(w, req, node, path[2:])
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// serveNode handles the requests below /nodes/<node>.
+func (s *Server) serveNode(w http.ResponseWriter, req *http.Request, node *Node, path []string) {
+	withNode := func(handler func(http.ResponseWriter, *http.Request, *Node)) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) { handler(w, req, node) }
+	}
+	switch {
+	case len(path) == 0:
+		s.route(w, req, withNode(s.getNode), nil, nil)
+	case len(path) == 1 && path[0] == "start":
+		s.route(w, req, nil, withNode(s.startNode), nil)
+	case len(path) == 1 && path[0] == "stop":
+		s.route(w, req, nil, withNode(s.stopNode), nil)
+	case len(path) == 2 && path[0] == "conn":
+		peer := s.network.lookupNode(path[1])
+		if peer == nil {
+			http.Error(w, errNodeNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		s.route(w, req, nil,
+			func(w http.ResponseWriter, req *http.Request) { s.connectNode(w, req, node, peer) },
+			func(w http.ResponseWriter, req *http.Request) { s.disconnectNode(w, req, node, peer) },
+		)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// route dispatches a request to the handler of its method. Nil handlers
+// reject the method.
+func (s *Server) route(w http.ResponseWriter, req *http.Request, get, post, del http.HandlerFunc) {
+	var handler http.HandlerFunc
+	switch req.Method {
+	case "GET":
+		handler = get
+	case "POST":
+		handler = post
+	case "DELETE":
+		handler = del
+	}
+	if handler == nil {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	handler(w, req)
+}
+
+// getNetwork returns the nodes and connections of the network.
+func (s *Server) getNetwork(w http.ResponseWriter, req *http.Request) {
+	s.network.lock.RLock()
+	defer s.network.lock.RUnlock()
+	s.JSON(w, http.StatusOK, s.network)
+}
+
+// streamEvents streams the events of the network to the client as
+// server-sent events until the client disconnects.
+func (s *Server) streamEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	sub := s.network.SubscribeEvents()
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+	for {
+		select {
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev.Data)
+			if err != nil {
+				log.Error("Failed to encode simulation event", "err", err)
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: network\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-closed:
+			return
+		}
+	}
+}
+
+// createSnapshot returns a snapshot of the network.
+func (s *Server) createSnapshot(w http.ResponseWriter, req *http.Request) {
+	s.JSON(w, http.StatusOK, s.network.Snapshot())
+}
+
+// loadSnapshot loads the snapshot in the request body into the network.
+func (s *Server) loadSnapshot(w http.ResponseWriter, req *http.Request) {
+	snap := new(Snapshot)
+	if err := json.NewDecoder(req.Body).Decode(snap); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.network.Load(snap); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.JSON(w, http.StatusOK, s.network.Snapshot())
+}
+
+// getNodes returns the info of all nodes.
+func (s *Server) getNodes(w http.ResponseWriter, req *http.Request) {
+	nodes := s.network.GetNodes()
+	infos := make([]*p2p.NodeInfo, len(nodes))
+	for i, node := range nodes {
+		infos[i] = node.NodeInfo()
+	}
+	s.JSON(w, http.StatusOK, infos)
+}
+
+// createNode creates a node with the config in the request body, or a random
+// config if the body is empty.
+func (s *Server) createNode(w http.ResponseWriter, req *http.Request) {
+	config := new(adapters.NodeConfig)
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if config.PrivateKey == nil {
+		random := adapters.RandomNodeConfig()
+		config.ID, config.PrivateKey = random.ID, random.PrivateKey
+	}
+	node, err := s.network.NewNodeWithConfig(config)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.JSON(w, http.StatusCreated, node.NodeInfo())
+}
+
+// getNode returns the info of a node.
+func (s *Server) getNode(w http.ResponseWriter, req *http.Request, node *Node) {
+	s.JSON(w, http.StatusOK, node.NodeInfo())
+}
+
+// startNode starts a node.
+func (s *Server) startNode(w http.ResponseWriter, req *http.Request, node *Node) {
+	if err := s.network.Start(node.ID()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.JSON(w, http.StatusOK, node.NodeInfo())
+}
+
+// stopNode stops a node.
+func (s *Server) stopNode(w http.ResponseWriter, req *http.Request, node *Node) {
+	if err := s.network.Stop(node.ID()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.JSON(w, http.StatusOK, node.NodeInfo())
+}
+
+// connectNode connects a node to a peer.
+func (s *Server) connectNode(w http.ResponseWriter, req *http.Request, node, peer *Node) {
+	if err := s.network.Connect(node.ID(), peer.ID()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.JSON(w, http.StatusOK, node.NodeInfo())
+}
+
+// disconnectNode disconnects a node from a peer.
+func (s *Server) disconnectNode(w http.ResponseWriter, req *http.Request, node, peer *Node) {
+	if err := s.network.Disconnect(node.ID(), peer.ID()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.JSON(w, http.StatusOK, node.NodeInfo())
+}
+
+// JSON sends data as a JSON HTTP response.
+func (s *Server) JSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// lookupNode returns the node with the given hex ID or name, or nil if none
+// exists.
+func (net *Network) lookupNode(ref string) *Node {
+	if id, err := discover.HexID(ref); err == nil {
+		if node := net.GetNode(id); node != nil {
+			return node
+		}
+	}
+	return net.GetNodeByName(ref)
+}
+
+// Client is a client of the simulation network HTTP API.
+type Client struct {
+	URL string
+
+	client *http.Client
+}
+
+// NewClient returns a Client of the API served at the given base URL.
+func NewClient(url string) *Client {
+	return &Client{
+		URL:    url,
+		client: http.DefaultClient,
+	}
+}
+
+// GetNetwork returns the nodes and connections of the network.
+func (c *Client) GetNetwork() (*Network, error) {
+	network := new(Network)
+	return network, c.Get("/", network)
+}
+
+// CreateSnapshot returns a snapshot of the network.
+func (c *Client) CreateSnapshot() (*Snapshot, error) {
+	snap := new(Snapshot)
+	return snap, c.Get("/snapshot", snap)
+}
+
+// LoadSnapshot loads a snapshot into the network.
+func (c *Client) LoadSnapshot(snap *Snapshot) error {
+	return c.Post("/snapshot", snap, nil)
+}
+
+// GetNodes returns the info of all nodes.
+func (c *Client) GetNodes() ([]*p2p.NodeInfo, error) {
+	var nodes []*p2p.NodeInfo
+	return nodes, c.Get("/nodes", &nodes)
+}
+
+// CreateNode creates a node with the given config, or a random config if nil.
+func (c *Client) CreateNode(config *adapters.NodeConfig) (*p2p.NodeInfo, error) {
+	node := new(p2p.NodeInfo)
+	return node, c.Post("/nodes", config, node)
+}
+
+// GetNode returns the info of a node.
+func (c *Client) GetNode(nodeID string) (*p2p.NodeInfo, error) {
+	node := new(p2p.NodeInfo)
+	return node, c.Get("/nodes/"+nodeID, node)
+}
+
+// StartNode starts a node.
+func (c *Client) StartNode(nodeID string) error {
+	return c.Post("/nodes/"+nodeID+"/start", nil, nil)
+}
+
+// StopNode stops a node.
+func (c *Client) StopNode(nodeID string) error {
+	return c.Post("/nodes/"+nodeID+"/stop", nil, nil)
+}
+
+// ConnectNode connects a node to a peer.
+func (c *Client) ConnectNode(nodeID, peerID string) error {
+	return c.Post("/nodes/"+nodeID+"/conn/"+peerID, nil, nil)
+}
+
+// DisconnectNode disconnects a node from a peer.
+func (c *Client) DisconnectNode(nodeID, peerID string) error {
+	return c.Delete("/nodes/" + nodeID + "/conn/" + peerID)
+}
+
+// SubscribeNetwork subscribes to the events of the network. The events are
+// sent to the given channel until the stream is closed.
+func (c *Client) SubscribeNetwork(events chan<- *Event) (*EventStream, error) {
+	req, err := http.NewRequest("GET", c.URL+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		response, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected HTTP status: %s: %s", res.Status, response)
+	}
+	stream := &EventStream{body: res.Body, quit: make(chan struct{})}
+	go stream.read(events)
+	return stream, nil
+}
+
+// Get performs a HTTP GET request decoding the JSON response into out.
+func (c *Client) Get(path string, out interface{}) error {
+	return c.Send("GET", path, nil, out)
+}
+
+// Post performs a HTTP POST request sending in as JSON and decoding the JSON
+// response into out.
+func (c *Client) Post(path string, in, out interface{}) error {
+	return c.Send("POST", path, in, out)
+}
+
+// Delete performs a HTTP DELETE request.
+func (c *Client) Delete(path string) error {
+	return c.Send("DELETE", path, nil, nil)
+}
+
+// Send performs a HTTP request, sending in as JSON and decoding the JSON
+// response into out. Nil values are not sent or decoded.
+func (c *Client) Send(method, path string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		response, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("unexpected HTTP status: %s: %s", res.Status, bytes.TrimSpace(response))
+	}
+	if out != nil {
+		return json.NewDecoder(res.Body).Decode(out)
+	}
+	return nil
+}
+
+// EventStream is a stream of network events received by a Client.
+type EventStream struct {
+	body io.ReadCloser
+
+	closeOnce sync.Once
+	quit      chan struct{}
+	err       error
+}
+
+// Close ends the stream.
+func (s *EventStream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.quit)
+		s.body.Close()
+	})
+}
+
+// Err returns the error which ended the stream, if any. It must only be
+// called after the stream has been closed.
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+// read decodes server-sent events from the response body until it is closed.
+func (s *EventStream) read(events chan<- *Event) {
+	defer s.body.Close()
+
+	scanner := bufio.NewScanner(s.body)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		ev := new(Event)
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), ev); err != nil {
+			s.err = err
+			return
+		}
+		select {
+		case events <- ev:
+		case <-s.quit:
+			return
+		}
+	}
+	select {
+	case <-s.quit:
+	default:
+		if err := scanner.Err(); err != nil {
+			s.err = err
+		} else {
+			s.err = errors.New("event stream closed by server")
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+)
+
+func TestHTTPNetwork(t *testing.T) {
+	network := newTestNetwork()
+	defer network.Shutdown()
+	s := httptest.NewServer(NewServer(network))
+	defer s.Close()
+	client := NewClient(s.URL)
+
+	events := make(chan *Event, 100)
+	stream, err := client.SubscribeNetwork(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	// Create and start two nodes, referring to them by name and ID
+	one, err := client.CreateNode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := client.CreateNode(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartNode(one.Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartNode(other.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartNode(other.ID); err == nil {
+		t.Error("starting running node succeeded")
+	}
+	if err := client.ConnectNode(one.ID, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the events of the stream
+	want := []string{
+		"node " + one.ID + " false",
+		"node " + other.ID + " false",
+		"node " + one.ID + " true",
+		"node " + other.ID + " true",
+		"conn true",
+	}
+	timeout := time.After(5 * time.Second)
+	for len(want) > 0 {
+		select {
+		case ev := <-events:
+			var have string
+			switch ev.Type {
+			case EventTypeNode:
+				have = fmt.Sprintf("node %s %t", ev.Node.ID(), ev.Node.Up)
+			case EventTypeConn:
+				have = fmt.Sprintf("conn %t", ev.Conn.Up)
+			default:
+				continue
+			}
+			if have != want[0] {
+				t.Fatalf("event mismatch:\nhave %s\nwant %s", have, want[0])
+			}
+			want = want[1:]
+		case <-timeout:
+			t.Fatalf("timed out waiting for event %q", want[0])
+		}
+	}
+
+	// Check the state reported by the API
+	nodes, err := client.GetNodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].ID != one.ID || nodes[1].ID != other.ID {
+		t.Errorf("node list mismatch: %v", nodes)
+	}
+	net, err := client.GetNetwork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(net.Nodes) != 2 || len(net.Conns) != 1 || !net.Conns[0].Up {
+		t.Errorf("network mismatch: %d nodes, %d conns", len(net.Nodes), len(net.Conns))
+	}
+	snap, err := client.CreateSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Nodes) != 2 || snap.Nodes[0].Node.Config.PrivateKey == nil {
+		t.Errorf("snapshot mismatch: %v", snap)
+	}
+
+	if err := client.StopNode(one.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetNode(discover.NodeID{}.String()); err == nil {
+		t.Error("no error for unknown node")
+	}
+}
@@ -0,0 +1,550 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulations simulates p2p networks.
+//
+// A Network is a set of nodes and the connections between them. The nodes are
+// created by a NodeAdapter, which either runs them in memory or as separate
+// processes. The network can be controlled and observed through its methods
+// and through the HTTP API served by Server.
+package simulations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/log"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/simulations/adapters"
+)
+
+var (
+	errNodeNotFound = errors.New("node not found")
+	errNodeUp       = errors.New("node already up")
+	errNodeDown     = errors.New("node not up")
+	errSelfConnect  = errors.New("node can't connect to itself")
+)
+
+// NetworkConfig defines configuration options for creating a Network.
+type NetworkConfig struct {
+	// DefaultService is the service run by nodes created without
+	// an explicit list of services.
+	DefaultService string
+}
+
+// Network models a p2p simulation network which consists of a collection of
+// simulated nodes and the connections which exist between them.
+type Network struct {
+	NetworkConfig
+
+	Nodes   []*Node `json:"nodes"`
+	nodeMap map[discover.NodeID]int
+
+	Conns   []*Conn `json:"conns"`
+	connMap map[string]int
+
+	nodeAdapter adapters.NodeAdapter
+	events      event.TypeMux
+	lock        sync.RWMutex
+	watchers    sync.WaitGroup
+}
+
+// NewNetwork returns a Network which uses the given NodeAdapter and
+// NetworkConfig.
+func NewNetwork(nodeAdapter adapters.NodeAdapter, conf *NetworkConfig) *Network {
+	return &Network{
+		NetworkConfig: *conf,
+		nodeAdapter:   nodeAdapter,
+		nodeMap:       make(map[discover.NodeID]int),
+		connMap:       make(map[string]int),
+	}
+}
+
+// SubscribeEvents subscribes to the events of the network. The events are
+// delivered as *Event values.
+func (net *Network) SubscribeEvents() event.Subscription {
+	return net.events.Subscribe(&Event{})
+}
+
+// NewNode adds a new node to the network with a random configuration.
+func (net *Network) NewNode() (*Node, error) {
+	conf := adapters.RandomNodeConfig()
+	if net.DefaultService != "" {
+		conf.Services = []string{net.DefaultService}
+	}
+	return net.NewNodeWithConfig(conf)
+}
+
+// NewNodeWithConfig adds a new node to the network with the given config.
+// Nodes without services run the network's default service.
+func (net *Network) NewNodeWithConfig(conf *adapters.NodeConfig) (*Node, error) {
+	node, err := net.addNode(conf)
+	if err != nil {
+		return nil, err
+	}
+	net.events.Post(ControlEvent(node))
+	return node, nil
+}
+
+// addNode creates a node through the adapter and adds it to the network.
+func (net *Network) addNode(conf *adapters.NodeConfig) (*Node, error) {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+
+	if conf.PrivateKey == nil {
+		return nil, errors.New("node private key missing")
+	}
+	id := discover.PubkeyID(&conf.PrivateKey.PublicKey)
+	if conf.ID != (discover.NodeID{}) && conf.ID != id {
+		return nil, errors.New("node ID doesn't match private key")
+	}
+	conf.ID = id
+	if conf.Name == "" {
+		conf.Name = fmt.Sprintf("node%02d", len(net.Nodes)+1)
+	}
+	if _, exists := net.nodeMap[id]; exists {
+		return nil, fmt.Errorf("node with ID %q already exists", id)
+	}
+	if net.getNodeByName(conf.Name) != nil {
+		return nil, fmt.Errorf("node with name %q already exists", conf.Name)
+	}
+	if len(conf.Services) == 0 && net.DefaultService != "" {
+		conf.Services = []string{net.DefaultService}
+	}
+
+	adapterNode, err := net.nodeAdapter.NewNode(conf)
+	if err != nil {
+		return nil, err
+	}
+	node := &Node{Node: adapterNode, Config: conf}
+	log.Trace("Created simulation node", "id", id, "name", conf.Name, "adapter", net.nodeAdapter.Name())
+	net.nodeMap[id] = len(net.Nodes)
+	net.Nodes = append(net.Nodes, node)
+	return node, nil
+}
+
+// Start starts the node with the given ID.
+func (net *Network) Start(id discover.NodeID) error {
+	node, err := net.nodeInState(id, false)
+	if err != nil {
+		return err
+	}
+	log.Trace("Starting simulation node", "id", id, "adapter", net.nodeAdapter.Name())
+	if err := node.Start(); err != nil {
+		log.Warn("Simulation node failed to start", "id", id, "err", err)
+		return err
+	}
+	sub, err := node.SubscribeEvents()
+	if err != nil {
+		node.Stop()
+		return err
+	}
+
+	net.lock.Lock()
+	node.Up = true
+	ev := ControlEvent(node)
+	net.lock.Unlock()
+	net.events.Post(ev)
+
+	net.watchers.Add(1)
+	go net.watchPeerEvents(id, sub)
+	return nil
+}
+
+// watchPeerEvents tracks the peer events of a running node, updating the
+// connections of the network and emitting message events.
+func (net *Network) watchPeerEvents(id discover.NodeID, sub event.Subscription) {
+	defer net.watchers.Done()
+	defer sub.Unsubscribe()
+
+	for ev := range sub.Chan() {
+		pe, ok := ev.Data.(p2p.PeerEvent)
+		if !ok {
+			continue
+		}
+		switch pe.Type {
+		case p2p.PeerEventTypeAdd:
+			net.didConnect(id, pe.Peer)
+		case p2p.PeerEventTypeDrop:
+			net.didDisconnect(id, pe.Peer)
+		case p2p.PeerEventTypeMsgSend:
+			net.didSend(id, pe.Peer, pe.Protocol, *pe.MsgCode)
+		case p2p.PeerEventTypeMsgRecv:
+			net.didReceive(pe.Peer, id, pe.Protocol, *pe.MsgCode)
+		}
+	}
+}
+
+// Stop stops the node with the given ID. The connections of the node are
+// marked as down.
+func (net *Network) Stop(id discover.NodeID) error {
+	node, err := net.nodeInState(id, true)
+	if err != nil {
+		return err
+	}
+	if err := node.Stop(); err != nil {
+		return err
+	}
+
+	net.lock.Lock()
+	node.Up = false
+	events := []*Event{ControlEvent(node)}
+	for _, conn := range net.Conns {
+		if conn.Up && (conn.One == id || conn.Other == id) {
+			conn.Up = false
+			events = append(events, NewEvent(conn))
+		}
+	}
+	net.lock.Unlock()
+
+	log.Trace("Stopped simulation node", "id", id)
+	for _, ev := range events {
+		net.events.Post(ev)
+	}
+	return nil
+}
+
+// nodeInState returns the node with the given ID if it is in the given state.
+func (net *Network) nodeInState(id discover.NodeID, up bool) (*Node, error) {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+
+	node := net.getNode(id)
+	switch {
+	case node == nil:
+		return nil, errNodeNotFound
+	case node.Up && !up:
+		return nil, errNodeUp
+	case !node.Up && up:
+		return nil, errNodeDown
+	}
+	return node, nil
+}
+
+// Connect connects two nodes by making the first one add the second one as
+// a peer. The connection is marked up once the nodes report the new peer.
+func (net *Network) Connect(oneID, otherID discover.NodeID) error {
+	one, other, err := net.connectable(oneID, otherID)
+	if err != nil {
+		return err
+	}
+	if conn := net.GetConn(oneID, otherID); conn != nil && conn.Up {
+		return fmt.Errorf("%v and %v already connected", oneID, otherID)
+	}
+	client, err := one.Client()
+	if err != nil {
+		return err
+	}
+	log.Trace("Connecting simulation nodes", "one", oneID, "other", otherID)
+	return client.Call(nil, "admin_addPeer", string(other.Addr()))
+}
+
+// Disconnect disconnects two nodes by making the first one remove the second
+// one as a peer.
+func (net *Network) Disconnect(oneID, otherID discover.NodeID) error {
+	one, other, err := net.connectable(oneID, otherID)
+	if err != nil {
+		return err
+	}
+	if conn := net.GetConn(oneID, otherID); conn == nil || !conn.Up {
+		return fmt.Errorf("%v and %v not connected", oneID, otherID)
+	}
+	client, err := one.Client()
+	if err != nil {
+		return err
+	}
+	log.Trace("Disconnecting simulation nodes", "one", oneID, "other", otherID)
+	return client.Call(nil, "admin_removePeer", string(other.Addr()))
+}
+
+// connectable returns the nodes of a prospective connection if both exist
+// and are up.
+func (net *Network) connectable(oneID, otherID discover.NodeID) (*Node, *Node, error) {
+	if oneID == otherID {
+		return nil, nil, errSelfConnect
+	}
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+
+	one, other := net.getNode(oneID), net.getNode(otherID)
+	if one == nil || other == nil {
+		return nil, nil, errNodeNotFound
+	}
+	if !one.Up || !other.Up {
+		return nil, nil, errNodeDown
+	}
+	return one, other, nil
+}
+
+// didConnect marks the connection between two nodes as up.
+func (net *Network) didConnect(one, other discover.NodeID) {
+	net.lock.Lock()
+	if net.getNode(other) == nil {
+		net.lock.Unlock()
+		return
+	}
+	conn := net.getOrCreateConn(one, other)
+	if conn.Up {
+		net.lock.Unlock()
+		return
+	}
+	conn.Up = true
+	ev := NewEvent(conn)
+	net.lock.Unlock()
+	net.events.Post(ev)
+}
+
+// didDisconnect marks the connection between two nodes as down.
+func (net *Network) didDisconnect(one, other discover.NodeID) {
+	net.lock.Lock()
+	conn := net.getConn(one, other)
+	if conn == nil || !conn.Up {
+		net.lock.Unlock()
+		return
+	}
+	conn.Up = false
+	ev := NewEvent(conn)
+	net.lock.Unlock()
+	net.events.Post(ev)
+}
+
+// didSend emits an event for a message sent from one node to another.
+func (net *Network) didSend(sender, receiver discover.NodeID, proto string, code uint64) {
+	msg := &Msg{One: sender, Other: receiver, Protocol: proto, Code: code}
+	net.events.Post(NewEvent(msg))
+}
+
+// didReceive emits an event for a message received by one node from another.
+func (net *Network) didReceive(sender, receiver discover.NodeID, proto string, code uint64) {
+	msg := &Msg{One: sender, Other: receiver, Protocol: proto, Code: code, Received: true}
+	net.events.Post(NewEvent(msg))
+}
+
+// GetNode returns the node with the given ID, or nil if it doesn't exist.
+func (net *Network) GetNode(id discover.NodeID) *Node {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+	return net.getNode(id)
+}
+
+// GetNodeByName returns the node with the given name, or nil if it doesn't
+// exist.
+func (net *Network) GetNodeByName(name string) *Node {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+	return net.getNodeByName(name)
+}
+
+// GetNodes returns the existing nodes.
+func (net *Network) GetNodes() []*Node {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+	nodes := make([]*Node, len(net.Nodes))
+	copy(nodes, net.Nodes)
+	return nodes
+}
+
+func (net *Network) getNode(id discover.NodeID) *Node {
+	i, found := net.nodeMap[id]
+	if !found {
+		return nil
+	}
+	return net.Nodes[i]
+}
+
+func (net *Network) getNodeByName(name string) *Node {
+	for _, node := range net.Nodes {
+		if node.Config.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// GetConn returns the connection between two nodes in either direction, or
+// nil if it doesn't exist.
+func (net *Network) GetConn(oneID, otherID discover.NodeID) *Conn {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+	return net.getConn(oneID, otherID)
+}
+
+func (net *Network) getConn(oneID, otherID discover.NodeID) *Conn {
+	i, found := net.connMap[connLabel(oneID, otherID)]
+	if !found {
+		return nil
+	}
+	return net.Conns[i]
+}
+
+func (net *Network) getOrCreateConn(oneID, otherID discover.NodeID) *Conn {
+	if conn := net.getConn(oneID, otherID); conn != nil {
+		return conn
+	}
+	conn := &Conn{One: oneID, Other: otherID}
+	net.connMap[connLabel(oneID, otherID)] = len(net.Conns)
+	net.Conns = append(net.Conns, conn)
+	return conn
+}
+
+// Shutdown stops all running nodes of the network and ends the event
+// subscriptions.
+func (net *Network) Shutdown() {
+	for _, node := range net.GetNodes() {
+		log.Debug("Stopping simulation node", "id", node.ID())
+		if err := net.Stop(node.ID()); err != nil && err != errNodeDown {
+			log.Warn("Can't stop simulation node", "id", node.ID(), "err", err)
+		}
+	}
+	net.watchers.Wait()
+	net.events.Stop()
+}
+
+// Snapshot creates a snapshot of the network's nodes and connections.
+func (net *Network) Snapshot() *Snapshot {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+
+	snap := &Snapshot{
+		Nodes: make([]NodeSnapshot, len(net.Nodes)),
+		Conns: make([]Conn, len(net.Conns)),
+	}
+	for i, node := range net.Nodes {
+		snap.Nodes[i] = NodeSnapshot{Node: Node{Config: node.Config, Up: node.Up}}
+	}
+	for i, conn := range net.Conns {
+		snap.Conns[i] = *conn
+	}
+	return snap
+}
+
+// Load creates the nodes of a snapshot, starting the ones which are up, and
+// connects the nodes whose connections are up. Connections are established
+// asynchronously, they are reported through the event subscription.
+func (net *Network) Load(snap *Snapshot) error {
+	for _, n := range snap.Nodes {
+		if _, err := net.NewNodeWithConfig(n.Node.Config); err != nil {
+			return err
+		}
+		if !n.Node.Up {
+			continue
+		}
+		if err := net.Start(n.Node.Config.ID); err != nil {
+			return err
+		}
+	}
+	for _, conn := range snap.Conns {
+		if !conn.Up {
+			continue
+		}
+		if err := net.Connect(conn.One, conn.Other); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Node is a node of a simulation network.
+type Node struct {
+	adapters.Node `json:"-"`
+
+	// Config is the configuration used to create the node.
+	Config *adapters.NodeConfig `json:"config"`
+
+	// Up tracks whether the node is running.
+	Up bool `json:"up"`
+}
+
+// ID returns the ID of the node.
+func (n *Node) ID() discover.NodeID {
+	return n.Config.ID
+}
+
+// String returns a log-friendly string.
+func (n *Node) String() string {
+	return fmt.Sprintf("Node %v", n.ID())
+}
+
+// NodeInfo returns information about the node. Nodes which aren't running
+// only report their identity.
+func (n *Node) NodeInfo() *p2p.NodeInfo {
+	if n.Node != nil && n.Up {
+		if info := n.Node.NodeInfo(); info != nil {
+			return info
+		}
+	}
+	return &p2p.NodeInfo{
+		ID:    n.ID().String(),
+		Name:  n.Config.Name,
+		Enode: string(n.Addr()),
+	}
+}
+
+// Conn is a connection between two nodes of a simulation network.
+type Conn struct {
+	// One is the node which initiated the connection.
+	One discover.NodeID `json:"one"`
+
+	// Other is the node which was connected to.
+	Other discover.NodeID `json:"other"`
+
+	// Up tracks whether the connection is active.
+	Up bool `json:"up"`
+}
+
+// String returns a log-friendly string.
+func (c *Conn) String() string {
+	return fmt.Sprintf("Conn %x->%x", c.One[:8], c.Other[:8])
+}
+
+// Msg is a p2p message sent between two nodes of a simulation network.
+type Msg struct {
+	One      discover.NodeID `json:"one"`
+	Other    discover.NodeID `json:"other"`
+	Protocol string          `json:"protocol"`
+	Code     uint64          `json:"code"`
+	Received bool            `json:"received"`
+}
+
+// String returns a log-friendly string.
+func (m *Msg) String() string {
+	return fmt.Sprintf("Msg(%d) %x->%x", m.Code, m.One[:8], m.Other[:8])
+}
+
+// Snapshot represents the state of a network at a point in time, it can be
+// used to restore the network's topology.
+type Snapshot struct {
+	Nodes []NodeSnapshot `json:"nodes,omitempty"`
+	Conns []Conn         `json:"conns,omitempty"`
+}
+
+// NodeSnapshot represents the state of a node in a snapshot.
+type NodeSnapshot struct {
+	Node Node `json:"node,omitempty"`
+}
+
+// connLabel returns a key identifying the connection between two nodes,
+// independent of their order.
+func connLabel(source, target discover.NodeID) string {
+	if bytes.Compare(source[:], target[:]) > 0 {
+		source, target = target, source
+	}
+	return fmt.Sprintf("%v-%v", source, target)
+}
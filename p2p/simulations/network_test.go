@@ -0,0 +1,245 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/event"
+	"github.com/EarthDollar/go-earthdollar/node"
+	"github.com/EarthDollar/go-earthdollar/p2p"
+	"github.com/EarthDollar/go-earthdollar/p2p/discover"
+	"github.com/EarthDollar/go-earthdollar/p2p/simulations/adapters"
+	"github.com/EarthDollar/go-earthdollar/rpc"
+)
+
+// testService runs a protocol which sends a single message to every peer.
+type testService struct{}
+
+func newTestService(ctx *adapters.ServiceContext) (node.Service, error) {
+	return &testService{}, nil
+}
+
+func (s *testService) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    "test",
+		Version: 1,
+		Length:  1,
+		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+			if err := p2p.Send(rw, 0, "hello"); err != nil {
+				return err
+			}
+			for {
+				msg, err := rw.ReadMsg()
+				if err != nil {
+					return err
+				}
+				msg.Discard()
+			}
+		},
+	}}
+}
+
+func (s *testService) APIs() []rpc.API         { return nil }
+func (s *testService) Start(*p2p.Server) error { return nil }
+func (s *testService) Stop() error             { return nil }
+
+func newTestNetwork() *Network {
+	adapter := adapters.NewSimAdapter(adapters.Services{"test": newTestService})
+	return NewNetwork(adapter, &NetworkConfig{DefaultService: "test"})
+}
+
+// startNodes creates and starts n nodes.
+func startNodes(t *testing.T, net *Network, n int) []discover.NodeID {
+	ids := make([]discover.NodeID, n)
+	for i := range ids {
+		node, err := net.NewNode()
+		if err != nil {
+			t.Fatalf("error creating node: %v", err)
+		}
+		if err := net.Start(node.ID()); err != nil {
+			t.Fatalf("error starting node: %v", err)
+		}
+		ids[i] = node.ID()
+	}
+	return ids
+}
+
+// subscribeEvents forwards the events of the network to a buffered channel,
+// keeping the network from blocking on a test which doesn't read them.
+func subscribeEvents(net *Network) (chan *Event, event.Subscription) {
+	sub := net.SubscribeEvents()
+	events := make(chan *Event, 1000)
+	go func() {
+		for ev := range sub.Chan() {
+			events <- ev.Data.(*Event)
+		}
+	}()
+	return events, sub
+}
+
+// waitForConns waits until the connections between the given pairs of nodes
+// reach the given state.
+func waitForConns(t *testing.T, events chan *Event, up bool, pairs ...[2]discover.NodeID) {
+	pending := make(map[string]bool)
+	for _, pair := range pairs {
+		pending[connLabel(pair[0], pair[1])] = true
+	}
+	timeout := time.After(5 * time.Second)
+	for len(pending) > 0 {
+		select {
+		case e := <-events:
+			if e.Type == EventTypeConn && e.Conn.Up == up {
+				delete(pending, connLabel(e.Conn.One, e.Conn.Other))
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %d connections (up: %t)", len(pending), up)
+		}
+	}
+}
+
+func TestNetworkConnections(t *testing.T) {
+	net := newTestNetwork()
+	defer net.Shutdown()
+
+	events, sub := subscribeEvents(net)
+	defer sub.Unsubscribe()
+
+	ids := startNodes(t, net, 3)
+	if err := net.Connect(ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.Connect(ids[1], ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	waitForConns(t, events, true, [2]discover.NodeID{ids[0], ids[1]}, [2]discover.NodeID{ids[1], ids[2]})
+
+	if conn := net.GetConn(ids[1], ids[0]); conn == nil || !conn.Up {
+		t.Errorf("connection 0-1 not up: %v", conn)
+	}
+	if conn := net.GetConn(ids[0], ids[2]); conn != nil {
+		t.Errorf("unexpected connection 0-2: %v", conn)
+	}
+	if err := net.Connect(ids[0], ids[1]); err == nil {
+		t.Error("connecting connected nodes succeeded")
+	}
+	if err := net.Connect(ids[0], ids[0]); err != errSelfConnect {
+		t.Errorf("self connect error mismatch: have %v, want %v", err, errSelfConnect)
+	}
+
+	if err := net.Disconnect(ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	waitForConns(t, events, false, [2]discover.NodeID{ids[0], ids[1]})
+
+	// Stopping a node drops its connections
+	if err := net.Stop(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	if conn := net.GetConn(ids[1], ids[2]); conn == nil || conn.Up {
+		t.Errorf("connection 1-2 of stopped node still up: %v", conn)
+	}
+	if err := net.Connect(ids[1], ids[2]); err != errNodeDown {
+		t.Errorf("connect to stopped node error mismatch: have %v, want %v", err, errNodeDown)
+	}
+}
+
+func TestNetworkMessageEvents(t *testing.T) {
+	net := newTestNetwork()
+	defer net.Shutdown()
+
+	events, sub := subscribeEvents(net)
+	defer sub.Unsubscribe()
+
+	ids := startNodes(t, net, 2)
+	if err := net.Connect(ids[0], ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	// Each node sends one message which the other one receives.
+	want := map[Msg]bool{
+		{One: ids[0], Other: ids[1], Protocol: "test"}:                 true,
+		{One: ids[1], Other: ids[0], Protocol: "test"}:                 true,
+		{One: ids[0], Other: ids[1], Protocol: "test", Received: true}: true,
+		{One: ids[1], Other: ids[0], Protocol: "test", Received: true}: true,
+	}
+	timeout := time.After(5 * time.Second)
+	for len(want) > 0 {
+		select {
+		case e := <-events:
+			if e.Type == EventTypeMsg {
+				if !want[*e.Msg] {
+					t.Fatalf("unexpected message event: %v", e)
+				}
+				delete(want, *e.Msg)
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %d message events", len(want))
+		}
+	}
+}
+
+func TestNetworkSnapshot(t *testing.T) {
+	net := newTestNetwork()
+	events, sub := subscribeEvents(net)
+	ids := startNodes(t, net, 3)
+	extra, err := net.NewNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(ids)-1; i++ {
+		if err := net.Connect(ids[i], ids[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitForConns(t, events, true, [2]discover.NodeID{ids[0], ids[1]}, [2]discover.NodeID{ids[1], ids[2]})
+	sub.Unsubscribe()
+
+	snap := net.Snapshot()
+	net.Shutdown()
+	if len(snap.Nodes) != 4 || len(snap.Conns) != 2 {
+		t.Fatalf("wrong snapshot size: %d nodes, %d conns", len(snap.Nodes), len(snap.Conns))
+	}
+
+	// Restore the snapshot into a new network
+	blob, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Snapshot
+	if err := json.Unmarshal(blob, &restored); err != nil {
+		t.Fatal(err)
+	}
+	net2 := newTestNetwork()
+	defer net2.Shutdown()
+	events2, sub2 := subscribeEvents(net2)
+	defer sub2.Unsubscribe()
+	if err := net2.Load(&restored); err != nil {
+		t.Fatal(err)
+	}
+	waitForConns(t, events2, true, [2]discover.NodeID{ids[0], ids[1]}, [2]discover.NodeID{ids[1], ids[2]})
+
+	for _, id := range ids {
+		if node := net2.GetNode(id); node == nil || !node.Up {
+			t.Errorf("node %x not up after load", id[:8])
+		}
+	}
+	if node := net2.GetNode(extra.ID()); node == nil || node.Up {
+		t.Errorf("stopped node %v not restored as stopped", extra)
+	}
+}
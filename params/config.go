@@ -47,6 +47,18 @@ var TestnetChainConfig = &ChainConfig{
 	EIP158Block:    big.NewInt(10),
 }
 
+// DevChainConfig is the chain parameters to run a node on a developer network,
+// with all the protocol changes active from the genesis block.
+var DevChainConfig = &ChainConfig{
+	ChainId:        big.NewInt(1337),
+	HomesteadBlock: big.NewInt(0),
+	DAOForkBlock:   nil,
+	DAOForkSupport: false,
+	EIP150Block:    big.NewInt(0),
+	EIP155Block:    big.NewInt(0),
+	EIP158Block:    big.NewInt(0),
+}
+
 // ChainConfig is the core config which determines the blockchain settings.
 //
 // ChainConfig is stored in the database on a per block basis. This means
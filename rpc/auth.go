@@ -0,0 +1,301 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// subscriptionSeparator separates the subscription name in permissions
+	// granting a single subscription, e.g. "eth_subscribe:newHeads".
+	subscriptionSeparator = ":"
+
+	// minJWTSecretLength is the minimum size of the HMAC secret of JWTs.
+	minJWTSecretLength = 32
+
+	// jwtClockSkew is the tolerance on the time based claims of JWTs.
+	jwtClockSkew = 5 * time.Second
+)
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errInvalidToken = errors.New("invalid bearer token")
+	errInvalidHost  = errors.New("invalid host specified")
+)
+
+// AuthConfig configures the authentication of RPC requests received over HTTP
+// and WebSocket. The zero value disables all checks.
+type AuthConfig struct {
+	// Tokens is the list of static bearer tokens accepted by the endpoint.
+	Tokens []AuthToken
+
+	// JWTSecret is the hex encoded HMAC-SHA256 secret of the JSON Web Tokens
+	// accepted by the endpoint, at least 32 bytes long. JWTs may restrict the
+	// callable API through the "modules" and "methods" claims, and are rejected
+	// after their "exp" or before their "nbf" claims. WebSocket connections are
+	// only authenticated when established.
+	JWTSecret string
+
+	// VirtualHosts is the list of host names the endpoint accepts requests for,
+	// checked against the Host header to prevent DNS rebinding attacks. Any
+	// host is accepted if the list is empty or contains "*".
+	VirtualHosts []string
+}
+
+// AuthToken is a static bearer token along with the API it grants access to.
+type AuthToken struct {
+	// Token is the secret sent in the Authorization header of requests.
+	Token string
+
+	// Modules is the list of API modules callable with the token, and Methods
+	// the list of individual methods (e.g. "eth_blockNumber"). Subscriptions
+	// are granted by "eth_subscribe" as a whole, or individually by entries
+	// like "eth_subscribe:newHeads". If both lists are empty, the token grants
+	// access to every API served.
+	Modules []string
+	Methods []string
+}
+
+// authenticator verifies the credentials of requests according to an AuthConfig.
+type authenticator struct {
+	tokens []AuthToken
+	secret []byte
+	vhosts map[string]bool // nil if all hosts are accepted
+}
+
+// newAuthenticator validates an authentication config, returning nil if it
+// does not restrict access.
+func newAuthenticator(config *AuthConfig) (*authenticator, error) {
+	if config == nil {
+		return nil, nil
+	}
+	auth := &authenticator{tokens: config.Tokens}
+	for i, token := range config.Tokens {
+		if token.Token == "" {
+			return nil, fmt.Errorf("auth token %d: empty token", i)
+		}
+	}
+	if config.JWTSecret != "" {
+		secret, err := hex.DecodeString(strings.TrimPrefix(config.JWTSecret, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret: %v", err)
+		}
+		if len(secret) < minJWTSecretLength {
+			return nil, fmt.Errorf("JWT secret too short (%d<%d bytes)", len(secret), minJWTSecretLength)
+		}
+		auth.secret = secret
+	}
+	for _, host := range config.VirtualHosts {
+		if host == "*" {
+			auth.vhosts = nil
+			break
+		}
+		if auth.vhosts == nil {
+			auth.vhosts = make(map[string]bool)
+		}
+		auth.vhosts[strings.ToLower(host)] = true
+	}
+	if len(auth.tokens) == 0 && auth.secret == nil && auth.vhosts == nil {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// SetAuth restricts access to the HTTP and WebSocket handlers of the server.
+// It must be called before the server starts serving requests.
+func (s *Server) SetAuth(config *AuthConfig) error {
+	auth, err := newAuthenticator(config)
+	if err != nil {
+		return err
+	}
+	s.auth = auth
+	return nil
+}
+
+// authorize checks the host and the credentials of a request, returning the
+// API access it grants (nil meaning unrestricted) or the HTTP status code to
+// reject it with.
+func (a *authenticator) authorize(r *http.Request) (*permission, int, error) {
+	if a == nil {
+		return nil, 0, nil
+	}
+	if a.vhosts != nil {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host // no port specified
+		}
+		if !a.vhosts[strings.ToLower(host)] {
+			return nil, http.StatusForbidden, errInvalidHost
+		}
+	}
+	if len(a.tokens) == 0 && a.secret == nil {
+		return nil, 0, nil
+	}
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, http.StatusUnauthorized, errMissingToken
+	}
+	token := strings.TrimSpace(header[len("Bearer "):])
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return newPermission(t.Modules, t.Methods), 0, nil
+		}
+	}
+	if a.secret != nil {
+		claims, err := verifyJWT(token, a.secret, time.Now())
+		if err != nil {
+			return nil, http.StatusUnauthorized, err
+		}
+		return newPermission(claims.Modules, claims.Methods), 0, nil
+	}
+	return nil, http.StatusUnauthorized, errInvalidToken
+}
+
+// jwtClaims are the claims of a JWT interpreted by the authenticator.
+type jwtClaims struct {
+	Expiry    *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Modules   []string `json:"modules"`
+	Methods   []string `json:"methods"`
+}
+
+// verifyJWT checks the HS256 signature and the time based claims of a JWT.
+func verifyJWT(token string, secret []byte, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidToken
+	}
+	// Check the signature before looking at the contents
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+	claims := new(jwtClaims)
+	if err := decodeJWTPart(parts[1], claims); err != nil {
+		return nil, err
+	}
+	if claims.Expiry != nil && now.Add(-jwtClockSkew).Unix() >= *claims.Expiry {
+		return nil, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtClockSkew).Unix() < *claims.NotBefore {
+		return nil, errors.New("token not yet valid")
+	}
+	return claims, nil
+}
+
+// decodeJWTPart decodes a base64url encoded JSON part of a JWT.
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errInvalidToken
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+		return errInvalidToken
+	}
+	return nil
+}
+
+// permission is the set of API modules and methods a request may call.
+type permission struct {
+	modules map[string]bool
+	methods map[string]bool
+}
+
+// newPermission creates the permission for the allowlists of a token, returning
+// nil if both are empty.
+func newPermission(modules, methods []string) *permission {
+	if len(modules) == 0 && len(methods) == 0 {
+		return nil
+	}
+	p := &permission{modules: make(map[string]bool), methods: make(map[string]bool)}
+	for _, module := range modules {
+		p.modules[module] = true
+	}
+	for _, method := range methods {
+		p.methods[method] = true
+	}
+	return p
+}
+
+// allowed reports whether a request may be executed. The metadata module and
+// unsubscribing are always allowed.
+func (p *permission) allowed(r *rpcRequest) bool {
+	switch {
+	case p == nil || r.err != nil:
+		return true
+	case r.isPubSub && r.method == unsubscribeMethod:
+		return true
+	case r.service == MetadataApi || p.modules[r.service]:
+		return true
+	case r.isPubSub:
+		return p.methods[subscribeMethod] || p.methods[subscribeMethod+subscriptionSeparator+r.method]
+	default:
+		return p.methods[r.service+serviceMethodSeparator+r.method]
+	}
+}
+
+// wrap restricts the requests read from a codec to the permitted ones.
+func (p *permission) wrap(codec ServerCodec) ServerCodec {
+	if p == nil {
+		return codec
+	}
+	return &authCodec{ServerCodec: codec, perm: p}
+}
+
+// authCodec is a ServerCodec failing the requests not allowed by a permission.
+type authCodec struct {
+	ServerCodec
+	perm *permission
+}
+
+// ReadRequestHeaders reads the next requests, marking the disallowed ones as
+// failed so they are answered with an error instead of being executed.
+func (c *authCodec) ReadRequestHeaders() ([]rpcRequest, bool, Error) {
+	reqs, batch, err := c.ServerCodec.ReadRequestHeaders()
+	for i := range reqs {
+		if !c.perm.allowed(&reqs[i]) {
+			rejectedAuthMeter.Mark(1)
+			reqs[i].err = &unauthorizedError{reqs[i].service, reqs[i].method, reqs[i].isPubSub}
+		}
+	}
+	return reqs, batch, err
+}
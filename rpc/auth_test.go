@@ -0,0 +1,213 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+var testJWTSecret = bytes.Repeat([]byte{0x42}, 32)
+
+// makeJWT creates an HS256 signed JWT with the given claims.
+func makeJWT(secret []byte, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func newAuthTestServer(t *testing.T) *Server {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	err := server.SetAuth(&AuthConfig{
+		Tokens: []AuthToken{
+			{Token: "all"},
+			{Token: "module", Modules: []string{"test"}},
+			{Token: "method", Methods: []string{"test_rets"}},
+		},
+		JWTSecret:    hex.EncodeToString(testJWTSecret),
+		VirtualHosts: []string{"localhost"},
+	})
+	if err != nil {
+		t.Fatalf("failed to configure authentication: %v", err)
+	}
+	return server
+}
+
+// Tests that HTTP requests are checked against the host and token restrictions.
+func TestHTTPAuth(t *testing.T) {
+	server := newAuthTestServer(t)
+	defer server.Stop()
+
+	now := time.Now().Unix()
+	tests := []struct {
+		host   string
+		token  string
+		method string
+		status int
+		code   int // JSON-RPC error code, 0 if successful
+	}{
+		{host: "evil.com", token: "all", method: "test_rets", status: http.StatusForbidden},
+		{host: "localhost", method: "test_rets", status: http.StatusUnauthorized},
+		{host: "localhost", token: "wrong", method: "test_rets", status: http.StatusUnauthorized},
+		{host: "localhost:8545", token: "all", method: "test_noArgsRets", status: http.StatusOK},
+		{host: "localhost", token: "module", method: "test_noArgsRets", status: http.StatusOK},
+		{host: "localhost", token: "method", method: "test_rets", status: http.StatusOK},
+		{host: "localhost", token: "method", method: "test_noArgsRets", status: http.StatusOK, code: -32001},
+		{host: "localhost", token: "method", method: "rpc_modules", status: http.StatusOK},
+
+		// JSON Web Tokens
+		{host: "localhost", token: makeJWT(testJWTSecret, nil), method: "test_noArgsRets", status: http.StatusOK},
+		{host: "localhost", token: makeJWT(testJWTSecret, map[string]interface{}{"exp": now + 60}), method: "test_rets", status: http.StatusOK},
+		{host: "localhost", token: makeJWT(testJWTSecret, map[string]interface{}{"exp": now - 60}), method: "test_rets", status: http.StatusUnauthorized},
+		{host: "localhost", token: makeJWT(testJWTSecret, map[string]interface{}{"nbf": now + 60}), method: "test_rets", status: http.StatusUnauthorized},
+		{host: "localhost", token: makeJWT([]byte("wrong secret"), nil), method: "test_rets", status: http.StatusUnauthorized},
+		{host: "localhost", token: makeJWT(testJWTSecret, map[string]interface{}{"methods": []string{"test_rets"}}), method: "test_rets", status: http.StatusOK},
+		{host: "localhost", token: makeJWT(testJWTSecret, map[string]interface{}{"methods": []string{"test_rets"}}), method: "test_noArgsRets", status: http.StatusOK, code: -32001},
+	}
+	for i, tt := range tests {
+		body := `{"jsonrpc":"2.0","id":1,"method":"` + tt.method + `","params":[]}`
+		req := httptest.NewRequest("POST", "http://"+tt.host+"/", strings.NewReader(body))
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, rec.Code, tt.status)
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		var resp jsonErrResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("test %d: invalid response %q: %v", i, rec.Body.String(), err)
+			continue
+		}
+		if resp.Error.Code != tt.code {
+			t.Errorf("test %d: error code mismatch: have %d, want %d (%s)", i, resp.Error.Code, tt.code, resp.Error.Message)
+		}
+	}
+}
+
+// Tests that WebSocket connections are only accepted with valid credentials.
+func TestWebsocketAuth(t *testing.T) {
+	server := newAuthTestServer(t)
+	defer server.Stop()
+
+	httpsrv := httptest.NewServer(server.WebsocketHandler("*"))
+	defer httpsrv.Close()
+	endpoint := "ws" + strings.TrimPrefix(httpsrv.URL, "http")
+
+	dial := func(token string) (*websocket.Conn, error) {
+		config, err := websocket.NewConfig(endpoint, "http://localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "" {
+			config.Header.Set("Authorization", "Bearer "+token)
+		}
+		return websocket.DialConfig(config)
+	}
+	// The test server listens on 127.0.0.1, which is not a whitelisted host
+	if conn, err := dial("all"); err == nil {
+		conn.Close()
+		t.Fatalf("connection with invalid host accepted")
+	}
+	server.auth.vhosts = nil
+
+	if conn, err := dial(""); err == nil {
+		conn.Close()
+		t.Fatalf("connection without token accepted")
+	}
+	// Rejected upgrades are answered with the status of the failed check
+	res, err := http.Get(httpsrv.URL)
+	if err != nil {
+		t.Fatalf("failed to send upgrade request: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status mismatch: have %d, want %d", res.StatusCode, http.StatusUnauthorized)
+	}
+	conn, err := dial("method")
+	if err != nil {
+		t.Fatalf("failed to connect with valid token: %v", err)
+	}
+	defer conn.Close()
+
+	for method, code := range map[string]int{"test_rets": 0, "test_noArgsRets": -32001} {
+		if err := websocket.JSON.Send(conn, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": []interface{}{}}); err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		var resp jsonErrResponse
+		if err := websocket.JSON.Receive(conn, &resp); err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		if resp.Error.Code != code {
+			t.Errorf("%s: error code mismatch: have %d, want %d (%s)", method, resp.Error.Code, code, resp.Error.Message)
+		}
+	}
+}
+
+// Tests that subscriptions are granted as a whole or by name.
+func TestPermissionSubscriptions(t *testing.T) {
+	var (
+		heads  = rpcRequest{service: "eth", method: "newHeads", isPubSub: true}
+		logs   = rpcRequest{service: "eth", method: "logs", isPubSub: true}
+		unsub  = rpcRequest{method: unsubscribeMethod, isPubSub: true}
+		number = rpcRequest{service: "eth", method: "blockNumber"}
+	)
+	tests := []struct {
+		modules, methods []string
+		req              rpcRequest
+		want             bool
+	}{
+		{nil, []string{"eth_subscribe"}, heads, true},
+		{nil, []string{"eth_subscribe"}, logs, true},
+		{nil, []string{"eth_subscribe:newHeads"}, heads, true},
+		{nil, []string{"eth_subscribe:newHeads"}, logs, false},
+		{nil, []string{"eth_subscribe:newHeads"}, unsub, true},
+		{nil, []string{"eth_subscribe:newHeads"}, number, false},
+		{nil, []string{"eth_blockNumber"}, heads, false},
+		{[]string{"eth"}, nil, logs, true},
+	}
+	for i, tt := range tests {
+		req := tt.req
+		if have := newPermission(tt.modules, tt.methods).allowed(&req); have != tt.want {
+			t.Errorf("test %d: %v/%v calling %s: have %v, want %v", i, tt.modules, tt.methods, tt.req.method, have, tt.want)
+		}
+	}
+}
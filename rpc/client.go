@@ -47,6 +47,10 @@ const (
 	defaultDialTimeout   = 10 * time.Second // used when dialing if the context has no deadline
 	defaultWriteTimeout  = 10 * time.Second // used for calls if the context has no deadline
 	subscribeTimeout     = 5 * time.Second  // overall timeout eth_subscribe, rpc_modules calls
+
+	// Reconnection backoff defaults
+	defaultMinReconnectBackoff = 500 * time.Millisecond
+	defaultMaxReconnectBackoff = 30 * time.Second
 )
 
 const (
@@ -104,11 +108,19 @@ func (msg *jsonrpcMessage) String() string {
 	return string(b)
 }
 
+// ReconnectConfig configures the automatic reconnection of a websocket or IPC
+// client whose connection was lost.
+type ReconnectConfig struct {
+	MinBackoff time.Duration // Delay before the first reconnection attempt
+	MaxBackoff time.Duration // Upper limit of the exponentially growing delay between attempts
+}
+
 // Client represents a connection to an RPC server.
 type Client struct {
 	idCounter   uint32
 	connectFunc func(ctx context.Context) (net.Conn, error)
 	isHTTP      bool
+	reconnect   *ReconnectConfig // automatic reconnection settings, nil if disabled
 
 	// writeConn is only safe to access outside dispatch, with the
 	// write lock held. The write lock is taken by sending on
@@ -128,10 +140,11 @@ type Client struct {
 }
 
 type requestOp struct {
-	ids  []json.RawMessage
-	err  error
-	resp chan *jsonrpcMessage // receives up to len(ids) responses
-	sub  *ClientSubscription  // only set for EthSubscribe requests
+	ids   []json.RawMessage
+	err   error
+	resp  chan *jsonrpcMessage // receives up to len(ids) responses
+	sub   *ClientSubscription  // only set for EthSubscribe requests
+	resub bool                 // set if sub is being replayed after a reconnect
 }
 
 func (op *requestOp) wait(ctx context.Context) (*jsonrpcMessage, error) {
@@ -178,7 +191,43 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 	}
 }
 
-func newClient(initctx context.Context, connectFunc func(context.Context) (net.Conn, error)) (*Client, error) {
+// DialReconnect creates a new websocket or IPC client for the given URL, just like
+// DialContext, which automatically reconnects in the background with exponential
+// backoff when the connection is lost.
+//
+// Calls made while the connection is down fail, but subscriptions survive: they are
+// replayed on the new connection, after which a value is sent on the Gaps channel of
+// each subscription. Notifications sent by the server while the connection was down
+// are lost, consumers should backfill them when notified of a gap.
+func DialReconnect(ctx context.Context, rawurl string, config ReconnectConfig) (*Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if config.MinBackoff == 0 {
+		config.MinBackoff = defaultMinReconnectBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = defaultMaxReconnectBackoff
+		if config.MaxBackoff < config.MinBackoff {
+			config.MaxBackoff = config.MinBackoff
+		}
+	}
+	switch u.Scheme {
+	case "ws", "wss":
+		connect, err := wsConnectFunc(rawurl, "")
+		if err != nil {
+			return nil, err
+		}
+		return newClient(ctx, connect, &config)
+	case "":
+		return newClient(ctx, ipcConnectFunc(rawurl), &config)
+	default:
+		return nil, fmt.Errorf("no reconnecting transport for URL scheme %q", u.Scheme)
+	}
+}
+
+func newClient(initctx context.Context, connectFunc func(context.Context) (net.Conn, error), reconnect *ReconnectConfig) (*Client, error) {
 	conn, err := connectFunc(initctx)
 	if err != nil {
 		return nil, err
@@ -188,6 +237,7 @@ func newClient(initctx context.Context, connectFunc func(context.Context) (net.C
 	c := &Client{
 		writeConn:   conn,
 		isHTTP:      isHTTP,
+		reconnect:   reconnect,
 		connectFunc: connectFunc,
 		close:       make(chan struct{}),
 		didQuit:     make(chan struct{}),
@@ -381,7 +431,7 @@ func (c *Client) EthSubscribe(ctx context.Context, channel interface{}, args ...
 	op := &requestOp{
 		ids:  []json.RawMessage{msg.ID},
 		resp: make(chan *jsonrpcMessage),
-		sub:  newClientSubscription(c, chanVal),
+		sub:  newClientSubscription(c, chanVal, msg.Params),
 	}
 
 	// Send the subscription request.
@@ -430,7 +480,7 @@ func (c *Client) write(ctx context.Context, msg interface{}) error {
 	}
 	// The previous write failed. Try to establish a new connection.
 	if c.writeConn == nil {
-		if err := c.reconnect(ctx); err != nil {
+		if err := c.redial(ctx); err != nil {
 			return err
 		}
 	}
@@ -442,7 +492,7 @@ func (c *Client) write(ctx context.Context, msg interface{}) error {
 	return err
 }
 
-func (c *Client) reconnect(ctx context.Context) error {
+func (c *Client) redial(ctx context.Context) error {
 	newconn, err := c.connectFunc(ctx)
 	if err != nil {
 		glog.V(logger.Detail).Infof("reconnect failed: %v", err)
@@ -458,6 +508,78 @@ func (c *Client) reconnect(ctx context.Context) error {
 	}
 }
 
+// reconnectLoop re-establishes the connection after the read loop on the given
+// connection failed, retrying with exponential backoff until it succeeds or the
+// client is closed. Calls made in the meantime may have reconnected already.
+func (c *Client) reconnectLoop(dead net.Conn) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-c.didQuit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	backoff := c.reconnect.MinBackoff
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-c.didQuit:
+			return
+		}
+		// Take the write lock, the connection is only safe to touch with it held
+		select {
+		case c.requestOp <- new(requestOp):
+		case <-c.didQuit:
+			return
+		}
+		var err error
+		if c.writeConn == nil || c.writeConn == dead {
+			c.writeConn = nil
+			err = c.redial(ctx)
+		}
+		c.sendDone <- nil
+
+		if err == nil || err == ErrClientQuit {
+			return
+		}
+		if backoff *= 2; backoff > c.reconnect.MaxBackoff {
+			backoff = c.reconnect.MaxBackoff
+		}
+	}
+}
+
+// resubscribe replays a subscription on a new connection.
+func (c *Client) resubscribe(sub *ClientSubscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+	defer cancel()
+
+	msg := &jsonrpcMessage{Version: "2.0", ID: c.nextID(), Method: subscribeMethod, Params: sub.params}
+	op := &requestOp{
+		ids:   []json.RawMessage{msg.ID},
+		resp:  make(chan *jsonrpcMessage),
+		sub:   sub,
+		resub: true,
+	}
+	err := c.send(ctx, op, msg)
+	if err == nil {
+		_, err = op.wait(ctx)
+	}
+	if err != nil {
+		glog.V(logger.Debug).Infof("resubscription failed: %v", err)
+		sub.quitWithError(err, false)
+		return
+	}
+	// Let the consumer know notifications may have been missed. A pending gap
+	// notification not yet consumed covers this one too.
+	select {
+	case sub.gaps <- struct{}{}:
+	default:
+	}
+}
+
 // dispatch is the main loop of the client.
 // It sends read messages to waiting calls to Call and BatchCall
 // and subscription notifications to registered subscriptions.
@@ -515,7 +637,13 @@ func (c *Client) dispatch(conn net.Conn) {
 
 		case err := <-c.readErr:
 			glog.V(logger.Debug).Infof("<-readErr: %v", err)
-			c.closeRequestOps(err)
+			if c.reconnect != nil {
+				// Keep the subscriptions alive for replaying them once reconnected
+				c.closeRequests(err)
+				go c.reconnectLoop(conn)
+			} else {
+				c.closeRequestOps(err)
+			}
 			conn.Close()
 			reading = false
 
@@ -530,6 +658,14 @@ func (c *Client) dispatch(conn net.Conn) {
 			reading = true
 			conn = newconn
 
+			// Replay the subscriptions of the previous connection on the new one
+			if c.reconnect != nil {
+				for id, sub := range c.subs {
+					delete(c.subs, id)
+					go c.resubscribe(sub)
+				}
+			}
+
 		// Send path.
 		case op := <-requestOpLock:
 			// Stop listening for further send ops until the current one is done.
@@ -557,6 +693,15 @@ func (c *Client) dispatch(conn net.Conn) {
 
 // closeRequestOps unblocks pending send ops and active subscriptions.
 func (c *Client) closeRequestOps(err error) {
+	c.closeRequests(err)
+	for id, sub := range c.subs {
+		delete(c.subs, id)
+		sub.quitWithError(err, false)
+	}
+}
+
+// closeRequests unblocks pending send ops.
+func (c *Client) closeRequests(err error) {
 	didClose := make(map[*requestOp]bool)
 
 	for id, op := range c.respWait {
@@ -569,10 +714,6 @@ func (c *Client) closeRequestOps(err error) {
 			didClose[op] = true
 		}
 	}
-	for id, sub := range c.subs {
-		delete(c.subs, id)
-		sub.quitWithError(err, false)
-	}
 }
 
 func (c *Client) handleNotification(msg *jsonrpcMessage) {
@@ -613,10 +754,23 @@ func (c *Client) handleResponse(msg *jsonrpcMessage) {
 		op.err = msg.Error
 		return
 	}
-	if op.err = json.Unmarshal(msg.Result, &op.sub.subid); op.err == nil {
+	var subid string
+	if op.err = json.Unmarshal(msg.Result, &subid); op.err != nil {
+		return
+	}
+	op.sub.setID(subid)
+	if !op.resub {
 		go op.sub.start()
-		c.subs[op.sub.subid] = op.sub
+	} else {
+		// Drop replayed subscriptions unsubscribed while being replayed
+		select {
+		case <-op.sub.quit:
+			go op.sub.requestUnsubscribe()
+			return
+		default:
+		}
 	}
+	c.subs[subid] = op.sub
 }
 
 // Reading happens on a dedicated goroutine.
@@ -657,8 +811,12 @@ type ClientSubscription struct {
 	client  *Client
 	etype   reflect.Type
 	channel reflect.Value
-	subid   string
+	params  json.RawMessage // eth_subscribe parameters, for replaying after a reconnect
 	in      chan json.RawMessage
+	gaps    chan struct{}
+
+	subidLock sync.Mutex // protects subid, which changes on replay
+	subid     string
 
 	quitOnce sync.Once     // ensures quit is closed once
 	quit     chan struct{} // quit is closed when the subscription exits
@@ -666,18 +824,38 @@ type ClientSubscription struct {
 	err      chan error
 }
 
-func newClientSubscription(c *Client, channel reflect.Value) *ClientSubscription {
+func newClientSubscription(c *Client, channel reflect.Value, params json.RawMessage) *ClientSubscription {
 	sub := &ClientSubscription{
 		client:  c,
 		etype:   channel.Type().Elem(),
 		channel: channel,
+		params:  params,
 		quit:    make(chan struct{}),
 		err:     make(chan error, 1),
 		in:      make(chan json.RawMessage),
+		gaps:    make(chan struct{}, 1),
 	}
 	return sub
 }
 
+func (sub *ClientSubscription) setID(subid string) {
+	sub.subidLock.Lock()
+	defer sub.subidLock.Unlock()
+
+	sub.subid = subid
+}
+
+// Gaps returns a channel receiving a value whenever the subscription was replayed
+// after the connection of a reconnecting client was lost. Notifications sent by the
+// server while the connection was down are lost, consumers should backfill them on
+// receiving a gap. Multiple gaps not consumed yet are only signalled once.
+//
+// Subscriptions of clients not created by DialReconnect never have gaps, they end
+// with an error on the Err channel if the connection is lost.
+func (sub *ClientSubscription) Gaps() <-chan struct{} {
+	return sub.gaps
+}
+
 // Err returns the subscription error channel. The intended use of Err is to schedule
 // resubscription when the client connection is closed unexpectedly.
 //
@@ -771,6 +949,10 @@ func (sub *ClientSubscription) unmarshal(result json.RawMessage) (interface{}, e
 }
 
 func (sub *ClientSubscription) requestUnsubscribe() error {
+	sub.subidLock.Lock()
+	subid := sub.subid
+	sub.subidLock.Unlock()
+
 	var result interface{}
-	return sub.client.Call(&result, unsubscribeMethod, sub.subid)
+	return sub.client.Call(&result, unsubscribeMethod, subid)
 }
@@ -458,6 +458,98 @@ func TestClientReconnect(t *testing.T) {
 	}
 }
 
+// TickerService is a subscription service sending increasing numbers until the
+// subscription or the connection is closed.
+type TickerService struct{}
+
+func (s *TickerService) Ticks(ctx context.Context, start int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for i := start; ; i++ {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				if err := notifier.Notify(sub.ID, i); err != nil {
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// Tests that subscriptions of a reconnecting client survive a server restart,
+// reporting the gap in the notifications.
+func TestClientReconnectSubscription(t *testing.T) {
+	startServer := func(addr string) (*Server, net.Listener) {
+		srv := newTestServer("eth", new(TickerService))
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go http.Serve(l, srv.WebsocketHandler("*"))
+		return srv, l
+	}
+	s1, l1 := startServer("127.0.0.1:0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := DialReconnect(ctx, "ws://"+l1.Addr().String(), ReconnectConfig{MinBackoff: 50 * time.Millisecond, MaxBackoff: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal("can't dial", err)
+	}
+	defer client.Close()
+
+	ticks := make(chan int)
+	sub, err := client.EthSubscribe(ctx, ticks, "ticks", 0)
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+	for i := 0; i < 3; i++ {
+		if tick := <-ticks; tick != i {
+			t.Fatalf("tick mismatch: have %d, want %d", tick, i)
+		}
+	}
+	// Restart the server, the subscription should resume with a gap reported
+	l1.Close()
+	s1.Stop()
+
+	time.Sleep(500 * time.Millisecond)
+	s2, l2 := startServer(l1.Addr().String())
+	defer l2.Close()
+	defer s2.Stop()
+
+	select {
+	case <-sub.Gaps():
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-ctx.Done():
+		t.Fatalf("no gap reported after reconnect")
+	}
+	// Any ticks queued before the restart might arrive first, the replayed
+	// subscription restarts counting from zero
+	for {
+		select {
+		case tick := <-ticks:
+			if tick == 0 {
+				sub.Unsubscribe()
+				return
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatalf("no ticks received after reconnect")
+		}
+	}
+}
+
 func newTestServer(serviceName string, service interface{}) *Server {
 	server := NewServer()
 	if err := server.RegisterName(serviceName, service); err != nil {
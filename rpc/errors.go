@@ -30,6 +30,52 @@ func (e *methodNotFoundError) Error() string {
 	return fmt.Sprintf("The method %s%s%s does not exist/is not available", e.service, serviceMethodSeparator, e.method)
 }
 
+// request is not permitted by the credentials of the caller
+type unauthorizedError struct {
+	service  string
+	method   string
+	isPubSub bool
+}
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string {
+	if e.isPubSub {
+		return fmt.Sprintf("subscription %s%s%s is not permitted", e.service, serviceMethodSeparator, e.method)
+	}
+	return fmt.Sprintf("method %s%s%s is not permitted", e.service, serviceMethodSeparator, e.method)
+}
+
+// client exceeded its request rate
+type rateLimitError struct{ method string }
+
+func (e *rateLimitError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitError) Error() string {
+	if e.method == "" {
+		return "rate limit exceeded"
+	}
+	return fmt.Sprintf("rate limit of %s exceeded", e.method)
+}
+
+// batch contains more requests than allowed
+type batchTooLargeError struct{ size, limit int }
+
+func (e *batchTooLargeError) ErrorCode() int { return -32006 }
+
+func (e *batchTooLargeError) Error() string {
+	return fmt.Sprintf("batch too large (%d>%d)", e.size, e.limit)
+}
+
+// method execution exceeded its timeout
+type timeoutError struct{ method string }
+
+func (e *timeoutError) ErrorCode() int { return -32008 }
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%s execution timed out", e.method)
+}
+
 // received message isn't a valid request
 type invalidRequestError struct{ message string }
 
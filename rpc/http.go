@@ -75,7 +75,7 @@ func DialHTTP(endpoint string) (*Client, error) {
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (net.Conn, error) {
 		return &httpConn{client: new(http.Client), req: req, closed: make(chan struct{})}, nil
-	})
+	}, nil)
 }
 
 func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg interface{}) error {
@@ -141,25 +141,44 @@ func (t *httpReadWriteNopCloser) Close() error {
 //
 // Deprecated: Server implements http.Handler
 func NewHTTPServer(corsString string, srv *Server) *http.Server {
-	return &http.Server{Handler: newCorsHandler(srv, corsString)}
+	return &http.Server{Handler: srv.HTTPHandler(corsString)}
+}
+
+// HTTPHandler returns a handler that serves JSON-RPC over HTTP, sending the
+// Cross-Origin Resource Sharing headers for the comma-separated list of
+// allowed origins.
+func (srv *Server) HTTPHandler(corsString string) http.Handler {
+	return newCorsHandler(srv, corsString)
 }
 
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.ContentLength > maxHTTPRequestContentLength {
+	maxSize := srv.limiter.maxRequestSize(maxHTTPRequestContentLength)
+	if r.ContentLength > maxSize {
+		rejectedSizeMeter.Mark(1)
 		http.Error(w,
-			fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, maxHTTPRequestContentLength),
+			fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, maxSize),
 			http.StatusRequestEntityTooLarge)
 		return
 	}
+	perm, status, err := srv.auth.authorize(r)
+	if err != nil {
+		rejectedAuthMeter.Mark(1)
+		if status == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
 	w.Header().Set("content-type", "application/json")
 
 	// create a codec that reads direct from the request body until
 	// EOF and writes the response to w and order the server to process
 	// a single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	body := http.MaxBytesReader(w, r.Body, maxSize)
+	codec := NewJSONCodec(&httpReadWriteNopCloser{body, w})
 	defer codec.Close()
-	srv.ServeSingleRequest(codec, OptionMethodInvocation)
+	srv.ServeSingleRequest(srv.limiter.wrap(perm.wrap(codec), r.RemoteAddr), OptionMethodInvocation)
 }
 
 func newCorsHandler(srv *Server, corsString string) http.Handler {
@@ -170,6 +189,7 @@ func newCorsHandler(srv *Server, corsString string) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedOrigins: allowedOrigins,
 		AllowedMethods: []string{"POST", "GET"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
 		MaxAge:         600,
 	})
 	return c.Handler(srv)
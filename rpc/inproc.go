@@ -29,6 +29,6 @@ func DialInProc(handler *Server) *Client {
 		p1, p2 := net.Pipe()
 		go handler.ServeCodec(NewJSONCodec(p1), OptionMethodInvocation|OptionSubscriptions)
 		return p2, nil
-	})
+	}, nil)
 	return c
 }
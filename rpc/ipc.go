@@ -49,7 +49,12 @@ func (srv *Server) ServeListener(l net.Listener) error {
 // The context is used for the initial connection establishment. It does not
 // affect subsequent interactions with the client.
 func DialIPC(ctx context.Context, endpoint string) (*Client, error) {
-	return newClient(ctx, func(ctx context.Context) (net.Conn, error) {
+	return newClient(ctx, ipcConnectFunc(endpoint), nil)
+}
+
+// ipcConnectFunc creates the connection function dialing an IPC endpoint.
+func ipcConnectFunc(endpoint string) func(context.Context) (net.Conn, error) {
+	return func(ctx context.Context) (net.Conn, error) {
 		return newIPCConnection(ctx, endpoint)
-	})
+	}
 }
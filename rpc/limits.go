@@ -0,0 +1,310 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/EarthDollar/go-earthdollar/metrics"
+)
+
+// bucketExpiry is the interval after which the rate limiting state of idle
+// clients is dropped.
+const bucketExpiry = time.Minute
+
+var (
+	rejectedAuthMeter      = metrics.NewMeter("rpc/rejected/auth")
+	rejectedBatchMeter     = metrics.NewMeter("rpc/rejected/batch")
+	rejectedSizeMeter      = metrics.NewMeter("rpc/rejected/size")
+	rejectedRateLimitMeter = metrics.NewMeter("rpc/rejected/ratelimit")
+	rejectedTimeoutMeter   = metrics.NewMeter("rpc/rejected/timeout")
+)
+
+// Limits configures the resources clients of the HTTP and WebSocket handlers
+// of a server may use. The zero value imposes no limits.
+type Limits struct {
+	// MaxBatchSize is the maximum number of requests in a batch.
+	MaxBatchSize int
+
+	// MaxRequestSize is the maximum size of an HTTP request body or WebSocket
+	// message in bytes.
+	MaxRequestSize int64
+
+	// Timeout is the maximum execution time of method calls, after which
+	// their context is cancelled and an error is returned.
+	Timeout time.Duration
+
+	// RateLimit is the number of requests per second a client, identified by
+	// its IP address, may issue on average. RateBurst is the number of requests
+	// that may be issued at once, defaulting to one second's worth.
+	RateLimit float64
+	RateBurst int
+
+	// Methods overrides the limits for individual methods.
+	Methods []MethodLimits
+}
+
+// MethodLimits configures the limits of an individual method. Calls are subject
+// to both the method and the global rate limit.
+type MethodLimits struct {
+	// Method is the name of the limited method, e.g. "eth_getLogs". The
+	// limits of subscriptions are configured through "eth_subscribe".
+	Method string
+
+	// Timeout replaces the execution timeout of the method if non-zero.
+	Timeout time.Duration
+
+	// RateLimit and RateBurst configure the per-client rate limit of the method.
+	RateLimit float64
+	RateBurst int
+}
+
+// limiter enforces the Limits of a server.
+type limiter struct {
+	maxBatch int
+	maxSize  int64
+	timeout  time.Duration
+	rate     *rateLimiter // nil if the global rate is unlimited
+
+	timeouts map[string]time.Duration
+	rates    map[string]*rateLimiter
+}
+
+// newLimiter validates a limits config, returning nil if it imposes no limits.
+func newLimiter(config *Limits) (*limiter, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if config.MaxBatchSize < 0 || config.MaxRequestSize < 0 || config.Timeout < 0 {
+		return nil, fmt.Errorf("negative limit")
+	}
+	l := &limiter{
+		maxBatch: config.MaxBatchSize,
+		maxSize:  config.MaxRequestSize,
+		timeout:  config.Timeout,
+		timeouts: make(map[string]time.Duration),
+		rates:    make(map[string]*rateLimiter),
+	}
+	var err error
+	if l.rate, err = newRateLimiter(config.RateLimit, config.RateBurst); err != nil {
+		return nil, err
+	}
+	for _, method := range config.Methods {
+		if method.Timeout < 0 {
+			return nil, fmt.Errorf("%s: negative timeout", method.Method)
+		}
+		if method.Timeout > 0 {
+			l.timeouts[method.Method] = method.Timeout
+		}
+		rate, err := newRateLimiter(method.RateLimit, method.RateBurst)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", method.Method, err)
+		}
+		if rate != nil {
+			l.rates[method.Method] = rate
+		}
+	}
+	if l.maxBatch == 0 && l.maxSize == 0 && l.timeout == 0 && l.rate == nil && len(l.timeouts) == 0 && len(l.rates) == 0 {
+		return nil, nil
+	}
+	return l, nil
+}
+
+// SetLimits restricts the resources used by clients of the HTTP and WebSocket
+// handlers of the server. It must be called before the server starts serving
+// requests.
+func (s *Server) SetLimits(config *Limits) error {
+	limiter, err := newLimiter(config)
+	if err != nil {
+		return err
+	}
+	s.limiter = limiter
+	return nil
+}
+
+// maxRequestSize returns the maximum size of a request, or the given default
+// if not limited.
+func (l *limiter) maxRequestSize(def int64) int64 {
+	if l == nil || l.maxSize == 0 {
+		return def
+	}
+	return l.maxSize
+}
+
+// methodTimeout returns the execution timeout of a method, zero if unlimited.
+func (l *limiter) methodTimeout(method string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	if timeout, ok := l.timeouts[method]; ok {
+		return timeout
+	}
+	return l.timeout
+}
+
+// wrap applies the batch and rate limits to the requests read from a codec of
+// the client with the given network address.
+func (l *limiter) wrap(codec ServerCodec, addr string) ServerCodec {
+	if l == nil || (l.maxBatch == 0 && l.rate == nil && len(l.rates) == 0) {
+		return codec
+	}
+	client, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		client = addr
+	}
+	return &limitCodec{ServerCodec: codec, limiter: l, client: client}
+}
+
+// requestMethod returns the name of the method called by a request.
+func requestMethod(r *rpcRequest) string {
+	switch {
+	case r.isPubSub && r.method == unsubscribeMethod:
+		return unsubscribeMethod
+	case r.isPubSub:
+		return subscribeMethod
+	default:
+		return r.service + serviceMethodSeparator + r.method
+	}
+}
+
+// limitCodec is a ServerCodec failing the requests exceeding the limits of a
+// client.
+type limitCodec struct {
+	ServerCodec
+	limiter *limiter
+	client  string
+}
+
+// ReadRequestHeaders reads the next requests, marking the ones exceeding the
+// limits as failed so they are answered with an error instead of being executed.
+func (c *limitCodec) ReadRequestHeaders() ([]rpcRequest, bool, Error) {
+	reqs, batch, err := c.ServerCodec.ReadRequestHeaders()
+	if err != nil {
+		return reqs, batch, err
+	}
+	if c.limiter.maxBatch > 0 && len(reqs) > c.limiter.maxBatch {
+		rejectedBatchMeter.Mark(1)
+		for i := range reqs {
+			reqs[i].err = &batchTooLargeError{len(reqs), c.limiter.maxBatch}
+		}
+		return reqs, batch, nil
+	}
+	now := time.Now()
+	for i := range reqs {
+		if reqs[i].err != nil {
+			continue
+		}
+		// Only charge the global limit for calls the method limit lets through,
+		// and give the method token back if the global limit rejects the call.
+		method := requestMethod(&reqs[i])
+		if rate := c.limiter.rates[method]; !rate.allow(c.client, now) {
+			reqs[i].err = &rateLimitError{method}
+		} else if !c.limiter.rate.allow(c.client, now) {
+			rate.refund(c.client)
+			reqs[i].err = &rateLimitError{}
+		}
+		if reqs[i].err != nil {
+			rejectedRateLimitMeter.Mark(1)
+		}
+	}
+	return reqs, batch, nil
+}
+
+// rateLimiter is a set of token buckets, one per client.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	lock    sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a rate limiter, returning nil if rate is zero.
+func newRateLimiter(rate float64, burst int) (*rateLimiter, error) {
+	if rate < 0 || burst < 0 {
+		return nil, fmt.Errorf("negative rate limit")
+	}
+	if rate == 0 {
+		return nil, nil
+	}
+	if burst == 0 {
+		burst = int(rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*bucket)}, nil
+}
+
+// allow takes a token from the bucket of a client, reporting whether one was
+// available.
+func (r *rateLimiter) allow(client string, now time.Time) bool {
+	if r == nil {
+		return true
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Drop the buckets of clients that have been idle long enough to refill
+	if now.Sub(r.swept) > bucketExpiry {
+		for key, b := range r.buckets {
+			if now.Sub(b.last).Seconds()*r.rate >= r.burst {
+				delete(r.buckets, key)
+			}
+		}
+		r.swept = now
+	}
+	b, ok := r.buckets[client]
+	if !ok {
+		b = &bucket{tokens: r.burst, last: now}
+		r.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * r.rate
+	if b.tokens > r.burst {
+		b.tokens = r.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refund returns a token taken by allow to the bucket of a client.
+func (r *rateLimiter) refund(client string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if b, ok := r.buckets[client]; ok && b.tokens+1 <= r.burst {
+		b.tokens++
+	}
+}
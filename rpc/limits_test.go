@@ -0,0 +1,171 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLimitTestServer(t *testing.T, limits *Limits) *Server {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetLimits(limits); err != nil {
+		t.Fatalf("failed to configure limits: %v", err)
+	}
+	return server
+}
+
+// callHTTP sends a request body to the server from the given client address,
+// returning the HTTP status and the JSON-RPC error codes of the responses.
+func callHTTP(t *testing.T, server *Server, remote, body string) (int, []int) {
+	req := httptest.NewRequest("POST", "http://localhost/", strings.NewReader(body))
+	req.RemoteAddr = remote
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+	var resps []jsonErrResponse
+	if strings.HasPrefix(body, "[") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &resps); err != nil {
+			t.Fatalf("invalid batch response %q: %v", rec.Body.String(), err)
+		}
+	} else {
+		var resp jsonErrResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+		}
+		resps = append(resps, resp)
+	}
+	codes := make([]int, len(resps))
+	for i, resp := range resps {
+		codes[i] = resp.Error.Code
+	}
+	return rec.Code, codes
+}
+
+func testCall(method string, params string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[` + params + `]}`
+}
+
+// Tests that batches and request bodies exceeding the limits are rejected.
+func TestSizeLimits(t *testing.T) {
+	server := newLimitTestServer(t, &Limits{MaxBatchSize: 2, MaxRequestSize: 200})
+	defer server.Stop()
+
+	call := testCall("test_rets", "")
+	if _, codes := callHTTP(t, server, "1.2.3.4:1", "["+call+","+call+"]"); codes[0] != 0 || codes[1] != 0 {
+		t.Errorf("batch within limit failed: %v", codes)
+	}
+	_, codes := callHTTP(t, server, "1.2.3.4:1", "["+call+","+call+","+call+"]")
+	for i, code := range codes {
+		if code != -32006 {
+			t.Errorf("oversized batch item %d: error code mismatch: have %d, want %d", i, code, -32006)
+		}
+	}
+	if status, _ := callHTTP(t, server, "1.2.3.4:1", testCall("test_rets", `"`+strings.Repeat("a", 200)+`"`)); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized request status mismatch: have %d, want %d", status, http.StatusRequestEntityTooLarge)
+	}
+}
+
+// Tests that clients are rate limited independently, both globally and per method.
+func TestRateLimits(t *testing.T) {
+	server := newLimitTestServer(t, &Limits{
+		RateLimit: 0.001,
+		RateBurst: 3,
+		Methods:   []MethodLimits{{Method: "test_rets", RateLimit: 0.001, RateBurst: 1}},
+	})
+	defer server.Stop()
+
+	tests := []struct {
+		remote string
+		method string
+		code   int
+	}{
+		{"1.2.3.4:1", "test_rets", 0},
+		{"1.2.3.4:2", "test_rets", -32005}, // method limit exhausted
+		{"1.2.3.4:3", "test_noArgsRets", 0},
+		{"5.6.7.8:1", "test_rets", 0},       // other client
+		{"1.2.3.4:1", "test_noArgsRets", 0}, // rejected call not charged globally
+		{"1.2.3.4:1", "test_noArgsRets", -32005},
+	}
+	for i, tt := range tests {
+		if _, codes := callHTTP(t, server, tt.remote, testCall(tt.method, "")); codes[0] != tt.code {
+			t.Errorf("test %d: error code mismatch: have %d, want %d", i, codes[0], tt.code)
+		}
+	}
+}
+
+// Tests that method calls are cancelled after their execution timeout.
+func TestExecutionTimeout(t *testing.T) {
+	server := newLimitTestServer(t, &Limits{
+		Timeout: time.Minute,
+		Methods: []MethodLimits{{Method: "test_sleep", Timeout: 10 * time.Millisecond}},
+	})
+	defer server.Stop()
+
+	start := time.Now()
+	if _, codes := callHTTP(t, server, "1.2.3.4:1", testCall("test_sleep", "10000000000")); codes[0] != -32008 {
+		t.Errorf("error code mismatch: have %d, want %d", codes[0], -32008)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call not cancelled, took %v", elapsed)
+	}
+	if _, codes := callHTTP(t, server, "1.2.3.4:1", testCall("test_sleep", "1000")); codes[0] != 0 {
+		t.Errorf("call within timeout failed with code %d", codes[0])
+	}
+}
+
+// Tests that token buckets refill at the configured rate up to the burst size.
+func TestRateLimiter(t *testing.T) {
+	limiter, _ := newRateLimiter(10, 2)
+	now := time.Now()
+
+	if !limiter.allow("a", now) || !limiter.allow("a", now) {
+		t.Fatal("burst not allowed")
+	}
+	if limiter.allow("a", now) {
+		t.Fatal("request beyond burst allowed")
+	}
+	limiter.refund("a")
+	if !limiter.allow("a", now) {
+		t.Fatal("refunded token not available")
+	}
+	if !limiter.allow("a", now.Add(100*time.Millisecond)) {
+		t.Fatal("request not allowed after refill")
+	}
+	// Refunds don't fill a bucket beyond the burst size
+	limiter.allow("b", now)
+	limiter.refund("b")
+	limiter.refund("b")
+	if tokens := limiter.buckets["b"].tokens; tokens != 2 {
+		t.Fatalf("refunded bucket mismatch: have %v tokens, want 2", tokens)
+	}
+	// Idle buckets are dropped once refilled
+	limiter.allow("b", now.Add(200*time.Millisecond))
+	limiter.allow("c", now.Add(2*bucketExpiry))
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("idle buckets not dropped: have %d buckets", len(limiter.buckets))
+	}
+}
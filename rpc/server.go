@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync/atomic"
+	"time"
 
 	"github.com/EarthDollar/go-earthdollar/logger"
 	"github.com/EarthDollar/go-earthdollar/logger/glog"
@@ -187,7 +188,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 
 		// check if server is ordered to shutdown and return an error
 		// telling the client that his request failed.
-		if atomic.LoadInt32(&s.run) != 1 {
+		if !s.beginRequest() {
 			err = &shutdownError{}
 			if batch {
 				resps := make([]interface{}, len(reqs))
@@ -232,18 +233,70 @@ func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
 	s.serveRequest(codec, true, options)
 }
 
-// Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
-// close all codecs which will cancel pending requests/subscriptions.
+// Stop will stop reading new requests and close all codecs, which will cancel
+// pending requests/subscriptions.
 func (s *Server) Stop() {
-	if atomic.CompareAndSwapInt32(&s.run, 1, 0) {
-		glog.V(logger.Debug).Infoln("RPC Server shutdown initiatied")
-		s.codecsMu.Lock()
-		defer s.codecsMu.Unlock()
-		s.codecs.Each(func(c interface{}) bool {
-			c.(ServerCodec).Close()
-			return true
-		})
+	if s.stopReading() {
+		s.closeCodecs()
+	}
+}
+
+// Shutdown will stop reading new requests, wait up to the given timeout for the
+// requests being executed to finish and write their responses, and then close
+// all codecs, which will cancel remaining requests/subscriptions.
+func (s *Server) Shutdown(timeout time.Duration) {
+	if !s.stopReading() {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		glog.V(logger.Warn).Infof("RPC Server shutdown timed out waiting for pending requests")
+	}
+	s.closeCodecs()
+}
+
+// stopReading marks the server stopped, reporting whether it was running.
+func (s *Server) stopReading() bool {
+	if !atomic.CompareAndSwapInt32(&s.run, 1, 0) {
+		return false
+	}
+	glog.V(logger.Debug).Infoln("RPC Server shutdown initiatied")
+
+	// Requests passing the run check before the swap were counted as pending
+	// while holding the lock, later ones are rejected.
+	s.codecsMu.Lock()
+	s.codecsMu.Unlock()
+	return true
+}
+
+// closeCodecs closes all codecs currently being served.
+func (s *Server) closeCodecs() {
+	s.codecsMu.Lock()
+	defer s.codecsMu.Unlock()
+	s.codecs.Each(func(c interface{}) bool {
+		c.(ServerCodec).Close()
+		return true
+	})
+}
+
+// beginRequest counts a request as pending, reporting false if the server is
+// stopped and the request must be rejected. Requests are finished by exec and
+// execBatch.
+func (s *Server) beginRequest() bool {
+	s.codecsMu.Lock()
+	defer s.codecsMu.Unlock()
+
+	if atomic.LoadInt32(&s.run) != 1 {
+		return false
 	}
+	s.pending.Add(1)
+	return true
 }
 
 // createSubscription will call the subscription callback and returns the subscription id or error.
@@ -306,6 +359,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
+	if req.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.timeout)
+		defer cancel()
+	}
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
 		arguments = append(arguments, reflect.ValueOf(ctx))
@@ -314,8 +372,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		arguments = append(arguments, req.args...)
 	}
 
-	// execute RPC method and return result
+	// execute RPC method and return result, unless it ran out of time
 	reply := req.callb.method.Func.Call(arguments)
+	if req.timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+		rejectedTimeoutMeter.Mark(1)
+		method := req.svcname + serviceMethodSeparator + req.callb.method.Name
+		return codec.CreateErrorResponse(&req.id, &timeoutError{method}), nil
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
@@ -332,6 +395,8 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
+	defer s.pending.Done()
+
 	var response interface{}
 	var callback func()
 	if req.err != nil {
@@ -354,6 +419,8 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 // execBatch executes the given requests and writes the result back using the codec.
 // It will only write the response back when the last request is processed.
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
+	defer s.pending.Done()
+
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
 	for i, req := range requests {
@@ -435,6 +502,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 		if callb, ok := svc.callbacks[r.method]; ok { // lookup RPC method
 			requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+			requests[i].timeout = s.limiter.methodTimeout(r.service + serviceMethodSeparator + r.method)
 			if r.params != nil && len(callb.argTypes) > 0 {
 				if args, err := codec.ParseRequestArguments(callb.argTypes, r.params); err == nil {
 					requests[i].args = args
@@ -161,3 +161,49 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+// Tests that shutting down the server waits for the requests being executed to
+// finish, and rejects new ones.
+func TestServerShutdown(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	request := map[string]interface{}{"id": 1, "method": "test_sleep", "version": "2.0", "params": []interface{}{100 * time.Millisecond}}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	// Shut the server down while the request is being executed
+	time.Sleep(20 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		server.Shutdown(5 * time.Second)
+		close(done)
+	}()
+	var response jsonErrResponse
+	if err := in.Decode(&response); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if response.Error.Code != 0 {
+		t.Fatalf("pending request failed: %s", response.Error.Message)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("shutdown not finished after draining requests")
+	}
+	// The connection should be closed after the shutdown
+	if err := out.Encode(request); err == nil {
+		if err := in.Decode(&response); err == nil && response.Error.Code == 0 {
+			t.Fatalf("request served after shutdown")
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/fatih/set.v0"
 )
@@ -62,6 +63,7 @@ type serverRequest struct {
 	callb         *callback
 	args          []reflect.Value
 	isUnsubscribe bool
+	timeout       time.Duration // execution timeout, zero if unlimited
 	err           Error
 }
 
@@ -79,6 +81,10 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+	pending  sync.WaitGroup // requests being executed, counted under codecsMu
+
+	auth    *authenticator // restricts access through HTTP and WebSocket, nil if open
+	limiter *limiter       // limits resource usage through HTTP and WebSocket, nil if unlimited
 }
 
 // rpcRequest represents a raw incoming RPC request
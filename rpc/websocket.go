@@ -37,12 +37,27 @@ import (
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
 func (srv *Server) WebsocketHandler(allowedOrigins string) http.Handler {
-	return websocket.Server{
-		Handshake: wsHandshakeValidator(strings.Split(allowedOrigins, ",")),
-		Handler: func(conn *websocket.Conn) {
-			srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
-		},
-	}
+	validateOrigin := wsHandshakeValidator(strings.Split(allowedOrigins, ","))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check the credentials before upgrading, the API access they grant
+		// applies to the whole connection.
+		perm, status, err := srv.auth.authorize(r)
+		if err != nil {
+			rejectedAuthMeter.Mark(1)
+			http.Error(w, err.Error(), status)
+			return
+		}
+		websocket.Server{
+			Handshake: validateOrigin,
+			Handler: func(conn *websocket.Conn) {
+				if size := srv.limiter.maxRequestSize(0); size > 0 {
+					conn.MaxPayloadBytes = int(size)
+				}
+				codec := perm.wrap(NewJSONCodec(conn))
+				srv.ServeCodec(srv.limiter.wrap(codec, conn.Request().RemoteAddr), OptionMethodInvocation|OptionSubscriptions)
+			},
+		}.ServeHTTP(w, r)
+	})
 }
 
 // NewWSServer creates a new websocket RPC server around an API provider.
@@ -96,6 +111,15 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 // The context is used for the initial connection establishment. It does not
 // affect subsequent interactions with the client.
 func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error) {
+	connect, err := wsConnectFunc(endpoint, origin)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(ctx, connect, nil)
+}
+
+// wsConnectFunc creates the connection function dialing a websocket endpoint.
+func wsConnectFunc(endpoint, origin string) (func(context.Context) (net.Conn, error), error) {
 	if origin == "" {
 		var err error
 		if origin, err = os.Hostname(); err != nil {
@@ -111,10 +135,9 @@ func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error
 	if err != nil {
 		return nil, err
 	}
-
-	return newClient(ctx, func(ctx context.Context) (net.Conn, error) {
+	return func(ctx context.Context) (net.Conn, error) {
 		return wsDialContext(ctx, config)
-	})
+	}, nil
 }
 
 func wsDialContext(ctx context.Context, config *websocket.Config) (*websocket.Conn, error) {